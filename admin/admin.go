@@ -0,0 +1,474 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/economics"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/reputation"
+	"github.com/bnb-chain/greenfield-challenger/vote"
+)
+
+// Forgetter drops a challenge from the caches of a pipeline stage, so that the stage handles a reset event again.
+type Forgetter interface {
+	Forget(challengeId uint64)
+}
+
+// AttestReconciler updates the events of the challenges attested on chain, e.g. the ones missed while down.
+type AttestReconciler interface {
+	Reconcile(ctx context.Context) (int, error)
+}
+
+// Expirer marks the events which expired before reaching a final status.
+type Expirer interface {
+	ExpireEvents(ctx context.Context) (int, error)
+}
+
+// ReputationReporter reports the reputation scores of the storage providers.
+type ReputationReporter interface {
+	Scores() []*reputation.Score
+}
+
+// EconomicsReporter reports the profitability of the challenger account.
+type EconomicsReporter interface {
+	Report(ctx context.Context, since, now time.Time, withChallenges bool) (*economics.Report, error)
+}
+
+// CacheDumper reports the in-memory state of a component, to find out at runtime why an event is stuck.
+type CacheDumper interface {
+	DumpCache() interface{}
+}
+
+// Server serves the admin api, which inspects the events and resets them without touching the database by hand.
+type Server struct {
+	cfg          *config.AdminConfig
+	chainId      string
+	dataProvider DataProvider
+	attest       AttestReconciler
+	expirer      Expirer
+	reputation   ReputationReporter
+	forgetters   []Forgetter
+	caches       map[string]CacheDumper
+	economics    EconomicsReporter
+	mux          *http.ServeMux
+}
+
+func NewServer(cfg *config.AdminConfig, chainId string, dataProvider DataProvider, attest AttestReconciler, expirer Expirer,
+	reputation ReputationReporter, forgetters []Forgetter, caches map[string]CacheDumper,
+) *Server {
+	s := &Server{
+		cfg:          cfg,
+		chainId:      chainId,
+		dataProvider: dataProvider,
+		attest:       attest,
+		expirer:      expirer,
+		reputation:   reputation,
+		forgetters:   forgetters,
+		caches:       caches,
+		mux:          http.NewServeMux(),
+	}
+	s.mux.Handle(EventsPath, s.authorize(http.HandlerFunc(s.listEvents)))
+	s.mux.Handle(EventsPath+"/", s.authorize(http.HandlerFunc(s.handleEvent)))
+	s.mux.Handle(ReconcilePath, s.authorize(http.HandlerFunc(s.reconcile)))
+	s.mux.Handle(CachesPath, s.authorize(http.HandlerFunc(s.dumpCaches)))
+	s.mux.Handle(ReputationPath, s.authorize(http.HandlerFunc(s.listReputation)))
+	s.mux.Handle(EconomicsPath, s.authorize(http.HandlerFunc(s.reportEconomics)))
+	return s
+}
+
+// SetEconomicsReporter serves the profitability reports of reporter.
+func (s *Server) SetEconomicsReporter(reporter EconomicsReporter) {
+	s.economics = reporter
+}
+
+// Start serves the admin api if it is enabled, it blocks until ctx is done.
+func (s *Server) Start(ctx context.Context) {
+	if !s.cfg.Enabled() {
+		return
+	}
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", s.cfg.Port),
+		Handler:           s.mux,
+		ReadHeaderTimeout: RequestTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+	var err error
+	if s.cfg.TlsEnabled() {
+		server.TLSConfig, err = s.tlsConfig()
+		if err != nil {
+			panic(err)
+		}
+		err = server.ListenAndServeTLS(s.cfg.TlsCertFile, s.cfg.TlsKeyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		panic(err)
+	}
+}
+
+// tlsConfig requires the clients to present a certificate signed by the client ca, if it is set.
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.cfg.ClientCaFile == "" {
+		return tlsConfig, nil
+	}
+	bz, err := os.ReadFile(s.cfg.ClientCaFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bz) {
+		return nil, fmt.Errorf("no certificates found in client ca file %s", s.cfg.ClientCaFile)
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	return tlsConfig, nil
+}
+
+// authorize requires the bearer token, if it is set. The client certificates are verified by the tls handshake.
+func (s *Server) authorize(handler http.Handler) http.Handler {
+	if s.cfg.AuthToken == "" {
+		return handler
+	}
+	expected := []byte("Bearer " + s.cfg.AuthToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+type eventView struct {
+	ChallengeId       uint64 `json:"challenge_id"`
+	ObjectId          string `json:"object_id"`
+	SegmentIndex      uint32 `json:"segment_index"`
+	SpOperatorAddress string `json:"sp_operator_address"`
+	RedundancyIndex   int32  `json:"redundancy_index"`
+	ChallengerAddress string `json:"challenger_address"`
+	Height            uint64 `json:"height"`
+	ExpiredHeight     uint64 `json:"expired_height"`
+	Status            string `json:"status"`
+	VerifyResult      string `json:"verify_result"`
+	CreatedTime       int64  `json:"created_time"`
+}
+
+func newEventView(e *model.Event) eventView {
+	return eventView{
+		ChallengeId:       e.ChallengeId,
+		ObjectId:          e.ObjectId,
+		SegmentIndex:      e.SegmentIndex,
+		SpOperatorAddress: e.SpOperatorAddress,
+		RedundancyIndex:   e.RedundancyIndex,
+		ChallengerAddress: e.ChallengerAddress,
+		Height:            e.Height,
+		ExpiredHeight:     e.ExpiredHeight,
+		Status:            e.Status.String(),
+		VerifyResult:      e.VerifyResult.String(),
+		CreatedTime:       e.CreatedTime,
+	}
+}
+
+type voteView struct {
+	PubKey      string `json:"pub_key"`
+	EventHash   string `json:"event_hash"`
+	CreatedTime int64  `json:"created_time"`
+}
+
+type verificationResultView struct {
+	ExpectedHash string `json:"expected_hash"`
+	ActualHash   string `json:"actual_hash"`
+	LatencyMs    int64  `json:"latency_ms"`
+	Attempts     uint32 `json:"attempts"`
+	Outcome      string `json:"outcome"`
+	CreatedTime  int64  `json:"created_time"`
+}
+
+type eventsResponse struct {
+	Events     []eventView `json:"events"`
+	NextCursor uint64      `json:"next_cursor,omitempty"`
+}
+
+type eventResponse struct {
+	Event              eventView               `json:"event"`
+	VerificationResult *verificationResultView `json:"verification_result,omitempty"`
+	Votes              []voteView              `json:"votes"`
+}
+
+type resetResponse struct {
+	Reset int64 `json:"reset"`
+}
+
+type cachesResponse struct {
+	Caches map[string]interface{} `json:"caches"`
+	Queues map[string]int64       `json:"queues"` // events waiting in each status
+}
+
+type reconcileResponse struct {
+	Attested int `json:"attested"`
+	Expired  int `json:"expired"`
+}
+
+// listEvents serves GET /admin/events?status=verified,self_voted&sp=...&from_height=...&to_height=...&cursor=...&limit=...
+func (s *Server) listEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+	query := r.URL.Query()
+	filter := dao.EventFilter{
+		SpOperatorAddress: query.Get("sp"),
+		ChallengerAddress: query.Get("challenger"),
+	}
+	if statuses := query.Get("status"); statuses != "" {
+		for _, name := range strings.Split(statuses, ",") {
+			status, err := model.ParseEventStatus(name)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+	var (
+		cursor uint64
+		limit  int
+		err    error
+	)
+	for name, dest := range map[string]*uint64{"from_height": &filter.FromHeight, "to_height": &filter.ToHeight, "cursor": &cursor} {
+		if value := query.Get(name); value != "" {
+			if *dest, err = strconv.ParseUint(value, 10, 64); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid %s %q", name, value))
+				return
+			}
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", value))
+			return
+		}
+	}
+
+	events, nextCursor, err := s.dataProvider.ListEvents(r.Context(), filter, cursor, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	response := eventsResponse{Events: make([]eventView, 0, len(events)), NextCursor: nextCursor}
+	for _, event := range events {
+		response.Events = append(response.Events, newEventView(event))
+	}
+	writeJson(w, response)
+}
+
+// handleEvent serves GET /admin/events/{challenge_id}, POST /admin/events/{challenge_id}/reverify and
+// POST /admin/events/{challenge_id}/reset?status=...
+func (s *Server) handleEvent(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, EventsPath+"/"), "/")
+	challengeId, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil || len(parts) > 2 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown path %s", r.URL.Path))
+		return
+	}
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+			return
+		}
+		s.getEvent(w, r, challengeId)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+	switch parts[1] {
+	case ActionReverify:
+		// events reset to unprocessed lose their verify result and are verified again
+		s.resetEvent(w, r, challengeId, model.Unprocessed)
+	case ActionReset:
+		status, err := model.ParseEventStatus(r.URL.Query().Get("status"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		s.resetEvent(w, r, challengeId, status)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown action %s", parts[1]))
+	}
+}
+
+func (s *Server) getEvent(w http.ResponseWriter, r *http.Request, challengeId uint64) {
+	event, err := s.dataProvider.GetEventByChallengeId(r.Context(), challengeId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("challenge %d not found", challengeId))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	response := eventResponse{Event: newEventView(event), Votes: make([]voteView, 0)}
+
+	result, err := s.dataProvider.GetVerificationResult(r.Context(), challengeId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if result != nil {
+		response.VerificationResult = &verificationResultView{
+			ExpectedHash: result.ExpectedHash,
+			ActualHash:   result.ActualHash,
+			LatencyMs:    result.LatencyMs,
+			Attempts:     result.Attempts,
+			Outcome:      result.Outcome.String(),
+			CreatedTime:  result.CreatedTime,
+		}
+	}
+
+	// the votes are keyed by the event hash, which is only known once the event is verified
+	if event.VerifyResult == model.HashMatched || event.VerifyResult == model.HashMismatched {
+		eventHash := hex.EncodeToString(vote.EventHash(event, s.chainId))
+		votes, err := s.dataProvider.GetVotesByEventHash(r.Context(), eventHash)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		for _, v := range votes {
+			response.Votes = append(response.Votes, voteView{PubKey: v.PubKey, EventHash: v.EventHash, CreatedTime: v.CreatedTime})
+		}
+	}
+	writeJson(w, response)
+}
+
+func (s *Server) resetEvent(w http.ResponseWriter, r *http.Request, challengeId uint64, status model.EventStatus) {
+	reset, err := s.dataProvider.ReplayEvents(r.Context(), []uint64{challengeId}, status)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	for _, forgetter := range s.forgetters {
+		forgetter.Forget(challengeId)
+	}
+	logging.Logger.Infof("admin reset challenge %d to status %s", challengeId, status)
+	writeJson(w, resetResponse{Reset: reset})
+}
+
+// reconcile serves POST /admin/reconcile, it catches up with the attestations on chain and expires the stale events.
+func (s *Server) reconcile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+	attested, err := s.attest.Reconcile(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("reconcile attested challenges error, err=%s", err.Error()))
+		return
+	}
+	expired, err := s.expirer.ExpireEvents(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("expire events error, err=%s", err.Error()))
+		return
+	}
+	logging.Logger.Infof("admin reconciled events, attested: %d, expired: %d", attested, expired)
+	writeJson(w, reconcileResponse{Attested: attested, Expired: expired})
+}
+
+// dumpCaches serves GET /admin/caches, the in-memory caches of the pipeline and the events queued in each status.
+func (s *Server) dumpCaches(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+	counts, err := s.dataProvider.CountEventsByStatus(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("count events error, err=%s", err.Error()))
+		return
+	}
+	response := cachesResponse{
+		Caches: make(map[string]interface{}, len(s.caches)),
+		Queues: make(map[string]int64, len(counts)),
+	}
+	for name, cache := range s.caches {
+		response.Caches[name] = cache.DumpCache()
+	}
+	for status, count := range counts {
+		response.Queues[status.String()] = count
+	}
+	writeJson(w, response)
+}
+
+// listReputation returns the reputation scores of the storage providers, the least reliable first.
+func (s *Server) listReputation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+	writeJson(w, s.reputation.Scores())
+}
+
+// reportEconomics reports the profitability over the last days, 30 by default, listing the totals of each challenge
+// if challenges is true.
+func (s *Server) reportEconomics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+	if s.economics == nil {
+		writeError(w, http.StatusNotFound, errors.New("economics are not tracked"))
+		return
+	}
+	query := r.URL.Query()
+	days := uint64(DefaultEconomicsDays)
+	if value := query.Get("days"); value != "" {
+		var err error
+		if days, err = strconv.ParseUint(value, 10, 64); err != nil || days == 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid days %q", value))
+			return
+		}
+	}
+	withChallenges := query.Get("challenges") == "true"
+	now := time.Now()
+	report, err := s.economics.Report(r.Context(), now.Add(-time.Duration(days)*24*time.Hour), now, withChallenges)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJson(w, report)
+}
+
+func writeJson(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logging.Logger.Errorf("failed to write admin response, err=%+v", err.Error())
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,203 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/economics"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+	"github.com/bnb-chain/greenfield-challenger/reputation"
+)
+
+const testToken = "0123456789abcdef"
+
+type fakeStages struct {
+	forgotten []uint64
+}
+
+func (f *fakeStages) Forget(challengeId uint64) {
+	f.forgotten = append(f.forgotten, challengeId)
+}
+
+func (f *fakeStages) Reconcile(_ context.Context) (int, error) {
+	return 2, nil
+}
+
+func (f *fakeStages) DumpCache() interface{} {
+	return map[string][]uint64{"local_votes": f.forgotten}
+}
+
+func (f *fakeStages) ExpireEvents(_ context.Context) (int, error) {
+	return 1, nil
+}
+
+func newTestServer(t *testing.T) (*Server, *dao.DaoManager, *fakeStages) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	model.RunMigrations(db)
+	daoManager := dao.NewDaoManager(dao.NewBlockDao(db), dao.NewEventDao(db), dao.NewVoteDao(db), dao.NewSpStatsDao(db),
+		dao.NewVerificationResultDao(db), dao.NewSamplingFailureDao(db), dao.NewLeaseDao(db),
+		dao.NewLedgerDao(db), dao.NewRollupDao(db), dao.NewJobDao(db))
+
+	stages := &fakeStages{}
+	cfg := &config.AdminConfig{Port: 9001, AuthToken: testToken}
+	caches := map[string]CacheDumper{CacheBroadcaster: stages}
+	scorer := reputation.NewScorer(&config.ReputationConfig{}, daoManager, metrics.NewMetricService(&config.Config{}))
+	return NewServer(cfg, "greenfield_5600-1", NewDataHandler(daoManager), stages, stages, scorer, []Forgetter{stages},
+		caches), daoManager, stages
+}
+
+func serve(s *Server, method, target string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	r.Header.Set("Authorization", "Bearer "+testToken)
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, r)
+	return w
+}
+
+func TestServer_Authorize(t *testing.T) {
+	s, _, _ := newTestServer(t)
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, EventsPath, nil))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestServer_Events(t *testing.T) {
+	s, daoManager, stages := newTestServer(t)
+	ctx := context.Background()
+	events := []*model.Event{
+		{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", Height: 100, Status: model.Unprocessed},
+		{ChallengeId: 2, ObjectId: "2", SpOperatorAddress: "sp1", Height: 100, Status: model.Verified, VerifyResult: model.HashMismatched},
+	}
+	require.NoError(t, daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 100}, events))
+	require.NoError(t, daoManager.SaveVerificationResult(ctx, &model.VerificationResult{ChallengeId: 2,
+		SpOperatorAddress: "sp1", Attempts: 1, Outcome: model.OutcomeHashMismatched}))
+
+	w := serve(s, http.MethodGet, EventsPath+"?status=verified")
+	require.Equal(t, http.StatusOK, w.Code)
+	list := eventsResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Events, 1)
+	require.Equal(t, "hash_mismatched", list.Events[0].VerifyResult)
+
+	w = serve(s, http.MethodGet, EventsPath+"?status=bogus")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(s, http.MethodGet, EventsPath+"/1")
+	require.Equal(t, http.StatusOK, w.Code)
+	event := eventResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+	require.Equal(t, "unprocessed", event.Event.Status)
+	require.Nil(t, event.VerificationResult)
+	require.Empty(t, event.Votes)
+
+	w = serve(s, http.MethodGet, EventsPath+"/3")
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	// re-verification clears the verify result and the caches of the pipeline
+	w = serve(s, http.MethodPost, EventsPath+"/2/reverify")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, []uint64{2}, stages.forgotten)
+	reset, err := daoManager.GetEventByChallengeId(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, model.Unprocessed, reset.Status)
+	require.Equal(t, model.Unknown, reset.VerifyResult)
+
+	w = serve(s, http.MethodPost, EventsPath+"/2/reset?status=attested")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	w = serve(s, http.MethodGet, EventsPath+"/2/reset?status=verified")
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestServer_Reconcile(t *testing.T) {
+	s, _, _ := newTestServer(t)
+	w := serve(s, http.MethodPost, ReconcilePath)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"attested": 2, "expired": 1}`, w.Body.String())
+}
+
+func TestServer_Caches(t *testing.T) {
+	s, daoManager, stages := newTestServer(t)
+	stages.forgotten = []uint64{7}
+	events := []*model.Event{
+		{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", Height: 100, Status: model.Unprocessed},
+		{ChallengeId: 2, ObjectId: "2", SpOperatorAddress: "sp1", Height: 100, Status: model.Unprocessed},
+	}
+	require.NoError(t, daoManager.SaveBlockAndEvents(context.Background(), &model.Block{Height: 100}, events))
+
+	w := serve(s, http.MethodGet, CachesPath)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"caches": {"vote_broadcaster": {"local_votes": [7]}}, "queues": {"unprocessed": 2}}`,
+		w.Body.String())
+
+	w = serve(s, http.MethodPost, CachesPath)
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestServer_Reputation(t *testing.T) {
+	s, daoManager, _ := newTestServer(t)
+	events := []*model.Event{
+		{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", Height: 100, Status: model.Unprocessed},
+		{ChallengeId: 2, ObjectId: "2", SpOperatorAddress: "sp2", Height: 100, Status: model.Unprocessed},
+	}
+	require.NoError(t, daoManager.SaveBlockAndEvents(context.Background(), &model.Block{Height: 100}, events))
+	require.NoError(t, daoManager.RecordSpVerifyResult(context.Background(), "sp2", model.HashMismatched, 0))
+	require.NoError(t, s.reputation.(*reputation.Scorer).Refresh(context.Background()))
+
+	w := serve(s, http.MethodGet, ReputationPath)
+	require.Equal(t, http.StatusOK, w.Code)
+	scores := make([]*reputation.Score, 0)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &scores))
+	require.Len(t, scores, 2)
+	require.Equal(t, "sp2", scores[0].SpOperatorAddress)
+	require.Less(t, scores[0].Score, scores[1].Score)
+}
+
+type fakeAccount struct{}
+
+func (fakeAccount) GetAddr() string {
+	return "0xchallenger"
+}
+
+func TestServer_Economics(t *testing.T) {
+	s, daoManager, _ := newTestServer(t)
+	w := serve(s, http.MethodGet, EconomicsPath)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	s.SetEconomicsReporter(economics.NewTracker(&config.EconomicsConfig{}, "BNB", fakeAccount{}, daoManager,
+		metrics.NewMetricService(&config.Config{})))
+	now := time.Now().Unix()
+	require.NoError(t, daoManager.SaveLedgerEntries(context.Background(), []*model.LedgerEntry{
+		{TxHash: "A1", BlockTime: now, ChallengeId: 1, Kind: model.LedgerKindAttest, Fee: "5", Reward: "100"},
+		{TxHash: "A2", BlockTime: now, ChallengeId: 2, Kind: model.LedgerKindFailed, Fee: "5", Reward: "0"},
+		// older than the reported days
+		{TxHash: "A3", BlockTime: now - 3*24*3600, ChallengeId: 3, Kind: model.LedgerKindAttest, Fee: "5", Reward: "0"},
+	}))
+
+	w = serve(s, http.MethodGet, EconomicsPath+"?days=2&challenges=true")
+	require.Equal(t, http.StatusOK, w.Code)
+	report := economics.Report{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	require.Equal(t, "90", report.Net.String())
+	require.Equal(t, 2, report.Challenges)
+	require.Equal(t, 1, report.ProfitableChallenges)
+	require.Len(t, report.ChallengeTotals, 2)
+
+	w = serve(s, http.MethodGet, EconomicsPath+"?days=0")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,25 @@
+package admin
+
+import "time"
+
+const (
+	EventsPath     = "/admin/events"
+	ReconcilePath  = "/admin/reconcile"
+	CachesPath     = "/admin/caches"
+	ReputationPath = "/admin/reputation"
+	EconomicsPath  = "/admin/economics"
+
+	// in-memory caches dumped at CachesPath
+	CacheValidators  = "validators"
+	CacheVerifier    = "verifier"
+	CacheBroadcaster = "vote_broadcaster"
+	CacheSubmitter   = "submitter"
+
+	// actions on a single event, e.g. POST /admin/events/42/reverify
+	ActionReverify = "reverify"
+	ActionReset    = "reset"
+
+	RequestTimeout = 30 * time.Second
+
+	DefaultEconomicsDays = 30 // days reported at EconomicsPath unless asked otherwise
+)
@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+)
+
+type DataProvider interface {
+	ListEvents(ctx context.Context, filter dao.EventFilter, cursor uint64, limit int) ([]*model.Event, uint64, error)
+	GetEventByChallengeId(ctx context.Context, challengeId uint64) (*model.Event, error)
+	GetVotesByEventHash(ctx context.Context, eventHash string) ([]*model.Vote, error)
+	GetVerificationResult(ctx context.Context, challengeId uint64) (*model.VerificationResult, error)
+	ReplayEvents(ctx context.Context, challengeIds []uint64, status model.EventStatus) (int64, error)
+	CountEventsByStatus(ctx context.Context) (map[model.EventStatus]int64, error)
+}
+
+type DataHandler struct {
+	daoManager *dao.DaoManager
+}
+
+func NewDataHandler(daoManager *dao.DaoManager) *DataHandler {
+	return &DataHandler{
+		daoManager: daoManager,
+	}
+}
+
+func (h *DataHandler) ListEvents(ctx context.Context, filter dao.EventFilter, cursor uint64, limit int) ([]*model.Event, uint64, error) {
+	return h.daoManager.ListEvents(ctx, filter, cursor, limit)
+}
+
+func (h *DataHandler) GetEventByChallengeId(ctx context.Context, challengeId uint64) (*model.Event, error) {
+	return h.daoManager.GetEventByChallengeId(ctx, challengeId)
+}
+
+func (h *DataHandler) GetVotesByEventHash(ctx context.Context, eventHash string) ([]*model.Vote, error) {
+	return h.daoManager.GetVotesByEventHash(ctx, eventHash)
+}
+
+func (h *DataHandler) GetVerificationResult(ctx context.Context, challengeId uint64) (*model.VerificationResult, error) {
+	return h.daoManager.GetVerificationResult(ctx, challengeId)
+}
+
+func (h *DataHandler) ReplayEvents(ctx context.Context, challengeIds []uint64, status model.EventStatus) (int64, error) {
+	return h.daoManager.ReplayEvents(ctx, challengeIds, status)
+}
+
+func (h *DataHandler) CountEventsByStatus(ctx context.Context) (map[model.EventStatus]int64, error) {
+	return h.daoManager.CountEventsByStatus(ctx)
+}
@@ -2,26 +2,208 @@ package alert
 
 import (
 	"fmt"
-	"net/http"
-	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/bnb-chain/greenfield-challenger/config"
 	"github.com/bnb-chain/greenfield-challenger/logging"
 )
 
-func SendTelegramMessage(identity string, botId string, chatId string, msg string) {
-	if botId == "" || chatId == "" || msg == "" {
+// alertConfig holds the alerting targets, they are replaced when the config is reloaded.
+var alertConfig atomic.Pointer[config.AlertConfig]
+
+var (
+	raisedMtx sync.Mutex
+	raised    = make(map[string]time.Time) // the last time each alert was sent, for the cooldown
+)
+
+func SetAlertConfig(cfg *config.AlertConfig) {
+	alertConfig.Store(cfg)
+}
+
+// Alert is a critical condition of the challenger which is pushed to the notifiers.
+type Alert struct {
+	Name     string
+	Severity string
+	Message  string
+}
+
+// Notifier pushes alerts to an alerting target.
+type Notifier interface {
+	Notify(alert Alert) error
+}
+
+// notifiers returns the alerting targets of cfg which the alerts of severity are routed to.
+func notifiers(cfg *config.AlertConfig, severity string) []Notifier {
+	result := make([]Notifier, 0)
+	for _, backend := range cfg.RouteOf(severity) {
+		switch backend {
+		case config.AlertBackendTelegram:
+			result = append(result, NewTelegramNotifier(cfg.Identity, cfg.TelegramBotId, cfg.TelegramChatId))
+		case config.AlertBackendSlack:
+			result = append(result, NewSlackNotifier(cfg.Identity, cfg.SlackWebhookUrl))
+		case config.AlertBackendPagerDuty:
+			result = append(result, NewPagerDutyNotifier(cfg.Identity, cfg.PagerDutyRoutingKey))
+		}
+	}
+	return result
+}
+
+// Raise sends alert to the configured alerting targets in the background. An alert is sent at most once within the
+// cooldown, so a lasting condition does not flood the targets.
+func Raise(alert Alert) {
+	cfg := alertConfig.Load()
+	if cfg == nil {
 		return
 	}
+	if !startCooldown(alert.Name, time.Duration(cfg.CooldownInSeconds)*time.Second) {
+		return
+	}
+	logging.Logger.Warningf("alert raised, name: %s, severity: %s, msg: %s", alert.Name, alert.Severity, alert.Message)
+	for _, notifier := range notifiers(cfg, alert.Severity) {
+		go func(notifier Notifier) {
+			if err := notifier.Notify(alert); err != nil {
+				logging.Logger.Errorf("send alert error, name=%s, err=%+v", alert.Name, err.Error())
+			}
+		}(notifier)
+	}
+}
 
-	endPoint := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", botId)
-	formData := url.Values{
-		"chat_id":    {chatId},
-		"parse_mode": {"html"},
-		"text":       {fmt.Sprintf("%s: %s", identity, msg)},
+// startCooldown reports whether the alert called name may be sent and starts its cooldown if so.
+func startCooldown(name string, cooldown time.Duration) bool {
+	raisedMtx.Lock()
+	defer raisedMtx.Unlock()
+	now := time.Now()
+	if last, ok := raised[name]; ok && now.Sub(last) < cooldown {
+		return false
 	}
-	_, err := http.PostForm(endPoint, formData)
-	if err != nil {
-		logging.Logger.Errorf("send telegram message error, bot_id=%s, chat_id=%s, msg=%s, err=%+v \n", botId, chatId, msg, err.Error())
+	raised[name] = now
+	return true
+}
+
+// SendAlert sends msg to the configured alerting targets.
+func SendAlert(msg string) {
+	cfg := alertConfig.Load()
+	if cfg == nil {
 		return
 	}
+	SendTelegramMessage(cfg.Identity, cfg.TelegramBotId, cfg.TelegramChatId, msg)
+}
+
+// VerifyDivergence raises an alert for a challenge which the chain attested although it was verified as matching,
+// i.e. the verification of this challenger disagrees with the other validators.
+func VerifyDivergence(challengeId uint64, objectId string, sp string) {
+	Raise(Alert{
+		Name:     AlertVerifyDivergence,
+		Severity: SeverityWarning,
+		Message: fmt.Sprintf("challenge %d of object %s on sp %s was attested but verified as matching", challengeId,
+			objectId, sp),
+	})
+}
+
+// PeerDisagreement raises an alert for a challenge which a trusted challenger verified differently, i.e. one of them
+// may vote wrong.
+func PeerDisagreement(challengeId uint64, sp string, peer string, verifyResult, peerVerifyResult string) {
+	Raise(Alert{
+		Name:     AlertPeerDisagreement,
+		Severity: SeverityWarning,
+		Message: fmt.Sprintf("challenge %d on sp %s was verified as %s but as %s by peer %s", challengeId, sp,
+			verifyResult, peerVerifyResult, peer),
+	})
+}
+
+// RelayGap raises an alert for a package emitted by an attestation which is not relayed to BSC within the timeout.
+func RelayGap(challengeId uint64, channelId uint8, sequence uint64, age time.Duration) {
+	Raise(Alert{
+		Name:     AlertRelayGap,
+		Severity: SeverityWarning,
+		Message: fmt.Sprintf("package %d on channel %d of challenge %d is not relayed after %s", sequence, channelId,
+			challengeId, age.Truncate(time.Second)),
+	})
+}
+
+// RelayFailed raises an alert for a package emitted by an attestation whose handler failed on BSC.
+func RelayFailed(challengeId uint64, channelId uint8, sequence uint64) {
+	Raise(Alert{
+		Name:     AlertRelayFailed,
+		Severity: SeverityWarning,
+		Message: fmt.Sprintf("package %d on channel %d of challenge %d failed in its handler on bsc", sequence,
+			channelId, challengeId),
+	})
+}
+
+// IndexerMismatch raises an alert for the challenges of the blocks cross-checked with the indexer which were missed
+// or recorded differently.
+func IndexerMismatch(fromHeight, toHeight uint64, missed, mismatched int) {
+	Raise(Alert{
+		Name:     AlertIndexerMismatch,
+		Severity: SeverityWarning,
+		Message: fmt.Sprintf("blocks %d to %d disagree with the indexer: %d challenges missed, %d recorded differently",
+			fromHeight, toHeight, missed, mismatched),
+	})
+}
+
+// UnsupportedChain raises an alert for a chain whose versions are not in the compatibility matrix, the votes and the
+// attestations are refused unless the unsupported chains are allowed.
+func UnsupportedChain(appVersion, nodeVersion string, refused bool) {
+	action := "the votes and the attestations are refused"
+	if !refused {
+		action = "the votes and the attestations are still sent as unsupported chains are allowed"
+	}
+	Raise(Alert{
+		Name:     AlertUnsupportedChain,
+		Severity: SeverityCritical,
+		Message: fmt.Sprintf("chain with app version %s and node version %s is not supported by this release, %s",
+			appVersion, nodeVersion, action),
+	})
+}
+
+// Streak counts the consecutive failures of an operation and raises its alert once they reach the threshold.
+type Streak struct {
+	name      string
+	severity  string
+	threshold func(cfg *config.AlertConfig) int
+	mtx       sync.Mutex
+	failures  int
+}
+
+var (
+	AttestFailures = &Streak{
+		name:      AlertAttestFailures,
+		severity:  SeverityCritical,
+		threshold: func(cfg *config.AlertConfig) int { return cfg.AttestFailureThreshold },
+	}
+	VotePoolFailures = &Streak{
+		name:      AlertVotePoolDown,
+		severity:  SeverityCritical,
+		threshold: func(cfg *config.AlertConfig) int { return cfg.VotePoolFailureThreshold },
+	}
+)
+
+// Fail records a failure, msg describes it in the alert.
+func (s *Streak) Fail(msg string) {
+	s.mtx.Lock()
+	s.failures++
+	failures := s.failures
+	s.mtx.Unlock()
+
+	cfg := alertConfig.Load()
+	if cfg == nil {
+		return
+	}
+	if threshold := s.threshold(cfg); threshold > 0 && failures >= threshold {
+		Raise(Alert{
+			Name:     s.name,
+			Severity: s.severity,
+			Message:  fmt.Sprintf("%d consecutive failures, last: %s", failures, msg),
+		})
+	}
+}
+
+// Succeed ends the streak of failures.
+func (s *Streak) Succeed() {
+	s.mtx.Lock()
+	s.failures = 0
+	s.mtx.Unlock()
 }
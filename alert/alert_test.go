@@ -1,9 +1,16 @@
 package alert
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
 
 	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
 )
 
 // Set botId, chatId in config
@@ -12,3 +19,131 @@ func TestAlert(t *testing.T) {
 	cfg := config.ParseConfigFromFile(configFilePath)
 	SendTelegramMessage(cfg.AlertConfig.Identity, cfg.AlertConfig.TelegramChatId, cfg.AlertConfig.TelegramBotId, "hi")
 }
+
+func newTelegramServer(t *testing.T) chan string {
+	texts := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/botbot/sendMessage", r.URL.Path)
+		texts <- r.FormValue("text")
+	}))
+	t.Cleanup(server.Close)
+	apiUrl := telegramApiUrl
+	telegramApiUrl = server.URL
+	t.Cleanup(func() { telegramApiUrl = apiUrl })
+	return texts
+}
+
+func resetAlerts(cfg *config.AlertConfig) {
+	SetAlertConfig(cfg)
+	raisedMtx.Lock()
+	raised = make(map[string]time.Time)
+	raisedMtx.Unlock()
+}
+
+func TestRaise_Cooldown(t *testing.T) {
+	texts := newTelegramServer(t)
+	resetAlerts(&config.AlertConfig{Identity: "challenger", TelegramBotId: "bot", TelegramChatId: "chat",
+		CooldownInSeconds: 60})
+
+	Raise(Alert{Name: AlertMonitorLag, Severity: SeverityCritical, Message: "behind <100>"})
+	require.Equal(t, "challenger: <b>[critical] monitor_lag</b>\nbehind &lt;100&gt;", <-texts)
+
+	// the same alert is dropped within the cooldown, other alerts are not
+	Raise(Alert{Name: AlertMonitorLag, Severity: SeverityCritical, Message: "behind"})
+	Raise(Alert{Name: AlertLowBalance, Severity: SeverityWarning, Message: "low"})
+	require.Equal(t, "challenger: <b>[warning] low_balance</b>\nlow", <-texts)
+	select {
+	case text := <-texts:
+		t.Fatalf("unexpected alert %s", text)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestStreak(t *testing.T) {
+	texts := newTelegramServer(t)
+	resetAlerts(&config.AlertConfig{TelegramBotId: "bot", TelegramChatId: "chat", AttestFailureThreshold: 2})
+
+	streak := &Streak{name: AlertAttestFailures, severity: SeverityCritical,
+		threshold: func(cfg *config.AlertConfig) int { return cfg.AttestFailureThreshold }}
+	streak.Fail("first")
+	streak.Succeed()
+	streak.Fail("second")
+	select {
+	case text := <-texts:
+		t.Fatalf("unexpected alert %s", text)
+	case <-time.After(100 * time.Millisecond):
+	}
+	streak.Fail("third")
+	require.Contains(t, <-texts, "2 consecutive failures, last: third")
+}
+
+func TestRaise_Routes(t *testing.T) {
+	slackTexts := make(chan string, 10)
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := slackMessage{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		slackTexts <- msg.Text
+	}))
+	defer slack.Close()
+	pages := make(chan pagerDutyEvent, 10)
+	pagerDuty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event := pagerDutyEvent{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		pages <- event
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer pagerDuty.Close()
+	eventsUrl := pagerDutyEventsUrl
+	pagerDutyEventsUrl = pagerDuty.URL
+	defer func() { pagerDutyEventsUrl = eventsUrl }()
+
+	resetAlerts(&config.AlertConfig{
+		Identity:            "challenger",
+		SlackWebhookUrl:     slack.URL,
+		PagerDutyRoutingKey: "key",
+		Routes:              map[string][]string{SeverityCritical: {config.AlertBackendPagerDuty}},
+	})
+	// critical alerts page, the warnings without a route go to all backends
+	Raise(Alert{Name: AlertExpiringChallenge, Severity: SeverityCritical, Message: "expiring"})
+	page := <-pages
+	require.Equal(t, "key", page.RoutingKey)
+	require.Equal(t, "challenger/expiring_challenge", page.DedupKey)
+	require.Equal(t, SeverityCritical, page.Payload.Severity)
+	select {
+	case text := <-slackTexts:
+		t.Fatalf("unexpected slack alert %s", text)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	Raise(Alert{Name: AlertLowBalance, Severity: SeverityWarning, Message: "low"})
+	require.Equal(t, "challenger: *[warning] low_balance*\nlow", <-slackTexts)
+	require.Equal(t, "[low_balance] low", (<-pages).Payload.Summary)
+}
+
+func TestStallDetector(t *testing.T) {
+	d := stallDetector{}
+	start := time.Now()
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	counts := func(unprocessed, verified int64) map[model.EventStatus]int64 {
+		return map[model.EventStatus]int64{model.Unprocessed: unprocessed, model.Verified: verified}
+	}
+
+	_, ok := d.observe(at(0), counts(1, 0), 1)
+	require.False(t, ok)
+	// events move forward
+	_, ok = d.observe(at(1), counts(1, 1), 2)
+	require.False(t, ok)
+	// no new events, an idle pipeline is not stalled
+	_, ok = d.observe(at(2), counts(1, 1), 2)
+	require.False(t, ok)
+	// new events pile up while none moves forward
+	stalled, ok := d.observe(at(3), counts(2, 1), 3)
+	require.True(t, ok)
+	require.Equal(t, 2*time.Minute, stalled)
+	// deleted events do not count as progress
+	stalled, ok = d.observe(at(10), counts(3, 0), 4)
+	require.True(t, ok)
+	require.Equal(t, 9*time.Minute, stalled)
+	_, ok = d.observe(at(11), counts(3, 1), 4)
+	require.False(t, ok)
+}
@@ -0,0 +1,37 @@
+package alert
+
+import (
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+)
+
+const (
+	// the alerted conditions
+	AlertLowBalance        = "low_balance"
+	AlertAttestFailures    = "attest_failures"
+	AlertMonitorLag        = "monitor_lag"
+	AlertVerifyDivergence  = "verify_divergence"
+	AlertPeerDisagreement  = "peer_disagreement"
+	AlertVotePoolDown      = "votepool_down"
+	AlertUnsupportedChain  = "unsupported_chain"
+	AlertRelayGap          = "relay_gap"
+	AlertRelayFailed       = "relay_failed"
+	AlertIndexerMismatch   = "indexer_mismatch"
+	AlertExpiringChallenge = "expiring_challenge"
+	AlertPipelineStall     = "pipeline_stall"
+	AlertSlaReport         = "sla_report" // the periodic sla reports, not a condition
+	AlertMessage           = "message"    // the free form messages of SendAlert
+
+	SeverityInfo     = config.AlertSeverityInfo
+	SeverityWarning  = config.AlertSeverityWarning
+	SeverityCritical = config.AlertSeverityCritical
+
+	TelegramApiUrl     = "https://api.telegram.org"
+	PagerDutyEventsUrl = "https://events.pagerduty.com/v2/enqueue"
+
+	DefaultSource = "greenfield-challenger" // pagerduty requires a source, it is used if the identity is empty
+
+	NotifyTimeout = 10 * time.Second
+	WatchInterval = 1 * time.Minute
+)
@@ -0,0 +1,66 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// pagerDutyEventsUrl is replaced by the tests.
+var pagerDutyEventsUrl = PagerDutyEventsUrl
+
+// PagerDutyNotifier triggers pagerduty incidents for the alerts with the events api v2.
+type PagerDutyNotifier struct {
+	identity   string
+	routingKey string
+}
+
+func NewPagerDutyNotifier(identity, routingKey string) *PagerDutyNotifier {
+	if identity == "" {
+		identity = DefaultSource
+	}
+	return &PagerDutyNotifier{
+		identity:   identity,
+		routingKey: routingKey,
+	}
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyPayload struct {
+	Summary  string `json:"summary"`
+	Source   string `json:"source"`
+	Severity string `json:"severity"`
+}
+
+func (n *PagerDutyNotifier) Notify(alert Alert) error {
+	bz, err := json.Marshal(pagerDutyEvent{
+		RoutingKey:  n.routingKey,
+		EventAction: "trigger",
+		// repeated alerts of a condition are grouped into one incident
+		DedupKey: fmt.Sprintf("%s/%s", n.identity, alert.Name),
+		Payload: pagerDutyPayload{
+			Summary:  fmt.Sprintf("[%s] %s", alert.Name, alert.Message),
+			Source:   n.identity,
+			Severity: alert.Severity, // warning and critical are pagerduty severities as well
+		},
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Post(pagerDutyEventsUrl, "application/json", bytes.NewReader(bz))
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("pagerduty responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
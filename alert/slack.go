@@ -0,0 +1,43 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// SlackNotifier posts the alerts to a slack channel through an incoming webhook.
+type SlackNotifier struct {
+	identity   string
+	webhookUrl string
+}
+
+func NewSlackNotifier(identity, webhookUrl string) *SlackNotifier {
+	return &SlackNotifier{
+		identity:   identity,
+		webhookUrl: webhookUrl,
+	}
+}
+
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+func (n *SlackNotifier) Notify(alert Alert) error {
+	bz, err := json.Marshal(slackMessage{
+		Text: fmt.Sprintf("%s: *[%s] %s*\n%s", n.identity, alert.Severity, alert.Name, alert.Message),
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Post(n.webhookUrl, "application/json", bytes.NewReader(bz))
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package alert
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+
+	"github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+// telegramApiUrl is replaced by the tests.
+var telegramApiUrl = TelegramApiUrl
+
+var httpClient = &http.Client{Timeout: NotifyTimeout}
+
+// TelegramNotifier sends the alerts to a telegram chat.
+type TelegramNotifier struct {
+	identity string
+	botId    string
+	chatId   string
+}
+
+func NewTelegramNotifier(identity, botId, chatId string) *TelegramNotifier {
+	return &TelegramNotifier{
+		identity: identity,
+		botId:    botId,
+		chatId:   chatId,
+	}
+}
+
+func (n *TelegramNotifier) Notify(alert Alert) error {
+	text := fmt.Sprintf("<b>[%s] %s</b>\n%s", alert.Severity, alert.Name, html.EscapeString(alert.Message))
+	return sendTelegram(n.identity, n.botId, n.chatId, text)
+}
+
+func sendTelegram(identity string, botId string, chatId string, text string) error {
+	endPoint := fmt.Sprintf("%s/bot%s/sendMessage", telegramApiUrl, botId)
+	formData := url.Values{
+		"chat_id":    {chatId},
+		"parse_mode": {"html"},
+		"text":       {fmt.Sprintf("%s: %s", html.EscapeString(identity), text)},
+	}
+	resp, err := httpClient.PostForm(endPoint, formData)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func SendTelegramMessage(identity string, botId string, chatId string, msg string) {
+	if botId == "" || chatId == "" || msg == "" {
+		return
+	}
+	if err := sendTelegram(identity, botId, chatId, msg); err != nil {
+		logging.Logger.Errorf("send telegram message error, bot_id=%s, chat_id=%s, msg=%s, err=%+v \n", botId, chatId, msg, err.Error())
+	}
+}
@@ -0,0 +1,190 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cosmossdk.io/math"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+// BalanceProvider reports the balance of the challenger account.
+type BalanceProvider interface {
+	GetBalance() (math.Int, error)
+}
+
+// ChainProvider reports the block height cached from the chain.
+type ChainProvider interface {
+	GetCachedBlockHeight() uint64
+}
+
+// BlockProvider reports the latest block saved by the monitor.
+type BlockProvider interface {
+	GetLatestBlock(ctx context.Context) (*model.Block, error)
+}
+
+// EventProvider reports the events which are about to expire and the progress of the pipeline.
+type EventProvider interface {
+	GetExpiringEventsByStatuses(ctx context.Context, currentHeight, blocks uint64, statuses []model.EventStatus) ([]*model.Event, error)
+	CountEventsByStatus(ctx context.Context) (map[model.EventStatus]int64, error)
+	GetLatestChallengeId(ctx context.Context) (uint64, error)
+}
+
+// Watcher polls the conditions which are not noticed by the loops themselves, i.e. the balance of the challenger
+// account, the lag of the monitor, the challenges about to expire and the pipeline stalling.
+type Watcher struct {
+	balances BalanceProvider
+	chain    ChainProvider
+	blocks   BlockProvider
+	events   EventProvider
+	stall    stallDetector
+}
+
+func NewWatcher(balances BalanceProvider, chain ChainProvider, blocks BlockProvider, events EventProvider) *Watcher {
+	return &Watcher{
+		balances: balances,
+		chain:    chain,
+		blocks:   blocks,
+		events:   events,
+	}
+}
+
+func (w *Watcher) WatchLoop(ctx context.Context) {
+	ticker := time.NewTicker(WatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		cfg := alertConfig.Load()
+		if cfg == nil {
+			continue
+		}
+		if minBalance, ok := cfg.MinBalanceInt(); ok {
+			w.checkBalance(minBalance)
+		}
+		if cfg.MonitorLagThreshold > 0 {
+			w.checkMonitorLag(cfg.MonitorLagThreshold)
+		}
+		if cfg.ExpiringChallengeBlocks > 0 {
+			w.checkExpiringChallenges(cfg.ExpiringChallengeBlocks)
+		}
+		if cfg.StallThresholdInMinutes > 0 {
+			w.checkStall(time.Duration(cfg.StallThresholdInMinutes) * time.Minute)
+		}
+	}
+}
+
+func (w *Watcher) checkBalance(minBalance math.Int) {
+	balance, err := w.balances.GetBalance()
+	if err != nil {
+		logging.Logger.Errorf("alert watcher failed to get balance, err=%+v", err.Error())
+		return
+	}
+	if balance.LT(minBalance) {
+		Raise(Alert{
+			Name:     AlertLowBalance,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("balance %s is below %s, the attest transactions will fail once it runs out", balance, minBalance),
+		})
+	}
+}
+
+func (w *Watcher) checkMonitorLag(threshold uint64) {
+	block, err := w.blocks.GetLatestBlock(context.Background())
+	if err != nil {
+		logging.Logger.Errorf("alert watcher failed to get the latest saved block, err=%+v", err.Error())
+		return
+	}
+	chainHeight := w.chain.GetCachedBlockHeight()
+	if chainHeight > block.Height && chainHeight-block.Height > threshold {
+		Raise(Alert{
+			Name:     AlertMonitorLag,
+			Severity: SeverityCritical,
+			Message:  fmt.Sprintf("monitor is %d blocks behind the chain at height %d", chainHeight-block.Height, chainHeight),
+		})
+	}
+}
+
+// checkExpiringChallenges pages for the challenges which were not attested yet and expire within blocks, the
+// challenger misses the reward for them unless they are attested in time.
+func (w *Watcher) checkExpiringChallenges(blocks uint64) {
+	statuses := append([]model.EventStatus{model.Submitted}, model.UnfinishedEventStatuses...)
+	height := w.chain.GetCachedBlockHeight()
+	events, err := w.events.GetExpiringEventsByStatuses(context.Background(), height, blocks, statuses)
+	if err != nil {
+		logging.Logger.Errorf("alert watcher failed to get expiring events, err=%+v", err.Error())
+		return
+	}
+	if len(events) == 0 {
+		return
+	}
+	first := events[0]
+	Raise(Alert{
+		Name:     AlertExpiringChallenge,
+		Severity: SeverityCritical,
+		Message: fmt.Sprintf("%d challenges are not attested and expire within %d blocks, e.g. challenge %d in status %s "+
+			"expires at height %d", len(events), blocks, first.ChallengeId, first.Status, first.ExpiredHeight),
+	})
+}
+
+// checkStall pages if no event moved to a later status within threshold although new challenge events were saved
+// meanwhile, i.e. a loop of the pipeline is wedged without crashing.
+func (w *Watcher) checkStall(threshold time.Duration) {
+	counts, err := w.events.CountEventsByStatus(context.Background())
+	if err != nil {
+		logging.Logger.Errorf("alert watcher failed to count events, err=%+v", err.Error())
+		return
+	}
+	latestChallengeId, err := w.events.GetLatestChallengeId(context.Background())
+	if err != nil {
+		logging.Logger.Errorf("alert watcher failed to get the latest challenge id, err=%+v", err.Error())
+		return
+	}
+	stalled, ok := w.stall.observe(time.Now(), counts, latestChallengeId)
+	if ok && stalled > threshold {
+		Raise(Alert{
+			Name:     AlertPipelineStall,
+			Severity: SeverityCritical,
+			Message: fmt.Sprintf("no event moved to a later status for %s while new challenges up to %d were saved, "+
+				"%d events are unprocessed", stalled.Truncate(time.Minute), latestChallengeId, counts[model.Unprocessed]),
+		})
+	}
+}
+
+// stallDetector follows the event counts by status between the checks. The pipeline progresses when the count of a
+// status other than unprocessed grows, since the events only move forward and the wiper only deletes them.
+type stallDetector struct {
+	counts            map[model.EventStatus]int64
+	latestChallengeId uint64
+	lastProgress      time.Time
+	lastNewEvent      time.Time
+}
+
+// observe records the counts and the latest saved challenge id at now. It returns for how long the pipeline has not
+// progressed, and false unless new events were saved since it last progressed.
+func (d *stallDetector) observe(now time.Time, counts map[model.EventStatus]int64, latestChallengeId uint64) (time.Duration, bool) {
+	if d.counts == nil {
+		d.counts, d.latestChallengeId, d.lastProgress = counts, latestChallengeId, now
+		return 0, false
+	}
+	for status, count := range counts {
+		if status != model.Unprocessed && count > d.counts[status] {
+			d.lastProgress = now
+			break
+		}
+	}
+	if latestChallengeId > d.latestChallengeId {
+		d.lastNewEvent = now
+	}
+	d.counts, d.latestChallengeId = counts, latestChallengeId
+	if !d.lastNewEvent.After(d.lastProgress) {
+		return 0, false
+	}
+	return now.Sub(d.lastProgress), true
+}
@@ -0,0 +1,12 @@
+package analytics
+
+import "time"
+
+const (
+	// Window is the period the verification results are rolled up by
+	Window = time.Hour
+	// RollupDelay lets the results of a closed window be saved before it is rolled up
+	RollupDelay = time.Minute
+	// MaxWindowsPerRead bounds the windows whose verification results are read at once
+	MaxWindowsPerRead = 24
+)
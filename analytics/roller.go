@@ -0,0 +1,150 @@
+package analytics
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/common"
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+// DataProvider reads the verification results and saves their rollups.
+type DataProvider interface {
+	ListVerificationResultsBetween(ctx context.Context, from, to int64) ([]*model.VerificationResult, error)
+	GetEarliestVerificationResultTime(ctx context.Context, since int64) (int64, error)
+	GetLatestRollupWindow(ctx context.Context) (int64, error)
+	SaveRollups(ctx context.Context, from, to int64, spRollups []*model.SpRollup, bucketRollups []*model.BucketRollup) error
+	DeleteRollupsBefore(ctx context.Context, windowStart int64) error
+}
+
+// Roller rolls the verification results up by storage provider and by bucket into hourly windows, once the windows are
+// closed. Each window is rolled up once, a result replaced later, e.g. by a replay, is counted in the window it was
+// created in first.
+type Roller struct {
+	cfg          *config.AnalyticsConfig
+	dataProvider DataProvider
+}
+
+func NewRoller(cfg *config.AnalyticsConfig, dataProvider DataProvider) *Roller {
+	return &Roller{
+		cfg:          cfg,
+		dataProvider: dataProvider,
+	}
+}
+
+// WindowStart returns the start of the window of a unix timestamp.
+func WindowStart(unix int64) int64 {
+	window := int64(Window / time.Second)
+	return unix - unix%window
+}
+
+// Aggregate rolls the verification results up by window, the rollups are sorted by window and by key. The results of
+// unknown buckets are only rolled up by storage provider.
+func Aggregate(results []*model.VerificationResult) ([]*model.SpRollup, []*model.BucketRollup) {
+	type key struct {
+		windowStart int64
+		name        string
+	}
+	spRollups := make(map[key]*model.SpRollup)
+	bucketRollups := make(map[key]*model.BucketRollup)
+	for _, result := range results {
+		windowStart := WindowStart(result.CreatedTime)
+		spKey := key{windowStart, result.SpOperatorAddress}
+		if _, ok := spRollups[spKey]; !ok {
+			spRollups[spKey] = &model.SpRollup{WindowStart: windowStart, SpOperatorAddress: result.SpOperatorAddress}
+		}
+		spRollups[spKey].Count(result)
+		if result.BucketName == "" {
+			continue
+		}
+		bucketKey := key{windowStart, result.BucketName}
+		if _, ok := bucketRollups[bucketKey]; !ok {
+			bucketRollups[bucketKey] = &model.BucketRollup{WindowStart: windowStart, BucketName: result.BucketName}
+		}
+		bucketRollups[bucketKey].Count(result)
+	}
+
+	sps := make([]*model.SpRollup, 0, len(spRollups))
+	for _, r := range spRollups {
+		sps = append(sps, r)
+	}
+	sort.Slice(sps, func(i, j int) bool {
+		if sps[i].WindowStart != sps[j].WindowStart {
+			return sps[i].WindowStart < sps[j].WindowStart
+		}
+		return sps[i].SpOperatorAddress < sps[j].SpOperatorAddress
+	})
+	buckets := make([]*model.BucketRollup, 0, len(bucketRollups))
+	for _, r := range bucketRollups {
+		buckets = append(buckets, r)
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].WindowStart != buckets[j].WindowStart {
+			return buckets[i].WindowStart < buckets[j].WindowStart
+		}
+		return buckets[i].BucketName < buckets[j].BucketName
+	})
+	return sps, buckets
+}
+
+// Rollup rolls up the windows closed at now which are not rolled up yet and returns the number of results rolled up,
+// the rollups past the retention are deleted first.
+func (r *Roller) Rollup(ctx context.Context, now time.Time) (int, error) {
+	retentionStart := WindowStart(now.Add(-r.cfg.Retention()).Unix())
+	if err := r.dataProvider.DeleteRollupsBefore(ctx, retentionStart); err != nil {
+		return 0, err
+	}
+	// the windows starting before closed are closed
+	closed := WindowStart(now.Add(-RollupDelay).Unix())
+	window := int64(Window / time.Second)
+	rolled := 0
+	for {
+		latest, err := r.dataProvider.GetLatestRollupWindow(ctx)
+		if err != nil {
+			return rolled, err
+		}
+		since := retentionStart
+		if latest+window > since {
+			since = latest + window
+		}
+		earliest, err := r.dataProvider.GetEarliestVerificationResultTime(ctx, since)
+		if err != nil {
+			return rolled, err
+		}
+		from := WindowStart(earliest)
+		if earliest == 0 || from >= closed {
+			return rolled, nil
+		}
+		to := from + MaxWindowsPerRead*window
+		if to > closed {
+			to = closed
+		}
+		results, err := r.dataProvider.ListVerificationResultsBetween(ctx, from, to)
+		if err != nil {
+			return rolled, err
+		}
+		spRollups, bucketRollups := Aggregate(results)
+		if err = r.dataProvider.SaveRollups(ctx, from, to, spRollups, bucketRollups); err != nil {
+			return rolled, err
+		}
+		rolled += len(results)
+	}
+}
+
+// RollupLoop rolls up the closed windows every rollup interval until ctx is done.
+func (r *Roller) RollupLoop(ctx context.Context) {
+	for {
+		rolled, err := r.Rollup(ctx, time.Now())
+		if err != nil {
+			logging.Logger.Errorf("failed to roll up the verification results, err=%+v", err.Error())
+		} else if rolled != 0 {
+			logging.Logger.Infof("rolled up %d verification results", rolled)
+		}
+		if !common.Sleep(ctx, r.cfg.RollupInterval()) {
+			return
+		}
+	}
+}
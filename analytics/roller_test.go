@@ -0,0 +1,102 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+)
+
+type testDataProvider struct {
+	*dao.VerificationResultDao
+	*dao.RollupDao
+}
+
+func newTestDataProvider(t *testing.T) *testDataProvider {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	model.RunMigrations(db)
+	return &testDataProvider{dao.NewVerificationResultDao(db), dao.NewRollupDao(db)}
+}
+
+func TestAggregate(t *testing.T) {
+	results := []*model.VerificationResult{
+		{SpOperatorAddress: "sp2", BucketName: "b1", Outcome: model.OutcomeHashMismatched, Attempts: 1, LatencyMs: 100,
+			CreatedTime: 3600},
+		{SpOperatorAddress: "sp1", BucketName: "b1", Outcome: model.OutcomeSpUnavailable, Attempts: 3, LatencyMs: 900,
+			CreatedTime: 3700},
+		{SpOperatorAddress: "sp1", Outcome: model.OutcomeHashMatched, Attempts: 1, LatencyMs: 50, CreatedTime: 7199},
+		// the sp was not requested, its latency is not sampled
+		{SpOperatorAddress: "sp1", Outcome: model.OutcomeEndpointUnavailable, CreatedTime: 7200},
+	}
+	sps, buckets := Aggregate(results)
+	require.Len(t, sps, 3)
+	require.Equal(t, "sp1", sps[0].SpOperatorAddress)
+	require.Equal(t, model.RollupCounters{Verified: 2, HashMatched: 1, SpUnavailable: 1, TotalLatencyMs: 950,
+		LatencySamples: 2, MaxLatencyMs: 900}, sps[0].RollupCounters)
+	require.Equal(t, 0.5, sps[0].FailureRate())
+	require.Equal(t, int64(7200), sps[2].WindowStart)
+	require.Equal(t, model.RollupCounters{Verified: 1, Unverified: 1}, sps[2].RollupCounters)
+	require.Zero(t, sps[2].FailureRate())
+
+	// the results of unknown buckets are not rolled up by bucket
+	require.Len(t, buckets, 1)
+	require.Equal(t, uint64(2), buckets[0].Verified)
+	require.Equal(t, uint64(2), buckets[0].Failures())
+}
+
+func TestRollup(t *testing.T) {
+	ctx := context.Background()
+	dataProvider := newTestDataProvider(t)
+	roller := NewRoller(&config.AnalyticsConfig{RetentionInDays: 30}, dataProvider)
+	now := time.Unix(100*24*3600, 0)
+	save := func(challengeId uint64, createdTime time.Time) {
+		require.NoError(t, dataProvider.SaveVerificationResult(ctx, &model.VerificationResult{ChallengeId: challengeId,
+			SpOperatorAddress: "sp1", BucketName: "b1", CreatedTime: createdTime.Unix()}))
+	}
+	// past the retention
+	save(1, now.Add(-31*24*time.Hour))
+	// more windows than read at once, apart from each other
+	save(2, now.Add(-3*24*time.Hour))
+	save(3, now.Add(-2*time.Hour))
+	// the current window is not closed yet
+	save(4, now.Add(-time.Minute))
+
+	rolled, err := roller.Rollup(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, 2, rolled)
+	rollups, err := dataProvider.ListSpRollups(ctx, "sp1", 0, now.Unix())
+	require.NoError(t, err)
+	require.Len(t, rollups, 2)
+	require.Equal(t, WindowStart(now.Add(-2*time.Hour).Unix()), rollups[1].WindowStart)
+
+	// the windows rolled up are not rolled up again
+	rolled, err = roller.Rollup(ctx, now)
+	require.NoError(t, err)
+	require.Zero(t, rolled)
+
+	rolled, err = roller.Rollup(ctx, now.Add(Window))
+	require.NoError(t, err)
+	require.Equal(t, 1, rolled)
+	buckets, err := dataProvider.ListBucketRollups(ctx, "b1", 0, now.Unix()+int64(Window/time.Second))
+	require.NoError(t, err)
+	require.Len(t, buckets, 3)
+
+	// the rollups past the retention are deleted
+	_, err = roller.Rollup(ctx, now.Add(28*24*time.Hour))
+	require.NoError(t, err)
+	rollups, err = dataProvider.ListSpRollups(ctx, "sp1", 0, now.Unix()+int64(Window/time.Second))
+	require.NoError(t, err)
+	require.Len(t, rollups, 2)
+}
@@ -0,0 +1,24 @@
+package api
+
+import "time"
+
+const (
+	EventsPath  = "/v1/events"
+	SpStatsPath = "/v1/sp_stats"
+
+	SpTrendsPath     = "/v1/sp_trends"
+	BucketTrendsPath = "/v1/bucket_trends"
+
+	// DefaultTrendPeriod is covered by the trends which set no from_time
+	DefaultTrendPeriod = 7 * 24 * time.Hour
+	// MaxTrendWindows bounds the windows a trend may cover
+	MaxTrendWindows = 1000
+
+	RequestTimeout = 30 * time.Second
+
+	// HubBufferSize is the number of latest transitions kept to resume the subscriptions from
+	HubBufferSize = 10000
+	// SubscriberBufferSize is the number of updates a subscriber may fall behind before it is dropped
+	SubscriberBufferSize = 1000
+	MaxSubscribers       = 100
+)
@@ -0,0 +1,556 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/vote"
+)
+
+type DataProvider interface {
+	ListEvents(ctx context.Context, filter dao.EventFilter, cursor uint64, limit int) ([]*model.Event, uint64, error)
+	GetEventByChallengeId(ctx context.Context, challengeId uint64) (*model.Event, error)
+	GetVotesByEventHash(ctx context.Context, eventHash string) ([]*model.Vote, error)
+	GetVerificationResult(ctx context.Context, challengeId uint64) (*model.VerificationResult, error)
+	GetSpStats(ctx context.Context, spOperatorAddress string) (*model.SpStats, error)
+	ListSpStats(ctx context.Context) ([]*model.SpStats, error)
+	ListSpRollups(ctx context.Context, spOperatorAddress string, from, to int64) ([]*model.SpRollup, error)
+	ListBucketRollups(ctx context.Context, bucketName string, from, to int64) ([]*model.BucketRollup, error)
+}
+
+// Service serves the challenge results of the database over the Query service, read-only.
+type Service struct {
+	UnimplementedQueryServer
+	chainId      string
+	dataProvider DataProvider
+	hub          *Hub // nil if the transitions are not subscribed to
+}
+
+func NewService(chainId string, dataProvider DataProvider) *Service {
+	return &Service{
+		chainId:      chainId,
+		dataProvider: dataProvider,
+	}
+}
+
+// SetHub serves the subscriptions to the transitions observed by hub.
+func (s *Service) SetHub(hub *Hub) {
+	s.hub = hub
+}
+
+func (s *Service) Event(ctx context.Context, req *QueryEventRequest) (*QueryEventResponse, error) {
+	event, err := s.dataProvider.GetEventByChallengeId(ctx, req.ChallengeId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Errorf(codes.NotFound, "challenge %d not found", req.ChallengeId)
+	}
+	if err != nil {
+		return nil, internalError(err)
+	}
+	response := &QueryEventResponse{Event: newEvent(event)}
+
+	result, err := s.dataProvider.GetVerificationResult(ctx, req.ChallengeId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, internalError(err)
+	}
+	if result != nil {
+		response.VerificationResult = &VerificationResult{
+			Outcome:      result.Outcome.String(),
+			ExpectedHash: result.ExpectedHash,
+			ActualHash:   result.ActualHash,
+			LatencyMs:    result.LatencyMs,
+			Attempts:     result.Attempts,
+			CreatedTime:  result.CreatedTime,
+		}
+	}
+
+	// the votes are keyed by the event hash, which is only known once the event is verified
+	if event.VerifyResult == model.HashMatched || event.VerifyResult == model.HashMismatched {
+		eventHash := hex.EncodeToString(vote.EventHash(event, s.chainId))
+		votes, err := s.dataProvider.GetVotesByEventHash(ctx, eventHash)
+		if err != nil {
+			return nil, internalError(err)
+		}
+		tally := &VoteTally{EventHash: eventHash, Votes: uint64(len(votes)), PubKeys: make([]string, 0, len(votes))}
+		for _, v := range votes {
+			tally.PubKeys = append(tally.PubKeys, v.PubKey)
+		}
+		response.VoteTally = tally
+	}
+	return response, nil
+}
+
+func (s *Service) Events(ctx context.Context, req *QueryEventsRequest) (*QueryEventsResponse, error) {
+	filter := dao.EventFilter{
+		FromHeight:        req.FromHeight,
+		ToHeight:          req.ToHeight,
+		SpOperatorAddress: req.SpOperatorAddress,
+		ChallengerAddress: req.ChallengerAddress,
+	}
+	for _, name := range req.Statuses {
+		eventStatus, err := model.ParseEventStatus(name)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		filter.Statuses = append(filter.Statuses, eventStatus)
+	}
+	events, nextCursor, err := s.dataProvider.ListEvents(ctx, filter, req.Cursor, int(req.Limit))
+	if err != nil {
+		return nil, internalError(err)
+	}
+	response := &QueryEventsResponse{Events: make([]*Event, 0, len(events)), NextCursor: nextCursor}
+	for _, event := range events {
+		response.Events = append(response.Events, newEvent(event))
+	}
+	return response, nil
+}
+
+func (s *Service) SpStats(ctx context.Context, req *QuerySpStatsRequest) (*QuerySpStatsResponse, error) {
+	stats, err := s.dataProvider.GetSpStats(ctx, req.SpOperatorAddress)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Errorf(codes.NotFound, "storage provider %s not found", req.SpOperatorAddress)
+	}
+	if err != nil {
+		return nil, internalError(err)
+	}
+	return &QuerySpStatsResponse{SpStats: newSpStats(stats)}, nil
+}
+
+func (s *Service) AllSpStats(ctx context.Context, _ *QueryAllSpStatsRequest) (*QueryAllSpStatsResponse, error) {
+	stats, err := s.dataProvider.ListSpStats(ctx)
+	if err != nil {
+		return nil, internalError(err)
+	}
+	response := &QueryAllSpStatsResponse{SpStats: make([]*SpStats, 0, len(stats))}
+	for _, s := range stats {
+		response.SpStats = append(response.SpStats, newSpStats(s))
+	}
+	return response, nil
+}
+
+func (s *Service) SpTrend(ctx context.Context, req *QuerySpTrendRequest) (*QueryTrendResponse, error) {
+	from, to, window, err := trendRange(req.FromTime, req.ToTime, req.WindowHours)
+	if err != nil {
+		return nil, err
+	}
+	rollups, err := s.dataProvider.ListSpRollups(ctx, req.SpOperatorAddress, from, to)
+	if err != nil {
+		return nil, internalError(err)
+	}
+	trend := newTrend(from, window)
+	for _, r := range rollups {
+		trend.add(r.WindowStart, &r.RollupCounters)
+	}
+	return trend.response(), nil
+}
+
+func (s *Service) BucketTrend(ctx context.Context, req *QueryBucketTrendRequest) (*QueryTrendResponse, error) {
+	from, to, window, err := trendRange(req.FromTime, req.ToTime, req.WindowHours)
+	if err != nil {
+		return nil, err
+	}
+	rollups, err := s.dataProvider.ListBucketRollups(ctx, req.BucketName, from, to)
+	if err != nil {
+		return nil, internalError(err)
+	}
+	trend := newTrend(from, window)
+	for _, r := range rollups {
+		trend.add(r.WindowStart, &r.RollupCounters)
+	}
+	return trend.response(), nil
+}
+
+func (s *Service) SubscribeEvents(req *SubscribeEventsRequest, stream Query_SubscribeEventsServer) error {
+	if s.hub == nil {
+		return status.Error(codes.Unimplemented, "event subscriptions are not served")
+	}
+	sub, err := s.hub.subscribe(req.Statuses, req.SpOperatorAddress, req.ResumeToken)
+	if err != nil {
+		return err
+	}
+	defer s.hub.unsubscribe(sub)
+	for {
+		select {
+		case update := <-sub.updates:
+			if err := stream.Send(update); err != nil {
+				return err
+			}
+		case <-sub.dropped:
+			return status.Errorf(codes.Unavailable, "the subscriber fell more than %d updates behind, resume from the "+
+				"last update", SubscriberBufferSize)
+		case <-s.hub.done:
+			return status.Error(codes.Unavailable, "the challenger is shutting down")
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// trendRange returns the range and the window of a trend in seconds, applying the defaults of the request.
+func trendRange(fromTime, toTime int64, windowHours uint32) (int64, int64, int64, error) {
+	if toTime == 0 {
+		toTime = time.Now().Unix()
+	}
+	if fromTime == 0 {
+		fromTime = toTime - int64(DefaultTrendPeriod/time.Second)
+	}
+	if windowHours == 0 {
+		windowHours = 1
+	}
+	window := int64(windowHours) * int64(time.Hour/time.Second)
+	if fromTime >= toTime {
+		return 0, 0, 0, status.Errorf(codes.InvalidArgument, "from_time %d should be before to_time %d", fromTime, toTime)
+	}
+	if (toTime-fromTime)/window >= MaxTrendWindows {
+		return 0, 0, 0, status.Errorf(codes.InvalidArgument, "the trend covers more than %d windows, shorten it or "+
+			"raise window_hours", MaxTrendWindows)
+	}
+	return fromTime, toTime, window, nil
+}
+
+// trend merges the hourly rollups into the windows of a trend, aligned on its start.
+type trend struct {
+	from     int64
+	window   int64
+	starts   []int64
+	counters map[int64]*model.RollupCounters
+}
+
+func newTrend(from, window int64) *trend {
+	return &trend{
+		from:     from,
+		window:   window,
+		starts:   make([]int64, 0),
+		counters: make(map[int64]*model.RollupCounters),
+	}
+}
+
+// add adds a rollup, the rollups are added in window order.
+func (t *trend) add(windowStart int64, counters *model.RollupCounters) {
+	start := t.from + (windowStart-t.from)/t.window*t.window
+	merged, ok := t.counters[start]
+	if !ok {
+		merged = &model.RollupCounters{}
+		t.counters[start] = merged
+		t.starts = append(t.starts, start)
+	}
+	merged.Add(counters)
+}
+
+func (t *trend) response() *QueryTrendResponse {
+	response := &QueryTrendResponse{Points: make([]*TrendPoint, 0, len(t.starts))}
+	for _, start := range t.starts {
+		c := t.counters[start]
+		response.Points = append(response.Points, &TrendPoint{
+			WindowStart:          start,
+			Verified:             c.Verified,
+			HashMatched:          c.HashMatched,
+			HashMismatched:       c.HashMismatched,
+			SpUnavailable:        c.SpUnavailable,
+			PluginMismatched:     c.PluginMismatched,
+			FederatedMatched:     c.FederatedMatched,
+			Unverified:           c.Unverified,
+			FailureRate:          c.FailureRate(),
+			AvgResponseLatencyMs: c.AvgResponseLatencyMs(),
+			MaxResponseLatencyMs: c.MaxLatencyMs,
+		})
+	}
+	return response
+}
+
+// internalError hides the database errors from the public api, they are logged instead.
+func internalError(err error) error {
+	logging.Logger.Errorf("query api failed to read the database, err=%+v", err.Error())
+	return status.Error(codes.Internal, "internal error")
+}
+
+func newEvent(e *model.Event) *Event {
+	return &Event{
+		ChallengeId:       e.ChallengeId,
+		ObjectId:          e.ObjectId,
+		SegmentIndex:      e.SegmentIndex,
+		SpOperatorAddress: e.SpOperatorAddress,
+		RedundancyIndex:   e.RedundancyIndex,
+		ChallengerAddress: e.ChallengerAddress,
+		Height:            e.Height,
+		ExpiredHeight:     e.ExpiredHeight,
+		Status:            e.Status.String(),
+		VerifyResult:      e.VerifyResult.String(),
+		CreatedTime:       e.CreatedTime,
+	}
+}
+
+func newSpStats(s *model.SpStats) *SpStats {
+	return &SpStats{
+		SpOperatorAddress:    s.SpOperatorAddress,
+		Received:             s.Received,
+		Succeeded:            s.Succeeded,
+		Failed:               s.Failed,
+		Expired:              s.Expired,
+		Slashed:              s.Slashed,
+		AvgResponseLatencyMs: s.AvgResponseLatencyMs(),
+		UpdatedTime:          s.UpdatedTime,
+	}
+}
+
+// Server serves the Query service over grpc and its REST mapping over http, each on its own port if it is set.
+type Server struct {
+	cfg     *config.QueryApiConfig
+	service *Service
+	mux     *http.ServeMux
+}
+
+func NewServer(cfg *config.QueryApiConfig, service *Service) *Server {
+	s := &Server{
+		cfg:     cfg,
+		service: service,
+		mux:     http.NewServeMux(),
+	}
+	s.mux.HandleFunc(EventsPath, s.listEvents)
+	s.mux.HandleFunc(EventsPath+"/", s.getEvent)
+	s.mux.HandleFunc(SpStatsPath, s.listSpStats)
+	s.mux.HandleFunc(SpStatsPath+"/", s.getSpStats)
+	s.mux.HandleFunc(SpTrendsPath+"/", s.getSpTrend)
+	s.mux.HandleFunc(BucketTrendsPath+"/", s.getBucketTrend)
+	return s
+}
+
+// Start serves the enabled endpoints of the query api, it blocks until ctx is done.
+func (s *Server) Start(ctx context.Context) {
+	errCh := make(chan error, 2)
+	if s.cfg.GrpcPort != 0 {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.cfg.GrpcPort))
+		if err != nil {
+			panic(err)
+		}
+		server := grpc.NewServer()
+		RegisterQueryServer(server, s.service)
+		go func() {
+			<-ctx.Done()
+			if s.service.hub != nil {
+				s.service.hub.Close()
+			}
+			server.GracefulStop()
+		}()
+		go func() { errCh <- server.Serve(listener) }()
+	}
+	if s.cfg.RestPort != 0 {
+		server := &http.Server{
+			Addr:              fmt.Sprintf(":%d", s.cfg.RestPort),
+			Handler:           s.mux,
+			ReadHeaderTimeout: RequestTimeout,
+		}
+		go func() {
+			<-ctx.Done()
+			_ = server.Shutdown(context.Background())
+		}()
+		go func() {
+			if err := server.ListenAndServe(); err != http.ErrServerClosed {
+				errCh <- err
+				return
+			}
+			errCh <- nil
+		}()
+	}
+	select {
+	case <-ctx.Done():
+	case err := <-errCh:
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// listEvents serves GET /v1/events?statuses=verified,self_voted&sp_operator_address=...&challenger_address=...&
+// from_height=...&to_height=...&cursor=...&limit=...
+func (s *Server) listEvents(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	query := r.URL.Query()
+	req := &QueryEventsRequest{
+		SpOperatorAddress: query.Get("sp_operator_address"),
+		ChallengerAddress: query.Get("challenger_address"),
+	}
+	if statuses := query.Get("statuses"); statuses != "" {
+		req.Statuses = strings.Split(statuses, ",")
+	}
+	for name, dest := range map[string]*uint64{"from_height": &req.FromHeight, "to_height": &req.ToHeight, "cursor": &req.Cursor} {
+		if value := query.Get(name); value != "" {
+			var err error
+			if *dest, err = strconv.ParseUint(value, 10, 64); err != nil {
+				writeError(w, status.Errorf(codes.InvalidArgument, "invalid %s %q", name, value))
+				return
+			}
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			writeError(w, status.Errorf(codes.InvalidArgument, "invalid limit %q", value))
+			return
+		}
+		req.Limit = uint32(limit)
+	}
+	response, err := s.service.Events(r.Context(), req)
+	writeResponse(w, response, err)
+}
+
+// getEvent serves GET /v1/events/{challenge_id}
+func (s *Server) getEvent(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	challengeId, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, EventsPath+"/"), 10, 64)
+	if err != nil {
+		writeError(w, status.Errorf(codes.NotFound, "unknown path %s", r.URL.Path))
+		return
+	}
+	response, err := s.service.Event(r.Context(), &QueryEventRequest{ChallengeId: challengeId})
+	writeResponse(w, response, err)
+}
+
+// listSpStats serves GET /v1/sp_stats
+func (s *Server) listSpStats(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	response, err := s.service.AllSpStats(r.Context(), &QueryAllSpStatsRequest{})
+	writeResponse(w, response, err)
+}
+
+// getSpStats serves GET /v1/sp_stats/{sp_operator_address}
+func (s *Server) getSpStats(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	address := strings.TrimPrefix(r.URL.Path, SpStatsPath+"/")
+	if address == "" || strings.Contains(address, "/") {
+		writeError(w, status.Errorf(codes.NotFound, "unknown path %s", r.URL.Path))
+		return
+	}
+	response, err := s.service.SpStats(r.Context(), &QuerySpStatsRequest{SpOperatorAddress: address})
+	writeResponse(w, response, err)
+}
+
+// getSpTrend serves GET /v1/sp_trends/{sp_operator_address}?from_time=...&to_time=...&window_hours=...
+func (s *Server) getSpTrend(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	address := strings.TrimPrefix(r.URL.Path, SpTrendsPath+"/")
+	if address == "" || strings.Contains(address, "/") {
+		writeError(w, status.Errorf(codes.NotFound, "unknown path %s", r.URL.Path))
+		return
+	}
+	req := &QuerySpTrendRequest{SpOperatorAddress: address}
+	if err := parseTrendQuery(r, &req.FromTime, &req.ToTime, &req.WindowHours); err != nil {
+		writeError(w, err)
+		return
+	}
+	response, err := s.service.SpTrend(r.Context(), req)
+	writeResponse(w, response, err)
+}
+
+// getBucketTrend serves GET /v1/bucket_trends/{bucket_name}?from_time=...&to_time=...&window_hours=...
+func (s *Server) getBucketTrend(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	bucketName := strings.TrimPrefix(r.URL.Path, BucketTrendsPath+"/")
+	if bucketName == "" || strings.Contains(bucketName, "/") {
+		writeError(w, status.Errorf(codes.NotFound, "unknown path %s", r.URL.Path))
+		return
+	}
+	req := &QueryBucketTrendRequest{BucketName: bucketName}
+	if err := parseTrendQuery(r, &req.FromTime, &req.ToTime, &req.WindowHours); err != nil {
+		writeError(w, err)
+		return
+	}
+	response, err := s.service.BucketTrend(r.Context(), req)
+	writeResponse(w, response, err)
+}
+
+func parseTrendQuery(r *http.Request, fromTime, toTime *int64, windowHours *uint32) error {
+	query := r.URL.Query()
+	for name, dest := range map[string]*int64{"from_time": fromTime, "to_time": toTime} {
+		if value := query.Get(name); value != "" {
+			var err error
+			if *dest, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return status.Errorf(codes.InvalidArgument, "invalid %s %q", name, value)
+			}
+		}
+	}
+	if value := query.Get("window_hours"); value != "" {
+		hours, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid window_hours %q", value)
+		}
+		*windowHours = uint32(hours)
+	}
+	return nil
+}
+
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		writeError(w, status.Errorf(codes.Unimplemented, "method %s is not allowed", r.Method))
+		return false
+	}
+	return true
+}
+
+// jsonMarshaler writes the field names of the proto file and the zero values, so that the REST responses have a
+// stable shape.
+var jsonMarshaler = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+func writeResponse(w http.ResponseWriter, response proto.Message, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	bz, err := jsonMarshaler.Marshal(response)
+	if err != nil {
+		writeError(w, internalError(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(bz); err != nil {
+		logging.Logger.Errorf("failed to write query api response, err=%+v", err.Error())
+	}
+}
+
+// httpStatuses maps the grpc codes returned by the service to the http statuses of the REST responses.
+var httpStatuses = map[codes.Code]int{
+	codes.InvalidArgument: http.StatusBadRequest,
+	codes.NotFound:        http.StatusNotFound,
+	codes.Unimplemented:   http.StatusMethodNotAllowed,
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	httpStatus, ok := httpStatuses[st.Code()]
+	if !ok {
+		httpStatus = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": st.Message()})
+}
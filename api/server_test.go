@@ -0,0 +1,278 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/vote"
+)
+
+const (
+	testChainId = "greenfield_5600-1"
+	testSp1     = "0x1A2b3C4d5E6f708192a3B4c5D6e7F8091A2B3C4D"
+	testSp2     = "0x2B3c4D5e6F708192A3b4C5d6E7f8091a2B3C4D5E"
+)
+
+func newTestServer(t *testing.T) (*Server, *dao.DaoManager) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	model.RunMigrations(db)
+	daoManager := dao.NewDaoManager(dao.NewBlockDao(db), dao.NewEventDao(db), dao.NewVoteDao(db), dao.NewSpStatsDao(db),
+		dao.NewVerificationResultDao(db), dao.NewSamplingFailureDao(db), dao.NewLeaseDao(db),
+		dao.NewLedgerDao(db), dao.NewRollupDao(db), dao.NewJobDao(db))
+
+	ctx := context.Background()
+	events := []*model.Event{
+		{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: testSp1, Height: 100, Status: model.Unprocessed},
+		{ChallengeId: 2, ObjectId: "2", SpOperatorAddress: testSp1, Height: 100, Status: model.Verified, VerifyResult: model.HashMismatched},
+		{ChallengeId: 3, ObjectId: "3", SpOperatorAddress: testSp2, Height: 101, Status: model.Attested, VerifyResult: model.HashMismatched},
+	}
+	require.NoError(t, daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 101}, events))
+	require.NoError(t, daoManager.SaveVerificationResult(ctx, &model.VerificationResult{ChallengeId: 2,
+		SpOperatorAddress: testSp1, Attempts: 2, LatencyMs: 150, Outcome: model.OutcomeHashMismatched}))
+	eventHash := hex.EncodeToString(vote.CalculateEventHash(events[1], testChainId))
+	for _, pubKey := range []string{"a", "b"} {
+		require.NoError(t, daoManager.SaveVote(ctx, &model.Vote{ChallengeId: 2, PubKey: pubKey, EventHash: eventHash}))
+	}
+	require.NoError(t, daoManager.RecordSpVerifyResult(ctx, testSp1, model.HashMismatched, 200*time.Millisecond))
+
+	cfg := &config.QueryApiConfig{GrpcPort: 9401, RestPort: 9402}
+	return NewServer(cfg, NewService(testChainId, daoManager)), daoManager
+}
+
+func serve(s *Server, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestService_Grpc(t *testing.T) {
+	s, _ := newTestServer(t)
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	RegisterQueryServer(server, s.service)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := NewQueryClient(conn)
+	ctx := context.Background()
+
+	event, err := client.Event(ctx, &QueryEventRequest{ChallengeId: 2})
+	require.NoError(t, err)
+	require.Equal(t, "verified", event.Event.Status)
+	require.Equal(t, "hash_mismatched", event.VerificationResult.Outcome)
+	require.Equal(t, uint32(2), event.VerificationResult.Attempts)
+	require.Equal(t, uint64(2), event.VoteTally.Votes)
+	require.ElementsMatch(t, []string{"a", "b"}, event.VoteTally.PubKeys)
+
+	// the votes are only tallied for verified events
+	event, err = client.Event(ctx, &QueryEventRequest{ChallengeId: 1})
+	require.NoError(t, err)
+	require.Nil(t, event.VerificationResult)
+	require.Nil(t, event.VoteTally)
+
+	_, err = client.Event(ctx, &QueryEventRequest{ChallengeId: 4})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	events, err := client.Events(ctx, &QueryEventsRequest{Statuses: []string{"verified", "attested"}, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, events.Events, 1)
+	require.Equal(t, uint64(2), events.NextCursor)
+	events, err = client.Events(ctx, &QueryEventsRequest{Statuses: []string{"verified", "attested"}, Cursor: events.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, events.Events, 1)
+	require.Equal(t, uint64(3), events.Events[0].ChallengeId)
+	require.Zero(t, events.NextCursor)
+
+	_, err = client.Events(ctx, &QueryEventsRequest{Statuses: []string{"bogus"}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	stats, err := client.SpStats(ctx, &QuerySpStatsRequest{SpOperatorAddress: testSp1})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), stats.SpStats.Succeeded)
+	require.Equal(t, uint64(200), stats.SpStats.AvgResponseLatencyMs)
+
+	_, err = client.SpStats(ctx, &QuerySpStatsRequest{SpOperatorAddress: "sp9"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestService_SubscribeEvents(t *testing.T) {
+	s, daoManager := newTestServer(t)
+	hub := NewHub()
+	s.service.SetHub(hub)
+	daoManager.SetTransitionObserver(hub.Observe)
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	RegisterQueryServer(server, s.service)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := NewQueryClient(conn)
+	subscribed := func(n int) func() bool {
+		return func() bool {
+			hub.mtx.Lock()
+			defer hub.mtx.Unlock()
+			return len(hub.subscribers) == n
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.SubscribeEvents(ctx, &SubscribeEventsRequest{Statuses: []string{"verified"},
+		SpOperatorAddress: testSp1})
+	require.NoError(t, err)
+	require.Eventually(t, subscribed(1), 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, daoManager.UpdateEventStatusVerifyResultByChallengeId(context.Background(), 1, model.Verified,
+		model.HashMatched, ""))
+	update, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), update.Event.ChallengeId)
+	require.Equal(t, "unprocessed", update.FromStatus)
+	require.Equal(t, "verified", update.Event.Status)
+	cancel()
+	require.Eventually(t, subscribed(0), 5*time.Second, 10*time.Millisecond)
+
+	// the updates missed while disconnected are sent first on resume, the filtered ones are skipped
+	hub.Observe(&model.Event{Status: model.Unprocessed}, &model.Event{ChallengeId: 5, SpOperatorAddress: testSp2,
+		Status: model.Verified})
+	hub.Observe(&model.Event{Status: model.Unprocessed}, &model.Event{ChallengeId: 6, SpOperatorAddress: testSp1,
+		Status: model.Verified})
+	stream, err = client.SubscribeEvents(context.Background(), &SubscribeEventsRequest{Statuses: []string{"verified"},
+		SpOperatorAddress: testSp1, ResumeToken: update.ResumeToken})
+	require.NoError(t, err)
+	update, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), update.Event.ChallengeId)
+	require.Equal(t, hub.token(3), update.ResumeToken)
+
+	for token, code := range map[string]codes.Code{"bogus": codes.InvalidArgument, "1-1": codes.OutOfRange,
+		hub.token(4): codes.InvalidArgument} {
+		stream, err = client.SubscribeEvents(context.Background(), &SubscribeEventsRequest{ResumeToken: token})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.Equal(t, code, status.Code(err), token)
+	}
+
+	// the subscriptions end on shutdown
+	hub.Close()
+	stream, err = client.SubscribeEvents(context.Background(), &SubscribeEventsRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestServer_Rest(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	w := serve(s, http.MethodGet, EventsPath+"/2")
+	require.Equal(t, http.StatusOK, w.Code)
+	event := map[string]map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+	require.Equal(t, "hash_mismatched", event["event"]["verify_result"])
+	require.Equal(t, "2", event["vote_tally"]["votes"]) // 64 bit integers are strings in proto json
+	require.Equal(t, "hash_mismatched", event["verification_result"]["outcome"])
+
+	w = serve(s, http.MethodGet, EventsPath+"?statuses=attested&sp_operator_address="+testSp2)
+	require.Equal(t, http.StatusOK, w.Code)
+	events := struct {
+		Events     []map[string]interface{} `json:"events"`
+		NextCursor string                   `json:"next_cursor"`
+	}{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+	require.Len(t, events.Events, 1)
+	require.Equal(t, "0", events.NextCursor)
+
+	w = serve(s, http.MethodGet, EventsPath+"?limit=-1")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	w = serve(s, http.MethodGet, EventsPath+"?statuses=bogus")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	w = serve(s, http.MethodGet, EventsPath+"/4")
+	require.Equal(t, http.StatusNotFound, w.Code)
+	w = serve(s, http.MethodPost, EventsPath+"/2")
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = serve(s, http.MethodGet, SpStatsPath)
+	require.Equal(t, http.StatusOK, w.Code)
+	stats := map[string][]map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	require.Equal(t, testSp1, stats["sp_stats"][0]["sp_operator_address"])
+
+	w = serve(s, http.MethodGet, SpStatsPath+"/"+testSp1)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = serve(s, http.MethodGet, SpStatsPath+"/sp9")
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestServer_Trends(t *testing.T) {
+	s, daoManager := newTestServer(t)
+	ctx := context.Background()
+	start := int64(480000 * 3600)
+	sp := func(hours int64, counters model.RollupCounters) *model.SpRollup {
+		return &model.SpRollup{WindowStart: start + hours*3600, SpOperatorAddress: testSp1, RollupCounters: counters}
+	}
+	require.NoError(t, daoManager.SaveRollups(ctx, start, start+3*3600, []*model.SpRollup{
+		sp(0, model.RollupCounters{Verified: 2, HashMatched: 1, HashMismatched: 1, TotalLatencyMs: 300, LatencySamples: 2,
+			MaxLatencyMs: 200}),
+		sp(1, model.RollupCounters{Verified: 2, HashMatched: 1, SpUnavailable: 1, TotalLatencyMs: 900, LatencySamples: 2,
+			MaxLatencyMs: 800}),
+		sp(2, model.RollupCounters{Verified: 1, Unverified: 1}),
+	}, []*model.BucketRollup{
+		{WindowStart: start, BucketName: "bucket1", RollupCounters: model.RollupCounters{Verified: 1, HashMatched: 1}},
+	}))
+
+	// the hourly rollups are merged into windows of 2 hours
+	w := serve(s, http.MethodGet, SpTrendsPath+"/"+testSp1+fmt.Sprintf("?from_time=%d&to_time=%d&window_hours=2",
+		start, start+4*3600))
+	require.Equal(t, http.StatusOK, w.Code)
+	trend := map[string][]map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &trend))
+	require.Len(t, trend["points"], 2)
+	require.Equal(t, "4", trend["points"][0]["verified"])
+	require.Equal(t, 0.5, trend["points"][0]["failure_rate"])
+	require.Equal(t, "300", trend["points"][0]["avg_response_latency_ms"])
+	require.Equal(t, "800", trend["points"][0]["max_response_latency_ms"])
+	require.Equal(t, "1", trend["points"][1]["unverified"])
+	require.Equal(t, 0.0, trend["points"][1]["failure_rate"])
+
+	bucketTrend, err := s.service.BucketTrend(ctx, &QueryBucketTrendRequest{BucketName: "bucket1", FromTime: start,
+		ToTime: start + 3600})
+	require.NoError(t, err)
+	require.Len(t, bucketTrend.Points, 1)
+	require.Equal(t, start, bucketTrend.Points[0].WindowStart)
+
+	w = serve(s, http.MethodGet, SpTrendsPath+"/"+testSp1+fmt.Sprintf("?from_time=%d&to_time=%d", start,
+		start+MaxTrendWindows*3600))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	w = serve(s, http.MethodGet, BucketTrendsPath+"/bucket1?window_hours=x")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
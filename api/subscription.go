@@ -0,0 +1,167 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+)
+
+// update is a transition kept by the hub, seq orders the transitions of a run of the challenger.
+type update struct {
+	seq    uint64
+	sp     string
+	status string
+	msg    *EventUpdate
+}
+
+// subscriber receives the updates matching its filter, it is dropped once it falls SubscriberBufferSize updates
+// behind, so that a slow client does not hold back the pipeline.
+type subscriber struct {
+	statuses map[string]bool
+	sp       string
+	updates  chan *EventUpdate
+	dropped  chan struct{}
+}
+
+func (s *subscriber) matches(u *update) bool {
+	return (len(s.statuses) == 0 || s.statuses[u.status]) && (s.sp == "" || s.sp == u.sp)
+}
+
+// Hub fans the status transitions of the events out to the subscribers of SubscribeEvents. It keeps the latest
+// HubBufferSize transitions, so that a client which reconnects resumes from its last update. The resume tokens are
+// bound to the run of the challenger, the transitions are not kept across restarts.
+type Hub struct {
+	mtx         sync.Mutex
+	epoch       int64 // start of the run, unix milliseconds
+	seq         uint64
+	buffer      []*update // ring of the latest transitions
+	subscribers map[*subscriber]struct{}
+	done        chan struct{} // closed on shutdown, the subscriptions never end by themselves
+	closeOnce   sync.Once
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		epoch:       time.Now().UnixMilli(),
+		buffer:      make([]*update, 0, HubBufferSize),
+		subscribers: make(map[*subscriber]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Close ends the subscriptions, so that the grpc server can stop gracefully.
+func (h *Hub) Close() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
+
+// Observe hands the transition of event over to the subscribers, it is a transition observer of the daos and never
+// blocks.
+func (h *Hub) Observe(previous, event *model.Event) {
+	msg := &EventUpdate{Event: newEvent(event), Timestamp: time.Now().UnixMilli()}
+	if previous != nil {
+		msg.FromStatus = previous.Status.String()
+	}
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.seq++
+	msg.ResumeToken = h.token(h.seq)
+	u := &update{seq: h.seq, sp: event.SpOperatorAddress, status: event.Status.String(), msg: msg}
+	if len(h.buffer) < HubBufferSize {
+		h.buffer = append(h.buffer, u)
+	} else {
+		h.buffer[(h.seq-1)%HubBufferSize] = u
+	}
+	for s := range h.subscribers {
+		if !s.matches(u) {
+			continue
+		}
+		select {
+		case s.updates <- msg:
+		default:
+			h.drop(s)
+		}
+	}
+}
+
+// subscribe registers a subscriber, queueing the kept updates after the resume token first.
+func (h *Hub) subscribe(statuses []string, sp, resumeToken string) (*subscriber, error) {
+	s := &subscriber{
+		statuses: make(map[string]bool, len(statuses)),
+		sp:       sp,
+		updates:  make(chan *EventUpdate, SubscriberBufferSize),
+		dropped:  make(chan struct{}),
+	}
+	for _, name := range statuses {
+		eventStatus, err := model.ParseEventStatus(name)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		s.statuses[eventStatus.String()] = true
+	}
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if len(h.subscribers) >= MaxSubscribers {
+		return nil, status.Errorf(codes.ResourceExhausted, "more than %d subscribers", MaxSubscribers)
+	}
+	if resumeToken != "" {
+		after, err := h.resume(resumeToken)
+		if err != nil {
+			return nil, err
+		}
+		for seq := after + 1; seq <= h.seq; seq++ {
+			u := h.buffer[(seq-1)%HubBufferSize]
+			if !s.matches(u) {
+				continue
+			}
+			if len(s.updates) == cap(s.updates) {
+				return nil, status.Errorf(codes.OutOfRange, "more than %d updates to resume, re-sync with Events",
+					SubscriberBufferSize)
+			}
+			s.updates <- u.msg
+		}
+	}
+	h.subscribers[s] = struct{}{}
+	return s, nil
+}
+
+func (h *Hub) unsubscribe(s *subscriber) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	delete(h.subscribers, s)
+}
+
+// drop disconnects a subscriber which fell behind, it resumes from its last update once reconnected.
+func (h *Hub) drop(s *subscriber) {
+	delete(h.subscribers, s)
+	close(s.dropped)
+}
+
+func (h *Hub) token(seq uint64) string {
+	return fmt.Sprintf("%d-%d", h.epoch, seq)
+}
+
+// resume returns the sequence of the update of token, whose following updates must be kept.
+func (h *Hub) resume(token string) (uint64, error) {
+	var epoch int64
+	var seq uint64
+	if _, err := fmt.Sscanf(token, "%d-%d", &epoch, &seq); err != nil || fmt.Sprintf("%d-%d", epoch, seq) != token {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid resume token %q", token)
+	}
+	if epoch != h.epoch {
+		return 0, status.Error(codes.OutOfRange, "the resume token is from another run of the challenger, re-sync "+
+			"with Events")
+	}
+	if seq > h.seq {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid resume token %q", token)
+	}
+	if h.seq-seq > uint64(len(h.buffer)) {
+		return 0, status.Error(codes.OutOfRange, "the updates after the resume token are not kept anymore, re-sync "+
+			"with Events")
+	}
+	return seq, nil
+}
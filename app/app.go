@@ -1,130 +1,610 @@
 package app
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"github.com/bnb-chain/greenfield-challenger/admin"
+	"github.com/bnb-chain/greenfield-challenger/alert"
+	"github.com/bnb-chain/greenfield-challenger/analytics"
+	"github.com/bnb-chain/greenfield-challenger/api"
 	"github.com/bnb-chain/greenfield-challenger/attest"
+	"github.com/bnb-chain/greenfield-challenger/audit"
+	"github.com/bnb-chain/greenfield-challenger/compat"
 	"github.com/bnb-chain/greenfield-challenger/config"
 	"github.com/bnb-chain/greenfield-challenger/db/dao"
 	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/debug"
+	"github.com/bnb-chain/greenfield-challenger/economics"
 	"github.com/bnb-chain/greenfield-challenger/executor"
+	"github.com/bnb-chain/greenfield-challenger/federation"
+	"github.com/bnb-chain/greenfield-challenger/ha"
+	"github.com/bnb-chain/greenfield-challenger/health"
+	"github.com/bnb-chain/greenfield-challenger/indexer"
+	"github.com/bnb-chain/greenfield-challenger/logging"
 	"github.com/bnb-chain/greenfield-challenger/metrics"
 	"github.com/bnb-chain/greenfield-challenger/monitor"
+	"github.com/bnb-chain/greenfield-challenger/plugin"
+	"github.com/bnb-chain/greenfield-challenger/proactive"
+	"github.com/bnb-chain/greenfield-challenger/relay"
+	"github.com/bnb-chain/greenfield-challenger/reputation"
+	"github.com/bnb-chain/greenfield-challenger/scanner"
+	"github.com/bnb-chain/greenfield-challenger/sla"
+	"github.com/bnb-chain/greenfield-challenger/statuspage"
+	"github.com/bnb-chain/greenfield-challenger/stream"
 	"github.com/bnb-chain/greenfield-challenger/submitter"
+	"github.com/bnb-chain/greenfield-challenger/supervisor"
 	"github.com/bnb-chain/greenfield-challenger/verifier"
 	"github.com/bnb-chain/greenfield-challenger/vote"
+	"github.com/bnb-chain/greenfield-challenger/voteproxy"
+	"github.com/bnb-chain/greenfield-challenger/webhook"
 	"github.com/bnb-chain/greenfield-challenger/wiper"
 	"github.com/spf13/viper"
 )
 
 type App struct {
 	executor        *executor.Executor
+	identities      []*executor.Executor // executors of the other identities, voting and attesting with their keys
+	compatGuard     *compat.Guard
 	eventMonitor    *monitor.Monitor
 	hashVerifier    *verifier.Verifier
+	plugins         []*plugin.Client // the verifier plugins, closed on shutdown
+	streamer        *stream.Streamer // nil if the transitions are not streamed
 	voteCollector   *vote.VoteCollector
 	voteBroadcaster *vote.VoteBroadcaster
 	voteCollator    *vote.VoteCollator
 	txSubmitter     *submitter.TxSubmitter
 	attestMonitor   *attest.AttestMonitor
 	metricService   *metrics.MetricService
+	statsdExporter  *metrics.StatsdExporter // nil unless the metrics are pushed to statsd
+	pipelineCfg     *config.PipelineConfig
 	dbWiper         *wiper.DBWiper
+	dbProber        *dao.DBProber
+	alertWatcher    *alert.Watcher
+	slaReporter     *sla.Reporter
+	scorer          *reputation.Scorer
+	challenger      *proactive.Challenger // nil unless the proactive challenges, the sampling or the scanner are enabled
+	sampler         *proactive.Sampler    // nil unless the sampling is enabled
+	intake          *proactive.Intake     // nil unless the scanner is enabled
+	scannerServer   *scanner.Server       // nil unless the scanner is enabled
+	voteProxy       *voteproxy.Server     // nil unless the votes of other validators are relayed
+	adminServer     *admin.Server
+	queryServer     *api.Server
+	statusServer    *statuspage.Server
+	resultServer    *federation.Server  // nil unless the verify results are served to the trusted challengers
+	relayTracker    *relay.Tracker      // nil unless the relay of the attestations is followed
+	reconciler      *indexer.Reconciler // nil unless the events are cross-checked with an indexer
+	ledger          *economics.Tracker
+	roller          *analytics.Roller
+	elector         *ha.Elector
+	shardAssigner   *ha.ShardAssigner
+	voteSigner      *vote.VoteSigner
+	identitySigners []*vote.VoteSigner // signers of the identities, in the same order
+	daoManager      *dao.DaoManager
+	db              *gorm.DB
+	shutdownTimeout time.Duration
+	supervisor      *supervisor.Supervisor
+	// stages are stopped in order on shutdown, so that each stage drains what the previous one handed over
+	monitorStage    *supervisor.Group
+	processStage    *supervisor.Group
+	attestStage     *supervisor.Group
+	backgroundStage *supervisor.Group
 }
 
 func NewApp(cfg *config.Config) *App {
-	username := cfg.DBConfig.Username
-	password := viper.GetString(config.FlagConfigDbPass)
-	if password == "" {
-		password = getDBPass(&cfg.DBConfig)
-	}
-
-	dbPath := fmt.Sprintf("%s:%s@%s", username, password, cfg.DBConfig.DBPath)
-
-	db, err := gorm.Open(mysql.Open(dbPath), &gorm.Config{})
-
-	// only for debug purpose
-	//db = db.Debug()
-
-	if err != nil {
-		panic(fmt.Sprintf("open db error, err=%+v", err.Error()))
-	}
-
-	dbConfig, err := db.DB()
-	if err != nil {
+	db := openDB(cfg)
+	alert.SetAlertConfig(&cfg.AlertConfig)
+	if err := audit.Init(&cfg.AuditConfig); err != nil {
 		panic(err)
 	}
-	dbConfig.SetMaxIdleConns(cfg.DBConfig.MaxIdleConns)
-	dbConfig.SetMaxOpenConns(cfg.DBConfig.MaxOpenConns)
-
-	// For clearing database during debugging
-	//if cfg.DBConfig.DebugMode {
-	//	err = ResetDB(db, &model.Block{}, &model.Event{}, &model.Vote{})
-	//	if err != nil {
-	//		logging.Logger.Errorf("reset db error, err=%+v", err.Error())
-	//	}
-	//}
-
-	model.InitBlockTable(db)
-	model.InitEventTable(db)
-	model.InitVoteTable(db)
+	webhook.Init(&cfg.WebhookConfig)
+	applyTunables(&cfg.TunableConfig)
 
 	blockDao := dao.NewBlockDao(db)
 	eventDao := dao.NewEventDao(db)
 	voteDao := dao.NewVoteDao(db)
-	daoManager := dao.NewDaoManager(blockDao, eventDao, voteDao)
-
-	executor := executor.NewExecutor(cfg)
+	spStatsDao := dao.NewSpStatsDao(db)
+	verificationResultDao := dao.NewVerificationResultDao(db)
+	samplingFailureDao := dao.NewSamplingFailureDao(db)
+	leaseDao := dao.NewLeaseDao(db)
+	ledgerDao := dao.NewLedgerDao(db)
+	rollupDao := dao.NewRollupDao(db)
+	jobDao := dao.NewJobDao(db)
+	daoManager := dao.NewDaoManager(blockDao, eventDao, voteDao, spStatsDao, verificationResultDao, samplingFailureDao,
+		leaseDao, ledgerDao, rollupDao, jobDao)
+	daoManager.SetQueryTimeout(queryTimeout(cfg))
+	dbProber := dao.NewDBProber(db, cfg.DBConfig.MaxIdleConns, !cfg.DBConfig.IsInMemory())
 
 	metricService := metrics.NewMetricService(cfg)
+	if sqlDB, err := db.DB(); err == nil {
+		metricService.RegisterDB(sqlDB)
+	}
+	daoManager.SetStageObserver(metricService.ObserveStageDuration)
+	transitionObservers := make([]dao.TransitionObserver, 0)
+	var streamer *stream.Streamer
+	if cfg.StreamConfig.Enabled() {
+		publisher, err := stream.NewPublisher(&cfg.StreamConfig)
+		if err != nil {
+			panic(err)
+		}
+		streamer = stream.NewStreamer(publisher, cfg.StreamConfig.Serialization)
+		transitionObservers = append(transitionObservers, streamer.Observe)
+	}
+	var statsdExporter *metrics.StatsdExporter
+	if cfg.MetricsConfig.StatsdEnabled() {
+		var err error
+		if statsdExporter, err = metrics.NewStatsdExporter(&cfg.MetricsConfig, metricService.Gatherer()); err != nil {
+			panic(fmt.Sprintf("statsd exporter error, err=%+v", err.Error()))
+		}
+	}
+
+	identities := make([]*executor.Executor, 0, len(cfg.GreenfieldConfig.Identities))
+	for i := range cfg.GreenfieldConfig.Identities {
+		identities = append(identities, executor.NewIdentityExecutor(cfg, &cfg.GreenfieldConfig.Identities[i], metricService))
+	}
+	executor := executor.NewExecutor(cfg, metricService)
+	compatGuard := compat.NewGuard(&cfg.CompatConfig, compat.DefaultMatrix, executor, metricService)
+	// checked on startup, nothing is voted nor attested until the chain is found supported
+	_ = compatGuard.Check()
+	executor.SetGuard(compatGuard)
+	for _, identity := range identities {
+		identity.SetGuard(compatGuard)
+	}
 
 	monitorDataHandler := monitor.NewDataHandler(daoManager)
-	monitor := monitor.NewMonitor(executor, monitorDataHandler, metricService)
+	monitor := monitor.NewMonitor(executor, monitorDataHandler, metricService, cfg.GreenfieldConfig.StartHeight,
+		cfg.PipelineConfig.Queue())
+	ledger := economics.NewTracker(&cfg.EconomicsConfig, cfg.GreenfieldConfig.FeeDenom, executor, daoManager,
+		metricService)
+	monitor.SetLedgerRecorder(ledger)
+	roller := analytics.NewRoller(&cfg.AnalyticsConfig, daoManager)
+	var relayTracker *relay.Tracker
+	if cfg.RelayConfig.Enabled() {
+		bscClient, err := relay.NewBscClient(&cfg.RelayConfig)
+		if err != nil {
+			panic(fmt.Sprintf("bsc client error, err=%+v", err.Error()))
+		}
+		relayTracker = relay.NewTracker(&cfg.RelayConfig, bscClient, metricService)
+		monitor.SetRelayTracker(relayTracker)
+	}
+	var reconciler *indexer.Reconciler
+	if cfg.IndexerConfig.Enabled() {
+		indexerClient := indexer.NewClient(&cfg.IndexerConfig)
+		reconciler = indexer.NewReconciler(&cfg.IndexerConfig, indexerClient, daoManager, metricService)
+		if cfg.IndexerConfig.Fallback {
+			monitor.SetEventSource(indexer.NewEventSource(indexerClient))
+		}
+	}
 
 	verifierDataHandler := verifier.NewDataHandler(daoManager)
 	hashVerifier := verifier.NewHashVerifier(cfg, executor, verifierDataHandler, metricService)
+	scorer := reputation.NewScorer(&cfg.ReputationConfig, daoManager, metricService)
+	hashVerifier.SetRetryBudget(scorer)
+	if cfg.JobQueueConfig.Enabled {
+		hashVerifier.SetJobQueue(daoManager, cfg.HaConfig.Instance())
+	}
+	plugins := make([]*plugin.Client, 0, len(cfg.PluginConfig.VerifierAddrs))
+	for _, addr := range cfg.PluginConfig.VerifierAddrs {
+		client, err := plugin.Dial(addr, cfg.PluginConfig.Timeout())
+		if err != nil {
+			panic(fmt.Sprintf("verifier plugin %s error, err=%+v", addr, err.Error()))
+		}
+		hashVerifier.AddPlugin(client)
+		plugins = append(plugins, client)
+	}
 
-	signer := vote.NewVoteSigner(executor.BlsPrivKey)
+	signer := vote.NewVoteSigner(executor.GetBlsPrivKey())
+	if cfg.FederationConfig.Enabled() {
+		hashVerifier.SetFederation(federation.NewClient(&cfg.FederationConfig, cfg.GreenfieldConfig.ChainIdString,
+			metricService))
+	}
+	var federationServer *federation.Server
+	if cfg.FederationConfig.Port != 0 {
+		federationServer = federation.NewServer(&cfg.FederationConfig, cfg.GreenfieldConfig.ChainIdString, daoManager,
+			signer)
+	}
 	voteDataHandler := vote.NewDataHandler(daoManager, executor)
+	var shardAssigner *ha.ShardAssigner
+	if cfg.ShardConfig.Enabled() {
+		shardAssigner = ha.NewShardAssigner(&cfg.ShardConfig, &cfg.HaConfig, daoManager, metricService)
+		verifierDataHandler.SetSharder(shardAssigner)
+		voteDataHandler.SetSharder(shardAssigner)
+	}
 	voteCollector := vote.NewVoteCollector(cfg, executor, voteDataHandler, metricService)
 	voteBroadcaster := vote.NewVoteBroadcaster(cfg, signer, executor, voteDataHandler, metricService)
+	identitySigners := make([]*vote.VoteSigner, 0, len(identities))
+	for _, identity := range identities {
+		identitySigner := vote.NewVoteSigner(identity.GetBlsPrivKey())
+		voteBroadcaster.AddSigner(identitySigner)
+		identitySigners = append(identitySigners, identitySigner)
+	}
 	voteCollator := vote.NewVoteCollator(cfg, signer, executor, voteDataHandler, metricService)
 
 	txDataHandler := submitter.NewDataHandler(daoManager, executor)
 	txSubmitter := submitter.NewTxSubmitter(cfg, executor, txDataHandler, metricService)
+	for _, identity := range identities {
+		txSubmitter.AddIdentity(identity)
+	}
 
 	attestDataHandler := attest.NewDataHandler(daoManager)
 	attestMonitor := attest.NewAttestMonitor(executor, attestDataHandler, metricService)
+	voteCollator.SetAttestMarker(attestMonitor)
 
 	dbWiper := wiper.NewDBWiper(daoManager)
+	alertWatcher := alert.NewWatcher(executor, executor, daoManager, daoManager)
+	slaReporter := sla.NewReporter(&cfg.SlaConfig, daoManager, metricService)
+	var challenger *proactive.Challenger
+	if cfg.ProactiveConfig.Enabled() || cfg.SamplingConfig.Enabled() || cfg.ScannerConfig.Enabled() {
+		challenger = proactive.NewChallenger(&cfg.ProactiveConfig, executor, daoManager, scorer, metricService)
+	}
+	var sampler *proactive.Sampler
+	if cfg.SamplingConfig.Enabled() {
+		sampler = proactive.NewSampler(&cfg.SamplingConfig, cfg.ProactiveConfig.SpOperatorAddresses, executor, daoManager,
+			scorer, metricService)
+	}
+	var intake *proactive.Intake
+	var scannerServer *scanner.Server
+	if cfg.ScannerConfig.Enabled() {
+		intake = proactive.NewIntake(cfg.ScannerConfig.Queue(), cfg.ProactiveConfig.SpOperatorAddresses, executor,
+			daoManager, scorer, metricService)
+		scannerServer = scanner.NewServer(&cfg.ScannerConfig, intake)
+	}
+	var voteProxy *voteproxy.Server
+	if cfg.VoteProxyConfig.Enabled() {
+		voteProxy = voteproxy.NewServer(&cfg.VoteProxyConfig, executor, metricService)
+	}
+
+	var elector *ha.Elector
+	if cfg.HaConfig.Enabled {
+		elector = ha.NewElector(&cfg.HaConfig, daoManager, metricService)
+	}
+
+	var queryServer *api.Server
+	if cfg.QueryApiConfig.Enabled() {
+		queryService := api.NewService(cfg.GreenfieldConfig.ChainIdString, daoManager)
+		if cfg.QueryApiConfig.GrpcPort != 0 {
+			hub := api.NewHub()
+			queryService.SetHub(hub)
+			transitionObservers = append(transitionObservers, hub.Observe)
+		}
+		queryServer = api.NewServer(&cfg.QueryApiConfig, queryService)
+	}
+	if len(transitionObservers) != 0 {
+		daoManager.SetTransitionObserver(func(previous, event *model.Event) {
+			for _, observe := range transitionObservers {
+				observe(previous, event)
+			}
+		})
+	}
+	var statusServer *statuspage.Server
+	if cfg.StatusPageConfig.Enabled() {
+		statusServer = statuspage.NewServer(&cfg.StatusPageConfig, daoManager, executor)
+	}
+
+	adminServer := admin.NewServer(&cfg.AdminConfig, cfg.GreenfieldConfig.ChainIdString, admin.NewDataHandler(daoManager),
+		attestMonitor, monitor, scorer, []admin.Forgetter{hashVerifier, voteBroadcaster, txSubmitter},
+		map[string]admin.CacheDumper{
+			admin.CacheValidators:  executor,
+			admin.CacheVerifier:    hashVerifier,
+			admin.CacheBroadcaster: voteBroadcaster,
+			admin.CacheSubmitter:   txSubmitter,
+		})
+	adminServer.SetEconomicsReporter(ledger)
+
+	checker := health.NewChecker(executor, daoManager, dbProber, watchedLoops(&cfg.PipelineConfig))
+	metricService.Handle(health.LivenessPath, checker.LivenessHandler())
+	metricService.Handle(health.ReadinessPath, checker.ReadinessHandler())
+	statusReporter := health.NewStatusReporter(executor, daoManager, daoManager, executor, executor)
+	metricService.Handle(health.StatusPath, statusReporter.StatusHandler())
+	metricService.Handle(health.VersionPath, health.VersionHandler())
+	debug.Register(metricService, &cfg.DebugConfig)
+
+	loopSupervisor := supervisor.NewSupervisor(metricService)
 
 	return &App{
 		executor:        executor,
+		identities:      identities,
 		eventMonitor:    monitor,
 		hashVerifier:    hashVerifier,
+		plugins:         plugins,
+		streamer:        streamer,
 		voteCollector:   voteCollector,
 		voteBroadcaster: voteBroadcaster,
 		voteCollator:    voteCollator,
 		attestMonitor:   attestMonitor,
 		txSubmitter:     txSubmitter,
 		metricService:   metricService,
+		statsdExporter:  statsdExporter,
 		dbWiper:         dbWiper,
+		dbProber:        dbProber,
+		alertWatcher:    alertWatcher,
+		slaReporter:     slaReporter,
+		scorer:          scorer,
+		challenger:      challenger,
+		sampler:         sampler,
+		intake:          intake,
+		scannerServer:   scannerServer,
+		voteProxy:       voteProxy,
+		adminServer:     adminServer,
+		queryServer:     queryServer,
+		statusServer:    statusServer,
+		resultServer:    federationServer,
+		elector:         elector,
+		shardAssigner:   shardAssigner,
+		voteSigner:      signer,
+		identitySigners: identitySigners,
+		compatGuard:     compatGuard,
+		relayTracker:    relayTracker,
+		reconciler:      reconciler,
+		ledger:          ledger,
+		roller:          roller,
+		daoManager:      daoManager,
+		db:              db,
+		shutdownTimeout: time.Duration(cfg.PipelineConfig.ShutdownTimeoutInSeconds) * time.Second,
+		pipelineCfg:     &cfg.PipelineConfig,
+		supervisor:      loopSupervisor,
+		monitorStage:    loopSupervisor.NewGroup("monitor"),
+		processStage:    loopSupervisor.NewGroup("verifier and vote"),
+		attestStage:     loopSupervisor.NewGroup("attest"),
+		backgroundStage: loopSupervisor.NewGroup("background"),
 	}
 }
 
+// Start runs all loops of the challenger under the supervisor, they run until Shutdown. The pipeline loops are
+// critical, the challenger stops if one of them keeps crashing.
 func (a *App) Start() {
-	go a.executor.UpdateHeartbeatIntervalLoop()
-	go a.executor.CacheValidatorsLoop()
-	go a.executor.GetHeightLoop()
-	go a.eventMonitor.ListenEventLoop()
-	go a.hashVerifier.VerifyHashLoop()
-	go a.voteCollector.CollectVotesLoop()
-	go a.voteBroadcaster.BroadcastVotesLoop()
-	go a.voteCollator.CollateVotesLoop()
-	go a.attestMonitor.UpdateAttestedChallengeIdLoop()
-	go a.metricService.Start()
-	a.txSubmitter.SubmitTransactionLoop()
+	a.backgroundStage.Go(LoopDBProber, false, a.dbProber.ProbeLoop)
+	a.backgroundStage.Go(LoopHeartbeatPeriod, false, a.executor.UpdateHeartbeatIntervalLoop)
+	a.backgroundStage.Go(LoopCompat, false, a.compatGuard.CheckLoop)
+	a.backgroundStage.Go(LoopValidators, false, a.executor.CacheValidatorsLoop)
+	a.backgroundStage.Go(health.LoopHeight, true, a.executor.GetHeightLoop)
+	a.backgroundStage.Go(LoopKeys, false, func(ctx context.Context) { a.executor.RefreshKeysLoop(ctx, a.voteSigner.SetKey) })
+	for i, identity := range a.identities {
+		identity, signer := identity, a.identitySigners[i]
+		a.backgroundStage.Go(fmt.Sprintf("%s_%s", LoopKeys, identity.GetAddr()), false, func(ctx context.Context) {
+			identity.RefreshKeysLoop(ctx, signer.SetKey)
+		})
+	}
+	a.backgroundStage.Go(LoopAlertWatcher, false, a.alertWatcher.WatchLoop)
+	a.backgroundStage.Go(LoopSlaReport, false, a.slaReporter.ReportLoop)
+	a.backgroundStage.Go(LoopReputation, false, a.scorer.ScoreLoop)
+	if a.elector != nil {
+		a.backgroundStage.Go(LoopHaElection, false, a.elector.ElectLoop)
+	}
+	if a.shardAssigner != nil {
+		a.backgroundStage.Go(LoopShardAssignment, false, a.shardAssigner.AssignLoop)
+	}
+	if a.challenger != nil {
+		a.backgroundStage.Go(LoopProactive, false, a.leaderOnly(LoopProactive, a.challenger.ChallengeLoop))
+	}
+	if a.sampler != nil {
+		a.backgroundStage.Go(LoopSampling, false, a.leaderOnly(LoopSampling, a.sampler.SampleLoop))
+	}
+	if a.scannerServer != nil {
+		// every challenger takes and verifies the suspects it is sent, the leader challenges the failures
+		a.backgroundStage.Go(LoopScanner, false, a.scannerServer.Start)
+		a.backgroundStage.Go(LoopScannerIntake, false, a.intake.IntakeLoop)
+	}
+	if a.voteProxy != nil {
+		a.backgroundStage.Go(LoopVoteProxy, false, a.voteProxy.Start)
+	}
+	a.backgroundStage.Go(LoopAdminServer, false, a.adminServer.Start)
+	if a.queryServer != nil {
+		a.backgroundStage.Go(LoopQueryApi, false, a.queryServer.Start)
+	}
+	if a.statusServer != nil {
+		a.backgroundStage.Go(LoopStatusPage, false, a.statusServer.Start)
+	}
+	if a.resultServer != nil {
+		a.backgroundStage.Go(LoopFederation, false, a.resultServer.Start)
+	}
+	if a.relayTracker != nil {
+		a.backgroundStage.Go(LoopRelay, false, a.relayTracker.CheckLoop)
+	}
+	if a.reconciler != nil {
+		a.backgroundStage.Go(LoopIndexer, false, a.leaderOnly(LoopIndexer, a.reconciler.ReconcileLoop))
+	}
+	a.backgroundStage.Go(LoopEconomics, false, a.ledger.ReportLoop)
+	a.backgroundStage.Go(LoopAnalytics, false, a.leaderOnly(LoopAnalytics, a.roller.RollupLoop))
+	a.backgroundStage.Go(LoopMetricsServer, false, a.metricService.Start)
+	if a.statsdExporter != nil {
+		a.backgroundStage.Go(LoopStatsd, false, a.statsdExporter.ExportLoop)
+	}
+	a.backgroundStage.Go(LoopEventStats, false, func(ctx context.Context) { a.metricService.EventStatsLoop(ctx, a.daoManager) })
+	a.backgroundStage.Go(LoopHeartbeatMetrics, false, a.supervisor.HeartbeatLoop)
+	if a.pipelineCfg.DryRun {
+		logging.Logger.Warningf("dry run, the votes are not broadcast and the attest transactions are not sent")
+	}
+	if len(a.pipelineCfg.Components) != 0 {
+		logging.Logger.Infof("running the components %s", strings.Join(a.pipelineCfg.Components, ", "))
+	}
+	if a.pipelineCfg.ComponentEnabled(config.ComponentMonitor) {
+		a.monitorStage.Go(health.LoopMonitor, true, a.leaderOnly(health.LoopMonitor, a.eventMonitor.ListenEventLoop))
+		a.monitorStage.Go(LoopExpireEvents, false, a.leaderOnly(LoopExpireEvents, a.eventMonitor.ExpireEventsLoop))
+	}
+	if a.pipelineCfg.ComponentEnabled(config.ComponentVerifier) {
+		verifyLoop := a.sharded(health.LoopVerifier, a.hashVerifier.VerifyHashLoop)
+		if a.hashVerifier.Queued() {
+			// the verifications are leased from the job queue, so that every challenger takes a share of them
+			verifyLoop = a.hashVerifier.VerifyHashLoop
+		}
+		a.processStage.Go(health.LoopVerifier, true, verifyLoop)
+	}
+	if a.pipelineCfg.ComponentEnabled(config.ComponentVote) {
+		a.processStage.Go(health.LoopCollector, true, a.leaderOnly(health.LoopCollector, a.voteCollector.CollectVotesLoop))
+		a.processStage.Go(health.LoopBroadcaster, true,
+			a.sharded(health.LoopBroadcaster, a.voteBroadcaster.BroadcastVotesLoop))
+		a.processStage.Go(health.LoopCollator, true, a.sharded(health.LoopCollator, a.voteCollator.CollateVotesLoop))
+	}
+	if a.pipelineCfg.ComponentEnabled(config.ComponentAttest) {
+		a.attestStage.Go(health.LoopSubmitter, true, a.leaderOnly(health.LoopSubmitter, a.txSubmitter.SubmitTransactionLoop))
+		a.attestStage.Go(health.LoopAttest, true,
+			a.leaderOnly(health.LoopAttest, a.attestMonitor.UpdateAttestedChallengeIdLoop))
+	}
+}
+
+// leaderOnly runs loop only while the challenger is the leader in ha mode, the standbys run the loops serving the
+// metrics and the apis.
+func (a *App) leaderOnly(name string, loop func(ctx context.Context)) func(ctx context.Context) {
+	if a.elector == nil {
+		return loop
+	}
+	return a.elector.LeaderOnly(name, loop)
+}
+
+// sharded runs loop on every challenger when the events are sharded, it processes the events of the held shards
+// only. It runs on the leader only otherwise.
+func (a *App) sharded(name string, loop func(ctx context.Context)) func(ctx context.Context) {
+	if a.shardAssigner != nil {
+		return loop
+	}
+	return a.leaderOnly(name, loop)
+}
+
+// watchedLoops returns the loops watched by the liveness check, the ones of the disabled components never beat.
+func watchedLoops(cfg *config.PipelineConfig) []string {
+	loops := []string{health.LoopHeight}
+	for _, component := range config.Components {
+		if cfg.ComponentEnabled(component) {
+			loops = append(loops, componentLoops[component]...)
+		}
+	}
+	return loops
+}
+
+// Fatal receives the error of a critical loop which could not be revived, the challenger should shut down then.
+func (a *App) Fatal() <-chan error {
+	return a.supervisor.Fatal()
+}
+
+// DB returns the database of the challenger, the fault injection of the e2e tests hooks into its writes.
+func (a *App) DB() *gorm.DB {
+	return a.db
+}
+
+// SetKiller makes the supervisor crash the loops on the demand of killer, it must be set before Start.
+func (a *App) SetKiller(killer supervisor.Killer) {
+	a.supervisor.SetKiller(killer)
+}
+
+// Shutdown stops the monitor first so that no new challenges come in, then drains the verifier and vote loops and
+// waits for the attestations in flight. The database and rpc clients are closed last. The shutdown gives up waiting
+// after the configured timeout, the clients are closed anyway.
+func (a *App) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+	defer cancel()
+	var shutdownErr error
+	for _, s := range []*supervisor.Group{a.monitorStage, a.processStage, a.attestStage, a.backgroundStage} {
+		logging.Logger.Infof("stopping the %s stage", s.Name())
+		if err := s.Stop(ctx); err != nil {
+			logging.Logger.Errorf("shutdown error, err=%+v", err.Error())
+			shutdownErr = err
+		}
+	}
+	audit.Close()
+	webhook.Close()
+	if a.streamer != nil {
+		if err := a.streamer.Close(); err != nil {
+			logging.Logger.Errorf("failed to close event stream, err=%+v", err.Error())
+		}
+	}
+	a.executor.Close()
+	for _, identity := range a.identities {
+		identity.Close()
+	}
+	for _, p := range a.plugins {
+		if err := p.Close(); err != nil {
+			logging.Logger.Errorf("failed to close verifier plugin %s, err=%+v", p.Name(), err.Error())
+		}
+	}
+	if sqlDB, err := a.db.DB(); err == nil {
+		if err = sqlDB.Close(); err != nil {
+			logging.Logger.Errorf("failed to close database, err=%+v", err.Error())
+		}
+	}
+	return shutdownErr
+}
+
+// openDB connects to the configured database and applies the pending migrations to the challenger tables.
+func openDB(cfg *config.Config) *gorm.DB {
+	db := connectDB(cfg)
+	model.RunMigrations(db)
+	return db
+}
+
+// connectDB connects to the configured database without touching the schema.
+func connectDB(cfg *config.Config) *gorm.DB {
+	password := ""
+	if cfg.DBConfig.HasCredentials() {
+		password = viper.GetString(config.FlagConfigDbPass)
+		if password == "" {
+			password = getDBPass(&cfg.DBConfig)
+		}
+	}
+	return connectDBWithPassword(cfg, password)
+}
+
+// dialector returns the dialector of the configured database backend.
+func dialector(cfg *config.DBConfig, password string) gorm.Dialector {
+	switch cfg.Dialect {
+	case config.DBDialectSqlite3:
+		return sqlite.Open(cfg.DBPath)
+	case config.DBDialectPostgres:
+		return postgres.Open(fmt.Sprintf("postgres://%s@%s", url.UserPassword(cfg.Username, password).String(),
+			cfg.DBPath))
+	default:
+		return mysql.Open(fmt.Sprintf("%s:%s@%s", cfg.Username, password, cfg.DBPath))
+	}
+}
+
+// connectDBWithPassword connects to the configured database with password, the --db-pass flag is not applied.
+func connectDBWithPassword(cfg *config.Config, password string) *gorm.DB {
+	db, err := gorm.Open(dialector(&cfg.DBConfig, password), &gorm.Config{
+		Logger: dao.NewLogger(time.Duration(cfg.DBConfig.SlowQueryThresholdInMs)*time.Millisecond, cfg.DBConfig.DebugMode),
+	})
+	if err != nil {
+		panic(fmt.Sprintf("open db error, err=%+v", err.Error()))
+	}
+
+	dbConfig, err := db.DB()
+	if err != nil {
+		panic(err)
+	}
+	dbConfig.SetMaxIdleConns(cfg.DBConfig.MaxIdleConns)
+	dbConfig.SetMaxOpenConns(cfg.DBConfig.MaxOpenConns)
+	if cfg.DBConfig.IsInMemory() {
+		// every connection to an in-memory database sees its own empty database, so all queries share one connection
+		dbConfig.SetMaxIdleConns(1)
+		dbConfig.SetMaxOpenConns(1)
+		dbConfig.SetConnMaxLifetime(0)
+		dbConfig.SetConnMaxIdleTime(0)
+	}
+
+	// For clearing database during debugging
+	//if cfg.DBConfig.DebugMode {
+	//	err = ResetDB(db, &model.Block{}, &model.Event{}, &model.Vote{})
+	//	if err != nil {
+	//		logging.Logger.Errorf("reset db error, err=%+v", err.Error())
+	//	}
+	//}
+
+	model.TablePrefix = cfg.DBConfig.TablePrefix
+	dao.PrepareStatements = !cfg.DBConfig.DisablePreparedStatements
+	return db
+}
+
+// queryTimeout returns the timeout of the dao queries, a zero timeout leaves the dao default.
+func queryTimeout(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.DBConfig.QueryTimeout) * time.Second
 }
 
 func getDBPass(cfg *config.DBConfig) string {
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+
+	"github.com/bnb-chain/greenfield-challenger/budget"
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/executor"
+)
+
+// Budget projects the monthly fees and rewards of the submission strategies from the challenges saved in the last days
+// and the rewards of the challenges attested in the last sampleBlocks blocks. A transaction pays the configured fee
+// amount, or the gas limit at gasPrice wei if it is set. The database is only read.
+func Budget(ctx context.Context, cfg *config.Config, days int, sampleBlocks uint64, gasPrice string,
+) (*budget.Report, error) {
+	if days <= 0 {
+		return nil, errors.New("the days of the challenge volume should be positive")
+	}
+	// the fee amount is validated with the config
+	fee, _ := sdkmath.NewIntFromString(cfg.GreenfieldConfig.FeeAmount)
+	if gasPrice != "" {
+		price, ok := sdkmath.NewIntFromString(gasPrice)
+		if !ok {
+			return nil, fmt.Errorf("invalid gas price %q", gasPrice)
+		}
+		fee = price.Mul(sdkmath.NewIntFromUint64(cfg.GreenfieldConfig.GasLimit))
+	}
+	applyTunables(&cfg.TunableConfig)
+	e := executor.NewExecutor(cfg, nil)
+	defer e.Close()
+	heartbeatInterval, err := e.QueryChallengeHeartbeatInterval()
+	if err != nil {
+		return nil, fmt.Errorf("query heartbeat interval error, err=%+v", err)
+	}
+	params, err := e.QueryChallengeParams()
+	if err != nil {
+		return nil, fmt.Errorf("query challenge params error, err=%+v", err)
+	}
+	validators, err := e.QueryCachedLatestValidators()
+	if err != nil {
+		return nil, fmt.Errorf("query validators error, err=%+v", err)
+	}
+
+	eventDao := dao.NewEventDao(connectDB(cfg))
+	eventDao.SetQueryTimeout(queryTimeout(cfg))
+	now := time.Now()
+	volume, err := eventDao.CountEventVolume(ctx, now.AddDate(0, 0, -days).Unix(), heartbeatInterval)
+	if err != nil {
+		return nil, fmt.Errorf("count events error, err=%+v", err)
+	}
+	if volume.Challenges == 0 {
+		return nil, fmt.Errorf("no challenges were saved in the last %d days", days)
+	}
+	rewards, err := budget.SampleRewards(ctx, e, sampleBlocks, params, len(validators))
+	if err != nil {
+		return nil, fmt.Errorf("sample rewards error, err=%+v", err)
+	}
+	feeWei, _ := new(big.Float).SetInt(fee.BigInt()).Float64()
+	inputs := budget.Inputs{
+		// a database younger than the days only covers the time since its first challenge
+		Volume: budget.Volume{
+			Period:     now.Sub(time.Unix(volume.FirstTime, 0)),
+			Challenges: volume.Challenges,
+			Heartbeats: volume.Heartbeats,
+			Mismatched: volume.Mismatched,
+		},
+		Fee:        feeWei,
+		Validators: len(validators),
+		Rewards:    rewards,
+	}
+	inputs.Proactive = budget.ProactiveVolume(&cfg.ProactiveConfig, inputs.Volume.MismatchRatio())
+	return budget.Simulate(inputs)
+}
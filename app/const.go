@@ -0,0 +1,56 @@
+package app
+
+import (
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/health"
+)
+
+// names of the supervised loops which do not report their liveness with health.Beat
+const (
+	LoopDBProber         = "db_prober"
+	LoopHeartbeatPeriod  = "heartbeat_interval"
+	LoopCompat           = "chain_compat"
+	LoopValidators       = "validators"
+	LoopKeys             = "keys"
+	LoopAlertWatcher     = "alert_watcher"
+	LoopAdminServer      = "admin_server"
+	LoopMetricsServer    = "metrics_server"
+	LoopStatsd           = "statsd"
+	LoopEventStats       = "event_stats"
+	LoopHeartbeatMetrics = "loop_heartbeats"
+	LoopExpireEvents     = "expire_events"
+	LoopSlaReport        = "sla_report"
+	LoopProactive        = "proactive_challenges"
+	LoopReputation       = "sp_reputation"
+	LoopSampling         = "availability_sampling"
+	LoopQueryApi         = "query_api"
+	LoopFederation       = "federation"
+	LoopRelay            = "cross_chain_relay"
+	LoopIndexer          = "indexer_cross_check"
+	LoopScanner          = "scanner_api"
+	LoopScannerIntake    = "scanner_intake"
+	LoopVoteProxy        = "vote_proxy"
+	LoopEconomics        = "economics_report"
+	LoopAnalytics        = "analytics_rollup"
+	LoopStatusPage       = "status_page"
+	LoopHaElection       = "ha_election"
+	LoopShardAssignment  = "shard_assignment"
+)
+
+// names of the keystores written by ExportKeys, prefixed by the name of the identity
+const (
+	DefaultKeystoreName   = "challenger"
+	KeystoreFileSuffix    = ".key"
+	BlsKeystoreFileSuffix = "_bls.key"
+)
+
+// RecoveryBundleVersion is the version of the recovery bundles written by ExportRecovery.
+const RecoveryBundleVersion = 1
+
+// componentLoops are the pipeline loops run by each component, which report their liveness with health.Beat.
+var componentLoops = map[string][]string{
+	config.ComponentMonitor:  {health.LoopMonitor},
+	config.ComponentVerifier: {health.LoopVerifier},
+	config.ComponentVote:     {health.LoopCollector, health.LoopBroadcaster, health.LoopCollator},
+	config.ComponentAttest:   {health.LoopSubmitter, health.LoopAttest},
+}
@@ -0,0 +1,168 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/eth/bls"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/eth/ethsecp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/executor"
+)
+
+// GenerateKeys generates the keys of kind for the challenger, or for its identity named identity, and stores them
+// according to the key type of the config. It refuses to overwrite stored keys, RotateKeys replaces them. The public
+// parts of the keys and the registration of validatorAddress, if it is set, are written to w.
+func GenerateKeys(cfg *config.Config, identity, kind, validatorAddress string, w io.Writer) error {
+	keysCfg, err := identityKeysConfig(cfg, identity)
+	if err != nil {
+		return err
+	}
+	exist, err := executor.KeysExist(keysCfg, kind)
+	if err != nil {
+		return err
+	}
+	if exist {
+		return fmt.Errorf("the %s keys are already stored, rotate them instead", kind)
+	}
+	keys, err := executor.GenerateKeyPair(kind)
+	if err != nil {
+		return err
+	}
+	if err = executor.StoreKeyPair(keysCfg, keys); err != nil {
+		return err
+	}
+	return writeRegistration(w, keys, validatorAddress)
+}
+
+// RotateKeys replaces the stored keys of kind of the challenger, or of its identity named identity, by new keys. The
+// challengers reading the keys from a secret manager pick the new keys up within key_refresh_interval_in_seconds, the
+// ones reading keystores once restarted. The new keys must be registered on chain by the validator operator, with the
+// registration written to w, before the challengers vote with them.
+func RotateKeys(cfg *config.Config, identity, kind, validatorAddress string, w io.Writer) error {
+	keysCfg, err := identityKeysConfig(cfg, identity)
+	if err != nil {
+		return err
+	}
+	previous, err := executor.LoadKeyPair(keysCfg, kind)
+	if err != nil {
+		return fmt.Errorf("load the keys to rotate error, err=%+v", err)
+	}
+	previousKeys, err := previous.Public()
+	if err != nil {
+		return err
+	}
+	keys, err := executor.GenerateKeyPair(kind)
+	if err != nil {
+		return err
+	}
+	if err = executor.StoreKeyPair(keysCfg, keys); err != nil {
+		return err
+	}
+	if previousKeys.Address != "" {
+		// the balance of the previous address is not moved
+		fmt.Fprintf(w, "previous address:        %s\n", previousKeys.Address)
+	}
+	if previousKeys.BlsPubKey != "" {
+		fmt.Fprintf(w, "previous bls public key: %s\n", previousKeys.BlsPubKey)
+	}
+	return writeRegistration(w, keys, validatorAddress)
+}
+
+// ExportKeys writes the stored keys of kind of the challenger, or of its identity named identity, to outputDir as
+// keystores encrypted with the keystore passphrase, e.g. to move them to another key type. The written files are
+// listed to w, existing files are not overwritten.
+func ExportKeys(cfg *config.Config, identity, kind, outputDir string, w io.Writer) error {
+	keysCfg, err := identityKeysConfig(cfg, identity)
+	if err != nil {
+		return err
+	}
+	keys, err := executor.LoadKeyPair(keysCfg, kind)
+	if err != nil {
+		return err
+	}
+	if _, err = keys.Public(); err != nil {
+		return err
+	}
+	passphrase, err := config.KeystorePassphrase()
+	if err != nil {
+		return err
+	}
+	name := identity
+	if name == "" {
+		name = DefaultKeystoreName
+	}
+	var files []string
+	var privKeys []cryptotypes.PrivKey
+	if keys.PrivateKey != "" {
+		files = append(files, filepath.Join(outputDir, name+KeystoreFileSuffix))
+		privKeys = append(privKeys, &ethsecp256k1.PrivKey{Key: ethcommon.Hex2Bytes(keys.PrivateKey)})
+	}
+	if keys.BlsPrivateKey != "" {
+		files = append(files, filepath.Join(outputDir, name+BlsKeystoreFileSuffix))
+		privKeys = append(privKeys, &bls.PrivKey{Key: ethcommon.Hex2Bytes(keys.BlsPrivateKey)})
+	}
+	for _, file := range files {
+		if _, err = os.Stat(file); err == nil {
+			return fmt.Errorf("%s already exists", file)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	for i, file := range files {
+		if err = config.WriteKeystoreKey(file, passphrase, privKeys[i]); err != nil {
+			return fmt.Errorf("write keystore %s error, err=%+v", file, err)
+		}
+		fmt.Fprintf(w, "exported %s\n", file)
+	}
+	return nil
+}
+
+// identityKeysConfig returns the greenfield config holding the keys of the identity named identity, or of the
+// challenger itself if identity is empty.
+func identityKeysConfig(cfg *config.Config, identity string) (*config.GreenfieldConfig, error) {
+	if identity == "" {
+		return &cfg.GreenfieldConfig, nil
+	}
+	for i := range cfg.GreenfieldConfig.Identities {
+		if cfg.GreenfieldConfig.Identities[i].Name == identity {
+			return cfg.GreenfieldConfig.ForIdentity(&cfg.GreenfieldConfig.Identities[i]), nil
+		}
+	}
+	return nil, fmt.Errorf("identity %q is not configured", identity)
+}
+
+// writeRegistration writes the public parts of the new keys, and the unsigned transaction registering them as the
+// challenger keys of validatorAddress if it is set.
+func writeRegistration(w io.Writer, keys *executor.KeyPair, validatorAddress string) error {
+	public, err := keys.Public()
+	if err != nil {
+		return err
+	}
+	if public.Address != "" {
+		fmt.Fprintf(w, "address:                 %s\n", public.Address)
+	}
+	if public.BlsPubKey != "" {
+		blsProof, err := keys.BlsProof()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "bls public key:          %s\nbls proof:               %s\n", public.BlsPubKey, blsProof)
+	}
+	if validatorAddress == "" {
+		fmt.Fprintln(w, "set the validator address to get the registration transaction of the keys")
+		return nil
+	}
+	registration, err := keys.RegistrationTx(validatorAddress)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "registration transaction, to be signed by the validator operator:\n%s\n", registration)
+	return nil
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+// PlanMigrations writes the migrations pending on the configured database to w without applying them, so that
+// operators can review the schema changes before upgrading.
+func PlanMigrations(cfg *config.Config, w io.Writer) {
+	db := connectDB(cfg)
+	migrations := model.PlanMigrations(db)
+	if len(migrations) == 0 {
+		fmt.Fprintln(w, "database schema is up to date")
+		return
+	}
+
+	destructive := 0
+	fmt.Fprintf(w, "%d pending migrations:\n", len(migrations))
+	for i, m := range migrations {
+		if m.Destructive {
+			destructive++
+			fmt.Fprintf(w, "%3d. [DESTRUCTIVE] %s\n", i+1, m.Description)
+			continue
+		}
+		fmt.Fprintf(w, "%3d. %s\n", i+1, m.Description)
+	}
+	if destructive > 0 {
+		fmt.Fprintf(w, "WARNING: %d migrations drop or rewrite existing data, take a backup with --%s before upgrading\n",
+			destructive, config.FlagSnapshotPath)
+	}
+}
+
+// ApplyMigrations applies the migrations pending on the configured database.
+func ApplyMigrations(cfg *config.Config) {
+	db := connectDB(cfg)
+	migrations := model.PlanMigrations(db)
+	model.RunMigrations(db)
+	logging.Logger.Infof("applied %d migrations", len(migrations))
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+)
+
+// MigrateDB copies the challenger state from the database of source to the database of target, which may run another
+// backend, and verifies the copy. The schema of the target is created first, its tables must be empty. The source is
+// copied from a consistent read, but the challengers using it have to be stopped for the copy to hold all of their
+// in-flight challenges. Both databases must use the same table prefix.
+func MigrateDB(ctx context.Context, source, target *config.Config, w io.Writer) error {
+	if source.DBConfig.TablePrefix != target.DBConfig.TablePrefix {
+		return fmt.Errorf("source table prefix '%s' and target table prefix '%s' differ",
+			source.DBConfig.TablePrefix, target.DBConfig.TablePrefix)
+	}
+	sourceDB := openDB(source)
+	password := ""
+	if target.DBConfig.HasCredentials() {
+		password = getDBPass(&target.DBConfig)
+	}
+	targetDB := connectDBWithPassword(target, password)
+	model.RunMigrations(targetDB)
+
+	copyDao := dao.NewCopyDao(sourceDB, targetDB)
+	rows, err := copyDao.Copy(ctx)
+	if err != nil {
+		return fmt.Errorf("copy db error, err=%+v", err)
+	}
+	fmt.Fprintf(w, "copied %s to %s:\n", source.DBConfig.Dialect, target.DBConfig.Dialect)
+	for _, table := range dao.CopyTables {
+		fmt.Fprintf(w, "  %-22s %d rows\n", table, rows[table])
+	}
+
+	if err = copyDao.Verify(ctx); err != nil {
+		return fmt.Errorf("verify copy error, stop the challengers using the source database and migrate to an "+
+			"empty target again, err=%+v", err)
+	}
+	fmt.Fprintln(w, "verified: the row counts and digests of all tables match")
+	return nil
+}
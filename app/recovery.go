@@ -0,0 +1,126 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/executor"
+)
+
+// recoveryFile is a recovery bundle signed by the tx key of the challenger which exported it.
+type recoveryFile struct {
+	Version   int             `json:"version"`
+	Signer    string          `json:"signer"`
+	PubKey    string          `json:"pub_key"`
+	Signature string          `json:"signature"` // of the compact json bundle
+	Bundle    json.RawMessage `json:"bundle"`
+}
+
+// ExportRecovery writes the events of the challenger which are neither attested nor expired, with their collected
+// votes, to path as a bundle signed by the challenger tx key. It can run while the challenger is running, e.g.
+// periodically to storage off the host, and the bundle is written to a temporary file first, so path never holds a
+// partial bundle.
+func ExportRecovery(ctx context.Context, cfg *config.Config, path string, w io.Writer) error {
+	keys, err := executor.LoadKeyPair(&cfg.GreenfieldConfig, executor.KeyKindTx)
+	if err != nil {
+		return err
+	}
+	public, err := keys.Public()
+	if err != nil {
+		return err
+	}
+	bundle, err := dao.NewRecoveryDao(openDB(cfg)).Export(ctx)
+	if err != nil {
+		return fmt.Errorf("export the in-flight challenges error, err=%+v", err)
+	}
+	bz, err := json.Marshal(bundle)
+	if err != nil {
+		return err
+	}
+	signature, pubKey, err := keys.Sign(bz)
+	if err != nil {
+		return err
+	}
+	file, err := json.Marshal(&recoveryFile{
+		Version:   RecoveryBundleVersion,
+		Signer:    public.Address,
+		PubKey:    pubKey,
+		Signature: signature,
+		Bundle:    bz,
+	})
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+	if _, err = tmpFile.Write(file); err != nil {
+		return err
+	}
+	if err = tmpFile.Sync(); err != nil {
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpFile.Name(), path); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "exported %d events and %d votes at height %d to %s\n", len(bundle.Events), len(bundle.Votes),
+		bundle.LatestBlock.Height, path)
+	return nil
+}
+
+// ImportRecovery saves the bundle at path into the database of a fresh challenger, which then resumes the in-flight
+// challenges of the bundle and polls the blocks after it. The bundle must be signed by the tx key of the challenger,
+// and the database must not have polled any block yet. The challenger which exported the bundle must not run anymore,
+// or both would vote and submit the same challenges.
+func ImportRecovery(ctx context.Context, cfg *config.Config, path string, w io.Writer) error {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var file recoveryFile
+	if err = json.Unmarshal(bz, &file); err != nil {
+		return fmt.Errorf("decode the recovery bundle error, err=%+v", err)
+	}
+	if file.Version != RecoveryBundleVersion {
+		return fmt.Errorf("unsupported recovery bundle version %d", file.Version)
+	}
+	keys, err := executor.LoadKeyPair(&cfg.GreenfieldConfig, executor.KeyKindTx)
+	if err != nil {
+		return err
+	}
+	public, err := keys.Public()
+	if err != nil {
+		return err
+	}
+	signed := &bytes.Buffer{}
+	if err = json.Compact(signed, file.Bundle); err != nil {
+		return err
+	}
+	if err = executor.VerifySignature(public.Address, file.PubKey, file.Signature, signed.Bytes()); err != nil {
+		return fmt.Errorf("the recovery bundle is not signed by the challenger, err=%+v", err)
+	}
+	bundle := &dao.RecoveryBundle{}
+	if err = json.Unmarshal(file.Bundle, bundle); err != nil {
+		return fmt.Errorf("decode the recovery bundle error, err=%+v", err)
+	}
+	if err = dao.NewRecoveryDao(openDB(cfg)).Import(ctx, bundle); err != nil {
+		return fmt.Errorf("import the in-flight challenges error, err=%+v", err)
+	}
+	fmt.Fprintf(w, "imported %d events and %d votes, polling resumes after height %d\n", len(bundle.Events),
+		len(bundle.Votes), bundle.LatestBlock.Height)
+	return nil
+}
@@ -0,0 +1,172 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/alert"
+	"github.com/bnb-chain/greenfield-challenger/common"
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+// ConfigWatchInterval is how often the config file is checked for changes.
+const ConfigWatchInterval = 10 * time.Second
+
+// ConfigReloader reloads the tunable config on SIGHUP or when the config file changes, without restarting the
+// challenger. A reload changing fields which need a restart, e.g. keys or the database settings, is rejected as a
+// whole.
+type ConfigReloader struct {
+	mtx       sync.Mutex
+	cfg       *config.Config
+	load      func() *config.Config
+	watchPath string
+	modTime   time.Time
+}
+
+// NewConfigReloader creates a reloader of cfg, load reads the config again from its source. The file at watchPath
+// is watched for changes, an empty watchPath only reloads on SIGHUP.
+func NewConfigReloader(cfg *config.Config, load func() *config.Config, watchPath string) *ConfigReloader {
+	r := &ConfigReloader{
+		cfg:       cfg,
+		load:      load,
+		watchPath: watchPath,
+	}
+	if watchPath != "" {
+		if info, err := os.Stat(watchPath); err == nil {
+			r.modTime = info.ModTime()
+		}
+	}
+	return r
+}
+
+func (r *ConfigReloader) ReloadLoop() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	ticker := time.NewTicker(ConfigWatchInterval)
+	for {
+		select {
+		case <-hup:
+			logging.Logger.Infof("received SIGHUP, reloading config")
+		case <-ticker.C:
+			if !r.fileChanged() {
+				continue
+			}
+			logging.Logger.Infof("config file %s changed, reloading config", r.watchPath)
+		}
+		if err := r.Reload(); err != nil {
+			logging.Logger.Errorf("config reload rejected, err=%+v", err.Error())
+		}
+	}
+}
+
+func (r *ConfigReloader) fileChanged() bool {
+	if r.watchPath == "" {
+		return false
+	}
+	info, err := os.Stat(r.watchPath)
+	if err != nil {
+		logging.Logger.Errorf("failed to stat config file %s, err=%+v", r.watchPath, err.Error())
+		return false
+	}
+	if !info.ModTime().After(r.modTime) {
+		return false
+	}
+	r.modTime = info.ModTime()
+	return true
+}
+
+// RemoteWatchLoop reloads the config whenever it is changed in the remote source after revision.
+func (r *ConfigReloader) RemoteWatchLoop(source config.RemoteSource, revision uint64) {
+	for {
+		newRevision, err := source.Wait(revision)
+		if err != nil {
+			logging.Logger.Errorf("failed to watch remote config, err=%+v", err.Error())
+			time.Sleep(common.RetryInterval())
+			continue
+		}
+		if newRevision == revision {
+			continue
+		}
+		revision = newRevision
+		logging.Logger.Infof("remote config changed at revision %d, reloading config", revision)
+		if err = r.Reload(); err != nil {
+			logging.Logger.Errorf("config reload rejected, err=%+v", err.Error())
+		}
+	}
+}
+
+// Reload loads the config again and applies its tunable fields.
+func (r *ConfigReloader) Reload() error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	newCfg, err := r.safeLoad()
+	if err != nil {
+		return err
+	}
+	if changes := r.cfg.ImmutableChanges(newCfg); len(changes) != 0 {
+		return fmt.Errorf("fields %s cannot be changed without a restart", strings.Join(changes, ", "))
+	}
+	if err = logging.SetLevel(newCfg.LogConfig.Level); err != nil {
+		return err
+	}
+	if err = logging.SetModuleLevels(newCfg.LogConfig.ModuleLevels); err != nil {
+		return err
+	}
+	alert.SetAlertConfig(&newCfg.AlertConfig)
+	applyTunables(&newCfg.TunableConfig)
+	r.cfg = newCfg
+	logging.Logger.Infof("config reloaded, log level: %s, module log levels: %v, tunables: %+v", newCfg.LogConfig.Level,
+		logging.ModuleLevels(), newCfg.TunableConfig)
+	return nil
+}
+
+// safeLoad turns the panics of an invalid config into an error, an invalid config must not stop a running challenger.
+func (r *ConfigReloader) safeLoad() (cfg *config.Config, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("invalid config: %v", p)
+		}
+	}()
+	return r.load(), nil
+}
+
+func applyTunables(cfg *config.TunableConfig) {
+	retryInterval := common.DefaultRetryInterval
+	if cfg.RetryIntervalInMs > 0 {
+		retryInterval = time.Duration(cfg.RetryIntervalInMs) * time.Millisecond
+	}
+	common.SetRetryInterval(retryInterval)
+
+	retryAttempts := common.DefaultRetryAttempts
+	if cfg.RetryAttempts > 0 {
+		retryAttempts = cfg.RetryAttempts
+	}
+	common.SetRetryAttempts(retryAttempts)
+
+	retryDelay := common.DefaultRetryDelay
+	if cfg.RetryDelayInMs > 0 {
+		retryDelay = time.Duration(cfg.RetryDelayInMs) * time.Millisecond
+	}
+	common.SetRetryDelay(retryDelay)
+
+	common.SetSpTimeout(time.Duration(cfg.SpTimeoutInSeconds) * time.Second)
+	common.SetMaxSpRequests(cfg.MaxSpRequests)
+
+	eventInterval := common.DefaultEventInterval
+	if cfg.EventIntervalInMs > 0 {
+		eventInterval = time.Duration(cfg.EventIntervalInMs) * time.Millisecond
+	}
+	common.SetEventInterval(eventInterval)
+
+	updateValidatorsInterval := common.DefaultUpdateValidatorsInterval
+	if cfg.UpdateValidatorsIntervalInSeconds > 0 {
+		updateValidatorsInterval = time.Duration(cfg.UpdateValidatorsIntervalInSeconds) * time.Second
+	}
+	common.SetUpdateValidatorsInterval(updateValidatorsInterval)
+}
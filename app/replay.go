@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/executor"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/replay"
+)
+
+// Replay resets the events emitted between the heights, both inclusive, back to status so that the pipeline handles
+// them again, and returns the number of reset events. A running challenger keeps the challenges it handled recently
+// in its caches, so it should be restarted after a replay, or the events reset one by one through the admin api.
+func Replay(cfg *config.Config, fromHeight, toHeight uint64, status model.EventStatus) (int64, error) {
+	if toHeight != 0 && fromHeight > toHeight {
+		return 0, fmt.Errorf("from height %d is above to height %d", fromHeight, toHeight)
+	}
+	db := openDB(cfg)
+	eventDao := dao.NewEventDao(db)
+	jobDao := dao.NewJobDao(db)
+	eventDao.SetQueryTimeout(queryTimeout(cfg))
+	jobDao.SetQueryTimeout(queryTimeout(cfg))
+	ctx := context.Background()
+	filter := dao.EventFilter{FromHeight: fromHeight, ToHeight: toHeight}
+
+	var (
+		cursor uint64
+		total  int64
+	)
+	for {
+		events, next, err := eventDao.ListEvents(ctx, filter, cursor, dao.MaxListEventsLimit)
+		if err != nil {
+			return total, fmt.Errorf("list events error, err=%+v", err)
+		}
+		challengeIds := make([]uint64, 0, len(events))
+		for _, event := range events {
+			challengeIds = append(challengeIds, event.ChallengeId)
+		}
+		replayed, err := eventDao.ReplayEvents(ctx, challengeIds, status)
+		if err != nil {
+			return total, fmt.Errorf("replay events error, err=%+v", err)
+		}
+		if status == model.Unprocessed {
+			// the failed verification jobs of the events would keep them from being queued again
+			if err = jobDao.DeleteVerificationJobs(ctx, challengeIds); err != nil {
+				return total, fmt.Errorf("delete verification jobs error, err=%+v", err)
+			}
+		}
+		total += replayed
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+	logging.Logger.Infof("replayed %d events between heights %d and %d to status %s", total, fromHeight, toHeight, status)
+	return total, nil
+}
+
+// ReplayVerify verifies again the challenges started between the heights, both inclusive, and compares the votes with
+// the attestations on chain. The challenges are verified against the chain and the storage providers, or against the
+// fixtures read from fixturesPath if it is set. The fixtures of the verified challenges are recorded to recordPath if
+// it is set. The database is not used.
+func ReplayVerify(ctx context.Context, cfg *config.Config, fromHeight, toHeight uint64, fixturesPath,
+	recordPath string,
+) (*replay.Report, error) {
+	if fixturesPath != "" && recordPath != "" {
+		return nil, errors.New("the fixtures are either replayed or recorded")
+	}
+	applyTunables(&cfg.TunableConfig)
+	e := executor.NewExecutor(cfg, nil)
+	defer e.Close()
+	replayer := replay.NewReplayer(e)
+	if fixturesPath != "" {
+		f, err := os.Open(fixturesPath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		fixtures, err := replay.LoadFixtures(f)
+		if err != nil {
+			return nil, fmt.Errorf("load fixtures error, err=%+v", err)
+		}
+		replayer.SetFixtures(fixtures)
+	}
+	if recordPath != "" {
+		f, err := os.OpenFile(recordPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		replayer.SetRecorder(f)
+	}
+	return replayer.Replay(ctx, fromHeight, toHeight)
+}
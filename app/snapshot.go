@@ -0,0 +1,48 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+// Snapshot writes a consistent logical snapshot of the challenger database to path, for operators without managed
+// database backups. It can be taken while a challenger is running against the same database. The snapshot is
+// written to a temporary file first, so path never holds a partial snapshot.
+func Snapshot(cfg *config.Config, path string) error {
+	db := openDB(cfg)
+	snapshotDao := dao.NewSnapshotDao(db)
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	w := bufio.NewWriter(tmpFile)
+	summary, err := snapshotDao.Snapshot(context.Background(), w)
+	if err != nil {
+		return fmt.Errorf("take snapshot error, err=%+v", err)
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if err = tmpFile.Sync(); err != nil {
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpFile.Name(), path); err != nil {
+		return err
+	}
+	logging.Logger.Infof("snapshot at height %d written to %s, rows=%+v", summary.LatestHeight, path, summary.Rows)
+	return nil
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+)
+
+// Status writes the latest block polled by the monitor and the number of events in each status to w, read from the
+// configured database.
+func Status(cfg *config.Config, w io.Writer) error {
+	db := connectDB(cfg)
+	blockDao, eventDao := dao.NewBlockDao(db), dao.NewEventDao(db)
+	blockDao.SetQueryTimeout(queryTimeout(cfg))
+	eventDao.SetQueryTimeout(queryTimeout(cfg))
+	ctx := context.Background()
+	block, err := blockDao.GetLatestBlock(ctx)
+	if err != nil {
+		return fmt.Errorf("get latest block error, err=%+v", err)
+	}
+	counts, err := eventDao.CountEventsByStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("count events error, err=%+v", err)
+	}
+	fmt.Fprintf(w, "latest block: %d\n", block.Height)
+	fmt.Fprintln(w, "events:")
+	for status := model.Unprocessed; status <= model.Expired; status++ {
+		fmt.Fprintf(w, "  %-24s %d\n", status.String()+":", counts[status])
+	}
+	return nil
+}
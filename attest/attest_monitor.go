@@ -1,14 +1,19 @@
 package attest
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	"github.com/bnb-chain/greenfield-challenger/metrics"
 
+	"github.com/bnb-chain/greenfield-challenger/alert"
 	"github.com/bnb-chain/greenfield-challenger/db/model"
 	"github.com/bnb-chain/greenfield-challenger/executor"
+	"github.com/bnb-chain/greenfield-challenger/health"
 	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/tracing"
+	"github.com/bnb-chain/greenfield-challenger/webhook"
 )
 
 type AttestMonitor struct {
@@ -31,14 +36,21 @@ func NewAttestMonitor(executor *executor.Executor, dataProvider DataProvider, me
 }
 
 // UpdateAttestedChallengeIdLoop polls the blockchain for latest attested challengeIds and updates their status
-func (a *AttestMonitor) UpdateAttestedChallengeIdLoop() {
+func (a *AttestMonitor) UpdateAttestedChallengeIdLoop(ctx context.Context) {
 	ticker := time.NewTicker(QueryAttestedChallengeInterval)
 	queryCount := 0
-	for range ticker.C {
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		health.Beat(health.LoopAttest)
 		challengeIds, err := a.executor.QueryLatestAttestedChallengeIds()
-		// logging.Logger.Infof("latest attested challenge ids: %+v", challengeIds)
+		// logging.AttestLogger.Infof("latest attested challenge ids: %+v", challengeIds)
 		if err != nil {
-			logging.Logger.Errorf("update latest attested challenge error, err=%+v", err)
+			logging.AttestLogger.Errorf("update latest attested challenge error, err=%+v", err)
 			continue
 		}
 		a.mtx.Lock()
@@ -57,27 +69,79 @@ func (a *AttestMonitor) UpdateAttestedChallengeIdLoop() {
 	}
 }
 
-// updateAttestedCacheAndEventStatus only updates new entries
+// updateAttestedCacheAndEventStatus only updates new entries, their events are queried at once
 func (a *AttestMonitor) updateAttestedCacheAndEventStatus(old map[uint64]bool, latest []uint64) {
+	challengeIds := make([]uint64, 0, len(latest))
 	for _, challengeId := range latest {
 		if _, ok := old[challengeId]; !ok {
-			a.wg.Add(1)
-			go func(challengeId uint64) {
-				defer a.wg.Done() // Decrement the WaitGroup when the goroutine is done
-				a.updateEventStatus(challengeId)
-			}(challengeId)
+			challengeIds = append(challengeIds, challengeId)
 		}
 	}
+	if len(challengeIds) == 0 {
+		return
+	}
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done() // Decrement the WaitGroup when the goroutine is done
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		a.updateEventStatuses(ctx, challengeIds)
+	}()
 }
 
-func (a *AttestMonitor) updateEventStatus(challengeId uint64) {
-	event, err := a.dataProvider.GetEventByChallengeId(challengeId)
+// Reconcile queries the latest attested challenges and updates the status of the ones missed by the loop, e.g.
+// while the challenger was down. It returns the number of updated events.
+func (a *AttestMonitor) Reconcile(ctx context.Context) (int, error) {
+	challengeIds, err := a.executor.QueryLatestAttestedChallengeIds()
+	if err != nil {
+		return 0, err
+	}
+	return a.updateEventStatuses(ctx, challengeIds), nil
+}
+
+// MarkAttested marks the event of the challenge found attested on chain by another stage, e.g. by the collator
+// before it collates the votes, it reports whether the event is marked attested, including by an earlier call.
+func (a *AttestMonitor) MarkAttested(ctx context.Context, challengeId uint64) bool {
+	event, err := a.dataProvider.GetEventByChallengeId(ctx, challengeId)
 	if err != nil || event == nil {
-		logging.Logger.Errorf("attest monitor failed to get event by challengeId: %d, err=%+v", challengeId, err)
-		return
+		logging.WithFields(logging.AttestLogger, logging.Fields{ChallengeId: challengeId, Stage: logging.StageAttest}).Errorf(
+			"attest monitor failed to get event, err=%+v", err)
+		return false
 	}
 	if event.Status == model.SelfAttested || event.Status == model.Attested {
-		return
+		return true
+	}
+	return a.updateStatus(ctx, event)
+}
+
+// updateEventStatuses marks the events of the attested challenges as attested, the challenges of the other
+// challengers are not saved and skipped. It returns the number of updated events.
+func (a *AttestMonitor) updateEventStatuses(ctx context.Context, challengeIds []uint64) int {
+	events, err := a.dataProvider.GetEventsByChallengeIds(ctx, challengeIds)
+	if err != nil {
+		logging.AttestLogger.Errorf("attest monitor failed to get events, err=%+v", err.Error())
+		return 0
+	}
+	updated := 0
+	for _, event := range events {
+		if a.updateStatus(ctx, event) {
+			updated++
+		}
+	}
+	return updated
+}
+
+// updateStatus marks the event as attested unless it already is, it reports whether the status was updated.
+func (a *AttestMonitor) updateStatus(ctx context.Context, event *model.Event) bool {
+	if event.Status == model.SelfAttested || event.Status == model.Attested {
+		return false
+	}
+	var err error
+	// the inclusion of the attestation ends the challenge trace
+	ctx, span := tracing.StartEventSpan(ctx, event, tracing.SpanAttestInclusion)
+	defer func() { tracing.EndSpan(span, err) }()
+	if event.VerifyResult == model.HashMatched {
+		alert.VerifyDivergence(event.ChallengeId, event.ObjectId, event.SpOperatorAddress)
 	}
 	var status model.EventStatus
 	if event.Status == model.Submitted {
@@ -85,9 +149,14 @@ func (a *AttestMonitor) updateEventStatus(challengeId uint64) {
 	} else {
 		status = model.Attested
 	}
-	err = a.dataProvider.UpdateEventStatus(challengeId, status)
+	err = a.dataProvider.UpdateEventStatus(ctx, event.ChallengeId, status)
+	log := logging.WithFields(logging.AttestLogger, event.LogFields(logging.StageAttest))
 	if err != nil {
-		logging.Logger.Errorf("update attested event status error, err=%s", err.Error())
+		log.Errorf("update attested event status error, err=%s", err.Error())
+	} else {
+		log.Infof("challenge attested, status: %s", status)
+		webhook.Attested(event, status)
 	}
 	a.metricService.IncAttestedChallenges()
+	return err == nil
 }
@@ -1,13 +1,16 @@
 package attest
 
 import (
+	"context"
+
 	"github.com/bnb-chain/greenfield-challenger/db/dao"
 	"github.com/bnb-chain/greenfield-challenger/db/model"
 )
 
 type DataProvider interface {
-	GetEventByChallengeId(challengeId uint64) (*model.Event, error)
-	UpdateEventStatus(challengeId uint64, status model.EventStatus) error
+	GetEventByChallengeId(ctx context.Context, challengeId uint64) (*model.Event, error)
+	GetEventsByChallengeIds(ctx context.Context, challengeIds []uint64) ([]*model.Event, error)
+	UpdateEventStatus(ctx context.Context, challengeId uint64, status model.EventStatus) error
 }
 
 type DataHandler struct {
@@ -20,10 +23,14 @@ func NewDataHandler(daoManager *dao.DaoManager) *DataHandler {
 	}
 }
 
-func (h *DataHandler) UpdateEventStatus(challengeId uint64, status model.EventStatus) error {
-	return h.daoManager.UpdateEventStatusByChallengeId(challengeId, status)
+func (h *DataHandler) UpdateEventStatus(ctx context.Context, challengeId uint64, status model.EventStatus) error {
+	return h.daoManager.UpdateEventStatusByChallengeId(ctx, challengeId, status)
+}
+
+func (h *DataHandler) GetEventByChallengeId(ctx context.Context, challengeId uint64) (*model.Event, error) {
+	return h.daoManager.GetEventByChallengeId(ctx, challengeId)
 }
 
-func (h *DataHandler) GetEventByChallengeId(challengeId uint64) (*model.Event, error) {
-	return h.daoManager.GetEventByChallengeId(challengeId)
+func (h *DataHandler) GetEventsByChallengeIds(ctx context.Context, challengeIds []uint64) ([]*model.Event, error) {
+	return h.daoManager.GetEventsByChallengeIds(ctx, challengeIds)
 }
@@ -0,0 +1,207 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+// Record is an entry of the audit file. Each record carries the hash of the previous one, so that removing or
+// changing a record breaks the chain of all records after it.
+type Record struct {
+	Seq         uint64    `json:"seq"`
+	Time        time.Time `json:"time"`
+	Kind        string    `json:"kind"`
+	ChallengeId uint64    `json:"challenge_id"`
+	EventHash   string    `json:"event_hash"`
+	// Result is the verify result of a vote and the vote result of an attestation
+	Result    string `json:"result"`
+	Outcome   string `json:"outcome"`
+	PubKey    string `json:"pub_key,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	TxHash    string `json:"tx_hash,omitempty"`
+	Error     string `json:"error,omitempty"`
+	PrevHash  string `json:"prev_hash"`
+	Hash      string `json:"hash"`
+}
+
+// computeHash hashes the record without its own hash.
+func (r Record) computeHash() (string, error) {
+	r.Hash = ""
+	bz, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(bz)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Log appends records to an audit file, it is safe for concurrent use.
+type Log struct {
+	mtx      sync.Mutex
+	file     *os.File
+	seq      uint64
+	lastHash string
+}
+
+// Open opens the audit file at path, creating it if missing. The records already in the file are verified, so that a
+// tampered file is not extended.
+func Open(path string) (*Log, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	count, lastHash, err := Verify(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("audit file %s is corrupted, err=%s", path, err.Error())
+	}
+	return &Log{file: file, seq: count, lastHash: lastHash}, nil
+}
+
+// Append chains r to the previous record and writes it to the file.
+func (l *Log) Append(r *Record) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if r.Time.IsZero() {
+		r.Time = time.Now().UTC()
+	}
+	r.Seq = l.seq + 1
+	r.PrevHash = l.lastHash
+	hash, err := r.computeHash()
+	if err != nil {
+		return err
+	}
+	r.Hash = hash
+	bz, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err = l.file.Write(append(bz, '\n')); err != nil {
+		return err
+	}
+	if err = l.file.Sync(); err != nil {
+		return err
+	}
+	l.seq, l.lastHash = r.Seq, r.Hash
+	return nil
+}
+
+func (l *Log) Close() error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.file.Close()
+}
+
+// Verify checks the hash chain of the records read from r, it returns the number of records and the hash of the last
+// one.
+func Verify(r io.Reader) (uint64, string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	count, lastHash := uint64(0), GenesisHash
+	for scanner.Scan() {
+		record := Record{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return count, lastHash, fmt.Errorf("record %d is malformed, err=%s", count+1, err.Error())
+		}
+		if record.Seq != count+1 {
+			return count, lastHash, fmt.Errorf("record %d has seq %d", count+1, record.Seq)
+		}
+		if record.PrevHash != lastHash {
+			return count, lastHash, fmt.Errorf("record %d does not follow the previous record", record.Seq)
+		}
+		hash, err := record.computeHash()
+		if err != nil {
+			return count, lastHash, err
+		}
+		if hash != record.Hash {
+			return count, lastHash, fmt.Errorf("record %d was modified", record.Seq)
+		}
+		count, lastHash = record.Seq, record.Hash
+	}
+	return count, lastHash, scanner.Err()
+}
+
+// auditLog is the audit file of the challenger, nothing is audited while it is nil.
+var auditLog atomic.Pointer[Log]
+
+// Init opens the audit file of cfg, auditing stays off if it is not enabled.
+func Init(cfg *config.AuditConfig) error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	l, err := Open(cfg.FilePath)
+	if err != nil {
+		return err
+	}
+	auditLog.Store(l)
+	return nil
+}
+
+// Close closes the audit file, the records appended afterwards are dropped.
+func Close() {
+	l := auditLog.Swap(nil)
+	if l == nil {
+		return
+	}
+	if err := l.Close(); err != nil {
+		logging.Logger.Errorf("failed to close audit file, err=%+v", err.Error())
+	}
+}
+
+// RecordVote audits a vote signed by the challenger.
+func RecordVote(challengeId uint64, eventHash []byte, verifyResult string, pubKey, signature []byte) {
+	write(&Record{
+		Kind:        KindVote,
+		ChallengeId: challengeId,
+		EventHash:   hex.EncodeToString(eventHash),
+		Result:      verifyResult,
+		Outcome:     OutcomeSigned,
+		PubKey:      hex.EncodeToString(pubKey),
+		Signature:   hex.EncodeToString(signature),
+	})
+}
+
+// RecordAttest audits an attest tx broadcast by the challenger, err is the error of the broadcast if it failed.
+func RecordAttest(challengeId uint64, eventHash []byte, voteResult string, aggregatedSignature []byte, txHash string,
+	attested bool, err error,
+) {
+	record := &Record{
+		Kind:        KindAttest,
+		ChallengeId: challengeId,
+		EventHash:   hex.EncodeToString(eventHash),
+		Result:      voteResult,
+		Outcome:     OutcomeAttested,
+		Signature:   hex.EncodeToString(aggregatedSignature),
+		TxHash:      txHash,
+	}
+	switch {
+	case err != nil:
+		record.Outcome = OutcomeFailed
+		record.Error = err.Error()
+	case !attested:
+		record.Outcome = OutcomeRejected
+	}
+	write(record)
+}
+
+// write appends the record to the audit file, failing to audit does not stop the pipeline.
+func write(record *Record) {
+	l := auditLog.Load()
+	if l == nil {
+		return
+	}
+	if err := l.Append(record); err != nil {
+		logging.Logger.Errorf("failed to write audit record, challengeId=%d, err=%+v", record.ChallengeId, err.Error())
+	}
+}
@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog_Chain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, l.Append(&Record{Kind: KindVote, ChallengeId: 1, EventHash: "aa", Result: "hash_mismatched",
+		Outcome: OutcomeSigned}))
+	require.NoError(t, l.Append(&Record{Kind: KindAttest, ChallengeId: 1, EventHash: "aa", Result: "CHALLENGE_SUCCEED",
+		Outcome: OutcomeFailed, Error: "timeout"}))
+	require.NoError(t, l.Close())
+
+	// reopening continues the chain
+	l, err = Open(path)
+	require.NoError(t, err)
+	require.NoError(t, l.Append(&Record{Kind: KindAttest, ChallengeId: 1, EventHash: "aa", Result: "CHALLENGE_SUCCEED",
+		Outcome: OutcomeAttested, TxHash: "ABCD"}))
+	require.NoError(t, l.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	count, _, err := Verify(bytes.NewReader(content))
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), count)
+
+	// changing a record breaks the chain
+	tampered := bytes.Replace(content, []byte(`"error":"timeout"`), []byte(`"error":"rejected"`), 1)
+	count, _, err = Verify(bytes.NewReader(tampered))
+	require.EqualError(t, err, "record 2 was modified")
+	require.Equal(t, uint64(1), count)
+
+	// so does removing one
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	count, _, err = Verify(bytes.NewReader(append(append([]byte{}, lines[0]...), lines[2]...)))
+	require.EqualError(t, err, "record 2 has seq 3")
+	require.Equal(t, uint64(1), count)
+
+	require.NoError(t, os.WriteFile(path, tampered, 0o600))
+	_, err = Open(path)
+	require.Error(t, err)
+}
+
+func TestRecordAttest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := Open(path)
+	require.NoError(t, err)
+	auditLog.Store(l)
+	defer Close()
+
+	RecordVote(7, []byte{0xaa}, "hash_matched", []byte{0x01}, []byte{0x02})
+	RecordAttest(7, []byte{0xaa}, "CHALLENGE_FAILED", []byte{0x03}, "", false, errors.New("rpc down"))
+	RecordAttest(7, []byte{0xaa}, "CHALLENGE_FAILED", []byte{0x03}, "EF01", false, nil)
+	RecordAttest(7, []byte{0xaa}, "CHALLENGE_FAILED", []byte{0x03}, "EF02", true, nil)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	count, _, err := Verify(bytes.NewReader(content))
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), count)
+	require.Contains(t, string(content), `"outcome":"failed","signature":"03","error":"rpc down"`)
+	require.Contains(t, string(content), `"outcome":"rejected","signature":"03","tx_hash":"EF01"`)
+	require.Contains(t, string(content), `"outcome":"attested","signature":"03","tx_hash":"EF02"`)
+}
@@ -0,0 +1,14 @@
+package audit
+
+const (
+	KindVote   = "vote"
+	KindAttest = "attest"
+
+	OutcomeSigned   = "signed"
+	OutcomeAttested = "attested"
+	OutcomeRejected = "rejected" // the attest tx was broadcast but not accepted
+	OutcomeFailed   = "failed"   // the attest tx could not be broadcast
+
+	// GenesisHash is the previous hash of the first record of an audit file.
+	GenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+)
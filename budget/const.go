@@ -0,0 +1,17 @@
+package budget
+
+const (
+	// EventAttestChallenge is the type of the events of the challenges attested on chain
+	EventAttestChallenge = "greenfield.challenge.EventAttestChallenge"
+	// DaysPerMonth is the length of the projected month
+	DaysPerMonth = 30
+	// DefaultDays is the period of the challenge volume the month is projected from
+	DefaultDays = 7
+	// DefaultSampleBlocks is the number of latest blocks the rewards of the attestations are sampled from
+	DefaultSampleBlocks = 1000
+
+	// the simulated submission strategies
+	StrategyInturn     = "inturn"     // attests the challenges in its turn only, as the submitter does
+	StrategyAggressive = "aggressive" // attests every challenge without waiting for its turn
+	StrategyProactive  = "proactive"  // attests in its turn and submits challenges of its own
+)
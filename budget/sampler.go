@@ -0,0 +1,156 @@
+package budget
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	sdkmath "cosmossdk.io/math"
+	challengetypes "github.com/bnb-chain/greenfield/x/challenge/types"
+	abci "github.com/cometbft/cometbft/abci/types"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+)
+
+// Executor reads the blocks the rewards are sampled from.
+type Executor interface {
+	GetLatestBlockHeight() (uint64, error)
+	GetBlockAndBlockResultAtHeight(height int64) (*tmtypes.Block, *ctypes.ResultBlockResults, error)
+}
+
+// SampleRewards averages the rewards of the challenges attested in the latest blocks. Without slashes attested in
+// them, the slash rewards are estimated from params with the min slash amount.
+func SampleRewards(ctx context.Context, executor Executor, blocks uint64, params *challengetypes.Params,
+	validators int,
+) (Rewards, error) {
+	rewards := Rewards{}
+	latest, err := executor.GetLatestBlockHeight()
+	if err != nil {
+		return rewards, err
+	}
+	from := uint64(1)
+	if latest > blocks {
+		from = latest - blocks + 1
+	}
+	var heartbeats, slashSubmitters, slashChallengers float64
+	challenged := 0
+	for height := from; height <= latest; height++ {
+		if err = ctx.Err(); err != nil {
+			return rewards, err
+		}
+		_, blockResults, err := executor.GetBlockAndBlockResultAtHeight(int64(height))
+		if err != nil {
+			return rewards, err
+		}
+		for _, attrs := range parseAttestations(blockResults) {
+			submitter := weiToFloat(attrs["submitter_reward_amount"])
+			// heartbeats do not slash
+			if attrs["slash_amount"] == "" {
+				rewards.SampledHeartbeats++
+				heartbeats += submitter
+				continue
+			}
+			rewards.SampledSlashes++
+			slashSubmitters += submitter
+			if challenger := attrs["challenger_reward_amount"]; challenger != "" && challenger != "0" {
+				challenged++
+				slashChallengers += weiToFloat(challenger)
+			}
+		}
+	}
+	if rewards.SampledHeartbeats != 0 {
+		rewards.Heartbeat = heartbeats / float64(rewards.SampledHeartbeats)
+	}
+	if rewards.SampledSlashes != 0 {
+		rewards.SlashSubmitter = slashSubmitters / float64(rewards.SampledSlashes)
+	}
+	estimated := EstimateSlashRewards(params, validators)
+	if challenged != 0 {
+		rewards.SlashChallenger = slashChallengers / float64(challenged)
+	} else {
+		rewards.SlashChallenger = estimated.SlashChallenger
+	}
+	if rewards.SampledSlashes == 0 {
+		rewards.SlashSubmitter = estimated.SlashSubmitter
+	}
+	return rewards, nil
+}
+
+// EstimateSlashRewards estimates the rewards of a slash with the min slash amount, as the chain splits them.
+func EstimateSlashRewards(params *challengetypes.Params, validators int) Rewards {
+	total := params.SlashAmountMin
+	submitter := params.RewardSubmitterRatio.MulInt(total).TruncateInt()
+	if submitter.GT(params.RewardSubmitterThreshold) {
+		submitter = params.RewardSubmitterThreshold
+	}
+	left := total.Sub(submitter)
+	// the validators which voted share their ratio of the rest, the challenger gets what is left of it
+	challenger := left
+	if validators > 0 {
+		each := params.RewardValidatorRatio.MulInt(left).QuoInt64(int64(validators)).TruncateInt()
+		challenger = left.Sub(each.MulRaw(int64(validators)))
+	}
+	return Rewards{SlashSubmitter: intToFloat(submitter), SlashChallenger: intToFloat(challenger)}
+}
+
+// ProactiveVolume returns the challenges submitted by the proactive challenger of cfg, given the share of the
+// challenges verified as mismatched. With pre-verification, only the challenges which slash are submitted.
+func ProactiveVolume(cfg *config.ProactiveConfig, mismatchRatio float64) Proactive {
+	if !cfg.Enabled() || cfg.IntervalInMinutes == 0 {
+		return Proactive{}
+	}
+	roundsPerDay := float64(24*60) / float64(cfg.IntervalInMinutes)
+	samples := float64(cfg.SamplesPerRound)
+	if !cfg.PreVerify {
+		return Proactive{
+			ChallengesPerDay: roundsPerDay * minFloat(samples, float64(cfg.MaxChallengesPerRound)),
+			SuccessRatio:     mismatchRatio,
+		}
+	}
+	return Proactive{
+		ChallengesPerDay: roundsPerDay * minFloat(samples*mismatchRatio, float64(cfg.MaxChallengesPerRound)),
+		SuccessRatio:     1,
+	}
+}
+
+// parseAttestations returns the attributes of the attestations in the block.
+func parseAttestations(blockResults *ctypes.ResultBlockResults) []map[string]string {
+	events := append([]abci.Event(nil), blockResults.EndBlockEvents...)
+	for _, tx := range blockResults.TxsResults {
+		events = append(events, tx.Events...)
+	}
+	attestations := make([]map[string]string, 0)
+	for _, event := range events {
+		if event.Type != EventAttestChallenge {
+			continue
+		}
+		attrs := make(map[string]string, len(event.Attributes))
+		for _, attr := range event.Attributes {
+			attrs[attr.Key] = strings.Trim(attr.Value, `"`)
+		}
+		attestations = append(attestations, attrs)
+	}
+	return attestations
+}
+
+func weiToFloat(amount string) float64 {
+	i, ok := sdkmath.NewIntFromString(amount)
+	if !ok {
+		return 0
+	}
+	return intToFloat(i)
+}
+
+func intToFloat(i sdkmath.Int) float64 {
+	f, _ := new(big.Float).SetInt(i.BigInt()).Float64()
+	return f
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,98 @@
+package budget
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	challengetypes "github.com/bnb-chain/greenfield/x/challenge/types"
+	abci "github.com/cometbft/cometbft/abci/types"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+)
+
+type mockExecutor struct {
+	blocks  map[int64]*ctypes.ResultBlockResults
+	latest  uint64
+	queried []int64
+}
+
+func (e *mockExecutor) GetLatestBlockHeight() (uint64, error) {
+	return e.latest, nil
+}
+
+func (e *mockExecutor) GetBlockAndBlockResultAtHeight(height int64) (*tmtypes.Block, *ctypes.ResultBlockResults, error) {
+	e.queried = append(e.queried, height)
+	if results, ok := e.blocks[height]; ok {
+		return nil, results, nil
+	}
+	return nil, &ctypes.ResultBlockResults{Height: height}, nil
+}
+
+func attestEvent(slash, submitter, challenger string) abci.Event {
+	attrs := map[string]string{
+		"slash_amount":             slash,
+		"submitter_reward_amount":  submitter,
+		"challenger_reward_amount": challenger,
+	}
+	event := abci.Event{Type: EventAttestChallenge}
+	for key, value := range attrs {
+		event.Attributes = append(event.Attributes, abci.EventAttribute{Key: key, Value: strconv.Quote(value)})
+	}
+	return event
+}
+
+func newParams() *challengetypes.Params {
+	return &challengetypes.Params{
+		SlashAmountMin:           sdkmath.NewInt(10000),
+		RewardSubmitterRatio:     sdk.NewDecWithPrec(1, 3),
+		RewardSubmitterThreshold: sdkmath.NewInt(5),
+		RewardValidatorRatio:     sdk.NewDecWithPrec(9, 1),
+	}
+}
+
+func TestSampleRewards(t *testing.T) {
+	executor := &mockExecutor{latest: 20, blocks: map[int64]*ctypes.ResultBlockResults{
+		// before the sampled blocks
+		10: {EndBlockEvents: []abci.Event{attestEvent("", "1000", "")}},
+		11: {EndBlockEvents: []abci.Event{attestEvent("", "10", "")}},
+		15: {TxsResults: []*abci.ResponseDeliverTx{{Events: []abci.Event{
+			attestEvent("", "30", ""),
+			// a challenge of the chain has no challenger
+			attestEvent("10000", "500", "0"),
+			attestEvent("10000", "100", "2000"),
+		}}}},
+	}}
+	rewards, err := SampleRewards(context.Background(), executor, 10, newParams(), 3)
+	require.NoError(t, err)
+	require.Equal(t, int64(11), executor.queried[0])
+	require.Len(t, executor.queried, 10)
+	require.Equal(t, Rewards{Heartbeat: 20, SlashSubmitter: 300, SlashChallenger: 2000, SampledHeartbeats: 2,
+		SampledSlashes: 2}, rewards)
+}
+
+func TestSampleRewards_EstimatesSlashes(t *testing.T) {
+	executor := &mockExecutor{latest: 5, blocks: map[int64]*ctypes.ResultBlockResults{}}
+	rewards, err := SampleRewards(context.Background(), executor, 10, newParams(), 3)
+	require.NoError(t, err)
+	require.Len(t, executor.queried, 5)
+	// 10 for the submitter capped to 5, 90% of the rest to the 3 validators, truncated to 2998 each
+	require.Equal(t, Rewards{SlashSubmitter: 5, SlashChallenger: 9995 - 3*2998}, rewards)
+}
+
+func TestProactiveVolume(t *testing.T) {
+	cfg := &config.ProactiveConfig{IntervalInMinutes: 60, SamplesPerRound: 10, MaxChallengesPerRound: 5,
+		MaxObjectId: 100}
+	require.Equal(t, Proactive{ChallengesPerDay: 120, SuccessRatio: 0.1}, ProactiveVolume(cfg, 0.1))
+
+	// only the mismatched samples are submitted
+	cfg.PreVerify = true
+	require.Equal(t, Proactive{ChallengesPerDay: 24, SuccessRatio: 1}, ProactiveVolume(cfg, 0.1))
+
+	require.Equal(t, Proactive{}, ProactiveVolume(&config.ProactiveConfig{}, 0.1))
+}
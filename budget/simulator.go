@@ -0,0 +1,142 @@
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Volume is the challenge volume seen over a period, the month is projected from it.
+type Volume struct {
+	Period     time.Duration
+	Challenges int64
+	Heartbeats int64 // heartbeats served by the sp, attested to reward the submitter
+	Mismatched int64 // verified as mismatched, attested to slash the sp
+}
+
+// MismatchRatio returns the share of the challenges verified as mismatched, 0 without any.
+func (v *Volume) MismatchRatio() float64 {
+	if v.Challenges == 0 {
+		return 0
+	}
+	return float64(v.Mismatched) / float64(v.Challenges)
+}
+
+// Rewards are the average rewards of an attestation paid to the challenger account, in wei. The rewards of the
+// validators which voted are paid to the validators and are not counted.
+type Rewards struct {
+	Heartbeat       float64 // to the submitter of a heartbeat
+	SlashSubmitter  float64 // to the submitter of a slash
+	SlashChallenger float64 // to the challenger of a slash, only the submitted challenges have one
+	// SampledHeartbeats and SampledSlashes are the attestations the rewards are averaged from
+	SampledHeartbeats int
+	SampledSlashes    int
+}
+
+// Proactive is the volume of the challenges submitted by the proactive challenger.
+type Proactive struct {
+	ChallengesPerDay float64
+	SuccessRatio     float64 // share of the submitted challenges which slash the sp
+}
+
+// Inputs are what the month is projected from.
+type Inputs struct {
+	Volume     Volume
+	Fee        float64 // paid by each transaction, in wei
+	Validators int     // taking turns to submit the attestations
+	Rewards    Rewards
+	Proactive  Proactive
+}
+
+// Projection is the monthly cost and rewards of a submission strategy, in wei.
+type Projection struct {
+	Strategy     string
+	Transactions float64
+	Attestations float64 // accepted on chain
+	Challenges   float64 // submitted by the proactive challenger
+	Fees         float64
+	Rewards      float64
+}
+
+// Net returns the rewards left once the fees are paid, negative if the strategy costs more than it earns.
+func (p *Projection) Net() float64 {
+	return p.Rewards - p.Fees
+}
+
+// Report holds the projections of the strategies and what they are projected from.
+type Report struct {
+	Inputs      Inputs
+	Projections []*Projection
+}
+
+// Simulate projects the monthly cost and rewards of each strategy. The challenger gets its share of the attestations
+// in its turn, the transactions sent out of turn are rejected by the chain but still pay their fee.
+func Simulate(inputs Inputs) (*Report, error) {
+	if inputs.Volume.Period <= 0 {
+		return nil, errors.New("the period of the challenge volume should be positive")
+	}
+	if inputs.Validators <= 0 {
+		return nil, errors.New("there should be at least one validator")
+	}
+	scale := float64(DaysPerMonth*24*time.Hour) / float64(inputs.Volume.Period)
+	heartbeats := float64(inputs.Volume.Heartbeats) * scale
+	slashes := float64(inputs.Volume.Mismatched) * scale
+	share := 1 / float64(inputs.Validators)
+	rewards := inputs.Rewards
+
+	inturn := &Projection{
+		Strategy:     StrategyInturn,
+		Transactions: (heartbeats + slashes) * share,
+		Attestations: (heartbeats + slashes) * share,
+		Rewards:      (heartbeats*rewards.Heartbeat + slashes*rewards.SlashSubmitter) * share,
+	}
+	aggressive := &Projection{
+		Strategy:     StrategyAggressive,
+		Transactions: heartbeats + slashes,
+		Attestations: inturn.Attestations,
+		Rewards:      inturn.Rewards,
+	}
+	// the successful challenges are attested by the validator in turn like the others, the challenger reward is paid
+	// whoever attests them
+	challenges := inputs.Proactive.ChallengesPerDay * DaysPerMonth
+	succeeded := challenges * inputs.Proactive.SuccessRatio
+	proactive := &Projection{
+		Strategy:     StrategyProactive,
+		Transactions: inturn.Transactions + challenges + succeeded*share,
+		Attestations: inturn.Attestations + succeeded*share,
+		Challenges:   challenges,
+		Rewards:      inturn.Rewards + succeeded*(rewards.SlashChallenger+rewards.SlashSubmitter*share),
+	}
+	projections := []*Projection{inturn, aggressive, proactive}
+	for _, p := range projections {
+		p.Fees = p.Transactions * inputs.Fee
+	}
+	return &Report{Inputs: inputs, Projections: projections}, nil
+}
+
+// Write writes the projections as a table followed by what they are projected from.
+func (r *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STRATEGY\tTRANSACTIONS\tATTESTATIONS\tCHALLENGES\tFEES (WEI)\tREWARDS (WEI)\tNET (WEI)")
+	for _, p := range r.Projections {
+		fmt.Fprintf(tw, "%s\t%.0f\t%.0f\t%.0f\t%.0f\t%.0f\t%.0f\n", p.Strategy, p.Transactions, p.Attestations,
+			p.Challenges, p.Fees, p.Rewards, p.Net())
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	inputs := r.Inputs
+	fmt.Fprintf(w, "projected over %d days from %d challenges in %s (%d heartbeats, %d mismatched), %d validators "+
+		"taking turns, %.0f wei per transaction\n", DaysPerMonth, inputs.Volume.Challenges, inputs.Volume.Period,
+		inputs.Volume.Heartbeats, inputs.Volume.Mismatched, inputs.Validators, inputs.Fee)
+	fmt.Fprintf(w, "rewards averaged from %d heartbeats and %d slashes attested recently", inputs.Rewards.SampledHeartbeats,
+		inputs.Rewards.SampledSlashes)
+	if inputs.Rewards.SampledSlashes == 0 {
+		fmt.Fprint(w, ", the slash rewards are estimated with the min slash amount")
+	}
+	_, err := fmt.Fprintf(w, "\nproactive challenger submitting %.1f challenges a day, %.0f%% of them slashing the sp\n",
+		inputs.Proactive.ChallengesPerDay, inputs.Proactive.SuccessRatio*100)
+	return err
+}
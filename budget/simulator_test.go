@@ -0,0 +1,72 @@
+package budget
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newInputs() Inputs {
+	return Inputs{
+		// 100 heartbeats and 20 slashes over 3 days, 1000 and 200 a month
+		Volume:     Volume{Period: 3 * 24 * time.Hour, Challenges: 10000, Heartbeats: 100, Mismatched: 20},
+		Fee:        10,
+		Validators: 4,
+		Rewards:    Rewards{Heartbeat: 20, SlashSubmitter: 100, SlashChallenger: 1000},
+		Proactive:  Proactive{ChallengesPerDay: 2, SuccessRatio: 0.5},
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	report, err := Simulate(newInputs())
+	require.NoError(t, err)
+	require.Len(t, report.Projections, 3)
+
+	inturn := report.Projections[0]
+	require.Equal(t, StrategyInturn, inturn.Strategy)
+	require.InDelta(t, 300, inturn.Transactions, 1e-6)
+	require.InDelta(t, 300, inturn.Attestations, 1e-6)
+	require.InDelta(t, 3000, inturn.Fees, 1e-6)
+	require.InDelta(t, (1000*20+200*100)/4, inturn.Rewards, 1e-6)
+	require.InDelta(t, 7000, inturn.Net(), 1e-6)
+
+	// the transactions out of turn pay their fee for nothing
+	aggressive := report.Projections[1]
+	require.Equal(t, StrategyAggressive, aggressive.Strategy)
+	require.InDelta(t, 1200, aggressive.Transactions, 1e-6)
+	require.InDelta(t, inturn.Attestations, aggressive.Attestations, 1e-6)
+	require.InDelta(t, inturn.Rewards, aggressive.Rewards, 1e-6)
+	require.InDelta(t, -2000, aggressive.Net(), 1e-6)
+
+	// 60 challenges a month, 30 of which slash and get their challenger reward
+	proactive := report.Projections[2]
+	require.Equal(t, StrategyProactive, proactive.Strategy)
+	require.InDelta(t, 60, proactive.Challenges, 1e-6)
+	require.InDelta(t, 300+60+7.5, proactive.Transactions, 1e-6)
+	require.InDelta(t, 307.5, proactive.Attestations, 1e-6)
+	require.InDelta(t, inturn.Rewards+30*1000+7.5*100, proactive.Rewards, 1e-6)
+}
+
+func TestSimulate_InvalidInputs(t *testing.T) {
+	inputs := newInputs()
+	inputs.Validators = 0
+	_, err := Simulate(inputs)
+	require.Error(t, err)
+
+	inputs = newInputs()
+	inputs.Volume.Period = 0
+	_, err = Simulate(inputs)
+	require.Error(t, err)
+}
+
+func TestReport_Write(t *testing.T) {
+	report, err := Simulate(newInputs())
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out))
+	require.Contains(t, out.String(), "aggressive  1200")
+	require.Contains(t, out.String(), "projected over 30 days from 10000 challenges in 72h0m0s")
+	require.Contains(t, out.String(), "the slash rewards are estimated with the min slash amount")
+}
@@ -0,0 +1,70 @@
+package common
+
+import lru "github.com/hashicorp/golang-lru"
+
+// EventCache caches a value by challenge id while the challenge is not expired. It holds at most size entries, the
+// least recently used one being evicted first, and the entries of the expired challenges are evicted by EvictExpired,
+// so that a burst of challenges does not grow the cache beyond the challenges which can still be handled.
+type EventCache struct {
+	cache *lru.Cache
+}
+
+type eventCacheEntry struct {
+	expiredHeight uint64
+	value         interface{}
+}
+
+func NewEventCache(size int) *EventCache {
+	cache, _ := lru.New(size)
+	return &EventCache{cache: cache}
+}
+
+// Add caches value for the challenge expiring at expiredHeight.
+func (c *EventCache) Add(challengeId, expiredHeight uint64, value interface{}) {
+	c.cache.Add(challengeId, &eventCacheEntry{expiredHeight: expiredHeight, value: value})
+}
+
+// Get returns the value cached for the challenge.
+func (c *EventCache) Get(challengeId uint64) (interface{}, bool) {
+	entry, ok := c.cache.Get(challengeId)
+	if !ok {
+		return nil, false
+	}
+	return entry.(*eventCacheEntry).value, true
+}
+
+// Contains reports whether a value is cached for the challenge, without making it recently used.
+func (c *EventCache) Contains(challengeId uint64) bool {
+	return c.cache.Contains(challengeId)
+}
+
+// Remove drops the value cached for the challenge.
+func (c *EventCache) Remove(challengeId uint64) {
+	c.cache.Remove(challengeId)
+}
+
+// EvictExpired drops the entries of the challenges expired at currentHeight, from the oldest to the newest, and returns
+// how many were dropped.
+func (c *EventCache) EvictExpired(currentHeight uint64) int {
+	evicted := 0
+	for _, key := range c.cache.Keys() {
+		entry, ok := c.cache.Peek(key)
+		if ok && entry.(*eventCacheEntry).expiredHeight < currentHeight {
+			c.cache.Remove(key)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// ChallengeIds lists the cached challenge ids, from the oldest to the newest.
+func (c *EventCache) ChallengeIds() []uint64 {
+	keys := c.cache.Keys()
+	challengeIds := make([]uint64, 0, len(keys))
+	for _, key := range keys {
+		if challengeId, ok := key.(uint64); ok {
+			challengeIds = append(challengeIds, challengeId)
+		}
+	}
+	return challengeIds
+}
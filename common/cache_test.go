@@ -0,0 +1,31 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventCache(t *testing.T) {
+	cache := NewEventCache(3)
+	cache.Add(1, 100, "a")
+	cache.Add(2, 200, "b")
+	cache.Add(3, 100, "c")
+	value, ok := cache.Get(1)
+	require.True(t, ok)
+	require.Equal(t, "a", value)
+
+	// the least recently used entry is evicted beyond the size
+	cache.Add(4, 300, "d")
+	require.False(t, cache.Contains(2))
+	require.Equal(t, []uint64{3, 1, 4}, cache.ChallengeIds())
+
+	// the entries are kept up to their expired height
+	require.Equal(t, 0, cache.EvictExpired(100))
+	require.Equal(t, 2, cache.EvictExpired(101))
+	require.Equal(t, []uint64{4}, cache.ChallengeIds())
+
+	cache.Remove(4)
+	_, ok = cache.Get(4)
+	require.False(t, ok)
+}
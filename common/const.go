@@ -1,19 +1,14 @@
 package common
 
 import (
-	"time"
-
 	"github.com/avast/retry-go/v4"
 )
 
 var (
-	RtyAttemNum              = uint(2)
-	RtyAttem                 = retry.Attempts(RtyAttemNum)
-	RtyDelay                 = retry.Delay(time.Millisecond * 500)
-	RtyErr                   = retry.LastErrorOnly(true)
-	RetryInterval            = 1 * time.Second
-	CacheClearIterations     = 100
-	CacheSize                = 600
-	MaxSubmitAttempts        = 5
-	MaxCheckAttestedAttempts = 20
+	// RtyAttem and RtyDelay read the tunables when a retry starts, so that reloaded values apply to the next retry
+	RtyAttem                 retry.Option = func(c *retry.Config) { retry.Attempts(RetryAttempts())(c) }
+	RtyDelay                 retry.Option = func(c *retry.Config) { retry.Delay(RetryDelay())(c) }
+	RtyErr                                = retry.LastErrorOnly(true)
+	MaxSubmitAttempts                     = 5
+	MaxCheckAttestedAttempts              = 20
 )
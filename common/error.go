@@ -3,3 +3,10 @@ package common
 import "fmt"
 
 var ErrEventExpired = fmt.Errorf("event expired")
+
+// ErrVotePoolRejected is returned when the votepool rejects a request which cannot succeed if retried, e.g. a vote with
+// an invalid signature.
+var ErrVotePoolRejected = fmt.Errorf("votepool rejected the request")
+
+// ErrEventNotVerified is returned when an event is voted before its verification produced a result.
+var ErrEventNotVerified = fmt.Errorf("event not verified")
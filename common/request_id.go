@@ -0,0 +1,38 @@
+package common
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// instanceId tells the request ids of this challenger apart from the ones of other challengers and earlier runs.
+var instanceId = newInstanceId()
+
+func newInstanceId() string {
+	bz := make([]byte, 4)
+	if _, err := rand.Read(bz); err != nil {
+		return "00000000"
+	}
+	return hex.EncodeToString(bz)
+}
+
+// RequestId returns the correlation id of a challenge, it is sent to the storage provider with the challenge
+// requests and logged with every record of the challenge, so both sides can find the same request in a dispute.
+func RequestId(challengeId uint64) string {
+	return fmt.Sprintf("%d-%s", challengeId, instanceId)
+}
+
+type requestIdKey struct{}
+
+// WithRequestId returns a context carrying the request id, which is attached to the outgoing sp requests.
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// RequestIdFromContext returns the request id carried by ctx, an empty string if there is none.
+func RequestIdFromContext(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
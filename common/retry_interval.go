@@ -0,0 +1,99 @@
+package common
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// MaxIdleRetryInterval bounds the block interval waited by an idle loop, e.g. while the chain is halted
+	MaxIdleRetryInterval = 10 * time.Second
+	// MinRetryInterval bounds the wait of a loop with events close to their expiry
+	MinRetryInterval = 100 * time.Millisecond
+	// RetriesBeforeExpiry is how many retries an event close to its expiry gets at least
+	RetriesBeforeExpiry = 4
+
+	blockIntervalWeight = 5 // a new block interval counts for 1/blockIntervalWeight of the smoothed one
+)
+
+var blockInterval atomic.Int64
+
+// ObserveBlockInterval smooths the observed time between two blocks into BlockInterval.
+func ObserveBlockInterval(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	for {
+		previous := blockInterval.Load()
+		smoothed := int64(interval)
+		if previous > 0 {
+			smoothed = previous + (int64(interval)-previous)/blockIntervalWeight
+		}
+		if blockInterval.CompareAndSwap(previous, smoothed) {
+			return
+		}
+	}
+}
+
+// BlockInterval is the observed time between two blocks, 0 until one is observed.
+func BlockInterval() time.Duration {
+	return time.Duration(blockInterval.Load())
+}
+
+// AdaptiveRetryInterval derives the wait of a loop from RetryInterval, the block interval and its backlog, i.e. the
+// events it has left to handle. An idle loop waits about a block, since nothing new shows up before the next block. A
+// loop with a backlog retries within RetryInterval and half a block, and sooner when its events expire within
+// expiresIn, so that they are retried a few times before, 0 if they do not expire. RetryInterval is kept as is until
+// a block interval is observed.
+func AdaptiveRetryInterval(backlog int, expiresIn time.Duration) time.Duration {
+	interval := RetryInterval()
+	if backlog == 0 {
+		block := BlockInterval()
+		if block > MaxIdleRetryInterval {
+			block = MaxIdleRetryInterval
+		}
+		if block > interval {
+			interval = block
+		}
+		return interval
+	}
+	if half := BlockInterval() / 2; half > 0 && half < interval {
+		interval = half
+	}
+	if expiresIn > 0 {
+		if urgent := expiresIn / RetriesBeforeExpiry; urgent < interval {
+			interval = urgent
+		}
+	}
+	// a configured interval shorter than MinRetryInterval is kept, e.g. by the tests
+	floor := MinRetryInterval
+	if configured := RetryInterval(); configured < floor {
+		floor = configured
+	}
+	if interval < floor {
+		interval = floor
+	}
+	return interval
+}
+
+// IdleRetryInterval is the wait of a loop which found nothing to handle.
+func IdleRetryInterval() time.Duration {
+	return AdaptiveRetryInterval(0, 0)
+}
+
+// BackoffRetryInterval doubles RetryInterval with every consecutive failure of a loop, e.g. a node which cannot be
+// reached, up to MaxIdleRetryInterval or RetryInterval if longer.
+func BackoffRetryInterval(failures int) time.Duration {
+	interval := RetryInterval()
+	limit := MaxIdleRetryInterval
+	if interval > limit {
+		return interval
+	}
+	for i := 1; i < failures && interval < limit; i++ {
+		interval *= 2
+	}
+	if interval > limit {
+		interval = limit
+	}
+	return interval
+}
@@ -0,0 +1,52 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptiveRetryInterval(t *testing.T) {
+	defer SetRetryInterval(DefaultRetryInterval)
+	defer blockInterval.Store(0)
+	SetRetryInterval(time.Second)
+	blockInterval.Store(0)
+
+	// the configured interval is kept until a block interval is observed
+	require.Equal(t, time.Second, IdleRetryInterval())
+	require.Equal(t, time.Second, AdaptiveRetryInterval(5, 0))
+
+	ObserveBlockInterval(4 * time.Second)
+	require.Equal(t, 4*time.Second, BlockInterval())
+	ObserveBlockInterval(9 * time.Second)
+	require.Equal(t, 5*time.Second, BlockInterval())
+
+	// idle, the loop waits a block
+	require.Equal(t, 5*time.Second, IdleRetryInterval())
+	// with a backlog, it retries within the configured interval and half a block
+	require.Equal(t, time.Second, AdaptiveRetryInterval(5, 0))
+	// and a few times before the events expire, down to MinRetryInterval
+	require.Equal(t, 500*time.Millisecond, AdaptiveRetryInterval(5, 2*time.Second))
+	require.Equal(t, MinRetryInterval, AdaptiveRetryInterval(5, time.Millisecond))
+
+	// the idle wait of a halted chain is bounded
+	blockInterval.Store(int64(time.Minute))
+	require.Equal(t, MaxIdleRetryInterval, IdleRetryInterval())
+	SetRetryInterval(time.Minute)
+	require.Equal(t, time.Minute, IdleRetryInterval())
+}
+
+func TestBackoffRetryInterval(t *testing.T) {
+	defer SetRetryInterval(DefaultRetryInterval)
+	SetRetryInterval(time.Second)
+	require.Equal(t, time.Second, BackoffRetryInterval(0))
+	require.Equal(t, time.Second, BackoffRetryInterval(1))
+	require.Equal(t, 4*time.Second, BackoffRetryInterval(3))
+	require.Equal(t, MaxIdleRetryInterval, BackoffRetryInterval(10))
+	require.Equal(t, MaxIdleRetryInterval, BackoffRetryInterval(1000))
+
+	// a configured interval longer than the bound is kept
+	SetRetryInterval(time.Minute)
+	require.Equal(t, time.Minute, BackoffRetryInterval(5))
+}
@@ -0,0 +1,89 @@
+package common
+
+import (
+	"context"
+	"sync"
+)
+
+// spRequests caps the requests in flight to the storage providers across the challenger, so that a burst of
+// verifications does not exhaust the file descriptors or saturate the uplink of a small host.
+var spRequests = &requestLimiter{}
+
+// requestLimiter is a semaphore whose limit can be changed while requests are in flight, e.g. by a config reload.
+type requestLimiter struct {
+	mtx      sync.Mutex
+	limit    int // 0 for no limit
+	inFlight int
+	released chan struct{} // closed when a request is released or the limit is changed
+}
+
+func (l *requestLimiter) acquire(ctx context.Context) error {
+	for {
+		l.mtx.Lock()
+		if l.limit == 0 || l.inFlight < l.limit {
+			l.inFlight++
+			l.mtx.Unlock()
+			return nil
+		}
+		if l.released == nil {
+			l.released = make(chan struct{})
+		}
+		released := l.released
+		l.mtx.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-released:
+		}
+	}
+}
+
+func (l *requestLimiter) release() {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.inFlight--
+	l.notify()
+}
+
+func (l *requestLimiter) setLimit(limit int) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.limit = limit
+	l.notify()
+}
+
+func (l *requestLimiter) count() int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.inFlight
+}
+
+// notify wakes up the waiting requests, l.mtx must be held.
+func (l *requestLimiter) notify() {
+	if l.released != nil {
+		close(l.released)
+		l.released = nil
+	}
+}
+
+// AcquireSpRequest waits until a request to a storage provider may be sent, within MaxSpRequests, and returns the
+// error of ctx if it is done before. A nil error must be followed by ReleaseSpRequest once the request is done.
+func AcquireSpRequest(ctx context.Context) error {
+	return spRequests.acquire(ctx)
+}
+
+// ReleaseSpRequest ends a request acquired by AcquireSpRequest.
+func ReleaseSpRequest() {
+	spRequests.release()
+}
+
+// SetMaxSpRequests caps the requests in flight to the storage providers, 0 for no cap. The requests already in
+// flight are not interrupted by a lower cap.
+func SetMaxSpRequests(limit int) {
+	spRequests.setLimit(limit)
+}
+
+// SpRequestsInFlight counts the requests in flight to the storage providers.
+func SpRequestsInFlight() int {
+	return spRequests.count()
+}
@@ -0,0 +1,40 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLimiter(t *testing.T) {
+	l := &requestLimiter{limit: 2}
+	ctx := context.Background()
+	require.NoError(t, l.acquire(ctx))
+	require.NoError(t, l.acquire(ctx))
+
+	// the cap is reached, the request waits until ctx is done
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, l.acquire(timeoutCtx), context.DeadlineExceeded)
+	require.Equal(t, 2, l.count())
+
+	// or until a request is released
+	acquired := make(chan error)
+	go func() { acquired <- l.acquire(ctx) }()
+	l.release()
+	require.NoError(t, <-acquired)
+	require.Equal(t, 2, l.count())
+
+	// or the cap is raised
+	go func() { acquired <- l.acquire(ctx) }()
+	l.setLimit(3)
+	require.NoError(t, <-acquired)
+	require.Equal(t, 3, l.count())
+
+	// without a cap, nothing waits
+	l.setLimit(0)
+	require.NoError(t, l.acquire(ctx))
+	require.Equal(t, 4, l.count())
+}
@@ -0,0 +1,88 @@
+package common
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultRetryAttempts = uint(2)
+	DefaultRetryDelay    = 500 * time.Millisecond
+	DefaultRetryInterval = 1 * time.Second
+	// DefaultEventInterval paces the vote collator between two events
+	DefaultEventInterval            = 50 * time.Millisecond
+	DefaultUpdateValidatorsInterval = 1 * time.Minute
+)
+
+// The tunables can be changed by a config reload while the pipeline is running, so they are accessed atomically.
+var (
+	retryAttempts atomic.Uint64
+	retryDelay    atomic.Int64
+	retryInterval atomic.Int64
+	spTimeout     atomic.Int64
+
+	eventInterval            atomic.Int64
+	updateValidatorsInterval atomic.Int64
+)
+
+func init() {
+	SetRetryAttempts(DefaultRetryAttempts)
+	SetRetryDelay(DefaultRetryDelay)
+	SetRetryInterval(DefaultRetryInterval)
+	SetEventInterval(DefaultEventInterval)
+	SetUpdateValidatorsInterval(DefaultUpdateValidatorsInterval)
+}
+
+// RetryAttempts is the number of attempts of a retried chain or sp query.
+func RetryAttempts() uint {
+	return uint(retryAttempts.Load())
+}
+
+func SetRetryAttempts(attempts uint) {
+	retryAttempts.Store(uint64(attempts))
+}
+
+// RetryDelay is the delay between the attempts of a retried chain or sp query.
+func RetryDelay() time.Duration {
+	return time.Duration(retryDelay.Load())
+}
+
+func SetRetryDelay(delay time.Duration) {
+	retryDelay.Store(int64(delay))
+}
+
+// RetryInterval is the time the pipeline loops wait before retrying after an error.
+func RetryInterval() time.Duration {
+	return time.Duration(retryInterval.Load())
+}
+
+func SetRetryInterval(interval time.Duration) {
+	retryInterval.Store(int64(interval))
+}
+
+// SpTimeout bounds a single request to a storage provider, 0 means no timeout.
+func SpTimeout() time.Duration {
+	return time.Duration(spTimeout.Load())
+}
+
+func SetSpTimeout(timeout time.Duration) {
+	spTimeout.Store(int64(timeout))
+}
+
+// EventInterval is the pause of the vote collator between two events.
+func EventInterval() time.Duration {
+	return time.Duration(eventInterval.Load())
+}
+
+func SetEventInterval(interval time.Duration) {
+	eventInterval.Store(int64(interval))
+}
+
+// UpdateValidatorsInterval is how often the cached validators are queried again.
+func UpdateValidatorsInterval() time.Duration {
+	return time.Duration(updateValidatorsInterval.Load())
+}
+
+func SetUpdateValidatorsInterval(interval time.Duration) {
+	updateValidatorsInterval.Store(int64(interval))
+}
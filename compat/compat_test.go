@@ -0,0 +1,80 @@
+package compat
+
+import (
+	"errors"
+	"testing"
+
+	tmversion "github.com/cometbft/cometbft/version"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+func TestRange(t *testing.T) {
+	r := Range{From: "v1.0.0", Below: "v1.2.0"}
+	require.True(t, r.Contains("v1.0.0"))
+	require.True(t, r.Contains("1.1.9"))
+	// a build after a tag counts as the tag
+	require.True(t, r.Contains("v1.0.0-12-gabcdef"))
+	require.False(t, r.Contains("v0.2.5"))
+	require.False(t, r.Contains("v1.2.0"))
+	require.False(t, r.Contains(""))
+	require.False(t, r.Contains("main"))
+}
+
+func TestParseMatrix(t *testing.T) {
+	_, err := ParseMatrix([]byte(`[{"name": "bad", "app_versions": {"from": "v2.0.0", "below": "v1.0.0"},
+		"node_versions": {"from": "v0.37.0", "below": "v0.38.0"}}]`))
+	require.EqualError(t, err, "entry bad has an invalid range [v2.0.0, v1.0.0)")
+	_, err = ParseMatrix([]byte(`[{"name": "bad", "app_versions": {"from": "1.0.0", "below": "v2.0.0"},
+		"node_versions": {"from": "v0.37.0", "below": "v0.38.0"}}]`))
+	require.Error(t, err)
+}
+
+func TestDefaultMatrix(t *testing.T) {
+	// the release supports the chain it is built against
+	entry, err := DefaultMatrix.Negotiate("v1.0.0", tmversion.TMCoreSemVer)
+	require.NoError(t, err)
+	require.Equal(t, "greenfield-v1", entry.Name)
+
+	_, err = DefaultMatrix.Negotiate("v2.0.0", tmversion.TMCoreSemVer)
+	require.True(t, errors.Is(err, ErrUnsupportedChain))
+}
+
+type fakeQuerier struct {
+	appVersion  string
+	nodeVersion string
+	err         error
+}
+
+func (q *fakeQuerier) GetNodeVersions() (string, string, error) {
+	return q.appVersion, q.nodeVersion, q.err
+}
+
+func TestGuard(t *testing.T) {
+	querier := &fakeQuerier{err: errors.New("node unavailable")}
+	cfg := &config.CompatConfig{}
+	guard := NewGuard(cfg, DefaultMatrix, querier, metrics.NewMetricService(&config.Config{}))
+	require.Error(t, guard.Check())
+	require.Equal(t, ErrNotChecked, guard.Allow())
+
+	querier.appVersion, querier.nodeVersion, querier.err = "v1.0.0", "0.37.2", nil
+	require.NoError(t, guard.Check())
+	require.NoError(t, guard.Allow())
+	require.Equal(t, "greenfield-v1", guard.Negotiated().Name)
+
+	// the chain is upgraded
+	querier.appVersion = "v2.0.0"
+	require.NoError(t, guard.Check())
+	require.True(t, errors.Is(guard.Allow(), ErrUnsupportedChain))
+	require.Nil(t, guard.Negotiated())
+
+	// failing to query keeps the outcome of the previous check
+	querier.err = errors.New("node unavailable")
+	require.Error(t, guard.Check())
+	require.True(t, errors.Is(guard.Allow(), ErrUnsupportedChain))
+
+	cfg.AllowUnsupported = true
+	require.NoError(t, guard.Allow())
+}
@@ -0,0 +1,8 @@
+package compat
+
+import "errors"
+
+var (
+	ErrNotChecked       = errors.New("the chain versions have not been checked yet")
+	ErrUnsupportedChain = errors.New("unsupported chain")
+)
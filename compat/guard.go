@@ -0,0 +1,104 @@
+package compat
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bnb-chain/greenfield-challenger/alert"
+	"github.com/bnb-chain/greenfield-challenger/common"
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+// VersionQuerier queries the versions of the chain.
+type VersionQuerier interface {
+	GetNodeVersions() (appVersion string, nodeVersion string, err error)
+}
+
+// Guard checks the versions of the chain against the compatibility matrix, the votes and the attestations are refused
+// until the chain is found supported.
+type Guard struct {
+	cfg           *config.CompatConfig
+	matrix        Matrix
+	querier       VersionQuerier
+	metricService *metrics.MetricService
+	mtx           sync.RWMutex
+	checked       bool
+	entry         *Entry // the entry negotiated by the last check, nil if the chain is unsupported
+	err           error  // why the chain is unsupported
+}
+
+func NewGuard(cfg *config.CompatConfig, matrix Matrix, querier VersionQuerier, metricService *metrics.MetricService,
+) *Guard {
+	return &Guard{
+		cfg:           cfg,
+		matrix:        matrix,
+		querier:       querier,
+		metricService: metricService,
+	}
+}
+
+// Allow returns why the challenger may not vote nor attest on the chain, it is nil once the chain is found supported
+// or if the unsupported chains are allowed.
+func (g *Guard) Allow() error {
+	if g.cfg.AllowUnsupported {
+		return nil
+	}
+	g.mtx.RLock()
+	defer g.mtx.RUnlock()
+	if !g.checked {
+		return ErrNotChecked
+	}
+	return g.err
+}
+
+// Negotiated returns the entry of the matrix supporting the chain, nil if the chain is unsupported or not checked yet.
+func (g *Guard) Negotiated() *Entry {
+	g.mtx.RLock()
+	defer g.mtx.RUnlock()
+	return g.entry
+}
+
+// Check queries the versions of the chain and negotiates the entry of the matrix supporting them. Failing to query
+// keeps the outcome of the previous check.
+func (g *Guard) Check() error {
+	appVersion, nodeVersion, err := g.querier.GetNodeVersions()
+	if err != nil {
+		logging.ExecutorLogger.Errorf("compat guard failed to query the chain versions, err=%+v", err.Error())
+		return err
+	}
+	entry, err := g.matrix.Negotiate(appVersion, nodeVersion)
+	g.mtx.Lock()
+	g.checked, g.entry, g.err = true, entry, err
+	g.mtx.Unlock()
+	if err != nil {
+		g.metricService.SetChainCompatible(false)
+		logging.ExecutorLogger.Errorf("compat guard found the chain unsupported, votes and attestations refused: %t, "+
+			"err=%+v", !g.cfg.AllowUnsupported, err.Error())
+		alert.UnsupportedChain(appVersion, nodeVersion, !g.cfg.AllowUnsupported)
+		return nil
+	}
+	g.metricService.SetChainCompatible(true)
+	logging.ExecutorLogger.Infof("compat guard negotiated %s, app version: %s, node version: %s", entry.Name,
+		appVersion, nodeVersion)
+	return nil
+}
+
+// CheckLoop checks the versions of the chain periodically, so that an upgrade of the chain is caught.
+func (g *Guard) CheckLoop(ctx context.Context) {
+	for {
+		interval := g.cfg.Interval()
+		g.mtx.RLock()
+		checked := g.checked
+		g.mtx.RUnlock()
+		if !checked {
+			// retried sooner until the first check succeeds, nothing is voted meanwhile
+			interval = common.RetryInterval()
+		}
+		if !common.Sleep(ctx, interval) {
+			return
+		}
+		_ = g.Check()
+	}
+}
@@ -0,0 +1,86 @@
+package compat
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+//go:embed matrix.json
+var matrixJson []byte
+
+// DefaultMatrix is the compatibility matrix embedded in the binary, it is updated with the releases supporting new
+// chain versions.
+var DefaultMatrix = mustParseMatrix(matrixJson)
+
+// Range is the versions from From, included, to Below, excluded.
+type Range struct {
+	From  string `json:"from"`
+	Below string `json:"below"`
+}
+
+// Contains reports whether version is in the range, a pre-release or a build of a version counts as the version.
+func (r Range) Contains(version string) bool {
+	v := canonical(version)
+	return v != "" && semver.Compare(v, r.From) >= 0 && semver.Compare(v, r.Below) < 0
+}
+
+func (r Range) String() string {
+	return fmt.Sprintf("[%s, %s)", r.From, r.Below)
+}
+
+// Entry is a combination of the greenfield app and the cometbft node versions supported by the challenger.
+type Entry struct {
+	Name         string `json:"name"`
+	AppVersions  Range  `json:"app_versions"`
+	NodeVersions Range  `json:"node_versions"`
+}
+
+type Matrix []Entry
+
+func ParseMatrix(bz []byte) (Matrix, error) {
+	var m Matrix
+	if err := json.Unmarshal(bz, &m); err != nil {
+		return nil, err
+	}
+	for _, e := range m {
+		for _, r := range []Range{e.AppVersions, e.NodeVersions} {
+			if !semver.IsValid(r.From) || !semver.IsValid(r.Below) || semver.Compare(r.From, r.Below) >= 0 {
+				return nil, fmt.Errorf("entry %s has an invalid range %s", e.Name, r)
+			}
+		}
+	}
+	return m, nil
+}
+
+func mustParseMatrix(bz []byte) Matrix {
+	m, err := ParseMatrix(bz)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Negotiate returns the entry supporting the app and the node versions, it is an ErrUnsupportedChain if none does.
+func (m Matrix) Negotiate(appVersion, nodeVersion string) (*Entry, error) {
+	for i := range m {
+		if m[i].AppVersions.Contains(appVersion) && m[i].NodeVersions.Contains(nodeVersion) {
+			return &m[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w, app version %q and node version %q are not in the compatibility matrix",
+		ErrUnsupportedChain, appVersion, nodeVersion)
+}
+
+// canonical returns the semantic version of version without its pre-release, e.g. v1.0.0 for 1.0.0-12-gabcdef, it is
+// empty if version is not a semantic version.
+func canonical(version string) string {
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	v := semver.Canonical(version)
+	return strings.TrimSuffix(v, semver.Prerelease(v))
+}
@@ -1,26 +1,90 @@
 package config
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
 	"cosmossdk.io/math"
 )
 
+var (
+	// tablePrefixRegexp restricts table prefixes to characters which are safe to use in unquoted table names.
+	tablePrefixRegexp = regexp.MustCompile(`^[A-Za-z0-9_]*$`)
+	privateKeyRegexp  = regexp.MustCompile(`^(0x)?[0-9a-fA-F]{64}$`)
+	chainIdRegexp     = regexp.MustCompile(`^[a-z0-9_]+_[0-9]+-[0-9]+$`)
+	denomRegexp       = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9/:._-]{2,127}$`)
+	addressRegexp     = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	blsPubKeyRegexp   = regexp.MustCompile(`^[0-9a-fA-F]{96}$`)
+
+	greenfieldKeyTypes = []string{KeyTypeLocalPrivateKey, KeyTypeAWSPrivateKey, KeyTypeGCPPrivateKey, KeyTypeAzurePrivateKey,
+		KeyTypeKeystore}
+)
+
 type Config struct {
+	Version          int              `json:"version"`
 	GreenfieldConfig GreenfieldConfig `json:"greenfield_config"`
 	LogConfig        LogConfig        `json:"log_config"`
 	AlertConfig      AlertConfig      `json:"alert_config"`
 	DBConfig         DBConfig         `json:"db_config"`
 	MetricsConfig    MetricsConfig    `json:"metrics_config"`
+	TunableConfig    TunableConfig    `json:"tunable_config"`
+	PipelineConfig   PipelineConfig   `json:"pipeline_config"`
+	TracingConfig    TracingConfig    `json:"tracing_config"`
+	DebugConfig      DebugConfig      `json:"debug_config"`
+	AdminConfig      AdminConfig      `json:"admin_config"`
+	AuditConfig      AuditConfig      `json:"audit_config"`
+	SlaConfig        SlaConfig        `json:"sla_config"`
+	ProactiveConfig  ProactiveConfig  `json:"proactive_config"`
+	ReputationConfig ReputationConfig `json:"reputation_config"`
+	SamplingConfig   SamplingConfig   `json:"sampling_config"`
+	QueryApiConfig   QueryApiConfig   `json:"query_api_config"`
+	HaConfig         HaConfig         `json:"ha_config"`
+	ShardConfig      ShardConfig      `json:"shard_config"`
+	SentryConfig     SentryConfig     `json:"sentry_config"`
+	PluginConfig     PluginConfig     `json:"plugin_config"`
+	WebhookConfig    WebhookConfig    `json:"webhook_config"`
+	StreamConfig     StreamConfig     `json:"stream_config"`
+	FederationConfig FederationConfig `json:"federation_config"`
+	CompatConfig     CompatConfig     `json:"compat_config"`
+	RelayConfig      RelayConfig      `json:"relay_config"`
+	EconomicsConfig  EconomicsConfig  `json:"economics_config"`
+	AnalyticsConfig  AnalyticsConfig  `json:"analytics_config"`
+	StatusPageConfig StatusPageConfig `json:"status_page_config"`
+	JobQueueConfig   JobQueueConfig   `json:"job_queue_config"`
+	IndexerConfig    IndexerConfig    `json:"indexer_config"`
+	ScannerConfig    ScannerConfig    `json:"scanner_config"`
+	VoteProxyConfig  VoteProxyConfig  `json:"vote_proxy_config"`
+
+	warnings []string // raised while parsing, e.g. by the schema migration
+}
+
+// Warnings returns the problems raised while parsing the config which did not stop it from loading.
+func (cfg *Config) Warnings() []string {
+	return cfg.warnings
 }
 
 type GreenfieldConfig struct {
+	// Network names a built-in network profile which fills the chain id, rpc addrs and fee settings left empty
+	Network          string   `json:"network"`
 	KeyType          string   `json:"key_type"`
 	AWSRegion        string   `json:"aws_region"`
 	AWSSecretName    string   `json:"aws_secret_name"`
 	AWSBlsSecretName string   `json:"aws_bls_secret_name"`
+	GCPProjectId     string   `json:"gcp_project_id"`
+	AzureVaultURL    string   `json:"azure_vault_url"`
+	AzureClientId    string   `json:"azure_client_id"`
+	SecretName       string   `json:"secret_name"`       // secret holding the private key for gcp and azure
+	BlsSecretName    string   `json:"bls_secret_name"`   // secret holding the bls private key for gcp and azure
+	KeystorePath     string   `json:"keystore_path"`     // armored private key for keystore
+	BlsKeystorePath  string   `json:"bls_keystore_path"` // armored bls private key for keystore
 	PrivateKey       string   `json:"private_key"`
 	BlsPrivateKey    string   `json:"bls_private_key"`
 	RPCAddrs         []string `json:"rpc_addrs"`
@@ -28,54 +92,196 @@ type GreenfieldConfig struct {
 	GasLimit         uint64   `json:"gas_limit"`
 	FeeAmount        string   `json:"fee_amount"`
 	FeeDenom         string   `json:"fee_denom"`
+	// StartHeight is the first block polled with a fresh database, the latest block is used if it is 0
+	StartHeight uint64 `json:"start_height"`
+	// KeyRefreshIntervalInSeconds is how often the keys are fetched again from the secret manager to pick up rotated
+	// keys, 0 disables the refresh
+	KeyRefreshIntervalInSeconds uint64 `json:"key_refresh_interval_in_seconds"`
+	// SpEndpointOverrides maps sp operator addresses to endpoints used instead of the on-chain endpoints, e.g. internal
+	// mirrors
+	SpEndpointOverrides map[string]string `json:"sp_endpoint_overrides"`
+	// Identities are the challengers of other validators run by the same process, they vote and attest with their own
+	// keys but share the monitor and the verifier
+	Identities []IdentityConfig `json:"identities"`
+}
+
+// IdentityConfig holds the keys of an additional challenger identity, they are read the same way as the keys of the
+// greenfield config, according to its key_type. It is tagged for yaml as well, being written as a whole by WriteConfig.
+type IdentityConfig struct {
+	Name            string `json:"name" yaml:"name"`
+	SecretName      string `json:"secret_name" yaml:"secret_name"`         // secret holding the private key for aws, gcp and azure
+	BlsSecretName   string `json:"bls_secret_name" yaml:"bls_secret_name"` // secret holding the bls private key for aws, gcp and azure
+	KeystorePath    string `json:"keystore_path" yaml:"keystore_path"`
+	BlsKeystorePath string `json:"bls_keystore_path" yaml:"bls_keystore_path"`
+	PrivateKey      string `json:"private_key" yaml:"private_key"`
+	BlsPrivateKey   string `json:"bls_private_key" yaml:"bls_private_key"`
+}
+
+// ForIdentity returns a copy of the config whose keys are the keys of identity.
+func (cfg *GreenfieldConfig) ForIdentity(identity *IdentityConfig) *GreenfieldConfig {
+	c := *cfg
+	c.AWSSecretName, c.AWSBlsSecretName = identity.SecretName, identity.BlsSecretName
+	c.SecretName, c.BlsSecretName = identity.SecretName, identity.BlsSecretName
+	c.KeystorePath, c.BlsKeystorePath = identity.KeystorePath, identity.BlsKeystorePath
+	c.PrivateKey, c.BlsPrivateKey = identity.PrivateKey, identity.BlsPrivateKey
+	c.Identities = nil
+	return &c
+}
+
+// UsesSecretProvider returns whether the keys are read from a secret manager instead of the config.
+func (cfg *GreenfieldConfig) UsesSecretProvider() bool {
+	return cfg.KeyType == KeyTypeAWSPrivateKey || cfg.KeyType == KeyTypeGCPPrivateKey || cfg.KeyType == KeyTypeAzurePrivateKey
+}
+
+// SpEndpointOverride returns the endpoint overriding the on-chain endpoint of the sp operator address.
+func (cfg *GreenfieldConfig) SpEndpointOverride(address string) (string, bool) {
+	for addr, endpoint := range cfg.SpEndpointOverrides {
+		if strings.EqualFold(addr, address) {
+			return endpoint, true
+		}
+	}
+	return "", false
+}
+
+// KeySecretNames returns the names of the secrets holding the private key and the bls private key.
+func (cfg *GreenfieldConfig) KeySecretNames() (string, string) {
+	if cfg.KeyType == KeyTypeAWSPrivateKey {
+		return cfg.AWSSecretName, cfg.AWSBlsSecretName
+	}
+	return cfg.SecretName, cfg.BlsSecretName
 }
 
 func (cfg *GreenfieldConfig) Validate() {
-	if cfg.KeyType == "" {
-		panic("key_type should not be empty")
-	} else if cfg.KeyType == "aws_private_key" {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *GreenfieldConfig) validate() validationErrors {
+	errs := validationErrors{}
+	switch cfg.KeyType {
+	case "":
+		errs.add("greenfield_config.key_type", "should not be empty, use one of %s", strings.Join(greenfieldKeyTypes, ", "))
+	case KeyTypeAWSPrivateKey:
 		if cfg.AWSRegion == "" {
-			panic("aws_region should not be empty")
+			errs.add("greenfield_config.aws_region", "should not be empty for key_type %s", cfg.KeyType)
 		}
 		if cfg.AWSSecretName == "" {
-			panic("aws_secret_name should not be empty")
+			errs.add("greenfield_config.aws_secret_name", "should not be empty for key_type %s", cfg.KeyType)
 		}
 		if cfg.AWSBlsSecretName == "" {
-			panic("aws_bls_secret_name should not be empty")
+			errs.add("greenfield_config.aws_bls_secret_name", "should not be empty for key_type %s", cfg.KeyType)
+		}
+	case KeyTypeGCPPrivateKey, KeyTypeAzurePrivateKey:
+		if cfg.KeyType == KeyTypeGCPPrivateKey && cfg.GCPProjectId == "" {
+			errs.add("greenfield_config.gcp_project_id", "should not be empty for key_type %s", cfg.KeyType)
+		}
+		if cfg.KeyType == KeyTypeAzurePrivateKey {
+			if err := validateURL(cfg.AzureVaultURL); err != nil {
+				errs.add("greenfield_config.azure_vault_url", "%s", err.Error())
+			}
+		}
+		if cfg.SecretName == "" {
+			errs.add("greenfield_config.secret_name", "should not be empty for key_type %s", cfg.KeyType)
 		}
-	} else if cfg.KeyType == "local_private_key" {
-		if cfg.PrivateKey == "" {
-			panic("private_key should not be empty")
+		if cfg.BlsSecretName == "" {
+			errs.add("greenfield_config.bls_secret_name", "should not be empty for key_type %s", cfg.KeyType)
 		}
-		if cfg.BlsPrivateKey == "" {
-			panic("bls_private_key should not be empty")
+	case KeyTypeKeystore:
+		if cfg.KeystorePath == "" {
+			errs.add("greenfield_config.keystore_path", "should not be empty for key_type %s", cfg.KeyType)
 		}
-	} else {
-		panic(fmt.Sprintf("key_type %s is not supported", cfg.KeyType))
+		if cfg.BlsKeystorePath == "" {
+			errs.add("greenfield_config.bls_keystore_path", "should not be empty for key_type %s", cfg.KeyType)
+		}
+	case KeyTypeLocalPrivateKey:
+		if !privateKeyRegexp.MatchString(cfg.PrivateKey) {
+			errs.add("greenfield_config.private_key", "should be a hex encoded 32 bytes key for key_type %s", cfg.KeyType)
+		}
+		if !privateKeyRegexp.MatchString(cfg.BlsPrivateKey) {
+			errs.add("greenfield_config.bls_private_key", "should be a hex encoded 32 bytes key for key_type %s", cfg.KeyType)
+		}
+	default:
+		errs.add("greenfield_config.key_type", "%s is not supported, use one of %s", cfg.KeyType, strings.Join(greenfieldKeyTypes, ", "))
 	}
 
-	if cfg.RPCAddrs == nil || len(cfg.RPCAddrs) == 0 {
-		panic("rpc_addrs should not be empty")
+	names := make(map[string]bool)
+	for i, identity := range cfg.Identities {
+		field := fmt.Sprintf("greenfield_config.identities[%d]", i)
+		if identity.Name == "" {
+			errs.add(field+".name", "should not be empty")
+		} else if names[identity.Name] {
+			errs.add(field+".name", "%q is used by another identity", identity.Name)
+		}
+		names[identity.Name] = true
+		errs = append(errs, identity.validate(field, cfg.KeyType)...)
 	}
-	if cfg.ChainIdString == "" {
-		panic("chain_id_string should not be empty")
+
+	if len(cfg.RPCAddrs) == 0 {
+		errs.add("greenfield_config.rpc_addrs", "should not be empty")
 	}
-	if cfg.GasLimit == 0 {
-		panic("gas_limit should not be 0")
+	for i, addr := range cfg.RPCAddrs {
+		if err := validateURL(addr); err != nil {
+			errs.add(fmt.Sprintf("greenfield_config.rpc_addrs[%d]", i), "%s", err.Error())
+		}
 	}
-	if cfg.FeeAmount == "" {
-		panic("fee_amount should not be empty")
+	if !chainIdRegexp.MatchString(cfg.ChainIdString) {
+		errs.add("greenfield_config.chain_id_string", "%q should look like greenfield_1017-1", cfg.ChainIdString)
 	}
-	if cfg.FeeDenom == "" {
-		panic("fee_denom should not be empty")
+	for addr, endpoint := range cfg.SpEndpointOverrides {
+		if !addressRegexp.MatchString(addr) {
+			errs.add("greenfield_config.sp_endpoint_overrides", "%q is not a hex address", addr)
+		}
+		if err := validateURL(endpoint); err != nil {
+			errs.add(fmt.Sprintf("greenfield_config.sp_endpoint_overrides.%s", addr), "%s", err.Error())
+		}
+	}
+	if cfg.Network != "" {
+		if profile, ok := GetNetworkProfile(cfg.Network); !ok {
+			errs.add("greenfield_config.network", "unknown network %q, use one of %s", cfg.Network, strings.Join(NetworkNames(), ", "))
+		} else if cfg.ChainIdString != profile.ChainIdString {
+			errs.add("greenfield_config.chain_id_string", "%q does not match the chain id %s of network %s",
+				cfg.ChainIdString, profile.ChainIdString, cfg.Network)
+		}
 	}
-	feeAmount, ok := math.NewIntFromString(cfg.FeeAmount)
-	if !ok {
-		panic("error converting fee_amount to math.Int")
+	if cfg.GasLimit == 0 {
+		errs.add("greenfield_config.gas_limit", "should not be 0")
+	}
+	if feeAmount, ok := math.NewIntFromString(cfg.FeeAmount); !ok {
+		errs.add("greenfield_config.fee_amount", "%q should be an integer", cfg.FeeAmount)
+	} else if !feeAmount.IsPositive() {
+		errs.add("greenfield_config.fee_amount", "should be positive")
 	}
-	if !feeAmount.IsPositive() {
-		panic("fee_amount should not be negative")
+	if !denomRegexp.MatchString(cfg.FeeDenom) {
+		errs.add("greenfield_config.fee_denom", "%q is not a valid denom, e.g. BNB", cfg.FeeDenom)
+	}
+	return errs
+}
+
+func (cfg *IdentityConfig) validate(field, keyType string) validationErrors {
+	errs := validationErrors{}
+	switch keyType {
+	case KeyTypeAWSPrivateKey, KeyTypeGCPPrivateKey, KeyTypeAzurePrivateKey:
+		if cfg.SecretName == "" {
+			errs.add(field+".secret_name", "should not be empty for key_type %s", keyType)
+		}
+		if cfg.BlsSecretName == "" {
+			errs.add(field+".bls_secret_name", "should not be empty for key_type %s", keyType)
+		}
+	case KeyTypeKeystore:
+		if cfg.KeystorePath == "" {
+			errs.add(field+".keystore_path", "should not be empty for key_type %s", keyType)
+		}
+		if cfg.BlsKeystorePath == "" {
+			errs.add(field+".bls_keystore_path", "should not be empty for key_type %s", keyType)
+		}
+	case KeyTypeLocalPrivateKey:
+		if !privateKeyRegexp.MatchString(cfg.PrivateKey) {
+			errs.add(field+".private_key", "should be a hex encoded 32 bytes key for key_type %s", keyType)
+		}
+		if !privateKeyRegexp.MatchString(cfg.BlsPrivateKey) {
+			errs.add(field+".bls_private_key", "should be a hex encoded 32 bytes key for key_type %s", keyType)
+		}
 	}
+	return errs
 }
 
 type LogConfig struct {
@@ -87,20 +293,65 @@ type LogConfig struct {
 	UseConsoleLogger             bool   `json:"use_console_logger"`
 	UseFileLogger                bool   `json:"use_file_logger"`
 	Compress                     bool   `json:"compress"`
+	// ModuleLevels overrides the level of single components, e.g. {"vote": "WARNING"}
+	ModuleLevels map[string]string `json:"module_levels"`
+	// Format is text or json, json records carry the challenge fields, e.g. challenge_id, as separate keys
+	Format string `json:"format"`
+	// UseSyslogLogger logs to the local syslog daemon, or to SyslogAddress over SyslogNetwork if set
+	UseSyslogLogger bool   `json:"use_syslog_logger"`
+	SyslogNetwork   string `json:"syslog_network"`
+	SyslogAddress   string `json:"syslog_address"`
+	SyslogTag       string `json:"syslog_tag"`
 }
 
 func (cfg *LogConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *LogConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if !isLogLevel(cfg.Level) {
+		errs.add("log_config.level", "%q should be one of %s", cfg.Level, strings.Join(LogLevels, ", "))
+	}
+	for module, level := range cfg.ModuleLevels {
+		if !isLogModule(module) {
+			errs.add("log_config.module_levels", "unknown module %q, use one of %s", module, strings.Join(LogModules, ", "))
+		} else if !isLogLevel(level) {
+			errs.add(fmt.Sprintf("log_config.module_levels.%s", module), "%q should be one of %s", level, strings.Join(LogLevels, ", "))
+		}
+	}
+	if cfg.Format != "" && cfg.Format != LogFormatText && cfg.Format != LogFormatJson {
+		errs.add("log_config.format", "%q should be one of %s, %s", cfg.Format, LogFormatText, LogFormatJson)
+	}
 	if cfg.UseFileLogger {
 		if cfg.Filename == "" {
-			panic("filename should not be empty if use file logger")
+			errs.add("log_config.filename", "should not be empty if use file logger")
 		}
 		if cfg.MaxFileSizeInMB <= 0 {
-			panic("max_file_size_in_mb should be larger than 0 if use file logger")
+			errs.add("log_config.max_file_size_in_mb", "should be larger than 0 if use file logger")
 		}
 		if cfg.MaxBackupsOfLogFiles <= 0 {
-			panic("max_backups_off_log_files should be larger than 0 if use file logger")
+			errs.add("log_config.max_backups_of_log_files", "should be larger than 0 if use file logger")
+		}
+		if cfg.MaxAgeToRetainLogFilesInDays < 0 {
+			errs.add("log_config.max_age_to_retain_log_files_in_days", "should not be negative")
 		}
 	}
+	if cfg.UseSyslogLogger {
+		switch cfg.SyslogNetwork {
+		case "":
+			if cfg.SyslogAddress != "" {
+				errs.add("log_config.syslog_network", "should be %s or %s if syslog_address is set", SyslogNetworkUdp, SyslogNetworkTcp)
+			}
+		case SyslogNetworkUdp, SyslogNetworkTcp:
+			if cfg.SyslogAddress == "" {
+				errs.add("log_config.syslog_address", "should not be empty if syslog_network is set")
+			}
+		default:
+			errs.add("log_config.syslog_network", "%q should be empty for the local daemon, %s or %s", cfg.SyslogNetwork, SyslogNetworkUdp, SyslogNetworkTcp)
+		}
+	}
+	return errs
 }
 
 type DBConfig struct {
@@ -114,43 +365,1287 @@ type DBConfig struct {
 	MaxIdleConns  int    `json:"max_idle_conns"`
 	MaxOpenConns  int    `json:"max_open_conns"`
 	DebugMode     bool   `json:"debug_mode"`
+	// QueryTimeout bounds every DAO query in seconds, the dao default is used when it is not set
+	QueryTimeout int64 `json:"query_timeout"`
+	// TablePrefix is prepended to all table names, so that several challengers can share one database
+	TablePrefix string `json:"table_prefix"`
+	// SlowQueryThresholdInMs logs the statements slower than it as warnings, 0 does not log them
+	SlowQueryThresholdInMs int64 `json:"slow_query_threshold_in_ms"`
+	// DisablePreparedStatements runs the hot queries without preparing them, e.g. behind a pooler which does not
+	// support prepared statements
+	DisablePreparedStatements bool `json:"disable_prepared_statements"`
 }
 
 func (cfg *DBConfig) Validate() {
-	if cfg.Dialect != DBDialectMysql && cfg.Dialect != DBDialectSqlite3 {
-		panic(fmt.Sprintf("only %s and %s supported", DBDialectMysql, DBDialectSqlite3))
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *DBConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if !contains(DBDialects, cfg.Dialect) {
+		errs.add("db_config.dialect", "%q is not supported, use one of %s", cfg.Dialect, strings.Join(DBDialects, ", "))
+	}
+	if cfg.DBPath == "" {
+		errs.add("db_config.db_path", "should not be empty")
+	}
+	if cfg.HasCredentials() && cfg.Username == "" {
+		errs.add("db_config.username", "should not be empty for dialect %s", cfg.Dialect)
+	}
+	if cfg.HasCredentials() && cfg.KeyType == KeyTypeAWSPrivateKey && (cfg.AWSRegion == "" || cfg.AWSSecretName == "") {
+		errs.add("db_config.aws_region", "aws_region and aws_secret_name should not be empty for key_type %s", cfg.KeyType)
+	}
+	if cfg.MaxIdleConns < 0 {
+		errs.add("db_config.max_idle_conns", "should not be negative")
 	}
-	if cfg.Username == "" || cfg.DBPath == "" {
-		panic("db config is not correct")
+	if cfg.MaxOpenConns < 0 {
+		errs.add("db_config.max_open_conns", "should not be negative")
 	}
+	if cfg.MaxOpenConns > 0 && cfg.MaxIdleConns > cfg.MaxOpenConns {
+		errs.add("db_config.max_idle_conns", "should not be larger than max_open_conns %d", cfg.MaxOpenConns)
+	}
+	if cfg.QueryTimeout < 0 {
+		errs.add("db_config.query_timeout", "should not be negative")
+	}
+	if cfg.SlowQueryThresholdInMs < 0 {
+		errs.add("db_config.slow_query_threshold_in_ms", "should not be negative")
+	}
+	if !tablePrefixRegexp.MatchString(cfg.TablePrefix) {
+		errs.add("db_config.table_prefix", "%q should only contain letters, digits and underscores", cfg.TablePrefix)
+	}
+	return errs
+}
+
+// HasCredentials returns whether the database is a server which the challenger logs in to, unlike sqlite3.
+func (cfg *DBConfig) HasCredentials() bool {
+	return cfg.Dialect == DBDialectMysql || cfg.Dialect == DBDialectPostgres
+}
+
+// IsInMemory returns whether the database only lives in memory, which is meant for tests and e2e runs.
+func (cfg *DBConfig) IsInMemory() bool {
+	return cfg.Dialect == DBDialectSqlite3 &&
+		(cfg.DBPath == DBPathInMemory || strings.Contains(cfg.DBPath, "mode=memory"))
 }
 
 type MetricsConfig struct {
 	Port uint16 `json:"port"`
+	// StatsdAddress is the host:port of a statsd or dogstatsd agent the metrics are pushed to over udp, in addition to
+	// /metrics, pushing is off if it is empty
+	StatsdAddress string `json:"statsd_address"`
+	// StatsdFlavor is statsd, which folds the labels into the metric names, or dogstatsd, which sends them as tags
+	StatsdFlavor            string `json:"statsd_flavor"`
+	StatsdPrefix            string `json:"statsd_prefix"`
+	StatsdIntervalInSeconds int64  `json:"statsd_interval_in_seconds"`
+}
+
+func (cfg *MetricsConfig) StatsdEnabled() bool {
+	return cfg.StatsdAddress != ""
 }
 
 func (cfg *MetricsConfig) Validate() {
-	if cfg.Port <= 0 || cfg.Port > 65535 {
-		panic("port should be within (0, 65535]")
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *MetricsConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if cfg.Port == 0 {
+		errs.add("metrics_config.port", "should be within (0, 65535]")
+	}
+	if cfg.StatsdEnabled() {
+		if _, _, err := net.SplitHostPort(cfg.StatsdAddress); err != nil {
+			errs.add("metrics_config.statsd_address", "should be host:port, got %q", cfg.StatsdAddress)
+		}
+		if !contains([]string{StatsdFlavorStatsd, StatsdFlavorDogStatsd}, cfg.StatsdFlavor) {
+			errs.add("metrics_config.statsd_flavor", "should be %s or %s, got %q", StatsdFlavorStatsd,
+				StatsdFlavorDogStatsd, cfg.StatsdFlavor)
+		}
+		if cfg.StatsdIntervalInSeconds <= 0 {
+			errs.add("metrics_config.statsd_interval_in_seconds", "should be positive")
+		}
+	}
+	return errs
+}
+
+// TunableConfig holds the settings which can be reloaded while the challenger is running, zero values keep the
+// defaults.
+type TunableConfig struct {
+	RetryIntervalInMs  int64 `json:"retry_interval_in_ms"`
+	RetryAttempts      uint  `json:"retry_attempts"`
+	RetryDelayInMs     int64 `json:"retry_delay_in_ms"`
+	SpTimeoutInSeconds int64 `json:"sp_timeout_in_seconds"`
+	// MaxSpRequests caps the challenge requests in flight to the storage providers, 0 for no cap
+	MaxSpRequests int `json:"max_sp_requests"`
+	// EventIntervalInMs paces the vote collator between two events
+	EventIntervalInMs                 int64 `json:"event_interval_in_ms"`
+	UpdateValidatorsIntervalInSeconds int64 `json:"update_validators_interval_in_seconds"`
+}
+
+func (cfg *TunableConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *TunableConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if cfg.RetryIntervalInMs < 0 || cfg.RetryIntervalInMs > MaxRetryIntervalInMs {
+		errs.add("tunable_config.retry_interval_in_ms", "should be within [0, %d]", MaxRetryIntervalInMs)
+	}
+	if cfg.RetryAttempts > MaxRetryAttempts {
+		errs.add("tunable_config.retry_attempts", "should not be larger than %d", MaxRetryAttempts)
+	}
+	if cfg.RetryDelayInMs < 0 || cfg.RetryDelayInMs > MaxRetryIntervalInMs {
+		errs.add("tunable_config.retry_delay_in_ms", "should be within [0, %d]", MaxRetryIntervalInMs)
+	}
+	if cfg.SpTimeoutInSeconds < 0 || cfg.SpTimeoutInSeconds > MaxSpTimeoutInSeconds {
+		errs.add("tunable_config.sp_timeout_in_seconds", "should be within [0, %d]", MaxSpTimeoutInSeconds)
+	}
+	if cfg.MaxSpRequests < 0 || cfg.MaxSpRequests > MaxSpRequestsLimit {
+		errs.add("tunable_config.max_sp_requests", "should be within [0, %d]", MaxSpRequestsLimit)
+	}
+	if cfg.EventIntervalInMs < 0 || cfg.EventIntervalInMs > MaxRetryIntervalInMs {
+		errs.add("tunable_config.event_interval_in_ms", "should be within [0, %d]", MaxRetryIntervalInMs)
+	}
+	if cfg.UpdateValidatorsIntervalInSeconds < 0 || cfg.UpdateValidatorsIntervalInSeconds > MaxUpdateValidatorsIntervalInSeconds {
+		errs.add("tunable_config.update_validators_interval_in_seconds", "should be within [0, %d]",
+			MaxUpdateValidatorsIntervalInSeconds)
+	}
+	return errs
+}
+
+// PipelineConfig holds the pipeline settings which need a restart to change.
+type PipelineConfig struct {
+	// CacheSize bounds the caches of the recently handled challenges in the verifier, broadcaster and submitter
+	CacheSize int `json:"cache_size"`
+	// ShutdownTimeoutInSeconds bounds the graceful shutdown, the challenger exits once it passes even if stages are
+	// still draining
+	ShutdownTimeoutInSeconds int64 `json:"shutdown_timeout_in_seconds"`
+	// Components lists the stages run by this challenger, all of them if it is empty. The stages hand the events over
+	// through the database, so a split deployment runs them on separate hosts sharing one database.
+	Components []string `json:"components"`
+	// DryRun keeps the reads and the database writes but does not broadcast the votes nor send the attest
+	// transactions, they are logged instead, to shadow a production challenger with an upgrade
+	DryRun bool `json:"dry_run"`
+	// BroadcasterWorkers and CollatorWorkers are the events voted and collated concurrently, the monitor saves the
+	// blocks in order with a single worker
+	BroadcasterWorkers int `json:"broadcaster_workers"`
+	CollatorWorkers    int `json:"collator_workers"`
+	// QueueSize bounds the items fetched ahead of the workers of a stage, e.g. the blocks prefetched by the monitor
+	QueueSize int `json:"queue_size"`
+	// SpResponseCacheBlocks is how many blocks the verifier reuses the response of a storage provider to a piece, so
+	// that a piece challenged again meanwhile is not requested twice, 0 to request every challenge
+	SpResponseCacheBlocks uint64 `json:"sp_response_cache_blocks"`
+}
+
+// BroadcasterConcurrency returns the workers of the broadcaster, DefaultPipelineBroadcasterWorkers if not set.
+func (cfg *PipelineConfig) BroadcasterConcurrency() int {
+	if cfg.BroadcasterWorkers == 0 {
+		return DefaultPipelineBroadcasterWorkers
+	}
+	return cfg.BroadcasterWorkers
+}
+
+// CollatorConcurrency returns the workers of the collator, DefaultPipelineCollatorWorkers if not set.
+func (cfg *PipelineConfig) CollatorConcurrency() int {
+	if cfg.CollatorWorkers == 0 {
+		return DefaultPipelineCollatorWorkers
+	}
+	return cfg.CollatorWorkers
+}
+
+// Queue returns the queue size of the stages, DefaultPipelineQueueSize if not set.
+func (cfg *PipelineConfig) Queue() int {
+	if cfg.QueueSize == 0 {
+		return DefaultPipelineQueueSize
+	}
+	return cfg.QueueSize
+}
+
+// ComponentEnabled reports whether this challenger runs the component.
+func (cfg *PipelineConfig) ComponentEnabled(component string) bool {
+	return len(cfg.Components) == 0 || contains(cfg.Components, component)
+}
+
+func (cfg *PipelineConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *PipelineConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if cfg.CacheSize <= 0 || cfg.CacheSize > MaxCacheSize {
+		errs.add("pipeline_config.cache_size", "should be within (0, %d]", MaxCacheSize)
+	}
+	if cfg.ShutdownTimeoutInSeconds <= 0 {
+		errs.add("pipeline_config.shutdown_timeout_in_seconds", "should be positive")
+	}
+	for _, component := range cfg.Components {
+		if !contains(Components, component) {
+			errs.add("pipeline_config.components", "unknown component %q, use %s", component, strings.Join(Components, ", "))
+		}
+	}
+	if cfg.BroadcasterWorkers < 0 || cfg.BroadcasterWorkers > MaxPipelineWorkers {
+		errs.add("pipeline_config.broadcaster_workers", "should be within [0, %d]", MaxPipelineWorkers)
+	}
+	if cfg.CollatorWorkers < 0 || cfg.CollatorWorkers > MaxPipelineWorkers {
+		errs.add("pipeline_config.collator_workers", "should be within [0, %d]", MaxPipelineWorkers)
+	}
+	if cfg.QueueSize < 0 || cfg.QueueSize > MaxPipelineQueueSize {
+		errs.add("pipeline_config.queue_size", "should be within [0, %d]", MaxPipelineQueueSize)
+	}
+	if cfg.SpResponseCacheBlocks > MaxSpResponseCacheBlocks {
+		errs.add("pipeline_config.sp_response_cache_blocks", "should be within [0, %d]", MaxSpResponseCacheBlocks)
+	}
+	return errs
+}
+
+// TracingConfig sets up the export of the challenge traces, tracing is off if OtlpEndpoint is empty.
+type TracingConfig struct {
+	// OtlpEndpoint is the host:port of the otlp http collector, e.g. localhost:4318
+	OtlpEndpoint string `json:"otlp_endpoint"`
+	Insecure     bool   `json:"insecure"`
+	// SampleRatio is the share of the challenges which are traced
+	SampleRatio float64 `json:"sample_ratio"`
+}
+
+// Enabled returns whether the challenge traces are exported.
+func (cfg *TracingConfig) Enabled() bool {
+	return cfg.OtlpEndpoint != ""
+}
+
+func (cfg *TracingConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *TracingConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if strings.Contains(cfg.OtlpEndpoint, "://") {
+		errs.add("tracing_config.otlp_endpoint", "should be host:port without a scheme, use insecure for plain http")
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		errs.add("tracing_config.sample_ratio", "should be within [0, 1]")
+	}
+	return errs
+}
+
+// DebugConfig exposes the pprof and expvar endpoints on the metrics port, to diagnose a live challenger.
+type DebugConfig struct {
+	Enabled bool `json:"enabled"`
+	// AuthToken is required as bearer token by the debug endpoints if set
+	AuthToken string `json:"auth_token"`
+}
+
+func (cfg *DebugConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *DebugConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if cfg.AuthToken != "" && len(cfg.AuthToken) < MinAuthTokenLength {
+		errs.add("debug_config.auth_token", "should be at least %d characters", MinAuthTokenLength)
 	}
+	return errs
+}
+
+// AdminConfig serves the admin api, which inspects and repairs the events, on its own port. The api changes the
+// pipeline, so it requires a bearer token, client certificates signed by ClientCaFile (mTLS), or both.
+type AdminConfig struct {
+	// Port of the admin api, it is off if 0
+	Port         uint16 `json:"port"`
+	AuthToken    string `json:"auth_token"`
+	TlsCertFile  string `json:"tls_cert_file"`
+	TlsKeyFile   string `json:"tls_key_file"`
+	ClientCaFile string `json:"client_ca_file"`
+}
+
+func (cfg *AdminConfig) Enabled() bool {
+	return cfg.Port != 0
 }
 
+// TlsEnabled reports whether the admin api is served over https.
+func (cfg *AdminConfig) TlsEnabled() bool {
+	return cfg.TlsCertFile != ""
+}
+
+func (cfg *AdminConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *AdminConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if !cfg.Enabled() {
+		return errs
+	}
+	if cfg.AuthToken == "" && cfg.ClientCaFile == "" {
+		errs.add("admin_config.auth_token", "auth_token or client_ca_file should be set if the admin api is enabled")
+	}
+	if cfg.AuthToken != "" && len(cfg.AuthToken) < MinAuthTokenLength {
+		errs.add("admin_config.auth_token", "should be at least %d characters", MinAuthTokenLength)
+	}
+	if (cfg.TlsCertFile == "") != (cfg.TlsKeyFile == "") {
+		errs.add("admin_config.tls_key_file", "tls_cert_file and tls_key_file should be set together")
+	}
+	if cfg.ClientCaFile != "" && !cfg.TlsEnabled() {
+		errs.add("admin_config.client_ca_file", "requires tls_cert_file and tls_key_file")
+	}
+	return errs
+}
+
+// Validate checks the whole config and panics with all the field level problems at once, so that a misconfigured
+// challenger fails fast at startup instead of deep in the executor.
 func (cfg *Config) Validate() {
-	cfg.LogConfig.Validate()
-	cfg.DBConfig.Validate()
-	cfg.GreenfieldConfig.Validate()
-	cfg.MetricsConfig.Validate()
+	errs := validationErrors{}
+	errs = append(errs, cfg.GreenfieldConfig.validate()...)
+	errs = append(errs, cfg.LogConfig.validate()...)
+	errs = append(errs, cfg.AlertConfig.validate()...)
+	errs = append(errs, cfg.DBConfig.validate()...)
+	errs = append(errs, cfg.MetricsConfig.validate()...)
+	errs = append(errs, cfg.TunableConfig.validate()...)
+	errs = append(errs, cfg.PipelineConfig.validate()...)
+	errs = append(errs, cfg.TracingConfig.validate()...)
+	errs = append(errs, cfg.DebugConfig.validate()...)
+	errs = append(errs, cfg.AdminConfig.validate()...)
+	errs = append(errs, cfg.SlaConfig.validate()...)
+	errs = append(errs, cfg.ProactiveConfig.validate()...)
+	errs = append(errs, cfg.ReputationConfig.validate()...)
+	errs = append(errs, cfg.SamplingConfig.validate()...)
+	errs = append(errs, cfg.QueryApiConfig.validate()...)
+	errs = append(errs, cfg.HaConfig.validate()...)
+	if cfg.HaConfig.Enabled && cfg.DBConfig.IsInMemory() {
+		errs.add("ha_config.enabled", "requires a database shared by the challengers, not an in-memory one")
+	}
+	errs = append(errs, cfg.ShardConfig.validate()...)
+	if cfg.ShardConfig.Enabled() && !cfg.HaConfig.Enabled {
+		// the shards are leased like the leadership, the leader runs the stages which are not sharded
+		errs.add("shard_config.count", "requires ha_config.enabled")
+	}
+	if (cfg.SamplingConfig.Enabled() || cfg.ScannerConfig.Enabled()) && !cfg.ProactiveConfig.Enabled() {
+		// the sampling failures, including the suspects of the scanner found unavailable, are challenged by the
+		// proactive challenges, which run without their own sampling
+		if cfg.ProactiveConfig.IntervalInMinutes == 0 {
+			errs.add("proactive_config.interval_in_minutes", "should not be 0 to challenge the sampling failures")
+		}
+		if cfg.ProactiveConfig.MaxChallengesPerRound <= 0 {
+			errs.add("proactive_config.max_challenges_per_round", "should be positive to challenge the sampling failures")
+		}
+	}
+	errs = append(errs, cfg.SentryConfig.validate()...)
+	errs = append(errs, cfg.PluginConfig.validate()...)
+	errs = append(errs, cfg.WebhookConfig.validate()...)
+	errs = append(errs, cfg.StreamConfig.validate()...)
+	errs = append(errs, cfg.FederationConfig.validate()...)
+	errs = append(errs, cfg.RelayConfig.validate()...)
+	errs = append(errs, cfg.StatusPageConfig.validate()...)
+	errs = append(errs, cfg.JobQueueConfig.validate()...)
+	errs = append(errs, cfg.IndexerConfig.validate()...)
+	errs = append(errs, cfg.ScannerConfig.validate()...)
+	errs = append(errs, cfg.VoteProxyConfig.validate()...)
+	if cfg.AdminConfig.Enabled() && cfg.AdminConfig.Port == cfg.MetricsConfig.Port {
+		errs.add("admin_config.port", "should differ from metrics_config.port")
+	}
+	queryApiPorts := []struct {
+		field string
+		port  uint16
+	}{
+		{"query_api_config.grpc_port", cfg.QueryApiConfig.GrpcPort},
+		{"query_api_config.rest_port", cfg.QueryApiConfig.RestPort},
+		{"federation_config.port", cfg.FederationConfig.Port},
+		{"status_page_config.port", cfg.StatusPageConfig.Port},
+		{"scanner_config.port", cfg.ScannerConfig.Port},
+		{"vote_proxy_config.port", cfg.VoteProxyConfig.Port},
+	}
+	for _, p := range queryApiPorts {
+		if p.port != 0 && (p.port == cfg.MetricsConfig.Port || cfg.AdminConfig.Enabled() && p.port == cfg.AdminConfig.Port) {
+			errs.add(p.field, "should differ from metrics_config.port and admin_config.port")
+		}
+	}
+	if cfg.AuditConfig.Enabled() && cfg.LogConfig.UseFileLogger &&
+		filepath.Clean(cfg.AuditConfig.FilePath) == filepath.Clean(cfg.LogConfig.Filename) {
+		errs.add("audit_config.file_path", "should differ from log_config.filename")
+	}
+	errs.panicIfAny()
+}
+
+// AuditConfig writes the signed votes and the attestations to a hash-chained audit file, auditing is off if FilePath
+// is empty.
+type AuditConfig struct {
+	FilePath string `json:"file_path"`
+}
+
+func (cfg *AuditConfig) Enabled() bool {
+	return cfg.FilePath != ""
+}
+
+// SlaConfig sets the attestation sla report, which is computed over the rolling window and logged every
+// ReportIntervalInMinutes. Zero values keep the defaults.
+type SlaConfig struct {
+	WindowInHours           uint64 `json:"window_in_hours"`
+	ReportIntervalInMinutes uint64 `json:"report_interval_in_minutes"`
+	// Notify sends the reports to the alert backends of the info severity as well
+	Notify bool `json:"notify"`
+}
+
+func (cfg *SlaConfig) Window() time.Duration {
+	if cfg.WindowInHours == 0 {
+		return DefaultSlaWindowInHours * time.Hour
+	}
+	return time.Duration(cfg.WindowInHours) * time.Hour
+}
+
+func (cfg *SlaConfig) ReportInterval() time.Duration {
+	if cfg.ReportIntervalInMinutes == 0 {
+		return DefaultSlaReportIntervalInMinutes * time.Minute
+	}
+	return time.Duration(cfg.ReportIntervalInMinutes) * time.Minute
+}
+
+func (cfg *SlaConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *SlaConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if cfg.ReportInterval() > cfg.Window() {
+		errs.add("sla_config.report_interval_in_minutes", "should not exceed the window of %s", cfg.Window())
+	}
+	return errs
+}
+
+// ProactiveConfig samples stored objects every IntervalInMinutes and submits challenges for them from the challenger
+// account, it is off unless Buckets or an object id range are set.
+type ProactiveConfig struct {
+	IntervalInMinutes     uint64 `json:"interval_in_minutes"`
+	SamplesPerRound       int    `json:"samples_per_round"`
+	MaxChallengesPerRound int    `json:"max_challenges_per_round"`
+	// Buckets are sampled by listing their objects, MinObjectId to MaxObjectId by picking random object ids
+	Buckets     []string `json:"buckets"`
+	MinObjectId uint64   `json:"min_object_id"`
+	MaxObjectId uint64   `json:"max_object_id"`
+	// SpOperatorAddresses limits the challenged storage providers, all of the sampled objects are challenged if empty
+	SpOperatorAddresses []string `json:"sp_operator_addresses"`
+	// PreVerify challenges the sp first and only submits a challenge if the piece is unavailable or mismatched
+	PreVerify bool `json:"pre_verify"`
+}
+
+func (cfg *ProactiveConfig) Enabled() bool {
+	return len(cfg.Buckets) != 0 || cfg.MaxObjectId != 0
+}
+
+func (cfg *ProactiveConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *ProactiveConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if !cfg.Enabled() {
+		return errs
+	}
+	if cfg.IntervalInMinutes == 0 {
+		errs.add("proactive_config.interval_in_minutes", "should not be 0")
+	}
+	if cfg.SamplesPerRound <= 0 {
+		errs.add("proactive_config.samples_per_round", "should be positive")
+	}
+	if cfg.MaxChallengesPerRound <= 0 {
+		errs.add("proactive_config.max_challenges_per_round", "should be positive")
+	}
+	if cfg.MinObjectId > cfg.MaxObjectId {
+		errs.add("proactive_config.min_object_id", "should not exceed max_object_id %d", cfg.MaxObjectId)
+	}
+	for _, addr := range cfg.SpOperatorAddresses {
+		if !addressRegexp.MatchString(addr) {
+			errs.add("proactive_config.sp_operator_addresses", "%q is not a hex address", addr)
+		}
+	}
+	return errs
+}
+
+// ReputationConfig scores the storage providers from their challenge stats every IntervalInMinutes. Zero values keep
+// the defaults.
+type ReputationConfig struct {
+	IntervalInMinutes uint64 `json:"interval_in_minutes"`
+	// SlowLatencyInMs is the average sp response latency scored as the worst latency
+	SlowLatencyInMs uint64 `json:"slow_latency_in_ms"`
+	// MinRetryAttempts is the retry budget of the requests to the worst scored sps, the best scored sps get the retry
+	// attempts of the tunable config
+	MinRetryAttempts uint `json:"min_retry_attempts"`
+}
+
+func (cfg *ReputationConfig) Interval() time.Duration {
+	if cfg.IntervalInMinutes == 0 {
+		return DefaultReputationIntervalInMinutes * time.Minute
+	}
+	return time.Duration(cfg.IntervalInMinutes) * time.Minute
+}
+
+func (cfg *ReputationConfig) SlowLatency() time.Duration {
+	if cfg.SlowLatencyInMs == 0 {
+		return DefaultReputationSlowLatencyInMs * time.Millisecond
+	}
+	return time.Duration(cfg.SlowLatencyInMs) * time.Millisecond
+}
+
+func (cfg *ReputationConfig) RetryAttemptsFloor() uint {
+	if cfg.MinRetryAttempts == 0 {
+		return DefaultReputationMinRetryAttempts
+	}
+	return cfg.MinRetryAttempts
+}
+
+func (cfg *ReputationConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *ReputationConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if cfg.MinRetryAttempts > MaxRetryAttempts {
+		errs.add("reputation_config.min_retry_attempts", "should not be larger than %d", MaxRetryAttempts)
+	}
+	return errs
+}
+
+// SamplingConfig samples a random segment of an object of Buckets, or of the buckets owned by Accounts, every
+// IntervalInSeconds, and records the pieces the storage providers fail to serve as candidates for the proactive
+// challenges. Sampling is off unless Buckets or Accounts are set.
+type SamplingConfig struct {
+	IntervalInSeconds uint64   `json:"interval_in_seconds"`
+	Buckets           []string `json:"buckets"`
+	Accounts          []string `json:"accounts"`
+	// ListIntervalInMinutes is how often the buckets of the accounts and the objects of the buckets are listed again
+	ListIntervalInMinutes uint64 `json:"list_interval_in_minutes"`
+}
+
+func (cfg *SamplingConfig) Enabled() bool {
+	return len(cfg.Buckets) != 0 || len(cfg.Accounts) != 0
+}
+
+func (cfg *SamplingConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *SamplingConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if !cfg.Enabled() {
+		return errs
+	}
+	if cfg.IntervalInSeconds == 0 {
+		errs.add("sampling_config.interval_in_seconds", "should not be 0")
+	}
+	if cfg.ListIntervalInMinutes == 0 {
+		errs.add("sampling_config.list_interval_in_minutes", "should not be 0")
+	}
+	for _, account := range cfg.Accounts {
+		if !addressRegexp.MatchString(account) {
+			errs.add("sampling_config.accounts", "%q is not a hex address", account)
+		}
+	}
+	return errs
+}
+
+// QueryApiConfig serves the challenge results read-only over grpc on GrpcPort and over REST on RestPort, each is off
+// if its port is 0.
+type QueryApiConfig struct {
+	GrpcPort uint16 `json:"grpc_port"`
+	RestPort uint16 `json:"rest_port"`
+}
+
+func (cfg *QueryApiConfig) Enabled() bool {
+	return cfg.GrpcPort != 0 || cfg.RestPort != 0
+}
+
+func (cfg *QueryApiConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *QueryApiConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if cfg.GrpcPort != 0 && cfg.GrpcPort == cfg.RestPort {
+		errs.add("query_api_config.rest_port", "should differ from grpc_port")
+	}
+	return errs
+}
+
+// HaConfig runs the challenger as one of several instances sharing the database, of which only the leader runs the
+// pipeline. The leader holds a lease record it renews every RenewIntervalInSeconds, a standby takes over once the
+// lease is not renewed for LeaseTimeoutInSeconds. Zero values keep the defaults.
+type HaConfig struct {
+	Enabled bool `json:"enabled"`
+	// InstanceId names the instance in the lease record, the host name is used if it is empty
+	InstanceId             string `json:"instance_id"`
+	LeaseTimeoutInSeconds  uint64 `json:"lease_timeout_in_seconds"`
+	RenewIntervalInSeconds uint64 `json:"renew_interval_in_seconds"`
+}
+
+func (cfg *HaConfig) LeaseTimeout() time.Duration {
+	if cfg.LeaseTimeoutInSeconds == 0 {
+		return DefaultHaLeaseTimeoutInSeconds * time.Second
+	}
+	return time.Duration(cfg.LeaseTimeoutInSeconds) * time.Second
+}
+
+func (cfg *HaConfig) RenewInterval() time.Duration {
+	if cfg.RenewIntervalInSeconds == 0 {
+		return DefaultHaRenewIntervalInSeconds * time.Second
+	}
+	return time.Duration(cfg.RenewIntervalInSeconds) * time.Second
+}
+
+// Instance returns the id of the instance in the lease record.
+func (cfg *HaConfig) Instance() string {
+	if cfg.InstanceId != "" {
+		return cfg.InstanceId
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return fmt.Sprintf("pid-%d", os.Getpid())
+	}
+	return hostname
+}
+
+func (cfg *HaConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *HaConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if !cfg.Enabled {
+		return errs
+	}
+	// the leader stops one renew interval before its lease expires, so that it never overlaps with the next leader
+	if cfg.RenewInterval()*2 > cfg.LeaseTimeout() {
+		errs.add("ha_config.renew_interval_in_seconds", "should be at most half of lease_timeout_in_seconds")
+	}
+	if len(cfg.InstanceId) > MaxHaInstanceIdLength {
+		errs.add("ha_config.instance_id", "should be at most %d characters", MaxHaInstanceIdLength)
+	}
+	return errs
+}
+
+// ShardConfig shares the verification and the votes of the events among the challengers in ha mode, the event of a
+// challenge is in the shard challenge_id % Count. The shards are leased to the challengers, which take an even share
+// of them and take over the shards of a challenger which stopped. Sharding is off if Count is below 2.
+type ShardConfig struct {
+	Count uint64 `json:"count"`
+}
+
+func (cfg *ShardConfig) Enabled() bool {
+	return cfg.Count > 1
+}
+
+func (cfg *ShardConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *ShardConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if cfg.Count > MaxShardCount {
+		errs.add("shard_config.count", "should be at most %d", MaxShardCount)
+	}
+	return errs
+}
+
+// SentryConfig reports the panics and the errors to sentry, or a compatible server, reporting is off if Dsn is empty.
+type SentryConfig struct {
+	// Dsn is the client key url of the sentry project, e.g. https://key@sentry.example.com/42
+	Dsn         string `json:"dsn"`
+	Environment string `json:"environment"`
+}
+
+func (cfg *SentryConfig) Enabled() bool {
+	return cfg.Dsn != ""
+}
+
+func (cfg *SentryConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *SentryConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if !cfg.Enabled() {
+		return errs
+	}
+	dsn, err := url.Parse(cfg.Dsn)
+	if err != nil || (dsn.Scheme != "http" && dsn.Scheme != "https") || dsn.Host == "" || dsn.User.Username() == "" ||
+		strings.Trim(dsn.Path, "/") == "" {
+		errs.add("sentry_config.dsn", "should be a url like https://key@sentry.example.com/project_id")
+	}
+	return errs
+}
+
+// PluginConfig runs the custom verifications of the verifier plugins, served by sidecars of the challenger over grpc.
+// The plugins are asked about the challenges whose piece matched the checksums on chain, a plugin finding it mismatched
+// makes the challenge succeed.
+type PluginConfig struct {
+	// VerifierAddrs are the addresses of the plugins, host:port or unix:///path/to/socket
+	VerifierAddrs []string `json:"verifier_addrs"`
+	TimeoutInMs   uint64   `json:"timeout_in_ms"`
+}
+
+func (cfg *PluginConfig) Timeout() time.Duration {
+	if cfg.TimeoutInMs == 0 {
+		return DefaultPluginTimeoutInMs * time.Millisecond
+	}
+	return time.Duration(cfg.TimeoutInMs) * time.Millisecond
+}
+
+func (cfg *PluginConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *PluginConfig) validate() validationErrors {
+	errs := validationErrors{}
+	seen := make(map[string]bool, len(cfg.VerifierAddrs))
+	for _, addr := range cfg.VerifierAddrs {
+		if strings.TrimSpace(addr) == "" {
+			errs.add("plugin_config.verifier_addrs", "should not contain an empty address")
+		} else if seen[addr] {
+			errs.add("plugin_config.verifier_addrs", "%q is listed twice", addr)
+		}
+		seen[addr] = true
+	}
+	return errs
+}
+
+// WebhookConfig posts the pipeline events of the challenges to webhooks as json notifications, notifying is off if Urls
+// is empty.
+type WebhookConfig struct {
+	Urls []string `json:"urls"`
+	// Secret signs the notifications with hmac-sha256, they are not signed if empty
+	Secret string `json:"secret"`
+	// Triggers are the notified pipeline events, all of them if empty
+	Triggers    []string `json:"triggers"`
+	MaxRetries  int      `json:"max_retries"`
+	TimeoutInMs uint64   `json:"timeout_in_ms"`
+}
+
+func (cfg *WebhookConfig) Enabled() bool {
+	return len(cfg.Urls) > 0
+}
+
+// Triggered reports whether the pipeline event trigger is notified.
+func (cfg *WebhookConfig) Triggered(trigger string) bool {
+	return len(cfg.Triggers) == 0 || contains(cfg.Triggers, trigger)
+}
+
+func (cfg *WebhookConfig) Timeout() time.Duration {
+	if cfg.TimeoutInMs == 0 {
+		return DefaultWebhookTimeoutInMs * time.Millisecond
+	}
+	return time.Duration(cfg.TimeoutInMs) * time.Millisecond
+}
+
+func (cfg *WebhookConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *WebhookConfig) validate() validationErrors {
+	errs := validationErrors{}
+	for _, u := range cfg.Urls {
+		if err := validateURL(u); err != nil {
+			errs.add("webhook_config.urls", "%s", err.Error())
+		}
+	}
+	for _, trigger := range cfg.Triggers {
+		if !contains(WebhookTriggers, trigger) {
+			errs.add("webhook_config.triggers", "unknown trigger %q, use one of %s", trigger,
+				strings.Join(WebhookTriggers, ", "))
+		}
+	}
+	if cfg.MaxRetries < 0 {
+		errs.add("webhook_config.max_retries", "should not be negative")
+	}
+	return errs
+}
+
+// StreamConfig publishes the status transitions of the events to a kafka topic or a nats subject, streaming is off if
+// Backend is empty.
+type StreamConfig struct {
+	// Backend is kafka or nats
+	Backend string `json:"backend"`
+	// KafkaBrokers are the bootstrap brokers of the kafka cluster, host:port
+	KafkaBrokers []string `json:"kafka_brokers"`
+	KafkaTopic   string   `json:"kafka_topic"`
+	// NatsUrl is the nats server, e.g. nats://127.0.0.1:4222, it may carry the credentials
+	NatsUrl     string `json:"nats_url"`
+	NatsSubject string `json:"nats_subject"`
+	// Serialization is the encoding of the transitions, json or protobuf
+	Serialization string `json:"serialization"`
+}
+
+func (cfg *StreamConfig) Enabled() bool {
+	return cfg.Backend != ""
+}
+
+func (cfg *StreamConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *StreamConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if !cfg.Enabled() {
+		return errs
+	}
+	switch cfg.Backend {
+	case StreamBackendKafka:
+		if len(cfg.KafkaBrokers) == 0 {
+			errs.add("stream_config.kafka_brokers", "should not be empty for the kafka backend")
+		}
+		for _, broker := range cfg.KafkaBrokers {
+			if _, _, err := net.SplitHostPort(broker); err != nil {
+				errs.add("stream_config.kafka_brokers", "%q should be host:port", broker)
+			}
+		}
+		if cfg.KafkaTopic == "" {
+			errs.add("stream_config.kafka_topic", "should not be empty for the kafka backend")
+		}
+	case StreamBackendNats:
+		if u, err := url.Parse(cfg.NatsUrl); err != nil || u.Host == "" ||
+			(u.Scheme != "nats" && u.Scheme != "tls" && u.Scheme != "ws" && u.Scheme != "wss") {
+			errs.add("stream_config.nats_url", "should be a url like nats://127.0.0.1:4222")
+		}
+		if cfg.NatsSubject == "" || strings.ContainsAny(cfg.NatsSubject, " \t*>") {
+			errs.add("stream_config.nats_subject", "should be a subject without spaces or wildcards")
+		}
+	default:
+		errs.add("stream_config.backend", "unknown backend %q, use one of %s", cfg.Backend,
+			strings.Join(StreamBackends, ", "))
+	}
+	if !contains(StreamSerializations, cfg.Serialization) {
+		errs.add("stream_config.serialization", "unknown serialization %q, use one of %s", cfg.Serialization,
+			strings.Join(StreamSerializations, ", "))
+	}
+	return errs
+}
+
+// FederationConfig exchanges signed verify results with trusted challengers, the results are served if Port is not 0
+// and the ones of Peers are fetched if Peers is not empty.
+type FederationConfig struct {
+	Port uint16 `json:"port"`
+	// Peers are the base urls of the trusted challengers, e.g. http://challenger-b:9310
+	Peers []string `json:"peers"`
+	// TrustedPubKeys are the hex bls public keys whose signed results are accepted
+	TrustedPubKeys []string `json:"trusted_pub_keys"`
+	// SkipThreshold is the number of trusted challengers finding a piece matched, without any of them finding it
+	// mismatched, to skip requesting it from the sp, it is never skipped if 0
+	SkipThreshold int    `json:"skip_threshold"`
+	TimeoutInMs   uint64 `json:"timeout_in_ms"`
+}
+
+func (cfg *FederationConfig) Enabled() bool {
+	return len(cfg.Peers) > 0
+}
+
+func (cfg *FederationConfig) Timeout() time.Duration {
+	if cfg.TimeoutInMs == 0 {
+		return DefaultFederationTimeoutInMs * time.Millisecond
+	}
+	return time.Duration(cfg.TimeoutInMs) * time.Millisecond
+}
+
+func (cfg *FederationConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *FederationConfig) validate() validationErrors {
+	errs := validationErrors{}
+	for _, peer := range cfg.Peers {
+		if err := validateURL(peer); err != nil {
+			errs.add("federation_config.peers", "%s", err.Error())
+		}
+	}
+	if cfg.Enabled() && len(cfg.TrustedPubKeys) == 0 {
+		errs.add("federation_config.trusted_pub_keys", "should not be empty when peers are set")
+	}
+	for _, key := range cfg.TrustedPubKeys {
+		if bz, err := hex.DecodeString(key); err != nil || len(bz) != BlsPubKeyLength {
+			errs.add("federation_config.trusted_pub_keys", "%q should be a hex bls public key of %d bytes", key,
+				BlsPubKeyLength)
+		}
+	}
+	if cfg.SkipThreshold < 0 || cfg.SkipThreshold > len(cfg.TrustedPubKeys) {
+		errs.add("federation_config.skip_threshold", "should be between 0 and the number of trusted_pub_keys")
+	} else if cfg.SkipThreshold > 0 && !cfg.Enabled() {
+		errs.add("federation_config.skip_threshold", "requires peers")
+	}
+	return errs
+}
+
+// CompatConfig checks the versions of the chain against the compatibility matrix embedded in the binary, the votes and
+// the attestations are refused on a chain which is not in the matrix.
+type CompatConfig struct {
+	IntervalInMinutes uint64 `json:"interval_in_minutes"`
+	// AllowUnsupported keeps voting and attesting on an unsupported chain, it is only alerted
+	AllowUnsupported bool `json:"allow_unsupported"`
+}
+
+func (cfg *CompatConfig) Interval() time.Duration {
+	if cfg.IntervalInMinutes == 0 {
+		return DefaultCompatIntervalInMinutes * time.Minute
+	}
+	return time.Duration(cfg.IntervalInMinutes) * time.Minute
+}
+
+// RelayConfig follows the cross-chain packages emitted by the attestations until they are executed on BSC, it is off if
+// BscRpcAddr is empty.
+type RelayConfig struct {
+	BscRpcAddr string `json:"bsc_rpc_addr"`
+	// CrossChainContract is the address of the CrossChain contract on BSC
+	CrossChainContract string `json:"cross_chain_contract"`
+	// DestChainId is the cross-chain id of BSC, 56 on mainnet and 97 on testnet
+	DestChainId       uint32 `json:"dest_chain_id"`
+	TimeoutInMinutes  uint64 `json:"timeout_in_minutes"`
+	IntervalInSeconds uint64 `json:"interval_in_seconds"`
+}
+
+func (cfg *RelayConfig) Enabled() bool {
+	return cfg.BscRpcAddr != ""
+}
+
+// Timeout returns how long a package may stay unrelayed before it is alerted as a relay gap.
+func (cfg *RelayConfig) Timeout() time.Duration {
+	if cfg.TimeoutInMinutes == 0 {
+		return DefaultRelayTimeoutInMinutes * time.Minute
+	}
+	return time.Duration(cfg.TimeoutInMinutes) * time.Minute
+}
+
+func (cfg *RelayConfig) Interval() time.Duration {
+	if cfg.IntervalInSeconds == 0 {
+		return DefaultRelayIntervalInSeconds * time.Second
+	}
+	return time.Duration(cfg.IntervalInSeconds) * time.Second
+}
+
+func (cfg *RelayConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *RelayConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if !cfg.Enabled() {
+		return errs
+	}
+	if err := validateURL(cfg.BscRpcAddr); err != nil {
+		errs.add("relay_config.bsc_rpc_addr", "%s", err.Error())
+	}
+	if bz, err := hex.DecodeString(strings.TrimPrefix(cfg.CrossChainContract, "0x")); err != nil || len(bz) != 20 {
+		errs.add("relay_config.cross_chain_contract", "%q should be a hex address of 20 bytes", cfg.CrossChainContract)
+	}
+	if cfg.DestChainId == 0 {
+		errs.add("relay_config.dest_chain_id", "should not be 0 when bsc_rpc_addr is set")
+	}
+	return errs
+}
+
+// EconomicsConfig reports the fees paid and the rewards credited to the challenger account, they are recorded from
+// the polled blocks.
+type EconomicsConfig struct {
+	WindowInDays            uint64 `json:"window_in_days"`
+	ReportIntervalInMinutes uint64 `json:"report_interval_in_minutes"`
+}
+
+// Window returns the period the economics metrics cover.
+func (cfg *EconomicsConfig) Window() time.Duration {
+	if cfg.WindowInDays == 0 {
+		return DefaultEconomicsWindowInDays * 24 * time.Hour
+	}
+	return time.Duration(cfg.WindowInDays) * 24 * time.Hour
+}
+
+func (cfg *EconomicsConfig) ReportInterval() time.Duration {
+	if cfg.ReportIntervalInMinutes == 0 {
+		return DefaultEconomicsReportIntervalInMinutes * time.Minute
+	}
+	return time.Duration(cfg.ReportIntervalInMinutes) * time.Minute
+}
+
+// AnalyticsConfig rolls the verification results up by storage provider and by bucket into hourly windows, which are
+// kept after the results are replaced and served by the query api.
+type AnalyticsConfig struct {
+	RollupIntervalInMinutes uint64 `json:"rollup_interval_in_minutes"`
+	RetentionInDays         uint64 `json:"retention_in_days"`
+}
+
+func (cfg *AnalyticsConfig) RollupInterval() time.Duration {
+	if cfg.RollupIntervalInMinutes == 0 {
+		return DefaultAnalyticsRollupIntervalInMinutes * time.Minute
+	}
+	return time.Duration(cfg.RollupIntervalInMinutes) * time.Minute
+}
+
+// Retention returns how long the rollups are kept.
+func (cfg *AnalyticsConfig) Retention() time.Duration {
+	if cfg.RetentionInDays == 0 {
+		return DefaultAnalyticsRetentionInDays * 24 * time.Hour
+	}
+	return time.Duration(cfg.RetentionInDays) * 24 * time.Hour
+}
+
+// StatusPageConfig serves a public, read-only status page on Port, it is off if 0. Each client may load
+// RequestsPerMinute pages, the clients are told apart by their address, or by the X-Forwarded-For header set by the
+// proxy in front of the challenger if TrustForwardedFor. Zero values keep the defaults.
+type StatusPageConfig struct {
+	Port              uint16 `json:"port"`
+	RequestsPerMinute uint64 `json:"requests_per_minute"`
+	RecentChallenges  uint64 `json:"recent_challenges"`
+	TrustForwardedFor bool   `json:"trust_forwarded_for"`
+}
+
+func (cfg *StatusPageConfig) Enabled() bool {
+	return cfg.Port != 0
+}
+
+func (cfg *StatusPageConfig) Rate() uint64 {
+	if cfg.RequestsPerMinute == 0 {
+		return DefaultStatusPageRequestsPerMinute
+	}
+	return cfg.RequestsPerMinute
+}
+
+// Recent returns the number of recent challenges listed by the page.
+func (cfg *StatusPageConfig) Recent() int {
+	if cfg.RecentChallenges == 0 {
+		return DefaultStatusPageRecentChallenges
+	}
+	return int(cfg.RecentChallenges)
+}
+
+func (cfg *StatusPageConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if cfg.Enabled() && cfg.RecentChallenges > MaxStatusPageRecentChallenges {
+		errs.add("status_page_config.recent_challenges", "should not exceed %d", MaxStatusPageRecentChallenges)
+	}
+	return errs
+}
+
+// JobQueueConfig queues the verifications in the database, so that they survive a restart, are retried with a backoff
+// and are shared by all challengers of the database, each leasing the jobs it verifies. A job which is not done within
+// LeaseTimeoutInSeconds is taken over by another challenger, a job failing MaxAttempts times is kept as failed. The
+// retries wait BackoffInSeconds, doubled on every attempt up to MaxBackoffInSeconds. Zero values keep the defaults.
+type JobQueueConfig struct {
+	Enabled               bool   `json:"enabled"`
+	LeaseTimeoutInSeconds uint64 `json:"lease_timeout_in_seconds"`
+	MaxAttempts           uint32 `json:"max_attempts"`
+	BackoffInSeconds      uint64 `json:"backoff_in_seconds"`
+	MaxBackoffInSeconds   uint64 `json:"max_backoff_in_seconds"`
+}
+
+func (cfg *JobQueueConfig) LeaseTimeout() time.Duration {
+	if cfg.LeaseTimeoutInSeconds == 0 {
+		return DefaultJobQueueLeaseTimeoutInSeconds * time.Second
+	}
+	return time.Duration(cfg.LeaseTimeoutInSeconds) * time.Second
+}
+
+func (cfg *JobQueueConfig) Attempts() uint32 {
+	if cfg.MaxAttempts == 0 {
+		return DefaultJobQueueMaxAttempts
+	}
+	return cfg.MaxAttempts
+}
+
+// Backoff returns how long a job waits after its attempt failed.
+func (cfg *JobQueueConfig) Backoff(attempt uint32) time.Duration {
+	backoff, maxBackoff := cfg.BackoffInSeconds, cfg.MaxBackoffInSeconds
+	if backoff == 0 {
+		backoff = DefaultJobQueueBackoffInSeconds
+	}
+	if maxBackoff == 0 {
+		maxBackoff = DefaultJobQueueMaxBackoffInSeconds
+	}
+	for i := uint32(1); i < attempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return time.Duration(backoff) * time.Second
+}
+
+func (cfg *JobQueueConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if !cfg.Enabled {
+		return errs
+	}
+	if cfg.MaxAttempts > MaxJobQueueAttempts {
+		errs.add("job_queue_config.max_attempts", "should not exceed %d", MaxJobQueueAttempts)
+	}
+	if cfg.BackoffInSeconds != 0 && cfg.MaxBackoffInSeconds != 0 && cfg.BackoffInSeconds > cfg.MaxBackoffInSeconds {
+		errs.add("job_queue_config.backoff_in_seconds", "should not exceed max_backoff_in_seconds")
+	}
+	return errs
+}
+
+// IndexerConfig cross-checks the saved events with the challenges served by a greenfield indexer, flagging the
+// challenges which were missed or recorded differently, it is off if Url is empty. The blocks are checked once they are
+// DelayBlocks behind the latest polled block, so that the indexer caught up with them, starting LookbackBlocks back.
+// With Fallback, the monitor polls the challenges from the indexer while the rpc endpoints are down.
+type IndexerConfig struct {
+	Url               string `json:"url"`
+	ApiKey            string `json:"api_key"` // sent as a bearer token if set
+	IntervalInSeconds uint64 `json:"interval_in_seconds"`
+	LookbackBlocks    uint64 `json:"lookback_blocks"`
+	DelayBlocks       uint64 `json:"delay_blocks"`
+	TimeoutInMs       uint64 `json:"timeout_in_ms"`
+	Fallback          bool   `json:"fallback"`
+}
+
+func (cfg *IndexerConfig) Enabled() bool {
+	return cfg.Url != ""
+}
+
+func (cfg *IndexerConfig) Interval() time.Duration {
+	if cfg.IntervalInSeconds == 0 {
+		return DefaultIndexerIntervalInSeconds * time.Second
+	}
+	return time.Duration(cfg.IntervalInSeconds) * time.Second
+}
+
+func (cfg *IndexerConfig) Timeout() time.Duration {
+	if cfg.TimeoutInMs == 0 {
+		return DefaultIndexerTimeoutInMs * time.Millisecond
+	}
+	return time.Duration(cfg.TimeoutInMs) * time.Millisecond
+}
+
+func (cfg *IndexerConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if !cfg.Enabled() {
+		return errs
+	}
+	if err := validateURL(cfg.Url); err != nil {
+		errs.add("indexer_config.url", "%s", err.Error())
+	}
+	if cfg.LookbackBlocks == 0 {
+		errs.add("indexer_config.lookback_blocks", "should be positive when url is set")
+	}
+	return errs
+}
+
+// ScannerConfig serves the api where an external data-integrity scanner submits the objects it suspects, it is off if
+// Port is 0. The suspects are queued in memory and verified against the sps storing them, the pieces found unavailable
+// are recorded as sampling failures, which the proactive challenges submit on chain.
+type ScannerConfig struct {
+	Port                  uint16 `json:"port"`
+	AuthToken             string `json:"auth_token"`
+	QueueSize             int    `json:"queue_size"`
+	MaxSuspectsPerRequest int    `json:"max_suspects_per_request"`
+}
+
+func (cfg *ScannerConfig) Enabled() bool {
+	return cfg.Port != 0
+}
+
+func (cfg *ScannerConfig) Queue() int {
+	if cfg.QueueSize == 0 {
+		return DefaultScannerQueueSize
+	}
+	return cfg.QueueSize
+}
+
+func (cfg *ScannerConfig) MaxSuspects() int {
+	if cfg.MaxSuspectsPerRequest == 0 {
+		return DefaultScannerMaxSuspectsPerRequest
+	}
+	return cfg.MaxSuspectsPerRequest
+}
+
+func (cfg *ScannerConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if !cfg.Enabled() {
+		return errs
+	}
+	if cfg.AuthToken == "" {
+		errs.add("scanner_config.auth_token", "should not be empty, the suspects of the scanner are challenged on chain")
+	}
+	if cfg.QueueSize < 0 || cfg.QueueSize > MaxScannerQueueSize {
+		errs.add("scanner_config.queue_size", "should be between 0 and %d", MaxScannerQueueSize)
+	}
+	if cfg.MaxSuspectsPerRequest < 0 || cfg.MaxSuspectsPerRequest > cfg.Queue() {
+		errs.add("scanner_config.max_suspects_per_request", "should be between 0 and the queue size %d", cfg.Queue())
+	}
+	return errs
+}
+
+// VoteProxyConfig relays the votes of validators whose nodes do not expose the votepool rpc, it is off if Port is 0.
+// Each client authenticates with its own token and may only relay the votes signed by its bls keys, at most
+// VotesPerMinute of them.
+type VoteProxyConfig struct {
+	Port           uint16            `json:"port"`
+	VotesPerMinute uint64            `json:"votes_per_minute"`
+	Clients        []VoteProxyClient `json:"clients"`
+}
+
+// VoteProxyClient is a validator relaying its votes through the proxy.
+type VoteProxyClient struct {
+	Name       string   `json:"name"`
+	AuthToken  string   `json:"auth_token"`
+	BlsPubKeys []string `json:"bls_pub_keys"` // hex encoded
+}
+
+func (cfg *VoteProxyConfig) Enabled() bool {
+	return cfg.Port != 0
+}
+
+func (cfg *VoteProxyConfig) Rate() uint64 {
+	if cfg.VotesPerMinute == 0 {
+		return DefaultVoteProxyVotesPerMinute
+	}
+	return cfg.VotesPerMinute
+}
+
+func (cfg *VoteProxyConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if !cfg.Enabled() {
+		return errs
+	}
+	if len(cfg.Clients) == 0 {
+		errs.add("vote_proxy_config.clients", "should not be empty")
+	}
+	names, tokens := make(map[string]bool), make(map[string]bool)
+	for i, client := range cfg.Clients {
+		field := fmt.Sprintf("vote_proxy_config.clients[%d]", i)
+		switch {
+		case client.Name == "":
+			errs.add(field+".name", "should not be empty")
+		case names[client.Name]:
+			errs.add(field+".name", "%q is not unique", client.Name)
+		}
+		names[client.Name] = true
+		switch {
+		case client.AuthToken == "":
+			errs.add(field+".auth_token", "should not be empty")
+		case len(client.AuthToken) < MinAuthTokenLength:
+			errs.add(field+".auth_token", "should be at least %d characters", MinAuthTokenLength)
+		case tokens[client.AuthToken]:
+			errs.add(field+".auth_token", "should not be shared with another client")
+		}
+		tokens[client.AuthToken] = true
+		if len(client.BlsPubKeys) == 0 {
+			errs.add(field+".bls_pub_keys", "should not be empty")
+		}
+		for _, pubKey := range client.BlsPubKeys {
+			if !blsPubKeyRegexp.MatchString(pubKey) {
+				errs.add(field+".bls_pub_keys", "%q should be a hex encoded 48 bytes bls public key", pubKey)
+			}
+		}
+	}
+	return errs
 }
 
 func ParseConfigFromJson(content string) *Config {
-	var config Config
-	if err := json.Unmarshal([]byte(content), &config); err != nil {
+	bz, warnings, err := migrateConfig([]byte(content))
+	if err != nil {
 		panic(err)
 	}
+	config := DefaultConfig()
+	if err := json.Unmarshal(bz, config); err != nil {
+		panic(err)
+	}
+	config.warnings = warnings
 
+	config.applyEnvOverrides()
+	config.applyFlagOverrides()
+	config.GreenfieldConfig.applyNetworkProfile()
 	config.Validate()
 
-	return &config
+	return config
 }
 
 func ParseConfigFromFile(filePath string) *Config {
@@ -158,19 +1653,130 @@ func ParseConfigFromFile(filePath string) *Config {
 	if err != nil {
 		panic(err)
 	}
+	bz, err = configToJson(filePath, bz)
+	if err != nil {
+		panic(err)
+	}
+	bz, warnings, err := migrateConfig(bz)
+	if err != nil {
+		panic(err)
+	}
 
-	var config Config
-	if err := json.Unmarshal(bz, &config); err != nil {
+	config := DefaultConfig()
+	if err := json.Unmarshal(bz, config); err != nil {
 		panic(err)
 	}
+	config.warnings = warnings
 
+	config.applyEnvOverrides()
+	config.applyFlagOverrides()
+	config.GreenfieldConfig.applyNetworkProfile()
 	config.Validate()
 
-	return &config
+	return config
 }
 
 type AlertConfig struct {
 	Identity       string `json:"identity"`
 	TelegramBotId  string `json:"telegram_bot_id"`
 	TelegramChatId string `json:"telegram_chat_id"`
+	// SlackWebhookUrl is the incoming webhook of the slack channel receiving the alerts
+	SlackWebhookUrl string `json:"slack_webhook_url"`
+	// PagerDutyRoutingKey is the integration key of the pagerduty service paged by the alerts
+	PagerDutyRoutingKey string `json:"pagerduty_routing_key"`
+	// Routes maps the alert severities to the backends receiving them, e.g. {"critical": ["pagerduty", "slack"]}.
+	// The severities without a route are sent to all configured backends.
+	Routes map[string][]string `json:"routes"`
+	// the thresholds of the alerted conditions, a zero value turns the alert off
+	MinBalance               string `json:"min_balance"`
+	AttestFailureThreshold   int    `json:"attest_failure_threshold"`
+	MonitorLagThreshold      uint64 `json:"monitor_lag_threshold"`
+	VotePoolFailureThreshold int    `json:"votepool_failure_threshold"`
+	ExpiringChallengeBlocks  uint64 `json:"expiring_challenge_blocks"`
+	StallThresholdInMinutes  uint64 `json:"stall_threshold_in_minutes"`
+	CooldownInSeconds        int64  `json:"cooldown_in_seconds"`
+}
+
+// HasBackend reports whether the alerts can be sent to backend.
+func (cfg *AlertConfig) HasBackend(backend string) bool {
+	switch backend {
+	case AlertBackendTelegram:
+		return cfg.TelegramBotId != "" && cfg.TelegramChatId != ""
+	case AlertBackendSlack:
+		return cfg.SlackWebhookUrl != ""
+	case AlertBackendPagerDuty:
+		return cfg.PagerDutyRoutingKey != ""
+	}
+	return false
+}
+
+// RouteOf returns the configured backends receiving the alerts of severity.
+func (cfg *AlertConfig) RouteOf(severity string) []string {
+	backends, ok := cfg.Routes[severity]
+	if !ok && severity == AlertSeverityInfo {
+		// the reports are not worth a page
+		backends = []string{AlertBackendTelegram, AlertBackendSlack}
+	} else if !ok {
+		backends = AlertBackends
+	}
+	result := make([]string, 0, len(backends))
+	for _, backend := range backends {
+		if cfg.HasBackend(backend) {
+			result = append(result, backend)
+		}
+	}
+	return result
+}
+
+// MinBalanceInt returns MinBalance, false if the low balance alert is off.
+func (cfg *AlertConfig) MinBalanceInt() (math.Int, bool) {
+	if cfg.MinBalance == "" {
+		return math.Int{}, false
+	}
+	return math.NewIntFromString(cfg.MinBalance)
+}
+
+func (cfg *AlertConfig) Validate() {
+	cfg.validate().panicIfAny()
+}
+
+func (cfg *AlertConfig) validate() validationErrors {
+	errs := validationErrors{}
+	if (cfg.TelegramBotId == "") != (cfg.TelegramChatId == "") {
+		errs.add("alert_config.telegram_chat_id", "telegram_bot_id and telegram_chat_id should be set together")
+	}
+	if cfg.SlackWebhookUrl != "" {
+		if err := validateURL(cfg.SlackWebhookUrl); err != nil {
+			errs.add("alert_config.slack_webhook_url", "%s", err.Error())
+		}
+	}
+	for severity, backends := range cfg.Routes {
+		if !contains(AlertSeverities, severity) {
+			errs.add("alert_config.routes", "unknown severity %q, use one of %s", severity, strings.Join(AlertSeverities, ", "))
+			continue
+		}
+		for _, backend := range backends {
+			if !contains(AlertBackends, backend) {
+				errs.add(fmt.Sprintf("alert_config.routes.%s", severity), "unknown backend %q, use one of %s", backend,
+					strings.Join(AlertBackends, ", "))
+			} else if !cfg.HasBackend(backend) {
+				errs.add(fmt.Sprintf("alert_config.routes.%s", severity), "backend %q is not configured", backend)
+			}
+		}
+	}
+	if cfg.MinBalance != "" {
+		if minBalance, ok := math.NewIntFromString(cfg.MinBalance); !ok || minBalance.IsNegative() {
+			errs.add("alert_config.min_balance", "should be a non-negative integer amount in wei, got %q", cfg.MinBalance)
+		}
+	}
+	if cfg.AttestFailureThreshold < 0 {
+		errs.add("alert_config.attest_failure_threshold", "should not be negative")
+	}
+	if cfg.VotePoolFailureThreshold < 0 {
+		errs.add("alert_config.votepool_failure_threshold", "should not be negative")
+	}
+	if cfg.CooldownInSeconds < 0 {
+		errs.add("alert_config.cooldown_in_seconds", "should not be negative")
+	}
+	return errs
 }
@@ -8,15 +8,184 @@ const (
 	FlagConfigPrivateKey    = "private-key"
 	FlagConfigBlsPrivateKey = "bls-private-key"
 	FlagConfigDbPass        = "db-pass"
+	FlagSnapshotPath        = "snapshot-path"
+	FlagMigrate             = "migrate"
+	FlagRemoteConfigAddr    = "remote-config-addr"
+	FlagRemoteConfigKey     = "remote-config-key"
+	FlagLogLevel            = "log-level"
+	FlagMetricsPort         = "metrics-port"
+	FlagStartHeight         = "start-height"
+	FlagDryRun              = "dry-run"
+	FlagReplayFrom          = "from"
+	FlagReplayTo            = "to"
+	FlagReplayStatus        = "status"
+	FlagReplayFixtures      = "fixtures"
+	FlagReplayRecord        = "record"
+	FlagBudgetDays          = "days"
+	FlagBudgetSampleBlocks  = "sample-blocks"
+	FlagBudgetGasPrice      = "gas-price"
+	FlagTargetConfigPath    = "target-config-path"
+	FlagKeysKind            = "key"
+	FlagKeysIdentity        = "identity"
+	FlagKeysValidator       = "validator-address"
+	FlagKeysOutputDir       = "output-dir"
+	FlagRecoveryFile        = "file"
 
-	DBDialectMysql   = "mysql"
-	DBDialectSqlite3 = "sqlite3"
+	DBDialectMysql    = "mysql"
+	DBDialectPostgres = "postgres"
+	DBDialectSqlite3  = "sqlite3"
+	DBPathInMemory    = ":memory:"
+
+	MigratePlan  = "plan"
+	MigrateApply = "apply"
+
+	CommandRun            = "run"
+	CommandStatus         = "status"
+	CommandReplay         = "replay"
+	CommandReplayVerify   = "verify"
+	CommandKeys           = "keys"
+	CommandKeysShow       = "show"
+	CommandKeysGenerate   = "generate"
+	CommandKeysRotate     = "rotate"
+	CommandKeysExport     = "export"
+	CommandConfig         = "config"
+	CommandConfigInit     = "init"
+	CommandConfigDump     = "dump"
+	CommandAudit          = "audit"
+	CommandAuditCheck     = "verify"
+	CommandVersion        = "version"
+	CommandDashboard      = "dashboard"
+	CommandBudget         = "budget"
+	CommandMigrateDB      = "migrate-db"
+	CommandRecovery       = "recovery"
+	CommandRecoveryExport = "export"
+	CommandRecoveryImport = "import"
 
 	LocalConfig            = "local"
 	AWSConfig              = "aws"
+	EtcdConfig             = "etcd"
+	ConsulConfig           = "consul"
 	KeyTypeLocalPrivateKey = "local_private_key"
 	KeyTypeAWSPrivateKey   = "aws_private_key"
+	KeyTypeGCPPrivateKey   = "gcp_private_key"
+	KeyTypeAzurePrivateKey = "azure_private_key"
+	KeyTypeKeystore        = "keystore_private_key"
+
+	LogModuleMonitor  = "monitor"
+	LogModuleVerifier = "verifier"
+	LogModuleVote     = "vote"
+	LogModuleAttest   = "attest"
+	LogModuleExecutor = "executor"
+	LogModuleDao      = "dao"
+
+	AlertSeverityInfo     = "info"
+	AlertSeverityWarning  = "warning"
+	AlertSeverityCritical = "critical"
+	AlertBackendTelegram  = "telegram"
+	AlertBackendSlack     = "slack"
+	AlertBackendPagerDuty = "pagerduty"
+
+	LogFormatText = "text"
+	LogFormatJson = "json"
+
+	SyslogNetworkUdp = "udp"
+	SyslogNetworkTcp = "tcp"
+	DefaultSyslogTag = "greenfield-challenger"
+
+	ComponentMonitor  = "monitor"
+	ComponentVerifier = "verifier"
+	ComponentVote     = "vote"
+	ComponentAttest   = "attest"
+
+	StatsdFlavorStatsd    = "statsd"
+	StatsdFlavorDogStatsd = "dogstatsd"
+	DefaultStatsdPrefix   = "greenfield_challenger"
+
+	DefaultSlaWindowInHours           = 24
+	DefaultSlaReportIntervalInMinutes = 60
+
+	DefaultReputationIntervalInMinutes = 10
+	DefaultReputationSlowLatencyInMs   = 5000
+	DefaultReputationMinRetryAttempts  = 1
+
+	DefaultHaLeaseTimeoutInSeconds  = 15
+	DefaultHaRenewIntervalInSeconds = 5
+	MaxHaInstanceIdLength           = 128
+	MaxShardCount                   = 1024
+
+	DefaultPluginTimeoutInMs = 10000
+
+	WebhookTriggerEventIngested  = "event_ingested"
+	WebhookTriggerVoteBroadcast  = "vote_broadcast"
+	WebhookTriggerVotesCollected = "votes_collected"
+	WebhookTriggerAttested       = "attested"
+	WebhookTriggerExpired        = "expired"
+	DefaultWebhookMaxRetries     = 3
+	DefaultWebhookTimeoutInMs    = 5000
+
+	StreamBackendKafka          = "kafka"
+	StreamBackendNats           = "nats"
+	StreamSerializationJson     = "json"
+	StreamSerializationProtobuf = "protobuf"
+	DefaultStreamTopic          = "greenfield-challenger.transitions"
+
+	BlsPubKeyLength              = 48
+	DefaultFederationTimeoutInMs = 3000
+
+	DefaultCompatIntervalInMinutes = 10
+
+	DefaultRelayTimeoutInMinutes  = 30
+	DefaultRelayIntervalInSeconds = 60
+
+	DefaultEconomicsWindowInDays            = 30
+	DefaultEconomicsReportIntervalInMinutes = 10
+
+	DefaultAnalyticsRollupIntervalInMinutes = 10
+	DefaultAnalyticsRetentionInDays         = 365
+
+	DefaultPipelineBroadcasterWorkers = 4
+	DefaultPipelineCollatorWorkers    = 4
+	DefaultPipelineQueueSize          = 100
+	MaxPipelineWorkers                = 64
+	MaxPipelineQueueSize              = 10000
+	MaxSpResponseCacheBlocks          = 1000
+
+	DefaultStatusPageRequestsPerMinute = 60
+	DefaultStatusPageRecentChallenges  = 20
+	MaxStatusPageRecentChallenges      = 100
+
+	DefaultJobQueueLeaseTimeoutInSeconds = 300
+	DefaultJobQueueMaxAttempts           = 10
+	DefaultJobQueueBackoffInSeconds      = 5
+	DefaultJobQueueMaxBackoffInSeconds   = 300
+	MaxJobQueueAttempts                  = 100
+
+	DefaultIndexerIntervalInSeconds = 300
+	DefaultIndexerLookbackBlocks    = 10000
+	DefaultIndexerDelayBlocks       = 20
+	DefaultIndexerTimeoutInMs       = 10000
+
+	DefaultScannerQueueSize             = 1000
+	DefaultScannerMaxSuspectsPerRequest = 100
+	MaxScannerQueueSize                 = 100000
+
+	DefaultVoteProxyVotesPerMinute = 120
+
+	NetworkGreenfieldMainnet = "greenfield-mainnet"
+	NetworkGreenfieldTestnet = "greenfield-testnet"
+
+	MaxRetryIntervalInMs                 = 10 * 60 * 1000
+	MaxRetryAttempts                     = 100
+	MaxSpTimeoutInSeconds                = 10 * 60
+	DefaultMaxSpRequests                 = 32
+	MaxSpRequestsLimit                   = 1000
+	MaxUpdateValidatorsIntervalInSeconds = 60 * 60
+	MaxCacheSize                         = 1000000
+	MinAuthTokenLength                   = 16
 
-	ConfigType     = "CONFIG_TYPE"
-	ConfigFilePath = "CONFIG_FILE_PATH"
+	ConfigType            = "CONFIG_TYPE"
+	ConfigFilePath        = "CONFIG_FILE_PATH"
+	RemoteConfigAddr      = "REMOTE_CONFIG_ADDR"
+	RemoteConfigKey       = "REMOTE_CONFIG_KEY"
+	KeystorePassphraseEnv = "KEYSTORE_PASSPHRASE"
 )
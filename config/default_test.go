@@ -0,0 +1,62 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteConfig(t *testing.T) {
+	// every field is documented and the annotated default config parses back to the defaults
+	var buf bytes.Buffer
+	require.NoError(t, WriteConfig(&buf, DefaultConfig(), false))
+	bz, err := configToJson("config.yaml", buf.Bytes())
+	require.NoError(t, err)
+	cfg := &Config{}
+	require.NoError(t, json.Unmarshal(bz, cfg))
+	cfg.GreenfieldConfig.RPCAddrs = nil
+	cfg.LogConfig.ModuleLevels = nil
+	cfg.GreenfieldConfig.SpEndpointOverrides = nil
+	cfg.GreenfieldConfig.Identities = nil
+	cfg.AlertConfig.Routes = nil
+	cfg.PipelineConfig.Components = nil
+	cfg.ProactiveConfig.Buckets = nil
+	cfg.ProactiveConfig.SpOperatorAddresses = nil
+	cfg.SamplingConfig.Buckets = nil
+	cfg.SamplingConfig.Accounts = nil
+	cfg.PluginConfig.VerifierAddrs = nil
+	cfg.WebhookConfig.Urls = nil
+	cfg.WebhookConfig.Triggers = nil
+	cfg.StreamConfig.KafkaBrokers = nil
+	cfg.FederationConfig.Peers = nil
+	cfg.FederationConfig.TrustedPubKeys = nil
+	cfg.VoteProxyConfig.Clients = nil
+	require.Equal(t, DefaultConfig(), cfg)
+	require.Contains(t, buf.String(), "  # optional: CRITICAL, ERROR, WARNING, NOTICE, INFO or DEBUG\n  level: INFO\n")
+
+	cfg.GreenfieldConfig.PrivateKey = "6e2d7ab4a5d2d6a6e2d7ab4a5d2d6a6e2d7ab4a5d2d6a6e2d7ab4a5d2d6a6e2d"
+	buf.Reset()
+	require.NoError(t, WriteConfig(&buf, cfg, true))
+	require.False(t, strings.Contains(buf.String(), cfg.GreenfieldConfig.PrivateKey))
+	require.Contains(t, buf.String(), "private_key: <redacted>")
+}
+
+func TestParseConfigDefaults(t *testing.T) {
+	cfg := ParseConfigFromJson(`{
+		"greenfield_config": {
+			"network": "greenfield-testnet",
+			"key_type": "local_private_key",
+			"private_key": "6e2d7ab4a5d2d6a6e2d7ab4a5d2d6a6e2d7ab4a5d2d6a6e2d7ab4a5d2d6a6e2d",
+			"bls_private_key": "6e2d7ab4a5d2d6a6e2d7ab4a5d2d6a6e2d7ab4a5d2d6a6e2d7ab4a5d2d6a6e2d"
+		},
+		"db_config": {"dialect": "sqlite3", "db_path": ":memory:"}
+	}`)
+	require.Equal(t, "INFO", cfg.LogConfig.Level)
+	require.Equal(t, uint16(8080), cfg.MetricsConfig.Port)
+	require.Equal(t, int64(10), cfg.DBConfig.QueryTimeout)
+	require.Equal(t, int64(200), cfg.DBConfig.SlowQueryThresholdInMs)
+	require.Equal(t, uint(2), cfg.TunableConfig.RetryAttempts)
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// EnvPrefix prefixes the environment variables overriding config fields.
+const EnvPrefix = "CHALLENGER"
+
+// EnvName returns the environment variable overriding a config field, e.g. CHALLENGER_GREENFIELD_RPC_ADDRS for
+// rpc_addrs of greenfield_config.
+func EnvName(section, field string) string {
+	section = strings.TrimSuffix(section, "_config")
+	return strings.ToUpper(fmt.Sprintf("%s_%s_%s", EnvPrefix, section, field))
+}
+
+// applyEnvOverrides overrides the config fields which are set in the environment, so that containerized deployments
+// can inject secrets and endpoints without templating the config file. String fields take the value as is, string
+// lists are comma separated and all other fields are json, e.g. numbers, booleans or objects.
+func (cfg *Config) applyEnvOverrides() {
+	cfgValue := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < cfgValue.NumField(); i++ {
+		section := jsonName(cfgValue.Type().Field(i))
+		sectionValue := cfgValue.Field(i)
+		if sectionValue.Kind() != reflect.Struct || !cfgValue.Type().Field(i).IsExported() {
+			continue
+		}
+		for j := 0; j < sectionValue.NumField(); j++ {
+			field := jsonName(sectionValue.Type().Field(j))
+			name := EnvName(section, field)
+			env, ok := os.LookupEnv(name)
+			if !ok {
+				continue
+			}
+			if err := setFromEnv(sectionValue.Field(j), env); err != nil {
+				panic(fmt.Sprintf("invalid value of %s for %s.%s, err=%+v", name, section, field, err.Error()))
+			}
+		}
+	}
+}
+
+func setFromEnv(field reflect.Value, env string) error {
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(env)
+		return nil
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		values := make([]string, 0)
+		for _, v := range strings.Split(env, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+		return nil
+	default:
+		return json.Unmarshal([]byte(env), field.Addr().Interface())
+	}
+}
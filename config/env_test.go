@@ -0,0 +1,24 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyEnvOverrides(t *testing.T) {
+	t.Setenv("CHALLENGER_GREENFIELD_RPC_ADDRS", "http://rpc1:26750, http://rpc2:26750")
+	t.Setenv("CHALLENGER_GREENFIELD_PRIVATE_KEY", "env_key")
+	t.Setenv("CHALLENGER_DB_MAX_OPEN_CONNS", "80")
+	t.Setenv("CHALLENGER_LOG_USE_FILE_LOGGER", "true")
+
+	cfg := &Config{GreenfieldConfig: GreenfieldConfig{PrivateKey: "file_key"}}
+	cfg.applyEnvOverrides()
+	require.Equal(t, []string{"http://rpc1:26750", "http://rpc2:26750"}, cfg.GreenfieldConfig.RPCAddrs)
+	require.Equal(t, "env_key", cfg.GreenfieldConfig.PrivateKey)
+	require.Equal(t, 80, cfg.DBConfig.MaxOpenConns)
+	require.True(t, cfg.LogConfig.UseFileLogger)
+
+	t.Setenv("CHALLENGER_DB_MAX_OPEN_CONNS", "many")
+	require.Panics(t, cfg.applyEnvOverrides)
+}
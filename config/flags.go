@@ -0,0 +1,20 @@
+package config
+
+import "github.com/spf13/viper"
+
+// applyFlagOverrides overrides the config fields with the command line flags which are set explicitly, flags take
+// precedence over both the config file and the environment variables.
+func (cfg *Config) applyFlagOverrides() {
+	if viper.IsSet(FlagLogLevel) {
+		cfg.LogConfig.Level = viper.GetString(FlagLogLevel)
+	}
+	if viper.IsSet(FlagMetricsPort) {
+		cfg.MetricsConfig.Port = viper.GetUint16(FlagMetricsPort)
+	}
+	if viper.IsSet(FlagStartHeight) {
+		cfg.GreenfieldConfig.StartHeight = viper.GetUint64(FlagStartHeight)
+	}
+	if viper.IsSet(FlagDryRun) {
+		cfg.PipelineConfig.DryRun = viper.GetBool(FlagDryRun)
+	}
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyFlagOverrides(t *testing.T) {
+	defer viper.Reset()
+	cfg := &Config{
+		LogConfig:     LogConfig{Level: "INFO"},
+		MetricsConfig: MetricsConfig{Port: 9000},
+	}
+	cfg.applyFlagOverrides()
+	require.Equal(t, "INFO", cfg.LogConfig.Level)
+	require.Equal(t, uint16(9000), cfg.MetricsConfig.Port)
+
+	viper.Set(FlagLogLevel, "DEBUG")
+	viper.Set(FlagMetricsPort, 9100)
+	viper.Set(FlagStartHeight, 1200)
+	viper.Set(FlagDryRun, true)
+	cfg.applyFlagOverrides()
+	require.Equal(t, "DEBUG", cfg.LogConfig.Level)
+	require.Equal(t, uint16(9100), cfg.MetricsConfig.Port)
+	require.Equal(t, uint64(1200), cfg.GreenfieldConfig.StartHeight)
+	require.True(t, cfg.PipelineConfig.DryRun)
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// configToJson converts yaml and toml config files, detected by their extension, to json, so that every format
+// shares the json schema of Config. Other files are expected to be json already.
+func configToJson(filePath string, content []byte) ([]byte, error) {
+	var fields map[string]interface{}
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(content, &fields); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		if err := toml.Unmarshal(content, &fields); err != nil {
+			return nil, err
+		}
+	default:
+		return content, nil
+	}
+	return json.Marshal(fields)
+}
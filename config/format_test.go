@@ -0,0 +1,46 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigToJson(t *testing.T) {
+	yamlContent := `
+greenfield_config:
+  rpc_addrs:
+    - https://gnfd-testnet-fullnode-tendermint-us.bnbchain.org:443
+  gas_limit: 1000
+log_config:
+  level: INFO
+  use_console_logger: true
+`
+	tomlContent := `
+[greenfield_config]
+rpc_addrs = ["https://gnfd-testnet-fullnode-tendermint-us.bnbchain.org:443"]
+gas_limit = 1000
+
+[log_config]
+level = "INFO"
+use_console_logger = true
+`
+	jsonContent := `{
+  "greenfield_config": {
+    "rpc_addrs": ["https://gnfd-testnet-fullnode-tendermint-us.bnbchain.org:443"],
+    "gas_limit": 1000
+  },
+  "log_config": {
+    "level": "INFO",
+    "use_console_logger": true
+  }
+}`
+	expected, err := configToJson("config.json", []byte(jsonContent))
+	require.NoError(t, err)
+	fromYaml, err := configToJson("config.yaml", []byte(yamlContent))
+	require.NoError(t, err)
+	require.JSONEq(t, string(expected), string(fromYaml))
+	fromToml, err := configToJson("config.TOML", []byte(tomlContent))
+	require.NoError(t, err)
+	require.JSONEq(t, string(expected), string(fromToml))
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/crypto"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"golang.org/x/term"
+)
+
+var (
+	passphraseOnce sync.Once
+	passphrase     string
+	passphraseErr  error
+)
+
+// LoadKeystoreKey decrypts the armored private key at path, as written by `gnfd keys export`, and returns it hex
+// encoded.
+func LoadKeystoreKey(path, passphrase string) (string, error) {
+	armor, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	privKey, _, err := crypto.UnarmorDecryptPrivKey(string(armor), passphrase)
+	if err != nil {
+		return "", fmt.Errorf("decrypt keystore %s error, err=%+v", path, err)
+	}
+	return hex.EncodeToString(privKey.Bytes()), nil
+}
+
+// WriteKeystoreKey armors privKey encrypted with passphrase to path, in the format read by LoadKeystoreKey. A key
+// already at path is kept as path.bak-<unix seconds>, so that a rotated key can still be recovered.
+func WriteKeystoreKey(path, passphrase string, privKey cryptotypes.PrivKey) error {
+	armor := crypto.EncryptArmorPrivKey(privKey, passphrase, privKey.Type())
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.WriteString(armor); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if _, err = os.Stat(path); err == nil {
+		if err = os.Rename(path, fmt.Sprintf("%s.bak-%d", path, time.Now().Unix())); err != nil {
+			return fmt.Errorf("back up keystore %s error, err=%+v", path, err)
+		}
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// KeystorePassphrase returns the passphrase unlocking the keystores, it is read from the KEYSTORE_PASSPHRASE
+// environment variable, or prompted for once if stdin is a terminal.
+func KeystorePassphrase() (string, error) {
+	passphraseOnce.Do(func() {
+		if env, ok := os.LookupEnv(KeystorePassphraseEnv); ok {
+			passphrase = env
+			return
+		}
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			passphraseErr = fmt.Errorf("%s is not set and stdin is not a terminal", KeystorePassphraseEnv)
+			return
+		}
+		fmt.Fprint(os.Stderr, "keystore passphrase: ")
+		bz, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		passphrase, passphraseErr = string(bz), err
+	})
+	return passphrase, passphraseErr
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/crypto"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/eth/bls"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/eth/ethsecp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadKeystoreKey(t *testing.T) {
+	privKey, err := ethsecp256k1.GenPrivKey()
+	require.NoError(t, err)
+	blsPrivKey, err := bls.GenPrivKey()
+	require.NoError(t, err)
+
+	for _, key := range []cryptotypes.PrivKey{privKey, blsPrivKey} {
+		path := filepath.Join(t.TempDir(), "key.armor")
+		armor := crypto.EncryptArmorPrivKey(key, "passphrase", key.Type())
+		require.NoError(t, os.WriteFile(path, []byte(armor), 0o600))
+
+		hexKey, err := LoadKeystoreKey(path, "passphrase")
+		require.NoError(t, err)
+		require.Equal(t, hex.EncodeToString(key.Bytes()), hexKey)
+
+		_, err = LoadKeystoreKey(path, "wrong passphrase")
+		require.Error(t, err)
+	}
+}
+
+func TestWriteKeystoreKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key.armor")
+	for i := 0; i < 2; i++ {
+		key, err := bls.GenPrivKey()
+		require.NoError(t, err)
+		require.NoError(t, WriteKeystoreKey(path, "passphrase", key))
+		hexKey, err := LoadKeystoreKey(path, "passphrase")
+		require.NoError(t, err)
+		require.Equal(t, hex.EncodeToString(key.Bytes()), hexKey)
+	}
+	// the first key is kept as a backup when overwritten
+	backups, err := filepath.Glob(path + ".bak-*")
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+}
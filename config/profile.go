@@ -0,0 +1,82 @@
+package config
+
+import "sort"
+
+// NetworkProfile holds the well known settings of a greenfield network, so that an operator only needs to supply the
+// keys and the database settings.
+type NetworkProfile struct {
+	ChainIdString string
+	RPCAddrs      []string
+	GasLimit      uint64
+	FeeAmount     string
+	FeeDenom      string
+}
+
+var networkProfiles = map[string]NetworkProfile{
+	NetworkGreenfieldMainnet: {
+		ChainIdString: "greenfield_1017-1",
+		RPCAddrs: []string{
+			"https://greenfield-chain.bnbchain.org:443",
+			"https://greenfield-chain-ap.bnbchain.org:443",
+			"https://greenfield-chain-eu.bnbchain.org:443",
+			"https://greenfield-chain-us.bnbchain.org:443",
+		},
+		GasLimit:  1000,
+		FeeAmount: "5000000000000",
+		FeeDenom:  "BNB",
+	},
+	NetworkGreenfieldTestnet: {
+		ChainIdString: "greenfield_5600-1",
+		RPCAddrs: []string{
+			"https://gnfd-testnet-fullnode-tendermint-us.bnbchain.org:443",
+			"https://gnfd-testnet-fullnode-tendermint-ap.bnbchain.org:443",
+		},
+		GasLimit:  1000,
+		FeeAmount: "5000000000000",
+		FeeDenom:  "BNB",
+	},
+}
+
+// GetNetworkProfile returns the profile of a named network.
+func GetNetworkProfile(network string) (NetworkProfile, bool) {
+	profile, ok := networkProfiles[network]
+	return profile, ok
+}
+
+// NetworkNames returns the names of the built-in network profiles.
+func NetworkNames() []string {
+	names := make([]string, 0, len(networkProfiles))
+	for name := range networkProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyNetworkProfile fills the greenfield fields left empty in the config from the profile of the configured
+// network, the fields which are set explicitly take precedence.
+func (cfg *GreenfieldConfig) applyNetworkProfile() {
+	if cfg.Network == "" {
+		return
+	}
+	profile, ok := GetNetworkProfile(cfg.Network)
+	if !ok {
+		// reported by validate
+		return
+	}
+	if cfg.ChainIdString == "" {
+		cfg.ChainIdString = profile.ChainIdString
+	}
+	if len(cfg.RPCAddrs) == 0 {
+		cfg.RPCAddrs = append([]string{}, profile.RPCAddrs...)
+	}
+	if cfg.GasLimit == 0 {
+		cfg.GasLimit = profile.GasLimit
+	}
+	if cfg.FeeAmount == "" {
+		cfg.FeeAmount = profile.FeeAmount
+	}
+	if cfg.FeeDenom == "" {
+		cfg.FeeDenom = profile.FeeDenom
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyNetworkProfile(t *testing.T) {
+	cfg := &Config{
+		GreenfieldConfig: GreenfieldConfig{
+			Network:       NetworkGreenfieldTestnet,
+			KeyType:       KeyTypeLocalPrivateKey,
+			PrivateKey:    "6e2d7ab4a5d2d6a6e2d7ab4a5d2d6a6e2d7ab4a5d2d6a6e2d7ab4a5d2d6a6e2d",
+			BlsPrivateKey: "6e2d7ab4a5d2d6a6e2d7ab4a5d2d6a6e2d7ab4a5d2d6a6e2d7ab4a5d2d6a6e2d",
+			RPCAddrs:      []string{"https://my-testnet-node:443"},
+		},
+		LogConfig:      LogConfig{Level: "INFO"},
+		DBConfig:       DBConfig{Dialect: DBDialectSqlite3, DBPath: DBPathInMemory},
+		MetricsConfig:  MetricsConfig{Port: 9000},
+		PipelineConfig: PipelineConfig{CacheSize: 1000, ShutdownTimeoutInSeconds: 30},
+	}
+	cfg.GreenfieldConfig.applyNetworkProfile()
+	require.NotPanics(t, cfg.Validate)
+	// explicit fields take precedence over the profile
+	require.Equal(t, []string{"https://my-testnet-node:443"}, cfg.GreenfieldConfig.RPCAddrs)
+	require.Equal(t, "greenfield_5600-1", cfg.GreenfieldConfig.ChainIdString)
+	require.Equal(t, uint64(1000), cfg.GreenfieldConfig.GasLimit)
+	require.Equal(t, "BNB", cfg.GreenfieldConfig.FeeDenom)
+
+	cfg.GreenfieldConfig.ChainIdString = "greenfield_1017-1"
+	require.PanicsWithValue(t, "invalid config:\n"+
+		"  greenfield_config.chain_id_string: \"greenfield_1017-1\" does not match the chain id greenfield_5600-1 of network greenfield-testnet",
+		cfg.Validate)
+
+	cfg.GreenfieldConfig.Network = "greenfield-devnet"
+	require.PanicsWithValue(t, "invalid config:\n"+
+		"  greenfield_config.network: unknown network \"greenfield-devnet\", use one of greenfield-mainnet, greenfield-testnet",
+		cfg.Validate)
+}
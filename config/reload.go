@@ -0,0 +1,49 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// reloadableFields are the json paths of the config fields which can be reloaded while the challenger is running, a
+// section covers all of its fields.
+var reloadableFields = map[string]bool{
+	"log_config.level":         true,
+	"log_config.module_levels": true,
+	"alert_config":             true,
+	"tunable_config":           true,
+}
+
+// ImmutableChanges returns the json paths of the fields which differ in newCfg but can only be changed by restarting
+// the challenger, e.g. keys and the database settings.
+func (cfg *Config) ImmutableChanges(newCfg *Config) []string {
+	changes := make([]string, 0)
+	oldValue, newValue := reflect.ValueOf(cfg).Elem(), reflect.ValueOf(newCfg).Elem()
+	for i := 0; i < oldValue.NumField(); i++ {
+		section := jsonName(oldValue.Type().Field(i))
+		if reloadableFields[section] || !oldValue.Type().Field(i).IsExported() {
+			continue
+		}
+		oldSection, newSection := oldValue.Field(i), newValue.Field(i)
+		if oldSection.Kind() != reflect.Struct {
+			if !reflect.DeepEqual(oldSection.Interface(), newSection.Interface()) {
+				changes = append(changes, section)
+			}
+			continue
+		}
+		for j := 0; j < oldSection.NumField(); j++ {
+			path := section + "." + jsonName(oldSection.Type().Field(j))
+			if reloadableFields[path] {
+				continue
+			}
+			if !reflect.DeepEqual(oldSection.Field(j).Interface(), newSection.Field(j).Interface()) {
+				changes = append(changes, path)
+			}
+		}
+	}
+	return changes
+}
+
+func jsonName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
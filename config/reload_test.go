@@ -0,0 +1,30 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmutableChanges(t *testing.T) {
+	newConfig := func() *Config {
+		return &Config{
+			GreenfieldConfig: GreenfieldConfig{PrivateKey: "key", RPCAddrs: []string{"http://localhost:26750"}},
+			LogConfig:        LogConfig{Level: "INFO"},
+			DBConfig:         DBConfig{DBPath: "path"},
+		}
+	}
+	cfg := newConfig()
+
+	newCfg := newConfig()
+	newCfg.LogConfig.Level = "ERROR"
+	newCfg.AlertConfig.TelegramChatId = "other_chat"
+	newCfg.TunableConfig.RetryAttempts = 5
+	require.Empty(t, cfg.ImmutableChanges(newCfg))
+
+	newCfg.GreenfieldConfig.PrivateKey = "other_key"
+	newCfg.GreenfieldConfig.RPCAddrs = append(newCfg.GreenfieldConfig.RPCAddrs, "http://localhost:26751")
+	newCfg.DBConfig.DBPath = "other_path"
+	require.Equal(t, []string{"greenfield_config.private_key", "greenfield_config.rpc_addrs", "db_config.db_path"},
+		cfg.ImmutableChanges(newCfg))
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RemoteWatchTimeout bounds a single watch request, the watch is restarted after it.
+const RemoteWatchTimeout = 5 * time.Minute
+
+// RemoteSource reads the config from a key value store shared by many challengers.
+type RemoteSource interface {
+	// Get returns the config content and its revision.
+	Get() (string, uint64, error)
+	// Wait blocks until the config is changed after revision or RemoteWatchTimeout passes, and returns the latest
+	// revision known to it.
+	Wait(revision uint64) (uint64, error)
+}
+
+// NewRemoteSource returns the remote source of configType, addr is the http address of the store and key the key
+// holding the config.
+func NewRemoteSource(configType, addr, key string) (RemoteSource, error) {
+	switch configType {
+	case EtcdConfig:
+		return NewEtcdSource(addr, key), nil
+	case ConsulConfig:
+		return NewConsulSource(addr, key), nil
+	default:
+		return nil, fmt.Errorf("config type %s is not a remote source", configType)
+	}
+}
+
+// EtcdSource reads the config from etcd through its v3 json gateway.
+type EtcdSource struct {
+	addr   string
+	key    string
+	client *http.Client
+}
+
+func NewEtcdSource(addr, key string) *EtcdSource {
+	return &EtcdSource{
+		addr:   strings.TrimSuffix(addr, "/"),
+		key:    base64.StdEncoding.EncodeToString([]byte(key)),
+		client: &http.Client{},
+	}
+}
+
+func (s *EtcdSource) Get() (string, uint64, error) {
+	var resp struct {
+		Kvs []struct {
+			Value       string `json:"value"`
+			ModRevision string `json:"mod_revision"`
+		} `json:"kvs"`
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), SecretProviderTimeout)
+	defer cancel()
+	body, err := s.post(ctx, "/v3/kv/range", map[string]interface{}{"key": s.key})
+	if err != nil {
+		return "", 0, err
+	}
+	defer body.Close()
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		return "", 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return "", 0, fmt.Errorf("etcd key not found")
+	}
+	value, err := base64.StdEncoding.DecodeString(resp.Kvs[0].Value)
+	if err != nil {
+		return "", 0, err
+	}
+	revision, err := strconv.ParseUint(resp.Kvs[0].ModRevision, 10, 64)
+	if err != nil {
+		return "", 0, err
+	}
+	return string(value), revision, nil
+}
+
+func (s *EtcdSource) Wait(revision uint64) (uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), RemoteWatchTimeout)
+	defer cancel()
+	body, err := s.post(ctx, "/v3/watch", map[string]interface{}{
+		"create_request": map[string]interface{}{
+			"key":            s.key,
+			"start_revision": strconv.FormatUint(revision+1, 10),
+		},
+	})
+	if err != nil {
+		return revision, err
+	}
+	defer body.Close()
+
+	// the watch streams a json object per message, the first one confirms the creation of the watch
+	decoder := json.NewDecoder(body)
+	for {
+		var msg struct {
+			Result struct {
+				Events []struct {
+					Kv struct {
+						ModRevision string `json:"mod_revision"`
+					} `json:"kv"`
+				} `json:"events"`
+			} `json:"result"`
+		}
+		if err = decoder.Decode(&msg); err != nil {
+			if ctx.Err() != nil {
+				return revision, nil
+			}
+			return revision, err
+		}
+		events := msg.Result.Events
+		if len(events) == 0 {
+			continue
+		}
+		return strconv.ParseUint(events[len(events)-1].Kv.ModRevision, 10, 64)
+	}
+}
+
+func (s *EtcdSource) post(ctx context.Context, path string, reqBody interface{}) (io.ReadCloser, error) {
+	bz, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.addr+path, bytes.NewReader(bz))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status %d, body=%s", resp.StatusCode, string(body))
+	}
+	return resp.Body, nil
+}
+
+// ConsulSource reads the config from the consul kv store, changes are watched with blocking queries.
+type ConsulSource struct {
+	addr   string
+	key    string
+	client *http.Client
+}
+
+func NewConsulSource(addr, key string) *ConsulSource {
+	return &ConsulSource{
+		addr:   strings.TrimSuffix(addr, "/"),
+		key:    strings.TrimPrefix(key, "/"),
+		client: &http.Client{Timeout: RemoteWatchTimeout + SecretProviderTimeout},
+	}
+}
+
+func (s *ConsulSource) Get() (string, uint64, error) {
+	return s.get(url.Values{})
+}
+
+func (s *ConsulSource) Wait(revision uint64) (uint64, error) {
+	query := url.Values{
+		"index": {strconv.FormatUint(revision, 10)},
+		"wait":  {fmt.Sprintf("%ds", int(RemoteWatchTimeout.Seconds()))},
+	}
+	_, index, err := s.get(query)
+	if err != nil {
+		return revision, err
+	}
+	return index, nil
+}
+
+func (s *ConsulSource) get(query url.Values) (string, uint64, error) {
+	var kvs []struct {
+		Value       string `json:"Value"`
+		ModifyIndex uint64 `json:"ModifyIndex"`
+	}
+	err := getJson(s.client, fmt.Sprintf("%s/v1/kv/%s?%s", s.addr, s.key, query.Encode()), nil, &kvs)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(kvs) == 0 {
+		return "", 0, fmt.Errorf("consul key not found")
+	}
+	value, err := base64.StdEncoding.DecodeString(kvs[0].Value)
+	if err != nil {
+		return "", 0, err
+	}
+	return string(value), kvs[0].ModifyIndex, nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEtcdSource(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("/challenger/config"))
+	value := base64.StdEncoding.EncodeToString([]byte(`{"log_config":{"level":"INFO"}}`))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		switch r.URL.Path {
+		case "/v3/kv/range":
+			require.Equal(t, key, req["key"])
+			fmt.Fprintf(w, `{"kvs":[{"value":"%s","mod_revision":"7"}]}`, value)
+		case "/v3/watch":
+			createReq := req["create_request"].(map[string]interface{})
+			require.Equal(t, "8", createReq["start_revision"])
+			fmt.Fprint(w, `{"result":{"created":true}}`)
+			fmt.Fprint(w, `{"result":{"events":[{"kv":{"mod_revision":"9"}}]}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	source, err := NewRemoteSource(EtcdConfig, server.URL, "/challenger/config")
+	require.NoError(t, err)
+	content, revision, err := source.Get()
+	require.NoError(t, err)
+	require.Equal(t, `{"log_config":{"level":"INFO"}}`, content)
+	require.Equal(t, uint64(7), revision)
+
+	revision, err = source.Wait(revision)
+	require.NoError(t, err)
+	require.Equal(t, uint64(9), revision)
+}
+
+func TestConsulSource(t *testing.T) {
+	value := base64.StdEncoding.EncodeToString([]byte(`{"log_config":{"level":"INFO"}}`))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/kv/challenger/config", r.URL.Path)
+		index := 3
+		if r.URL.Query().Get("index") == "3" {
+			index = 4
+		}
+		fmt.Fprintf(w, `[{"Value":"%s","ModifyIndex":%d}]`, value, index)
+	}))
+	defer server.Close()
+
+	source, err := NewRemoteSource(ConsulConfig, server.URL, "/challenger/config")
+	require.NoError(t, err)
+	content, revision, err := source.Get()
+	require.NoError(t, err)
+	require.Equal(t, `{"log_config":{"level":"INFO"}}`, content)
+	require.Equal(t, uint64(3), revision)
+
+	revision, err = source.Wait(revision)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), revision)
+
+	_, err = NewRemoteSource(LocalConfig, server.URL, "/challenger/config")
+	require.Error(t, err)
+}
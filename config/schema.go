@@ -0,0 +1,117 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// CurrentConfigVersion is the version of the config schema, configs without a version are version 1.
+const CurrentConfigVersion = 2
+
+// configMigration upgrades a config from version from to from+1, it returns warnings about the fields it changed.
+type configMigration struct {
+	from    int
+	migrate func(fields map[string]interface{}) []string
+}
+
+var configMigrations = []configMigration{
+	{from: 1, migrate: migrateConfigV1},
+}
+
+// migrateConfigV1 drops the fields of version 1 which were never read, so that operators learn they have no effect.
+func migrateConfigV1(fields map[string]interface{}) []string {
+	warnings := make([]string, 0)
+	for _, path := range [][2]string{
+		{"alert_config", "interval"},
+		{"greenfield_config", "no_simulate"},
+		{"greenfield_config", "deduplication_interval"},
+	} {
+		section, ok := fields[path[0]].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok = section[path[1]]; ok {
+			delete(section, path[1])
+			warnings = append(warnings, fmt.Sprintf("%s.%s was removed in config version 2 and is ignored", path[0], path[1]))
+		}
+	}
+	return warnings
+}
+
+// migrateConfig upgrades the json config content to CurrentConfigVersion, and returns warnings about the migrated
+// fields and the fields which are not part of the schema.
+func migrateConfig(content []byte) ([]byte, []string, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return nil, nil, err
+	}
+	version := 1
+	if v, ok := fields["version"]; ok {
+		f, ok := v.(float64)
+		if !ok || f != float64(int(f)) || f < 1 {
+			return nil, nil, fmt.Errorf("invalid config version %v", v)
+		}
+		version = int(f)
+	}
+	if version > CurrentConfigVersion {
+		return nil, nil, fmt.Errorf("config version %d is newer than the supported version %d", version,
+			CurrentConfigVersion)
+	}
+
+	warnings := make([]string, 0)
+	if version < CurrentConfigVersion {
+		warnings = append(warnings, fmt.Sprintf("config version %d is migrated to version %d, run config dump to "+
+			"get the migrated config", version, CurrentConfigVersion))
+	}
+	for _, m := range configMigrations {
+		if m.from >= version {
+			warnings = append(warnings, m.migrate(fields)...)
+		}
+	}
+	fields["version"] = CurrentConfigVersion
+	warnings = append(warnings, unknownFields(fields)...)
+
+	bz, err := json.Marshal(fields)
+	return bz, warnings, err
+}
+
+// unknownFields returns warnings about the fields which are not part of Config, e.g. misspelled fields.
+func unknownFields(fields map[string]interface{}) []string {
+	known := make(map[string]map[string]bool)
+	cfgType := reflect.TypeOf(Config{})
+	for i := 0; i < cfgType.NumField(); i++ {
+		section := cfgType.Field(i)
+		if !section.IsExported() {
+			continue
+		}
+		known[jsonName(section)] = make(map[string]bool)
+		if section.Type.Kind() != reflect.Struct {
+			continue
+		}
+		for j := 0; j < section.Type.NumField(); j++ {
+			known[jsonName(section)][jsonName(section.Type.Field(j))] = true
+		}
+	}
+
+	warnings := make([]string, 0)
+	for name, value := range fields {
+		knownFields, ok := known[name]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("%s is not a config field and is ignored", name))
+			continue
+		}
+		section, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for field := range section {
+			if !knownFields[field] {
+				warnings = append(warnings, fmt.Sprintf("%s.%s is not a config field and is ignored", name, field))
+			}
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateConfig(t *testing.T) {
+	bz, warnings, err := migrateConfig([]byte(`{
+		"greenfield_config": {"chain_id_string": "greenfield_5600-1", "no_simulate": true},
+		"alert_config": {"interval": 300, "identity": "challenger"},
+		"log_config": {"levl": "INFO"}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"config version 1 is migrated to version 2, run config dump to get the migrated config",
+		"alert_config.interval was removed in config version 2 and is ignored",
+		"greenfield_config.no_simulate was removed in config version 2 and is ignored",
+		"log_config.levl is not a config field and is ignored",
+	}, warnings)
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(bz, &fields))
+	require.Equal(t, float64(CurrentConfigVersion), fields["version"])
+	require.Equal(t, map[string]interface{}{"identity": "challenger"}, fields["alert_config"])
+
+	_, warnings, err = migrateConfig([]byte(`{"version": 2, "greenfield_config": {"chain_id_string": "greenfield_5600-1"}}`))
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+
+	_, _, err = migrateConfig([]byte(`{"version": 3}`))
+	require.EqualError(t, err, "config version 3 is newer than the supported version 2")
+}
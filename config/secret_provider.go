@@ -0,0 +1,243 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SecretProviderTimeout bounds a single request to a secret manager.
+const SecretProviderTimeout = 30 * time.Second
+
+var (
+	gcpMetadataTokenURL   = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	gcpSecretManagerURL   = "https://secretmanager.googleapis.com/v1"
+	azureIMDSTokenURL     = "http://169.254.169.254/metadata/identity/oauth2/token"
+	azureKeyVaultResource = "https://vault.azure.net"
+)
+
+// SecretProvider fetches secrets, e.g. the challenger keys, from a secret manager, and stores them on key rotation.
+type SecretProvider interface {
+	GetSecret(name string) (string, error)
+	// PutSecret stores value as the latest version of the secret, creating the secret if it does not exist.
+	PutSecret(name, value string) error
+}
+
+// NewSecretProvider returns the secret provider of the key type of cfg.
+func NewSecretProvider(cfg *GreenfieldConfig) (SecretProvider, error) {
+	switch cfg.KeyType {
+	case KeyTypeAWSPrivateKey:
+		return &AWSSecretProvider{Region: cfg.AWSRegion}, nil
+	case KeyTypeGCPPrivateKey:
+		return NewGCPSecretProvider(cfg.GCPProjectId), nil
+	case KeyTypeAzurePrivateKey:
+		return NewAzureSecretProvider(cfg.AzureVaultURL, cfg.AzureClientId), nil
+	default:
+		return nil, fmt.Errorf("key_type %s does not use a secret manager", cfg.KeyType)
+	}
+}
+
+// AWSSecretProvider reads secrets from AWS Secrets Manager.
+type AWSSecretProvider struct {
+	Region string
+}
+
+func (p *AWSSecretProvider) GetSecret(name string) (string, error) {
+	return GetSecret(name, p.Region)
+}
+
+func (p *AWSSecretProvider) PutSecret(name, value string) error {
+	return PutSecret(name, value, p.Region)
+}
+
+// GCPSecretProvider reads the latest version of secrets from GCP Secret Manager, authenticated as the service account
+// of the instance.
+type GCPSecretProvider struct {
+	projectId string
+	client    *http.Client
+}
+
+func NewGCPSecretProvider(projectId string) *GCPSecretProvider {
+	return &GCPSecretProvider{
+		projectId: projectId,
+		client:    &http.Client{Timeout: SecretProviderTimeout},
+	}
+}
+
+func (p *GCPSecretProvider) GetSecret(name string) (string, error) {
+	token, err := p.token()
+	if err != nil {
+		return "", err
+	}
+
+	var secret struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	secretURL := fmt.Sprintf("%s/projects/%s/secrets/%s/versions/latest:access", gcpSecretManagerURL,
+		url.PathEscape(p.projectId), url.PathEscape(name))
+	err = getJson(p.client, secretURL, map[string]string{"Authorization": "Bearer " + token}, &secret)
+	if err != nil {
+		return "", fmt.Errorf("get gcp secret %s error, err=%+v", name, err)
+	}
+	data, err := base64.StdEncoding.DecodeString(secret.Payload.Data)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (p *GCPSecretProvider) PutSecret(name, value string) error {
+	token, err := p.token()
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{"Authorization": "Bearer " + token}
+	secretURL := fmt.Sprintf("%s/projects/%s/secrets/%s", gcpSecretManagerURL, url.PathEscape(p.projectId),
+		url.PathEscape(name))
+	version := map[string]interface{}{
+		"payload": map[string]string{"data": base64.StdEncoding.EncodeToString([]byte(value))},
+	}
+	status, err := sendJson(p.client, http.MethodPost, secretURL+":addVersion", headers, version, nil)
+	if status == http.StatusNotFound {
+		secretsURL := fmt.Sprintf("%s/projects/%s/secrets?secretId=%s", gcpSecretManagerURL,
+			url.PathEscape(p.projectId), url.QueryEscape(name))
+		secret := map[string]interface{}{"replication": map[string]interface{}{"automatic": map[string]string{}}}
+		if _, err = sendJson(p.client, http.MethodPost, secretsURL, headers, secret, nil); err != nil {
+			return fmt.Errorf("create gcp secret %s error, err=%+v", name, err)
+		}
+		_, err = sendJson(p.client, http.MethodPost, secretURL+":addVersion", headers, version, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("put gcp secret %s error, err=%+v", name, err)
+	}
+	return nil
+}
+
+func (p *GCPSecretProvider) token() (string, error) {
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	err := getJson(p.client, gcpMetadataTokenURL, map[string]string{"Metadata-Flavor": "Google"}, &token)
+	if err != nil {
+		return "", fmt.Errorf("get gcp access token error, err=%+v", err)
+	}
+	return token.AccessToken, nil
+}
+
+// AzureSecretProvider reads secrets from Azure Key Vault, authenticated with the managed identity of the instance.
+// clientId selects a user assigned identity, the system assigned identity is used when it is empty.
+type AzureSecretProvider struct {
+	vaultURL string
+	clientId string
+	client   *http.Client
+}
+
+func NewAzureSecretProvider(vaultURL, clientId string) *AzureSecretProvider {
+	return &AzureSecretProvider{
+		vaultURL: strings.TrimSuffix(vaultURL, "/"),
+		clientId: clientId,
+		client:   &http.Client{Timeout: SecretProviderTimeout},
+	}
+}
+
+func (p *AzureSecretProvider) GetSecret(name string) (string, error) {
+	token, err := p.token()
+	if err != nil {
+		return "", err
+	}
+
+	var secret struct {
+		Value string `json:"value"`
+	}
+	secretURL := fmt.Sprintf("%s/secrets/%s?api-version=7.4", p.vaultURL, url.PathEscape(name))
+	err = getJson(p.client, secretURL, map[string]string{"Authorization": "Bearer " + token}, &secret)
+	if err != nil {
+		return "", fmt.Errorf("get azure secret %s error, err=%+v", name, err)
+	}
+	return secret.Value, nil
+}
+
+// PutSecret sets a new version of the secret, Key Vault creates the secret on its first version.
+func (p *AzureSecretProvider) PutSecret(name, value string) error {
+	token, err := p.token()
+	if err != nil {
+		return err
+	}
+	secretURL := fmt.Sprintf("%s/secrets/%s?api-version=7.4", p.vaultURL, url.PathEscape(name))
+	_, err = sendJson(p.client, http.MethodPut, secretURL, map[string]string{"Authorization": "Bearer " + token},
+		map[string]string{"value": value}, nil)
+	if err != nil {
+		return fmt.Errorf("put azure secret %s error, err=%+v", name, err)
+	}
+	return nil
+}
+
+func (p *AzureSecretProvider) token() (string, error) {
+	query := url.Values{
+		"api-version": {"2018-02-01"},
+		"resource":    {azureKeyVaultResource},
+	}
+	if p.clientId != "" {
+		query.Set("client_id", p.clientId)
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	err := getJson(p.client, azureIMDSTokenURL+"?"+query.Encode(), map[string]string{"Metadata": "true"}, &token)
+	if err != nil {
+		return "", fmt.Errorf("get azure access token error, err=%+v", err)
+	}
+	return token.AccessToken, nil
+}
+
+func getJson(client *http.Client, url string, headers map[string]string, v interface{}) error {
+	_, err := sendJson(client, http.MethodGet, url, headers, nil, v)
+	return err
+}
+
+// sendJson sends in as the json body of the request if it is not nil, and decodes the response into out if it is not
+// nil. It returns the status of the response, any status but 200 is an error.
+func sendJson(client *http.Client, method, url string, headers map[string]string, in, out interface{}) (int, error) {
+	var reqBody io.Reader
+	if in != nil {
+		bz, err := json.Marshal(in)
+		if err != nil {
+			return 0, err
+		}
+		reqBody = bytes.NewReader(bz)
+	}
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		return 0, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, h := range headers {
+		req.Header.Set(k, h)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d, body=%s", resp.StatusCode, string(body))
+	}
+	if out == nil {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, json.Unmarshal(body, out)
+}
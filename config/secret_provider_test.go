@@ -0,0 +1,155 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCPSecretProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			require.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+			fmt.Fprint(w, `{"access_token":"gcp_token"}`)
+		case "/projects/my-project/secrets/challenger/versions/latest:access":
+			require.Equal(t, "Bearer gcp_token", r.Header.Get("Authorization"))
+			data := base64.StdEncoding.EncodeToString([]byte(`{"private_key":"abc"}`))
+			fmt.Fprintf(w, `{"payload":{"data":"%s"}}`, data)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tokenURL, secretManagerURL := gcpMetadataTokenURL, gcpSecretManagerURL
+	gcpMetadataTokenURL, gcpSecretManagerURL = server.URL+"/token", server.URL
+	defer func() {
+		gcpMetadataTokenURL, gcpSecretManagerURL = tokenURL, secretManagerURL
+	}()
+
+	provider, err := NewSecretProvider(&GreenfieldConfig{KeyType: KeyTypeGCPPrivateKey, GCPProjectId: "my-project"})
+	require.NoError(t, err)
+	secret, err := provider.GetSecret("challenger")
+	require.NoError(t, err)
+	require.Equal(t, `{"private_key":"abc"}`, secret)
+
+	_, err = provider.GetSecret("missing")
+	require.Error(t, err)
+}
+
+func TestAzureSecretProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			require.Equal(t, "true", r.Header.Get("Metadata"))
+			require.Equal(t, "client", r.URL.Query().Get("client_id"))
+			fmt.Fprint(w, `{"access_token":"azure_token"}`)
+		case "/secrets/challenger":
+			require.Equal(t, "Bearer azure_token", r.Header.Get("Authorization"))
+			fmt.Fprint(w, `{"value":"{\"bls_private_key\":\"def\"}"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tokenURL := azureIMDSTokenURL
+	azureIMDSTokenURL = server.URL + "/token"
+	defer func() {
+		azureIMDSTokenURL = tokenURL
+	}()
+
+	provider, err := NewSecretProvider(&GreenfieldConfig{
+		KeyType:       KeyTypeAzurePrivateKey,
+		AzureVaultURL: server.URL + "/",
+		AzureClientId: "client",
+	})
+	require.NoError(t, err)
+	secret, err := provider.GetSecret("challenger")
+	require.NoError(t, err)
+	require.Equal(t, `{"bls_private_key":"def"}`, secret)
+
+	_, err = NewSecretProvider(&GreenfieldConfig{KeyType: KeyTypeLocalPrivateKey})
+	require.Error(t, err)
+}
+
+func TestGCPSecretProvider_PutSecret(t *testing.T) {
+	secrets := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			fmt.Fprint(w, `{"access_token":"gcp_token"}`)
+		case r.URL.Path == "/projects/my-project/secrets" && r.Method == http.MethodPost:
+			require.Equal(t, "Bearer gcp_token", r.Header.Get("Authorization"))
+			secrets[r.URL.Query().Get("secretId")] = ""
+			fmt.Fprint(w, `{}`)
+		case strings.HasSuffix(r.URL.Path, ":addVersion") && r.Method == http.MethodPost:
+			name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/projects/my-project/secrets/"), ":addVersion")
+			if _, ok := secrets[name]; !ok {
+				http.NotFound(w, r)
+				return
+			}
+			var version struct {
+				Payload struct {
+					Data string `json:"data"`
+				} `json:"payload"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&version))
+			data, err := base64.StdEncoding.DecodeString(version.Payload.Data)
+			require.NoError(t, err)
+			secrets[name] = string(data)
+			fmt.Fprint(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tokenURL, secretManagerURL := gcpMetadataTokenURL, gcpSecretManagerURL
+	gcpMetadataTokenURL, gcpSecretManagerURL = server.URL+"/token", server.URL
+	defer func() {
+		gcpMetadataTokenURL, gcpSecretManagerURL = tokenURL, secretManagerURL
+	}()
+
+	provider := NewGCPSecretProvider("my-project")
+	require.NoError(t, provider.PutSecret("challenger", `{"private_key":"abc"}`))
+	require.Equal(t, `{"private_key":"abc"}`, secrets["challenger"])
+	require.NoError(t, provider.PutSecret("challenger", `{"private_key":"def"}`))
+	require.Equal(t, `{"private_key":"def"}`, secrets["challenger"])
+}
+
+func TestAzureSecretProvider_PutSecret(t *testing.T) {
+	var value string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			fmt.Fprint(w, `{"access_token":"azure_token"}`)
+		case "/secrets/challenger":
+			require.Equal(t, http.MethodPut, r.Method)
+			require.Equal(t, "Bearer azure_token", r.Header.Get("Authorization"))
+			var secret struct {
+				Value string `json:"value"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&secret))
+			value = secret.Value
+			fmt.Fprint(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tokenURL := azureIMDSTokenURL
+	azureIMDSTokenURL = server.URL + "/token"
+	defer func() {
+		azureIMDSTokenURL = tokenURL
+	}()
+
+	provider := NewAzureSecretProvider(server.URL, "")
+	require.NoError(t, provider.PutSecret("challenger", `{"bls_private_key":"def"}`))
+	require.Equal(t, `{"bls_private_key":"def"}`, value)
+	require.Error(t, provider.PutSecret("missing", "value"))
+}
@@ -2,9 +2,11 @@ package config
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 )
@@ -44,3 +46,27 @@ func GetSecret(secretName, region string) (string, error) {
 		return decodedBinarySecret, nil
 	}
 }
+
+// PutSecret stores secretValue as the current version of the secret, creating the secret if it does not exist.
+func PutSecret(secretName, secretValue, region string) error {
+	sess, err := session.NewSession(&aws.Config{
+		Region: &region,
+	})
+	if err != nil {
+		return err
+	}
+
+	svc := secretsmanager.New(sess)
+	_, err = svc.PutSecretValue(&secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(secretName),
+		SecretString: aws.String(secretValue),
+	})
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == secretsmanager.ErrCodeResourceNotFoundException {
+		_, err = svc.CreateSecret(&secretsmanager.CreateSecretInput{
+			Name:         aws.String(secretName),
+			SecretString: aws.String(secretValue),
+		})
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// LogLevels are the supported log levels.
+var LogLevels = []string{"CRITICAL", "ERROR", "WARNING", "NOTICE", "INFO", "DEBUG"}
+
+// LogModules are the components whose log level can be set separately.
+var LogModules = []string{LogModuleMonitor, LogModuleVerifier, LogModuleVote, LogModuleAttest, LogModuleExecutor, LogModuleDao}
+
+// validationErrors collects the field level problems of a config, so that all of them are reported at once.
+type validationErrors []string
+
+func (e *validationErrors) add(field, format string, args ...interface{}) {
+	*e = append(*e, fmt.Sprintf("%s: %s", field, fmt.Sprintf(format, args...)))
+}
+
+func (e validationErrors) panicIfAny() {
+	if len(e) != 0 {
+		panic(fmt.Sprintf("invalid config:\n  %s", strings.Join(e, "\n  ")))
+	}
+}
+
+// filter returns the problems of the fields under path.
+func (e validationErrors) filter(path string) validationErrors {
+	filtered := validationErrors{}
+	for _, err := range e {
+		if strings.HasPrefix(err, path) {
+			filtered = append(filtered, err)
+		}
+	}
+	return filtered
+}
+
+// DBDialects are the supported database backends.
+var DBDialects = []string{DBDialectMysql, DBDialectPostgres, DBDialectSqlite3}
+
+// AlertSeverities are the severities of the alerts, which are routed to the alert backends.
+var AlertSeverities = []string{AlertSeverityInfo, AlertSeverityWarning, AlertSeverityCritical}
+
+// AlertBackends are the alerting targets.
+var AlertBackends = []string{AlertBackendTelegram, AlertBackendSlack, AlertBackendPagerDuty}
+
+// WebhookTriggers are the pipeline events notified to the webhooks.
+var WebhookTriggers = []string{WebhookTriggerEventIngested, WebhookTriggerVoteBroadcast, WebhookTriggerVotesCollected,
+	WebhookTriggerAttested, WebhookTriggerExpired}
+
+// StreamBackends are the brokers the status transitions of the events can be streamed to.
+var StreamBackends = []string{StreamBackendKafka, StreamBackendNats}
+
+// StreamSerializations are the encodings of the streamed status transitions.
+var StreamSerializations = []string{StreamSerializationJson, StreamSerializationProtobuf}
+
+// Components are the stages of the pipeline which can run on separate hosts sharing the database.
+var Components = []string{ComponentMonitor, ComponentVerifier, ComponentVote, ComponentAttest}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isLogLevel(level string) bool {
+	for _, l := range LogLevels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+func isLogModule(module string) bool {
+	for _, m := range LogModules {
+		if m == module {
+			return true
+		}
+	}
+	return false
+}
+
+func validateURL(addr string) error {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid url", addr)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "tcp" {
+		return fmt.Errorf("%q should start with http://, https:// or tcp://", addr)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q has no host", addr)
+	}
+	return nil
+}
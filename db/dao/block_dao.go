@@ -1,12 +1,14 @@
 package dao
 
 import (
+	"context"
 	"github.com/bnb-chain/greenfield-challenger/db/model"
 	"gorm.io/gorm"
 )
 
 type BlockDao struct {
 	DB *gorm.DB
+	queryTimeout
 }
 
 func NewBlockDao(db *gorm.DB) *BlockDao {
@@ -15,15 +17,19 @@ func NewBlockDao(db *gorm.DB) *BlockDao {
 	}
 }
 
-func (d *BlockDao) GetLatestBlock() (*model.Block, error) {
+func (d *BlockDao) GetLatestBlock(ctx context.Context) (*model.Block, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
 	block := model.Block{}
-	err := d.DB.Model(model.Block{}).Order("height desc").Take(&block).Error
+	err := prepared(d.DB).WithContext(ctx).Model(model.Block{}).Order("height desc").Take(&block).Error
 	if err != nil && err != gorm.ErrRecordNotFound {
 		return nil, err
 	}
 	return &block, nil
 }
 
-func (d *BlockDao) DeleteBlocksBefore(unixTimestamp int64) error {
-	return d.DB.Model(&model.Block{}).Where("created_time < ?", unixTimestamp).Delete(&model.Block{}).Error
+func (d *BlockDao) DeleteBlocksBefore(ctx context.Context, unixTimestamp int64) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return d.DB.WithContext(ctx).Model(&model.Block{}).Where("created_time < ?", unixTimestamp).Delete(&model.Block{}).Error
 }
@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"context"
 	"testing"
 
 	"github.com/bnb-chain/greenfield-challenger/db/model"
@@ -57,7 +58,7 @@ func (s *blockSuite) TestBlockDao_GetLatestBlock() {
 	result = s.dao.DB.Create(block2)
 	s.Require().NoError(result.Error, "failed to create")
 
-	latest, err := s.dao.GetLatestBlock()
+	latest, err := s.dao.GetLatestBlock(context.Background())
 	s.Require().NoError(err, "failed to query")
 	s.Require().True(latest.Height == uint64(200))
 }
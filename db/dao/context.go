@@ -0,0 +1,27 @@
+package dao
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultQueryTimeout bounds every database query of a dao whose query timeout is not set.
+const DefaultQueryTimeout = 10 * time.Second
+
+// queryTimeout bounds every database query of the dao embedding it, on top of the deadline of the caller's context.
+type queryTimeout struct {
+	timeout time.Duration
+}
+
+// SetQueryTimeout sets the timeout of every query of the dao, DefaultQueryTimeout is used if timeout is not positive.
+func (q *queryTimeout) SetQueryTimeout(timeout time.Duration) {
+	q.timeout = timeout
+}
+
+func (q *queryTimeout) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := q.timeout
+	if timeout <= 0 {
+		timeout = DefaultQueryTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,226 @@
+package dao
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// CopyBatchSize is the number of rows inserted at a time while copying a database.
+const CopyBatchSize = 100
+
+const (
+	CopyTableSamplingFailures = "sampling_failures"
+	CopyTableLedgerEntries    = "ledger_entries"
+	CopyTableSpRollups        = "sp_rollups"
+	CopyTableBucketRollups    = "bucket_rollups"
+)
+
+// TableDigest is the number of rows of a table and a digest of their content in id order.
+type TableDigest struct {
+	Rows   int64
+	Digest string
+}
+
+// CopyDao copies the challenger tables from one database to another, which may run another backend. Leases are not
+// copied, they only hold while the challengers sharing the source database are running. Neither are the verification
+// jobs, the unprocessed events are queued again by the challengers of the target database.
+type CopyDao struct {
+	Source *gorm.DB
+	Target *gorm.DB
+}
+
+func NewCopyDao(source, target *gorm.DB) *CopyDao {
+	return &CopyDao{
+		Source: source,
+		Target: target,
+	}
+}
+
+// Copy copies all challenger tables from the source to the target database and returns the copied rows by table. The
+// source is read in a single repeatable read transaction and the target is written in a single transaction, so the
+// target either holds a consistent copy or nothing. The rows keep their ids and deleted events are copied too. The
+// tables of the target must exist and be empty.
+func (d *CopyDao) Copy(ctx context.Context) (map[string]int64, error) {
+	rows := make(map[string]int64)
+	err := d.Target.WithContext(ctx).Transaction(func(dst *gorm.DB) error {
+		if err := checkEmpty(dst); err != nil {
+			return err
+		}
+		return d.Source.WithContext(ctx).Transaction(func(src *gorm.DB) error {
+			var err error
+			if rows[SnapshotTableBlocks], err = copyTable[model.Block](src, dst); err != nil {
+				return err
+			}
+			if rows[SnapshotTableEvents], err = copyTable[model.Event](src, dst); err != nil {
+				return err
+			}
+			if rows[SnapshotTableVotes], err = copyTable[model.Vote](src, dst); err != nil {
+				return err
+			}
+			if rows[SnapshotTableSpStats], err = copyTable[model.SpStats](src, dst); err != nil {
+				return err
+			}
+			if rows[SnapshotTableVerificationResults], err = copyTable[model.VerificationResult](src, dst); err != nil {
+				return err
+			}
+			if rows[CopyTableSamplingFailures], err = copyTable[model.SamplingFailure](src, dst); err != nil {
+				return err
+			}
+			if rows[CopyTableLedgerEntries], err = copyTable[model.LedgerEntry](src, dst); err != nil {
+				return err
+			}
+			if rows[CopyTableSpRollups], err = copyTable[model.SpRollup](src, dst); err != nil {
+				return err
+			}
+			rows[CopyTableBucketRollups], err = copyTable[model.BucketRollup](src, dst)
+			return err
+		}, readTxOptions(d.Source))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Verify compares the row counts and the content digests of all copied tables in the source and the target
+// database, and returns an error naming the first table which differs.
+func (d *CopyDao) Verify(ctx context.Context) error {
+	source, err := Digests(ctx, d.Source)
+	if err != nil {
+		return fmt.Errorf("digest source error, err=%+v", err)
+	}
+	target, err := Digests(ctx, d.Target)
+	if err != nil {
+		return fmt.Errorf("digest target error, err=%+v", err)
+	}
+	for _, table := range CopyTables {
+		if source[table] != target[table] {
+			return fmt.Errorf("table %s differs, source has %d rows with digest %s, target has %d rows with digest %s",
+				table, source[table].Rows, source[table].Digest, target[table].Rows, target[table].Digest)
+		}
+	}
+	return nil
+}
+
+// Digests returns the digests of all copied tables of db, read in a single repeatable read transaction. Digests do
+// not depend on the backend, so that databases running different backends can be compared.
+func Digests(ctx context.Context, db *gorm.DB) (map[string]TableDigest, error) {
+	digests := make(map[string]TableDigest)
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if digests[SnapshotTableBlocks], err = digestTable[model.Block](tx); err != nil {
+			return err
+		}
+		if digests[SnapshotTableEvents], err = digestTable[model.Event](tx); err != nil {
+			return err
+		}
+		if digests[SnapshotTableVotes], err = digestTable[model.Vote](tx); err != nil {
+			return err
+		}
+		if digests[SnapshotTableSpStats], err = digestTable[model.SpStats](tx); err != nil {
+			return err
+		}
+		if digests[SnapshotTableVerificationResults], err = digestTable[model.VerificationResult](tx); err != nil {
+			return err
+		}
+		if digests[CopyTableSamplingFailures], err = digestTable[model.SamplingFailure](tx); err != nil {
+			return err
+		}
+		if digests[CopyTableLedgerEntries], err = digestTable[model.LedgerEntry](tx); err != nil {
+			return err
+		}
+		if digests[CopyTableSpRollups], err = digestTable[model.SpRollup](tx); err != nil {
+			return err
+		}
+		digests[CopyTableBucketRollups], err = digestTable[model.BucketRollup](tx)
+		return err
+	}, readTxOptions(db))
+	if err != nil {
+		return nil, err
+	}
+	return digests, nil
+}
+
+// CopyTables are the tables copied by Copy, in order.
+var CopyTables = []string{SnapshotTableBlocks, SnapshotTableEvents, SnapshotTableVotes, SnapshotTableSpStats,
+	SnapshotTableVerificationResults, CopyTableSamplingFailures, CopyTableLedgerEntries, CopyTableSpRollups,
+	CopyTableBucketRollups}
+
+func readTxOptions(db *gorm.DB) *sql.TxOptions {
+	switch db.Dialector.Name() {
+	case "mysql", "postgres":
+		return &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	}
+	return &sql.TxOptions{}
+}
+
+func checkEmpty(tx *gorm.DB) error {
+	for _, m := range []schema.Tabler{&model.Block{}, &model.Event{}, &model.Vote{}, &model.SpStats{},
+		&model.VerificationResult{}, &model.SamplingFailure{}, &model.LedgerEntry{}, &model.SpRollup{},
+		&model.BucketRollup{}} {
+		var count int64
+		if err := tx.Unscoped().Model(m).Count(&count).Error; err != nil {
+			return err
+		}
+		if count != 0 {
+			return fmt.Errorf("target table %s is not empty, it has %d rows", m.TableName(), count)
+		}
+	}
+	return nil
+}
+
+func copyTable[T any](src, dst *gorm.DB) (int64, error) {
+	var count int64
+	rows := make([]*T, 0, CopyBatchSize)
+	err := src.Unscoped().Model(new(T)).Order("id asc").FindInBatches(&rows, CopyBatchSize, func(_ *gorm.DB, _ int) error {
+		if err := dst.Create(&rows).Error; err != nil {
+			return err
+		}
+		count += int64(len(rows))
+		return nil
+	}).Error
+	if err != nil {
+		return 0, err
+	}
+	if dst.Dialector.Name() == "postgres" && count > 0 {
+		// the ids were inserted explicitly, the sequence has to continue after them
+		table := any(new(T)).(schema.Tabler).TableName()
+		err = dst.Exec(fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', 'id'), (SELECT MAX(id) FROM %s))",
+			table, table)).Error
+	}
+	return count, err
+}
+
+func digestTable[T any](tx *gorm.DB) (TableDigest, error) {
+	var count int64
+	h := sha256.New()
+	rows := make([]*T, 0, SnapshotBatchSize)
+	err := tx.Unscoped().Model(new(T)).Order("id asc").FindInBatches(&rows, SnapshotBatchSize, func(_ *gorm.DB, _ int) error {
+		for _, row := range rows {
+			if event, ok := any(row).(*model.Event); ok && event.DeletedAt.Valid {
+				// backends keep timestamps with different precisions and time zones
+				event.DeletedAt.Time = event.DeletedAt.Time.UTC().Truncate(time.Millisecond)
+			}
+			raw, err := json.Marshal(row)
+			if err != nil {
+				return err
+			}
+			h.Write(raw)
+		}
+		count += int64(len(rows))
+		return nil
+	}).Error
+	if err != nil {
+		return TableDigest{}, err
+	}
+	return TableDigest{Rows: count, Digest: hex.EncodeToString(h.Sum(nil))}, nil
+}
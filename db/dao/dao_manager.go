@@ -1,15 +1,75 @@
 package dao
 
+import (
+	"context"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+)
+
 type DaoManager struct {
 	*BlockDao
 	*EventDao
 	*VoteDao
+	*SpStatsDao
+	*VerificationResultDao
+	*SamplingFailureDao
+	*LeaseDao
+	*LedgerDao
+	*RollupDao
+	*JobDao
 }
 
-func NewDaoManager(blockDao *BlockDao, eventDao *EventDao, voteDao *VoteDao) *DaoManager {
+func NewDaoManager(blockDao *BlockDao, eventDao *EventDao, voteDao *VoteDao, spStatsDao *SpStatsDao,
+	verificationResultDao *VerificationResultDao, samplingFailureDao *SamplingFailureDao, leaseDao *LeaseDao,
+	ledgerDao *LedgerDao, rollupDao *RollupDao, jobDao *JobDao,
+) *DaoManager {
 	return &DaoManager{
-		BlockDao: blockDao,
-		EventDao: eventDao,
-		VoteDao:  voteDao,
+		BlockDao:              blockDao,
+		EventDao:              eventDao,
+		VoteDao:               voteDao,
+		SpStatsDao:            spStatsDao,
+		VerificationResultDao: verificationResultDao,
+		SamplingFailureDao:    samplingFailureDao,
+		LeaseDao:              leaseDao,
+		LedgerDao:             ledgerDao,
+		RollupDao:             rollupDao,
+		JobDao:                jobDao,
+	}
+}
+
+// ReplayEvents resets the events back to an earlier pipeline stage, see EventDao.ReplayEvents. The verification jobs
+// of the events reset to Unprocessed are dropped, so that the failed ones are queued again.
+func (m *DaoManager) ReplayEvents(ctx context.Context, challengeIds []uint64, status model.EventStatus) (int64, error) {
+	replayed, err := m.EventDao.ReplayEvents(ctx, challengeIds, status)
+	if err != nil || status != model.Unprocessed {
+		return replayed, err
 	}
+	return replayed, m.DeleteVerificationJobs(ctx, challengeIds)
+}
+
+// SetQueryTimeout sets the timeout of every query of the daos, DefaultQueryTimeout is used if timeout is not positive.
+func (m *DaoManager) SetQueryTimeout(timeout time.Duration) {
+	m.BlockDao.SetQueryTimeout(timeout)
+	m.EventDao.SetQueryTimeout(timeout)
+	m.VoteDao.SetQueryTimeout(timeout)
+	m.SpStatsDao.SetQueryTimeout(timeout)
+	m.VerificationResultDao.SetQueryTimeout(timeout)
+	m.SamplingFailureDao.SetQueryTimeout(timeout)
+	m.LeaseDao.SetQueryTimeout(timeout)
+	m.LedgerDao.SetQueryTimeout(timeout)
+	m.RollupDao.SetQueryTimeout(timeout)
+	m.JobDao.SetQueryTimeout(timeout)
+}
+
+// SetStageObserver sets the observer of the pipeline stages completed by the event status updates.
+func (m *DaoManager) SetStageObserver(observer StageObserver) {
+	m.EventDao.SetStageObserver(observer)
+	m.VoteDao.SetStageObserver(observer)
+}
+
+// SetTransitionObserver sets the observer of the status transitions of the events.
+func (m *DaoManager) SetTransitionObserver(observer TransitionObserver) {
+	m.EventDao.SetTransitionObserver(observer)
+	m.VoteDao.SetTransitionObserver(observer)
 }
@@ -0,0 +1,110 @@
+package dao
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"gorm.io/gorm"
+)
+
+const (
+	DBProbeInterval = 5 * time.Second
+	DBProbeTimeout  = 3 * time.Second
+)
+
+// DBStatus is the result of the last database probe.
+type DBStatus struct {
+	Healthy       bool
+	LastErr       error
+	LastProbeTime time.Time
+}
+
+// DBProber periodically pings the database and reports its health. When the database becomes unreachable, e.g.
+// during a MySQL failover, the idle connections of the pool are dropped so that fresh connections are established
+// to the new primary once it is back, instead of every loop failing on stale connections until restart.
+type DBProber struct {
+	db            *gorm.DB
+	maxIdleConns  int
+	resetPool     bool // in-memory databases must keep their connections, closing them drops the data
+	mtx           sync.RWMutex
+	healthy       bool
+	lastErr       error
+	lastProbeTime time.Time
+}
+
+func NewDBProber(db *gorm.DB, maxIdleConns int, resetPool bool) *DBProber {
+	return &DBProber{
+		db:           db,
+		maxIdleConns: maxIdleConns,
+		resetPool:    resetPool,
+		healthy:      true,
+	}
+}
+
+func (p *DBProber) ProbeLoop(ctx context.Context) {
+	ticker := time.NewTicker(DBProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		_ = p.Probe()
+	}
+}
+
+// Probe pings the database once and updates the health status, the connection pool is reset when the database
+// turns unhealthy.
+func (p *DBProber) Probe() error {
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DBProbeTimeout)
+	defer cancel()
+	err = sqlDB.PingContext(ctx)
+
+	p.mtx.Lock()
+	wasHealthy := p.healthy
+	p.healthy = err == nil
+	p.lastErr = err
+	p.lastProbeTime = time.Now()
+	p.mtx.Unlock()
+
+	if err != nil {
+		if wasHealthy && p.resetPool {
+			logging.DaoLogger.Errorf("database became unhealthy, resetting connection pool, err=%+v", err.Error())
+			// dropping the idle connections forces the pool to dial again on the next query
+			sqlDB.SetMaxIdleConns(0)
+		} else if wasHealthy {
+			logging.DaoLogger.Errorf("database became unhealthy, err=%+v", err.Error())
+		}
+		return err
+	}
+	if !wasHealthy {
+		logging.DaoLogger.Infof("database is healthy again")
+		if p.resetPool {
+			sqlDB.SetMaxIdleConns(p.maxIdleConns)
+		}
+	}
+	return nil
+}
+
+func (p *DBProber) IsHealthy() bool {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+	return p.healthy
+}
+
+func (p *DBProber) Status() DBStatus {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+	return DBStatus{
+		Healthy:       p.healthy,
+		LastErr:       p.lastErr,
+		LastProbeTime: p.lastProbeTime,
+	}
+}
@@ -1,12 +1,64 @@
 package dao
 
 import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
 	"github.com/bnb-chain/greenfield-challenger/db/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DefaultListEventsLimit = 100
+	MaxListEventsLimit     = 1000
+	ExpireBatchSize        = 1000 // events expired by a single update
+	EventsQueryBatchSize   = 500  // challenge ids queried at once
 )
 
+// EventFilter narrows down the events returned by ListEvents, zero values are ignored.
+type EventFilter struct {
+	Statuses          []model.EventStatus
+	FromHeight        uint64 // inclusive
+	ToHeight          uint64 // inclusive
+	SpOperatorAddress string
+	ChallengerAddress string
+}
+
+// ShardFilter restricts the events to the shards held by a challenger, the event of a challenge is in the shard
+// challenge_id % Count.
+type ShardFilter struct {
+	Count  uint64
+	Shards []uint64
+}
+
+// ErrBlockSaved is returned when saving a block which was already saved, with its events, e.g. a block polled again
+// after its commit was not acknowledged or a block saved by another leader.
+var ErrBlockSaved = fmt.Errorf("block already saved")
+
+// StageObserver is told how long an event took to complete a pipeline stage.
+type StageObserver func(stage string, duration time.Duration)
+
+// TransitionObserver is told about an event moved to another status, previous is nil for an event just ingested.
+type TransitionObserver func(previous, event *model.Event)
+
 type EventDao struct {
-	DB *gorm.DB
+	DB                 *gorm.DB
+	stageObserver      StageObserver
+	transitionObserver TransitionObserver
+	queryTimeout
+}
+
+// SetStageObserver sets the observer of the stages completed by the status updates.
+func (d *EventDao) SetStageObserver(observer StageObserver) {
+	d.stageObserver = observer
+}
+
+// SetTransitionObserver sets the observer of the status transitions of the events.
+func (d *EventDao) SetTransitionObserver(observer TransitionObserver) {
+	d.transitionObserver = observer
 }
 
 func NewEventDao(db *gorm.DB) *EventDao {
@@ -15,11 +67,19 @@ func NewEventDao(db *gorm.DB) *EventDao {
 	}
 }
 
-func (d *EventDao) SaveBlockAndEvents(b *model.Block, events []*model.Event) error {
-	return d.DB.Transaction(func(dbTx *gorm.DB) error {
-		err := dbTx.Create(b).Error
-		if err != nil {
-			return err
+// SaveBlockAndEvents saves the events of a block with the block, which is the checkpoint of the monitor, in a single
+// transaction, so that the events of a block are saved once across restarts. ErrBlockSaved is returned, without
+// saving the events again, if the block was already saved.
+func (d *EventDao) SaveBlockAndEvents(ctx context.Context, b *model.Block, events []*model.Event) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	err := d.DB.WithContext(ctx).Transaction(func(dbTx *gorm.DB) error {
+		result := dbTx.Clauses(clause.OnConflict{DoNothing: true}).Create(b)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrBlockSaved
 		}
 
 		if len(events) != 0 {
@@ -28,23 +88,66 @@ func (d *EventDao) SaveBlockAndEvents(b *model.Block, events []*model.Event) err
 				return err
 			}
 		}
+
+		received := make(map[string]uint64)
+		for _, e := range events {
+			received[e.SpOperatorAddress]++
+		}
+		for spOperatorAddress, count := range received {
+			err := incrSpStats(dbTx, &model.SpStats{SpOperatorAddress: spOperatorAddress, Received: count})
+			if err != nil {
+				return err
+			}
+		}
 		return nil
 	})
+	if err != nil || d.transitionObserver == nil {
+		return err
+	}
+	for _, event := range events {
+		d.transitionObserver(nil, event)
+	}
+	return nil
 }
 
-func (d *EventDao) GetLatestEventByStatus(status model.EventStatus) (*model.Event, error) {
+func (d *EventDao) GetLatestEventByStatus(ctx context.Context, status model.EventStatus) (*model.Event, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
 	e := model.Event{}
-	err := d.DB.Where("status = ?", status).Order("challenge_id desc").First(&e).Error
+	err := d.DB.WithContext(ctx).Where("status = ?", status).Order("challenge_id desc").First(&e).Error
 	if err != nil {
 		return nil, err
 	}
 	return &e, nil
 }
 
-func (d *EventDao) GetUnexpiredEventsByStatus(currentHeight uint64, status model.EventStatus) ([]*model.Event, error) {
+func (d *EventDao) GetUnexpiredEventsByStatus(ctx context.Context, currentHeight uint64, status model.EventStatus) ([]*model.Event, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	events := []*model.Event{}
+	err := prepared(d.DB).WithContext(ctx).Where("expired_height > ?", currentHeight).
+		Where("status = ?", status).
+		Order("challenge_id asc").
+		Find(&events).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetUnexpiredEventsByStatusInShards returns the unexpired events in the status which are in the shards of the filter.
+func (d *EventDao) GetUnexpiredEventsByStatusInShards(ctx context.Context, currentHeight uint64, status model.EventStatus,
+	filter ShardFilter,
+) ([]*model.Event, error) {
 	events := []*model.Event{}
-	err := d.DB.Where("expired_height > ?", currentHeight).
+	if len(filter.Shards) == 0 {
+		return events, nil
+	}
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	err := d.DB.WithContext(ctx).Where("expired_height > ?", currentHeight).
 		Where("status = ?", status).
+		Where("challenge_id % ? IN ?", filter.Count, filter.Shards).
 		Order("challenge_id asc").
 		Find(&events).Error
 	if err != nil && err != gorm.ErrRecordNotFound {
@@ -53,9 +156,11 @@ func (d *EventDao) GetUnexpiredEventsByStatus(currentHeight uint64, status model
 	return events, nil
 }
 
-func (d *EventDao) GetUnexpiredEventsByVerifyResult(limit int, currentHeight uint64, verifyResult model.VerifyResult) ([]*model.Event, error) {
+func (d *EventDao) GetUnexpiredEventsByVerifyResult(ctx context.Context, limit int, currentHeight uint64, verifyResult model.VerifyResult) ([]*model.Event, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
 	events := []*model.Event{}
-	err := d.DB.Where("verify_result = ?", verifyResult).
+	err := d.DB.WithContext(ctx).Where("verify_result = ?", verifyResult).
 		Where("expired_height > ?", currentHeight).
 		Order("challenge_id asc").
 		Limit(limit).
@@ -66,39 +171,456 @@ func (d *EventDao) GetUnexpiredEventsByVerifyResult(limit int, currentHeight uin
 	return events, nil
 }
 
-func (d *EventDao) GetEventByChallengeId(challengeId uint64) (*model.Event, error) {
+// GetExpiringEventsByStatuses returns the events in one of the statuses which expire within the next blocks after the
+// current height, except the ones verified as matching, which are not attested anyway.
+func (d *EventDao) GetExpiringEventsByStatuses(ctx context.Context, currentHeight, blocks uint64, statuses []model.EventStatus) ([]*model.Event, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	events := []*model.Event{}
+	err := d.DB.WithContext(ctx).Where("expired_height >= ? AND expired_height <= ?", currentHeight, currentHeight+blocks).
+		Where("status IN ?", statuses).
+		Where("verify_result <> ?", model.HashMatched).
+		Order("challenge_id asc").
+		Find(&events).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (d *EventDao) GetEventByChallengeId(ctx context.Context, challengeId uint64) (*model.Event, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
 	var event model.Event
-	err := d.DB.Where("challenge_id = ?", challengeId).Take(&event).Error
+	err := prepared(d.DB).WithContext(ctx).Where("challenge_id = ?", challengeId).Take(&event).Error
 	if err != nil {
 		return nil, err
 	}
 	return &event, nil
 }
 
-func (d *EventDao) UpdateEventStatusByChallengeId(challengeId uint64, status model.EventStatus) error {
-	return d.DB.Model(&model.Event{}).
-		Where("challenge_id = ?", challengeId).
-		Update("status", status).
-		Error
+// GetEventsByChallengeIds returns the events of the challenge ids which are saved, they are queried by batches of
+// EventsQueryBatchSize ids.
+func (d *EventDao) GetEventsByChallengeIds(ctx context.Context, challengeIds []uint64) ([]*model.Event, error) {
+	events := make([]*model.Event, 0)
+	for start := 0; start < len(challengeIds); start += EventsQueryBatchSize {
+		end := start + EventsQueryBatchSize
+		if end > len(challengeIds) {
+			end = len(challengeIds)
+		}
+		batch := make([]*model.Event, 0)
+		queryCtx, cancel := d.withQueryTimeout(ctx)
+		err := d.DB.WithContext(queryCtx).Where("challenge_id IN ?", challengeIds[start:end]).Find(&batch).Error
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, batch...)
+	}
+	return events, nil
+}
+
+// CountEventsByStatus counts the events by their status, deleted events are not counted.
+func (d *EventDao) CountEventsByStatus(ctx context.Context) (map[model.EventStatus]int64, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	var rows []struct {
+		Status model.EventStatus
+		Count  int64
+	}
+	err := d.DB.WithContext(ctx).Model(&model.Event{}).
+		Select("status, count(*) as count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[model.EventStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// ListSettledEvents returns the events saved since the unix time which were verified as mismatched and were attested
+// or expired, including the deleted events. Only the status and the times are loaded.
+func (d *EventDao) ListSettledEvents(ctx context.Context, since int64) ([]*model.Event, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	events := make([]*model.Event, 0)
+	err := d.DB.WithContext(ctx).Unscoped().
+		Select("challenge_id", "status", "created_time", "stage_time").
+		Where("created_time >= ? and verify_result = ? and status IN ?", since, model.HashMismatched,
+			[]model.EventStatus{model.SelfAttested, model.Attested, model.Expired}).
+		Find(&events).Error
+	return events, err
+}
+
+// EventVolume is the number of challenges saved since a time, by the attestation they need.
+type EventVolume struct {
+	Challenges int64
+	Heartbeats int64 // heartbeats served by the sp, attested to reward the submitter
+	Mismatched int64 // verified as mismatched, attested to slash the sp
+	FirstTime  int64 // unix time of the earliest challenge counted, 0 without any
+}
+
+// CountEventVolume counts the events saved since the unix time, including the deleted events. The challenges whose id
+// is a multiple of heartbeatInterval are heartbeats, unless they are mismatched.
+func (d *EventDao) CountEventVolume(ctx context.Context, since int64, heartbeatInterval uint64) (*EventVolume, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	volume := &EventVolume{}
+	err := d.DB.WithContext(ctx).Unscoped().Model(&model.Event{}).
+		Select("count(*) as challenges, "+
+			"coalesce(sum(case when challenge_id % ? = 0 and verify_result <> ? then 1 else 0 end), 0) as heartbeats, "+
+			"coalesce(sum(case when verify_result = ? then 1 else 0 end), 0) as mismatched, "+
+			"coalesce(min(created_time), 0) as first_time", heartbeatInterval, model.HashMismatched, model.HashMismatched).
+		Where("created_time >= ?", since).
+		Scan(volume).Error
+	if err != nil {
+		return nil, err
+	}
+	return volume, nil
+}
+
+// GetLatestChallengeId returns the highest challenge id saved, including the deleted events, 0 if there is none.
+func (d *EventDao) GetLatestChallengeId(ctx context.Context) (uint64, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	var challengeId uint64
+	err := d.DB.WithContext(ctx).Unscoped().Model(&model.Event{}).
+		Select("coalesce(max(challenge_id), 0)").
+		Scan(&challengeId).Error
+	if err != nil {
+		return 0, err
+	}
+	return challengeId, nil
+}
+
+func (d *EventDao) UpdateEventStatusByChallengeId(ctx context.Context, challengeId uint64, status model.EventStatus) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return updateEventStatus(d.DB.WithContext(ctx), challengeId, status, map[string]interface{}{"status": status},
+		d.stageObserver, d.transitionObserver)
+}
+
+// UpdateEventStatusVerifyResultByChallengeId records the verify result of the event with eventHash, the hex hash voted
+// for it, so that the later stages do not hash the event again.
+func (d *EventDao) UpdateEventStatusVerifyResultByChallengeId(ctx context.Context, challengeId uint64, status model.EventStatus, result model.VerifyResult, eventHash string) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return updateEventStatus(d.DB.WithContext(ctx), challengeId, status,
+		map[string]interface{}{"status": status, "verify_result": result, "event_hash": eventHash}, d.stageObserver,
+		d.transitionObserver)
+}
+
+// updateEventStatus applies the updates moving an event to status. When the status completes a pipeline stage, the
+// stage time of the event moves forward and the duration of the stage is reported to observer. The event moved to
+// another status is reported to transition.
+func updateEventStatus(db *gorm.DB, challengeId uint64, status model.EventStatus, updates map[string]interface{},
+	observer StageObserver, transition TransitionObserver,
+) error {
+	stage, completed := model.CompletedStage(status)
+	if !completed && transition == nil {
+		return db.Model(&model.Event{}).Where("challenge_id = ?", challengeId).Updates(updates).Error
+	}
+	previous := model.Event{}
+	query := db
+	if transition == nil {
+		query = db.Select("status", "sp_operator_address", "created_time", "stage_time")
+	}
+	err := query.Where("challenge_id = ?", challengeId).Take(&previous).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	now := time.Now()
+	if completed {
+		updates["stage_time"] = now.UnixMilli()
+	}
+	err = db.Transaction(func(dbTx *gorm.DB) error {
+		if err := dbTx.Model(&model.Event{}).Where("challenge_id = ?", challengeId).Updates(updates).Error; err != nil {
+			return err
+		}
+		// the sp of an attested challenge is slashed, counted once whoever attested it
+		if !completed || stage != model.StageAttest || previous.SpOperatorAddress == "" ||
+			previous.Status == model.SelfAttested || previous.Status == model.Attested {
+			return nil
+		}
+		return incrSpStats(dbTx, &model.SpStats{SpOperatorAddress: previous.SpOperatorAddress, Slashed: 1})
+	})
+	if err != nil {
+		return err
+	}
+	// an event which is already in status, e.g. updated twice, has not completed the stage again
+	startedAt := previous.StageStartedAt()
+	if completed && observer != nil && previous.Status != status && !startedAt.IsZero() {
+		observer(stage, now.Sub(startedAt))
+	}
+	if transition != nil && previous.ChallengeId != 0 && previous.Status != status {
+		event := previous
+		event.Status = status
+		if result, ok := updates["verify_result"].(model.VerifyResult); ok {
+			event.VerifyResult = result
+		}
+		if eventHash, ok := updates["event_hash"].(string); ok {
+			event.EventHash = eventHash
+		}
+		transition(&previous, &event)
+	}
+	return nil
+}
+
+// ExpireEvent marks an event as expired, an event which expired before being verified is also counted in the stats
+// of its storage provider.
+func (d *EventDao) ExpireEvent(ctx context.Context, event *model.Event) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	expired := false
+	err := d.DB.WithContext(ctx).Transaction(func(dbTx *gorm.DB) error {
+		result := dbTx.Model(&model.Event{}).
+			Where("challenge_id = ? and status = ?", event.ChallengeId, event.Status).
+			Update("status", model.Expired)
+		if result.Error != nil {
+			return result.Error
+		}
+		// the event status was changed by others in the meantime
+		expired = result.RowsAffected > 0
+		if !expired || event.VerifyResult != model.Unknown {
+			return nil
+		}
+		return incrSpStats(dbTx, &model.SpStats{SpOperatorAddress: event.SpOperatorAddress, Expired: 1})
+	})
+	if err == nil && expired && d.transitionObserver != nil {
+		expiredEvent := *event
+		expiredEvent.Status = model.Expired
+		d.transitionObserver(event, &expiredEvent)
+	}
+	return err
 }
 
-func (d *EventDao) UpdateEventStatusVerifyResultByChallengeId(challengeId uint64, status model.EventStatus, result model.VerifyResult) error {
-	return d.DB.Model(&model.Event{}).
-		Where("challenge_id = ?", challengeId).
-		Updates(model.Event{Status: status, VerifyResult: result}).
-		Error
+// ExpireEvents marks at most ExpireBatchSize events in one of the statuses which are expired at the current height with
+// a single update, and returns them as they were before. The events expired before being verified are counted in the
+// stats of their storage providers. The events which changed in the meantime are left to the next call, the others are
+// expired and returned.
+func (d *EventDao) ExpireEvents(ctx context.Context, currentHeight uint64, statuses []model.EventStatus) ([]*model.Event, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	events := make([]*model.Event, 0)
+	err := d.DB.WithContext(ctx).Transaction(func(dbTx *gorm.DB) error {
+		err := dbTx.Where("expired_height < ? and status IN ?", currentHeight, statuses).
+			Order("challenge_id asc").Limit(ExpireBatchSize).Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		challengeIds := make([]uint64, 0, len(events))
+		for _, event := range events {
+			challengeIds = append(challengeIds, event.ChallengeId)
+		}
+		result := dbTx.Model(&model.Event{}).Where("challenge_id IN ? and status IN ?", challengeIds, statuses).
+			Update("status", model.Expired)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(events)) {
+			// the events which changed in the meantime were skipped by the update, only the expired ones are kept
+			if events, err = expiredAmong(dbTx, events, challengeIds); err != nil {
+				return err
+			}
+		}
+		unverified := make(map[string]uint64)
+		for _, event := range events {
+			if event.VerifyResult == model.Unknown {
+				unverified[event.SpOperatorAddress]++
+			}
+		}
+		sps := make([]string, 0, len(unverified))
+		for sp := range unverified {
+			sps = append(sps, sp)
+		}
+		// the stats rows are updated in the same order by every challenger
+		sort.Strings(sps)
+		for _, sp := range sps {
+			if err = incrSpStats(dbTx, &model.SpStats{SpOperatorAddress: sp, Expired: unverified[sp]}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if d.transitionObserver != nil {
+		for _, event := range events {
+			expiredEvent := *event
+			expiredEvent.Status = model.Expired
+			d.transitionObserver(event, &expiredEvent)
+		}
+	}
+	return events, nil
+}
+
+// expiredAmong returns the events which were just expired by the transaction, the events changed by other transactions
+// meanwhile still have their earlier status in its snapshot.
+func expiredAmong(dbTx *gorm.DB, events []*model.Event, challengeIds []uint64) ([]*model.Event, error) {
+	expiredIds := make([]uint64, 0, len(challengeIds))
+	err := dbTx.Model(&model.Event{}).Where("challenge_id IN ? and status = ?", challengeIds, model.Expired).
+		Pluck("challenge_id", &expiredIds).Error
+	if err != nil {
+		return nil, err
+	}
+	expired := make(map[uint64]bool, len(expiredIds))
+	for _, id := range expiredIds {
+		expired[id] = true
+	}
+	kept := make([]*model.Event, 0, len(expiredIds))
+	for _, event := range events {
+		if expired[event.ChallengeId] {
+			kept = append(kept, event)
+		}
+	}
+	return kept, nil
 }
 
-func (d *EventDao) IsEventExistsBetween(objectId, spOperatorAddress string, lowChallengeId, highChallengeId uint64) (bool, error) {
+func (d *EventDao) IsEventExistsBetween(ctx context.Context, objectId, spOperatorAddress string, lowChallengeId, highChallengeId uint64) (bool, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
 	exists := false
-	if err := d.DB.Raw(
-		"SELECT EXISTS(SELECT id FROM events WHERE object_id = ? and sp_operator_address = ? and challenge_id between ? and ?)",
+	if err := prepared(d.DB).WithContext(ctx).Raw(
+		fmt.Sprintf("SELECT EXISTS(SELECT id FROM %s WHERE object_id = ? and sp_operator_address = ? and challenge_id between ? and ? and deleted_at IS NULL)",
+			(&model.Event{}).TableName()),
 		objectId, spOperatorAddress, lowChallengeId, highChallengeId).Scan(&exists).Error; err != nil {
 		return false, err
 	}
 	return exists, nil
 }
 
-func (d *EventDao) DeleteEventsBefore(unixTimestamp int64) error {
-	return d.DB.Model(&model.Event{}).Where("created_time < ?", unixTimestamp).Delete(&model.Event{}).Error
+// ListEvents returns at most limit events matching the filter with challenge id greater than cursor, ordered by
+// challenge id. The returned cursor should be passed to the next call to fetch the following page, it is 0 when
+// there are no more events.
+func (d *EventDao) ListEvents(ctx context.Context, filter EventFilter, cursor uint64, limit int) ([]*model.Event, uint64, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	if limit <= 0 {
+		limit = DefaultListEventsLimit
+	}
+	if limit > MaxListEventsLimit {
+		limit = MaxListEventsLimit
+	}
+
+	query := d.DB.WithContext(ctx).Where("challenge_id > ?", cursor)
+	if len(filter.Statuses) != 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.FromHeight != 0 {
+		query = query.Where("height >= ?", filter.FromHeight)
+	}
+	if filter.ToHeight != 0 {
+		query = query.Where("height <= ?", filter.ToHeight)
+	}
+	if filter.SpOperatorAddress != "" {
+		query = query.Where("sp_operator_address = ?", filter.SpOperatorAddress)
+	}
+	if filter.ChallengerAddress != "" {
+		query = query.Where("challenger_address = ?", filter.ChallengerAddress)
+	}
+
+	// fetch one more record to know whether there is a next page
+	events := make([]*model.Event, 0, limit+1)
+	err := query.Order("challenge_id asc").Limit(limit + 1).Find(&events).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, 0, err
+	}
+	if len(events) <= limit {
+		return events, 0, nil
+	}
+	events = events[:limit]
+	return events, events[limit-1].ChallengeId, nil
+}
+
+// DeleteEventsBefore soft deletes the events created before the timestamp, they can be brought back with ReplayEvents.
+func (d *EventDao) DeleteEventsBefore(ctx context.Context, unixTimestamp int64) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return d.DB.WithContext(ctx).Model(&model.Event{}).Where("created_time < ?", unixTimestamp).Delete(&model.Event{}).Error
+}
+
+// PurgeEventsBefore hard deletes the events created before the timestamp, the soft deleted ones included, which cannot
+// be replayed anymore. Soft deleting alone would keep the rows and the deleted_at index growing.
+func (d *EventDao) PurgeEventsBefore(ctx context.Context, unixTimestamp int64) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return d.DB.WithContext(ctx).Unscoped().Where("created_time < ?", unixTimestamp).Delete(&model.Event{}).Error
+}
+
+// ReplayEvents resets the events back to an earlier pipeline stage, restoring them if they were deleted. Resetting to
+// Unprocessed also clears the verify result so that the events are verified again. It returns the number of events
+// which have been reset.
+func (d *EventDao) ReplayEvents(ctx context.Context, challengeIds []uint64, status model.EventStatus) (int64, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	replayable := false
+	for _, s := range model.ReplayableEventStatuses {
+		if s == status {
+			replayable = true
+			break
+		}
+	}
+	if !replayable {
+		return 0, fmt.Errorf("events cannot be replayed to status %d", status)
+	}
+	if len(challengeIds) == 0 {
+		return 0, nil
+	}
+
+	updates := map[string]interface{}{
+		"status":     status,
+		"deleted_at": nil,
+		// the replayed stage is measured from the replay
+		"stage_time": time.Now().UnixMilli(),
+	}
+	query := d.DB.WithContext(ctx).Unscoped().Model(&model.Event{}).Where("challenge_id IN ?", challengeIds)
+	if status == model.Unprocessed {
+		updates["verify_result"] = model.Unknown
+		updates["event_hash"] = ""
+	} else {
+		// later stages rely on the verify result, so events which were never verified cannot skip verification
+		query = query.Where("verify_result <> ?", model.Unknown)
+	}
+	query = query.Session(&gorm.Session{})
+	previous := make([]*model.Event, 0)
+	if d.transitionObserver != nil {
+		if err := query.Find(&previous).Error; err != nil {
+			return 0, err
+		}
+	}
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	for _, p := range previous {
+		if p.Status == status && !p.DeletedAt.Valid {
+			continue
+		}
+		event := *p
+		event.Status = status
+		event.DeletedAt = gorm.DeletedAt{}
+		if status == model.Unprocessed {
+			event.VerifyResult = model.Unknown
+			event.EventHash = ""
+		}
+		d.transitionObserver(p, &event)
+	}
+	return result.RowsAffected, nil
+}
+
+// ListRecentEvents returns the limit events with the highest challenge ids, the latest first.
+func (d *EventDao) ListRecentEvents(ctx context.Context, limit int) ([]*model.Event, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	events := make([]*model.Event, 0, limit)
+	err := d.DB.WithContext(ctx).Order("challenge_id desc").Limit(limit).Find(&events).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return events, nil
 }
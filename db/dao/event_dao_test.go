@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -87,21 +88,21 @@ func (s *eventSuite) createEvents() (*model.Block, *model.Event, *model.Event, *
 func (s *eventSuite) TestEventDao_SaveBlockAndEvents() {
 	block, event1, event2, event3 := s.createEvents()
 	events := []*model.Event{event1, event2, event3}
-	err := s.dao.SaveBlockAndEvents(block, events)
+	err := s.dao.SaveBlockAndEvents(context.Background(), block, events)
 	s.Require().NoError(err, "failed to create")
 }
 
 func (s *eventSuite) TestEventDao_GetEarliestEventByStatus() {
 	block, event1, event2, event3 := s.createEvents()
 	events := []*model.Event{event1, event2, event3}
-	_ = s.dao.SaveBlockAndEvents(block, events)
+	_ = s.dao.SaveBlockAndEvents(context.Background(), block, events)
 
-	result, err := s.dao.GetUnexpiredEventsByStatus(0, model.Unprocessed)
+	result, err := s.dao.GetUnexpiredEventsByStatus(context.Background(), 0, model.Unprocessed)
 	s.Require().NoError(err, "failed to query")
 	s.Require().True(len(result) == 2)
 	s.Require().True(result[0].ChallengeId == 1)
 
-	result, err = s.dao.GetUnexpiredEventsByStatus(0, model.Verified)
+	result, err = s.dao.GetUnexpiredEventsByStatus(context.Background(), 0, model.Verified)
 	s.Require().NoError(err, "failed to query")
 	s.Require().True(len(result) == 1)
 	s.Require().True(result[0].ChallengeId == 1000)
@@ -110,9 +111,9 @@ func (s *eventSuite) TestEventDao_GetEarliestEventByStatus() {
 func (s *eventSuite) TestEventDao_GetEarliestEventsByStatusAndAfter() {
 	block, event1, event2, event3 := s.createEvents()
 	events := []*model.Event{event1, event2, event3}
-	_ = s.dao.SaveBlockAndEvents(block, events)
+	_ = s.dao.SaveBlockAndEvents(context.Background(), block, events)
 
-	result, err := s.dao.GetUnexpiredEventsByStatus(0, model.Unprocessed)
+	result, err := s.dao.GetUnexpiredEventsByStatus(context.Background(), 0, model.Unprocessed)
 	s.Require().NoError(err, "failed to query")
 	s.Require().True(len(result) == 1)
 	s.Require().True(result[0].ChallengeId == 10)
@@ -121,9 +122,9 @@ func (s *eventSuite) TestEventDao_GetEarliestEventsByStatusAndAfter() {
 func (s *eventSuite) TestEventDao_GetEventByChallengeId() {
 	block, event1, event2, event3 := s.createEvents()
 	events := []*model.Event{event1, event2, event3}
-	_ = s.dao.SaveBlockAndEvents(block, events)
+	_ = s.dao.SaveBlockAndEvents(context.Background(), block, events)
 
-	result, err := s.dao.GetEventByChallengeId(event2.ChallengeId)
+	result, err := s.dao.GetEventByChallengeId(context.Background(), event2.ChallengeId)
 	s.Require().NoError(err, "failed to query")
 	s.Require().True(result.ChallengeId == event2.ChallengeId)
 }
@@ -131,9 +132,9 @@ func (s *eventSuite) TestEventDao_GetEventByChallengeId() {
 func (s *eventSuite) TestEventDao_GetLatestEventByStatus() {
 	block, event1, event2, event3 := s.createEvents()
 	events := []*model.Event{event1, event2, event3}
-	_ = s.dao.SaveBlockAndEvents(block, events)
+	_ = s.dao.SaveBlockAndEvents(context.Background(), block, events)
 
-	result, err := s.dao.GetLatestEventByStatus(model.Unprocessed)
+	result, err := s.dao.GetLatestEventByStatus(context.Background(), model.Unprocessed)
 	s.Require().NoError(err, "failed to query")
 	s.Require().True(result.ChallengeId == 10)
 }
@@ -141,14 +142,14 @@ func (s *eventSuite) TestEventDao_GetLatestEventByStatus() {
 func (s *eventSuite) TestEventDao_IsEventExistsBetween() {
 	block, event1, event2, event3 := s.createEvents()
 	events := []*model.Event{event1, event2, event3}
-	_ = s.dao.SaveBlockAndEvents(block, events)
+	_ = s.dao.SaveBlockAndEvents(context.Background(), block, events)
 
-	result, err := s.dao.IsEventExistsBetween(event2.ObjectId, event2.SpOperatorAddress,
+	result, err := s.dao.IsEventExistsBetween(context.Background(), event2.ObjectId, event2.SpOperatorAddress,
 		event2.ChallengeId-1, event2.ChallengeId+1)
 	s.Require().NoError(err, "failed to query")
 	s.Require().True(result)
 
-	result, err = s.dao.IsEventExistsBetween(event2.ObjectId, event2.SpOperatorAddress+"fake",
+	result, err = s.dao.IsEventExistsBetween(context.Background(), event2.ObjectId, event2.SpOperatorAddress+"fake",
 		event2.ChallengeId-1, event2.ChallengeId+1)
 	s.Require().NoError(err, "failed to query")
 	s.Require().True(!result)
@@ -157,24 +158,87 @@ func (s *eventSuite) TestEventDao_IsEventExistsBetween() {
 func (s *eventSuite) TestEventDao_UpdateEventStatusByChallengeId() {
 	block, event1, event2, event3 := s.createEvents()
 	events := []*model.Event{event1, event2, event3}
-	_ = s.dao.SaveBlockAndEvents(block, events)
+	_ = s.dao.SaveBlockAndEvents(context.Background(), block, events)
 
-	err := s.dao.UpdateEventStatusByChallengeId(event2.ChallengeId, model.SelfAttested)
+	err := s.dao.UpdateEventStatusByChallengeId(context.Background(), event2.ChallengeId, model.SelfAttested)
 	s.Require().NoError(err, "failed to update")
 
-	result, _ := s.dao.GetEventByChallengeId(event2.ChallengeId)
+	result, _ := s.dao.GetEventByChallengeId(context.Background(), event2.ChallengeId)
 	s.Require().True(result.Status == model.SelfAttested)
 }
 
 func (s *eventSuite) TestEventDao_UpdateEventStatusVerifyResultByChallengeId() {
 	block, event1, event2, event3 := s.createEvents()
 	events := []*model.Event{event1, event2, event3}
-	_ = s.dao.SaveBlockAndEvents(block, events)
+	_ = s.dao.SaveBlockAndEvents(context.Background(), block, events)
 
-	err := s.dao.UpdateEventStatusVerifyResultByChallengeId(event2.ChallengeId, model.Verified, model.HashMatched)
+	err := s.dao.UpdateEventStatusVerifyResultByChallengeId(context.Background(), event2.ChallengeId, model.Verified, model.HashMatched, "ab12")
 	s.Require().NoError(err, "failed to update")
 
-	result, _ := s.dao.GetEventByChallengeId(event2.ChallengeId)
+	result, _ := s.dao.GetEventByChallengeId(context.Background(), event2.ChallengeId)
 	s.Require().True(result.Status == model.Verified)
 	s.Require().True(result.VerifyResult == model.HashMatched)
+	s.Require().Equal("ab12", result.EventHash)
+}
+
+func (s *eventSuite) TestEventDao_ListEvents() {
+	block, event1, event2, event3 := s.createEvents()
+	event3.SpOperatorAddress = "sp2"
+	events := []*model.Event{event1, event2, event3}
+	_ = s.dao.SaveBlockAndEvents(context.Background(), block, events)
+
+	result, cursor, err := s.dao.ListEvents(context.Background(), EventFilter{}, 0, 2)
+	s.Require().NoError(err, "failed to query")
+	s.Require().True(len(result) == 2)
+	s.Require().True(cursor == event2.ChallengeId)
+
+	result, cursor, err = s.dao.ListEvents(context.Background(), EventFilter{}, cursor, 2)
+	s.Require().NoError(err, "failed to query")
+	s.Require().True(len(result) == 1)
+	s.Require().True(result[0].ChallengeId == event3.ChallengeId)
+	s.Require().True(cursor == 0)
+
+	result, _, err = s.dao.ListEvents(context.Background(), EventFilter{Statuses: []model.EventStatus{model.Unprocessed}}, 0, 10)
+	s.Require().NoError(err, "failed to query")
+	s.Require().True(len(result) == 2)
+
+	result, _, err = s.dao.ListEvents(context.Background(), EventFilter{SpOperatorAddress: "sp2"}, 0, 10)
+	s.Require().NoError(err, "failed to query")
+	s.Require().True(len(result) == 1)
+	s.Require().True(result[0].ChallengeId == event3.ChallengeId)
+
+	result, _, err = s.dao.ListEvents(context.Background(), EventFilter{FromHeight: 101}, 0, 10)
+	s.Require().NoError(err, "failed to query")
+	s.Require().True(len(result) == 0)
+}
+
+func (s *eventSuite) TestEventDao_ReplayEvents() {
+	block, event1, event2, event3 := s.createEvents()
+	event3.EventHash = "ab12"
+	events := []*model.Event{event1, event2, event3}
+	_ = s.dao.SaveBlockAndEvents(context.Background(), block, events)
+
+	err := s.dao.DeleteEventsBefore(context.Background(), time.Now().Unix()+1)
+	s.Require().NoError(err, "failed to delete")
+	_, err = s.dao.GetEventByChallengeId(context.Background(), event3.ChallengeId)
+	s.Require().Error(err)
+
+	count, err := s.dao.ReplayEvents(context.Background(), []uint64{event1.ChallengeId, event3.ChallengeId}, model.Verified)
+	s.Require().NoError(err, "failed to replay")
+	s.Require().True(count == 1)
+	result, err := s.dao.GetEventByChallengeId(context.Background(), event3.ChallengeId)
+	s.Require().NoError(err, "failed to query")
+	s.Require().True(result.Status == model.Verified)
+	s.Require().Equal("ab12", result.EventHash)
+
+	count, err = s.dao.ReplayEvents(context.Background(), []uint64{event3.ChallengeId}, model.Unprocessed)
+	s.Require().NoError(err, "failed to replay")
+	s.Require().True(count == 1)
+	result, _ = s.dao.GetEventByChallengeId(context.Background(), event3.ChallengeId)
+	s.Require().True(result.Status == model.Unprocessed)
+	s.Require().True(result.VerifyResult == model.Unknown)
+	s.Require().Empty(result.EventHash)
+
+	_, err = s.dao.ReplayEvents(context.Background(), []uint64{event3.ChallengeId}, model.Attested)
+	s.Require().Error(err)
 }
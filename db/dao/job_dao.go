@@ -0,0 +1,167 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxJobErrorLength is the longest error kept by a failed verification job.
+const MaxJobErrorLength = 1024
+
+type JobDao struct {
+	DB *gorm.DB
+	queryTimeout
+}
+
+func NewJobDao(db *gorm.DB) *JobDao {
+	return &JobDao{
+		DB: db,
+	}
+}
+
+// EnqueueVerificationJobs queues the verification of the challenges which are not queued yet.
+func (d *JobDao) EnqueueVerificationJobs(ctx context.Context, challengeIds []uint64, now time.Time) error {
+	if len(challengeIds) == 0 {
+		return nil
+	}
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	jobs := make([]*model.VerificationJob, 0, len(challengeIds))
+	for _, challengeId := range challengeIds {
+		jobs = append(jobs, &model.VerificationJob{
+			ChallengeId:   challengeId,
+			Status:        model.JobPending,
+			AvailableTime: now.UnixMilli(),
+			CreatedTime:   now.Unix(),
+			UpdatedTime:   now.Unix(),
+		})
+	}
+	return d.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&jobs).Error
+}
+
+// LeaseVerificationJobs leases up to limit jobs to holder until expireTime, the pending jobs which are available and
+// the leased jobs whose lease expired, the longest waiting first. The updates are conditional, so that a job is leased
+// by only one of the holders racing for it. A job which was leased maxAttempts times already is failed instead.
+func (d *JobDao) LeaseVerificationJobs(ctx context.Context, holder string, limit int, maxAttempts uint32, now,
+	expireTime time.Time,
+) ([]*model.VerificationJob, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	leasable := []interface{}{"((status = ? and available_time <= ?) or (status = ? and lease_expire_time < ?))",
+		model.JobPending, now.UnixMilli(), model.JobLeased, now.UnixMilli()}
+	candidates := make([]*model.VerificationJob, 0, limit)
+	err := d.DB.WithContext(ctx).Where(leasable[0], leasable[1:]...).Order("available_time asc").Order("id asc").
+		Limit(limit).Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+	leased := make([]*model.VerificationJob, 0, len(candidates))
+	for _, job := range candidates {
+		updates := map[string]interface{}{
+			"status":            model.JobLeased,
+			"holder":            holder,
+			"attempts":          job.Attempts + 1,
+			"lease_expire_time": expireTime.UnixMilli(),
+			"updated_time":      now.Unix(),
+		}
+		if job.Attempts >= maxAttempts {
+			// the last holder crashed or gave up without failing the job
+			updates = map[string]interface{}{
+				"status":       model.JobFailed,
+				"last_error":   "lease expired",
+				"updated_time": now.Unix(),
+			}
+		}
+		// the attempts tell whether another holder leased the job since it was read
+		result := d.DB.WithContext(ctx).Model(&model.VerificationJob{}).Where("id = ? and attempts = ?", job.Id, job.Attempts).
+			Where(leasable[0], leasable[1:]...).Updates(updates)
+		if result.Error != nil {
+			return leased, result.Error
+		}
+		if result.RowsAffected != 1 || job.Attempts >= maxAttempts {
+			continue
+		}
+		job.Status = model.JobLeased
+		job.Holder = holder
+		job.Attempts++
+		job.LeaseExpireTime = expireTime.UnixMilli()
+		job.UpdatedTime = now.Unix()
+		leased = append(leased, job)
+	}
+	return leased, nil
+}
+
+// AckVerificationJob deletes the job once it is done, if its holder still holds it.
+func (d *JobDao) AckVerificationJob(ctx context.Context, job *model.VerificationJob) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return d.DB.WithContext(ctx).Where("id = ? and holder = ? and status = ?", job.Id, job.Holder, model.JobLeased).
+		Delete(&model.VerificationJob{}).Error
+}
+
+// NackVerificationJob gives the job back once its attempt failed, to be leased again from availableTime. It is failed
+// for good if it was leased maxAttempts times. Nothing is updated if its holder does not hold it anymore.
+func (d *JobDao) NackVerificationJob(ctx context.Context, job *model.VerificationJob, jobErr string, maxAttempts uint32,
+	now, availableTime time.Time,
+) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	if len(jobErr) > MaxJobErrorLength {
+		jobErr = jobErr[:MaxJobErrorLength]
+	}
+	status := model.JobPending
+	if job.Attempts >= maxAttempts {
+		status = model.JobFailed
+	}
+	return d.DB.WithContext(ctx).Model(&model.VerificationJob{}).
+		Where("id = ? and holder = ? and status = ?", job.Id, job.Holder, model.JobLeased).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"available_time": availableTime.UnixMilli(),
+			"last_error":     jobErr,
+			"updated_time":   now.Unix(),
+		}).Error
+}
+
+// DeleteVerificationJobs drops the jobs of the challenges, failed ones included, so that they can be queued again.
+func (d *JobDao) DeleteVerificationJobs(ctx context.Context, challengeIds []uint64) error {
+	if len(challengeIds) == 0 {
+		return nil
+	}
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return d.DB.WithContext(ctx).Where("challenge_id IN ?", challengeIds).Delete(&model.VerificationJob{}).Error
+}
+
+// DeleteVerificationJobsBefore drops the jobs queued before the timestamp, e.g. the failed ones of wiped events.
+func (d *JobDao) DeleteVerificationJobsBefore(ctx context.Context, unixTimestamp int64) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return d.DB.WithContext(ctx).Where("created_time < ?", unixTimestamp).Delete(&model.VerificationJob{}).Error
+}
+
+// CountVerificationJobsByStatus returns the number of queued jobs by status.
+func (d *JobDao) CountVerificationJobsByStatus(ctx context.Context) (map[model.JobStatus]int64, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	var rows []struct {
+		Status model.JobStatus
+		Count  int64
+	}
+	err := d.DB.WithContext(ctx).Model(&model.VerificationJob{}).
+		Select("status, count(*) as count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[model.JobStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
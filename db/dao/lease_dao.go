@@ -0,0 +1,93 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LeaseDao struct {
+	DB *gorm.DB
+	queryTimeout
+}
+
+func NewLeaseDao(db *gorm.DB) *LeaseDao {
+	return &LeaseDao{
+		DB: db,
+	}
+}
+
+// dbNowMs returns the sql expression of the current unix time in milliseconds on the database. The leases are written
+// and compared on the clock of the database, so that the clocks of the challengers sharing it may drift apart.
+func dbNowMs(db *gorm.DB) string {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return "CAST(EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 AS BIGINT)"
+	case "sqlite":
+		return "CAST((JULIANDAY('now') - 2440587.5) * 86400000 AS INTEGER)"
+	default:
+		return "CAST(UNIX_TIMESTAMP(NOW(3)) * 1000 AS SIGNED)"
+	}
+}
+
+// AcquireLease takes the lease called name for holder for timeout if it is free, expired or already held by holder, it
+// reports whether holder holds the lease. The update is conditional, so that only one of the holders racing for an
+// expired lease gets it. The lease expires on the clock of the database, a holder should consider it lost after
+// timeout measured on its own clock from before the call.
+func (d *LeaseDao) AcquireLease(ctx context.Context, name, holder string, timeout time.Duration) (bool, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	// the lease row is created once, it is taken by the update below
+	err := d.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&model.Lease{Name: name}).Error
+	if err != nil {
+		return false, err
+	}
+	now := dbNowMs(d.DB)
+	result := d.DB.WithContext(ctx).Model(&model.Lease{}).
+		Where("name = ? and (holder = ? or expire_time < "+now+")", name, holder).
+		Updates(map[string]interface{}{
+			"holder":      holder,
+			"expire_time": gorm.Expr(now+" + ?", timeout.Milliseconds()),
+			// only informational, it is not compared
+			"updated_time": time.Now().Unix(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseLease expires the lease called name if holder holds it, so that another holder can take it right away.
+func (d *LeaseDao) ReleaseLease(ctx context.Context, name, holder string) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return d.DB.WithContext(ctx).Model(&model.Lease{}).Where("name = ? and holder = ?", name, holder).
+		Update("expire_time", 0).Error
+}
+
+// ListLeases returns the leases whose name starts with prefix and which have not expired on the clock of the database.
+func (d *LeaseDao) ListLeases(ctx context.Context, prefix string) ([]*model.Lease, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	leases := make([]*model.Lease, 0)
+	err := d.DB.WithContext(ctx).Where("name LIKE ? and expire_time >= "+dbNowMs(d.DB), prefix+"%").
+		Order("name asc").Find(&leases).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return leases, nil
+}
+
+func (d *LeaseDao) GetLease(ctx context.Context, name string) (*model.Lease, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	lease := model.Lease{}
+	err := d.DB.WithContext(ctx).Where("name = ?", name).Take(&lease).Error
+	if err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
@@ -0,0 +1,43 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LedgerDao struct {
+	DB *gorm.DB
+	queryTimeout
+}
+
+func NewLedgerDao(db *gorm.DB) *LedgerDao {
+	return &LedgerDao{
+		DB: db,
+	}
+}
+
+// SaveLedgerEntries saves the entries of a block, the entries of the transactions already saved are skipped, so that
+// a block polled again is not counted twice.
+func (d *LedgerDao) SaveLedgerEntries(ctx context.Context, entries []*model.LedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return d.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&entries).Error
+}
+
+// ListLedgerEntries returns the entries of the blocks produced at or after since, a unix timestamp.
+func (d *LedgerDao) ListLedgerEntries(ctx context.Context, since int64) ([]*model.LedgerEntry, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	entries := make([]*model.LedgerEntry, 0)
+	err := d.DB.WithContext(ctx).Where("block_time >= ?", since).Order("id asc").Find(&entries).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return entries, nil
+}
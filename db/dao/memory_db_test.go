@@ -0,0 +1,857 @@
+package dao
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// memoryDBSuite runs the daos against an in-memory sqlite database, which is what the e2e runs use instead of MySQL.
+type memoryDBSuite struct {
+	suite.Suite
+	db         *gorm.DB
+	daoManager *DaoManager
+}
+
+func TestMemoryDBSuite(t *testing.T) {
+	suite.Run(t, new(memoryDBSuite))
+}
+
+func (s *memoryDBSuite) SetupTest() {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	s.Require().NoError(err)
+	sqlDB, err := db.DB()
+	s.Require().NoError(err)
+	sqlDB.SetMaxOpenConns(1)
+
+	model.InitBlockTable(db)
+	model.InitEventTable(db)
+	model.InitVoteTable(db)
+	model.InitSpStatsTable(db)
+	model.InitVerificationResultTable(db)
+	model.InitSamplingFailureTable(db)
+	model.InitLeaseTable(db)
+	model.InitLedgerEntryTable(db)
+	model.InitRollupTables(db)
+	model.InitVerificationJobTable(db)
+
+	s.db = db
+	s.daoManager = NewDaoManager(NewBlockDao(db), NewEventDao(db), NewVoteDao(db), NewSpStatsDao(db),
+		NewVerificationResultDao(db), NewSamplingFailureDao(db), NewLeaseDao(db), NewLedgerDao(db),
+		NewRollupDao(db), NewJobDao(db))
+}
+
+func (s *memoryDBSuite) TearDownTest() {
+	sqlDB, err := s.db.DB()
+	s.Require().NoError(err)
+	s.Require().NoError(sqlDB.Close())
+}
+
+func (s *memoryDBSuite) TestMemoryDB_EventLifecycle() {
+	ctx := context.Background()
+	block := &model.Block{Height: 100, BlockTime: 1000}
+	event := &model.Event{
+		ChallengeId:       1,
+		ObjectId:          "1",
+		SpOperatorAddress: "sp1",
+		Height:            100,
+		ExpiredHeight:     200,
+		Status:            model.Unprocessed,
+		VerifyResult:      model.Unknown,
+		CreatedTime:       time.Now().Unix(),
+	}
+	err := s.daoManager.SaveBlockAndEvents(ctx, block, []*model.Event{event})
+	s.Require().NoError(err)
+
+	latest, err := s.daoManager.GetLatestBlock(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(100), latest.Height)
+
+	exists, err := s.daoManager.IsEventExistsBetween(ctx, "1", "sp1", 0, 10)
+	s.Require().NoError(err)
+	s.Require().True(exists)
+
+	err = s.daoManager.UpdateEventStatusVerifyResultByChallengeId(ctx, 1, model.Verified, model.HashMismatched, "")
+	s.Require().NoError(err)
+	err = s.daoManager.RecordSpVerifyResult(ctx, "sp1", model.HashMismatched, time.Second)
+	s.Require().NoError(err)
+
+	stats, err := s.daoManager.GetSpStats(ctx, "sp1")
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(1), stats.Received)
+	s.Require().Equal(uint64(1), stats.Succeeded)
+
+	err = s.daoManager.SaveVote(ctx, &model.Vote{ChallengeId: 1, EventHash: "hash", PubKey: "key1"})
+	s.Require().NoError(err)
+	count, err := s.daoManager.CountVotesByEventHash(ctx, "hash")
+	s.Require().NoError(err)
+	s.Require().Equal(int64(1), count)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_SaveBlockAndEventsOnce() {
+	ctx := context.Background()
+	event := &model.Event{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", Height: 100, ExpiredHeight: 200}
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 100}, []*model.Event{event}))
+
+	// a block saved again, e.g. after its commit was not acknowledged, does not save its events twice
+	again := &model.Event{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", Height: 100, ExpiredHeight: 200}
+	err := s.daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 100}, []*model.Event{again})
+	s.Require().ErrorIs(err, ErrBlockSaved)
+	stats, err := s.daoManager.GetSpStats(ctx, "sp1")
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(1), stats.Received)
+
+	// the block is not saved without its events
+	duplicated := &model.Event{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", Height: 101, ExpiredHeight: 200}
+	s.Require().Error(s.daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 101}, []*model.Event{duplicated}))
+	latest, err := s.daoManager.GetLatestBlock(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(100), latest.Height)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_VotesByEventHashes() {
+	ctx := context.Background()
+	for i := 0; i < VotesQueryBatchSize+2; i++ {
+		s.Require().NoError(s.daoManager.SaveVote(ctx, &model.Vote{EventHash: fmt.Sprintf("hash%d", i), PubKey: "key"}))
+	}
+	s.Require().NoError(s.daoManager.SaveVote(ctx, &model.Vote{EventHash: "hash0", PubKey: "other"}))
+
+	eventHashes := []string{"hash0", "unknown"}
+	for i := 2; i < VotesQueryBatchSize+2; i++ {
+		eventHashes = append(eventHashes, fmt.Sprintf("hash%d", i))
+	}
+	votes, err := s.daoManager.GetVotesByEventHashes(ctx, eventHashes)
+	s.Require().NoError(err)
+	s.Require().Len(votes, VotesQueryBatchSize+2)
+	for _, v := range votes {
+		s.Require().NotEqual("hash1", v.EventHash)
+	}
+}
+
+func (s *memoryDBSuite) TestMemoryDB_EventsByChallengeIds() {
+	ctx := context.Background()
+	events := make([]*model.Event, 0)
+	for i := 1; i <= EventsQueryBatchSize+2; i++ {
+		events = append(events, &model.Event{ChallengeId: uint64(i), ObjectId: "1", SpOperatorAddress: "sp1", Height: 100,
+			ExpiredHeight: 200})
+	}
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 100}, events))
+
+	// the challenges of the other challengers are not saved
+	challengeIds := []uint64{1, 1000000}
+	for i := 3; i <= EventsQueryBatchSize+2; i++ {
+		challengeIds = append(challengeIds, uint64(i))
+	}
+	found, err := s.daoManager.GetEventsByChallengeIds(ctx, challengeIds)
+	s.Require().NoError(err)
+	s.Require().Len(found, EventsQueryBatchSize+1)
+	for _, e := range found {
+		s.Require().NotEqual(uint64(2), e.ChallengeId)
+	}
+}
+
+func (s *memoryDBSuite) TestMemoryDB_PreparedStatements() {
+	ctx := context.Background()
+	event := &model.Event{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", Height: 100, ExpiredHeight: 200}
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 100}, []*model.Event{event}))
+	s.Require().NoError(s.daoManager.SaveVote(ctx, &model.Vote{EventHash: "hash", PubKey: "key"}))
+
+	defer func() { PrepareStatements = true }()
+	for _, prepare := range []bool{true, false} {
+		PrepareStatements = prepare
+		// the statements prepared by the first call are reused by the next ones
+		for i := 0; i < 2; i++ {
+			exists, err := s.daoManager.IsVoteExists(ctx, "hash", "key")
+			s.Require().NoError(err)
+			s.Require().True(exists)
+			exists, err = s.daoManager.IsVoteExists(ctx, "hash", "other")
+			s.Require().NoError(err)
+			s.Require().False(exists)
+
+			count, err := s.daoManager.CountVotesByEventHash(ctx, "hash")
+			s.Require().NoError(err)
+			s.Require().Equal(int64(1), count)
+
+			e, err := s.daoManager.GetEventByChallengeId(ctx, 1)
+			s.Require().NoError(err)
+			s.Require().Equal("sp1", e.SpOperatorAddress)
+			_, err = s.daoManager.GetEventByChallengeId(ctx, 2)
+			s.Require().ErrorIs(err, gorm.ErrRecordNotFound)
+
+			block, err := s.daoManager.GetLatestBlock(ctx)
+			s.Require().NoError(err)
+			s.Require().Equal(uint64(100), block.Height)
+		}
+	}
+}
+
+func (s *memoryDBSuite) TestMemoryDB_TablePrefix() {
+	model.TablePrefix = "testnet_"
+	defer func() { model.TablePrefix = "" }()
+	// the table names are cached per database with their schema
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	s.Require().NoError(err)
+	sqlDB, err := db.DB()
+	s.Require().NoError(err)
+	defer sqlDB.Close()
+	sqlDB.SetMaxOpenConns(1)
+	model.InitVoteTable(db)
+	s.Require().True(db.Migrator().HasTable("testnet_votes"))
+
+	ctx := context.Background()
+	voteDao := NewVoteDao(db)
+	s.Require().NoError(voteDao.SaveVote(ctx, &model.Vote{EventHash: "hash", PubKey: "key"}))
+	exists, err := voteDao.IsVoteExists(ctx, "hash", "key")
+	s.Require().NoError(err)
+	s.Require().True(exists)
+	exists, err = voteDao.IsVoteExists(ctx, "hash", "other")
+	s.Require().NoError(err)
+	s.Require().False(exists)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_Transitions() {
+	ctx := context.Background()
+	transitions := make([]string, 0)
+	s.daoManager.SetTransitionObserver(func(previous, event *model.Event) {
+		from := "none"
+		if previous != nil {
+			from = previous.Status.String()
+		}
+		transitions = append(transitions, from+">"+event.Status.String()+":"+event.VerifyResult.String())
+	})
+	events := []*model.Event{
+		{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", Height: 100},
+		{ChallengeId: 2, ObjectId: "2", SpOperatorAddress: "sp1", Height: 100},
+	}
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 100}, events))
+	s.Require().NoError(s.daoManager.UpdateEventStatusVerifyResultByChallengeId(ctx, 1, model.Verified, model.HashMismatched, ""))
+	s.Require().NoError(s.daoManager.SaveVoteAndUpdateEventStatus(ctx, &model.Vote{ChallengeId: 1, EventHash: "hash"}, 1))
+	// the status is set again, it is not a transition
+	s.Require().NoError(s.daoManager.UpdateEventStatusByChallengeId(ctx, 1, model.SelfVoted))
+	s.Require().NoError(s.daoManager.ExpireEvent(ctx, events[1]))
+	// event 2 was never verified, it cannot skip the verification
+	replayed, err := s.daoManager.ReplayEvents(ctx, []uint64{1, 2}, model.Verified)
+	s.Require().NoError(err)
+	s.Require().Equal(int64(1), replayed)
+
+	s.Require().Equal([]string{
+		"none>unprocessed:unknown",
+		"none>unprocessed:unknown",
+		"unprocessed>verified:hash_mismatched",
+		"verified>self_voted:hash_mismatched",
+		"unprocessed>expired:unknown",
+		"self_voted>verified:hash_mismatched",
+	}, transitions)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_StageDurations() {
+	ctx := context.Background()
+	observed := make(map[string]time.Duration)
+	s.daoManager.SetStageObserver(func(stage string, duration time.Duration) {
+		observed[stage] = duration
+	})
+	ingested := time.Now().Add(-time.Minute)
+	event := &model.Event{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", Height: 100,
+		CreatedTime: ingested.Unix(), StageTime: ingested.UnixMilli()}
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 100}, []*model.Event{event}))
+
+	s.Require().NoError(s.daoManager.UpdateEventStatusVerifyResultByChallengeId(ctx, 1, model.Verified, model.HashMismatched, ""))
+	s.Require().GreaterOrEqual(observed[model.StageVerify], time.Minute)
+	s.Require().NoError(s.daoManager.SaveVoteAndUpdateEventStatus(ctx, &model.Vote{ChallengeId: 1, EventHash: "hash"}, 1))
+	s.Require().Less(observed[model.StageVote], time.Minute)
+	s.Require().NoError(s.daoManager.UpdateEventStatusByChallengeId(ctx, 1, model.EnoughVotesCollected))
+	s.Require().Contains(observed, model.StageCollect)
+
+	// submitted does not complete a stage, the attest stage runs up to the attestation
+	s.Require().NoError(s.db.Model(&model.Event{}).Where("challenge_id = ?", 1).
+		Update("stage_time", ingested.UnixMilli()).Error)
+	s.Require().NoError(s.daoManager.UpdateEventStatusByChallengeId(ctx, 1, model.Submitted))
+	s.Require().NotContains(observed, model.StageAttest)
+	s.Require().NoError(s.daoManager.UpdateEventStatusByChallengeId(ctx, 1, model.Attested))
+	s.Require().GreaterOrEqual(observed[model.StageAttest], time.Minute)
+
+	// the status is set again, the stage is not completed twice
+	delete(observed, model.StageAttest)
+	s.Require().NoError(s.daoManager.UpdateEventStatusByChallengeId(ctx, 1, model.Attested))
+	s.Require().NotContains(observed, model.StageAttest)
+
+	// and the sp is counted as slashed once
+	stats, err := s.daoManager.GetSpStats(ctx, "sp1")
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(1), stats.Slashed)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_Snapshot() {
+	ctx := context.Background()
+	block := &model.Block{Height: 100, BlockTime: 1000}
+	events := []*model.Event{
+		{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", Height: 100, CreatedTime: 1},
+		{ChallengeId: 2, ObjectId: "2", SpOperatorAddress: "sp1", Height: 100, CreatedTime: 1},
+	}
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, block, events))
+	counts, err := s.daoManager.CountEventsByStatus(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(map[model.EventStatus]int64{model.Unprocessed: 2}, counts)
+	// deleted events are part of the snapshot
+	s.Require().NoError(s.daoManager.DeleteEventsBefore(ctx, 2))
+	latest, err := s.daoManager.GetLatestChallengeId(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(2), latest)
+
+	buf := &bytes.Buffer{}
+	summary, err := NewSnapshotDao(s.db).Snapshot(ctx, buf)
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(100), summary.LatestHeight)
+	s.Require().Equal(int64(1), summary.Rows[SnapshotTableBlocks])
+	s.Require().Equal(int64(2), summary.Rows[SnapshotTableEvents])
+	s.Require().Equal(int64(1), summary.Rows[SnapshotTableSpStats])
+
+	tables := make([]string, 0)
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		record := SnapshotRecord{}
+		s.Require().NoError(json.Unmarshal(scanner.Bytes(), &record))
+		tables = append(tables, record.Table)
+	}
+	s.Require().Equal([]string{SnapshotTableHeader, SnapshotTableBlocks, SnapshotTableEvents, SnapshotTableEvents,
+		SnapshotTableSpStats}, tables)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_PurgeEvents() {
+	ctx := context.Background()
+	events := []*model.Event{
+		{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", Height: 100, CreatedTime: 1},
+		{ChallengeId: 2, ObjectId: "2", SpOperatorAddress: "sp1", Height: 100, CreatedTime: 2},
+		{ChallengeId: 3, ObjectId: "3", SpOperatorAddress: "sp1", Height: 100, CreatedTime: 3},
+	}
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 100}, events))
+	s.Require().NoError(s.daoManager.DeleteEventsBefore(ctx, 3))
+
+	// the soft deleted events are purged as well as the live ones
+	s.Require().NoError(s.daoManager.PurgeEventsBefore(ctx, 2))
+	count := int64(0)
+	s.Require().NoError(s.db.Unscoped().Model(&model.Event{}).Count(&count).Error)
+	s.Require().Equal(int64(2), count)
+	replayed, err := s.daoManager.ReplayEvents(ctx, []uint64{1, 2}, model.Unprocessed)
+	s.Require().NoError(err)
+	s.Require().Equal(int64(1), replayed)
+
+	s.Require().NoError(s.daoManager.PurgeEventsBefore(ctx, 4))
+	s.Require().NoError(s.db.Unscoped().Model(&model.Event{}).Count(&count).Error)
+	s.Require().Equal(int64(0), count)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_Copy() {
+	ctx := context.Background()
+	block := &model.Block{Height: 100, BlockTime: 1000}
+	events := []*model.Event{
+		{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", Height: 100, CreatedTime: 1},
+		{ChallengeId: 2, ObjectId: "2", SpOperatorAddress: "sp1", Height: 100, CreatedTime: 1},
+	}
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, block, events))
+	s.Require().NoError(s.daoManager.SaveVerificationResult(ctx, &model.VerificationResult{ChallengeId: 2,
+		SpOperatorAddress: "sp1", Attempts: 1, Outcome: model.OutcomeSpUnavailable}))
+	// deleted events are copied too
+	s.Require().NoError(s.daoManager.DeleteEventsBefore(ctx, 2))
+
+	target, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	s.Require().NoError(err)
+	sqlDB, err := target.DB()
+	s.Require().NoError(err)
+	sqlDB.SetMaxOpenConns(1)
+	defer sqlDB.Close()
+	model.RunMigrations(target)
+
+	copyDao := NewCopyDao(s.db, target)
+	rows, err := copyDao.Copy(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(int64(1), rows[SnapshotTableBlocks])
+	s.Require().Equal(int64(2), rows[SnapshotTableEvents])
+	s.Require().Equal(int64(1), rows[SnapshotTableVerificationResults])
+	s.Require().NoError(copyDao.Verify(ctx))
+
+	targetEvents := NewEventDao(target)
+	latest, err := targetEvents.GetLatestChallengeId(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(2), latest)
+
+	// the target has to be empty
+	_, err = copyDao.Copy(ctx)
+	s.Require().ErrorContains(err, "not empty")
+
+	// the source changed after the copy
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 101, BlockTime: 1001}, nil))
+	s.Require().ErrorContains(copyDao.Verify(ctx), "table blocks differs")
+}
+
+func (s *memoryDBSuite) TestMemoryDB_Recovery() {
+	ctx := context.Background()
+	recoveryDao := NewRecoveryDao(s.db)
+	_, err := recoveryDao.Export(ctx)
+	s.Require().ErrorContains(err, "no block")
+
+	events := []*model.Event{
+		{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", Height: 100, ExpiredHeight: 200, Status: model.SelfVoted},
+		{ChallengeId: 2, ObjectId: "2", SpOperatorAddress: "sp1", Height: 100, ExpiredHeight: 200, Status: model.Attested},
+		{ChallengeId: 3, ObjectId: "3", SpOperatorAddress: "sp1", Height: 100, ExpiredHeight: 100, Status: model.Verified},
+		{ChallengeId: 4, ObjectId: "4", SpOperatorAddress: "sp1", Height: 100, ExpiredHeight: 200, Status: model.Submitted},
+	}
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 100, BlockTime: 1000}, events))
+	s.Require().NoError(s.daoManager.SaveVote(ctx, &model.Vote{ChallengeId: 1, PubKey: "pk1", EventHash: "h1"}))
+	s.Require().NoError(s.daoManager.SaveVote(ctx, &model.Vote{ChallengeId: 1, PubKey: "pk2", EventHash: "h1"}))
+	s.Require().NoError(s.daoManager.SaveVote(ctx, &model.Vote{ChallengeId: 2, PubKey: "pk1", EventHash: "h2"}))
+
+	// the attested and the expired events are not recovered
+	bundle, err := recoveryDao.Export(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(100), bundle.LatestBlock.Height)
+	s.Require().Len(bundle.Events, 2)
+	s.Require().Equal(uint64(1), bundle.Events[0].ChallengeId)
+	s.Require().Equal(uint64(4), bundle.Events[1].ChallengeId)
+	s.Require().Len(bundle.Votes, 2)
+
+	// the database has to be fresh
+	s.Require().ErrorContains(recoveryDao.Import(ctx, bundle), "not empty")
+
+	target, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	s.Require().NoError(err)
+	sqlDB, err := target.DB()
+	s.Require().NoError(err)
+	sqlDB.SetMaxOpenConns(1)
+	defer sqlDB.Close()
+	model.RunMigrations(target)
+	s.Require().NoError(NewRecoveryDao(target).Import(ctx, bundle))
+
+	latest, err := NewBlockDao(target).GetLatestBlock(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(100), latest.Height)
+	event, err := NewEventDao(target).GetEventByChallengeId(ctx, 1)
+	s.Require().NoError(err)
+	s.Require().Equal(model.SelfVoted, event.Status)
+	votes, err := NewVoteDao(target).GetVotesByEventHash(ctx, "h1")
+	s.Require().NoError(err)
+	s.Require().Len(votes, 2)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_ExpiringEvents() {
+	ctx := context.Background()
+	block := &model.Block{Height: 100, BlockTime: 1000}
+	events := []*model.Event{
+		{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", ExpiredHeight: 105, Status: model.Verified, VerifyResult: model.HashMismatched},
+		{ChallengeId: 2, ObjectId: "2", SpOperatorAddress: "sp1", ExpiredHeight: 105, Status: model.Verified, VerifyResult: model.HashMatched},
+		{ChallengeId: 3, ObjectId: "3", SpOperatorAddress: "sp1", ExpiredHeight: 200, Status: model.Unprocessed},
+		{ChallengeId: 4, ObjectId: "4", SpOperatorAddress: "sp1", ExpiredHeight: 105, Status: model.Attested, VerifyResult: model.HashMismatched},
+	}
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, block, events))
+
+	expiring, err := s.daoManager.GetExpiringEventsByStatuses(ctx, 100, 10, model.UnfinishedEventStatuses)
+	s.Require().NoError(err)
+	s.Require().Len(expiring, 1)
+	s.Require().Equal(uint64(1), expiring[0].ChallengeId)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_ExpireEvents() {
+	ctx := context.Background()
+	transitions := 0
+	s.daoManager.SetTransitionObserver(func(_, _ *model.Event) { transitions++ })
+	events := []*model.Event{
+		{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", ExpiredHeight: 105, Status: model.Unprocessed},
+		{ChallengeId: 2, ObjectId: "2", SpOperatorAddress: "sp1", ExpiredHeight: 105, Status: model.SelfVoted, VerifyResult: model.HashMismatched},
+		{ChallengeId: 3, ObjectId: "3", SpOperatorAddress: "sp2", ExpiredHeight: 105, Status: model.Unprocessed},
+		{ChallengeId: 4, ObjectId: "4", SpOperatorAddress: "sp1", ExpiredHeight: 200, Status: model.Unprocessed},
+		{ChallengeId: 5, ObjectId: "5", SpOperatorAddress: "sp1", ExpiredHeight: 105, Status: model.Attested, VerifyResult: model.HashMismatched},
+	}
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 100}, events))
+	transitions = 0
+
+	expired, err := s.daoManager.ExpireEvents(ctx, 150, model.UnfinishedEventStatuses)
+	s.Require().NoError(err)
+	s.Require().Len(expired, 3)
+	s.Require().Equal(model.SelfVoted, expired[1].Status)
+	s.Require().Equal(3, transitions)
+	for _, challengeId := range []uint64{1, 2, 3} {
+		event, err := s.daoManager.GetEventByChallengeId(ctx, challengeId)
+		s.Require().NoError(err)
+		s.Require().Equal(model.Expired, event.Status)
+	}
+	event, err := s.daoManager.GetEventByChallengeId(ctx, 4)
+	s.Require().NoError(err)
+	s.Require().Equal(model.Unprocessed, event.Status)
+
+	// only the events expired before being verified are counted
+	stats, err := s.daoManager.GetSpStats(ctx, "sp1")
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(1), stats.Expired)
+	stats, err = s.daoManager.GetSpStats(ctx, "sp2")
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(1), stats.Expired)
+
+	expired, err = s.daoManager.ExpireEvents(ctx, 150, model.UnfinishedEventStatuses)
+	s.Require().NoError(err)
+	s.Require().Empty(expired)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_ExpireEventsChanged() {
+	ctx := context.Background()
+	events := []*model.Event{
+		{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", ExpiredHeight: 105, Status: model.Unprocessed},
+		{ChallengeId: 2, ObjectId: "2", SpOperatorAddress: "sp1", ExpiredHeight: 105, Status: model.Unprocessed},
+		{ChallengeId: 3, ObjectId: "3", SpOperatorAddress: "sp1", ExpiredHeight: 105, Status: model.Unprocessed},
+	}
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 100}, events))
+	// the second event is attested between the query and the update of the expiry
+	raced := false
+	s.Require().NoError(s.db.Callback().Update().Before("gorm:update").Register("test:race", func(tx *gorm.DB) {
+		if raced || tx.Statement.Table != (&model.Event{}).TableName() {
+			return
+		}
+		raced = true
+		s.Require().NoError(tx.Session(&gorm.Session{NewDB: true}).Model(&model.Event{}).
+			Where("challenge_id = ?", 2).Update("status", model.Attested).Error)
+	}))
+
+	expired, err := s.daoManager.ExpireEvents(ctx, 150, model.UnfinishedEventStatuses)
+	s.Require().NoError(err)
+	s.Require().True(raced)
+	s.Require().Len(expired, 2)
+	s.Require().Equal(uint64(1), expired[0].ChallengeId)
+	s.Require().Equal(uint64(3), expired[1].ChallengeId)
+	event, err := s.daoManager.GetEventByChallengeId(ctx, 2)
+	s.Require().NoError(err)
+	s.Require().Equal(model.Attested, event.Status)
+	stats, err := s.daoManager.GetSpStats(ctx, "sp1")
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(2), stats.Expired)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_VerificationResults() {
+	ctx := context.Background()
+	result := &model.VerificationResult{
+		ChallengeId:       1,
+		SpOperatorAddress: "sp1",
+		Attempts:          3,
+		Outcome:           model.OutcomeSpUnavailable,
+	}
+	s.Require().NoError(s.daoManager.SaveVerificationResult(ctx, result))
+
+	// verifying a replayed event replaces the previous result
+	result = &model.VerificationResult{
+		ChallengeId:       1,
+		SpOperatorAddress: "sp1",
+		ExpectedHash:      "aa",
+		ActualHash:        "aa",
+		Attempts:          1,
+		Outcome:           model.OutcomeHashMatched,
+	}
+	s.Require().NoError(s.daoManager.SaveVerificationResult(ctx, result))
+
+	saved, err := s.daoManager.GetVerificationResult(ctx, 1)
+	s.Require().NoError(err)
+	s.Require().Equal(model.OutcomeHashMatched, saved.Outcome)
+	s.Require().Equal(uint32(1), saved.Attempts)
+
+	results, err := s.daoManager.GetVerificationResultsByChallengeIds(ctx, []uint64{1, 2})
+	s.Require().NoError(err)
+	s.Require().Len(results, 1)
+	s.Require().Equal("aa", results[1].ActualHash)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_RecentEvents() {
+	ctx := context.Background()
+	events := []*model.Event{{ChallengeId: 1, ObjectId: "1"}, {ChallengeId: 3, ObjectId: "3"}, {ChallengeId: 2, ObjectId: "2"}}
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 100}, events))
+	recent, err := s.daoManager.ListRecentEvents(ctx, 2)
+	s.Require().NoError(err)
+	s.Require().Len(recent, 2)
+	s.Require().Equal(uint64(3), recent[0].ChallengeId)
+	s.Require().Equal(uint64(2), recent[1].ChallengeId)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_Rollups() {
+	ctx := context.Background()
+	for i, createdTime := range []int64{3599, 3600, 7300} {
+		s.Require().NoError(s.daoManager.SaveVerificationResult(ctx, &model.VerificationResult{ChallengeId: uint64(i + 1),
+			SpOperatorAddress: "sp1", BucketName: "bucket1", CreatedTime: createdTime}))
+	}
+	results, err := s.daoManager.ListVerificationResultsBetween(ctx, 3600, 7200)
+	s.Require().NoError(err)
+	s.Require().Len(results, 1)
+	s.Require().Equal("bucket1", results[0].BucketName)
+	earliest, err := s.daoManager.GetEarliestVerificationResultTime(ctx, 3600)
+	s.Require().NoError(err)
+	s.Require().Equal(int64(3600), earliest)
+
+	latest, err := s.daoManager.GetLatestRollupWindow(ctx)
+	s.Require().NoError(err)
+	s.Require().Zero(latest)
+	sp := func(windowStart int64, verified uint64) *model.SpRollup {
+		return &model.SpRollup{WindowStart: windowStart, SpOperatorAddress: "sp1",
+			RollupCounters: model.RollupCounters{Verified: verified}}
+	}
+	s.Require().NoError(s.daoManager.SaveRollups(ctx, 0, 7200, []*model.SpRollup{sp(0, 1), sp(3600, 1)},
+		[]*model.BucketRollup{{WindowStart: 3600, BucketName: "bucket1", RollupCounters: model.RollupCounters{Verified: 1}}}))
+	// rolling up a window again replaces its rollups
+	s.Require().NoError(s.daoManager.SaveRollups(ctx, 3600, 7200, []*model.SpRollup{sp(3600, 2)}, nil))
+	latest, err = s.daoManager.GetLatestRollupWindow(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(int64(3600), latest)
+
+	spRollups, err := s.daoManager.ListSpRollups(ctx, "sp1", 0, 7200)
+	s.Require().NoError(err)
+	s.Require().Len(spRollups, 2)
+	s.Require().Equal(uint64(2), spRollups[1].Verified)
+	bucketRollups, err := s.daoManager.ListBucketRollups(ctx, "bucket1", 0, 7200)
+	s.Require().NoError(err)
+	s.Require().Empty(bucketRollups)
+
+	s.Require().NoError(s.daoManager.DeleteRollupsBefore(ctx, 3600))
+	spRollups, err = s.daoManager.ListSpRollups(ctx, "sp1", 0, 7200)
+	s.Require().NoError(err)
+	s.Require().Len(spRollups, 1)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_SamplingFailures() {
+	ctx := context.Background()
+	failure := func(objectId, sp string, segmentIndex uint32) *model.SamplingFailure {
+		return &model.SamplingFailure{ObjectId: objectId, SpOperatorAddress: sp, BucketName: "bucket", ObjectName: objectId,
+			SegmentIndex: segmentIndex, Result: "unavailable"}
+	}
+	s.Require().NoError(s.daoManager.RecordSamplingFailure(ctx, failure("1", "sp1", 0)))
+	s.Require().NoError(s.daoManager.RecordSamplingFailure(ctx, failure("2", "sp1", 0)))
+	failures, err := s.daoManager.ListSamplingFailures(ctx, 10)
+	s.Require().NoError(err)
+	s.Require().Len(failures, 2)
+	s.Require().NoError(s.daoManager.MarkSamplingFailureChallenged(ctx, failures[0].Id))
+
+	failures, err = s.daoManager.ListSamplingFailures(ctx, 10)
+	s.Require().NoError(err)
+	s.Require().Len(failures, 1)
+	s.Require().Equal("2", failures[0].ObjectId)
+
+	// a challenged piece which fails again is a candidate again, with the latest piece
+	s.Require().NoError(s.daoManager.RecordSamplingFailure(ctx, failure("1", "sp1", 3)))
+	failures, err = s.daoManager.ListSamplingFailures(ctx, 10)
+	s.Require().NoError(err)
+	s.Require().Len(failures, 2)
+	for _, f := range failures {
+		if f.ObjectId == "1" {
+			s.Require().Equal(uint32(3), f.SegmentIndex)
+			s.Require().Equal(uint64(2), f.Failures)
+		}
+	}
+}
+
+func (s *memoryDBSuite) TestMemoryDB_Leases() {
+	ctx := context.Background()
+	start := time.Now().UnixMilli()
+	held, err := s.daoManager.AcquireLease(ctx, "leader", "a", 15*time.Second)
+	s.Require().NoError(err)
+	s.Require().True(held)
+	// the lease expires on the clock of the database
+	lease, err := s.daoManager.GetLease(ctx, "leader")
+	s.Require().NoError(err)
+	s.Require().Greater(lease.ExpireTime, start+14000)
+	s.Require().Less(lease.ExpireTime, time.Now().UnixMilli()+16000)
+
+	// the lease is renewed by its holder only, until it expires
+	held, err = s.daoManager.AcquireLease(ctx, "leader", "b", 15*time.Second)
+	s.Require().NoError(err)
+	s.Require().False(held)
+	held, err = s.daoManager.AcquireLease(ctx, "leader", "a", -time.Second)
+	s.Require().NoError(err)
+	s.Require().True(held)
+	held, err = s.daoManager.AcquireLease(ctx, "leader", "b", 15*time.Second)
+	s.Require().NoError(err)
+	s.Require().True(held)
+
+	// a released lease is free right away
+	s.Require().NoError(s.daoManager.ReleaseLease(ctx, "leader", "a"))
+	lease, err = s.daoManager.GetLease(ctx, "leader")
+	s.Require().NoError(err)
+	s.Require().Equal("b", lease.Holder)
+	s.Require().NoError(s.daoManager.ReleaseLease(ctx, "leader", "b"))
+	held, err = s.daoManager.AcquireLease(ctx, "leader", "a", 15*time.Second)
+	s.Require().NoError(err)
+	s.Require().True(held)
+
+	// the expired leases are not listed
+	held, err = s.daoManager.AcquireLease(ctx, "shard/0", "a", 15*time.Second)
+	s.Require().NoError(err)
+	s.Require().True(held)
+	held, err = s.daoManager.AcquireLease(ctx, "shard/1", "a", -time.Second)
+	s.Require().NoError(err)
+	s.Require().True(held)
+	leases, err := s.daoManager.ListLeases(ctx, "shard/")
+	s.Require().NoError(err)
+	s.Require().Len(leases, 1)
+	s.Require().Equal("shard/0", leases[0].Name)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_VerificationJobs() {
+	ctx := context.Background()
+	now := time.Now()
+	s.Require().NoError(s.daoManager.EnqueueVerificationJobs(ctx, []uint64{1, 2, 3}, now))
+	// the queued challenges are not queued again
+	s.Require().NoError(s.daoManager.EnqueueVerificationJobs(ctx, []uint64{1, 4}, now))
+
+	jobs, err := s.daoManager.LeaseVerificationJobs(ctx, "a", 2, 2, now, now.Add(time.Minute))
+	s.Require().NoError(err)
+	s.Require().Len(jobs, 2)
+	s.Require().Equal(uint64(1), jobs[0].ChallengeId)
+	s.Require().Equal(uint32(1), jobs[0].Attempts)
+	// the leased jobs are not leased by another holder until their lease expires
+	others, err := s.daoManager.LeaseVerificationJobs(ctx, "b", 10, 2, now, now.Add(time.Minute))
+	s.Require().NoError(err)
+	s.Require().Len(others, 2)
+	s.Require().Equal(uint64(3), others[0].ChallengeId)
+
+	s.Require().NoError(s.daoManager.AckVerificationJob(ctx, jobs[0]))
+	// a failed job is retried after its backoff
+	s.Require().NoError(s.daoManager.NackVerificationJob(ctx, jobs[1], "sp unavailable", 2, now, now.Add(time.Second)))
+	retried, err := s.daoManager.LeaseVerificationJobs(ctx, "b", 10, 2, now, now.Add(time.Minute))
+	s.Require().NoError(err)
+	s.Require().Empty(retried)
+	retried, err = s.daoManager.LeaseVerificationJobs(ctx, "b", 10, 2, now.Add(time.Second), now.Add(time.Minute))
+	s.Require().NoError(err)
+	s.Require().Len(retried, 1)
+	s.Require().Equal(uint64(2), retried[0].ChallengeId)
+	s.Require().Equal(uint32(2), retried[0].Attempts)
+	// a job is acked and nacked by its holder only
+	s.Require().NoError(s.daoManager.AckVerificationJob(ctx, jobs[1]))
+	s.Require().NoError(s.daoManager.NackVerificationJob(ctx, retried[0], "sp unavailable", 2, now, now))
+
+	// the expired leases are taken over, the jobs leased the max attempts are failed
+	expired := now.Add(2 * time.Minute)
+	taken, err := s.daoManager.LeaseVerificationJobs(ctx, "a", 10, 2, expired, expired.Add(time.Minute))
+	s.Require().NoError(err)
+	s.Require().Len(taken, 2)
+	s.Require().Equal([]uint64{3, 4}, []uint64{taken[0].ChallengeId, taken[1].ChallengeId})
+	counts, err := s.daoManager.CountVerificationJobsByStatus(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(map[model.JobStatus]int64{model.JobLeased: 2, model.JobFailed: 1}, counts)
+
+	// replaying an event drops its failed job, so that it is queued again
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 100}, []*model.Event{
+		{ChallengeId: 2, ObjectId: "1", SpOperatorAddress: "sp", Height: 100, ExpiredHeight: 200, Status: model.Verified,
+			VerifyResult: model.HashMatched},
+	}))
+	_, err = s.daoManager.ReplayEvents(ctx, []uint64{2}, model.Unprocessed)
+	s.Require().NoError(err)
+	counts, err = s.daoManager.CountVerificationJobsByStatus(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(map[model.JobStatus]int64{model.JobLeased: 2}, counts)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_EventsInShards() {
+	ctx := context.Background()
+	events := make([]*model.Event, 0)
+	for id := uint64(1); id <= 6; id++ {
+		events = append(events, &model.Event{ChallengeId: id, ObjectId: "1", SpOperatorAddress: "sp", Height: 100,
+			ExpiredHeight: 200, Status: model.Unprocessed})
+	}
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 100}, events))
+
+	sharded, err := s.daoManager.GetUnexpiredEventsByStatusInShards(ctx, 150, model.Unprocessed,
+		ShardFilter{Count: 3, Shards: []uint64{0, 2}})
+	s.Require().NoError(err)
+	ids := make([]uint64, 0)
+	for _, e := range sharded {
+		ids = append(ids, e.ChallengeId)
+	}
+	s.Require().Equal([]uint64{2, 3, 5, 6}, ids)
+
+	// no events without shards
+	sharded, err = s.daoManager.GetUnexpiredEventsByStatusInShards(ctx, 150, model.Unprocessed, ShardFilter{Count: 3})
+	s.Require().NoError(err)
+	s.Require().Empty(sharded)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_EventVolume() {
+	ctx := context.Background()
+	events := make([]*model.Event, 0)
+	for id := uint64(1); id <= 6; id++ {
+		// 2 and 4 are heartbeats, 5 and 6 are mismatched, 1 was saved before the counted period
+		event := &model.Event{ChallengeId: id, ObjectId: "1", SpOperatorAddress: "sp", Height: 100,
+			ExpiredHeight: 200, VerifyResult: model.HashMatched, CreatedTime: 1000 + int64(id)}
+		if id >= 5 {
+			event.VerifyResult = model.HashMismatched
+		}
+		events = append(events, event)
+	}
+	s.Require().NoError(s.daoManager.SaveBlockAndEvents(ctx, &model.Block{Height: 100}, events))
+	// the deleted events are counted
+	s.Require().NoError(s.daoManager.DeleteEventsBefore(ctx, 1004))
+
+	volume, err := s.daoManager.CountEventVolume(ctx, 1002, 2)
+	s.Require().NoError(err)
+	s.Require().Equal(EventVolume{Challenges: 5, Heartbeats: 2, Mismatched: 2, FirstTime: 1002}, *volume)
+
+	volume, err = s.daoManager.CountEventVolume(ctx, 2000, 2)
+	s.Require().NoError(err)
+	s.Require().Equal(EventVolume{}, *volume)
+}
+
+func (s *memoryDBSuite) TestMemoryDB_PlanMigrations() {
+	s.Require().Empty(model.PlanMigrations(s.db))
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	s.Require().NoError(err)
+	defer func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	}()
+	// only the tables are created on an empty database, their columns and indexes come with them
+	migrations := model.PlanMigrations(db)
+	s.Require().Len(migrations, 11)
+	s.Require().Equal("create table events", migrations[1].Description)
+
+	model.RunMigrations(db)
+	s.Require().Empty(model.PlanMigrations(db))
+}
+
+func (s *memoryDBSuite) TestMemoryDB_RenameVoteIndex() {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	s.Require().NoError(err)
+	defer func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	}()
+	// the votes table as created by older versions
+	s.Require().NoError(db.Migrator().CreateTable(&model.Vote{}))
+	s.Require().NoError(db.Migrator().DropIndex(&model.Vote{}, "idx_vote_challenge_id"))
+	s.Require().NoError(db.Exec("CREATE INDEX idx_challenge_id ON votes (challenge_id)").Error)
+
+	// the rename is the only migration asking for a backup first
+	destructive := make([]string, 0)
+	for _, m := range model.PlanMigrations(db) {
+		if m.Destructive {
+			destructive = append(destructive, m.Description)
+		}
+	}
+	s.Require().Equal([]string{"rename index idx_challenge_id to idx_vote_challenge_id on table votes"}, destructive)
+
+	model.InitVoteTable(db)
+	s.Require().True(db.Migrator().HasIndex(&model.Vote{}, "idx_vote_challenge_id"))
+	s.Require().False(db.Migrator().HasIndex(&model.Vote{}, "idx_challenge_id"))
+}
+
+func (s *memoryDBSuite) TestMemoryDB_QueryTimeout() {
+	ctx := context.Background()
+	s.daoManager.SetQueryTimeout(time.Nanosecond)
+	_, err := s.daoManager.GetLatestBlock(ctx)
+	s.Require().ErrorIs(err, context.DeadlineExceeded)
+
+	// a zero timeout restores the default
+	s.daoManager.SetQueryTimeout(0)
+	_, err = s.daoManager.CountEventsByStatus(ctx)
+	s.Require().NoError(err)
+}
@@ -0,0 +1,117 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// RecoveryBatchSize is the number of challenges whose votes are read or inserted at a time.
+const RecoveryBatchSize = 500
+
+// RecoverableEventStatuses are the statuses of the events which may still be attested, and are worth recovering.
+var RecoverableEventStatuses = []model.EventStatus{model.Unprocessed, model.Verified, model.SelfVoted,
+	model.EnoughVotesCollected, model.Submitted}
+
+// RecoveryBundle is the in-flight state of a challenger: the latest polled block, the events which are neither
+// attested nor expired at it, and the votes collected for them.
+type RecoveryBundle struct {
+	LatestBlock *model.Block   `json:"latest_block"`
+	Events      []*model.Event `json:"events"`
+	Votes       []*model.Vote  `json:"votes"`
+}
+
+type RecoveryDao struct {
+	DB *gorm.DB
+}
+
+func NewRecoveryDao(db *gorm.DB) *RecoveryDao {
+	return &RecoveryDao{
+		DB: db,
+	}
+}
+
+// Export reads the recovery bundle in a single repeatable read transaction, so the votes match the events even though
+// the pipeline keeps running. Export is not bounded by the query timeout, only by ctx.
+func (d *RecoveryDao) Export(ctx context.Context) (*RecoveryBundle, error) {
+	bundle := &RecoveryBundle{}
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		block := &model.Block{}
+		if err := tx.Order("height desc").Take(block).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("no block was polled yet")
+			}
+			return err
+		}
+		bundle.LatestBlock = block
+		if err := tx.Where("status IN ? AND expired_height > ?", RecoverableEventStatuses, block.Height).
+			Order("challenge_id asc").Find(&bundle.Events).Error; err != nil {
+			return err
+		}
+		bundle.Votes = make([]*model.Vote, 0)
+		for start := 0; start < len(bundle.Events); start += RecoveryBatchSize {
+			end := start + RecoveryBatchSize
+			if end > len(bundle.Events) {
+				end = len(bundle.Events)
+			}
+			challengeIds := make([]uint64, 0, end-start)
+			for _, event := range bundle.Events[start:end] {
+				challengeIds = append(challengeIds, event.ChallengeId)
+			}
+			votes := make([]*model.Vote, 0)
+			if err := tx.Where("challenge_id IN ?", challengeIds).Order("id asc").Find(&votes).Error; err != nil {
+				return err
+			}
+			bundle.Votes = append(bundle.Votes, votes...)
+		}
+		return nil
+	}, readTxOptions(d.DB))
+	if err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// Import saves the recovery bundle in a single transaction, the rows getting new ids. The database must not have
+// polled any block nor saved any event or vote, the challenger then resumes polling after the latest block of the
+// bundle. The sp stats are not imported.
+func (d *RecoveryDao) Import(ctx context.Context, bundle *RecoveryBundle) error {
+	if bundle.LatestBlock == nil {
+		return errors.New("the bundle has no latest block")
+	}
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, m := range []schema.Tabler{&model.Block{}, &model.Event{}, &model.Vote{}} {
+			var count int64
+			if err := tx.Unscoped().Model(m).Count(&count).Error; err != nil {
+				return err
+			}
+			if count != 0 {
+				return fmt.Errorf("table %s is not empty, it has %d rows", m.TableName(), count)
+			}
+		}
+		block := *bundle.LatestBlock
+		block.Id = 0
+		if err := tx.Create(&block).Error; err != nil {
+			return err
+		}
+		for _, event := range bundle.Events {
+			event.Id = 0
+		}
+		if len(bundle.Events) != 0 {
+			if err := tx.CreateInBatches(bundle.Events, RecoveryBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		for _, vote := range bundle.Votes {
+			vote.Id = 0
+		}
+		if len(bundle.Votes) != 0 {
+			return tx.CreateInBatches(bundle.Votes, RecoveryBatchSize).Error
+		}
+		return nil
+	})
+}
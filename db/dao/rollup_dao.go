@@ -0,0 +1,102 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"gorm.io/gorm"
+)
+
+type RollupDao struct {
+	DB *gorm.DB
+	queryTimeout
+}
+
+func NewRollupDao(db *gorm.DB) *RollupDao {
+	return &RollupDao{
+		DB: db,
+	}
+}
+
+// GetLatestRollupWindow returns the start of the latest window rolled up, 0 if there is none. Every verification
+// result has a storage provider, so the sp rollups hold all the windows rolled up.
+func (d *RollupDao) GetLatestRollupWindow(ctx context.Context) (int64, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	var windowStart int64
+	err := d.DB.WithContext(ctx).Model(&model.SpRollup{}).
+		Select("coalesce(max(window_start), 0)").
+		Scan(&windowStart).Error
+	if err != nil {
+		return 0, err
+	}
+	return windowStart, nil
+}
+
+// SaveRollups replaces the rollups of the windows starting in [from, to) in a single transaction, so that rolling up
+// the same windows again does not count their results twice.
+func (d *RollupDao) SaveRollups(ctx context.Context, from, to int64, spRollups []*model.SpRollup,
+	bucketRollups []*model.BucketRollup,
+) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("window_start >= ? AND window_start < ?", from, to).Delete(&model.SpRollup{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("window_start >= ? AND window_start < ?", from, to).Delete(&model.BucketRollup{}).Error
+		if err != nil {
+			return err
+		}
+		if len(spRollups) != 0 {
+			if err = tx.CreateInBatches(spRollups, CopyBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(bucketRollups) != 0 {
+			return tx.CreateInBatches(bucketRollups, CopyBatchSize).Error
+		}
+		return nil
+	})
+}
+
+// DeleteRollupsBefore deletes the rollups of the windows starting before windowStart.
+func (d *RollupDao) DeleteRollupsBefore(ctx context.Context, windowStart int64) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("window_start < ?", windowStart).Delete(&model.SpRollup{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("window_start < ?", windowStart).Delete(&model.BucketRollup{}).Error
+	})
+}
+
+// ListSpRollups returns the rollups of a storage provider for the windows starting in [from, to), the oldest first.
+func (d *RollupDao) ListSpRollups(ctx context.Context, spOperatorAddress string, from, to int64) ([]*model.SpRollup, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	rollups := make([]*model.SpRollup, 0)
+	err := d.DB.WithContext(ctx).
+		Where("sp_operator_address = ? AND window_start >= ? AND window_start < ?", spOperatorAddress, from, to).
+		Order("window_start asc").Find(&rollups).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return rollups, nil
+}
+
+// ListBucketRollups returns the rollups of a bucket for the windows starting in [from, to), the oldest first.
+func (d *RollupDao) ListBucketRollups(ctx context.Context, bucketName string, from, to int64) ([]*model.BucketRollup, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	rollups := make([]*model.BucketRollup, 0)
+	err := d.DB.WithContext(ctx).
+		Where("bucket_name = ? AND window_start >= ? AND window_start < ?", bucketName, from, to).
+		Order("window_start asc").Find(&rollups).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return rollups, nil
+}
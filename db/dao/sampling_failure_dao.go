@@ -0,0 +1,62 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SamplingFailureDao struct {
+	DB *gorm.DB
+	queryTimeout
+}
+
+func NewSamplingFailureDao(db *gorm.DB) *SamplingFailureDao {
+	return &SamplingFailureDao{
+		DB: db,
+	}
+}
+
+// RecordSamplingFailure saves a failed sample of a storage provider, it replaces the piece of the failure recorded for
+// the same object, which is a candidate again if it was challenged.
+func (d *SamplingFailureDao) RecordSamplingFailure(ctx context.Context, failure *model.SamplingFailure) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	failure.FailedTime = time.Now().Unix()
+	failure.Failures = 1
+	failure.Challenged = false
+	return d.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "object_id"}, {Name: "sp_operator_address"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"redundancy_index": failure.RedundancyIndex,
+			"segment_index":    failure.SegmentIndex,
+			"result":           failure.Result,
+			"failures":         gorm.Expr("failures + 1"),
+			"failed_time":      failure.FailedTime,
+			"challenged":       false,
+		}),
+	}).Create(failure).Error
+}
+
+// ListSamplingFailures returns up to limit failures which were not challenged yet, the oldest first.
+func (d *SamplingFailureDao) ListSamplingFailures(ctx context.Context, limit int) ([]*model.SamplingFailure, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	failures := make([]*model.SamplingFailure, 0)
+	err := d.DB.WithContext(ctx).Where("challenged = ?", false).Order("failed_time asc").Order("id asc").
+		Limit(limit).Find(&failures).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return failures, nil
+}
+
+// MarkSamplingFailureChallenged marks a failure as challenged, so that it is not listed again until it fails again.
+func (d *SamplingFailureDao) MarkSamplingFailureChallenged(ctx context.Context, id int64) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return d.DB.WithContext(ctx).Model(&model.SamplingFailure{}).Where("id = ?", id).Update("challenged", true).Error
+}
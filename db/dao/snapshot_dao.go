@@ -0,0 +1,123 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"gorm.io/gorm"
+)
+
+// SnapshotBatchSize is the number of rows read at a time while taking a snapshot.
+const SnapshotBatchSize = 1000
+
+const (
+	SnapshotTableHeader  = "header"
+	SnapshotTableBlocks  = "blocks"
+	SnapshotTableEvents  = "events"
+	SnapshotTableVotes   = "votes"
+	SnapshotTableSpStats = "sp_stats"
+
+	SnapshotTableVerificationResults = "verification_results"
+)
+
+// SnapshotRecord is a single line of a snapshot. Tables are named without the configured table prefix, so that a
+// snapshot can be restored into a differently prefixed database.
+type SnapshotRecord struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+// SnapshotHeader is the first record of a snapshot.
+type SnapshotHeader struct {
+	LatestHeight uint64 `json:"latest_height"`
+	CreatedTime  int64  `json:"created_time"`
+}
+
+// SnapshotSummary describes a snapshot taken by Snapshot.
+type SnapshotSummary struct {
+	SnapshotHeader
+	Rows map[string]int64
+}
+
+type SnapshotDao struct {
+	DB *gorm.DB
+}
+
+func NewSnapshotDao(db *gorm.DB) *SnapshotDao {
+	return &SnapshotDao{
+		DB: db,
+	}
+}
+
+// Snapshot writes all challenger tables to w as json lines. The tables are read in a single repeatable read
+// transaction, every pipeline stage persists its writes in one transaction, so the snapshot never contains half of a
+// write even though the pipeline keeps running. Deleted events are included so that they can still be replayed
+// after a restore. Snapshot is not bounded by the query timeout, only by ctx.
+func (d *SnapshotDao) Snapshot(ctx context.Context, w io.Writer) (*SnapshotSummary, error) {
+	summary := &SnapshotSummary{Rows: make(map[string]int64)}
+	enc := json.NewEncoder(w)
+
+	opts := &sql.TxOptions{}
+	if d.DB.Dialector.Name() == "mysql" {
+		opts = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	}
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		block := model.Block{}
+		err := tx.Model(&model.Block{}).Order("height desc").Take(&block).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		summary.LatestHeight = block.Height
+		summary.CreatedTime = time.Now().Unix()
+		if err = writeSnapshotRecord(enc, SnapshotTableHeader, &summary.SnapshotHeader); err != nil {
+			return err
+		}
+
+		if summary.Rows[SnapshotTableBlocks], err = snapshotTable[model.Block](tx, enc, SnapshotTableBlocks); err != nil {
+			return err
+		}
+		if summary.Rows[SnapshotTableEvents], err = snapshotTable[model.Event](tx, enc, SnapshotTableEvents); err != nil {
+			return err
+		}
+		if summary.Rows[SnapshotTableVotes], err = snapshotTable[model.Vote](tx, enc, SnapshotTableVotes); err != nil {
+			return err
+		}
+		if summary.Rows[SnapshotTableSpStats], err = snapshotTable[model.SpStats](tx, enc, SnapshotTableSpStats); err != nil {
+			return err
+		}
+		summary.Rows[SnapshotTableVerificationResults], err = snapshotTable[model.VerificationResult](tx, enc,
+			SnapshotTableVerificationResults)
+		return err
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+func snapshotTable[T any](tx *gorm.DB, enc *json.Encoder, table string) (int64, error) {
+	var count int64
+	rows := make([]*T, 0, SnapshotBatchSize)
+	err := tx.Unscoped().Model(new(T)).Order("id asc").FindInBatches(&rows, SnapshotBatchSize, func(_ *gorm.DB, _ int) error {
+		for _, row := range rows {
+			if err := writeSnapshotRecord(enc, table, row); err != nil {
+				return err
+			}
+		}
+		count += int64(len(rows))
+		return nil
+	}).Error
+	return count, err
+}
+
+func writeSnapshotRecord(enc *json.Encoder, table string, row interface{}) error {
+	raw, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	return enc.Encode(&SnapshotRecord{Table: table, Row: raw})
+}
@@ -0,0 +1,80 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SpStatsDao struct {
+	DB *gorm.DB
+	queryTimeout
+}
+
+func NewSpStatsDao(db *gorm.DB) *SpStatsDao {
+	return &SpStatsDao{
+		DB: db,
+	}
+}
+
+// incrSpStats adds the counters of delta to the stats of its storage provider, creating the stats row when missing.
+func incrSpStats(db *gorm.DB, delta *model.SpStats) error {
+	delta.UpdatedTime = time.Now().Unix()
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "sp_operator_address"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"received":         gorm.Expr("received + ?", delta.Received),
+			"succeeded":        gorm.Expr("succeeded + ?", delta.Succeeded),
+			"failed":           gorm.Expr("failed + ?", delta.Failed),
+			"expired":          gorm.Expr("expired + ?", delta.Expired),
+			"slashed":          gorm.Expr("slashed + ?", delta.Slashed),
+			"total_latency_ms": gorm.Expr("total_latency_ms + ?", delta.TotalLatencyMs),
+			"latency_samples":  gorm.Expr("latency_samples + ?", delta.LatencySamples),
+			"updated_time":     delta.UpdatedTime,
+		}),
+	}).Create(delta).Error
+}
+
+// RecordSpVerifyResult counts a verified challenge of a storage provider along with its response latency.
+func (d *SpStatsDao) RecordSpVerifyResult(ctx context.Context, spOperatorAddress string, result model.VerifyResult, latency time.Duration) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	delta := &model.SpStats{
+		SpOperatorAddress: spOperatorAddress,
+		TotalLatencyMs:    uint64(latency.Milliseconds()),
+		LatencySamples:    1,
+	}
+	switch result {
+	case model.HashMismatched:
+		delta.Succeeded = 1
+	case model.HashMatched:
+		delta.Failed = 1
+	}
+	return incrSpStats(d.DB.WithContext(ctx), delta)
+}
+
+func (d *SpStatsDao) GetSpStats(ctx context.Context, spOperatorAddress string) (*model.SpStats, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	stats := model.SpStats{}
+	err := d.DB.WithContext(ctx).Where("sp_operator_address = ?", spOperatorAddress).Take(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// ListSpStats returns the stats of all storage providers, the ones with the most succeeded challenges first.
+func (d *SpStatsDao) ListSpStats(ctx context.Context) ([]*model.SpStats, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	stats := make([]*model.SpStats, 0)
+	err := d.DB.WithContext(ctx).Order("succeeded desc").Order("expired desc").Order("sp_operator_address asc").Find(&stats).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,92 @@
+package dao
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/stretchr/testify/suite"
+)
+
+type spStatsSuite struct {
+	suite.Suite
+	dao      *SpStatsDao
+	eventDao *EventDao
+	db       *Database
+}
+
+func TestSpStatsSuite(t *testing.T) {
+	suite.Run(t, new(spStatsSuite))
+}
+
+func (s *spStatsSuite) SetupSuite() {
+	dbName := "challenger"
+	db, err := RunDB(dbName)
+	s.Require().NoError(err)
+	s.db = db
+}
+
+func (s *spStatsSuite) TearDownSuite() {
+	err := s.db.StopDB()
+	s.Require().NoError(err)
+}
+
+func (s *spStatsSuite) SetupTest() {
+	model.InitBlockTable(s.db.DB)
+	model.InitEventTable(s.db.DB)
+	model.InitSpStatsTable(s.db.DB)
+
+	s.dao = NewSpStatsDao(s.db.DB)
+	s.eventDao = NewEventDao(s.db.DB)
+}
+
+func (s *spStatsSuite) TearDownTest() {
+	err := s.db.ClearDB()
+	s.Require().NoError(err)
+}
+
+func (s *spStatsSuite) TestSpStatsDao_RecordSpStats() {
+	block := &model.Block{Height: 100, BlockTime: 1000}
+	events := []*model.Event{
+		{ChallengeId: 1, ObjectId: "1", SpOperatorAddress: "sp1", Height: 100, ExpiredHeight: 200, Status: model.Unprocessed},
+		{ChallengeId: 2, ObjectId: "2", SpOperatorAddress: "sp1", Height: 100, ExpiredHeight: 200, Status: model.Unprocessed},
+		{ChallengeId: 3, ObjectId: "3", SpOperatorAddress: "sp2", Height: 100, ExpiredHeight: 200, Status: model.Unprocessed},
+	}
+	err := s.eventDao.SaveBlockAndEvents(context.Background(), block, events)
+	s.Require().NoError(err, "failed to save")
+
+	err = s.dao.RecordSpVerifyResult(context.Background(), "sp1", model.HashMismatched, 100*time.Millisecond)
+	s.Require().NoError(err, "failed to record")
+	err = s.dao.RecordSpVerifyResult(context.Background(), "sp1", model.HashMatched, 300*time.Millisecond)
+	s.Require().NoError(err, "failed to record")
+
+	expired, err := s.eventDao.GetEventByChallengeId(context.Background(), 3)
+	s.Require().NoError(err, "failed to query")
+	err = s.eventDao.ExpireEvent(context.Background(), expired)
+	s.Require().NoError(err, "failed to expire")
+
+	// an attested challenge is counted once
+	err = s.eventDao.UpdateEventStatusByChallengeId(context.Background(), 1, model.SelfAttested)
+	s.Require().NoError(err, "failed to update")
+	err = s.eventDao.UpdateEventStatusByChallengeId(context.Background(), 1, model.Attested)
+	s.Require().NoError(err, "failed to update")
+
+	stats, err := s.dao.GetSpStats(context.Background(), "sp1")
+	s.Require().NoError(err, "failed to query")
+	s.Require().True(stats.Received == 2)
+	s.Require().True(stats.Succeeded == 1)
+	s.Require().True(stats.Failed == 1)
+	s.Require().True(stats.Slashed == 1)
+	s.Require().True(stats.AvgResponseLatencyMs() == 200)
+
+	stats, err = s.dao.GetSpStats(context.Background(), "sp2")
+	s.Require().NoError(err, "failed to query")
+	s.Require().True(stats.Received == 1)
+	s.Require().True(stats.Expired == 1)
+
+	list, err := s.dao.ListSpStats(context.Background())
+	s.Require().NoError(err, "failed to query")
+	s.Require().True(len(list) == 2)
+	s.Require().True(list[0].SpOperatorAddress == "sp1")
+}
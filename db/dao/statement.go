@@ -0,0 +1,51 @@
+package dao
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+// PrepareStatements runs the hot queries as cached prepared statements, so that the database does not parse them again
+// on every call, e.g. the vote lookups of a burst of challenges.
+var PrepareStatements = true
+
+// prepared returns db running its statements as cached prepared statements if PrepareStatements is set. Every distinct
+// sql is prepared once and kept as long as the connection pool, so it is only used by the queries whose sql does not
+// vary with their arguments, unlike the IN queries and the batch inserts.
+func prepared(db *gorm.DB) *gorm.DB {
+	if !PrepareStatements {
+		return db
+	}
+	return db.Session(&gorm.Session{PrepareStmt: true})
+}
+
+// NewLogger returns the logger of the sql statements, the statements slower than slowThreshold and the errors are
+// logged as warnings, and every statement is logged in debug mode. A zero slowThreshold does not log the slow ones.
+func NewLogger(slowThreshold time.Duration, debug bool) logger.Interface {
+	level := logger.Warn
+	if debug {
+		level = logger.Info
+	}
+	return logger.New(statementWriter{debug: debug}, logger.Config{
+		SlowThreshold:             slowThreshold,
+		LogLevel:                  level,
+		IgnoreRecordNotFoundError: true,
+	})
+}
+
+// statementWriter writes the records of the sql logger to the dao logger.
+type statementWriter struct {
+	debug bool
+}
+
+func (w statementWriter) Printf(format string, args ...interface{}) {
+	if w.debug {
+		logging.DaoLogger.Infof(format, args...)
+		return
+	}
+	logging.DaoLogger.Warningf(format, args...)
+}
@@ -0,0 +1,90 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VerificationResultDao struct {
+	DB *gorm.DB
+	queryTimeout
+}
+
+func NewVerificationResultDao(db *gorm.DB) *VerificationResultDao {
+	return &VerificationResultDao{
+		DB: db,
+	}
+}
+
+// SaveVerificationResult saves the verification result of an event, replacing the result of a previous verification
+// of the same event, e.g. when the event is replayed.
+func (d *VerificationResultDao) SaveVerificationResult(ctx context.Context, result *model.VerificationResult) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return d.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "challenge_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"sp_operator_address", "segment_index", "bucket_name",
+			"expected_hash", "actual_hash", "latency_ms", "attempts", "outcome", "created_time"}),
+	}).Create(result).Error
+}
+
+func (d *VerificationResultDao) GetVerificationResult(ctx context.Context, challengeId uint64) (*model.VerificationResult, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	result := model.VerificationResult{}
+	err := d.DB.WithContext(ctx).Where("challenge_id = ?", challengeId).Take(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetVerificationResultsByChallengeIds returns the verification results of the events keyed by challenge id, events
+// which have not been verified are missing from the map.
+func (d *VerificationResultDao) GetVerificationResultsByChallengeIds(ctx context.Context, challengeIds []uint64) (map[uint64]*model.VerificationResult, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	results := make([]*model.VerificationResult, 0, len(challengeIds))
+	if len(challengeIds) != 0 {
+		err := d.DB.WithContext(ctx).Where("challenge_id IN ?", challengeIds).Find(&results).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	resultMap := make(map[uint64]*model.VerificationResult, len(results))
+	for _, r := range results {
+		resultMap[r.ChallengeId] = r
+	}
+	return resultMap, nil
+}
+
+// ListVerificationResultsBetween returns the verification results created in [from, to), unix timestamps.
+func (d *VerificationResultDao) ListVerificationResultsBetween(ctx context.Context, from, to int64) ([]*model.VerificationResult, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	results := make([]*model.VerificationResult, 0)
+	err := d.DB.WithContext(ctx).Where("created_time >= ? AND created_time < ?", from, to).Order("id asc").
+		Find(&results).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetEarliestVerificationResultTime returns the earliest creation time of the verification results created at or after
+// since, 0 if there is none.
+func (d *VerificationResultDao) GetEarliestVerificationResultTime(ctx context.Context, since int64) (int64, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	var createdTime int64
+	err := d.DB.WithContext(ctx).Model(&model.VerificationResult{}).Where("created_time >= ?", since).
+		Select("coalesce(min(created_time), 0)").
+		Scan(&createdTime).Error
+	if err != nil {
+		return 0, err
+	}
+	return createdTime, nil
+}
@@ -1,12 +1,30 @@
 package dao
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/bnb-chain/greenfield-challenger/db/model"
 	"gorm.io/gorm"
 )
 
+const VotesQueryBatchSize = 500 // event hashes queried at once
+
 type VoteDao struct {
-	DB *gorm.DB
+	DB                 *gorm.DB
+	stageObserver      StageObserver
+	transitionObserver TransitionObserver
+	queryTimeout
+}
+
+// SetStageObserver sets the observer of the stages completed by the status updates.
+func (d *VoteDao) SetStageObserver(observer StageObserver) {
+	d.stageObserver = observer
+}
+
+// SetTransitionObserver sets the observer of the status transitions of the events.
+func (d *VoteDao) SetTransitionObserver(observer TransitionObserver) {
+	d.transitionObserver = observer
 }
 
 func NewVoteDao(db *gorm.DB) *VoteDao {
@@ -15,30 +33,34 @@ func NewVoteDao(db *gorm.DB) *VoteDao {
 	}
 }
 
-func (d *VoteDao) SaveVote(vote *model.Vote) error {
-	err := d.DB.Create(vote).Error
+func (d *VoteDao) SaveVote(ctx context.Context, vote *model.Vote) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	err := d.DB.WithContext(ctx).Create(vote).Error
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (d *VoteDao) SaveVoteAndUpdateEventStatus(vote *model.Vote, challengeId uint64) error {
-	return d.DB.Transaction(func(tx *gorm.DB) error {
+func (d *VoteDao) SaveVoteAndUpdateEventStatus(ctx context.Context, vote *model.Vote, challengeId uint64) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Save(vote).Error; err != nil {
 			return err
 		}
 
-		if err := tx.Model(&model.Event{}).Where("challenge_id = ?", challengeId).Update("status", model.SelfVoted).Error; err != nil {
-			return err
-		}
-		return nil
+		return updateEventStatus(tx, challengeId, model.SelfVoted, map[string]interface{}{"status": model.SelfVoted},
+			d.stageObserver, d.transitionObserver)
 	})
 }
 
-func (d *VoteDao) GetVotesByEventHash(eventHash string) ([]*model.Vote, error) {
+func (d *VoteDao) GetVotesByEventHash(ctx context.Context, eventHash string) ([]*model.Vote, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
 	votes := make([]*model.Vote, 0)
-	err := d.DB.
+	err := d.DB.WithContext(ctx).
 		Where("event_hash = ?", eventHash).
 		Find(&votes).Error
 	if err != nil && err != gorm.ErrRecordNotFound {
@@ -47,16 +69,58 @@ func (d *VoteDao) GetVotesByEventHash(eventHash string) ([]*model.Vote, error) {
 	return votes, nil
 }
 
-func (d *VoteDao) IsVoteExists(eventHash string, pubKey string) (bool, error) {
+// GetVotesByEventHashes returns the votes for any of the event hashes, they are queried by batches of
+// VotesQueryBatchSize hashes.
+func (d *VoteDao) GetVotesByEventHashes(ctx context.Context, eventHashes []string) ([]*model.Vote, error) {
+	votes := make([]*model.Vote, 0)
+	for start := 0; start < len(eventHashes); start += VotesQueryBatchSize {
+		end := start + VotesQueryBatchSize
+		if end > len(eventHashes) {
+			end = len(eventHashes)
+		}
+		batch := make([]*model.Vote, 0)
+		queryCtx, cancel := d.withQueryTimeout(ctx)
+		err := d.DB.WithContext(queryCtx).Where("event_hash IN ?", eventHashes[start:end]).Find(&batch).Error
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		votes = append(votes, batch...)
+	}
+	return votes, nil
+}
+
+// CountVotesByEventHash counts the distinct validators which voted for the event hash, it is served by the
+// idx_eventhash_pubkey index without reading the vote rows.
+func (d *VoteDao) CountVotesByEventHash(ctx context.Context, eventHash string) (int64, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	var count int64
+	err := prepared(d.DB).WithContext(ctx).Model(&model.Vote{}).
+		Where("event_hash = ?", eventHash).
+		Distinct("pub_key").
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// IsVoteExists reports whether the validator of pubKey voted for the event hash.
+func (d *VoteDao) IsVoteExists(ctx context.Context, eventHash string, pubKey string) (bool, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
 	exists := false
-	if err := d.DB.Raw(
-		"SELECT EXISTS(SELECT id FROM votes WHERE event_hash = ? and pub_key = ?)",
+	if err := prepared(d.DB).WithContext(ctx).Raw(
+		fmt.Sprintf("SELECT EXISTS(SELECT id FROM %s WHERE event_hash = ? and pub_key = ?)", (&model.Vote{}).TableName()),
 		eventHash, pubKey).Scan(&exists).Error; err != nil {
 		return false, err
 	}
 	return exists, nil
 }
 
-func (d *VoteDao) DeleteVotesBefore(unixTimestamp int64) error {
-	return d.DB.Model(&model.Vote{}).Where("created_time = ?", unixTimestamp).Delete(&model.Vote{}).Error
+func (d *VoteDao) DeleteVotesBefore(ctx context.Context, unixTimestamp int64) error {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return d.DB.WithContext(ctx).Model(&model.Vote{}).Where("created_time = ?", unixTimestamp).Delete(&model.Vote{}).Error
 }
@@ -2,6 +2,7 @@ package dao
 
 import (
 	"bytes"
+	"context"
 	"testing"
 
 	"github.com/bnb-chain/greenfield-challenger/db/model"
@@ -52,28 +53,44 @@ func (s *voteSuite) createVote() *model.Vote {
 
 func (s *voteSuite) TestVoteDao_SaveVote() {
 	vote := s.createVote()
-	err := s.dao.SaveVote(vote)
+	err := s.dao.SaveVote(context.Background(), vote)
 	s.Require().NoError(err, "failed to save")
 }
 
 func (s *voteSuite) TestVoteDao_GetVotesByEventHash() {
 	vote := s.createVote()
-	_ = s.dao.SaveVote(vote)
+	_ = s.dao.SaveVote(context.Background(), vote)
 
-	result, err := s.dao.GetVotesByEventHash(vote.EventHash)
+	result, err := s.dao.GetVotesByEventHash(context.Background(), vote.EventHash)
 	s.Require().NoError(err, "failed to query")
 	s.Require().True(bytes.Equal([]byte(result[0].EventHash), []byte(vote.EventHash)))
 }
 
+func (s *voteSuite) TestVoteDao_CountVotesByEventHash() {
+	vote := s.createVote()
+	_ = s.dao.SaveVote(context.Background(), vote)
+	other := s.createVote()
+	other.PubKey = "other"
+	_ = s.dao.SaveVote(context.Background(), other)
+
+	count, err := s.dao.CountVotesByEventHash(context.Background(), vote.EventHash)
+	s.Require().NoError(err, "failed to query")
+	s.Require().True(count == 2)
+
+	count, err = s.dao.CountVotesByEventHash(context.Background(), vote.EventHash+"fake")
+	s.Require().NoError(err, "failed to query")
+	s.Require().True(count == 0)
+}
+
 func (s *voteSuite) TestVoteDao_IsVoteExists() {
 	vote := s.createVote()
-	_ = s.dao.SaveVote(vote)
+	_ = s.dao.SaveVote(context.Background(), vote)
 
-	result, err := s.dao.IsVoteExists(vote.EventHash, vote.PubKey)
+	result, err := s.dao.IsVoteExists(context.Background(), vote.EventHash, vote.PubKey)
 	s.Require().NoError(err, "failed to query")
 	s.Require().True(result)
 
-	result, err = s.dao.IsVoteExists(vote.EventHash, string(vote.PubKey)+"fake")
+	result, err = s.dao.IsVoteExists(context.Background(), vote.EventHash, string(vote.PubKey)+"fake")
 	s.Require().NoError(err, "failed to query")
 	s.Require().True(!result)
 }
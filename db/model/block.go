@@ -10,14 +10,15 @@ type Block struct {
 }
 
 func (*Block) TableName() string {
-	return "blocks"
+	return TablePrefix + "blocks"
 }
 
 func InitBlockTable(db *gorm.DB) {
-	if !db.Migrator().HasTable(&Block{}) {
-		err := db.Migrator().CreateTable(&Block{})
-		if err != nil {
-			panic(err)
-		}
+	runMigrations(db, blockMigrations())
+}
+
+func blockMigrations() []*Migration {
+	return []*Migration{
+		createTableMigration(&Block{}),
 	}
 }
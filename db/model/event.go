@@ -1,35 +1,88 @@
 package model
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/common"
+	"github.com/bnb-chain/greenfield-challenger/logging"
 	"gorm.io/gorm"
 )
 
 type Event struct {
 	Id                int64
-	ChallengeId       uint64       `gorm:"NOT NULL;uniqueIndex:idx_challenge_id"`
-	ObjectId          string       `gorm:"NOT NULL;index:idx_object_id_sp_addr"`
-	SegmentIndex      uint32       `gorm:"NOT NULL"`
-	SpOperatorAddress string       `gorm:"NOT NULL;index:idx_object_id_sp_addr"`
-	RedundancyIndex   int32        `gorm:"NOT NULL"`
-	ChallengerAddress string       `gorm:"NOT NULL"`
-	Height            uint64       `gorm:"NOT NULL;"`
-	Status            EventStatus  `gorm:"NOT NULL;index:idx_status"`
-	VerifyResult      VerifyResult `gorm:"NOT NULL;index:idx_verify_result"`
-	CreatedTime       int64        `gorm:"NOT NULL"`
-	ExpiredHeight     uint64       `gorm:"NOT NULL;index:idx_expired_height"`
+	ChallengeId       uint64         `gorm:"NOT NULL;uniqueIndex:idx_challenge_id"`
+	ObjectId          string         `gorm:"NOT NULL;index:idx_object_id_sp_addr"`
+	SegmentIndex      uint32         `gorm:"NOT NULL"`
+	SpOperatorAddress string         `gorm:"NOT NULL;index:idx_object_id_sp_addr"`
+	RedundancyIndex   int32          `gorm:"NOT NULL"`
+	ChallengerAddress string         `gorm:"NOT NULL"`
+	Height            uint64         `gorm:"NOT NULL;"`
+	Status            EventStatus    `gorm:"NOT NULL;index:idx_status"`
+	VerifyResult      VerifyResult   `gorm:"NOT NULL;index:idx_verify_result"`
+	CreatedTime       int64          `gorm:"NOT NULL"`
+	StageTime         int64          `gorm:"NOT NULL;default:0"` // unix milliseconds when the event completed its last pipeline stage
+	ExpiredHeight     uint64         `gorm:"NOT NULL;index:idx_expired_height"`
+	EventHash         string         `gorm:"NOT NULL;default:''"`  // hex hash voted for the verify result, empty until verified
+	DeletedAt         gorm.DeletedAt `gorm:"index:idx_deleted_at"` // soft delete marker, deleted events can be replayed
 }
 
 func (*Event) TableName() string {
-	return "events"
+	return TablePrefix + "events"
 }
 
 func InitEventTable(db *gorm.DB) {
-	if !db.Migrator().HasTable(&Event{}) {
-		err := db.Migrator().CreateTable(&Event{})
-		if err != nil {
-			panic(err)
-		}
+	runMigrations(db, eventMigrations())
+}
+
+func eventMigrations() []*Migration {
+	return []*Migration{
+		createTableMigration(&Event{}),
+		// tables created before soft delete was introduced
+		addColumnMigration(&Event{}, "DeletedAt", "deleted_at"),
+		createIndexMigration(&Event{}, "idx_deleted_at"),
+		// tables created before the stage durations were measured
+		addColumnMigration(&Event{}, "StageTime", "stage_time"),
+		// tables created before the event hash was saved with the verify result
+		addColumnMigration(&Event{}, "EventHash", "event_hash"),
+	}
+}
+
+// StageStartedAt returns when the event entered its current pipeline stage, the zero time if it is unknown.
+func (e *Event) StageStartedAt() time.Time {
+	if e.StageTime != 0 {
+		return time.UnixMilli(e.StageTime)
+	}
+	if e.CreatedTime != 0 {
+		return time.Unix(e.CreatedTime, 0)
+	}
+	return time.Time{}
+}
+
+// Pipeline stages, each is measured from the time the event completed the previous one.
+const (
+	StageVerify  = "verify"  // ingested -> verified
+	StageVote    = "vote"    // verified -> self voted
+	StageCollect = "collect" // self voted -> enough votes collected
+	StageAttest  = "attest"  // enough votes collected -> attested
+)
+
+var Stages = []string{StageVerify, StageVote, StageCollect, StageAttest}
+
+// CompletedStage returns the pipeline stage an event completes by moving to status. Submitted does not complete a
+// stage, so that the attest stage is measured up to the attestation.
+func CompletedStage(status EventStatus) (string, bool) {
+	switch status {
+	case Verified:
+		return StageVerify, true
+	case SelfVoted:
+		return StageVote, true
+	case EnoughVotesCollected:
+		return StageCollect, true
+	case SelfAttested, Attested:
+		return StageAttest, true
 	}
+	return "", false
 }
 
 type EventStatus int
@@ -45,8 +98,37 @@ const (
 	Duplicated
 	DuplicatedSlash
 	VerificationFailed // Event cannot be verified due to at least 1 endpoint not responding
+	Expired            // Event expired before it reached a final status
 )
 
+var eventStatusNames = []string{
+	"unprocessed", "verified", "self_voted", "enough_votes_collected", "submitted", "self_attested", "attested",
+	"duplicated", "duplicated_slash", "verification_failed", "expired",
+}
+
+func (s EventStatus) String() string {
+	if s < 0 || int(s) >= len(eventStatusNames) {
+		return fmt.Sprintf("unknown_%d", int(s))
+	}
+	return eventStatusNames[s]
+}
+
+// ParseEventStatus returns the status called name.
+func ParseEventStatus(name string) (EventStatus, error) {
+	for i, statusName := range eventStatusNames {
+		if statusName == name {
+			return EventStatus(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown event status %q", name)
+}
+
+// ReplayableEventStatuses are the pipeline stages an event can be reset to.
+var ReplayableEventStatuses = []EventStatus{Unprocessed, Verified, SelfVoted, EnoughVotesCollected}
+
+// UnfinishedEventStatuses are the statuses of events still being processed by the pipeline.
+var UnfinishedEventStatuses = []EventStatus{Unprocessed, Verified, SelfVoted, EnoughVotesCollected}
+
 type VerifyResult int
 
 const (
@@ -54,3 +136,29 @@ const (
 	HashMatched                        // The challenge failed, hashes are matched
 	HashMismatched                     // The challenge succeed, hashed are not matched
 )
+
+var verifyResultNames = []string{"unknown", "hash_matched", "hash_mismatched"}
+
+// Decided reports whether the verification produced a result, which can be voted.
+func (r VerifyResult) Decided() bool {
+	return r == HashMatched || r == HashMismatched
+}
+
+func (r VerifyResult) String() string {
+	if r < 0 || int(r) >= len(verifyResultNames) {
+		return fmt.Sprintf("unknown_%d", int(r))
+	}
+	return verifyResultNames[r]
+}
+
+// LogFields returns the fields correlating the log records of the event in stage.
+func (e *Event) LogFields(stage string) logging.Fields {
+	return logging.Fields{
+		ChallengeId: e.ChallengeId,
+		ObjectId:    e.ObjectId,
+		Sp:          e.SpOperatorAddress,
+		Stage:       stage,
+		Height:      e.Height,
+		RequestId:   common.RequestId(e.ChallengeId),
+	}
+}
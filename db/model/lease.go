@@ -0,0 +1,27 @@
+package model
+
+import "gorm.io/gorm"
+
+// Lease is a named lock held by one of the challengers sharing the database until ExpireTime, unless the holder renews
+// it. The leader of the challengers is the holder of the leader lease.
+type Lease struct {
+	Id          int64
+	Name        string `gorm:"NOT NULL;uniqueIndex:idx_lease_name;size:64"`
+	Holder      string `gorm:"NOT NULL;size:128"` // instance id of the holder, empty before the lease is first taken
+	ExpireTime  int64  `gorm:"NOT NULL"`          // unix milliseconds after which the lease can be taken by another holder
+	UpdatedTime int64  `gorm:"NOT NULL"`
+}
+
+func (*Lease) TableName() string {
+	return TablePrefix + "leases"
+}
+
+func InitLeaseTable(db *gorm.DB) {
+	runMigrations(db, leaseMigrations())
+}
+
+func leaseMigrations() []*Migration {
+	return []*Migration{
+		createTableMigration(&Lease{}),
+	}
+}
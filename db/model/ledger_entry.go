@@ -0,0 +1,38 @@
+package model
+
+import "gorm.io/gorm"
+
+// the kinds of the ledger entries
+const (
+	LedgerKindAttest = "attest" // attests a challenge, paying its fee or earning the challenger reward
+	LedgerKindSubmit = "submit" // submits a challenge
+	LedgerKindFailed = "failed" // failed on chain, only its fee was paid
+	LedgerKindOther  = "other"
+)
+
+// LedgerEntry is a transaction paid or rewarded by the challenger account, the amounts are integers of the fee denom
+// kept as strings, as they do not fit an int64.
+type LedgerEntry struct {
+	Id          int64
+	TxHash      string `gorm:"NOT NULL;uniqueIndex:idx_ledger_entry_tx_hash;size:64"`
+	Height      uint64 `gorm:"NOT NULL"`
+	BlockTime   int64  `gorm:"NOT NULL;index:idx_ledger_entry_block_time"`
+	ChallengeId uint64 `gorm:"NOT NULL"` // 0 if the transaction neither submits nor attests a challenge
+	Kind        string `gorm:"NOT NULL"`
+	Fee         string `gorm:"NOT NULL"` // fee paid by the challenger
+	Reward      string `gorm:"NOT NULL"` // amount received by the challenger
+}
+
+func (*LedgerEntry) TableName() string {
+	return TablePrefix + "ledger_entries"
+}
+
+func InitLedgerEntryTable(db *gorm.DB) {
+	runMigrations(db, ledgerEntryMigrations())
+}
+
+func ledgerEntryMigrations() []*Migration {
+	return []*Migration{
+		createTableMigration(&LedgerEntry{}),
+	}
+}
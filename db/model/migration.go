@@ -0,0 +1,126 @@
+package model
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Migration is a schema change applied to the challenger database on start up. Migrations are applied in order and
+// only when pending, so that they can be planned and reviewed before upgrading.
+type Migration struct {
+	Table       string
+	Description string
+	// Destructive migrations drop or rewrite existing data, they deserve a backup before upgrading
+	Destructive bool
+	pending     func(db *gorm.DB) bool
+	apply       func(db *gorm.DB) error
+}
+
+// Migrations returns all the schema migrations of the challenger tables in the order they are applied.
+func Migrations() []*Migration {
+	migrations := make([]*Migration, 0)
+	migrations = append(migrations, blockMigrations()...)
+	migrations = append(migrations, eventMigrations()...)
+	migrations = append(migrations, voteMigrations()...)
+	migrations = append(migrations, spStatsMigrations()...)
+	migrations = append(migrations, verificationResultMigrations()...)
+	migrations = append(migrations, samplingFailureMigrations()...)
+	migrations = append(migrations, leaseMigrations()...)
+	migrations = append(migrations, ledgerEntryMigrations()...)
+	migrations = append(migrations, rollupMigrations()...)
+	migrations = append(migrations, verificationJobMigrations()...)
+	return migrations
+}
+
+// PlanMigrations returns the migrations which would be applied to the database, without applying them. Migrations
+// depending on a table which does not exist yet are covered by the creation of the table.
+func PlanMigrations(db *gorm.DB) []*Migration {
+	pending := make([]*Migration, 0)
+	for _, m := range Migrations() {
+		if m.pending(db) {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// RunMigrations applies all pending migrations.
+func RunMigrations(db *gorm.DB) {
+	runMigrations(db, Migrations())
+}
+
+func runMigrations(db *gorm.DB, migrations []*Migration) {
+	for _, m := range migrations {
+		if !m.pending(db) {
+			continue
+		}
+		if err := m.apply(db); err != nil {
+			panic(fmt.Sprintf("migration '%s' failed, err=%+v", m.Description, err.Error()))
+		}
+	}
+}
+
+func createTableMigration(model schema.Tabler) *Migration {
+	return &Migration{
+		Table:       model.TableName(),
+		Description: fmt.Sprintf("create table %s", model.TableName()),
+		pending: func(db *gorm.DB) bool {
+			return !db.Migrator().HasTable(model)
+		},
+		apply: func(db *gorm.DB) error {
+			return db.Migrator().CreateTable(model)
+		},
+	}
+}
+
+// addColumnMigration adds a column missing in tables created by older versions.
+func addColumnMigration(model schema.Tabler, field, column string) *Migration {
+	return &Migration{
+		Table:       model.TableName(),
+		Description: fmt.Sprintf("add column %s to table %s", column, model.TableName()),
+		pending: func(db *gorm.DB) bool {
+			return db.Migrator().HasTable(model) && !db.Migrator().HasColumn(model, field)
+		},
+		apply: func(db *gorm.DB) error {
+			return db.Migrator().AddColumn(model, field)
+		},
+	}
+}
+
+// createIndexMigration adds an index missing in tables created by older versions.
+func createIndexMigration(model schema.Tabler, index string) *Migration {
+	return &Migration{
+		Table:       model.TableName(),
+		Description: fmt.Sprintf("create index %s on table %s", index, model.TableName()),
+		pending: func(db *gorm.DB) bool {
+			return db.Migrator().HasTable(model) && !db.Migrator().HasIndex(model, index)
+		},
+		apply: func(db *gorm.DB) error {
+			return db.Migrator().CreateIndex(model, index)
+		},
+	}
+}
+
+// renameIndexMigration renames an index of tables created by older versions. The index is created under its new name
+// before the old one is dropped, since not every dialect renames indexes in place, it is destructive as the index is
+// rebuilt on the whole table.
+func renameIndexMigration(model schema.Tabler, oldIndex, newIndex string) *Migration {
+	return &Migration{
+		Table:       model.TableName(),
+		Description: fmt.Sprintf("rename index %s to %s on table %s", oldIndex, newIndex, model.TableName()),
+		Destructive: true,
+		pending: func(db *gorm.DB) bool {
+			return db.Migrator().HasTable(model) && db.Migrator().HasIndex(model, oldIndex)
+		},
+		apply: func(db *gorm.DB) error {
+			if !db.Migrator().HasIndex(model, newIndex) {
+				if err := db.Migrator().CreateIndex(model, newIndex); err != nil {
+					return err
+				}
+			}
+			return db.Migrator().DropIndex(model, oldIndex)
+		},
+	}
+}
@@ -0,0 +1,119 @@
+package model
+
+import "gorm.io/gorm"
+
+// RollupCounters are the verification outcomes and the sp response latencies rolled up in a window.
+type RollupCounters struct {
+	Verified         uint64 `gorm:"NOT NULL"` // verification results in the window
+	HashMatched      uint64 `gorm:"NOT NULL"`
+	HashMismatched   uint64 `gorm:"NOT NULL"`
+	SpUnavailable    uint64 `gorm:"NOT NULL"`
+	PluginMismatched uint64 `gorm:"NOT NULL"`
+	FederatedMatched uint64 `gorm:"NOT NULL"`
+	Unverified       uint64 `gorm:"NOT NULL"` // the sp endpoint or the object checksums could not be queried
+	TotalLatencyMs   uint64 `gorm:"NOT NULL"` // sum of the sp response latencies
+	LatencySamples   uint64 `gorm:"NOT NULL"` // number of sp responses summed in TotalLatencyMs
+	MaxLatencyMs     uint64 `gorm:"NOT NULL"`
+}
+
+// Count adds a verification result to the counters.
+func (c *RollupCounters) Count(result *VerificationResult) {
+	c.Verified++
+	switch result.Outcome {
+	case OutcomeHashMatched:
+		c.HashMatched++
+	case OutcomeHashMismatched:
+		c.HashMismatched++
+	case OutcomeSpUnavailable:
+		c.SpUnavailable++
+	case OutcomePluginMismatched:
+		c.PluginMismatched++
+	case OutcomeFederatedMatched:
+		c.FederatedMatched++
+	default:
+		c.Unverified++
+	}
+	if result.Attempts == 0 {
+		// the sp was not requested
+		return
+	}
+	latency := uint64(result.LatencyMs)
+	c.TotalLatencyMs += latency
+	c.LatencySamples++
+	if latency > c.MaxLatencyMs {
+		c.MaxLatencyMs = latency
+	}
+}
+
+// Add adds the counters of another window.
+func (c *RollupCounters) Add(other *RollupCounters) {
+	c.Verified += other.Verified
+	c.HashMatched += other.HashMatched
+	c.HashMismatched += other.HashMismatched
+	c.SpUnavailable += other.SpUnavailable
+	c.PluginMismatched += other.PluginMismatched
+	c.FederatedMatched += other.FederatedMatched
+	c.Unverified += other.Unverified
+	c.TotalLatencyMs += other.TotalLatencyMs
+	c.LatencySamples += other.LatencySamples
+	if other.MaxLatencyMs > c.MaxLatencyMs {
+		c.MaxLatencyMs = other.MaxLatencyMs
+	}
+}
+
+// Failures returns the challenges the sp failed, it returned corrupted data or did not return the piece.
+func (c *RollupCounters) Failures() uint64 {
+	return c.HashMismatched + c.SpUnavailable + c.PluginMismatched
+}
+
+// FailureRate returns the share of the challenges judged against the sp which it failed.
+func (c *RollupCounters) FailureRate() float64 {
+	judged := c.Failures() + c.HashMatched + c.FederatedMatched
+	if judged == 0 {
+		return 0
+	}
+	return float64(c.Failures()) / float64(judged)
+}
+
+// AvgResponseLatencyMs returns the average sp response latency in milliseconds.
+func (c *RollupCounters) AvgResponseLatencyMs() uint64 {
+	if c.LatencySamples == 0 {
+		return 0
+	}
+	return c.TotalLatencyMs / c.LatencySamples
+}
+
+// SpRollup rolls up the verification results of a storage provider in a window.
+type SpRollup struct {
+	Id                int64
+	WindowStart       int64  `gorm:"NOT NULL;uniqueIndex:idx_sp_rollup_window_sp"` // unix timestamp
+	SpOperatorAddress string `gorm:"NOT NULL;uniqueIndex:idx_sp_rollup_window_sp;index:idx_sp_rollup_sp"`
+	RollupCounters    `gorm:"embedded"`
+}
+
+func (*SpRollup) TableName() string {
+	return TablePrefix + "sp_rollups"
+}
+
+// BucketRollup rolls up the verification results of the objects of a bucket in a window.
+type BucketRollup struct {
+	Id             int64
+	WindowStart    int64  `gorm:"NOT NULL;uniqueIndex:idx_bucket_rollup_window_bucket"` // unix timestamp
+	BucketName     string `gorm:"NOT NULL;size:63;uniqueIndex:idx_bucket_rollup_window_bucket;index:idx_bucket_rollup_bucket"`
+	RollupCounters `gorm:"embedded"`
+}
+
+func (*BucketRollup) TableName() string {
+	return TablePrefix + "bucket_rollups"
+}
+
+func InitRollupTables(db *gorm.DB) {
+	runMigrations(db, rollupMigrations())
+}
+
+func rollupMigrations() []*Migration {
+	return []*Migration{
+		createTableMigration(&SpRollup{}),
+		createTableMigration(&BucketRollup{}),
+	}
+}
@@ -0,0 +1,33 @@
+package model
+
+import "gorm.io/gorm"
+
+// SamplingFailure is a piece a storage provider failed to serve when it was sampled, a candidate for a proactive
+// challenge. A storage provider has at most one failure recorded by object, the latest one.
+type SamplingFailure struct {
+	Id                int64
+	ObjectId          string `gorm:"NOT NULL;uniqueIndex:idx_sampling_failure_object_sp"`
+	SpOperatorAddress string `gorm:"NOT NULL;uniqueIndex:idx_sampling_failure_object_sp"`
+	BucketName        string `gorm:"NOT NULL"`
+	ObjectName        string `gorm:"NOT NULL"`
+	RedundancyIndex   int32  `gorm:"NOT NULL"`
+	SegmentIndex      uint32 `gorm:"NOT NULL"`
+	Result            string `gorm:"NOT NULL"` // unavailable or mismatched
+	Failures          uint64 `gorm:"NOT NULL"` // failed samples of the piece
+	FailedTime        int64  `gorm:"NOT NULL"`
+	Challenged        bool   `gorm:"NOT NULL;index:idx_sampling_failure_challenged"` // set once a challenge is submitted
+}
+
+func (*SamplingFailure) TableName() string {
+	return TablePrefix + "sampling_failures"
+}
+
+func InitSamplingFailureTable(db *gorm.DB) {
+	runMigrations(db, samplingFailureMigrations())
+}
+
+func samplingFailureMigrations() []*Migration {
+	return []*Migration{
+		createTableMigration(&SamplingFailure{}),
+	}
+}
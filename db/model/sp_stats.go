@@ -0,0 +1,42 @@
+package model
+
+import "gorm.io/gorm"
+
+// SpStats aggregates the challenge outcomes of a storage provider, so that providers can be ranked without scanning
+// the events table.
+type SpStats struct {
+	Id                int64
+	SpOperatorAddress string `gorm:"NOT NULL;uniqueIndex:idx_sp_operator_address"`
+	Received          uint64 `gorm:"NOT NULL"`           // challenges received by the sp
+	Succeeded         uint64 `gorm:"NOT NULL"`           // challenges succeeded, the sp failed to prove the data integrity
+	Failed            uint64 `gorm:"NOT NULL"`           // challenges failed, the sp proved the data integrity
+	Expired           uint64 `gorm:"NOT NULL"`           // challenges expired before they could be verified
+	Slashed           uint64 `gorm:"NOT NULL;default:0"` // challenges attested, the sp was slashed
+	TotalLatencyMs    uint64 `gorm:"NOT NULL"`           // sum of the sp response latencies
+	LatencySamples    uint64 `gorm:"NOT NULL"`           // number of sp responses summed in TotalLatencyMs
+	UpdatedTime       int64  `gorm:"NOT NULL"`
+}
+
+func (*SpStats) TableName() string {
+	return TablePrefix + "sp_stats"
+}
+
+// AvgResponseLatencyMs returns the average sp response latency in milliseconds.
+func (s *SpStats) AvgResponseLatencyMs() uint64 {
+	if s.LatencySamples == 0 {
+		return 0
+	}
+	return s.TotalLatencyMs / s.LatencySamples
+}
+
+func InitSpStatsTable(db *gorm.DB) {
+	runMigrations(db, spStatsMigrations())
+}
+
+func spStatsMigrations() []*Migration {
+	return []*Migration{
+		createTableMigration(&SpStats{}),
+		// tables created before the slashes were counted
+		addColumnMigration(&SpStats{}, "Slashed", "slashed"),
+	}
+}
@@ -0,0 +1,5 @@
+package model
+
+// TablePrefix is prepended to the names of all challenger tables, so that several challenger instances can share one
+// database. It must be set before the tables are initialized.
+var TablePrefix string
@@ -0,0 +1,53 @@
+package model
+
+import "gorm.io/gorm"
+
+type JobStatus int
+
+const (
+	JobPending JobStatus = iota // waiting for AvailableTime to be leased
+	JobLeased                   // leased by Holder until LeaseExpireTime
+	JobFailed                   // gave up after the max attempts, kept for inspection
+)
+
+func (s JobStatus) String() string {
+	switch s {
+	case JobPending:
+		return "pending"
+	case JobLeased:
+		return "leased"
+	case JobFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// VerificationJob is the verification of the event of a challenge, queued for the challengers sharing the database. A
+// job is leased by one challenger at a time and deleted once it is done, a lease which is not done before it expires
+// is taken over by another challenger, e.g. after a crash.
+type VerificationJob struct {
+	Id              int64
+	ChallengeId     uint64    `gorm:"NOT NULL;uniqueIndex:idx_verification_job_challenge_id"`
+	Status          JobStatus `gorm:"NOT NULL;index:idx_verification_job_status"`
+	Attempts        uint32    `gorm:"NOT NULL"`           // leases taken so far
+	AvailableTime   int64     `gorm:"NOT NULL"`           // unix milliseconds from which a pending job can be leased
+	Holder          string    `gorm:"NOT NULL;size:128"`  // instance id of the last holder
+	LeaseExpireTime int64     `gorm:"NOT NULL"`           // unix milliseconds after which the lease can be taken over
+	LastError       string    `gorm:"NOT NULL;size:1024"` // error of the last failed attempt
+	CreatedTime     int64     `gorm:"NOT NULL;index:idx_verification_job_created_time"`
+	UpdatedTime     int64     `gorm:"NOT NULL"`
+}
+
+func (*VerificationJob) TableName() string {
+	return TablePrefix + "verification_jobs"
+}
+
+func InitVerificationJobTable(db *gorm.DB) {
+	runMigrations(db, verificationJobMigrations())
+}
+
+func verificationJobMigrations() []*Migration {
+	return []*Migration{
+		createTableMigration(&VerificationJob{}),
+	}
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// VerificationResult records how the verifier reached the verify result of an event, so that the outcome of a
+// challenge can be audited without overloading the event status.
+type VerificationResult struct {
+	Id                int64
+	ChallengeId       uint64              `gorm:"NOT NULL;uniqueIndex:idx_verification_result_challenge_id"`
+	SpOperatorAddress string              `gorm:"NOT NULL;index:idx_verification_result_sp_addr"`
+	SegmentIndex      uint32              `gorm:"NOT NULL"`                    // index of the challenged piece
+	BucketName        string              `gorm:"NOT NULL;size:63;default:''"` // bucket of the object, empty if unknown
+	ExpectedHash      string              `gorm:"NOT NULL;size:64"`            // hex root hash stored on chain
+	ActualHash        string              `gorm:"NOT NULL;size:64"`            // hex root hash computed from the sp response
+	LatencyMs         int64               `gorm:"NOT NULL"`                    // time spent waiting for the sp, including retries
+	Attempts          uint32              `gorm:"NOT NULL"`                    // requests sent to the sp
+	Outcome           VerificationOutcome `gorm:"NOT NULL;index:idx_verification_result_outcome"`
+	CreatedTime       int64               `gorm:"NOT NULL"`
+}
+
+func (*VerificationResult) TableName() string {
+	return TablePrefix + "verification_results"
+}
+
+func InitVerificationResultTable(db *gorm.DB) {
+	runMigrations(db, verificationResultMigrations())
+}
+
+func verificationResultMigrations() []*Migration {
+	return []*Migration{
+		createTableMigration(&VerificationResult{}),
+		// tables created before the results were rolled up by bucket
+		addColumnMigration(&VerificationResult{}, "BucketName", "bucket_name"),
+	}
+}
+
+type VerificationOutcome int
+
+const (
+	OutcomeHashMatched          VerificationOutcome = iota // The sp returned the expected data, the challenge failed
+	OutcomeHashMismatched                                  // The sp returned corrupted data, the challenge succeeded
+	OutcomeSpUnavailable                                   // The sp did not return the challenged piece, the challenge succeeded
+	OutcomeEndpointUnavailable                             // The sp endpoint could not be queried from chain
+	OutcomeChecksumsUnavailable                            // The object checksums could not be queried from chain
+	OutcomePluginMismatched                                // The sp returned the expected data, but a verifier plugin found it mismatched
+	OutcomeFederatedMatched                                // The sp was not requested, enough trusted challengers found the piece matched
+)
+
+var verificationOutcomeNames = []string{
+	"hash_matched", "hash_mismatched", "sp_unavailable", "endpoint_unavailable", "checksums_unavailable",
+	"plugin_mismatched", "federated_matched",
+}
+
+func (o VerificationOutcome) String() string {
+	if o < 0 || int(o) >= len(verificationOutcomeNames) {
+		return fmt.Sprintf("unknown_%d", int(o))
+	}
+	return verificationOutcomeNames[o]
+}
@@ -6,23 +6,28 @@ import (
 
 type Vote struct {
 	Id          int64
-	ChallengeId uint64 `gorm:"NOT NULL;index:idx_challenge_id"`
-	PubKey      string `gorm:"NOT NULL;uniqueIndex:idx_pubkey_eventhash;size:96"`
+	ChallengeId uint64 `gorm:"NOT NULL;index:idx_vote_challenge_id"` // index names must be unique across tables in sqlite
+	PubKey      string `gorm:"NOT NULL;uniqueIndex:idx_pubkey_eventhash;index:idx_eventhash_pubkey,priority:2;size:96"`
 	Signature   string `gorm:"NOT NULL;size:192"`
 	EventType   uint32 `gorm:"NOT NULL"`
-	EventHash   string `gorm:"NOT NULL;uniqueIndex:idx_pubkey_eventhash;size:64"`
+	EventHash   string `gorm:"NOT NULL;uniqueIndex:idx_pubkey_eventhash;index:idx_eventhash_pubkey,priority:1;size:64"`
 	CreatedTime int64  `gorm:"NOT NULL"`
 }
 
 func (*Vote) TableName() string {
-	return "votes"
+	return TablePrefix + "votes"
 }
 
 func InitVoteTable(db *gorm.DB) {
-	if !db.Migrator().HasTable(&Vote{}) {
-		err := db.Migrator().CreateTable(&Vote{})
-		if err != nil {
-			panic(err)
-		}
+	runMigrations(db, voteMigrations())
+}
+
+func voteMigrations() []*Migration {
+	return []*Migration{
+		createTableMigration(&Vote{}),
+		// covering index for counting votes by event hash, missing in tables created by older versions
+		createIndexMigration(&Vote{}, "idx_eventhash_pubkey"),
+		// the index shared its name with the one of the events, which sqlite does not allow
+		renameIndexMigration(&Vote{}, "idx_challenge_id", "idx_vote_challenge_id"),
 	}
 }
@@ -0,0 +1,6 @@
+package debug
+
+const (
+	PprofPath  = "/debug/pprof/"
+	ExpvarPath = "/debug/vars"
+)
@@ -0,0 +1,54 @@
+package debug
+
+import (
+	"crypto/subtle"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/health"
+)
+
+var publishOnce sync.Once
+
+// Mux is where the debug endpoints are served, e.g. the metrics service.
+type Mux interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+// Register serves pprof and expvar on mux if enabled in cfg, guarded by the auth token if set.
+func Register(mux Mux, cfg *config.DebugConfig) {
+	if !cfg.Enabled {
+		return
+	}
+	publishOnce.Do(func() {
+		expvar.Publish("goroutines", expvar.Func(func() interface{} { return runtime.NumGoroutine() }))
+		// the last beat of each loop, to spot a loop which stopped before the liveness probe fails
+		expvar.Publish("loop_beats", expvar.Func(func() interface{} { return health.Beats() }))
+	})
+	mux.Handle(ExpvarPath, authorize(cfg.AuthToken, expvar.Handler()))
+	// pprof.Index serves the named profiles, e.g. heap and goroutine, below PprofPath
+	mux.Handle(PprofPath, authorize(cfg.AuthToken, http.HandlerFunc(pprof.Index)))
+	mux.Handle(PprofPath+"cmdline", authorize(cfg.AuthToken, http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle(PprofPath+"profile", authorize(cfg.AuthToken, http.HandlerFunc(pprof.Profile)))
+	mux.Handle(PprofPath+"symbol", authorize(cfg.AuthToken, http.HandlerFunc(pprof.Symbol)))
+	mux.Handle(PprofPath+"trace", authorize(cfg.AuthToken, http.HandlerFunc(pprof.Trace)))
+}
+
+// authorize rejects the requests without the bearer token, all requests pass if token is empty.
+func authorize(token string, handler http.Handler) http.Handler {
+	if token == "" {
+		return handler
+	}
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,39 @@
+package debug
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+)
+
+func TestRegister(t *testing.T) {
+	mux := http.NewServeMux()
+	Register(mux, &config.DebugConfig{})
+	_, pattern := mux.Handler(httptest.NewRequest(http.MethodGet, ExpvarPath, nil))
+	require.Empty(t, pattern, "debug endpoints are off by default")
+
+	mux = http.NewServeMux()
+	Register(mux, &config.DebugConfig{Enabled: true, AuthToken: "0123456789abcdef"})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	get := func(path, token string) int {
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		return res.StatusCode
+	}
+	require.Equal(t, http.StatusUnauthorized, get(ExpvarPath, ""))
+	require.Equal(t, http.StatusUnauthorized, get(PprofPath+"goroutine", "wrong-token-0000"))
+	require.Equal(t, http.StatusOK, get(ExpvarPath, "0123456789abcdef"))
+	require.Equal(t, http.StatusOK, get(PprofPath+"goroutine", "0123456789abcdef"))
+}
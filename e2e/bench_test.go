@@ -0,0 +1,56 @@
+package e2e
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	challengetypes "github.com/bnb-chain/greenfield/x/challenge/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+// LoadChallenges is how many challenges every iteration of BenchmarkPipeline_Load emits at once
+const LoadChallenges = 50
+
+// BenchmarkPipeline_Load emits LoadChallenges challenges of corrupted objects by iteration and waits until all of them
+// are attested, so that they go through every stage of the pipeline: verified, voted, collated and submitted. The
+// allocations are the ones of the whole challenger, the fake node and the storage provider, for a batch.
+func BenchmarkPipeline_Load(b *testing.B) {
+	h, err := NewHarness(2)
+	require.NoError(b, err)
+	sp := h.AddStorageProvider()
+	require.NoError(b, h.Start())
+	defer func() { require.NoError(b, h.Stop()) }()
+	segments := [][]byte{bytes.Repeat([]byte("a"), 1024), bytes.Repeat([]byte("b"), 1024)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	var challengeId uint64
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		challenges := make([]challengetypes.EventStartChallenge, 0, LoadChallenges)
+		for j := 0; j < LoadChallenges; j++ {
+			challengeId++
+			if challengeId%HeartbeatInterval == 0 {
+				challengeId++
+			}
+			// a challenge of the same object is skipped, every challenge is of an object of its own
+			objectId := strconv.FormatUint(challengeId, 10)
+			h.PutObject(sp, objectId, segments)
+			sp.Corrupt(objectId)
+			challenges = append(challenges, challengetypes.EventStartChallenge{ChallengeId: challengeId,
+				ObjectId: sdk.NewUint(challengeId), SpOperatorAddress: sp.OperatorAddress, SegmentIndex: 1,
+				RedundancyIndex: -1})
+		}
+		attested := len(h.Node.Attestations())
+		b.StartTimer()
+		for _, challenge := range challenges {
+			h.Node.Challenge(challenge)
+		}
+		require.NoError(b, h.WaitFor("the attestations", func() bool {
+			return len(h.Node.Attestations()) == attested+LoadChallenges
+		}))
+	}
+	b.ReportMetric(float64(b.N*LoadChallenges)/b.Elapsed().Seconds(), "challenges/s")
+}
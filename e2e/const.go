@@ -0,0 +1,30 @@
+package e2e
+
+import "time"
+
+const (
+	// ChainId is an eip155 chain id, the attest transactions are signed with eip712
+	ChainId = "greenfield_9000-121"
+	// AppVersion is the version of the greenfield app reported by the fake node, it is in the compatibility matrix
+	AppVersion = "v1.0.0"
+	// BlockInterval is how often the fake node produces a block
+	BlockInterval = 200 * time.Millisecond
+	// ChallengeExpiryBlocks is how many blocks an emitted challenge lives, unless it sets its expired height
+	ChallengeExpiryBlocks = 3000
+	// HeartbeatInterval makes every challenge whose id is a multiple of it a heartbeat challenge
+	HeartbeatInterval = 100
+	// SubmitWindow is how long the challenger stays the in-turn attestation submitter
+	SubmitWindow = 5 * time.Minute
+	// SimulatedGasUsed and MinGasPrice are returned by the simulation of the transactions
+	SimulatedGasUsed = 1200
+	MinGasPrice      = "5000000000BNB"
+	// Segments of the objects, as for the redundancy params served by the node
+	RedundantDataChunkNum   = 4
+	RedundantParityChunkNum = 2
+	MaxSegmentSize          = 16 * 1024 * 1024
+
+	// WaitTimeout bounds the waits of the tests for the pipeline, the vote collector and the attest monitor poll every
+	// few seconds
+	WaitTimeout  = time.Minute
+	WaitInterval = 100 * time.Millisecond
+)
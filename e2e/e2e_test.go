@@ -0,0 +1,104 @@
+package e2e
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/health"
+	challengetypes "github.com/bnb-chain/greenfield/x/challenge/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+// pipeline is a challenger with a healthy and a corrupted storage provider and their challenges, of which a heartbeat
+// and the challenge of the corrupted one are attested.
+type pipeline struct {
+	*Harness
+	healthy    *StorageProvider
+	corrupted  *StorageProvider
+	challenges []challengetypes.EventStartChallenge
+}
+
+func newPipeline(t *testing.T) *pipeline {
+	if testing.Short() {
+		t.Skip("the pipeline takes a few polls of the vote collector and the attest monitor")
+	}
+	h, err := NewHarness(2)
+	require.NoError(t, err)
+	p := &pipeline{Harness: h, healthy: h.AddStorageProvider(), corrupted: h.AddStorageProvider()}
+	segments := [][]byte{bytes.Repeat([]byte("a"), 1024), bytes.Repeat([]byte("b"), 1024)}
+	h.PutObject(p.healthy, "1", segments)
+	h.PutObject(p.healthy, "2", segments)
+	h.PutObject(p.corrupted, "3", segments)
+	p.corrupted.Corrupt("3")
+	p.challenges = []challengetypes.EventStartChallenge{
+		// the sp serves the object, the challenge is dropped
+		{ChallengeId: 1, ObjectId: sdk.NewUint(1), SpOperatorAddress: p.healthy.OperatorAddress, SegmentIndex: 1,
+			RedundancyIndex: -1},
+		// a heartbeat is attested although the sp serves the object
+		{ChallengeId: HeartbeatInterval, ObjectId: sdk.NewUint(2), SpOperatorAddress: p.healthy.OperatorAddress,
+			RedundancyIndex: 2},
+		// the sp serves corrupted data, the challenge succeeds
+		{ChallengeId: HeartbeatInterval + 1, ObjectId: sdk.NewUint(3), SpOperatorAddress: p.corrupted.OperatorAddress,
+			SegmentIndex: 1, RedundancyIndex: -1},
+	}
+	return p
+}
+
+func (p *pipeline) challenge() {
+	for _, challenge := range p.challenges {
+		p.Node.Challenge(challenge)
+	}
+}
+
+func (p *pipeline) requireAttested(t *testing.T) {
+	require.NoError(t, p.WaitFor("the attestations", func() bool { return len(p.Node.Attestations()) == 2 }))
+	heartbeat := p.Node.Attestation(HeartbeatInterval)
+	require.NotNil(t, heartbeat)
+	require.Equal(t, challengetypes.CHALLENGE_FAILED, heartbeat.VoteResult)
+	require.Equal(t, p.healthy.OperatorAddress, heartbeat.SpOperatorAddress)
+	succeeded := p.Node.Attestation(HeartbeatInterval + 1)
+	require.NotNil(t, succeeded)
+	require.Equal(t, challengetypes.CHALLENGE_SUCCEED, succeeded.VoteResult)
+	require.Equal(t, "3", succeeded.ObjectId.String())
+	require.Nil(t, p.Node.Attestation(1))
+}
+
+func TestPipeline(t *testing.T) {
+	p := newPipeline(t)
+	require.NoError(t, p.Start())
+	defer func() { require.NoError(t, p.Stop()) }()
+	p.challenge()
+
+	p.requireAttested(t)
+	// the challenger and both peers voted for the two attested challenges only
+	require.Len(t, p.Node.Votes(), 6)
+}
+
+func TestPipeline_Faults(t *testing.T) {
+	p := newPipeline(t)
+	// the retries absorb the dropped calls and the corrupted responses
+	p.Config.TunableConfig.RetryAttempts = 5
+	// the rpc drops start once the challenger is up, its clients are created with the first calls
+	p.Faults.CorruptSP(0.2)
+	p.Faults.DelayDBWrites(5 * time.Millisecond)
+	for _, loop := range []string{health.LoopMonitor, health.LoopVerifier, health.LoopCollector, health.LoopCollator,
+		health.LoopSubmitter} {
+		p.Faults.KillLoop(loop, time.Second, 2)
+	}
+	require.NoError(t, p.Start())
+	defer func() { require.NoError(t, p.Stop()) }()
+	p.Faults.DropRPC(0.1)
+	p.challenge()
+
+	// the same challenges are attested despite the faults
+	p.requireAttested(t)
+	require.NotZero(t, p.Faults.DroppedRPC())
+	require.NoError(t, p.WaitFor("the kills", func() bool { return p.Faults.Killed(health.LoopSubmitter) == 2 }))
+	select {
+	case err := <-p.app.Fatal():
+		t.Fatalf("the challenger gave up, err=%v", err)
+	default:
+	}
+}
@@ -0,0 +1,178 @@
+package e2e
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Faults injects faults in the node, the storage providers and the challenger of a harness, so that the resilience of
+// the challenger is exercised: the retries of the rpc and sp requests, the restarts of the supervisor and the slow
+// database writes. The dropped and corrupted calls are drawn at random rather than every n-th call, the pollers of the
+// challenger would hit the same faults on every retry otherwise. They can be changed while the challenger runs, none is
+// injected by default.
+type Faults struct {
+	mtx           sync.Mutex
+	rand          *rand.Rand
+	dropRPCRate   float64
+	droppedRPC    int
+	corruptSPRate float64
+	corruptedSP   int
+	dbWriteDelay  time.Duration
+	kills         map[string]chan struct{} // by loop
+	killed        map[string]int
+	stop          chan struct{}
+	wg            sync.WaitGroup
+}
+
+// NewFaults returns faults drawn from seed.
+func NewFaults(seed int64) *Faults {
+	return &Faults{
+		rand:   rand.New(rand.NewSource(seed)),
+		kills:  make(map[string]chan struct{}),
+		killed: make(map[string]int),
+		stop:   make(chan struct{}),
+	}
+}
+
+// DropRPC makes the share rate of the rpc calls to the node fail with an unavailable error.
+func (f *Faults) DropRPC(rate float64) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.dropRPCRate = rate
+}
+
+// CorruptSP makes the share rate of the challenge responses of the storage providers truncated ones.
+func (f *Faults) CorruptSP(rate float64) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.corruptSPRate = rate
+}
+
+// DelayDBWrites delays every insert, update, delete and raw statement of the challenger by delay.
+func (f *Faults) DelayDBWrites(delay time.Duration) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.dbWriteDelay = delay
+}
+
+// KillLoop kills the supervised loop times, once every interval, the supervisor is expected to restart it.
+func (f *Faults) KillLoop(loop string, every time.Duration, times int) {
+	kill := f.killChannel(loop)
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		for i := 0; i < times; i++ {
+			select {
+			case <-f.stop:
+				return
+			case <-time.After(every):
+			}
+			// the kill waits for the loop if it is restarting
+			select {
+			case <-f.stop:
+				return
+			case kill <- struct{}{}:
+			}
+			f.mtx.Lock()
+			f.killed[loop]++
+			f.mtx.Unlock()
+		}
+	}()
+}
+
+// Kills implements supervisor.Killer.
+func (f *Faults) Kills(loop string) <-chan struct{} {
+	return f.killChannel(loop)
+}
+
+func (f *Faults) killChannel(loop string) chan struct{} {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	kill, ok := f.kills[loop]
+	if !ok {
+		kill = make(chan struct{})
+		f.kills[loop] = kill
+	}
+	return kill
+}
+
+// DroppedRPC returns how many rpc calls were dropped.
+func (f *Faults) DroppedRPC() int {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.droppedRPC
+}
+
+// CorruptedSP returns how many challenge responses were corrupted.
+func (f *Faults) CorruptedSP() int {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.corruptedSP
+}
+
+// Killed returns how often the loop was killed.
+func (f *Faults) Killed(loop string) int {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.killed[loop]
+}
+
+// dropRPC tells whether the rpc call being served is dropped, f may be nil.
+func (f *Faults) dropRPC() bool {
+	if f == nil {
+		return false
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.rand.Float64() >= f.dropRPCRate {
+		return false
+	}
+	f.droppedRPC++
+	return true
+}
+
+// corruptSP tells whether the challenge response being served is corrupted, f may be nil.
+func (f *Faults) corruptSP() bool {
+	if f == nil {
+		return false
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.rand.Float64() >= f.corruptSPRate {
+		return false
+	}
+	f.corruptedSP++
+	return true
+}
+
+// RegisterDB delays the writes to db as set by DelayDBWrites.
+func (f *Faults) RegisterDB(db *gorm.DB) error {
+	delay := func(*gorm.DB) {
+		f.mtx.Lock()
+		d := f.dbWriteDelay
+		f.mtx.Unlock()
+		if d > 0 {
+			time.Sleep(d)
+		}
+	}
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("e2e:delay_create", delay); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("e2e:delay_update", delay); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("e2e:delay_delete", delay); err != nil {
+		return err
+	}
+	return callbacks.Raw().Before("gorm:raw").Register("e2e:delay_raw", delay)
+}
+
+// Close stops the scheduled kills.
+func (f *Faults) Close() {
+	close(f.stop)
+	f.wg.Wait()
+}
@@ -0,0 +1,127 @@
+package e2e
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/app"
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/cometbft/cometbft/crypto/secp256k1"
+	"github.com/prysmaticlabs/prysm/crypto/bls/blst"
+)
+
+// Harness runs a challenger with an in-memory database against a fake Greenfield node and mock storage providers,
+// for the regression tests of the whole pipeline: the challenges emitted by the node are verified against the
+// storage providers, voted in the votepool of the node and attested to it.
+type Harness struct {
+	Node *Node
+	// Faults are injected in the node, the storage providers and the challenger
+	Faults *Faults
+	// Config is the config of the challenger, it can be changed until Start
+	Config *config.Config
+	sps    []*StorageProvider
+	app    *app.App
+}
+
+// NewHarness starts the node, the challenger is one of its validators along with peers validators voting like it.
+func NewHarness(peers int) (*Harness, error) {
+	blsKey, err := blst.RandKey()
+	if err != nil {
+		return nil, err
+	}
+	faults := NewFaults(time.Now().UnixNano())
+	node, err := NewNode(blsKey.PublicKey().Marshal(), peers, faults)
+	if err != nil {
+		return nil, err
+	}
+	node.Start()
+	metricsPort, err := freePort()
+	if err != nil {
+		node.Close()
+		return nil, err
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.LogConfig.Level = "ERROR"
+	cfg.GreenfieldConfig.KeyType = config.KeyTypeLocalPrivateKey
+	cfg.GreenfieldConfig.PrivateKey = hex.EncodeToString(secp256k1.GenPrivKey().Bytes())
+	cfg.GreenfieldConfig.BlsPrivateKey = hex.EncodeToString(blsKey.Marshal())
+	cfg.GreenfieldConfig.RPCAddrs = []string{node.Endpoint()}
+	cfg.GreenfieldConfig.ChainIdString = ChainId
+	cfg.GreenfieldConfig.GasLimit = SimulatedGasUsed
+	cfg.GreenfieldConfig.FeeAmount = "6000000000000"
+	cfg.GreenfieldConfig.FeeDenom = "BNB"
+	cfg.GreenfieldConfig.StartHeight = 1
+	cfg.DBConfig.Dialect = config.DBDialectSqlite3
+	cfg.DBConfig.DBPath = config.DBPathInMemory
+	cfg.MetricsConfig.Port = metricsPort
+	cfg.TunableConfig.RetryIntervalInMs = 100
+	cfg.TunableConfig.RetryDelayInMs = 100
+	cfg.TunableConfig.EventIntervalInMs = 10
+	cfg.PipelineConfig.ShutdownTimeoutInSeconds = 10
+	return &Harness{Node: node, Faults: faults, Config: cfg}, nil
+}
+
+// AddStorageProvider starts a storage provider and registers it on the node.
+func (h *Harness) AddStorageProvider() *StorageProvider {
+	sp := NewStorageProvider(h.Faults)
+	h.sps = append(h.sps, sp)
+	h.Node.AddStorageProvider(sp.OperatorAddress, sp.Endpoint())
+	return sp
+}
+
+// PutObject stores the segments of the object on the storage provider and seals the object on the node.
+func (h *Harness) PutObject(sp *StorageProvider, objectId string, segments [][]byte) {
+	h.Node.AddObject(objectId, sp.PutObject(objectId, segments))
+}
+
+// Start starts the challenger, the storage providers must be added before since the challenger lists them once.
+func (h *Harness) Start() error {
+	h.Config.Validate()
+	logging.InitLogger(&h.Config.LogConfig)
+	h.app = app.NewApp(h.Config)
+	if err := h.Faults.RegisterDB(h.app.DB()); err != nil {
+		return err
+	}
+	h.app.SetKiller(h.Faults)
+	h.app.Start()
+	return nil
+}
+
+// Stop shuts the challenger down, then the node and the storage providers.
+func (h *Harness) Stop() error {
+	var err error
+	if h.app != nil {
+		err = h.app.Shutdown()
+	}
+	h.Faults.Close()
+	h.Node.Close()
+	for _, sp := range h.sps {
+		sp.Close()
+	}
+	return err
+}
+
+// WaitFor polls condition until it holds, it fails after WaitTimeout.
+func (h *Harness) WaitFor(description string, condition func() bool) error {
+	deadline := time.Now().Add(WaitTimeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for %s", description)
+		}
+		time.Sleep(WaitInterval)
+	}
+	return nil
+}
+
+func freePort() (uint16, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return uint16(listener.Addr().(*net.TCPAddr).Port), nil
+}
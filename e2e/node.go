@@ -0,0 +1,559 @@
+package e2e
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	gnfdtypes "github.com/bnb-chain/greenfield/sdk/types"
+	challengetypes "github.com/bnb-chain/greenfield/x/challenge/types"
+	sptypes "github.com/bnb-chain/greenfield/x/sp/types"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+	virtualgrouptypes "github.com/bnb-chain/greenfield/x/virtualgroup/types"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	"github.com/cometbft/cometbft/libs/bytes"
+	"github.com/cometbft/cometbft/libs/log"
+	"github.com/cometbft/cometbft/p2p"
+	tmp2p "github.com/cometbft/cometbft/proto/tendermint/p2p"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	rpcserver "github.com/cometbft/cometbft/rpc/jsonrpc/server"
+	rpctypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+	tmversion "github.com/cometbft/cometbft/version"
+	"github.com/cometbft/cometbft/votepool"
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authtx "github.com/cosmos/cosmos-sdk/x/auth/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/prysmaticlabs/prysm/crypto/bls"
+	"github.com/prysmaticlabs/prysm/crypto/bls/blst"
+	blscmn "github.com/prysmaticlabs/prysm/crypto/bls/common"
+	"github.com/willf/bitset"
+)
+
+// codeRejected is the abci code of the rejected queries and transactions
+const codeRejected = 1
+
+// message is a query response, request a query request
+type message interface {
+	Marshal() ([]byte, error)
+}
+
+type request interface {
+	Unmarshal(data []byte) error
+}
+
+// Node fakes the rpc of a Greenfield node, so that the whole pipeline of a challenger runs against it. It produces a
+// block every BlockInterval with the challenges emitted since the previous block, answers the queries of the
+// challenger from the storage providers and objects it was given and keeps the votes and the attestations it
+// receives. The challenger is a validator and always the in-turn submitter, the peer validators vote like it.
+type Node struct {
+	mtx          sync.Mutex
+	height       int64
+	blockTimes   map[int64]time.Time
+	blockEvents  map[int64][]abci.Event
+	pending      []abci.Event // events of the next block
+	validators   []*tmtypes.Validator
+	submitter    string // hex encoded bls public key of the in-turn submitter
+	peers        []blscmn.SecretKey
+	sps          []*sptypes.StorageProvider
+	objects      map[string]*storagetypes.ObjectInfo
+	votes        []*votepool.Vote
+	attestations []*challengetypes.MsgAttest
+	sequence     uint64
+	txDecoder    sdk.TxDecoder
+	faults       *Faults
+	server       *httptest.Server
+	stop         chan struct{}
+	wg           sync.WaitGroup
+}
+
+// NewNode returns a node whose validators are the challenger with blsPubKey and peers other validators. It injects
+// the rpc faults of faults, if not nil.
+func NewNode(blsPubKey []byte, peers int, faults *Faults) (*Node, error) {
+	n := &Node{
+		blockTimes:  make(map[int64]time.Time),
+		blockEvents: make(map[int64][]abci.Event),
+		submitter:   hex.EncodeToString(blsPubKey),
+		objects:     make(map[string]*storagetypes.ObjectInfo),
+		txDecoder: authtx.NewTxConfig(gnfdtypes.Codec(), []signing.SignMode{signing.SignMode_SIGN_MODE_EIP_712}).
+			TxDecoder(),
+		faults: faults,
+		stop:   make(chan struct{}),
+	}
+	n.validators = append(n.validators, newValidator(blsPubKey))
+	for i := 0; i < peers; i++ {
+		key, err := blst.RandKey()
+		if err != nil {
+			return nil, err
+		}
+		n.peers = append(n.peers, key)
+		n.validators = append(n.validators, newValidator(key.PublicKey().Marshal()))
+	}
+	return n, nil
+}
+
+func newValidator(blsPubKey []byte) *tmtypes.Validator {
+	pubKey := ed25519.GenPrivKey().PubKey()
+	return &tmtypes.Validator{Address: pubKey.Address(), PubKey: pubKey, VotingPower: 1, BlsKey: blsPubKey}
+}
+
+// Start serves the rpc and produces the blocks until Close.
+func (n *Node) Start() {
+	mux := http.NewServeMux()
+	rpcserver.RegisterRPCFuncs(mux, n.routes(), log.NewNopLogger())
+	n.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n.faults.dropRPC() {
+			http.Error(w, "dropped by the fault injection", http.StatusServiceUnavailable)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	n.produceBlock()
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		ticker := time.NewTicker(BlockInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-n.stop:
+				return
+			case <-ticker.C:
+				n.produceBlock()
+			}
+		}
+	}()
+}
+
+// Endpoint is the rpc address of the node.
+func (n *Node) Endpoint() string {
+	return n.server.URL
+}
+
+func (n *Node) Close() {
+	close(n.stop)
+	n.wg.Wait()
+	n.server.Close()
+}
+
+func (n *Node) produceBlock() {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.height++
+	n.blockTimes[n.height] = time.Now()
+	n.blockEvents[n.height] = n.pending
+	n.pending = nil
+}
+
+// Height returns the latest block height.
+func (n *Node) Height() int64 {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return n.height
+}
+
+// AddStorageProvider registers the storage provider with its endpoint on chain.
+func (n *Node) AddStorageProvider(operatorAddress, endpoint string) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.sps = append(n.sps, &sptypes.StorageProvider{
+		Id:              uint32(len(n.sps) + 1),
+		OperatorAddress: operatorAddress,
+		FundingAddress:  operatorAddress,
+		SealAddress:     operatorAddress,
+		ApprovalAddress: operatorAddress,
+		GcAddress:       operatorAddress,
+		Status:          sptypes.STATUS_IN_SERVICE,
+		Endpoint:        endpoint,
+	})
+}
+
+// AddObject registers the object with its checksums on chain, the primary one first.
+func (n *Node) AddObject(objectId string, checksums [][]byte) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.objects[objectId] = &storagetypes.ObjectInfo{
+		Id:           sdkmath.NewUintFromString(objectId),
+		BucketName:   "e2e",
+		ObjectName:   objectId,
+		ObjectStatus: storagetypes.OBJECT_STATUS_SEALED,
+		Checksums:    checksums,
+	}
+}
+
+// Challenge emits the challenge in the next block, it expires after ChallengeExpiryBlocks unless its expired height
+// is set.
+func (n *Node) Challenge(event challengetypes.EventStartChallenge) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if event.ExpiredHeight == 0 {
+		event.ExpiredHeight = uint64(n.height) + ChallengeExpiryBlocks
+	}
+	// typed events carry their attributes json encoded
+	attributes := map[string]string{
+		"challenge_id":        strconv.FormatUint(event.ChallengeId, 10),
+		"object_id":           event.ObjectId.String(),
+		"segment_index":       strconv.FormatUint(uint64(event.SegmentIndex), 10),
+		"sp_operator_address": event.SpOperatorAddress,
+		"redundancy_index":    strconv.FormatInt(int64(event.RedundancyIndex), 10),
+		"challenger_address":  event.ChallengerAddress,
+		"expired_height":      strconv.FormatUint(event.ExpiredHeight, 10),
+	}
+	abciEvent := abci.Event{Type: "greenfield.challenge.EventStartChallenge"}
+	for key, value := range attributes {
+		abciEvent.Attributes = append(abciEvent.Attributes, abci.EventAttribute{Key: key, Value: strconv.Quote(value)})
+	}
+	n.pending = append(n.pending, abciEvent)
+}
+
+// Votes returns the votes received by the votepool, the ones of the peer validators included.
+func (n *Node) Votes() []*votepool.Vote {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return append([]*votepool.Vote(nil), n.votes...)
+}
+
+// Attestations returns the attestations accepted by the node.
+func (n *Node) Attestations() []*challengetypes.MsgAttest {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return append([]*challengetypes.MsgAttest(nil), n.attestations...)
+}
+
+// Attestation returns the attestation of the challenge, nil if it was not attested.
+func (n *Node) Attestation(challengeId uint64) *challengetypes.MsgAttest {
+	for _, attestation := range n.Attestations() {
+		if attestation.ChallengeId == challengeId {
+			return attestation
+		}
+	}
+	return nil
+}
+
+func (n *Node) routes() map[string]*rpcserver.RPCFunc {
+	return map[string]*rpcserver.RPCFunc{
+		"status":            rpcserver.NewRPCFunc(n.status, ""),
+		"block":             rpcserver.NewRPCFunc(n.block, "height"),
+		"block_results":     rpcserver.NewRPCFunc(n.blockResults, "height"),
+		"validators":        rpcserver.NewRPCFunc(n.validatorSet, "height,page,per_page"),
+		"abci_query":        rpcserver.NewRPCFunc(n.abciQuery, "path,data,height,prove"),
+		"broadcast_tx_sync": rpcserver.NewRPCFunc(n.broadcastTxSync, "tx"),
+		"broadcast_vote":    rpcserver.NewRPCFunc(n.broadcastVote, "vote"),
+		"query_vote":        rpcserver.NewRPCFunc(n.queryVote, "event_type,event_hash"),
+	}
+}
+
+func (n *Node) status(_ *rpctypes.Context) (*ctypes.ResultStatus, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	validator := n.validators[0]
+	return &ctypes.ResultStatus{
+		NodeInfo: p2p.DefaultNodeInfo{Network: ChainId},
+		SyncInfo: ctypes.SyncInfo{LatestBlockHeight: n.height, LatestBlockTime: n.blockTimes[n.height]},
+		ValidatorInfo: ctypes.ValidatorInfo{Address: validator.Address, PubKey: validator.PubKey,
+			VotingPower: validator.VotingPower},
+	}, nil
+}
+
+// blockHeight returns the height requested, the latest one if it is not set. It must hold mtx.
+func (n *Node) blockHeight(height *int64) (int64, error) {
+	if height == nil || *height == 0 {
+		return n.height, nil
+	}
+	if *height > n.height {
+		return 0, fmt.Errorf("height %d must be less than or equal to the current blockchain height %d", *height,
+			n.height)
+	}
+	if *height < 1 {
+		return 0, fmt.Errorf("height %d must be greater than 0", *height)
+	}
+	return *height, nil
+}
+
+func (n *Node) block(_ *rpctypes.Context, height *int64) (*ctypes.ResultBlock, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	h, err := n.blockHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	return &ctypes.ResultBlock{
+		Block: &tmtypes.Block{Header: tmtypes.Header{ChainID: ChainId, Height: h, Time: n.blockTimes[h]}},
+	}, nil
+}
+
+func (n *Node) blockResults(_ *rpctypes.Context, height *int64) (*ctypes.ResultBlockResults, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	h, err := n.blockHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	return &ctypes.ResultBlockResults{Height: h, EndBlockEvents: n.blockEvents[h]}, nil
+}
+
+func (n *Node) validatorSet(_ *rpctypes.Context, height *int64, _, _ *int) (*ctypes.ResultValidators, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	h, err := n.blockHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	return &ctypes.ResultValidators{BlockHeight: h, Validators: n.validators, Count: len(n.validators),
+		Total: len(n.validators)}, nil
+}
+
+func (n *Node) abciQuery(_ *rpctypes.Context, path string, data bytes.HexBytes, _ int64, _ bool,
+) (*ctypes.ResultABCIQuery, error) {
+	handler, ok := n.queries()[path]
+	if !ok {
+		return &ctypes.ResultABCIQuery{Response: abci.ResponseQuery{Code: codeRejected, Log: "unknown query " + path}}, nil
+	}
+	n.mtx.Lock()
+	res, err := handler(data)
+	height := n.height
+	n.mtx.Unlock()
+	if err != nil {
+		return &ctypes.ResultABCIQuery{Response: abci.ResponseQuery{Code: codeRejected, Log: err.Error()}}, nil
+	}
+	value, err := res.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return &ctypes.ResultABCIQuery{Response: abci.ResponseQuery{Value: value, Height: height}}, nil
+}
+
+// queries returns the handlers of the grpc queries sent over abci, they are called with mtx held.
+func (n *Node) queries() map[string]func(data []byte) (message, error) {
+	return map[string]func(data []byte) (message, error){
+		"/cosmos.base.tendermint.v1beta1.Service/GetNodeInfo": func(data []byte) (message, error) {
+			return &tmservice.GetNodeInfoResponse{
+				DefaultNodeInfo:    &tmp2p.DefaultNodeInfo{Network: ChainId, Version: tmversion.TMCoreSemVer},
+				ApplicationVersion: &tmservice.VersionInfo{Version: AppVersion},
+			}, nil
+		},
+		"/greenfield.sp.Query/StorageProviders": func(data []byte) (message, error) {
+			return &sptypes.QueryStorageProvidersResponse{Sps: n.sps}, nil
+		},
+		"/greenfield.sp.Query/StorageProviderByOperatorAddress": func(data []byte) (message, error) {
+			req := &sptypes.QueryStorageProviderByOperatorAddressRequest{}
+			if err := unmarshal(req, data); err != nil {
+				return nil, err
+			}
+			for _, sp := range n.sps {
+				if strings.EqualFold(sp.OperatorAddress, req.OperatorAddress) {
+					return &sptypes.QueryStorageProviderByOperatorAddressResponse{StorageProvider: sp}, nil
+				}
+			}
+			return nil, fmt.Errorf("no such storage provider %s", req.OperatorAddress)
+		},
+		"/greenfield.storage.Query/Params": func(data []byte) (message, error) {
+			return &storagetypes.QueryParamsResponse{Params: storagetypes.Params{
+				VersionedParams: storagetypes.VersionedParams{
+					MaxSegmentSize:          MaxSegmentSize,
+					RedundantDataChunkNum:   RedundantDataChunkNum,
+					RedundantParityChunkNum: RedundantParityChunkNum,
+				},
+			}}, nil
+		},
+		"/greenfield.storage.Query/HeadObjectById": func(data []byte) (message, error) {
+			req := &storagetypes.QueryHeadObjectByIdRequest{}
+			if err := unmarshal(req, data); err != nil {
+				return nil, err
+			}
+			object, ok := n.objects[req.ObjectId]
+			if !ok {
+				return nil, errors.New("No such object")
+			}
+			return &storagetypes.QueryHeadObjectResponse{ObjectInfo: object,
+				GlobalVirtualGroup: &virtualgrouptypes.GlobalVirtualGroup{}}, nil
+		},
+		"/greenfield.challenge.Query/Params": func(data []byte) (message, error) {
+			params := challengetypes.DefaultParams()
+			params.HeartbeatInterval = HeartbeatInterval
+			return &challengetypes.QueryParamsResponse{Params: params}, nil
+		},
+		"/greenfield.challenge.Query/InturnAttestationSubmitter": func(data []byte) (message, error) {
+			now := time.Now()
+			return &challengetypes.QueryInturnAttestationSubmitterResponse{
+				BlsPubKey: n.submitter,
+				SubmitInterval: &challengetypes.SubmitInterval{Start: uint64(now.Unix()),
+					End: uint64(now.Add(SubmitWindow).Unix())},
+			}, nil
+		},
+		"/greenfield.challenge.Query/LatestAttestedChallenges": func(data []byte) (message, error) {
+			res := &challengetypes.QueryLatestAttestedChallengesResponse{}
+			for _, attestation := range n.attestations {
+				res.Challenges = append(res.Challenges,
+					&challengetypes.AttestedChallenge{Id: attestation.ChallengeId, Result: attestation.VoteResult})
+			}
+			return res, nil
+		},
+		"/cosmos.auth.v1beta1.Query/Account": func(data []byte) (message, error) {
+			req := &authtypes.QueryAccountRequest{}
+			if err := unmarshal(req, data); err != nil {
+				return nil, err
+			}
+			address, err := sdk.AccAddressFromHexUnsafe(req.Address)
+			if err != nil {
+				return nil, err
+			}
+			account, err := codectypes.NewAnyWithValue(authtypes.NewBaseAccount(address, nil, 1, n.sequence))
+			if err != nil {
+				return nil, err
+			}
+			return &authtypes.QueryAccountResponse{Account: account}, nil
+		},
+		"/cosmos.bank.v1beta1.Query/Balance": func(data []byte) (message, error) {
+			req := &banktypes.QueryBalanceRequest{}
+			if err := unmarshal(req, data); err != nil {
+				return nil, err
+			}
+			balance := sdk.NewCoin(req.Denom, sdk.NewInt(1e18))
+			return &banktypes.QueryBalanceResponse{Balance: &balance}, nil
+		},
+		"/cosmos.tx.v1beta1.Service/Simulate": func(data []byte) (message, error) {
+			return &tx.SimulateResponse{
+				GasInfo: &sdk.GasInfo{GasUsed: SimulatedGasUsed, MinGasPrice: MinGasPrice},
+				Result:  &sdk.Result{},
+			}, nil
+		},
+	}
+}
+
+func unmarshal(req request, data []byte) error {
+	if err := req.Unmarshal(data); err != nil {
+		return fmt.Errorf("invalid request, err=%s", err.Error())
+	}
+	return nil
+}
+
+// broadcastTxSync accepts the transactions whose attestations the chain would accept.
+func (n *Node) broadcastTxSync(_ *rpctypes.Context, txBytes tmtypes.Tx) (*ctypes.ResultBroadcastTx, error) {
+	decoded, err := n.txDecoder(txBytes)
+	if err != nil {
+		return &ctypes.ResultBroadcastTx{Code: codeRejected, Log: err.Error(), Hash: txBytes.Hash()}, nil
+	}
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	attestations := make([]*challengetypes.MsgAttest, 0)
+	for _, msg := range decoded.GetMsgs() {
+		attestation, ok := msg.(*challengetypes.MsgAttest)
+		if !ok {
+			continue
+		}
+		if err = n.verifyAttestation(attestation); err != nil {
+			return &ctypes.ResultBroadcastTx{Code: codeRejected, Log: err.Error(), Hash: txBytes.Hash()}, nil
+		}
+		attestations = append(attestations, attestation)
+	}
+	n.attestations = append(n.attestations, attestations...)
+	n.sequence++
+	return &ctypes.ResultBroadcastTx{Hash: txBytes.Hash()}, nil
+}
+
+// verifyAttestation checks the aggregated signature of the attestation like the challenge module does, it must hold
+// mtx.
+func (n *Node) verifyAttestation(attestation *challengetypes.MsgAttest) error {
+	for _, attested := range n.attestations {
+		if attested.ChallengeId == attestation.ChallengeId {
+			return fmt.Errorf("challenge %d is already attested", attestation.ChallengeId)
+		}
+	}
+	voted := bitset.From(attestation.VoteValidatorSet)
+	pubKeys := make([]bls.PublicKey, 0)
+	for i, validator := range n.validators {
+		if !voted.Test(uint(i)) {
+			continue
+		}
+		pubKey, err := bls.PublicKeyFromBytes(validator.BlsKey)
+		if err != nil {
+			return err
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+	if len(pubKeys) <= len(n.validators)*2/3 {
+		return fmt.Errorf("not enough validators voted, voted: %d, validators: %d", len(pubKeys), len(n.validators))
+	}
+	signature, err := bls.SignatureFromBytes(attestation.VoteAggSignature)
+	if err != nil {
+		return err
+	}
+	if !signature.FastAggregateVerify(pubKeys, attestation.GetBlsSignBytes(ChainId)) {
+		return errors.New("invalid aggregated signature")
+	}
+	return nil
+}
+
+// broadcastVote adds the vote to the votepool, the peer validators vote for the same event.
+func (n *Node) broadcastVote(_ *rpctypes.Context, vote votepool.Vote) (*ctypes.ResultBroadcastVote, error) {
+	if err := verifyVote(&vote); err != nil {
+		return nil, err
+	}
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if !n.addVote(&vote) {
+		return &ctypes.ResultBroadcastVote{}, nil
+	}
+	for _, peer := range n.peers {
+		n.addVote(&votepool.Vote{
+			EventType: vote.EventType,
+			EventHash: vote.EventHash,
+			PubKey:    peer.PublicKey().Marshal(),
+			Signature: peer.Sign(vote.EventHash).Marshal(),
+		})
+	}
+	return &ctypes.ResultBroadcastVote{}, nil
+}
+
+// addVote adds the vote unless the validator already voted for the event, it must hold mtx.
+func (n *Node) addVote(vote *votepool.Vote) bool {
+	for _, v := range n.votes {
+		if v.EventType == vote.EventType && string(v.EventHash) == string(vote.EventHash) &&
+			string(v.PubKey) == string(vote.PubKey) {
+			return false
+		}
+	}
+	n.votes = append(n.votes, vote)
+	return true
+}
+
+func verifyVote(vote *votepool.Vote) error {
+	pubKey, err := bls.PublicKeyFromBytes(vote.PubKey)
+	if err != nil {
+		return err
+	}
+	signature, err := bls.SignatureFromBytes(vote.Signature)
+	if err != nil {
+		return err
+	}
+	if !signature.Verify(pubKey, vote.EventHash) {
+		return errors.New("invalid vote signature")
+	}
+	return nil
+}
+
+func (n *Node) queryVote(_ *rpctypes.Context, eventType int, eventHash []byte) (*ctypes.ResultQueryVote, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	votes := make([]*votepool.Vote, 0)
+	for _, v := range n.votes {
+		if int(v.EventType) == eventType && (len(eventHash) == 0 || string(v.EventHash) == string(eventHash)) {
+			votes = append(votes, v)
+		}
+	}
+	return &ctypes.ResultQueryVote{Votes: votes}, nil
+}
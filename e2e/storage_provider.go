@@ -0,0 +1,123 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bnb-chain/greenfield-common/go/hash"
+	"github.com/bnb-chain/greenfield-go-sdk/types"
+	"github.com/cometbft/cometbft/crypto/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// StorageProvider fakes the challenge api of a storage provider, it serves the segments of the objects it stores.
+// Every redundancy index of an object holds the same segments, so that one set of checksums fits all of them.
+type StorageProvider struct {
+	OperatorAddress string
+	mtx             sync.Mutex
+	segments        map[string][][]byte // by object id
+	corrupted       map[string]bool
+	faults          *Faults
+	server          *httptest.Server
+}
+
+// NewStorageProvider starts a storage provider with a random operator address, it injects the sp faults of faults if
+// not nil.
+func NewStorageProvider(faults *Faults) *StorageProvider {
+	sp := &StorageProvider{
+		OperatorAddress: sdk.AccAddress(secp256k1.GenPrivKey().PubKey().Address()).String(),
+		segments:        make(map[string][][]byte),
+		corrupted:       make(map[string]bool),
+		faults:          faults,
+	}
+	sp.server = httptest.NewServer(http.HandlerFunc(sp.challenge))
+	return sp
+}
+
+// Endpoint is the endpoint of the storage provider registered on chain.
+func (sp *StorageProvider) Endpoint() string {
+	return sp.server.URL
+}
+
+func (sp *StorageProvider) Close() {
+	sp.server.Close()
+}
+
+// PutObject stores the segments of the object and returns its checksums as sealed on chain, the integrity hash of
+// the primary pieces first.
+func (sp *StorageProvider) PutObject(objectId string, segments [][]byte) [][]byte {
+	sp.mtx.Lock()
+	defer sp.mtx.Unlock()
+	sp.segments[objectId] = segments
+	integrityHash := hash.GenerateChecksum(bytes.Join(pieceHashes(segments), nil))
+	checksums := make([][]byte, 0, RedundantDataChunkNum+RedundantParityChunkNum+1)
+	for i := 0; i < cap(checksums); i++ {
+		checksums = append(checksums, integrityHash)
+	}
+	return checksums
+}
+
+// Corrupt makes the storage provider serve altered segments of the object, so that its challenges succeed.
+func (sp *StorageProvider) Corrupt(objectId string) {
+	sp.mtx.Lock()
+	defer sp.mtx.Unlock()
+	sp.corrupted[objectId] = true
+}
+
+func pieceHashes(segments [][]byte) [][]byte {
+	hashes := make([][]byte, 0, len(segments))
+	for _, segment := range segments {
+		hashes = append(hashes, hash.GenerateChecksum(segment))
+	}
+	return hashes
+}
+
+// challenge serves the v2 challenge api, the request is not authenticated.
+func (sp *StorageProvider) challenge(w http.ResponseWriter, r *http.Request) {
+	objectId := r.Header.Get(types.HTTPHeaderObjectID)
+	pieceIndex, err := strconv.Atoi(r.Header.Get(types.HTTPHeaderPieceIndex))
+	if err != nil {
+		http.Error(w, "invalid piece index", http.StatusBadRequest)
+		return
+	}
+	sp.mtx.Lock()
+	segments, ok := sp.segments[objectId]
+	corrupted := sp.corrupted[objectId]
+	sp.mtx.Unlock()
+	if !ok || pieceIndex < 0 || pieceIndex >= len(segments) {
+		http.Error(w, "no such piece", http.StatusNotFound)
+		return
+	}
+
+	piece := segments[pieceIndex]
+	if corrupted {
+		piece = append([]byte("corrupted"), piece...)
+	}
+	hashes := make([]string, 0, len(segments))
+	for _, h := range pieceHashes(segments) {
+		hashes = append(hashes, hex.EncodeToString(h))
+	}
+	var body bytes.Buffer
+	_ = xml.NewEncoder(&body).Encode(types.ChallengeV2Result{
+		Version:         "1.0",
+		ObjectID:        objectId,
+		RedundancyIndex: r.Header.Get(types.HTTPHeaderRedundancyIndex),
+		PieceIndex:      strconv.Itoa(pieceIndex),
+		IntegrityHash:   hex.EncodeToString(hash.GenerateChecksum(bytes.Join(pieceHashes(segments), nil))),
+		PieceHash:       strings.Join(hashes, ","),
+		PieceData:       hex.EncodeToString(piece),
+	})
+	response := body.Bytes()
+	if sp.faults.corruptSP() {
+		// a response cut short cannot be decoded, unlike a corrupted piece it is no proof against the sp
+		response = response[:len(response)/2]
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write(response)
+}
@@ -0,0 +1,13 @@
+package economics
+
+import "time"
+
+const (
+	EventAttestChallenge = "greenfield.challenge.EventAttestChallenge"
+	EventStartChallenge  = "greenfield.challenge.EventStartChallenge"
+
+	// MaxReportChallenges bounds the challenges listed by a report
+	MaxReportChallenges = 1000
+
+	ReportTimeout = 30 * time.Second
+)
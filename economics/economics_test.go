@@ -0,0 +1,111 @@
+package economics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	abci "github.com/cometbft/cometbft/abci/types"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+	"github.com/stretchr/testify/require"
+)
+
+const challenger = "0xchallenger"
+
+func event(eventType string, attrs ...string) abci.Event {
+	e := abci.Event{Type: eventType}
+	for i := 0; i < len(attrs); i += 2 {
+		e.Attributes = append(e.Attributes, abci.EventAttribute{Key: attrs[i], Value: attrs[i+1]})
+	}
+	return e
+}
+
+func TestParseEntries(t *testing.T) {
+	block := &tmtypes.Block{
+		Header: tmtypes.Header{Height: 100, Time: time.Unix(1000, 0)},
+		Data: tmtypes.Data{Txs: tmtypes.Txs{tmtypes.Tx("attest"), tmtypes.Tx("failed"), tmtypes.Tx("other"),
+			tmtypes.Tx("rewarded"), tmtypes.Tx("transfer")}},
+	}
+	blockRes := &ctypes.ResultBlockResults{Height: 100, TxsResults: []*abci.ResponseDeliverTx{
+		{Events: []abci.Event{
+			event("tx", "fee", "5000BNB", "fee_payer", challenger),
+			event(EventAttestChallenge, "challenge_id", `"7"`, "submitter_address", `"`+challenger+`"`,
+				"submitter_reward_amount", `"100"`, "challenger_address", `"0xvalidator"`, "challenger_reward_amount", `"300"`),
+			event("coin_received", "receiver", challenger, "amount", "100BNB"),
+		}},
+		// a failed transaction only pays its fee
+		{Code: 1, Events: []abci.Event{event("tx", "fee", "5000BNB", "fee_payer", challenger)}},
+		// the transactions of other accounts are skipped
+		{Events: []abci.Event{event("tx", "fee", "5000BNB", "fee_payer", "0xother")}},
+		// the challenger reward of an attestation sent by another submitter
+		{Events: []abci.Event{
+			event("tx", "fee", "5000BNB", "fee_payer", "0xother"),
+			event(EventAttestChallenge, "challenge_id", `"8"`, "submitter_address", `"0xother"`,
+				"submitter_reward_amount", `"50"`, "challenger_address", `"`+challenger+`"`, "challenger_reward_amount", `"200"`),
+			event("coin_received", "receiver", challenger, "amount", "200BNB,1stake"),
+		}},
+		// a plain transfer to the challenger, e.g. a top up, is not a reward
+		{Events: []abci.Event{
+			event("tx", "fee", "5000BNB", "fee_payer", "0xoperator"),
+			event("coin_received", "receiver", challenger, "amount", "1000000BNB"),
+		}},
+	}, EndBlockEvents: []abci.Event{
+		event(EventAttestChallenge, "challenge_id", `"9"`, "challenger_address", `"`+challenger+`"`,
+			"challenger_reward_amount", `"40"`),
+		event("coin_received", "receiver", challenger, "amount", "40BNB"),
+	}}
+
+	entries := ParseEntries(block, blockRes, challenger, "BNB")
+	require.Len(t, entries, 4)
+	require.Equal(t, &model.LedgerEntry{TxHash: entries[0].TxHash, Height: 100, BlockTime: 1000, ChallengeId: 7,
+		Kind: model.LedgerKindAttest, Fee: "5000", Reward: "100"}, entries[0])
+	require.Len(t, entries[0].TxHash, 64)
+	require.Equal(t, model.LedgerKindFailed, entries[1].Kind)
+	require.Equal(t, "0", entries[1].Reward)
+	require.Equal(t, uint64(8), entries[2].ChallengeId)
+	require.Equal(t, "0", entries[2].Fee)
+	require.Equal(t, "200", entries[2].Reward)
+	// the reward of the end block events is keyed by the block and the challenge
+	require.Equal(t, &model.LedgerEntry{TxHash: endBlockEntryHash(100, 9), Height: 100, BlockTime: 1000, ChallengeId: 9,
+		Kind: model.LedgerKindAttest, Fee: "0", Reward: "40"}, entries[3])
+	require.Len(t, entries[3].TxHash, 64)
+}
+
+func TestParseRewards(t *testing.T) {
+	events := []abci.Event{
+		event(EventAttestChallenge, "challenge_id", `"7"`, "submitter_address", `"`+challenger+`"`,
+			"submitter_reward_amount", `"100"`, "challenger_address", `"`+challenger+`"`, "challenger_reward_amount", `"300"`),
+		event(EventAttestChallenge, "challenge_id", `"8"`, "submitter_address", `"0xother"`, "submitter_reward_amount", `"50"`),
+		event("coin_received", "receiver", challenger, "amount", "1000BNB"),
+	}
+	rewards := ParseRewards(events, challenger)
+	require.Len(t, rewards, 1)
+	require.Equal(t, uint64(7), rewards[0].ChallengeId)
+	require.Equal(t, "400", rewards[0].Amount.String())
+}
+
+func TestBuildReport(t *testing.T) {
+	entries := []*model.LedgerEntry{
+		{ChallengeId: 7, Kind: model.LedgerKindSubmit, Fee: "50", Reward: "0"},
+		{ChallengeId: 7, Kind: model.LedgerKindAttest, Fee: "0", Reward: "80"},
+		{ChallengeId: 8, Kind: model.LedgerKindAttest, Fee: "50", Reward: "20"},
+		{Kind: model.LedgerKindOther, Fee: "10", Reward: "0"},
+		// a top up recorded by an older version is not a reward
+		{Kind: model.LedgerKindOther, Fee: "0", Reward: "1000"},
+	}
+
+	report := BuildReport(entries, 0, 100, "BNB", true)
+	require.Equal(t, "110", report.FeesSpent.String())
+	require.Equal(t, "100", report.RewardsEarned.String())
+	require.Equal(t, "-10", report.Net.String())
+	require.Equal(t, "0", report.ByKind[model.LedgerKindOther].RewardsEarned.String())
+	require.Equal(t, 2, report.ByKind[model.LedgerKindAttest].Txs)
+	require.Equal(t, "50", report.ByKind[model.LedgerKindAttest].Net().String())
+	require.Equal(t, 2, report.Challenges)
+	require.Equal(t, 1, report.ProfitableChallenges)
+	require.Equal(t, uint64(8), report.ChallengeTotals[0].ChallengeId)
+	require.Equal(t, "30", report.ChallengeTotals[1].Net.String())
+
+	require.Empty(t, BuildReport(entries, 0, 100, "BNB", false).ChallengeTotals)
+}
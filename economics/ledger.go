@@ -0,0 +1,134 @@
+package economics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/tmhash"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Reward is the reward of an attested challenge paid to the challenger, as the submitter of the attestation or as the
+// challenger.
+type Reward struct {
+	ChallengeId uint64
+	Amount      sdkmath.Int
+}
+
+// ParseRewards returns the rewards of the challenges attested by events which were paid to address. Only the reward
+// amounts of the attestations are read, the other coins credited to address, e.g. a top up, are not rewards.
+func ParseRewards(events []abci.Event, address string) []*Reward {
+	rewards := make([]*Reward, 0)
+	for _, event := range events {
+		if event.Type != EventAttestChallenge {
+			continue
+		}
+		attrs := eventAttributes(event)
+		amount := sdkmath.ZeroInt()
+		for _, role := range []string{"submitter", "challenger"} {
+			if attrs[role+"_address"] != address {
+				continue
+			}
+			if reward, ok := sdkmath.NewIntFromString(attrs[role+"_reward_amount"]); ok {
+				amount = amount.Add(reward)
+			}
+		}
+		if amount.IsZero() {
+			continue
+		}
+		challengeId, _ := strconv.ParseUint(attrs["challenge_id"], 10, 64)
+		rewards = append(rewards, &Reward{ChallengeId: challengeId, Amount: amount})
+	}
+	return rewards
+}
+
+// ParseEntries returns the ledger entries of the transactions of the block which address paid the fee of, or which
+// rewarded address for an attested challenge, e.g. the challenger reward of an attestation sent by another submitter.
+// The rewards of the attestations in the end block events get an entry of their own, keyed by endBlockEntryHash.
+func ParseEntries(block *tmtypes.Block, blockRes *ctypes.ResultBlockResults, address, denom string) []*model.LedgerEntry {
+	entries := make([]*model.LedgerEntry, 0)
+	for i, tx := range blockRes.TxsResults {
+		if i >= len(block.Data.Txs) {
+			break
+		}
+		fee, reward := sdkmath.ZeroInt(), sdkmath.ZeroInt()
+		kind := model.LedgerKindOther
+		var challengeId uint64
+		for _, event := range tx.Events {
+			attrs := eventAttributes(event)
+			switch event.Type {
+			case sdk.EventTypeTx:
+				if attrs[sdk.AttributeKeyFeePayer] == address {
+					fee = fee.Add(amountOf(attrs[sdk.AttributeKeyFee], denom))
+				}
+			case EventAttestChallenge, EventStartChallenge:
+				if id, err := strconv.ParseUint(attrs["challenge_id"], 10, 64); err == nil && challengeId == 0 {
+					challengeId = id
+					kind = model.LedgerKindAttest
+					if event.Type == EventStartChallenge {
+						kind = model.LedgerKindSubmit
+					}
+				}
+			}
+		}
+		for _, r := range ParseRewards(tx.Events, address) {
+			reward = reward.Add(r.Amount)
+		}
+		if fee.IsZero() && reward.IsZero() {
+			continue
+		}
+		if tx.Code != 0 {
+			kind = model.LedgerKindFailed
+		}
+		entries = append(entries, &model.LedgerEntry{
+			TxHash:      fmt.Sprintf("%X", block.Data.Txs[i].Hash()),
+			Height:      uint64(block.Height),
+			BlockTime:   block.Time.Unix(),
+			ChallengeId: challengeId,
+			Kind:        kind,
+			Fee:         fee.String(),
+			Reward:      reward.String(),
+		})
+	}
+	for _, r := range ParseRewards(blockRes.EndBlockEvents, address) {
+		entries = append(entries, &model.LedgerEntry{
+			TxHash:      endBlockEntryHash(block.Height, r.ChallengeId),
+			Height:      uint64(block.Height),
+			BlockTime:   block.Time.Unix(),
+			ChallengeId: r.ChallengeId,
+			Kind:        model.LedgerKindAttest,
+			Fee:         sdkmath.ZeroInt().String(),
+			Reward:      r.Amount.String(),
+		})
+	}
+	return entries
+}
+
+// endBlockEntryHash returns the key of the entry of a reward paid by the end block events, which belong to no
+// transaction, in the format of the transaction hashes so that a block recorded twice is counted once.
+func endBlockEntryHash(height int64, challengeId uint64) string {
+	return fmt.Sprintf("%X", tmhash.Sum([]byte(fmt.Sprintf("end_block/%d/%d", height, challengeId))))
+}
+
+func eventAttributes(event abci.Event) map[string]string {
+	attrs := make(map[string]string, len(event.Attributes))
+	for _, attr := range event.Attributes {
+		attrs[string(attr.Key)] = strings.Trim(string(attr.Value), `"`)
+	}
+	return attrs
+}
+
+// amountOf returns the amount of denom in coins, e.g. 5000BNB, 0 if they cannot be parsed.
+func amountOf(coins string, denom string) sdkmath.Int {
+	parsed, err := sdk.ParseCoinsNormalized(coins)
+	if err != nil {
+		return sdkmath.ZeroInt()
+	}
+	return parsed.AmountOf(denom)
+}
@@ -0,0 +1,107 @@
+package economics
+
+import (
+	"sort"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+)
+
+// Totals are the fees spent and the rewards earned by a set of transactions, in the fee denom.
+type Totals struct {
+	Txs           int         `json:"txs"`
+	FeesSpent     sdkmath.Int `json:"fees_spent"`
+	RewardsEarned sdkmath.Int `json:"rewards_earned"`
+}
+
+func newTotals() *Totals {
+	return &Totals{FeesSpent: sdkmath.ZeroInt(), RewardsEarned: sdkmath.ZeroInt()}
+}
+
+// add adds the fee and the reward of entry, the coins received by the transactions of kind other are not rewards of the
+// challenges, e.g. the top ups recorded by older versions, and are left out.
+func (t *Totals) add(entry *model.LedgerEntry) {
+	t.Txs++
+	if fee, ok := sdkmath.NewIntFromString(entry.Fee); ok {
+		t.FeesSpent = t.FeesSpent.Add(fee)
+	}
+	if entry.Kind == model.LedgerKindOther {
+		return
+	}
+	if reward, ok := sdkmath.NewIntFromString(entry.Reward); ok {
+		t.RewardsEarned = t.RewardsEarned.Add(reward)
+	}
+}
+
+// Net returns the rewards earned minus the fees spent.
+func (t *Totals) Net() sdkmath.Int {
+	return t.RewardsEarned.Sub(t.FeesSpent)
+}
+
+// ChallengeTotals are the fees spent on a challenge, submitting and attesting it, and the rewards it earned.
+type ChallengeTotals struct {
+	ChallengeId uint64 `json:"challenge_id"`
+	Totals
+	Net sdkmath.Int `json:"net"`
+}
+
+// Report is the profitability of the challenger account over a window.
+type Report struct {
+	Since int64  `json:"since"`
+	Until int64  `json:"until"`
+	Denom string `json:"denom"`
+	Totals
+	Net    sdkmath.Int        `json:"net"`
+	ByKind map[string]*Totals `json:"by_kind"`
+	// Challenges counts the challenges the challenger paid for or was rewarded by, the profitable ones earned more
+	// than they cost
+	Challenges           int `json:"challenges"`
+	ProfitableChallenges int `json:"profitable_challenges"`
+	// ChallengeTotals lists the latest challenges, up to MaxReportChallenges, if they were asked for
+	ChallengeTotals []*ChallengeTotals `json:"challenge_totals,omitempty"`
+}
+
+// BuildReport sums the ledger entries of the window by kind and by challenge.
+func BuildReport(entries []*model.LedgerEntry, since, until int64, denom string, withChallenges bool) *Report {
+	report := &Report{
+		Since:  since,
+		Until:  until,
+		Denom:  denom,
+		Totals: *newTotals(),
+		ByKind: make(map[string]*Totals),
+	}
+	challenges := make(map[uint64]*ChallengeTotals)
+	for _, entry := range entries {
+		report.Totals.add(entry)
+		if report.ByKind[entry.Kind] == nil {
+			report.ByKind[entry.Kind] = newTotals()
+		}
+		report.ByKind[entry.Kind].add(entry)
+		if entry.ChallengeId == 0 {
+			continue
+		}
+		if challenges[entry.ChallengeId] == nil {
+			challenges[entry.ChallengeId] = &ChallengeTotals{ChallengeId: entry.ChallengeId, Totals: *newTotals()}
+		}
+		challenges[entry.ChallengeId].add(entry)
+	}
+	report.Net = report.Totals.Net()
+
+	report.Challenges = len(challenges)
+	for _, c := range challenges {
+		c.Net = c.Totals.Net()
+		if c.Net.IsPositive() {
+			report.ProfitableChallenges++
+		}
+		if withChallenges {
+			report.ChallengeTotals = append(report.ChallengeTotals, c)
+		}
+	}
+	sort.Slice(report.ChallengeTotals, func(i, j int) bool {
+		return report.ChallengeTotals[i].ChallengeId > report.ChallengeTotals[j].ChallengeId
+	})
+	if len(report.ChallengeTotals) > MaxReportChallenges {
+		report.ChallengeTotals = report.ChallengeTotals[:MaxReportChallenges]
+	}
+	return report
+}
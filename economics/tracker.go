@@ -0,0 +1,87 @@
+package economics
+
+import (
+	"context"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/common"
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+)
+
+// DataProvider saves and lists the ledger entries.
+type DataProvider interface {
+	SaveLedgerEntries(ctx context.Context, entries []*model.LedgerEntry) error
+	ListLedgerEntries(ctx context.Context, since int64) ([]*model.LedgerEntry, error)
+}
+
+// AddressProvider returns the address of the challenger account.
+type AddressProvider interface {
+	GetAddr() string
+}
+
+// Tracker records the fees paid and the rewards credited to the challenger account from the polled blocks, and reports
+// its profitability.
+type Tracker struct {
+	cfg           *config.EconomicsConfig
+	denom         string
+	account       AddressProvider
+	dataProvider  DataProvider
+	metricService *metrics.MetricService
+}
+
+func NewTracker(cfg *config.EconomicsConfig, denom string, account AddressProvider, dataProvider DataProvider,
+	metricService *metrics.MetricService,
+) *Tracker {
+	return &Tracker{
+		cfg:           cfg,
+		denom:         denom,
+		account:       account,
+		dataProvider:  dataProvider,
+		metricService: metricService,
+	}
+}
+
+// Record saves the ledger entries of a polled block, a block recorded twice is counted once.
+func (t *Tracker) Record(ctx context.Context, block *tmtypes.Block, blockRes *ctypes.ResultBlockResults) error {
+	return t.dataProvider.SaveLedgerEntries(ctx, ParseEntries(block, blockRes, t.account.GetAddr(), t.denom))
+}
+
+// Report reports the profitability of the blocks produced since, with the totals of each challenge if asked for.
+func (t *Tracker) Report(ctx context.Context, since, now time.Time, withChallenges bool) (*Report, error) {
+	entries, err := t.dataProvider.ListLedgerEntries(ctx, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	return BuildReport(entries, since.Unix(), now.Unix(), t.denom, withChallenges), nil
+}
+
+// ReportLoop exposes the profitability over the configured window as metrics every report interval.
+func (t *Tracker) ReportLoop(ctx context.Context) {
+	for {
+		now := time.Now()
+		reportCtx, cancel := context.WithTimeout(ctx, ReportTimeout)
+		report, err := t.Report(reportCtx, now.Add(-t.cfg.Window()), now, false)
+		cancel()
+		if err != nil {
+			logging.Logger.Errorf("failed to compute the economics report, err=%+v", err.Error())
+		} else {
+			for _, kind := range []string{model.LedgerKindAttest, model.LedgerKindSubmit, model.LedgerKindFailed,
+				model.LedgerKindOther} {
+				totals := report.ByKind[kind]
+				if totals == nil {
+					totals = newTotals()
+				}
+				t.metricService.SetEconomics(kind, totals.FeesSpent, totals.RewardsEarned)
+			}
+			t.metricService.SetEconomicsNet(report.Net, report.Challenges, report.ProfitableChallenges)
+		}
+		if !common.Sleep(ctx, t.cfg.ReportInterval()) {
+			return
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsChallengeAttested(t *testing.T) {
+	e := &Executor{}
+	e.attested.Store(&attestedChallenges{challengeIds: map[uint64]struct{}{7: {}}, queriedAt: time.Now()})
+
+	// the cached challenges are used until they are older than AttestedChallengesMaxAge
+	attested, err := e.IsChallengeAttested(7)
+	require.NoError(t, err)
+	require.True(t, attested)
+	attested, err = e.IsChallengeAttested(8)
+	require.NoError(t, err)
+	require.False(t, attested)
+}
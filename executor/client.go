@@ -2,8 +2,13 @@ package executor
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"sync"
 
+	"github.com/bnb-chain/greenfield-challenger/common"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/version"
 	gnfdclient "github.com/bnb-chain/greenfield-go-sdk/client"
 	"github.com/bnb-chain/greenfield-go-sdk/types"
 	"github.com/bnb-chain/greenfield/sdk/client"
@@ -12,6 +17,24 @@ import (
 
 type JsonRpcClient = *jsonrpcclient.Client
 
+// requestIdTransport attaches the request id carried by the request context to the sp requests, as a header and as
+// a suffix of the user agent. Neither header is signed by the sdk, so they can be set after it signed the request.
+type requestIdTransport struct {
+	base http.RoundTripper
+}
+
+func (t *requestIdTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestId := common.RequestIdFromContext(req.Context())
+	if requestId == "" {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set(HeaderRequestId, requestId)
+	req.Header.Set(HeaderUserAgent, fmt.Sprintf("%s greenfield-challenger/%s request-id/%s",
+		req.Header.Get(HeaderUserAgent), version.AppVersion, requestId))
+	return t.base.RoundTrip(req)
+}
+
 type GnfdCompositeClient struct {
 	gnfdclient.IClient
 	client.TendermintClient
@@ -27,7 +50,10 @@ func NewGnfdCompositClients(rpcAddrs []string, chainId string, account *types.Ac
 	clients := make([]*GnfdCompositeClient, 0)
 	for i := 0; i < len(rpcAddrs); i++ {
 
-		sdkClient, err := gnfdclient.New(chainId, rpcAddrs[i], gnfdclient.Option{DefaultAccount: account})
+		sdkClient, err := gnfdclient.New(chainId, rpcAddrs[i], gnfdclient.Option{
+			DefaultAccount: account,
+			Transport:      &requestIdTransport{base: http.DefaultTransport},
+		})
 		if err != nil {
 			panic(err)
 		}
@@ -83,3 +109,14 @@ func getClientBlockHeight(clientChan chan *GnfdCompositeClient, wg *sync.WaitGro
 	client.Height = latestHeight
 	clientChan <- client
 }
+
+// Close stops the tendermint clients which are still running, e.g. subscribed to events.
+func (gc *GnfdCompositeClients) Close() {
+	for _, c := range gc.clients {
+		if c.TmClient.IsRunning() {
+			if err := c.TmClient.Stop(); err != nil {
+				logging.ExecutorLogger.Errorf("failed to stop tendermint client, err=%+v", err.Error())
+			}
+		}
+	}
+}
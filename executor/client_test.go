@@ -0,0 +1,45 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/common"
+)
+
+func TestRequestIdTransport(t *testing.T) {
+	headers := make(chan http.Header, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: &requestIdTransport{base: http.DefaultTransport}}
+
+	requestId := common.RequestId(42)
+	require.True(t, strings.HasPrefix(requestId, "42-"))
+	req, err := http.NewRequestWithContext(common.WithRequestId(context.Background(), requestId), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set(HeaderUserAgent, "Greenfield")
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	header := <-headers
+	require.Equal(t, requestId, header.Get(HeaderRequestId))
+	require.True(t, strings.HasPrefix(header.Get(HeaderUserAgent), "Greenfield greenfield-challenger/"))
+	require.True(t, strings.HasSuffix(header.Get(HeaderUserAgent), " request-id/"+requestId))
+	// the request of the caller is not modified
+	require.Empty(t, req.Header.Get(HeaderRequestId))
+
+	// requests without a request id are sent as they are
+	req, err = http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	res, err = client.Do(req)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	require.Empty(t, (<-headers).Get(HeaderRequestId))
+}
@@ -5,8 +5,9 @@ import (
 )
 
 const (
-	UpdateCachedValidatorsInterval = 1 * time.Minute
 	QueryHeartbeatIntervalInterval = 120 * time.Minute // blockchain challenge heartbeat interval only changed by governance
+	// AttestedChallengesMaxAge is how long the latest attested challenges are reused before they are queried again
+	AttestedChallengesMaxAge = 10 * time.Second
 
 	VotePoolBroadcastMethodName   = "broadcast_vote"
 	VotePoolBroadcastParameterKey = "vote"
@@ -14,4 +15,23 @@ const (
 	VotePoolQueryMethodName         = "query_vote"
 	VotePoolQueryParameterEventType = "event_type"
 	VotePoolQueryParameterEventHash = "event_hash"
+
+	// VotePoolEventTypeChallenge labels the votepool metrics of the challenge votes
+	VotePoolEventTypeChallenge = "data_availability_challenge"
+
+	// the json-rpc error codes of the node, the requests it cannot handle and the errors returned by its handlers
+	RpcCodeParseError     = -32700
+	RpcCodeInvalidRequest = -32600
+	RpcCodeMethodNotFound = -32601
+	RpcCodeInvalidParams  = -32602
+	RpcCodeInternalError  = -32603
+
+	// HeaderRequestId carries the correlation id of a challenge in the sp requests
+	HeaderRequestId = "X-Request-Id"
+	HeaderUserAgent = "User-Agent"
+
+	// KeyKindAll, KeyKindTx and KeyKindBls select the challenger keys handled by the key commands
+	KeyKindAll = "all"
+	KeyKindTx  = "tx"
+	KeyKindBls = "bls"
 )
@@ -1,43 +1,73 @@
 package executor
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"encoding/json"
 	_ "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	sdkmath "cosmossdk.io/math"
+	"github.com/bnb-chain/greenfield-challenger/alert"
 	"github.com/bnb-chain/greenfield-challenger/common"
 	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/health"
 	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
 	"github.com/bnb-chain/greenfield-go-sdk/types"
 	sdktypes "github.com/bnb-chain/greenfield/sdk/types"
 	challengetypes "github.com/bnb-chain/greenfield/x/challenge/types"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
 	coretypes "github.com/cometbft/cometbft/rpc/core/types"
 	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	rpctypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
 	tmtypes "github.com/cometbft/cometbft/types"
 	"github.com/cometbft/cometbft/votepool"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/prysmaticlabs/prysm/crypto/bls/blst"
 	"github.com/spf13/viper"
+	"golang.org/x/sync/singleflight"
 )
 
+// Guard tells whether the challenger may vote and attest on the chain.
+type Guard interface {
+	Allow() error
+}
+
 type Executor struct {
 	clients           GnfdCompositeClients
 	config            *config.Config
 	address           string
 	mtx               sync.RWMutex
-	validators        []*tmtypes.Validator // used to cache validators
-	heartbeatInterval uint64               // used to save challenge heartbeat interval
+	validators        atomic.Pointer[ValidatorSet]         // the cached validators, swapped as a whole
+	validatorsChanged chan struct{}                        // closed when the cached validators change, guarded by mtx
+	validatorsFlight  singleflight.Group                   // shares a query of the validators between the concurrent callers
+	queryValidators   func() ([]*tmtypes.Validator, error) // queries the latest validators, nil to query the chain
+	attested          atomic.Pointer[attestedChallenges]
+	attestedFlight    singleflight.Group // shares a query of the attested challenges between the concurrent callers
+	heartbeatInterval uint64             // used to save challenge heartbeat interval
 	height            uint64
-	BlsPrivKey        []byte
-	BlsPubKey         []byte
+	heightTime        time.Time    // when the height was last queried successfully
+	heightChangedTime time.Time    // when the height was first queried at its value
+	keyMtx            sync.RWMutex // guards the keys and the clients built from them, they change on key rotation
+	privKey           string
+	blsPrivKey        []byte
+	blsPubKey         []byte
+	metricService     *metrics.MetricService
+	guard             Guard // nil if the votes and the attestations are always sent
 }
 
-func NewExecutor(cfg *config.Config) *Executor {
+func NewExecutor(cfg *config.Config, metricService *metrics.MetricService) *Executor {
 	privKey := viper.GetString(config.FlagConfigPrivateKey)
 	if privKey == "" {
 		privKey = getGreenfieldPrivateKey(&cfg.GreenfieldConfig)
@@ -47,18 +77,32 @@ func NewExecutor(cfg *config.Config) *Executor {
 	if blsPrivKeyStr == "" {
 		blsPrivKeyStr = getGreenfieldBlsPrivateKey(&cfg.GreenfieldConfig)
 	}
+	return newExecutor(cfg, privKey, blsPrivKeyStr, metricService)
+}
+
+// NewIdentityExecutor returns an executor voting and attesting with the keys of identity, which are refreshed like the
+// keys of the challenger.
+func NewIdentityExecutor(cfg *config.Config, identity *config.IdentityConfig, metricService *metrics.MetricService) *Executor {
+	identityCfg := *cfg
+	identityCfg.GreenfieldConfig = *cfg.GreenfieldConfig.ForIdentity(identity)
+	privKey := getGreenfieldPrivateKey(&identityCfg.GreenfieldConfig)
+	blsPrivKeyStr := getGreenfieldBlsPrivateKey(&identityCfg.GreenfieldConfig)
+	return newExecutor(&identityCfg, privKey, blsPrivKeyStr, metricService)
+}
 
+func newExecutor(cfg *config.Config, privKey, blsPrivKeyStr string, metricService *metrics.MetricService) *Executor {
 	blsPrivKeyBytes := ethcommon.Hex2Bytes(blsPrivKeyStr)
 	blsPrivKey, err := blst.SecretKeyFromBytes(blsPrivKeyBytes)
 	if err != nil {
-		logging.Logger.Errorf("executor failed to derive bls private key, err=%+v", err.Error())
+		logging.ExecutorLogger.Errorf("executor failed to derive bls private key, err=%+v", err.Error())
+		panic(fmt.Sprintf("bls private key should be a hex encoded 32 bytes bls key, err=%+v", err.Error()))
 	}
 	blsPubKey := blsPrivKey.PublicKey().Marshal()
 
 	account, err := types.NewAccountFromPrivateKey("challenger", privKey)
 	if err != nil {
-		logging.Logger.Errorf("executor failed to initiate with a key manager, err=%+v", err.Error())
-		panic(err)
+		logging.ExecutorLogger.Errorf("executor failed to initiate with a key manager, err=%+v", err.Error())
+		panic(fmt.Sprintf("private key should be a hex encoded 32 bytes secp256k1 key, err=%+v", err.Error()))
 	}
 
 	clients := NewGnfdCompositClients(
@@ -68,83 +112,311 @@ func NewExecutor(cfg *config.Config) *Executor {
 	)
 
 	return &Executor{
-		clients:    clients,
-		address:    account.GetAddress().String(),
-		config:     cfg,
-		mtx:        sync.RWMutex{},
-		BlsPrivKey: blsPrivKeyBytes,
-		BlsPubKey:  blsPubKey,
+		clients:       clients,
+		address:       account.GetAddress().String(),
+		config:        cfg,
+		mtx:           sync.RWMutex{},
+		privKey:       privKey,
+		blsPrivKey:    blsPrivKeyBytes,
+		blsPubKey:     blsPubKey,
+		metricService: metricService,
 	}
 }
 
-func getGreenfieldPrivateKey(cfg *config.GreenfieldConfig) string {
-	if cfg.KeyType == config.KeyTypeAWSPrivateKey {
-		result, err := config.GetSecret(cfg.AWSSecretName, cfg.AWSRegion)
+// SetGuard sets the guard refusing the votes and the attestations on an unsupported chain.
+func (e *Executor) SetGuard(guard Guard) {
+	e.guard = guard
+}
+
+// allow returns why the votes and the attestations are refused, nil if they are not.
+func (e *Executor) allow() error {
+	if e.guard == nil {
+		return nil
+	}
+	return e.guard.Allow()
+}
+
+// observeRpc records the duration of a greenfield rpc request which started at start.
+func (e *Executor) observeRpc(method string, start time.Time) {
+	if e.metricService != nil {
+		e.metricService.ObserveRpcRequest(method, time.Since(start))
+	}
+}
+
+// observeSp records the duration of a storage provider request which started at start.
+func (e *Executor) observeSp(start time.Time) {
+	if e.metricService != nil {
+		e.metricService.ObserveSpRequest(time.Since(start))
+	}
+}
+
+// setSpRequestsInFlight exports the requests in flight to the storage providers.
+func (e *Executor) setSpRequestsInFlight() {
+	if e.metricService != nil {
+		e.metricService.SetSpRequestsInFlight(common.SpRequestsInFlight())
+	}
+}
+
+// Close releases the rpc clients, the executor must not be used afterwards.
+func (e *Executor) Close() {
+	e.keyMtx.RLock()
+	clients := e.clients
+	e.keyMtx.RUnlock()
+	clients.Close()
+}
+
+// getClient returns the client of the rpc node with the highest block.
+func (e *Executor) getClient() *GnfdCompositeClient {
+	e.keyMtx.RLock()
+	clients := e.clients
+	e.keyMtx.RUnlock()
+	return clients.GetClient()
+}
+
+func (e *Executor) GetBlsPrivKey() []byte {
+	e.keyMtx.RLock()
+	defer e.keyMtx.RUnlock()
+	return e.blsPrivKey
+}
+
+func (e *Executor) GetBlsPubKey() []byte {
+	e.keyMtx.RLock()
+	defer e.keyMtx.RUnlock()
+	return e.blsPubKey
+}
+
+// RefreshKeysLoop re-fetches the keys stored in a secret manager, so that rotating them does not need a restart.
+// onBlsKeyChanged is called with the new bls private key when it changes. Keys stored in the config file are never
+// refreshed.
+func (e *Executor) RefreshKeysLoop(ctx context.Context, onBlsKeyChanged func(blsPrivKey []byte) error) {
+	interval := e.config.GreenfieldConfig.KeyRefreshIntervalInSeconds
+	if !e.config.GreenfieldConfig.UsesSecretProvider() || interval == 0 {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := e.refreshKeys(onBlsKeyChanged)
 		if err != nil {
-			logging.Logger.Errorf("executor failed to get aws private key, err=%+v", err.Error())
-			panic(err)
+			logging.ExecutorLogger.Errorf("executor failed to refresh keys, err=%+v", err.Error())
 		}
-		type AwsPrivateKey struct {
-			PrivateKey string `json:"private_key"`
+	}
+}
+
+func (e *Executor) refreshKeys(onBlsKeyChanged func(blsPrivKey []byte) error) error {
+	privKey, err := fetchGreenfieldPrivateKey(&e.config.GreenfieldConfig)
+	if err != nil {
+		return err
+	}
+	blsPrivKeyStr, err := fetchGreenfieldBlsPrivateKey(&e.config.GreenfieldConfig)
+	if err != nil {
+		return err
+	}
+	blsPrivKeyBytes := ethcommon.Hex2Bytes(blsPrivKeyStr)
+
+	e.keyMtx.RLock()
+	privKeyChanged := privKey != e.privKey
+	blsKeyChanged := !bytes.Equal(blsPrivKeyBytes, e.blsPrivKey)
+	e.keyMtx.RUnlock()
+
+	if privKeyChanged {
+		account, err := types.NewAccountFromPrivateKey("challenger", privKey)
+		if err != nil {
+			return fmt.Errorf("rotated private key is invalid, err=%+v", err)
 		}
-		var awsPrivateKey AwsPrivateKey
-		err = json.Unmarshal([]byte(result), &awsPrivateKey)
+		clients := NewGnfdCompositClients(
+			e.config.GreenfieldConfig.RPCAddrs,
+			e.config.GreenfieldConfig.ChainIdString,
+			account,
+		)
+		e.keyMtx.Lock()
+		e.privKey = privKey
+		e.clients = clients
+		e.address = account.GetAddress().String()
+		e.keyMtx.Unlock()
+		logging.ExecutorLogger.Infof("executor rotated private key, address: %s", account.GetAddress().String())
+	}
+
+	if blsKeyChanged {
+		blsPrivKey, err := blst.SecretKeyFromBytes(blsPrivKeyBytes)
 		if err != nil {
-			logging.Logger.Errorf("executor failed to unmarshal aws private key, err=%+v", err.Error())
-			panic(err)
+			return fmt.Errorf("rotated bls private key is invalid, err=%+v", err)
+		}
+		if err = onBlsKeyChanged(blsPrivKeyBytes); err != nil {
+			return err
 		}
-		return awsPrivateKey.PrivateKey
+		e.keyMtx.Lock()
+		e.blsPrivKey = blsPrivKeyBytes
+		e.blsPubKey = blsPrivKey.PublicKey().Marshal()
+		e.keyMtx.Unlock()
+		logging.ExecutorLogger.Infof("executor rotated bls private key, bls public key: %s", hex.EncodeToString(blsPrivKey.PublicKey().Marshal()))
 	}
-	return cfg.PrivateKey
+	return nil
+}
+
+func getGreenfieldPrivateKey(cfg *config.GreenfieldConfig) string {
+	privKey, err := fetchGreenfieldPrivateKey(cfg)
+	if err != nil {
+		logging.ExecutorLogger.Errorf("executor failed to get private key, err=%+v", err.Error())
+		panic(err)
+	}
+	return privKey
 }
 
 func getGreenfieldBlsPrivateKey(cfg *config.GreenfieldConfig) string {
-	if cfg.KeyType == config.KeyTypeAWSPrivateKey {
-		result, err := config.GetSecret(cfg.AWSBlsSecretName, cfg.AWSRegion)
-		if err != nil {
-			panic(err)
-		}
-		type AwsPrivateKey struct {
-			PrivateKey string `json:"bls_private_key"`
+	blsPrivKey, err := fetchGreenfieldBlsPrivateKey(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return blsPrivKey
+}
+
+// Keys are the public parts of the challenger keys.
+type Keys struct {
+	Address   string
+	BlsPubKey string // hex encoded
+}
+
+// LoadKeys looks the challenger keys up the same way as NewExecutor and returns their public parts.
+func LoadKeys(cfg *config.Config) (*Keys, error) {
+	var err error
+	privKey := viper.GetString(config.FlagConfigPrivateKey)
+	if privKey == "" {
+		if privKey, err = fetchGreenfieldPrivateKey(&cfg.GreenfieldConfig); err != nil {
+			return nil, err
 		}
-		var awsBlsPrivateKey AwsPrivateKey
-		err = json.Unmarshal([]byte(result), &awsBlsPrivateKey)
-		if err != nil {
-			panic(err)
+	}
+	blsPrivKeyStr := viper.GetString(config.FlagConfigBlsPrivateKey)
+	if blsPrivKeyStr == "" {
+		if blsPrivKeyStr, err = fetchGreenfieldBlsPrivateKey(&cfg.GreenfieldConfig); err != nil {
+			return nil, err
 		}
-		return awsBlsPrivateKey.PrivateKey
 	}
-	return cfg.BlsPrivateKey
+	if privKey == "" || blsPrivKeyStr == "" {
+		return nil, errors.New("the private key and the bls private key should not be empty")
+	}
+	return (&KeyPair{PrivateKey: privKey, BlsPrivateKey: blsPrivKeyStr}).Public()
+}
+
+func fetchGreenfieldPrivateKey(cfg *config.GreenfieldConfig) (string, error) {
+	if cfg.KeyType == config.KeyTypeKeystore {
+		return loadKeystoreKey(cfg.KeystorePath)
+	}
+	if !cfg.UsesSecretProvider() {
+		// local keys may be configured with a 0x prefix, which Hex2Bytes does not accept
+		return strings.TrimPrefix(cfg.PrivateKey, "0x"), nil
+	}
+	secretName, _ := cfg.KeySecretNames()
+	result, err := getSecret(cfg, secretName)
+	if err != nil {
+		return "", err
+	}
+	type SecretPrivateKey struct {
+		PrivateKey string `json:"private_key"`
+	}
+	var secretPrivateKey SecretPrivateKey
+	err = json.Unmarshal([]byte(result), &secretPrivateKey)
+	if err != nil {
+		return "", err
+	}
+	return secretPrivateKey.PrivateKey, nil
+}
+
+func fetchGreenfieldBlsPrivateKey(cfg *config.GreenfieldConfig) (string, error) {
+	if cfg.KeyType == config.KeyTypeKeystore {
+		return loadKeystoreKey(cfg.BlsKeystorePath)
+	}
+	if !cfg.UsesSecretProvider() {
+		return strings.TrimPrefix(cfg.BlsPrivateKey, "0x"), nil
+	}
+	_, blsSecretName := cfg.KeySecretNames()
+	result, err := getSecret(cfg, blsSecretName)
+	if err != nil {
+		return "", err
+	}
+	type SecretBlsPrivateKey struct {
+		PrivateKey string `json:"bls_private_key"`
+	}
+	var secretBlsPrivateKey SecretBlsPrivateKey
+	err = json.Unmarshal([]byte(result), &secretBlsPrivateKey)
+	if err != nil {
+		return "", err
+	}
+	return secretBlsPrivateKey.PrivateKey, nil
+}
+
+func loadKeystoreKey(path string) (string, error) {
+	passphrase, err := config.KeystorePassphrase()
+	if err != nil {
+		return "", err
+	}
+	return config.LoadKeystoreKey(path, passphrase)
+}
+
+func getSecret(cfg *config.GreenfieldConfig, secretName string) (string, error) {
+	provider, err := config.NewSecretProvider(cfg)
+	if err != nil {
+		return "", err
+	}
+	return provider.GetSecret(secretName)
 }
 
 func (e *Executor) GetBlockAndBlockResultAtHeight(height int64) (*tmtypes.Block, *ctypes.ResultBlockResults, error) {
-	block, err := e.clients.GetClient().TmClient.Block(context.Background(), &height)
+	defer e.observeRpc("block", time.Now())
+	block, err := e.getClient().TmClient.Block(context.Background(), &height)
 	if err != nil {
-		//logging.Logger.Errorf("executor failed to get block at height %d, err=%+v", height, err.Error())
+		//logging.ExecutorLogger.Errorf("executor failed to get block at height %d, err=%+v", height, err.Error())
 		return nil, nil, err
 	}
-	blockResults, err := e.clients.GetClient().TmClient.BlockResults(context.Background(), &height)
+	blockResults, err := e.getClient().TmClient.BlockResults(context.Background(), &height)
 	if err != nil {
-		logging.Logger.Errorf("executor failed to get block results at height %d, err=%+v", height, err.Error())
+		logging.ExecutorLogger.Errorf("executor failed to get block results at height %d, err=%+v", height, err.Error())
 		return nil, nil, err
 	}
 	return block.Block, blockResults, nil
 }
 
 func (e *Executor) GetLatestBlockHeight() (uint64, error) {
-	client := e.clients.GetClient()
+	defer e.observeRpc("latest_block_height", time.Now())
+	client := e.getClient()
 	res, err := client.GetLatestBlockHeight(context.Background())
-	latestHeight := uint64(res)
 	if err != nil {
-		logging.Logger.Errorf("executor failed to get latest block height, err=%s", err.Error())
+		logging.ExecutorLogger.Errorf("executor failed to get latest block height, err=%s", err.Error())
+		return 0, err
 	}
+	latestHeight := uint64(res)
 
+	now := time.Now()
 	e.mtx.Lock()
+	if latestHeight > e.height {
+		// the blocks produced since the height last changed give the block interval
+		if e.height > 0 && !e.heightChangedTime.IsZero() {
+			common.ObserveBlockInterval(now.Sub(e.heightChangedTime) / time.Duration(latestHeight-e.height))
+		}
+		e.heightChangedTime = now
+	}
 	e.height = latestHeight
+	e.heightTime = now
 	e.mtx.Unlock()
 	return latestHeight, nil
 }
 
+// GetNodeVersions returns the version of the greenfield app run by the rpc node and the cometbft version of the node.
+func (e *Executor) GetNodeVersions() (string, string, error) {
+	defer e.observeRpc("node_info", time.Now())
+	nodeInfo, versionInfo, err := e.getClient().IClient.GetNodeInfo(context.Background())
+	if err != nil {
+		logging.ExecutorLogger.Errorf("executor failed to get node info, err=%+v", err.Error())
+		return "", "", err
+	}
+	return versionInfo.Version, nodeInfo.Version, nil
+}
+
 func (e *Executor) GetCachedBlockHeight() (latestHeight uint64) {
 	e.mtx.Lock()
 	cachedHeight := e.height
@@ -152,50 +424,181 @@ func (e *Executor) GetCachedBlockHeight() (latestHeight uint64) {
 	return cachedHeight
 }
 
+// LastHeightUpdate returns when the block height was last queried successfully, the zero time if never.
+func (e *Executor) LastHeightUpdate() time.Time {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	return e.heightTime
+}
+
+// ValidatorSet is a snapshot of the cached validators. It is never modified once cached, a change of the validators
+// caches a new snapshot, so it is read without locking and must not be modified by the readers.
+type ValidatorSet struct {
+	Validators []*tmtypes.Validator
+	UpdatedAt  time.Time // when the validators were queried
+}
+
+// Age returns how long ago the validators were queried.
+func (s *ValidatorSet) Age() time.Duration {
+	return time.Since(s.UpdatedAt)
+}
+
+// CachedValidators returns the snapshot of the cached validators, nil if they were never queried.
+func (e *Executor) CachedValidators() *ValidatorSet {
+	return e.validators.Load()
+}
+
+// ValidatorsChanged returns a channel closed once the cached validators change, e.g. on a validator set update, a
+// subscriber calls it again to wait for the next change.
+func (e *Executor) ValidatorsChanged() <-chan struct{} {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if e.validatorsChanged == nil {
+		e.validatorsChanged = make(chan struct{})
+	}
+	return e.validatorsChanged
+}
+
+// ValidatorsUpdatedAt returns when the cached validators were last updated, the zero time if never.
+func (e *Executor) ValidatorsUpdatedAt() time.Time {
+	if set := e.validators.Load(); set != nil {
+		return set.UpdatedAt
+	}
+	return time.Time{}
+}
+
+// CachedValidator is a validator of the cached validator set, as dumped by the admin api.
+type CachedValidator struct {
+	Address     string `json:"address"`
+	BlsKey      string `json:"bls_key"`
+	VotingPower int64  `json:"voting_power"`
+}
+
+// ValidatorsCache is the cached validator set, as dumped by the admin api.
+type ValidatorsCache struct {
+	UpdatedAt  time.Time         `json:"updated_at,omitempty"`
+	AgeSeconds float64           `json:"age_seconds,omitempty"`
+	Validators []CachedValidator `json:"validators"`
+}
+
+// DumpCache returns the cached validator set and its age.
+func (e *Executor) DumpCache() interface{} {
+	set := e.validators.Load()
+	if set == nil {
+		return ValidatorsCache{Validators: make([]CachedValidator, 0)}
+	}
+	dump := ValidatorsCache{
+		UpdatedAt:  set.UpdatedAt,
+		AgeSeconds: set.Age().Seconds(),
+		Validators: make([]CachedValidator, 0, len(set.Validators)),
+	}
+	for _, validator := range set.Validators {
+		dump.Validators = append(dump.Validators, CachedValidator{
+			Address:     validator.Address.String(),
+			BlsKey:      hex.EncodeToString(validator.BlsKey),
+			VotingPower: validator.VotingPower,
+		})
+	}
+	return dump
+}
+
 func (e *Executor) queryLatestValidators() ([]*tmtypes.Validator, error) {
-	client := e.clients.GetClient().TmClient
+	defer e.observeRpc("validators", time.Now())
+	client := e.getClient().TmClient
 
 	validators, err := client.Validators(context.Background(), nil, nil, nil)
 	if err != nil {
-		logging.Logger.Errorf("executor failed to query the latest validators, err=%+v", err.Error())
+		logging.ExecutorLogger.Errorf("executor failed to query the latest validators, err=%+v", err.Error())
 		return nil, err
 	}
 	return validators.Validators, nil
 }
 
+// QueryCachedLatestValidators returns the cached validators, they are queried and cached if the cache is empty, e.g.
+// at startup. They are shared with the other readers and must not be modified.
 func (e *Executor) QueryCachedLatestValidators() ([]*tmtypes.Validator, error) {
-	e.mtx.Lock()
-	result := make([]*tmtypes.Validator, len(e.validators))
-	if len(e.validators) > 0 {
-		for i, p := range e.validators {
-			v := *p
-			result[i] = &v
-		}
+	if set := e.validators.Load(); set != nil && len(set.Validators) != 0 {
+		return set.Validators, nil
 	}
-	e.mtx.Unlock()
-
-	if len(result) != 0 {
-		return result, nil
+	set, err := e.refreshValidators()
+	if err != nil {
+		return nil, err
 	}
+	return set.Validators, nil
+}
 
-	validators, err := e.queryLatestValidators()
+// QueryFreshValidators returns the cached validators if they were queried within maxAge, they are queried again
+// otherwise, e.g. when the update loop lags behind. An error is returned rather than stale validators.
+func (e *Executor) QueryFreshValidators(maxAge time.Duration) ([]*tmtypes.Validator, error) {
+	if set := e.validators.Load(); set != nil && len(set.Validators) != 0 && set.Age() <= maxAge {
+		return set.Validators, nil
+	}
+	set, err := e.refreshValidators()
 	if err != nil {
 		return nil, err
 	}
-	return validators, nil
+	return set.Validators, nil
 }
 
-func (e *Executor) CacheValidatorsLoop() {
-	ticker := time.NewTicker(UpdateCachedValidatorsInterval)
-	for range ticker.C {
-		validators, err := e.queryLatestValidators()
+// refreshValidators queries the latest validators and caches them, the subscribers are notified if they changed. The
+// callers refreshing concurrently share a single query, so that an empty cache does not send a query per caller.
+func (e *Executor) refreshValidators() (*ValidatorSet, error) {
+	query := e.queryValidators
+	if query == nil {
+		query = e.queryLatestValidators
+	}
+	set, err, _ := e.validatorsFlight.Do("validators", func() (interface{}, error) {
+		validators, err := query()
 		if err != nil {
-			logging.Logger.Errorf("update latest greenfield validators error, err=%+v", err)
-			continue
+			return nil, err
+		}
+		return e.cacheValidators(validators), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return set.(*ValidatorSet), nil
+}
+
+// cacheValidators caches a snapshot of the validators and notifies the subscribers if they changed.
+func (e *Executor) cacheValidators(validators []*tmtypes.Validator) *ValidatorSet {
+	set := &ValidatorSet{Validators: validators, UpdatedAt: time.Now()}
+	previous := e.validators.Swap(set)
+	if previous != nil && sameValidators(previous.Validators, validators) {
+		return set
+	}
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if e.validatorsChanged != nil {
+		close(e.validatorsChanged)
+		e.validatorsChanged = nil
+	}
+	return set
+}
+
+// sameValidators reports whether both sets have the same validators with the same keys and voting powers, in the
+// same order since the validator bitset of an attestation follows it.
+func sameValidators(a, b []*tmtypes.Validator) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i].Address, b[i].Address) || !bytes.Equal(a[i].BlsKey, b[i].BlsKey) ||
+			a[i].VotingPower != b[i].VotingPower {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Executor) CacheValidatorsLoop(ctx context.Context) {
+	for {
+		if !common.Sleep(ctx, common.UpdateValidatorsInterval()) {
+			return
+		}
+		if _, err := e.refreshValidators(); err != nil {
+			logging.ExecutorLogger.Errorf("update latest greenfield validators error, err=%+v", err)
 		}
-		e.mtx.Lock()
-		e.validators = validators
-		e.mtx.Unlock()
 	}
 }
 
@@ -212,58 +615,101 @@ func (e *Executor) GetValidatorsBlsPublicKey() ([]string, error) {
 }
 
 func (e *Executor) QueryInturnAttestationSubmitter() (*challengetypes.QueryInturnAttestationSubmitterResponse, error) {
-	client := e.clients.GetClient()
+	defer e.observeRpc("inturn_attestation_submitter", time.Now())
+	client := e.getClient()
 	res, err := client.InturnAttestationSubmitter(context.Background(), &challengetypes.QueryInturnAttestationSubmitterRequest{})
 	if err != nil {
-		logging.Logger.Errorf("executor failed to get inturn attestation submitter, err=%+v", err.Error())
+		logging.ExecutorLogger.Errorf("executor failed to get inturn attestation submitter, err=%+v", err.Error())
 		return nil, err
 	}
 	return res, nil
 }
 
-func (e *Executor) AttestChallenge(submitterAddress, challengerAddress, spOperatorAddress string, challengeId uint64, objectId sdkmath.Uint, voteResult challengetypes.VoteResult, voteValidatorSet []uint64, VoteAggSignature []byte, txOption sdktypes.TxOption) (bool, error) {
-	client := e.clients.GetClient()
-	logging.Logger.Infof("attest challenge params: submitterAddress=%s, challengerAddress=%s, spOperatorAddress=%s, challengeId=%d, objectId=%s, voteResult=%s, voteValidatorSet=%+v, VoteAggSignature=%+v, txOption=%+v", submitterAddress, challengerAddress, spOperatorAddress, challengeId, objectId.String(), voteResult.String(), voteValidatorSet, VoteAggSignature, txOption)
+// AttestChallenge broadcasts the attest tx and returns its hash, it reports false if the tx was not accepted.
+func (e *Executor) AttestChallenge(submitterAddress, challengerAddress, spOperatorAddress string, challengeId uint64, objectId sdkmath.Uint, voteResult challengetypes.VoteResult, voteValidatorSet []uint64, VoteAggSignature []byte, txOption sdktypes.TxOption) (string, bool, error) {
+	if e.config.PipelineConfig.DryRun {
+		logging.ExecutorLogger.Infof("dry run, not sending the attest transaction of challengeId: %d, spOperatorAddress=%s, "+
+			"objectId=%s, voteResult=%s, voteValidatorSet=%+v", challengeId, spOperatorAddress, objectId.String(),
+			voteResult.String(), voteValidatorSet)
+		return "", true, nil
+	}
+	if err := e.allow(); err != nil {
+		logging.ExecutorLogger.Errorf("refusing to send the attest transaction of challengeId: %d, err=%+v",
+			challengeId, err.Error())
+		return "", false, err
+	}
+	defer e.observeRpc("attest", time.Now())
+	client := e.getClient()
+	logging.ExecutorLogger.Infof("attest challenge params: submitterAddress=%s, challengerAddress=%s, spOperatorAddress=%s, challengeId=%d, objectId=%s, voteResult=%s, voteValidatorSet=%+v, VoteAggSignature=%+v, txOption=%+v", submitterAddress, challengerAddress, spOperatorAddress, challengeId, objectId.String(), voteResult.String(), voteValidatorSet, VoteAggSignature, txOption)
 	res, err := client.AttestChallenge(context.Background(), submitterAddress, challengerAddress, spOperatorAddress, challengeId, objectId, voteResult, voteValidatorSet, VoteAggSignature, txOption)
 	if err != nil {
 		if res == nil {
-			logging.Logger.Infof("attest failed for challengeId: %d, res is nil, err=%s", challengeId, err.Error())
+			logging.ExecutorLogger.Infof("attest failed for challengeId: %d, res is nil, err=%s", challengeId, err.Error())
 		} else {
-			logging.Logger.Infof("challengeId: %d attest failed, code=%d, log=%s, txhash=%s, timestamp: %s, err=%s", challengeId, res.Code, res.RawLog, res.TxHash, time.Now().Format("15:04:05.000000"), err.Error())
+			logging.ExecutorLogger.Infof("challengeId: %d attest failed, code=%d, log=%s, txhash=%s, timestamp: %s, err=%s", challengeId, res.Code, res.RawLog, res.TxHash, time.Now().Format("15:04:05.000000"), err.Error())
+			return res.TxHash, false, err
 		}
-		return false, err
+		return "", false, err
 	}
 	if res.Code != 0 {
-		logging.Logger.Infof("challengeId: %d attest failed, code=%d, log=%s, txhash=%s, timestamp: %s", challengeId, res.Code, res.RawLog, res.TxHash, time.Now().Format("15:04:05.000000"))
-		return false, nil
+		logging.ExecutorLogger.Infof("challengeId: %d attest failed, code=%d, log=%s, txhash=%s, timestamp: %s", challengeId, res.Code, res.RawLog, res.TxHash, time.Now().Format("15:04:05.000000"))
+		return res.TxHash, false, nil
 	}
-	logging.Logger.Infof("challengeId: %d attest succeeded, code=%d, log=%s, txhash=%s, timestamp: %s", challengeId, res.Code, res.RawLog, res.TxHash, time.Now().Format("15:04:05.000000"))
-	return true, nil
+	logging.ExecutorLogger.Infof("challengeId: %d attest succeeded, code=%d, log=%s, txhash=%s, timestamp: %s", challengeId, res.Code, res.RawLog, res.TxHash, time.Now().Format("15:04:05.000000"))
+	return res.TxHash, true, nil
+}
+
+// attestedChallenges are the latest attested challenges, as cached by QueryLatestAttestedChallengeIds.
+type attestedChallenges struct {
+	challengeIds map[uint64]struct{}
+	queriedAt    time.Time
 }
 
+// IsChallengeAttested reports whether the challenge is among the latest attested challenges, they are queried again
+// once older than AttestedChallengesMaxAge, e.g. by the attest monitor, and a single query is shared by the callers.
+func (e *Executor) IsChallengeAttested(challengeId uint64) (bool, error) {
+	attested := e.attested.Load()
+	if attested == nil || time.Since(attested.queriedAt) > AttestedChallengesMaxAge {
+		if _, err, _ := e.attestedFlight.Do("attested", func() (interface{}, error) {
+			return e.QueryLatestAttestedChallengeIds()
+		}); err != nil {
+			return false, err
+		}
+		attested = e.attested.Load()
+	}
+	_, ok := attested.challengeIds[challengeId]
+	return ok, nil
+}
+
+// QueryLatestAttestedChallengeIds queries the latest attested challenges and caches them for IsChallengeAttested.
 func (e *Executor) QueryLatestAttestedChallengeIds() ([]uint64, error) {
-	client := e.clients.GetClient()
+	defer e.observeRpc("latest_attested_challenge_ids", time.Now())
+	client := e.getClient()
 
 	res, err := client.LatestAttestedChallenges(context.Background(), &challengetypes.QueryLatestAttestedChallengesRequest{})
 	if err != nil {
-		logging.Logger.Errorf("executor failed to get latest attested challenge, err=%+v", err.Error())
+		logging.ExecutorLogger.Errorf("executor failed to get latest attested challenge, err=%+v", err.Error())
 		return nil, err
 	}
 
 	var challengeIds []uint64
+	attested := &attestedChallenges{challengeIds: make(map[uint64]struct{}, len(res.GetChallenges())), queriedAt: time.Now()}
 	for _, v := range res.GetChallenges() {
 		challengeIds = append(challengeIds, v.GetId())
+		attested.challengeIds[v.GetId()] = struct{}{}
 	}
+	e.attested.Store(attested)
 
 	return challengeIds, nil
 }
 
 func (e *Executor) queryChallengeHeartbeatInterval() (uint64, error) {
-	client := e.clients.GetClient()
+	defer e.observeRpc("challenge_params", time.Now())
+	client := e.getClient()
 	q := challengetypes.QueryParamsRequest{}
 	res, err := client.ChallengeParams(context.Background(), &q)
 	if err != nil {
-		logging.Logger.Errorf("executor failed to get latest heartbeat interval, err=%+v", err.Error())
+		logging.ExecutorLogger.Errorf("executor failed to get latest heartbeat interval, err=%+v", err.Error())
 		return 0, err
 	}
 
@@ -288,22 +734,41 @@ func (e *Executor) QueryChallengeHeartbeatInterval() (uint64, error) {
 }
 
 func (e *Executor) QueryChallengeSlashCoolingOffPeriod() (uint64, error) {
-	client := e.clients.GetClient()
+	defer e.observeRpc("challenge_params", time.Now())
+	client := e.getClient()
 	params, err := client.ChallengeParams(context.Background(), &challengetypes.QueryParamsRequest{})
 	if err != nil {
-		logging.Logger.Errorf("query challenge params failed, err=%+v", err.Error())
+		logging.ExecutorLogger.Errorf("query challenge params failed, err=%+v", err.Error())
 		return 0, err
 	}
-	logging.Logger.Infof("challenge slash cooling off period: %d", params.Params.SlashCoolingOffPeriod)
+	logging.ExecutorLogger.Infof("challenge slash cooling off period: %d", params.Params.SlashCoolingOffPeriod)
 	return params.Params.SlashCoolingOffPeriod, nil
 }
 
-func (e *Executor) UpdateHeartbeatIntervalLoop() {
+// QueryChallengeParams returns the params of the challenge module, which set the slash amounts and the rewards.
+func (e *Executor) QueryChallengeParams() (*challengetypes.Params, error) {
+	defer e.observeRpc("challenge_params", time.Now())
+	client := e.getClient()
+	res, err := client.ChallengeParams(context.Background(), &challengetypes.QueryParamsRequest{})
+	if err != nil {
+		logging.ExecutorLogger.Errorf("query challenge params failed, err=%+v", err.Error())
+		return nil, err
+	}
+	return &res.Params, nil
+}
+
+func (e *Executor) UpdateHeartbeatIntervalLoop(ctx context.Context) {
 	ticker := time.NewTicker(QueryHeartbeatIntervalInterval)
-	for range ticker.C {
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 		heartbeatInterval, err := e.queryChallengeHeartbeatInterval()
 		if err != nil {
-			logging.Logger.Errorf("update latest heartbeat interval error, err=%+v", err)
+			logging.ExecutorLogger.Errorf("update latest heartbeat interval error, err=%+v", err)
 			continue
 		}
 		e.mtx.Lock()
@@ -312,97 +777,378 @@ func (e *Executor) UpdateHeartbeatIntervalLoop() {
 	}
 }
 
-func (e *Executor) GetHeightLoop() {
-	ticker := time.NewTicker(common.RetryInterval)
-	for range ticker.C {
+func (e *Executor) GetHeightLoop(ctx context.Context) {
+	ticker := time.NewTicker(common.RetryInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		health.Beat(health.LoopHeight)
 		height, err := e.GetLatestBlockHeight()
 		if err != nil {
-			logging.Logger.Errorf("error trying to get current height, err=%+v", err.Error())
+			logging.ExecutorLogger.Errorf("error trying to get current height, err=%+v", err.Error())
+			continue
 		}
-		logging.Logger.Infof("current height=%d", height)
+		logging.ExecutorLogger.Infof("current height=%d", height)
 	}
 }
 
+// GetStorageProviderEndpoint returns the endpoint of the sp, an endpoint override in the config takes precedence over
+// the on-chain endpoint.
 func (e *Executor) GetStorageProviderEndpoint(address string) (string, error) {
-	client := e.clients.GetClient()
+	if endpoint, ok := e.config.GreenfieldConfig.SpEndpointOverride(address); ok {
+		return endpoint, nil
+	}
+	defer e.observeRpc("storage_provider", time.Now())
+	client := e.getClient()
 	spAddr, err := sdk.AccAddressFromHexUnsafe(address)
 	if err != nil {
-		logging.Logger.Errorf("error converting addr from hex unsafe when getting sp endpoint, err=%+v", err.Error())
+		logging.ExecutorLogger.Errorf("error converting addr from hex unsafe when getting sp endpoint, err=%+v", err.Error())
 		return "", err
 	}
 	res, err := client.GetStorageProviderInfo(context.Background(), spAddr)
 	if err != nil {
-		logging.Logger.Errorf("executor failed to query storage provider %s, err=%+v", address, err.Error())
+		logging.ExecutorLogger.Errorf("executor failed to query storage provider %s, err=%+v", address, err.Error())
 		return "", err
 	}
-	logging.Logger.Infof("response res.endpoint %s", res.Endpoint)
+	logging.ExecutorLogger.Infof("response res.endpoint %s", res.Endpoint)
 
 	return res.Endpoint, nil
 }
 
 func (e *Executor) GetObjectInfoChecksums(objectId string) ([][]byte, error) {
-	client := e.clients.GetClient()
+	defer e.observeRpc("head_object", time.Now())
+	client := e.getClient()
 
 	res, err := client.HeadObjectByID(context.Background(), objectId)
 	if err != nil {
-		logging.Logger.Errorf("executor failed to query storage client for objectId %s, err=%+v", objectId, err.Error())
+		logging.ExecutorLogger.Errorf("executor failed to query storage client for objectId %s, err=%+v", objectId, err.Error())
 		return nil, err
 	}
 	return res.ObjectInfo.GetChecksums(), nil
 }
 
-func (e *Executor) GetChallengeResultFromSp(objectId, endpoint string, segmentIndex, redundancyIndex int) (*types.ChallengeResult, error) {
-	client := e.clients.GetClient()
+// GetObjectDetail returns the object and the global virtual group storing it.
+func (e *Executor) GetObjectDetail(objectId string) (*types.ObjectDetail, error) {
+	defer e.observeRpc("head_object", time.Now())
+	client := e.getClient()
+
+	res, err := client.HeadObjectByID(context.Background(), objectId)
+	if err != nil {
+		logging.ExecutorLogger.Errorf("executor failed to query object %s, err=%+v", objectId, err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+// ListBucketNames returns the names of the buckets owned by the account, listed by a sp.
+func (e *Executor) ListBucketNames(account string) ([]string, error) {
+	defer e.observeRpc("list_buckets", time.Now())
+	client := e.getClient()
+
+	res, err := client.ListBuckets(context.Background(), types.ListBucketsOptions{Account: account})
+	if err != nil {
+		logging.ExecutorLogger.Errorf("executor failed to list the buckets of account %s, err=%+v", account, err.Error())
+		return nil, err
+	}
+	names := make([]string, 0, len(res.Buckets))
+	for _, bucket := range res.Buckets {
+		if bucket.Removed || bucket.BucketInfo == nil {
+			continue
+		}
+		names = append(names, bucket.BucketInfo.BucketName)
+	}
+	return names, nil
+}
+
+// ListObjectIds returns the ids of up to limit sealed objects of the bucket, listed by its primary sp.
+func (e *Executor) ListObjectIds(bucketName string, limit uint64) ([]string, error) {
+	defer e.observeRpc("list_objects", time.Now())
+	client := e.getClient()
+
+	res, err := client.ListObjects(context.Background(), bucketName, types.ListObjectsOptions{MaxKeys: limit})
+	if err != nil {
+		logging.ExecutorLogger.Errorf("executor failed to list the objects of bucket %s, err=%+v", bucketName, err.Error())
+		return nil, err
+	}
+	objectIds := make([]string, 0, len(res.Objects))
+	for _, object := range res.Objects {
+		if object.Removed || object.ObjectInfo == nil || object.ObjectInfo.ObjectStatus != storagetypes.OBJECT_STATUS_SEALED {
+			continue
+		}
+		objectIds = append(objectIds, strconv.FormatUint(object.ObjectInfo.Id, 10))
+	}
+	return objectIds, nil
+}
+
+// GetStorageProviderAddresses maps the ids of the storage providers to their operator addresses.
+func (e *Executor) GetStorageProviderAddresses() (map[uint32]string, error) {
+	defer e.observeRpc("storage_providers", time.Now())
+	client := e.getClient()
+
+	sps, err := client.ListStorageProviders(context.Background(), false)
+	if err != nil {
+		logging.ExecutorLogger.Errorf("executor failed to list the storage providers, err=%+v", err.Error())
+		return nil, err
+	}
+	addresses := make(map[uint32]string, len(sps))
+	for _, sp := range sps {
+		addresses[sp.Id] = sp.OperatorAddress
+	}
+	return addresses, nil
+}
+
+// SubmitChallenge broadcasts a challenge of a piece of the object stored by the sp and returns the tx hash, the piece
+// is picked by the chain if randomIndex is set. In dry run the challenge is only logged.
+func (e *Executor) SubmitChallenge(spOperatorAddress, bucketName, objectName string, segmentIndex uint32, randomIndex bool) (string, error) {
+	if e.config.PipelineConfig.DryRun {
+		logging.ExecutorLogger.Infof("dry run, not submitting the challenge of object %s/%s, spOperatorAddress=%s, "+
+			"segmentIndex=%d, randomIndex=%t", bucketName, objectName, spOperatorAddress, segmentIndex, randomIndex)
+		return "", nil
+	}
+	defer e.observeRpc("submit_challenge", time.Now())
+	client := e.getClient()
+
+	feeAmount, ok := sdkmath.NewIntFromString(e.config.GreenfieldConfig.FeeAmount)
+	if !ok {
+		return "", fmt.Errorf("invalid fee_amount %q", e.config.GreenfieldConfig.FeeAmount)
+	}
+	mode := tx.BroadcastMode_BROADCAST_MODE_SYNC
+	txOpts := sdktypes.TxOption{
+		GasLimit:  e.config.GreenfieldConfig.GasLimit,
+		FeeAmount: sdk.NewCoins(sdk.NewCoin(e.config.GreenfieldConfig.FeeDenom, feeAmount)),
+		Mode:      &mode,
+	}
+	res, err := client.SubmitChallenge(context.Background(), e.GetAddr(), spOperatorAddress, bucketName, objectName,
+		randomIndex, segmentIndex, txOpts)
+	if err != nil {
+		logging.ExecutorLogger.Errorf("executor failed to submit the challenge of object %s/%s, err=%+v", bucketName,
+			objectName, err.Error())
+		return "", err
+	}
+	if res.Code != 0 {
+		return res.TxHash, fmt.Errorf("challenge tx %s failed, code=%d, log=%s", res.TxHash, res.Code, res.RawLog)
+	}
+	logging.ExecutorLogger.Infof("submitted the challenge of object %s/%s, spOperatorAddress=%s, txhash=%s", bucketName,
+		objectName, spOperatorAddress, res.TxHash)
+	return res.TxHash, nil
+}
+
+// GetChallengeResultFromSp challenges the sp, the request id carried by ctx is sent along, see common.WithRequestId.
+// It waits while the requests in flight to the sps are capped, see common.AcquireSpRequest.
+func (e *Executor) GetChallengeResultFromSp(ctx context.Context, objectId, endpoint string, segmentIndex, redundancyIndex int) (*types.ChallengeResult, error) {
+	if err := common.AcquireSpRequest(ctx); err != nil {
+		return nil, err
+	}
+	e.setSpRequestsInFlight()
+	defer func() {
+		common.ReleaseSpRequest()
+		e.setSpRequestsInFlight()
+	}()
+	defer e.observeSp(time.Now())
+	client := e.getClient()
 
 	challengeInfoOpts := types.GetChallengeInfoOptions{
 		Endpoint:     endpoint,
 		UseV2version: true,
 	}
-	challengeInfo, err := client.GetChallengeInfo(context.Background(), objectId, segmentIndex, redundancyIndex, challengeInfoOpts)
+	if spTimeout := common.SpTimeout(); spTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, spTimeout)
+		defer cancel()
+	}
+	challengeInfo, err := client.GetChallengeInfo(ctx, objectId, segmentIndex, redundancyIndex, challengeInfoOpts)
+	if err != nil {
+		logging.ExecutorLogger.Errorf("executor failed to query challenge result info from sp client for objectId %s, request id %s, err=%+v", objectId, common.RequestIdFromContext(ctx), err.Error())
+		return nil, err
+	}
+	// the piece data is streamed from the sp, so it is read before the timeout is released
+	pieceData, err := io.ReadAll(challengeInfo.PieceData)
+	_ = challengeInfo.PieceData.Close()
 	if err != nil {
-		logging.Logger.Errorf("executor failed to query challenge result info from sp client for objectId %s, err=%+v", objectId, err.Error())
+		logging.ExecutorLogger.Errorf("executor failed to read piece data from sp for objectId %s, request id %s, err=%+v", objectId, common.RequestIdFromContext(ctx), err.Error())
 		return nil, err
 	}
+	challengeInfo.PieceData = io.NopCloser(bytes.NewReader(pieceData))
 
 	return &challengeInfo, nil
 }
 
+// eventTypeLabel names eventType in the votepool metrics.
+func eventTypeLabel(eventType votepool.EventType) string {
+	if eventType == votepool.DataAvailabilityChallengeEvent {
+		return VotePoolEventTypeChallenge
+	}
+	return strconv.Itoa(int(eventType))
+}
+
+// observeVotePool records the duration of a votepool request which started at start.
+func (e *Executor) observeVotePool(method string, eventType votepool.EventType, start time.Time) {
+	e.observeRpc(method, start)
+	if e.metricService != nil {
+		e.metricService.ObserveVotePoolRequest(method, eventTypeLabel(eventType), time.Since(start))
+	}
+}
+
 func (e *Executor) QueryVotes(eventType votepool.EventType) ([]*votepool.Vote, error) {
-	client := e.clients.GetClient().JsonRpcClient
+	defer e.observeVotePool("query_votes", eventType, time.Now())
+	client := e.getClient().JsonRpcClient
 
 	queryMap := make(map[string]interface{})
 	queryMap[VotePoolQueryParameterEventType] = int(eventType)
 	queryMap[VotePoolQueryParameterEventHash] = nil
 	var queryVote coretypes.ResultQueryVote
 	_, err := client.Call(context.Background(), VotePoolQueryMethodName, queryMap, &queryVote)
+	if ClassifyVotePoolError(err) == VotePoolRejected {
+		// the votepool is healthy, it cannot serve the query
+		logging.ExecutorLogger.Errorf("votepool rejected the query of the votes for event type %s, err=%+v",
+			eventTypeLabel(eventType), err.Error())
+		if e.metricService != nil {
+			e.metricService.IncVotePoolQueryErrors(eventTypeLabel(eventType))
+		}
+		return nil, fmt.Errorf("%w: %s", common.ErrVotePoolRejected, err.Error())
+	}
 	if err != nil {
-		logging.Logger.Errorf("executor failed to query votes for event type %s, err=%+v", string(eventType), err.Error())
+		logging.ExecutorLogger.Errorf("executor failed to query votes for event type %s, err=%+v", eventTypeLabel(eventType), err.Error())
+		alert.VotePoolFailures.Fail(fmt.Sprintf("query votes error, err=%s", err.Error()))
+		if e.metricService != nil {
+			e.metricService.IncVotePoolQueryErrors(eventTypeLabel(eventType))
+		}
 		return nil, err
 	}
+	alert.VotePoolFailures.Succeed()
+	if e.metricService != nil {
+		e.metricService.ObserveVotePoolQuerySize(eventTypeLabel(eventType), len(queryVote.Votes))
+	}
 	return queryVote.Votes, nil
 }
 
+// VotePoolErrorKind classifies the errors of the votepool, which are handled differently by the callers.
+type VotePoolErrorKind int
+
+const (
+	VotePoolNoError        VotePoolErrorKind = iota
+	VotePoolTransportError                   // the node failed or could not be reached, retried with a backoff
+	VotePoolVoteExists                       // the vote is already in the votepool, not retried
+	VotePoolEventExpired                     // the votepool reported the event expired, its vote is not needed anymore
+	VotePoolRejected                         // the request can never succeed, e.g. a vote with an invalid signature
+)
+
+// votePoolErrors are the errors returned by the votepool in the data of an internal json-rpc error. The votepool of the
+// greenfield nodes accepts a vote again without an error and prunes the expired votes itself, a vote already there and
+// an expired event are still told apart for the nodes which reject them, e.g. behind a proxy.
+var votePoolErrors = map[string]VotePoolErrorKind{
+	"vote already exists":         VotePoolVoteExists,
+	"event expired":               VotePoolEventExpired,
+	"invalid event hash":          VotePoolRejected,
+	"invalid event type":          VotePoolRejected,
+	"invalid public key":          VotePoolRejected,
+	"invalid signature":           VotePoolRejected,
+	"unsupported event type":      VotePoolRejected,
+	"vote is not from validators": VotePoolRejected,
+}
+
+// ClassifyVotePoolError tells the kind of an error of the votepool from the json-rpc error returned by the node. Any
+// other error, e.g. an expired tls certificate or a proxy error, means the node was not reached.
+func ClassifyVotePoolError(err error) VotePoolErrorKind {
+	if err == nil {
+		return VotePoolNoError
+	}
+	var rpcErr *rpctypes.RPCError
+	if !errors.As(err, &rpcErr) {
+		return VotePoolTransportError
+	}
+	switch rpcErr.Code {
+	case RpcCodeParseError, RpcCodeInvalidRequest, RpcCodeMethodNotFound, RpcCodeInvalidParams:
+		return VotePoolRejected
+	case RpcCodeInternalError:
+		if kind, ok := votePoolErrors[rpcErr.Data]; ok {
+			return kind
+		}
+	}
+	return VotePoolTransportError
+}
+
+// BroadcastVote sends the vote to the votepool, a vote already there is not an error. An expired event is reported with
+// common.ErrEventExpired and a rejected vote with common.ErrVotePoolRejected, so that the vote is not retried.
 func (e *Executor) BroadcastVote(v *votepool.Vote) error {
-	client := e.clients.GetClient().JsonRpcClient
+	if e.config.PipelineConfig.DryRun {
+		logging.ExecutorLogger.Infof("dry run, not broadcasting the vote for event hash %s event type %s",
+			hex.EncodeToString(v.EventHash), eventTypeLabel(v.EventType))
+		return nil
+	}
+	if err := e.allow(); err != nil {
+		logging.ExecutorLogger.Errorf("refusing to broadcast the vote for event hash %s, err=%+v",
+			hex.EncodeToString(v.EventHash), err.Error())
+		return err
+	}
+	defer e.observeVotePool("broadcast_vote", v.EventType, time.Now())
+	if e.metricService != nil {
+		e.metricService.IncVotePoolBroadcasts(eventTypeLabel(v.EventType))
+	}
+	client := e.getClient().JsonRpcClient
 	broadcastMap := make(map[string]interface{})
 	broadcastMap[VotePoolBroadcastParameterKey] = *v
 	_, err := client.Call(context.Background(), VotePoolBroadcastMethodName, broadcastMap, &ctypes.ResultBroadcastVote{})
+	switch ClassifyVotePoolError(err) {
+	case VotePoolVoteExists:
+		logging.ExecutorLogger.Infof("vote for event hash %s already in the votepool, err=%+v",
+			hex.EncodeToString(v.EventHash), err.Error())
+		alert.VotePoolFailures.Succeed()
+		return nil
+	case VotePoolEventExpired:
+		// the votepool is healthy, the vote is just not needed anymore
+		if e.metricService != nil {
+			e.metricService.IncVotePoolRejections(eventTypeLabel(v.EventType))
+		}
+		return fmt.Errorf("%w: %s", common.ErrEventExpired, err.Error())
+	case VotePoolRejected:
+		logging.ExecutorLogger.Errorf("votepool rejected the vote for event hash %s event type %s, err=%+v",
+			hex.EncodeToString(v.EventHash), eventTypeLabel(v.EventType), err.Error())
+		if e.metricService != nil {
+			e.metricService.IncVotePoolRejections(eventTypeLabel(v.EventType))
+		}
+		return fmt.Errorf("%w: %s", common.ErrVotePoolRejected, err.Error())
+	}
 	if err != nil {
-		logging.Logger.Errorf("executor failed to broadcast vote to votepool for event hash %s event type %s, err=%+v", string(v.EventHash), string(v.EventType), err.Error())
+		logging.ExecutorLogger.Errorf("executor failed to broadcast vote to votepool for event hash %s event type %s, err=%+v", hex.EncodeToString(v.EventHash), eventTypeLabel(v.EventType), err.Error())
+		alert.VotePoolFailures.Fail(fmt.Sprintf("broadcast vote error, err=%s", err.Error()))
+		if e.metricService != nil {
+			e.metricService.IncVotePoolRejections(eventTypeLabel(v.EventType))
+		}
 		return err
 	}
+	alert.VotePoolFailures.Succeed()
 	return nil
 }
 
 func (e *Executor) GetAddr() string {
+	e.keyMtx.RLock()
+	defer e.keyMtx.RUnlock()
 	return e.address
 }
 
+// GetBalance returns the balance of the challenger account.
+func (e *Executor) GetBalance() (sdkmath.Int, error) {
+	defer e.observeRpc("balance", time.Now())
+	client := e.getClient()
+	balance, err := client.GetAccountBalance(context.Background(), e.GetAddr())
+	if err != nil {
+		logging.ExecutorLogger.Errorf("error getting balance, err=%+v", err.Error())
+		return sdkmath.Int{}, err
+	}
+	return balance.Amount, nil
+}
+
 func (e *Executor) GetNonce() (uint64, error) {
-	client := e.clients.GetClient()
+	defer e.observeRpc("nonce", time.Now())
+	client := e.getClient()
 	account, err := client.GetAccount(context.Background(), e.GetAddr())
 	if err != nil {
-		logging.Logger.Errorf("error getting account, err=%+v", err.Error())
+		logging.ExecutorLogger.Errorf("error getting account, err=%+v", err.Error())
 		return 0, err
 	}
 	nonce := account.GetSequence()
@@ -0,0 +1,265 @@
+package executor
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-go-sdk/types"
+	"github.com/cometbft/cometbft/crypto/tmhash"
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/eth/bls"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/eth/ethsecp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/prysmaticlabs/prysm/crypto/bls/blst"
+)
+
+// KeyPair holds the hex encoded private keys of a challenger. A key is empty when only the other key is handled, e.g.
+// when only the bls key is rotated.
+type KeyPair struct {
+	PrivateKey    string
+	BlsPrivateKey string
+}
+
+// GenerateKeyPair generates the keys of kind, one of KeyKindAll, KeyKindTx and KeyKindBls.
+func GenerateKeyPair(kind string) (*KeyPair, error) {
+	keys := &KeyPair{}
+	if kind != KeyKindBls {
+		privKey, err := ethsecp256k1.GenPrivKey()
+		if err != nil {
+			return nil, err
+		}
+		keys.PrivateKey = hex.EncodeToString(privKey.Bytes())
+	}
+	if kind != KeyKindTx {
+		blsPrivKey, err := bls.GenPrivKey()
+		if err != nil {
+			return nil, err
+		}
+		keys.BlsPrivateKey = hex.EncodeToString(blsPrivKey.Bytes())
+	}
+	return keys, nil
+}
+
+// LoadKeyPair fetches the keys of kind according to the key type of cfg.
+func LoadKeyPair(cfg *config.GreenfieldConfig, kind string) (*KeyPair, error) {
+	var err error
+	keys := &KeyPair{}
+	if kind != KeyKindBls {
+		if keys.PrivateKey, err = fetchGreenfieldPrivateKey(cfg); err != nil {
+			return nil, err
+		}
+	}
+	if kind != KeyKindTx {
+		if keys.BlsPrivateKey, err = fetchGreenfieldBlsPrivateKey(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// KeysExist reports whether any key of kind is already stored according to the key type of cfg. A secret which can
+// not be read is taken as missing, storing it reports the actual error.
+func KeysExist(cfg *config.GreenfieldConfig, kind string) (bool, error) {
+	switch {
+	case cfg.KeyType == config.KeyTypeKeystore:
+		for _, path := range keyLocations(kind, cfg.KeystorePath, cfg.BlsKeystorePath) {
+			if _, err := os.Stat(path); err == nil {
+				return true, nil
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return false, err
+			}
+		}
+		return false, nil
+	case cfg.UsesSecretProvider():
+		provider, err := config.NewSecretProvider(cfg)
+		if err != nil {
+			return false, err
+		}
+		secretName, blsSecretName := cfg.KeySecretNames()
+		for _, name := range keyLocations(kind, secretName, blsSecretName) {
+			if secret, err := provider.GetSecret(name); err == nil && secret != "" {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("key_type %s keeps the keys in the config file, use %s or a secret manager",
+			cfg.KeyType, config.KeyTypeKeystore)
+	}
+}
+
+// StoreKeyPair stores the non-empty keys of keys according to the key type of cfg: the keystores are written encrypted
+// with the keystore passphrase, keeping the previous files as backups, and the secrets get a new version holding the
+// same json as the secrets read by the challenger.
+func StoreKeyPair(cfg *config.GreenfieldConfig, keys *KeyPair) error {
+	if _, err := keys.Public(); err != nil {
+		return err
+	}
+	switch {
+	case cfg.KeyType == config.KeyTypeKeystore:
+		passphrase, err := config.KeystorePassphrase()
+		if err != nil {
+			return err
+		}
+		if keys.PrivateKey != "" {
+			privKey := &ethsecp256k1.PrivKey{Key: ethcommon.Hex2Bytes(keys.PrivateKey)}
+			if err = config.WriteKeystoreKey(cfg.KeystorePath, passphrase, privKey); err != nil {
+				return fmt.Errorf("write keystore %s error, err=%+v", cfg.KeystorePath, err)
+			}
+		}
+		if keys.BlsPrivateKey != "" {
+			blsPrivKey := &bls.PrivKey{Key: ethcommon.Hex2Bytes(keys.BlsPrivateKey)}
+			if err = config.WriteKeystoreKey(cfg.BlsKeystorePath, passphrase, blsPrivKey); err != nil {
+				return fmt.Errorf("write keystore %s error, err=%+v", cfg.BlsKeystorePath, err)
+			}
+		}
+		return nil
+	case cfg.UsesSecretProvider():
+		provider, err := config.NewSecretProvider(cfg)
+		if err != nil {
+			return err
+		}
+		secretName, blsSecretName := cfg.KeySecretNames()
+		if keys.PrivateKey != "" {
+			secret, _ := json.Marshal(map[string]string{"private_key": keys.PrivateKey})
+			if err = provider.PutSecret(secretName, string(secret)); err != nil {
+				return err
+			}
+		}
+		if keys.BlsPrivateKey != "" {
+			secret, _ := json.Marshal(map[string]string{"bls_private_key": keys.BlsPrivateKey})
+			if err = provider.PutSecret(blsSecretName, string(secret)); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("key_type %s keeps the keys in the config file, use %s or a secret manager",
+			cfg.KeyType, config.KeyTypeKeystore)
+	}
+}
+
+// Public returns the public parts of the non-empty keys, validating them.
+func (k *KeyPair) Public() (*Keys, error) {
+	keys := &Keys{}
+	if k.PrivateKey != "" {
+		account, err := types.NewAccountFromPrivateKey("challenger", k.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("private key should be a hex encoded 32 bytes secp256k1 key, err=%+v", err.Error())
+		}
+		keys.Address = account.GetAddress().String()
+	}
+	if k.BlsPrivateKey != "" {
+		blsPrivKey, err := blst.SecretKeyFromBytes(ethcommon.Hex2Bytes(k.BlsPrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("bls private key should be a hex encoded 32 bytes bls key, err=%+v", err.Error())
+		}
+		keys.BlsPubKey = hex.EncodeToString(blsPrivKey.PublicKey().Marshal())
+	}
+	return keys, nil
+}
+
+// BlsProof returns the hex encoded proof of possession of the bls key, the signature of the hash of its public key
+// which the chain checks when the key is registered.
+func (k *KeyPair) BlsProof() (string, error) {
+	blsPrivKey, err := blst.SecretKeyFromBytes(ethcommon.Hex2Bytes(k.BlsPrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("bls private key should be a hex encoded 32 bytes bls key, err=%+v", err.Error())
+	}
+	return hex.EncodeToString(blsPrivKey.Sign(tmhash.Sum(blsPrivKey.PublicKey().Marshal())).Marshal()), nil
+}
+
+// RegistrationTx returns the unsigned json transaction of the validator operator registering the non-empty keys of
+// keys as the challenger keys of the validator, to be signed and broadcast with `gnfd tx sign` and
+// `gnfd tx broadcast`. The description of the validator is left unchanged.
+func (k *KeyPair) RegistrationTx(validatorAddress string) ([]byte, error) {
+	validator, err := sdk.AccAddressFromHexUnsafe(validatorAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validator address %q, err=%+v", validatorAddress, err)
+	}
+	keys, err := k.Public()
+	if err != nil {
+		return nil, err
+	}
+	var challenger sdk.AccAddress
+	if keys.Address != "" {
+		challenger = sdk.MustAccAddressFromHex(keys.Address)
+	}
+	var blsProof string
+	if keys.BlsPubKey != "" {
+		if blsProof, err = k.BlsProof(); err != nil {
+			return nil, err
+		}
+	}
+	description := stakingtypes.NewDescription(stakingtypes.DoNotModifyDesc, stakingtypes.DoNotModifyDesc,
+		stakingtypes.DoNotModifyDesc, stakingtypes.DoNotModifyDesc, stakingtypes.DoNotModifyDesc)
+	msg := stakingtypes.NewMsgEditValidator(validator, description, nil, nil, nil, challenger, keys.BlsPubKey,
+		blsProof)
+	if err = msg.ValidateBasic(); err != nil {
+		return nil, err
+	}
+	anyMsg, err := codectypes.NewAnyWithValue(msg)
+	if err != nil {
+		return nil, err
+	}
+	registry := codectypes.NewInterfaceRegistry()
+	stakingtypes.RegisterInterfaces(registry)
+	return codec.NewProtoCodec(registry).MarshalJSON(&tx.Tx{
+		Body:     &tx.TxBody{Messages: []*codectypes.Any{anyMsg}},
+		AuthInfo: &tx.AuthInfo{Fee: &tx.Fee{}},
+	})
+}
+
+// Sign returns the hex encoded signature of msg by the tx key, and the hex encoded public key verifying it.
+func (k *KeyPair) Sign(msg []byte) (string, string, error) {
+	if k.PrivateKey == "" {
+		return "", "", errors.New("the private key should not be empty")
+	}
+	privKey := &ethsecp256k1.PrivKey{Key: ethcommon.Hex2Bytes(k.PrivateKey)}
+	sig, err := privKey.Sign(msg)
+	if err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(sig), hex.EncodeToString(privKey.PubKey().Bytes()), nil
+}
+
+// VerifySignature checks that signature is the signature of msg by the tx key of address, pubKey being its public key.
+func VerifySignature(address, pubKey, signature string, msg []byte) error {
+	pubKeyBz, err := hex.DecodeString(pubKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key, err=%+v", err)
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature, err=%+v", err)
+	}
+	key := &ethsecp256k1.PubKey{Key: pubKeyBz}
+	if signer := sdk.AccAddress(key.Address()).String(); !strings.EqualFold(signer, address) {
+		return fmt.Errorf("signed by %s instead of %s", signer, address)
+	}
+	if !key.VerifySignature(msg, sig) {
+		return errors.New("the signature does not match")
+	}
+	return nil
+}
+
+// keyLocations returns the locations of the keys of kind among the location of the key and of the bls key.
+func keyLocations(kind, location, blsLocation string) []string {
+	switch kind {
+	case KeyKindTx:
+		return []string{location}
+	case KeyKindBls:
+		return []string{blsLocation}
+	default:
+		return []string{location, blsLocation}
+	}
+}
@@ -0,0 +1,119 @@
+package executor
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/cometbft/cometbft/crypto/tmhash"
+	"github.com/prysmaticlabs/prysm/crypto/bls/blst"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+)
+
+func TestKeyPair_Keystore(t *testing.T) {
+	t.Setenv(config.KeystorePassphraseEnv, "passphrase")
+	dir := t.TempDir()
+	cfg := &config.GreenfieldConfig{
+		KeyType:         config.KeyTypeKeystore,
+		KeystorePath:    filepath.Join(dir, "challenger.key"),
+		BlsKeystorePath: filepath.Join(dir, "challenger_bls.key"),
+	}
+	exist, err := KeysExist(cfg, KeyKindAll)
+	require.NoError(t, err)
+	require.False(t, exist)
+
+	keys, err := GenerateKeyPair(KeyKindAll)
+	require.NoError(t, err)
+	require.NoError(t, StoreKeyPair(cfg, keys))
+	loaded, err := LoadKeyPair(cfg, KeyKindAll)
+	require.NoError(t, err)
+	require.Equal(t, keys, loaded)
+	exist, err = KeysExist(cfg, KeyKindBls)
+	require.NoError(t, err)
+	require.True(t, exist)
+
+	// rotating the bls key keeps the tx key
+	blsKeys, err := GenerateKeyPair(KeyKindBls)
+	require.NoError(t, err)
+	require.Empty(t, blsKeys.PrivateKey)
+	require.NoError(t, StoreKeyPair(cfg, blsKeys))
+	loaded, err = LoadKeyPair(cfg, KeyKindAll)
+	require.NoError(t, err)
+	require.Equal(t, keys.PrivateKey, loaded.PrivateKey)
+	require.Equal(t, blsKeys.BlsPrivateKey, loaded.BlsPrivateKey)
+	backups, err := filepath.Glob(cfg.BlsKeystorePath + ".bak-*")
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+
+	require.Error(t, StoreKeyPair(&config.GreenfieldConfig{KeyType: config.KeyTypeLocalPrivateKey}, keys))
+}
+
+func TestKeyPair_LocalPrefixed(t *testing.T) {
+	keys, err := GenerateKeyPair(KeyKindAll)
+	require.NoError(t, err)
+	cfg := &config.GreenfieldConfig{
+		KeyType:       config.KeyTypeLocalPrivateKey,
+		PrivateKey:    "0x" + keys.PrivateKey,
+		BlsPrivateKey: "0x" + keys.BlsPrivateKey,
+	}
+	loaded, err := LoadKeyPair(cfg, KeyKindAll)
+	require.NoError(t, err)
+	require.Equal(t, keys, loaded)
+}
+
+func TestKeyPair_Registration(t *testing.T) {
+	keys, err := GenerateKeyPair(KeyKindAll)
+	require.NoError(t, err)
+	public, err := keys.Public()
+	require.NoError(t, err)
+
+	proof, err := keys.BlsProof()
+	require.NoError(t, err)
+	pubKeyBz, err := hex.DecodeString(public.BlsPubKey)
+	require.NoError(t, err)
+	pubKey, err := blst.PublicKeyFromBytes(pubKeyBz)
+	require.NoError(t, err)
+	proofBz, err := hex.DecodeString(proof)
+	require.NoError(t, err)
+	sig, err := blst.SignatureFromBytes(proofBz)
+	require.NoError(t, err)
+	require.True(t, sig.Verify(pubKey, tmhash.Sum(pubKeyBz)))
+
+	bz, err := keys.RegistrationTx("0x76d244CE05c3De4BbC6fDd7F56379B145709ade9")
+	require.NoError(t, err)
+	var registration struct {
+		Body struct {
+			Messages []map[string]interface{} `json:"messages"`
+		} `json:"body"`
+	}
+	require.NoError(t, json.Unmarshal(bz, &registration))
+	require.Len(t, registration.Body.Messages, 1)
+	msg := registration.Body.Messages[0]
+	require.Equal(t, "/cosmos.staking.v1beta1.MsgEditValidator", msg["@type"])
+	require.Equal(t, public.Address, msg["challenger_address"])
+	require.Equal(t, public.BlsPubKey, msg["bls_key"])
+	require.Equal(t, proof, msg["bls_proof"])
+
+	_, err = keys.RegistrationTx("validator")
+	require.Error(t, err)
+}
+
+func TestKeyPair_Sign(t *testing.T) {
+	keys, err := GenerateKeyPair(KeyKindTx)
+	require.NoError(t, err)
+	public, err := keys.Public()
+	require.NoError(t, err)
+	signature, pubKey, err := keys.Sign([]byte("bundle"))
+	require.NoError(t, err)
+	require.NoError(t, VerifySignature(public.Address, pubKey, signature, []byte("bundle")))
+
+	require.ErrorContains(t, VerifySignature(public.Address, pubKey, signature, []byte("other")), "does not match")
+	other, err := GenerateKeyPair(KeyKindTx)
+	require.NoError(t, err)
+	otherPublic, err := other.Public()
+	require.NoError(t, err)
+	require.ErrorContains(t, VerifySignature(otherPublic.Address, pubKey, signature, []byte("bundle")), "signed by")
+}
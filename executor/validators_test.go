@@ -0,0 +1,110 @@
+package executor
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	tmtypes "github.com/cometbft/cometbft/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedValidators(t *testing.T) {
+	e := &Executor{}
+	require.Nil(t, e.CachedValidators())
+	require.True(t, e.ValidatorsUpdatedAt().IsZero())
+
+	validators := []*tmtypes.Validator{
+		{Address: []byte{1}, BlsKey: []byte{1}, VotingPower: 10},
+		{Address: []byte{2}, BlsKey: []byte{2}, VotingPower: 10},
+	}
+	changed := e.ValidatorsChanged()
+	e.cacheValidators(validators)
+	require.Equal(t, validators, e.CachedValidators().Validators)
+	require.False(t, e.ValidatorsUpdatedAt().IsZero())
+	<-changed
+
+	// the same validators do not notify the subscribers
+	changed = e.ValidatorsChanged()
+	e.cacheValidators([]*tmtypes.Validator{
+		{Address: []byte{1}, BlsKey: []byte{1}, VotingPower: 10},
+		{Address: []byte{2}, BlsKey: []byte{2}, VotingPower: 10},
+	})
+	select {
+	case <-changed:
+		t.Fatal("the subscribers were notified without a change")
+	default:
+	}
+
+	// a change of voting power does
+	e.cacheValidators([]*tmtypes.Validator{
+		{Address: []byte{1}, BlsKey: []byte{1}, VotingPower: 10},
+		{Address: []byte{2}, BlsKey: []byte{2}, VotingPower: 20},
+	})
+	<-changed
+	require.Equal(t, int64(20), e.CachedValidators().Validators[1].VotingPower)
+}
+
+func TestCachedValidators_ConcurrentReads(t *testing.T) {
+	e := &Executor{}
+	e.cacheValidators([]*tmtypes.Validator{{Address: []byte{1}, VotingPower: 1}})
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				validators, err := e.QueryCachedLatestValidators()
+				require.NoError(t, err)
+				require.Len(t, validators, 1)
+				_ = e.DumpCache()
+			}
+		}()
+	}
+	for i := int64(0); i < 100; i++ {
+		e.cacheValidators([]*tmtypes.Validator{{Address: []byte{1}, VotingPower: i + 1}})
+	}
+	wg.Wait()
+}
+
+func TestQueryFreshValidators(t *testing.T) {
+	validators := []*tmtypes.Validator{{Address: []byte{1}, VotingPower: 1}}
+	queries := 0
+	e := &Executor{queryValidators: func() ([]*tmtypes.Validator, error) {
+		queries++
+		return validators, nil
+	}}
+
+	// an empty cache is queried
+	fresh, err := e.QueryFreshValidators(time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, validators, fresh)
+	require.Equal(t, 1, queries)
+
+	// fresh validators are returned from the cache, without a query
+	require.Less(t, e.CachedValidators().Age(), time.Minute)
+	fresh, err = e.QueryFreshValidators(time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, validators, fresh)
+	require.Equal(t, 1, queries)
+
+	// validators older than maxAge are queried again
+	e.validators.Store(&ValidatorSet{Validators: validators, UpdatedAt: time.Now().Add(-2 * time.Minute)})
+	require.GreaterOrEqual(t, e.CachedValidators().Age(), 2*time.Minute)
+	fresh, err = e.QueryFreshValidators(time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, validators, fresh)
+	require.Equal(t, 2, queries)
+	require.Less(t, e.CachedValidators().Age(), time.Minute)
+
+	// an error is returned rather than stale validators
+	e.validators.Store(&ValidatorSet{Validators: validators, UpdatedAt: time.Now().Add(-2 * time.Minute)})
+	e.queryValidators = func() ([]*tmtypes.Validator, error) {
+		queries++
+		return nil, errors.New("rpc down")
+	}
+	_, err = e.QueryFreshValidators(time.Minute)
+	require.Error(t, err)
+	require.Equal(t, 3, queries)
+}
@@ -0,0 +1,34 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	rpctypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyVotePoolError(t *testing.T) {
+	internalError := func(data string) error {
+		return &rpctypes.RPCError{Code: RpcCodeInternalError, Message: "Internal error", Data: data}
+	}
+	for err, kind := range map[error]VotePoolErrorKind{
+		nil:                                  VotePoolNoError,
+		internalError("vote already exists"): VotePoolVoteExists,
+		internalError("event expired"):       VotePoolEventExpired,
+		internalError("invalid signature"):   VotePoolRejected,
+		fmt.Errorf("post failed: %w", internalError("vote is not from validators")): VotePoolRejected,
+		&rpctypes.RPCError{Code: RpcCodeInvalidParams, Message: "Invalid params"}:   VotePoolRejected,
+		internalError("database closed"):                                            VotePoolTransportError,
+		&rpctypes.RPCError{Code: -32000, Message: "Server error"}:                   VotePoolTransportError,
+		// the errors of the node or of a proxy in front of it are never taken for an expired event
+		errors.New("x509: certificate has expired or is not yet valid"): VotePoolTransportError,
+		errors.New("token expired"):                                     VotePoolTransportError,
+		errors.New("vote already exists"):                               VotePoolTransportError,
+		errors.New("connection refused"):                                VotePoolTransportError,
+		errors.New("post failed: EOF"):                                  VotePoolTransportError,
+	} {
+		require.Equal(t, kind, ClassifyVotePoolError(err), "%v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package federation
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prysmaticlabs/prysm/crypto/bls"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+// Client fetches the signed verify results of the trusted challengers.
+type Client struct {
+	cfg           *config.FederationConfig
+	chainId       string
+	trusted       map[string]bool // the lowercase hex bls public keys whose results are accepted
+	httpClient    *http.Client
+	metricService *metrics.MetricService
+}
+
+func NewClient(cfg *config.FederationConfig, chainId string, metricService *metrics.MetricService) *Client {
+	trusted := make(map[string]bool, len(cfg.TrustedPubKeys))
+	for _, key := range cfg.TrustedPubKeys {
+		trusted[strings.ToLower(key)] = true
+	}
+	return &Client{
+		cfg:           cfg,
+		chainId:       chainId,
+		trusted:       trusted,
+		httpClient:    &http.Client{Timeout: cfg.Timeout()},
+		metricService: metricService,
+	}
+}
+
+// SkipThreshold is the number of peers finding a piece matched to skip requesting it from the sp, 0 if never skipped.
+func (c *Client) SkipThreshold() int {
+	return c.cfg.SkipThreshold
+}
+
+// PeerResults returns the verify results of the event signed by the trusted challengers, the peers which have not
+// verified it yet, are unavailable or serve an invalid result are left out. A key counts once even if several peers
+// serve its results.
+func (c *Client) PeerResults(ctx context.Context, event *model.Event) []PeerResult {
+	var (
+		mtx     sync.Mutex
+		wg      sync.WaitGroup
+		results = make([]PeerResult, 0, len(c.cfg.Peers))
+		seen    = make(map[string]bool, len(c.cfg.Peers))
+	)
+	for _, peer := range c.cfg.Peers {
+		wg.Add(1)
+		go func(peer string) {
+			defer wg.Done()
+			result, found, err := c.peerResult(ctx, peer, event)
+			if err != nil {
+				c.metricService.IncFederationPeerResults(peer, VerdictInvalid)
+				logging.VerifierLogger.Errorf("federation peer %s served an invalid result for challenge %d, err=%+v",
+					peer, event.ChallengeId, err.Error())
+				return
+			}
+			if !found {
+				return
+			}
+			mtx.Lock()
+			defer mtx.Unlock()
+			if !seen[result.PubKey] {
+				seen[result.PubKey] = true
+				results = append(results, *result)
+			}
+		}(peer)
+	}
+	wg.Wait()
+	return results
+}
+
+// peerResult fetches the result of the event from peer, it is not found if the peer has not verified it or is
+// unavailable, and an error if it is not signed by a trusted key.
+func (c *Client) peerResult(ctx context.Context, peer string, event *model.Event) (*PeerResult, bool, error) {
+	url := strings.TrimSuffix(peer, "/") + ResultsPath + strconv.FormatUint(event.ChallengeId, 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.metricService.IncFederationPeerResults(peer, VerdictUnavailable)
+		logging.VerifierLogger.Errorf("federation peer %s is unavailable, err=%+v", peer, err.Error())
+		return nil, false, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		c.metricService.IncFederationPeerResults(peer, VerdictUnavailable)
+		logging.VerifierLogger.Errorf("federation peer %s responded with status %d", peer, resp.StatusCode)
+		return nil, false, nil
+	}
+	signed := &SignedResult{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, MaxResponseSize)).Decode(signed); err != nil {
+		return nil, false, err
+	}
+	result, err := c.verify(event, signed)
+	if err != nil {
+		return nil, false, err
+	}
+	result.Peer = peer
+	return result, true, nil
+}
+
+// verify checks that the signed result is the one of the event, signed by a trusted key.
+func (c *Client) verify(event *model.Event, signed *SignedResult) (*PeerResult, error) {
+	if signed.ChallengeId != event.ChallengeId {
+		return nil, fmt.Errorf("result of challenge %d served for challenge %d", signed.ChallengeId, event.ChallengeId)
+	}
+	pubKey := strings.ToLower(signed.PubKey)
+	if !c.trusted[pubKey] {
+		return nil, fmt.Errorf("untrusted public key %s", signed.PubKey)
+	}
+	verifyResult, err := parseVerifyResult(signed.VerifyResult)
+	if err != nil {
+		return nil, err
+	}
+	pubKeyBz, err := hex.DecodeString(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	blsPubKey, err := bls.PublicKeyFromBytes(pubKeyBz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key, err=%s", err.Error())
+	}
+	signatureBz, err := hex.DecodeString(signed.Signature)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := bls.SignatureFromBytes(signatureBz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature, err=%s", err.Error())
+	}
+	if !signature.Verify(blsPubKey, Digest(event, verifyResult, c.chainId)) {
+		return nil, fmt.Errorf("signature of %s does not verify", signed.PubKey)
+	}
+	return &PeerResult{PubKey: pubKey, VerifyResult: verifyResult}, nil
+}
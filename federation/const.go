@@ -0,0 +1,23 @@
+package federation
+
+import "time"
+
+const (
+	// Domain separates the digests of the verify results from the event hashes signed by the votes, so that a signed
+	// result cannot be replayed as a vote.
+	Domain = "greenfield-challenger/federation/v1"
+
+	ResultsPath = "/v1/federation/results/"
+
+	RequestTimeout = 10 * time.Second
+	// MaxResponseSize bounds the body read from a peer, a signed result is a few hundred bytes
+	MaxResponseSize = 4096
+)
+
+// verdicts of the results of the peers, as counted by the federation_peer_results_total metric
+const (
+	VerdictAgreed      = "agreed"
+	VerdictDisagreed   = "disagreed"
+	VerdictInvalid     = "invalid"
+	VerdictUnavailable = "unavailable"
+)
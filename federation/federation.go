@@ -0,0 +1,46 @@
+package federation
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/vote"
+)
+
+// SignedResult is the verify result of a challenge signed with the bls key of the challenger serving it.
+type SignedResult struct {
+	ChallengeId  uint64 `json:"challenge_id"`
+	VerifyResult string `json:"verify_result"`
+	PubKey       string `json:"pub_key"`
+	Signature    string `json:"signature"`
+}
+
+// PeerResult is a verify result of a trusted challenger whose signature has been checked.
+type PeerResult struct {
+	Peer         string
+	PubKey       string
+	VerifyResult model.VerifyResult
+}
+
+// Digest returns the signed digest of the verify result of the event. It covers the event hash voted with the result,
+// so a result signed for an object or a storage provider other than the ones of the local event does not verify.
+func Digest(event *model.Event, verifyResult model.VerifyResult, chainId string) []byte {
+	e := *event
+	e.VerifyResult = verifyResult
+	h := sha256.New()
+	h.Write([]byte(Domain))
+	h.Write(vote.CalculateEventHash(&e, chainId))
+	return h.Sum(nil)
+}
+
+// parseVerifyResult parses a verified result, unknown is not a result which can be signed.
+func parseVerifyResult(name string) (model.VerifyResult, error) {
+	switch name {
+	case model.HashMatched.String():
+		return model.HashMatched, nil
+	case model.HashMismatched.String():
+		return model.HashMismatched, nil
+	}
+	return model.Unknown, fmt.Errorf("unknown verify result %q", name)
+}
@@ -0,0 +1,113 @@
+package federation
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prysmaticlabs/prysm/crypto/bls/blst"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+	"github.com/bnb-chain/greenfield-challenger/vote"
+)
+
+const chainId = "greenfield_9000-121"
+
+type fakeDataProvider struct {
+	events  map[uint64]*model.Event
+	results map[uint64]*model.VerificationResult
+}
+
+func (p *fakeDataProvider) GetEventByChallengeId(_ context.Context, challengeId uint64) (*model.Event, error) {
+	if event, ok := p.events[challengeId]; ok {
+		return event, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (p *fakeDataProvider) GetVerificationResult(_ context.Context, challengeId uint64) (*model.VerificationResult, error) {
+	if result, ok := p.results[challengeId]; ok {
+		return result, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func newEvent(challengeId uint64, verifyResult model.VerifyResult) *model.Event {
+	return &model.Event{
+		ChallengeId:       challengeId,
+		ObjectId:          "42",
+		SpOperatorAddress: "0x9A4B1c1dBA2A2AAd1F0d6Ac1A2b07D7cF0e3fd71",
+		Status:            model.Verified,
+		VerifyResult:      verifyResult,
+	}
+}
+
+// newPeer serves the results of events signed with a new bls key, it returns the url and the hex public key.
+func newPeer(t *testing.T, provider *fakeDataProvider) (string, string) {
+	key, err := blst.RandKey()
+	require.NoError(t, err)
+	signer := vote.NewVoteSigner(key.Marshal())
+	server := httptest.NewServer(NewServer(&config.FederationConfig{}, chainId, provider, signer).mux)
+	t.Cleanup(server.Close)
+	return server.URL, hex.EncodeToString(key.PublicKey().Marshal())
+}
+
+func TestPeerResults(t *testing.T) {
+	provider := &fakeDataProvider{
+		events: map[uint64]*model.Event{
+			1: newEvent(1, model.HashMatched),
+			2: newEvent(2, model.HashMismatched),
+			3: newEvent(3, model.Unknown),
+			4: newEvent(4, model.HashMatched),
+		},
+		results: map[uint64]*model.VerificationResult{
+			1: {ChallengeId: 1, Outcome: model.OutcomeHashMatched},
+			2: {ChallengeId: 2, Outcome: model.OutcomeSpUnavailable},
+			4: {ChallengeId: 4, Outcome: model.OutcomeFederatedMatched},
+		},
+	}
+	trustedUrl, trustedKey := newPeer(t, provider)
+	untrustedUrl, _ := newPeer(t, provider)
+	cfg := &config.FederationConfig{
+		// the trusted peer is listed twice, its results count once
+		Peers:          []string{trustedUrl, trustedUrl + "/", untrustedUrl, "http://127.0.0.1:1"},
+		TrustedPubKeys: []string{trustedKey},
+	}
+	client := NewClient(cfg, chainId, metrics.NewMetricService(&config.Config{}))
+	ctx := context.Background()
+
+	results := client.PeerResults(ctx, newEvent(1, model.Unknown))
+	require.Len(t, results, 1)
+	require.Equal(t, trustedKey, results[0].PubKey)
+	require.Equal(t, model.HashMatched, results[0].VerifyResult)
+	results = client.PeerResults(ctx, newEvent(2, model.Unknown))
+	require.Len(t, results, 1)
+	require.Equal(t, model.HashMismatched, results[0].VerifyResult)
+
+	// not verified yet, taken from the peers, or unknown
+	require.Empty(t, client.PeerResults(ctx, newEvent(3, model.Unknown)))
+	require.Empty(t, client.PeerResults(ctx, newEvent(4, model.Unknown)))
+	require.Empty(t, client.PeerResults(ctx, newEvent(5, model.Unknown)))
+
+	// the result is signed for the event of the peer, it does not verify for another object
+	event := newEvent(1, model.Unknown)
+	event.ObjectId = "43"
+	require.Empty(t, client.PeerResults(ctx, event))
+}
+
+func TestDigest(t *testing.T) {
+	event := newEvent(1, model.Unknown)
+	matched := Digest(event, model.HashMatched, chainId)
+	require.Equal(t, model.Unknown, event.VerifyResult)
+	require.NotEqual(t, matched, Digest(event, model.HashMismatched, chainId))
+	require.NotEqual(t, matched, Digest(event, model.HashMatched, "greenfield_1017-1"))
+
+	// the digest is never the event hash signed by a vote
+	event.VerifyResult = model.HashMatched
+	require.NotEqual(t, vote.CalculateEventHash(event, chainId), matched)
+}
@@ -0,0 +1,112 @@
+package federation
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+type DataProvider interface {
+	GetEventByChallengeId(ctx context.Context, challengeId uint64) (*model.Event, error)
+	GetVerificationResult(ctx context.Context, challengeId uint64) (*model.VerificationResult, error)
+}
+
+// Signer signs the digests of the verify results, it is the vote signer so the results are signed with the bls key.
+type Signer interface {
+	Sign(data []byte) ([]byte, []byte)
+}
+
+// Server serves the signed verify results of the challenges to the trusted challengers.
+type Server struct {
+	cfg          *config.FederationConfig
+	chainId      string
+	dataProvider DataProvider
+	signer       Signer
+	mux          *http.ServeMux
+}
+
+func NewServer(cfg *config.FederationConfig, chainId string, dataProvider DataProvider, signer Signer) *Server {
+	s := &Server{
+		cfg:          cfg,
+		chainId:      chainId,
+		dataProvider: dataProvider,
+		signer:       signer,
+		mux:          http.NewServeMux(),
+	}
+	s.mux.HandleFunc(ResultsPath, s.getResult)
+	return s
+}
+
+// Start serves the signed verify results, it blocks until ctx is done.
+func (s *Server) Start(ctx context.Context) {
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", s.cfg.Port),
+		Handler:           s.mux,
+		ReadHeaderTimeout: RequestTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		panic(err)
+	}
+}
+
+// getResult serves GET /v1/federation/results/{challenge_id}, the results which are not verified yet, or which were
+// taken from the peers rather than verified, are not found.
+func (s *Server) getResult(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	challengeId, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, ResultsPath), 10, 64)
+	if err != nil {
+		http.Error(w, "unknown path "+r.URL.Path, http.StatusNotFound)
+		return
+	}
+	event, err := s.dataProvider.GetEventByChallengeId(r.Context(), challengeId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, fmt.Sprintf("challenge %d not found", challengeId), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logging.VerifierLogger.Errorf("federation server failed to get event, err=%+v", err.Error())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if event.VerifyResult != model.HashMatched && event.VerifyResult != model.HashMismatched {
+		http.Error(w, fmt.Sprintf("challenge %d not verified", challengeId), http.StatusNotFound)
+		return
+	}
+	// the results taken from the peers are not served, a result is only vouched for by the challengers verifying it
+	result, err := s.dataProvider.GetVerificationResult(r.Context(), challengeId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logging.VerifierLogger.Errorf("federation server failed to get verification result, err=%+v", err.Error())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if result == nil || result.Outcome == model.OutcomeFederatedMatched {
+		http.Error(w, fmt.Sprintf("challenge %d not verified", challengeId), http.StatusNotFound)
+		return
+	}
+	pubKey, signature := s.signer.Sign(Digest(event, event.VerifyResult, s.chainId))
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(&SignedResult{
+		ChallengeId:  challengeId,
+		VerifyResult: event.VerifyResult.String(),
+		PubKey:       hex.EncodeToString(pubKey),
+		Signature:    hex.EncodeToString(signature),
+	})
+}
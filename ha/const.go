@@ -0,0 +1,15 @@
+package ha
+
+import "time"
+
+const (
+	// LeaderLease is the lease held by the leader of the challengers sharing the database
+	LeaderLease = "leader"
+	// ShardLeasePrefix prefixes the index of a shard in the name of its lease, e.g. shard/3
+	ShardLeasePrefix = "shard/"
+	// MemberLeasePrefix prefixes the leases held by the challengers sharing the shards, to count them
+	MemberLeasePrefix = "member/"
+
+	// LeaderPollInterval is how often the loops run by the leader only check whether the challenger is still the leader
+	LeaderPollInterval = time.Second
+)
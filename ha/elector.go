@@ -0,0 +1,167 @@
+package ha
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/common"
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/health"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+type LeaseProvider interface {
+	AcquireLease(ctx context.Context, name, holder string, timeout time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, name, holder string) error
+	GetLease(ctx context.Context, name string) (*model.Lease, error)
+}
+
+// Elector elects the leader among the challengers sharing the database with a lease record. The leader renews the
+// lease every renew interval and considers itself the leader until one renew interval before the lease expires, so
+// that it has stopped by the time a standby can take the lease over, even if it cannot reach the database. The lease
+// expires on the clock of the database and the leader measures its leadership on its own clock from before the
+// renewal, so that the clocks of the instances do not need to agree.
+type Elector struct {
+	cfg           *config.HaConfig
+	instance      string
+	leases        LeaseProvider
+	metricService *metrics.MetricService
+
+	mtx         sync.RWMutex
+	leaderUntil time.Time
+	leader      string // last known holder of the lease, for the logs
+}
+
+func NewElector(cfg *config.HaConfig, leases LeaseProvider, metricService *metrics.MetricService) *Elector {
+	return &Elector{
+		cfg:           cfg,
+		instance:      cfg.Instance(),
+		leases:        leases,
+		metricService: metricService,
+	}
+}
+
+// IsLeader reports whether the challenger is the leader.
+func (e *Elector) IsLeader() bool {
+	return e.isLeaderAt(time.Now())
+}
+
+func (e *Elector) isLeaderAt(now time.Time) bool {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+	return now.Before(e.leaderUntil)
+}
+
+// ElectLoop takes or renews the leader lease every renew interval until ctx is done, the lease is released then so
+// that a standby takes over right away.
+func (e *Elector) ElectLoop(ctx context.Context) {
+	ticker := time.NewTicker(e.cfg.RenewInterval())
+	defer ticker.Stop()
+	for {
+		e.elect(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			e.resign()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// elect tries to take or renew the lease at now. The leadership is kept until it runs out if the database cannot be
+// reached, the lease cannot be taken by another challenger before.
+func (e *Elector) elect(ctx context.Context, now time.Time) {
+	wasLeader := e.isLeaderAt(now)
+	held, err := e.leases.AcquireLease(ctx, LeaderLease, e.instance, e.cfg.LeaseTimeout())
+	if err != nil {
+		logging.Logger.Errorf("failed to acquire the leader lease, err=%+v", err.Error())
+		e.metricService.SetHaLeader(e.isLeaderAt(now))
+		return
+	}
+	e.mtx.Lock()
+	if held {
+		e.leaderUntil = now.Add(e.cfg.LeaseTimeout() - e.cfg.RenewInterval())
+	} else {
+		e.leaderUntil = time.Time{}
+	}
+	e.mtx.Unlock()
+	e.metricService.SetHaLeader(held)
+
+	switch {
+	case held && !wasLeader:
+		logging.Logger.Infof("instance %s is elected leader, running the pipeline", e.instance)
+	case !held && wasLeader:
+		logging.Logger.Warningf("instance %s lost the leader lease, standing by", e.instance)
+	}
+	if !held {
+		e.logLeader(ctx)
+	}
+}
+
+// logLeader logs the holder of the lease when it changes, so that the standbys tell which instance leads.
+func (e *Elector) logLeader(ctx context.Context) {
+	lease, err := e.leases.GetLease(ctx, LeaderLease)
+	if err != nil {
+		logging.Logger.Errorf("failed to get the leader lease, err=%+v", err.Error())
+		return
+	}
+	e.mtx.Lock()
+	changed := lease.Holder != e.leader
+	e.leader = lease.Holder
+	e.mtx.Unlock()
+	if changed {
+		logging.Logger.Infof("instance %s stands by, the leader is %s", e.instance, lease.Holder)
+	}
+}
+
+func (e *Elector) resign() {
+	if !e.IsLeader() {
+		return
+	}
+	e.mtx.Lock()
+	e.leaderUntil = time.Time{}
+	e.mtx.Unlock()
+	e.metricService.SetHaLeader(false)
+	if err := e.leases.ReleaseLease(context.Background(), LeaderLease, e.instance); err != nil {
+		logging.Logger.Errorf("failed to release the leader lease, err=%+v", err.Error())
+		return
+	}
+	logging.Logger.Infof("instance %s released the leader lease", e.instance)
+}
+
+// LeaderOnly returns a loop which runs loop while the challenger is the leader and stands by otherwise. loop is
+// cancelled as soon as the leadership is lost, and run again once it is won back. The standby beats for the loop,
+// so that a healthy standby is live.
+func (e *Elector) LeaderOnly(name string, loop func(ctx context.Context)) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		for {
+			for !e.IsLeader() {
+				health.Beat(name)
+				if !common.Sleep(ctx, LeaderPollInterval) {
+					return
+				}
+			}
+			e.runWhileLeader(ctx, loop)
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}
+}
+
+func (e *Elector) runWhileLeader(ctx context.Context, loop func(ctx context.Context)) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		for e.IsLeader() {
+			if !common.Sleep(leaderCtx, LeaderPollInterval) {
+				return
+			}
+		}
+		cancel()
+	}()
+	loop(leaderCtx)
+}
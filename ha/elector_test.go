@@ -0,0 +1,125 @@
+package ha
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+func newTestElectors(t *testing.T) (*Elector, *Elector) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	model.InitLeaseTable(db)
+	leases := dao.NewLeaseDao(db)
+
+	metricService := metrics.NewMetricService(&config.Config{})
+	a := NewElector(&config.HaConfig{Enabled: true, InstanceId: "a"}, leases, metricService)
+	b := NewElector(&config.HaConfig{Enabled: true, InstanceId: "b"}, leases, metricService)
+	return a, b
+}
+
+// expireLeases lets the leases run out on the clock of the database, as if their holders stopped renewing them.
+func expireLeases(t *testing.T, leases interface{}, holder string) {
+	db := leases.(*dao.LeaseDao).DB
+	require.NoError(t, db.Model(&model.Lease{}).Where("holder = ?", holder).Update("expire_time", 1).Error)
+}
+
+func TestElector_Failover(t *testing.T) {
+	a, b := newTestElectors(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	a.elect(ctx, now)
+	b.elect(ctx, now)
+	require.True(t, a.IsLeader())
+	require.False(t, b.IsLeader())
+	require.Equal(t, "a", b.leader)
+
+	// the leader renews its lease, the standby cannot take it meanwhile
+	now = now.Add(a.cfg.RenewInterval())
+	a.elect(ctx, now)
+	b.elect(ctx, now.Add(a.cfg.LeaseTimeout()-time.Millisecond))
+	require.False(t, b.isLeaderAt(now))
+
+	// the leader stops renewing, it steps down one renew interval before its lease expires
+	require.False(t, a.isLeaderAt(now.Add(a.cfg.LeaseTimeout()-a.cfg.RenewInterval())))
+	later := now.Add(a.cfg.LeaseTimeout() + time.Millisecond)
+	expireLeases(t, a.leases, "a")
+	b.elect(ctx, later)
+	require.True(t, b.isLeaderAt(later))
+	a.elect(ctx, later)
+	require.False(t, a.isLeaderAt(later))
+	require.Equal(t, "b", a.leader)
+}
+
+func TestElector_Resign(t *testing.T) {
+	a, b := newTestElectors(t)
+	ctx := context.Background()
+
+	a.elect(ctx, time.Now())
+	require.True(t, a.IsLeader())
+	a.resign()
+	require.False(t, a.IsLeader())
+
+	// the released lease is taken right away
+	b.elect(ctx, time.Now())
+	require.True(t, b.IsLeader())
+}
+
+func TestElector_LeaderOnly(t *testing.T) {
+	a, _ := newTestElectors(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{}, 1)
+	stopped := make(chan struct{}, 1)
+	loop := a.LeaderOnly("test", func(ctx context.Context) {
+		started <- struct{}{}
+		<-ctx.Done()
+		stopped <- struct{}{}
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		loop(ctx)
+	}()
+
+	// the loop stands by until the challenger is elected
+	select {
+	case <-started:
+		t.Fatal("loop started before the election")
+	case <-time.After(LeaderPollInterval + 100*time.Millisecond):
+	}
+	a.elect(ctx, time.Now())
+	select {
+	case <-started:
+	case <-time.After(2 * LeaderPollInterval):
+		t.Fatal("loop did not start once elected")
+	}
+
+	// and is cancelled once the leadership is lost
+	a.mtx.Lock()
+	a.leaderUntil = time.Time{}
+	a.mtx.Unlock()
+	select {
+	case <-stopped:
+	case <-time.After(2 * LeaderPollInterval):
+		t.Fatal("loop was not cancelled when the leadership was lost")
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,192 @@
+package ha
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+type ShardLeaseProvider interface {
+	AcquireLease(ctx context.Context, name, holder string, timeout time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, name, holder string) error
+	ListLeases(ctx context.Context, prefix string) ([]*model.Lease, error)
+}
+
+// ShardAssigner leases shards of the events to the challenger. Every challenger holds a member lease, so that the
+// challengers know how many they are, and takes an even share of the shards: it takes free or expired shards while it
+// holds less than its share and releases shards while it holds more, e.g. after a challenger joined. The shards are
+// held like the leadership, until one renew interval before their lease expires.
+type ShardAssigner struct {
+	cfg           *config.ShardConfig
+	haCfg         *config.HaConfig
+	instance      string
+	memberLease   string
+	leases        ShardLeaseProvider
+	metricService *metrics.MetricService
+
+	mtx  sync.RWMutex
+	held map[uint64]time.Time // held shards, until when
+}
+
+func NewShardAssigner(cfg *config.ShardConfig, haCfg *config.HaConfig, leases ShardLeaseProvider,
+	metricService *metrics.MetricService,
+) *ShardAssigner {
+	instance := haCfg.Instance()
+	// the instance id may be longer than a lease name
+	hash := sha256.Sum256([]byte(instance))
+	return &ShardAssigner{
+		cfg:           cfg,
+		haCfg:         haCfg,
+		instance:      instance,
+		memberLease:   MemberLeasePrefix + hex.EncodeToString(hash[:16]),
+		leases:        leases,
+		metricService: metricService,
+		held:          make(map[uint64]time.Time),
+	}
+}
+
+// ShardFilter returns the shards held by the challenger.
+func (a *ShardAssigner) ShardFilter() dao.ShardFilter {
+	return dao.ShardFilter{Count: a.cfg.Count, Shards: a.heldAt(time.Now())}
+}
+
+func (a *ShardAssigner) heldAt(now time.Time) []uint64 {
+	a.mtx.RLock()
+	defer a.mtx.RUnlock()
+	shards := make([]uint64, 0, len(a.held))
+	for shard, until := range a.held {
+		if now.Before(until) {
+			shards = append(shards, shard)
+		}
+	}
+	sort.Slice(shards, func(i, j int) bool { return shards[i] < shards[j] })
+	return shards
+}
+
+// AssignLoop renews the held shards and rebalances them every renew interval until ctx is done, the shards are
+// released then so that the other challengers take them over right away.
+func (a *ShardAssigner) AssignLoop(ctx context.Context) {
+	ticker := time.NewTicker(a.haCfg.RenewInterval())
+	defer ticker.Stop()
+	for {
+		if err := a.assign(ctx, time.Now()); err != nil {
+			logging.Logger.Errorf("failed to assign the shards, err=%+v", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			a.releaseAll()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *ShardAssigner) assign(ctx context.Context, now time.Time) error {
+	if _, err := a.leases.AcquireLease(ctx, a.memberLease, a.instance, a.haCfg.LeaseTimeout()); err != nil {
+		return err
+	}
+	// only the leases which have not expired are listed
+	members, err := a.leases.ListLeases(ctx, MemberLeasePrefix)
+	if err != nil {
+		return err
+	}
+	live := len(members)
+	if live == 0 {
+		// the member lease was just taken, it can only be missing if the database clock jumped
+		live = 1
+	}
+	share := int((a.cfg.Count + uint64(live) - 1) / uint64(live))
+
+	shardLeases, err := a.leases.ListLeases(ctx, ShardLeasePrefix)
+	if err != nil {
+		return err
+	}
+	// the holders of the shards whose leases have not expired
+	holders := make(map[string]*model.Lease, len(shardLeases))
+	for _, lease := range shardLeases {
+		holders[lease.Name] = lease
+	}
+
+	// keep the held shards up to the share, the lowest first, and release the others
+	kept := 0
+	for shard := uint64(0); shard < a.cfg.Count; shard++ {
+		lease, ok := holders[shardLeaseName(shard)]
+		if !ok || lease.Holder != a.instance {
+			continue
+		}
+		if kept >= share {
+			a.release(shard)
+			continue
+		}
+		if a.renew(ctx, shard, now) {
+			kept++
+		}
+	}
+	// take the free shards up to the share
+	for shard := uint64(0); shard < a.cfg.Count && kept < share; shard++ {
+		if _, ok := holders[shardLeaseName(shard)]; ok {
+			continue
+		}
+		if a.renew(ctx, shard, now) {
+			logging.Logger.Infof("instance %s took shard %d of %d", a.instance, shard, a.cfg.Count)
+			kept++
+		}
+	}
+	a.metricService.SetHaHeldShards(len(a.heldAt(now)))
+	return nil
+}
+
+// renew takes or renews the lease of the shard, it reports whether the shard is held.
+func (a *ShardAssigner) renew(ctx context.Context, shard uint64, now time.Time) bool {
+	held, err := a.leases.AcquireLease(ctx, shardLeaseName(shard), a.instance, a.haCfg.LeaseTimeout())
+	if err != nil {
+		logging.Logger.Errorf("failed to acquire the lease of shard %d, err=%+v", shard, err.Error())
+		return false
+	}
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if !held {
+		delete(a.held, shard)
+		return false
+	}
+	a.held[shard] = now.Add(a.haCfg.LeaseTimeout() - a.haCfg.RenewInterval())
+	return true
+}
+
+// release stops fetching the events of the shard before its lease is released. The events already fetched are still
+// processed, so the challenger taking the shard over may handle them as well, which only repeats a verification or a
+// vote the votepool takes once.
+func (a *ShardAssigner) release(shard uint64) {
+	a.mtx.Lock()
+	delete(a.held, shard)
+	a.mtx.Unlock()
+	if err := a.leases.ReleaseLease(context.Background(), shardLeaseName(shard), a.instance); err != nil {
+		logging.Logger.Errorf("failed to release the lease of shard %d, err=%+v", shard, err.Error())
+		return
+	}
+	logging.Logger.Infof("instance %s released shard %d of %d", a.instance, shard, a.cfg.Count)
+}
+
+func (a *ShardAssigner) releaseAll() {
+	for _, shard := range a.heldAt(time.Now()) {
+		a.release(shard)
+	}
+	if err := a.leases.ReleaseLease(context.Background(), a.memberLease, a.instance); err != nil {
+		logging.Logger.Errorf("failed to release the member lease, err=%+v", err.Error())
+	}
+	a.metricService.SetHaHeldShards(0)
+}
+
+func shardLeaseName(shard uint64) string {
+	return fmt.Sprintf("%s%d", ShardLeasePrefix, shard)
+}
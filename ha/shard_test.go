@@ -0,0 +1,60 @@
+package ha
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+func TestShardAssigner_Rebalance(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	model.InitLeaseTable(db)
+	leases := dao.NewLeaseDao(db)
+
+	metricService := metrics.NewMetricService(&config.Config{})
+	shardCfg := &config.ShardConfig{Count: 4}
+	a := NewShardAssigner(shardCfg, &config.HaConfig{Enabled: true, InstanceId: "a"}, leases, metricService)
+	b := NewShardAssigner(shardCfg, &config.HaConfig{Enabled: true, InstanceId: "b"}, leases, metricService)
+	ctx := context.Background()
+	now := time.Now()
+
+	// a single challenger takes all shards
+	require.NoError(t, a.assign(ctx, now))
+	require.Equal(t, []uint64{0, 1, 2, 3}, a.heldAt(now))
+
+	// a challenger joining gets its share once the others released theirs
+	require.NoError(t, b.assign(ctx, now))
+	require.Empty(t, b.heldAt(now))
+	require.NoError(t, a.assign(ctx, now))
+	require.Equal(t, []uint64{0, 1}, a.heldAt(now))
+	require.NoError(t, b.assign(ctx, now))
+	require.Equal(t, []uint64{2, 3}, b.heldAt(now))
+	require.Equal(t, dao.ShardFilter{Count: 4, Shards: []uint64{2, 3}}, b.ShardFilter())
+
+	// the shards of a challenger which stopped renewing are taken over once their leases expired
+	later := now.Add(a.haCfg.LeaseTimeout() + time.Millisecond)
+	require.Empty(t, b.heldAt(later))
+	expireLeases(t, leases, "b")
+	require.NoError(t, a.assign(ctx, later))
+	require.Equal(t, []uint64{0, 1, 2, 3}, a.heldAt(later))
+
+	// the shards are released on shutdown
+	a.releaseAll()
+	require.Empty(t, a.heldAt(later))
+	require.NoError(t, b.assign(ctx, later))
+	require.Equal(t, []uint64{0, 1, 2, 3}, b.heldAt(later))
+}
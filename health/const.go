@@ -0,0 +1,30 @@
+package health
+
+import "time"
+
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+	StatusPath    = "/status"
+	VersionPath   = "/version"
+
+	// loops which report their liveness with Beat
+	LoopHeight      = "height"
+	LoopMonitor     = "monitor"
+	LoopVerifier    = "verifier"
+	LoopCollector   = "vote_collector"
+	LoopBroadcaster = "vote_broadcaster"
+	LoopCollator    = "vote_collator"
+	LoopSubmitter   = "submitter"
+	LoopAttest      = "attest_monitor"
+
+	CheckChain      = "chain"
+	CheckDB         = "db"
+	CheckMonitorLag = "monitor_lag"
+	CheckLoopPrefix = "loop."
+
+	LoopStallTimeout  = 5 * time.Minute // a loop which has not beaten for this long is considered stuck
+	ChainStaleTimeout = 1 * time.Minute // the chain is unreachable if the block height was not queried for this long
+	MaxMonitorLag     = 100             // blocks the monitor may fall behind the chain while being ready
+	CheckTimeout      = 3 * time.Second // timeout of the database queries of a check
+)
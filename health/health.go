@@ -0,0 +1,191 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+var (
+	beatMtx sync.Mutex
+	beats   = make(map[string]time.Time)
+)
+
+// Beat reports that loop is alive, the loops beat once per iteration.
+func Beat(loop string) {
+	beatMtx.Lock()
+	beats[loop] = time.Now()
+	beatMtx.Unlock()
+}
+
+// Beats returns the time of the last beat of each loop.
+func Beats() map[string]time.Time {
+	beatMtx.Lock()
+	defer beatMtx.Unlock()
+	result := make(map[string]time.Time, len(beats))
+	for loop, t := range beats {
+		result[loop] = t
+	}
+	return result
+}
+
+func lastBeat(loop string) (time.Time, bool) {
+	beatMtx.Lock()
+	defer beatMtx.Unlock()
+	t, ok := beats[loop]
+	return t, ok
+}
+
+// ChainProvider reports the block height cached from the chain.
+type ChainProvider interface {
+	GetCachedBlockHeight() uint64
+	LastHeightUpdate() time.Time
+}
+
+// BlockProvider reports the latest block saved by the monitor.
+type BlockProvider interface {
+	GetLatestBlock(ctx context.Context) (*model.Block, error)
+}
+
+// DBStatusProvider reports the result of the last database probe.
+type DBStatusProvider interface {
+	Status() dao.DBStatus
+}
+
+// Check is the result of a single health check.
+type Check struct {
+	Healthy bool   `json:"healthy"`
+	Message string `json:"message,omitempty"`
+}
+
+// Report is the result of the liveness or readiness checks, it is healthy if all checks are.
+type Report struct {
+	Healthy bool             `json:"healthy"`
+	Checks  map[string]Check `json:"checks"`
+}
+
+func (r *Report) add(name string, check Check) {
+	r.Checks[name] = check
+	r.Healthy = r.Healthy && check.Healthy
+}
+
+// Checker serves the liveness and readiness probes. The challenger is live while all its loops are running, and
+// ready while it is additionally connected to the chain and the database and the monitor keeps up with the chain.
+type Checker struct {
+	chain     ChainProvider
+	blocks    BlockProvider
+	db        DBStatusProvider
+	loops     []string
+	startTime time.Time // loops which never beat are measured from the start
+}
+
+func NewChecker(chain ChainProvider, blocks BlockProvider, db DBStatusProvider, loops []string) *Checker {
+	return &Checker{
+		chain:     chain,
+		blocks:    blocks,
+		db:        db,
+		loops:     loops,
+		startTime: time.Now(),
+	}
+}
+
+// Liveness checks that none of the loops is stuck.
+func (c *Checker) Liveness() Report {
+	report := Report{Healthy: true, Checks: make(map[string]Check)}
+	for _, loop := range c.loops {
+		report.add(CheckLoopPrefix+loop, c.checkLoop(loop))
+	}
+	return report
+}
+
+// Readiness checks the connectivity to the chain and the database, the monitor lag and the loops.
+func (c *Checker) Readiness(ctx context.Context) Report {
+	report := c.Liveness()
+	report.add(CheckChain, c.checkChain())
+	report.add(CheckDB, c.checkDB())
+	report.add(CheckMonitorLag, c.checkMonitorLag(ctx))
+	return report
+}
+
+func (c *Checker) checkLoop(loop string) Check {
+	last, ok := lastBeat(loop)
+	if !ok {
+		last = c.startTime
+	}
+	silence := time.Since(last)
+	if silence > LoopStallTimeout {
+		if !ok {
+			return Check{Message: fmt.Sprintf("has not run since the start %s ago", silence.Truncate(time.Second))}
+		}
+		return Check{Message: fmt.Sprintf("has not run for %s", silence.Truncate(time.Second))}
+	}
+	return Check{Healthy: true}
+}
+
+func (c *Checker) checkChain() Check {
+	last := c.chain.LastHeightUpdate()
+	if last.IsZero() {
+		return Check{Message: "block height was not queried yet"}
+	}
+	if silence := time.Since(last); silence > ChainStaleTimeout {
+		return Check{Message: fmt.Sprintf("block height was not updated for %s", silence.Truncate(time.Second))}
+	}
+	return Check{Healthy: true, Message: fmt.Sprintf("height %d", c.chain.GetCachedBlockHeight())}
+}
+
+func (c *Checker) checkDB() Check {
+	status := c.db.Status()
+	if !status.Healthy {
+		msg := "unreachable"
+		if status.LastErr != nil {
+			msg = status.LastErr.Error()
+		}
+		return Check{Message: msg}
+	}
+	return Check{Healthy: true}
+}
+
+func (c *Checker) checkMonitorLag(ctx context.Context) Check {
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+	block, err := c.blocks.GetLatestBlock(ctx)
+	if err != nil {
+		return Check{Message: fmt.Sprintf("failed to get the latest saved block, err=%s", err.Error())}
+	}
+	chainHeight := c.chain.GetCachedBlockHeight()
+	if chainHeight <= block.Height {
+		return Check{Healthy: true, Message: "0 blocks behind"}
+	}
+	lag := chainHeight - block.Height
+	return Check{Healthy: lag <= MaxMonitorLag, Message: fmt.Sprintf("%d blocks behind", lag)}
+}
+
+// LivenessHandler serves the liveness report, with status 503 if it is unhealthy.
+func (c *Checker) LivenessHandler() http.Handler {
+	return reportHandler(func(_ context.Context) Report { return c.Liveness() })
+}
+
+// ReadinessHandler serves the readiness report, with status 503 if it is unhealthy.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return reportHandler(c.Readiness)
+}
+
+func reportHandler(check func(ctx context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := check(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		if !report.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			logging.Logger.Errorf("failed to write health report, err=%+v", err.Error())
+		}
+	})
+}
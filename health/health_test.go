@@ -0,0 +1,129 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/version"
+)
+
+type fakeChain struct {
+	height     uint64
+	heightTime time.Time
+}
+
+func (c *fakeChain) GetCachedBlockHeight() uint64 { return c.height }
+func (c *fakeChain) LastHeightUpdate() time.Time  { return c.heightTime }
+func (c *fakeChain) Status() dao.DBStatus         { return dao.DBStatus{Healthy: true} }
+func (c *fakeChain) GetLatestBlock(_ context.Context) (*model.Block, error) {
+	return &model.Block{Height: 1000}, nil
+}
+
+type fakeDB struct {
+	status dao.DBStatus
+}
+
+func (d *fakeDB) Status() dao.DBStatus { return d.status }
+
+func TestLiveness(t *testing.T) {
+	chain := &fakeChain{}
+	checker := NewChecker(chain, chain, chain, []string{"test_running", "test_stuck", "test_not_started"})
+	checker.startTime = time.Now().Add(-2 * LoopStallTimeout)
+	Beat("test_running")
+	beatMtx.Lock()
+	beats["test_stuck"] = time.Now().Add(-LoopStallTimeout - time.Second)
+	beatMtx.Unlock()
+
+	report := checker.Liveness()
+	require.False(t, report.Healthy)
+	require.True(t, report.Checks["loop.test_running"].Healthy)
+	require.Equal(t, "has not run for 5m1s", report.Checks["loop.test_stuck"].Message)
+	require.Equal(t, "has not run since the start 10m0s ago", report.Checks["loop.test_not_started"].Message)
+}
+
+func TestReadiness(t *testing.T) {
+	chain := &fakeChain{height: 1050, heightTime: time.Now()}
+	db := &fakeDB{status: dao.DBStatus{Healthy: true}}
+	checker := NewChecker(chain, chain, db, nil)
+
+	server := httptest.NewServer(checker.ReadinessHandler())
+	defer server.Close()
+	get := func() (int, Report) {
+		res, err := http.Get(server.URL)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		report := Report{}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&report))
+		return res.StatusCode, report
+	}
+
+	status, report := get()
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, Check{Healthy: true, Message: "50 blocks behind"}, report.Checks[CheckMonitorLag])
+
+	chain.height = 1000 + MaxMonitorLag + 1
+	chain.heightTime = time.Now().Add(-ChainStaleTimeout - time.Second)
+	db.status = dao.DBStatus{LastErr: errors.New("connection refused")}
+	status, report = get()
+	require.Equal(t, http.StatusServiceUnavailable, status)
+	require.False(t, report.Healthy)
+	require.Equal(t, Check{Message: "101 blocks behind"}, report.Checks[CheckMonitorLag])
+	require.Equal(t, Check{Message: "block height was not updated for 1m1s"}, report.Checks[CheckChain])
+	require.Equal(t, Check{Message: "connection refused"}, report.Checks[CheckDB])
+}
+
+type fakeAccount struct {
+	counts map[model.EventStatus]int64
+}
+
+func (a *fakeAccount) CountEventsByStatus(_ context.Context) (map[model.EventStatus]int64, error) {
+	return a.counts, nil
+}
+func (a *fakeAccount) GetBalance() (math.Int, error) {
+	return math.Int{}, errors.New("rpc unavailable")
+}
+func (a *fakeAccount) ValidatorsUpdatedAt() time.Time { return time.Time{} }
+
+func TestStatus(t *testing.T) {
+	chain := &fakeChain{height: 1050, heightTime: time.Now()}
+	account := &fakeAccount{counts: map[model.EventStatus]int64{
+		model.Unprocessed: 4, model.EnoughVotesCollected: 2, model.Submitted: 1, model.Attested: 10,
+	}}
+	reporter := NewStatusReporter(chain, chain, account, account, account)
+
+	server := httptest.NewServer(reporter.StatusHandler())
+	defer server.Close()
+	res, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	status := Status{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&status))
+	require.Equal(t, uint64(1050), status.ChainHeight)
+	require.Equal(t, uint64(50), status.MonitorLag)
+	require.Equal(t, int64(3), status.PendingAttestations)
+	require.Equal(t, int64(10), status.EventsByStatus["attested"])
+	require.Empty(t, status.Balance)
+	require.Nil(t, status.ValidatorsCacheAgeSeconds)
+	require.Equal(t, []string{"failed to get the balance, err=rpc unavailable"}, status.Errors)
+}
+
+func TestVersionHandler(t *testing.T) {
+	server := httptest.NewServer(VersionHandler())
+	defer server.Close()
+	res, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	info := version.Info{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&info))
+	require.Equal(t, version.Get(), info)
+}
@@ -0,0 +1,136 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"cosmossdk.io/math"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/version"
+)
+
+// EventCounter counts the events in the database by their status.
+type EventCounter interface {
+	CountEventsByStatus(ctx context.Context) (map[model.EventStatus]int64, error)
+}
+
+// BalanceProvider reports the balance of the challenger account.
+type BalanceProvider interface {
+	GetBalance() (math.Int, error)
+}
+
+// ValidatorsProvider reports when the cached validators were last updated.
+type ValidatorsProvider interface {
+	ValidatorsUpdatedAt() time.Time
+}
+
+// Status is a snapshot of the challenger for dashboards and scripts. The parts which could not be queried are left
+// out and their errors are listed in Errors.
+type Status struct {
+	Version                   string           `json:"version"`
+	GitCommit                 string           `json:"git_commit"`
+	ChainHeight               uint64           `json:"chain_height"`
+	SavedHeight               uint64           `json:"saved_height,omitempty"`
+	MonitorLag                uint64           `json:"monitor_lag,omitempty"`
+	EventsByStatus            map[string]int64 `json:"events_by_status,omitempty"`
+	PendingAttestations       int64            `json:"pending_attestations"`
+	Balance                   string           `json:"balance,omitempty"`
+	ValidatorsCacheAgeSeconds *float64         `json:"validators_cache_age_seconds,omitempty"`
+	Errors                    []string         `json:"errors,omitempty"`
+}
+
+// pendingAttestationStatuses are the statuses of the events which collected enough votes but are not attested yet.
+var pendingAttestationStatuses = []model.EventStatus{model.EnoughVotesCollected, model.Submitted}
+
+// StatusReporter serves the status snapshot.
+type StatusReporter struct {
+	chain      ChainProvider
+	blocks     BlockProvider
+	events     EventCounter
+	balances   BalanceProvider
+	validators ValidatorsProvider
+}
+
+func NewStatusReporter(chain ChainProvider, blocks BlockProvider, events EventCounter, balances BalanceProvider,
+	validators ValidatorsProvider,
+) *StatusReporter {
+	return &StatusReporter{
+		chain:      chain,
+		blocks:     blocks,
+		events:     events,
+		balances:   balances,
+		validators: validators,
+	}
+}
+
+// Status takes the status snapshot.
+func (r *StatusReporter) Status(ctx context.Context) Status {
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+	status := Status{
+		Version:     version.AppVersion,
+		GitCommit:   version.GitCommit,
+		ChainHeight: r.chain.GetCachedBlockHeight(),
+	}
+	addErr := func(what string, err error) {
+		status.Errors = append(status.Errors, fmt.Sprintf("failed to get %s, err=%s", what, err.Error()))
+	}
+
+	if block, err := r.blocks.GetLatestBlock(ctx); err != nil {
+		addErr("the latest saved block", err)
+	} else {
+		status.SavedHeight = block.Height
+		if status.ChainHeight > block.Height {
+			status.MonitorLag = status.ChainHeight - block.Height
+		}
+	}
+
+	if counts, err := r.events.CountEventsByStatus(ctx); err != nil {
+		addErr("the event counts", err)
+	} else {
+		status.EventsByStatus = make(map[string]int64, len(counts))
+		for eventStatus, count := range counts {
+			status.EventsByStatus[eventStatus.String()] = count
+		}
+		for _, eventStatus := range pendingAttestationStatuses {
+			status.PendingAttestations += counts[eventStatus]
+		}
+	}
+
+	if balance, err := r.balances.GetBalance(); err != nil {
+		addErr("the balance", err)
+	} else {
+		status.Balance = balance.String()
+	}
+
+	if updatedAt := r.validators.ValidatorsUpdatedAt(); !updatedAt.IsZero() {
+		age := time.Since(updatedAt).Seconds()
+		status.ValidatorsCacheAgeSeconds = &age
+	}
+	return status
+}
+
+// StatusHandler serves the status snapshot as json.
+func (r *StatusReporter) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(r.Status(req.Context())); err != nil {
+			logging.Logger.Errorf("failed to write status, err=%+v", err.Error())
+		}
+	})
+}
+
+// VersionHandler serves the build information of the binary as json.
+func VersionHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(version.Get()); err != nil {
+			logging.Logger.Errorf("failed to write version, err=%+v", err.Error())
+		}
+	})
+}
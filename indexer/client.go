@@ -0,0 +1,115 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+)
+
+// Challenge is a challenge as seen by the indexer. The redundancy index, the challenger and the expired height are only
+// required to poll the challenges from the indexer.
+type Challenge struct {
+	ChallengeId       uint64 `json:"challenge_id"`
+	Height            uint64 `json:"height"`
+	ObjectId          string `json:"object_id"`
+	SegmentIndex      uint32 `json:"segment_index"`
+	SpOperatorAddress string `json:"sp_operator_address"`
+	RedundancyIndex   int32  `json:"redundancy_index"`
+	ChallengerAddress string `json:"challenger_address"`
+	ExpiredHeight     uint64 `json:"expired_height"`
+	Attested          bool   `json:"attested"`
+}
+
+type challengesResponse struct {
+	Challenges    []*Challenge `json:"challenges"`
+	NextCursor    string       `json:"next_cursor"`    // empty on the last page
+	IndexedHeight uint64       `json:"indexed_height"` // latest block indexed, 0 if not reported
+}
+
+// Client reads the challenges from the http api of an indexer:
+// GET <url>/challenges?from_height=<h>&to_height=<h>[&cursor=<c>] returns
+// {"challenges": [...], "next_cursor": "...", "indexed_height": <h>}, the challenges being emitted between the
+// heights, both included.
+type Client struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func NewClient(cfg *config.IndexerConfig) *Client {
+	return &Client{
+		url:        strings.TrimSuffix(cfg.Url, "/"),
+		apiKey:     cfg.ApiKey,
+		httpClient: &http.Client{Timeout: cfg.Timeout()},
+	}
+}
+
+// Challenges returns the challenges emitted from fromHeight to toHeight, following the pages of the indexer.
+func (c *Client) Challenges(ctx context.Context, fromHeight, toHeight uint64) ([]*Challenge, error) {
+	challenges, _, err := c.IndexedChallenges(ctx, fromHeight, toHeight)
+	return challenges, err
+}
+
+// IndexedChallenges returns the challenges emitted from fromHeight to toHeight, and the latest block the indexer had
+// indexed while serving them, the lowest reported by the pages.
+func (c *Client) IndexedChallenges(ctx context.Context, fromHeight, toHeight uint64) ([]*Challenge, uint64, error) {
+	challenges := make([]*Challenge, 0)
+	var indexedHeight uint64
+	cursor := ""
+	for page := 0; page < MaxChallengePages; page++ {
+		query := url.Values{
+			"from_height": {strconv.FormatUint(fromHeight, 10)},
+			"to_height":   {strconv.FormatUint(toHeight, 10)},
+		}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		res, err := c.get(ctx, c.url+ChallengesPath+"?"+query.Encode())
+		if err != nil {
+			return nil, 0, err
+		}
+		challenges = append(challenges, res.Challenges...)
+		if page == 0 || res.IndexedHeight < indexedHeight {
+			indexedHeight = res.IndexedHeight
+		}
+		if res.NextCursor == "" {
+			return challenges, indexedHeight, nil
+		}
+		cursor = res.NextCursor
+	}
+	return nil, 0, fmt.Errorf("the indexer served more than %d pages of challenges", MaxChallengePages)
+}
+
+func (c *Client) get(ctx context.Context, u string) (*challengesResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d of the indexer, body=%s", resp.StatusCode, string(body))
+	}
+	var res challengesResponse
+	if err = json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("decode the challenges of the indexer error, err=%+v", err)
+	}
+	return &res, nil
+}
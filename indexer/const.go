@@ -0,0 +1,15 @@
+package indexer
+
+const (
+	// ChallengesPath serves the challenges emitted between two heights, relative to the url of the indexer
+	ChallengesPath = "/challenges"
+	// MaxCheckedBlocks bounds the blocks cross-checked at once, the following blocks are checked on the next round
+	MaxCheckedBlocks = 1000
+	// MaxChallengePages bounds the pages of challenges fetched for a round, against an indexer serving pages forever
+	MaxChallengePages = 1000
+
+	// the kinds of the discrepancies with the indexer
+	KindMissed     = "missed"     // the indexer knows a challenge which was not saved
+	KindUnknown    = "unknown"    // a saved challenge is not known by the indexer
+	KindMismatched = "mismatched" // the challenge was saved with other fields or another outcome
+)
@@ -0,0 +1,219 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bnb-chain/greenfield-challenger/alert"
+	"github.com/bnb-chain/greenfield-challenger/common"
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+// Indexer serves the challenges known by an indexer.
+type Indexer interface {
+	Challenges(ctx context.Context, fromHeight, toHeight uint64) ([]*Challenge, error)
+}
+
+// DataProvider reads the polled blocks and the saved events.
+type DataProvider interface {
+	GetLatestBlock(ctx context.Context) (*model.Block, error)
+	ListEvents(ctx context.Context, filter dao.EventFilter, cursor uint64, limit int) ([]*model.Event, uint64, error)
+}
+
+// Discrepancy is a challenge the saved events disagree on with the indexer.
+type Discrepancy struct {
+	ChallengeId uint64
+	Kind        string
+	Detail      string
+}
+
+// Report is the outcome of cross-checking a range of blocks.
+type Report struct {
+	FromHeight    uint64
+	ToHeight      uint64
+	Challenges    int // challenges known by the indexer in the range
+	Discrepancies []Discrepancy
+}
+
+// Reconciler cross-checks the events saved from the polled blocks with the challenges known by an indexer, the blocks
+// being checked once each. The discrepancies are logged, counted and alerted for the operator to investigate, the events
+// are not changed.
+type Reconciler struct {
+	cfg           *config.IndexerConfig
+	indexer       Indexer
+	dataProvider  DataProvider
+	metricService *metrics.MetricService
+	mtx           sync.Mutex
+	checked       uint64 // latest checked height, 0 before the first round
+}
+
+func NewReconciler(cfg *config.IndexerConfig, indexer Indexer, dataProvider DataProvider,
+	metricService *metrics.MetricService,
+) *Reconciler {
+	return &Reconciler{
+		cfg:           cfg,
+		indexer:       indexer,
+		dataProvider:  dataProvider,
+		metricService: metricService,
+	}
+}
+
+// Reconcile cross-checks the blocks polled since the previous round which the indexer should have caught up with, at
+// most MaxCheckedBlocks of them. It returns nil if there are no such blocks.
+func (r *Reconciler) Reconcile(ctx context.Context) (*Report, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	latest, err := r.dataProvider.GetLatestBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if latest.Height <= r.cfg.DelayBlocks {
+		return nil, nil
+	}
+	toHeight := latest.Height - r.cfg.DelayBlocks
+	fromHeight := r.checked + 1
+	// the blocks older than the lookback are not checked, e.g. after a restart or a long lag
+	if r.checked == 0 || r.checked+r.cfg.LookbackBlocks < toHeight {
+		fromHeight = 1
+		if toHeight > r.cfg.LookbackBlocks {
+			fromHeight = toHeight - r.cfg.LookbackBlocks + 1
+		}
+	}
+	if fromHeight > toHeight {
+		return nil, nil
+	}
+	if toHeight-fromHeight+1 > MaxCheckedBlocks {
+		toHeight = fromHeight + MaxCheckedBlocks - 1
+	}
+
+	challenges, err := r.indexer.Challenges(ctx, fromHeight, toHeight)
+	if err != nil {
+		return nil, fmt.Errorf("get the challenges of the indexer error, err=%+v", err)
+	}
+	events, err := r.listEvents(ctx, fromHeight, toHeight)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{
+		FromHeight:    fromHeight,
+		ToHeight:      toHeight,
+		Challenges:    len(challenges),
+		Discrepancies: compare(challenges, events, fromHeight, toHeight),
+	}
+	r.flag(report)
+	r.checked = toHeight
+	r.metricService.SetIndexerCheckedHeight(toHeight)
+	return report, nil
+}
+
+// ReconcileLoop cross-checks the polled blocks every interval until ctx is done.
+func (r *Reconciler) ReconcileLoop(ctx context.Context) {
+	for {
+		if !common.Sleep(ctx, r.cfg.Interval()) {
+			return
+		}
+		if _, err := r.Reconcile(ctx); err != nil {
+			logging.MonitorLogger.Errorf("failed to cross-check the events with the indexer, err=%+v", err.Error())
+		}
+	}
+}
+
+func (r *Reconciler) listEvents(ctx context.Context, fromHeight, toHeight uint64) ([]*model.Event, error) {
+	filter := dao.EventFilter{FromHeight: fromHeight, ToHeight: toHeight}
+	events := make([]*model.Event, 0)
+	var cursor uint64
+	for {
+		page, next, err := r.dataProvider.ListEvents(ctx, filter, cursor, dao.MaxListEventsLimit)
+		if err != nil {
+			return nil, fmt.Errorf("list the events of blocks %d to %d error, err=%+v", fromHeight, toHeight, err)
+		}
+		events = append(events, page...)
+		if next == 0 {
+			return events, nil
+		}
+		cursor = next
+	}
+}
+
+func (r *Reconciler) flag(report *Report) {
+	missed, mismatched := 0, 0
+	for _, d := range report.Discrepancies {
+		logging.MonitorLogger.Errorf("challenge %d is %s compared to the indexer, %s", d.ChallengeId, d.Kind, d.Detail)
+		r.metricService.IncIndexerDiscrepancies(d.Kind)
+		if d.Kind == KindMissed {
+			missed++
+		} else {
+			mismatched++
+		}
+	}
+	if len(report.Discrepancies) == 0 {
+		logging.MonitorLogger.Infof("blocks %d to %d agree with the indexer, %d challenges", report.FromHeight,
+			report.ToHeight, report.Challenges)
+		return
+	}
+	alert.IndexerMismatch(report.FromHeight, report.ToHeight, missed, mismatched)
+}
+
+// compare returns the discrepancies between the challenges of the indexer and the events saved from the blocks
+// fromHeight to toHeight.
+func compare(challenges []*Challenge, events []*model.Event, fromHeight, toHeight uint64) []Discrepancy {
+	saved := make(map[uint64]*model.Event, len(events))
+	for _, event := range events {
+		saved[event.ChallengeId] = event
+	}
+	discrepancies := make([]Discrepancy, 0)
+	known := make(map[uint64]bool, len(challenges))
+	for _, c := range challenges {
+		// an indexer ignoring the heights of the request is not trusted with the challenges of other blocks
+		if c.Height < fromHeight || c.Height > toHeight || known[c.ChallengeId] {
+			continue
+		}
+		known[c.ChallengeId] = true
+		event, ok := saved[c.ChallengeId]
+		if !ok {
+			discrepancies = append(discrepancies, Discrepancy{ChallengeId: c.ChallengeId, Kind: KindMissed,
+				Detail: fmt.Sprintf("emitted at height %d on sp %s", c.Height, c.SpOperatorAddress)})
+			continue
+		}
+		if diff := diffEvent(c, event); diff != "" {
+			discrepancies = append(discrepancies, Discrepancy{ChallengeId: c.ChallengeId, Kind: KindMismatched,
+				Detail: diff})
+		}
+	}
+	for _, event := range events {
+		if !known[event.ChallengeId] {
+			discrepancies = append(discrepancies, Discrepancy{ChallengeId: event.ChallengeId, Kind: KindUnknown,
+				Detail: fmt.Sprintf("saved at height %d with status %s", event.Height, event.Status)})
+		}
+	}
+	return discrepancies
+}
+
+// diffEvent describes how the event differs from the challenge of the indexer, it is empty if they agree. The outcome
+// is only compared once the event settled: an attested event must be attested, and an expired one must not.
+func diffEvent(c *Challenge, event *model.Event) string {
+	diffs := make([]string, 0)
+	if c.Height != event.Height {
+		diffs = append(diffs, fmt.Sprintf("height %d instead of %d", event.Height, c.Height))
+	}
+	if c.ObjectId != event.ObjectId {
+		diffs = append(diffs, fmt.Sprintf("object %s instead of %s", event.ObjectId, c.ObjectId))
+	}
+	if c.SegmentIndex != event.SegmentIndex {
+		diffs = append(diffs, fmt.Sprintf("segment %d instead of %d", event.SegmentIndex, c.SegmentIndex))
+	}
+	if !strings.EqualFold(c.SpOperatorAddress, event.SpOperatorAddress) {
+		diffs = append(diffs, fmt.Sprintf("sp %s instead of %s", event.SpOperatorAddress, c.SpOperatorAddress))
+	}
+	attested := event.Status == model.SelfAttested || event.Status == model.Attested
+	if attested && !c.Attested || event.Status == model.Expired && c.Attested {
+		diffs = append(diffs, fmt.Sprintf("status %s but attested %t", event.Status, c.Attested))
+	}
+	return strings.Join(diffs, ", ")
+}
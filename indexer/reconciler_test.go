@@ -0,0 +1,119 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+type fakeIndexer struct {
+	challenges []*Challenge
+	requested  [][2]uint64 // the height ranges requested
+}
+
+func (i *fakeIndexer) Challenges(_ context.Context, fromHeight, toHeight uint64) ([]*Challenge, error) {
+	i.requested = append(i.requested, [2]uint64{fromHeight, toHeight})
+	return i.challenges, nil
+}
+
+type fakeDataProvider struct {
+	height uint64
+	events []*model.Event
+}
+
+func (p *fakeDataProvider) GetLatestBlock(context.Context) (*model.Block, error) {
+	return &model.Block{Height: p.height}, nil
+}
+
+func (p *fakeDataProvider) ListEvents(_ context.Context, filter dao.EventFilter, cursor uint64, _ int,
+) ([]*model.Event, uint64, error) {
+	events := make([]*model.Event, 0)
+	for _, e := range p.events {
+		if e.ChallengeId > cursor && e.Height >= filter.FromHeight && e.Height <= filter.ToHeight {
+			events = append(events, e)
+		}
+	}
+	return events, 0, nil
+}
+
+func TestReconciler_Reconcile(t *testing.T) {
+	idx := &fakeIndexer{challenges: []*Challenge{
+		{ChallengeId: 1, Height: 950, ObjectId: "10", SegmentIndex: 1, SpOperatorAddress: "0xAB", Attested: true},
+		{ChallengeId: 2, Height: 960, ObjectId: "11", SpOperatorAddress: "0xab"},
+		{ChallengeId: 3, Height: 970, ObjectId: "12", SpOperatorAddress: "0xab", Attested: true},
+		{ChallengeId: 4, Height: 975, ObjectId: "13", SpOperatorAddress: "0xab", Attested: true},
+		// out of the requested blocks
+		{ChallengeId: 9, Height: 10, ObjectId: "19", SpOperatorAddress: "0xab"},
+	}}
+	provider := &fakeDataProvider{height: 1000, events: []*model.Event{
+		{ChallengeId: 1, Height: 950, ObjectId: "10", SegmentIndex: 1, SpOperatorAddress: "0xab", Status: model.Attested},
+		{ChallengeId: 2, Height: 960, ObjectId: "11", SegmentIndex: 2, SpOperatorAddress: "0xab", Status: model.SelfVoted},
+		{ChallengeId: 3, Height: 970, ObjectId: "12", SpOperatorAddress: "0xab", Status: model.Expired},
+		{ChallengeId: 5, Height: 980, ObjectId: "15", SpOperatorAddress: "0xab", Status: model.Verified},
+	}}
+	cfg := &config.IndexerConfig{LookbackBlocks: 100, DelayBlocks: 20}
+	reconciler := NewReconciler(cfg, idx, provider, metrics.NewMetricService(&config.Config{}))
+
+	report, err := reconciler.Reconcile(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(881), report.FromHeight)
+	require.Equal(t, uint64(980), report.ToHeight)
+	require.Equal(t, []Discrepancy{
+		{ChallengeId: 2, Kind: KindMismatched, Detail: "segment 2 instead of 0"},
+		{ChallengeId: 3, Kind: KindMismatched, Detail: "status expired but attested true"},
+		{ChallengeId: 4, Kind: KindMissed, Detail: "emitted at height 975 on sp 0xab"},
+		{ChallengeId: 5, Kind: KindUnknown, Detail: "saved at height 980 with status verified"},
+	}, report.Discrepancies)
+
+	// the checked blocks are not checked again
+	report, err = reconciler.Reconcile(context.Background())
+	require.NoError(t, err)
+	require.Nil(t, report)
+	provider.height = 1010
+	report, err = reconciler.Reconcile(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(981), report.FromHeight)
+	require.Equal(t, uint64(990), report.ToHeight)
+
+	// the blocks beyond the lookback are skipped after a long lag
+	provider.height = 2000
+	report, err = reconciler.Reconcile(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(1881), report.FromHeight)
+	require.Equal(t, [][2]uint64{{881, 980}, {981, 990}, {1881, 1980}}, idx.requested)
+}
+
+func TestClient_Challenges(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, ChallengesPath, r.URL.Path)
+		require.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		require.Equal(t, "100", r.URL.Query().Get("from_height"))
+		require.Equal(t, "200", r.URL.Query().Get("to_height"))
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			fmt.Fprint(w, `{"challenges":[{"challenge_id":1,"height":100,"attested":true}],"next_cursor":"p2"}`)
+		case "p2":
+			fmt.Fprint(w, `{"challenges":[{"challenge_id":2,"height":150,"object_id":"7"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(&config.IndexerConfig{Url: server.URL + "/", ApiKey: "key"})
+	challenges, err := client.Challenges(context.Background(), 100, 200)
+	require.NoError(t, err)
+	require.Equal(t, []*Challenge{
+		{ChallengeId: 1, Height: 100, Attested: true},
+		{ChallengeId: 2, Height: 150, ObjectId: "7"},
+	}, challenges)
+}
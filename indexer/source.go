@@ -0,0 +1,93 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/monitor"
+)
+
+// EventSource serves the challenges of the indexer to the monitor while the rpc endpoints are down. The blocks are
+// served up to the latest block the indexer indexed, so that a block is not saved before the indexer knows all of its
+// challenges. The indexer does not serve the time of the blocks, they are timed when served.
+type EventSource struct {
+	client *Client
+}
+
+func NewEventSource(client *Client) *EventSource {
+	return &EventSource{client: client}
+}
+
+// Blocks returns the blocks from fromHeight up to toHeight which the indexer indexed, including the blocks without
+// challenges.
+func (s *EventSource) Blocks(ctx context.Context, fromHeight, toHeight uint64) ([]*monitor.SourceBlock, error) {
+	challenges, indexedHeight, err := s.client.IndexedChallenges(ctx, fromHeight, toHeight)
+	if err != nil {
+		return nil, err
+	}
+	if indexedHeight == 0 {
+		return nil, errors.New("the indexer does not report the latest block it indexed")
+	}
+	servedHeight := toHeight
+	if indexedHeight < servedHeight {
+		servedHeight = indexedHeight
+	}
+	if servedHeight < fromHeight {
+		return nil, nil
+	}
+	now := time.Now()
+	blocks := make([]*monitor.SourceBlock, 0, servedHeight-fromHeight+1)
+	for height := fromHeight; height <= servedHeight; height++ {
+		blocks = append(blocks, &monitor.SourceBlock{Height: height, Time: now, Events: make([]*model.Event, 0)})
+	}
+	seen := make(map[uint64]bool, len(challenges))
+	for _, c := range challenges {
+		if c.Height < fromHeight || c.Height > toHeight {
+			return nil, fmt.Errorf("the indexer served challenge %d at height %d, out of %d to %d", c.ChallengeId,
+				c.Height, fromHeight, toHeight)
+		}
+		if seen[c.ChallengeId] {
+			return nil, fmt.Errorf("the indexer served challenge %d twice", c.ChallengeId)
+		}
+		seen[c.ChallengeId] = true
+		if c.Height > servedHeight {
+			continue
+		}
+		event, err := toEvent(c, now)
+		if err != nil {
+			return nil, err
+		}
+		block := blocks[c.Height-fromHeight]
+		block.Events = append(block.Events, event)
+	}
+	return blocks, nil
+}
+
+// toEvent returns the event of a challenge polled from the indexer, which must serve all of its fields.
+func toEvent(c *Challenge, now time.Time) (*model.Event, error) {
+	if _, err := sdkmath.ParseUint(c.ObjectId); err != nil {
+		return nil, fmt.Errorf("the indexer served challenge %d with object id %q", c.ChallengeId, c.ObjectId)
+	}
+	if c.SpOperatorAddress == "" || c.ChallengerAddress == "" || c.ExpiredHeight <= c.Height {
+		return nil, fmt.Errorf("the indexer served challenge %d without its sp, challenger or expired height",
+			c.ChallengeId)
+	}
+	return &model.Event{
+		ChallengeId:       c.ChallengeId,
+		ObjectId:          c.ObjectId,
+		SegmentIndex:      c.SegmentIndex,
+		SpOperatorAddress: c.SpOperatorAddress,
+		RedundancyIndex:   c.RedundancyIndex,
+		ChallengerAddress: c.ChallengerAddress,
+		Height:            c.Height,
+		Status:            model.Unprocessed,
+		VerifyResult:      model.Unknown,
+		CreatedTime:       now.Unix(),
+		StageTime:         now.UnixMilli(),
+		ExpiredHeight:     c.ExpiredHeight,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+)
+
+func TestEventSource_Blocks(t *testing.T) {
+	body := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "10", r.URL.Query().Get("from_height"))
+		require.Equal(t, "15", r.URL.Query().Get("to_height"))
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+	source := NewEventSource(NewClient(&config.IndexerConfig{Url: server.URL}))
+	challenge := func(id, height uint64) string {
+		return fmt.Sprintf(`{"challenge_id":%d,"height":%d,"object_id":"7","segment_index":1,`+
+			`"sp_operator_address":"0xsp","redundancy_index":-1,"challenger_address":"0xc","expired_height":%d}`,
+			id, height, height+100)
+	}
+
+	// the blocks are served up to the indexed height, the challenges of the following blocks are polled later
+	body = `{"challenges":[` + challenge(1, 10) + `,` + challenge(2, 12) + `,` + challenge(3, 12) + `,` +
+		challenge(4, 14) + `],"indexed_height":12}`
+	blocks, err := source.Blocks(context.Background(), 10, 15)
+	require.NoError(t, err)
+	require.Len(t, blocks, 3)
+	for i, b := range blocks {
+		require.Equal(t, uint64(10+i), b.Height)
+	}
+	require.Len(t, blocks[0].Events, 1)
+	require.Empty(t, blocks[1].Events)
+	require.Len(t, blocks[2].Events, 2)
+	event := blocks[2].Events[1]
+	require.Equal(t, uint64(3), event.ChallengeId)
+	require.Equal(t, uint64(12), event.Height)
+	require.Equal(t, int32(-1), event.RedundancyIndex)
+	require.Equal(t, "0xc", event.ChallengerAddress)
+	require.Equal(t, uint64(112), event.ExpiredHeight)
+
+	body = `{"challenges":[],"indexed_height":9}`
+	blocks, err = source.Blocks(context.Background(), 10, 15)
+	require.NoError(t, err)
+	require.Empty(t, blocks)
+
+	for _, body = range []string{
+		`{"challenges":[]}`, // no indexed height
+		`{"challenges":[` + challenge(1, 16) + `],"indexed_height":20}`,                          // out of the range
+		`{"challenges":[` + challenge(1, 10) + `,` + challenge(1, 11) + `],"indexed_height":20}`, // served twice
+		`{"challenges":[{"challenge_id":1,"height":10,"object_id":"7"}],"indexed_height":20}`,    // missing fields
+	} {
+		_, err = source.Blocks(context.Background(), 10, 15)
+		require.Error(t, err, body)
+	}
+}
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/op/go-logging"
+)
+
+// stages of the challenge pipeline, logged as the stage field
+const (
+	StageMonitor   = "monitor"
+	StageVerify    = "verify"
+	StageBroadcast = "broadcast"
+	StageCollate   = "collate"
+	StageSubmit    = "submit"
+	StageAttest    = "attest"
+)
+
+// Fields correlate the records of a challenge across the pipeline stages, so that its whole lifecycle can be
+// filtered in a log aggregator. Zero fields are omitted.
+type Fields struct {
+	ChallengeId uint64 `json:"challenge_id,omitempty"`
+	ObjectId    string `json:"object_id,omitempty"`
+	Sp          string `json:"sp,omitempty"`
+	Stage       string `json:"stage,omitempty"`
+	Height      uint64 `json:"height,omitempty"`
+	RequestId   string `json:"request_id,omitempty"`
+}
+
+func (f Fields) String() string {
+	parts := make([]string, 0, 6)
+	if f.ChallengeId != 0 {
+		parts = append(parts, fmt.Sprintf("challenge_id=%d", f.ChallengeId))
+	}
+	if f.ObjectId != "" {
+		parts = append(parts, "object_id="+f.ObjectId)
+	}
+	if f.Sp != "" {
+		parts = append(parts, "sp="+f.Sp)
+	}
+	if f.Stage != "" {
+		parts = append(parts, "stage="+f.Stage)
+	}
+	if f.Height != 0 {
+		parts = append(parts, fmt.Sprintf("height=%d", f.Height))
+	}
+	if f.RequestId != "" {
+		parts = append(parts, "request_id="+f.RequestId)
+	}
+	return strings.Join(parts, " ")
+}
+
+// entry is the only argument of a record logged by a FieldLogger, the json formatter emits its fields as keys and
+// the text formatter appends them to the message.
+type entry struct {
+	fields Fields
+	msg    string
+}
+
+func (e *entry) String() string {
+	if fields := e.fields.String(); fields != "" {
+		return e.msg + " " + fields
+	}
+	return e.msg
+}
+
+// FieldLogger logs the records of a challenge with its fields.
+type FieldLogger struct {
+	logger *logging.Logger
+	fields Fields
+}
+
+// WithFields returns a logger of the module of logger which attaches fields to its records.
+func WithFields(logger *logging.Logger, fields Fields) *FieldLogger {
+	return &FieldLogger{
+		// the extra call depth reports the caller of FieldLogger instead of FieldLogger itself
+		logger: &logging.Logger{Module: logger.Module, ExtraCalldepth: logger.ExtraCalldepth + 1},
+		fields: fields,
+	}
+}
+
+func (l *FieldLogger) Debugf(format string, args ...interface{}) {
+	l.logger.Debug(&entry{fields: l.fields, msg: fmt.Sprintf(format, args...)})
+}
+
+func (l *FieldLogger) Infof(format string, args ...interface{}) {
+	l.logger.Info(&entry{fields: l.fields, msg: fmt.Sprintf(format, args...)})
+}
+
+func (l *FieldLogger) Warningf(format string, args ...interface{}) {
+	l.logger.Warning(&entry{fields: l.fields, msg: fmt.Sprintf(format, args...)})
+}
+
+func (l *FieldLogger) Errorf(format string, args ...interface{}) {
+	l.logger.Error(&entry{fields: l.fields, msg: fmt.Sprintf(format, args...)})
+}
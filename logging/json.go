@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"encoding/json"
+	"io"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/op/go-logging"
+)
+
+// jsonRecord is a log record as written by jsonFormatter, one json object per line.
+type jsonRecord struct {
+	Time   string `json:"time"`
+	Level  string `json:"level"`
+	Module string `json:"module"`
+	Func   string `json:"func,omitempty"`
+	Msg    string `json:"msg"`
+	Fields
+}
+
+// jsonFormatter formats the records as json, the fields of a FieldLogger become keys of the record.
+type jsonFormatter struct{}
+
+func (f jsonFormatter) Format(calldepth int, r *logging.Record, w io.Writer) error {
+	record := jsonRecord{
+		Time:   r.Time.Format(time.RFC3339Nano),
+		Level:  r.Level.String(),
+		Module: r.Module,
+	}
+	// same depth as the shortfunc verb of the text format
+	if pc, _, _, ok := runtime.Caller(calldepth + 1); ok {
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			record.Func = shortFunc(fn.Name())
+		}
+	}
+	if e, ok := fieldsEntry(r); ok {
+		record.Msg = e.msg
+		record.Fields = e.fields
+	} else {
+		record.Msg = r.Message()
+	}
+	bz, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bz)
+	return err
+}
+
+func fieldsEntry(r *logging.Record) (*entry, bool) {
+	if len(r.Args) != 1 {
+		return nil, false
+	}
+	e, ok := r.Args[0].(*entry)
+	return e, ok
+}
+
+// shortFunc strips the package path from a function name, e.g. (*Verifier).verifyHash.
+func shortFunc(name string) string {
+	name = name[strings.LastIndex(name, "/")+1:]
+	if i := strings.Index(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/op/go-logging"
+	"github.com/stretchr/testify/require"
+)
+
+func useBuffer(formatter logging.Formatter) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	backend := logging.AddModuleLevel(logging.NewBackendFormatter(logging.NewLogBackend(buf, "", 0), formatter))
+	backend.SetLevel(logging.DEBUG, "")
+	logging.SetBackend(backend)
+	return buf
+}
+
+func TestJsonFormat(t *testing.T) {
+	buf := useBuffer(jsonFormatter{})
+	fields := Fields{ChallengeId: 7, ObjectId: "1", Sp: "0x01", Stage: StageVerify, Height: 100, RequestId: "7-0a0b0c0d"}
+	WithFields(VerifierLogger, fields).Infof("verifier started, attempt %d", 1)
+	VerifierLogger.Infof("fetched %d events", 2)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	record := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	require.Equal(t, "verifier started, attempt 1", record["msg"])
+	require.Equal(t, "INFO", record["level"])
+	require.Equal(t, "verifier", record["module"])
+	require.Equal(t, "TestJsonFormat", record["func"])
+	require.Equal(t, float64(7), record["challenge_id"])
+	require.Equal(t, "0x01", record["sp"])
+	require.Equal(t, StageVerify, record["stage"])
+	require.Equal(t, float64(100), record["height"])
+	require.Equal(t, "7-0a0b0c0d", record["request_id"])
+
+	record = map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	require.Equal(t, "fetched 2 events", record["msg"])
+	require.NotContains(t, record, "challenge_id")
+}
+
+func TestTextFormatFields(t *testing.T) {
+	buf := useBuffer(logging.MustStringFormatter(`%{shortfunc} %{message}`))
+	WithFields(VoteLogger, Fields{ChallengeId: 7, Stage: StageCollate}).Errorf("failed to count votes")
+	require.Equal(t, "TestTextFormatFields failed to count votes challenge_id=7 stage=collate\n", buf.String())
+}
@@ -1,7 +1,9 @@
 package logging
 
 import (
+	"fmt"
 	"os"
+	"sync"
 
 	"github.com/bnb-chain/greenfield-challenger/config"
 	"github.com/op/go-logging"
@@ -11,6 +13,13 @@ import (
 var (
 	// Logger instance for quick declarative logging levels
 	Logger = logging.MustGetLogger("greenfield-challenger")
+	// loggers of the components whose level can be set separately, see config.LogModules
+	MonitorLogger  = logging.MustGetLogger(config.LogModuleMonitor)
+	VerifierLogger = logging.MustGetLogger(config.LogModuleVerifier)
+	VoteLogger     = logging.MustGetLogger(config.LogModuleVote)
+	AttestLogger   = logging.MustGetLogger(config.LogModuleAttest)
+	ExecutorLogger = logging.MustGetLogger(config.LogModuleExecutor)
+	DaoLogger      = logging.MustGetLogger(config.LogModuleDao)
 	// log levels that are available
 	levels = map[string]logging.Level{
 		"CRITICAL": logging.CRITICAL,
@@ -20,35 +29,164 @@ var (
 		"INFO":     logging.INFO,
 		"DEBUG":    logging.DEBUG,
 	}
+	// leveled backends set up by InitLogger, their level can be changed by SetLevel
+	leveledBackends []logging.LeveledBackend
+
+	levelMtx sync.Mutex
+	// defaultLevel applies to all modules without an override in moduleLevels
+	defaultLevel logging.Level
+	moduleLevels = make(map[string]logging.Level)
 )
 
 // InitLogger initialises the logger.
-func InitLogger(config *config.LogConfig) {
+func InitLogger(cfg *config.LogConfig) {
 	backends := make([]logging.Backend, 0)
+	leveledBackends = make([]logging.LeveledBackend, 0)
+	var format logging.Formatter = logging.MustStringFormatter(`%{time:2006-01-02 15:04:05} %{level} %{shortfunc} %{message}`)
+	if cfg.Format == config.LogFormatJson {
+		format = jsonFormatter{}
+	}
 
-	if config.UseConsoleLogger {
-		consoleFormat := logging.MustStringFormatter(`%{time:2006-01-02 15:04:05} %{level} %{shortfunc} %{message}`)
+	if cfg.UseConsoleLogger {
 		consoleLogger := logging.NewLogBackend(os.Stdout, "", 0)
-		consoleFormatter := logging.NewBackendFormatter(consoleLogger, consoleFormat)
+		consoleFormatter := logging.NewBackendFormatter(consoleLogger, format)
 		consoleLoggerLeveled := logging.AddModuleLevel(consoleFormatter)
-		consoleLoggerLeveled.SetLevel(levels[config.Level], "")
+		consoleLoggerLeveled.SetLevel(levels[cfg.Level], "")
 		backends = append(backends, consoleLoggerLeveled)
+		leveledBackends = append(leveledBackends, consoleLoggerLeveled)
 	}
 
-	if config.UseFileLogger {
+	if cfg.UseFileLogger {
 		fileLogger := logging.NewLogBackend(&lumberjack.Logger{
-			Filename:   config.Filename,
-			MaxSize:    config.MaxFileSizeInMB,              // MaxSize is the maximum size in megabytes of the log file
-			MaxBackups: config.MaxBackupsOfLogFiles,         // MaxBackups is the maximum number of old log files to retain
-			MaxAge:     config.MaxAgeToRetainLogFilesInDays, // MaxAge is the maximum number of days to retain old log files
-			Compress:   config.Compress,
+			Filename:   cfg.Filename,
+			MaxSize:    cfg.MaxFileSizeInMB,              // MaxSize is the maximum size in megabytes of the log file
+			MaxBackups: cfg.MaxBackupsOfLogFiles,         // MaxBackups is the maximum number of old log files to retain
+			MaxAge:     cfg.MaxAgeToRetainLogFilesInDays, // MaxAge is the maximum number of days to retain old log files
+			Compress:   cfg.Compress,
 		}, "", 0)
-		fileFormat := logging.MustStringFormatter(`%{time:2006-01-02 15:04:05} %{level} %{shortfunc} %{message}`)
-		fileFormatter := logging.NewBackendFormatter(fileLogger, fileFormat)
+		fileFormatter := logging.NewBackendFormatter(fileLogger, format)
 		fileLoggerLeveled := logging.AddModuleLevel(fileFormatter)
-		fileLoggerLeveled.SetLevel(levels[config.Level], "")
+		fileLoggerLeveled.SetLevel(levels[cfg.Level], "")
 		backends = append(backends, fileLoggerLeveled)
+		leveledBackends = append(leveledBackends, fileLoggerLeveled)
+	}
+
+	if cfg.UseSyslogLogger {
+		syslogLogger, err := newSyslogBackend(cfg)
+		if err != nil {
+			panic(fmt.Errorf("failed to connect to syslog, err=%s", err.Error()))
+		}
+		// syslog stamps the records itself
+		var syslogFormat logging.Formatter = logging.MustStringFormatter(`%{level} %{shortfunc} %{message}`)
+		if cfg.Format == config.LogFormatJson {
+			syslogFormat = format
+		}
+		syslogFormatter := logging.NewBackendFormatter(syslogLogger, syslogFormat)
+		syslogLoggerLeveled := logging.AddModuleLevel(syslogFormatter)
+		syslogLoggerLeveled.SetLevel(levels[cfg.Level], "")
+		backends = append(backends, syslogLoggerLeveled)
+		leveledBackends = append(leveledBackends, syslogLoggerLeveled)
 	}
 
+	backends = append(backends, reporterBackend{})
 	logging.SetBackend(backends...)
+
+	if err := SetLevel(cfg.Level); err != nil {
+		panic(err)
+	}
+	if err := SetModuleLevels(cfg.ModuleLevels); err != nil {
+		panic(err)
+	}
+}
+
+// SetLevel changes the default level of the loggers set up by InitLogger while the challenger is running, the modules
+// with their own level are not affected.
+func SetLevel(level string) error {
+	l, ok := levels[level]
+	if !ok {
+		return fmt.Errorf("unknown log level %s", level)
+	}
+	levelMtx.Lock()
+	defer levelMtx.Unlock()
+	defaultLevel = l
+	applyLevels()
+	return nil
+}
+
+// SetModuleLevel changes the level of a single module, an empty level makes the module follow the default level again.
+func SetModuleLevel(module, level string) error {
+	if !isModule(module) {
+		return fmt.Errorf("unknown log module %s", module)
+	}
+	levelMtx.Lock()
+	defer levelMtx.Unlock()
+	if level == "" {
+		delete(moduleLevels, module)
+	} else {
+		l, ok := levels[level]
+		if !ok {
+			return fmt.Errorf("unknown log level %s", level)
+		}
+		moduleLevels[module] = l
+	}
+	applyLevels()
+	return nil
+}
+
+// SetModuleLevels replaces the levels of all modules, the modules missing in levelByModule follow the default level.
+func SetModuleLevels(levelByModule map[string]string) error {
+	newModuleLevels := make(map[string]logging.Level, len(levelByModule))
+	for module, level := range levelByModule {
+		if !isModule(module) {
+			return fmt.Errorf("unknown log module %s", module)
+		}
+		l, ok := levels[level]
+		if !ok {
+			return fmt.Errorf("unknown log level %s", level)
+		}
+		newModuleLevels[module] = l
+	}
+	levelMtx.Lock()
+	defer levelMtx.Unlock()
+	moduleLevels = newModuleLevels
+	applyLevels()
+	return nil
+}
+
+// ModuleLevels returns the effective level of every module.
+func ModuleLevels() map[string]string {
+	levelMtx.Lock()
+	defer levelMtx.Unlock()
+	result := make(map[string]string, len(config.LogModules))
+	for _, module := range config.LogModules {
+		l, ok := moduleLevels[module]
+		if !ok {
+			l = defaultLevel
+		}
+		result[module] = l.String()
+	}
+	return result
+}
+
+// applyLevels sets the levels on all backends, the caller must hold levelMtx.
+func applyLevels() {
+	for _, backend := range leveledBackends {
+		backend.SetLevel(defaultLevel, "")
+		for _, module := range config.LogModules {
+			l, ok := moduleLevels[module]
+			if !ok {
+				l = defaultLevel
+			}
+			backend.SetLevel(l, module)
+		}
+	}
+}
+
+func isModule(module string) bool {
+	for _, m := range config.LogModules {
+		if m == module {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"sync"
+
+	"github.com/op/go-logging"
+)
+
+// Reporter receives the error and critical records, e.g. to forward them to an error tracker.
+type Reporter interface {
+	Report(module string, level logging.Level, msg string, fields Fields)
+}
+
+var (
+	reporterMtx sync.RWMutex
+	reporter    Reporter
+)
+
+// SetReporter forwards the error records to r, a nil r stops forwarding. The reporter must not log errors itself.
+func SetReporter(r Reporter) {
+	reporterMtx.Lock()
+	reporter = r
+	reporterMtx.Unlock()
+}
+
+// reporterBackend passes the error records to the reporter, regardless of the configured levels.
+type reporterBackend struct{}
+
+func (reporterBackend) Log(level logging.Level, _ int, r *logging.Record) error {
+	if level > logging.ERROR {
+		return nil
+	}
+	reporterMtx.RLock()
+	rep := reporter
+	reporterMtx.RUnlock()
+	if rep == nil {
+		return nil
+	}
+	if e, ok := fieldsEntry(r); ok {
+		rep.Report(r.Module, level, e.msg, e.fields)
+	} else {
+		rep.Report(r.Module, level, r.Message(), Fields{})
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"log/syslog"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/op/go-logging"
+)
+
+// newSyslogBackend connects to the local syslog daemon, or to the remote server of cfg.
+func newSyslogBackend(cfg *config.LogConfig) (logging.Backend, error) {
+	writer, err := syslog.Dial(cfg.SyslogNetwork, cfg.SyslogAddress, syslog.LOG_INFO|syslog.LOG_DAEMON, cfg.SyslogTag)
+	if err != nil {
+		return nil, err
+	}
+	return &logging.SyslogBackend{Writer: writer}, nil
+}
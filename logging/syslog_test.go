@@ -0,0 +1,31 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+)
+
+func TestSyslogBackend(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	InitLogger(&config.LogConfig{Level: "INFO", Format: config.LogFormatText, UseSyslogLogger: true,
+		SyslogNetwork: config.SyslogNetworkUdp, SyslogAddress: conn.LocalAddr().String(), SyslogTag: "challenger-test"})
+	VoteLogger.Debugf("not sent")
+	VoteLogger.Errorf("vote collector failed")
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	// <27> is the daemon facility with the error severity
+	require.Regexp(t, `^<27>.* challenger-test\[\d+\]: ERROR TestSyslogBackend vote collector failed`, string(buf[:n]))
+}
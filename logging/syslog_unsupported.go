@@ -0,0 +1,14 @@
+//go:build windows || plan9
+
+package logging
+
+import (
+	"errors"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/op/go-logging"
+)
+
+func newSyslogBackend(_ *config.LogConfig) (logging.Backend, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
@@ -1,93 +1,654 @@
 package main
 
 import (
-	"flag"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
 	"github.com/bnb-chain/greenfield-challenger/app"
+	"github.com/bnb-chain/greenfield-challenger/audit"
+	"github.com/bnb-chain/greenfield-challenger/budget"
 	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/executor"
 	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+	"github.com/bnb-chain/greenfield-challenger/sentry"
+	"github.com/bnb-chain/greenfield-challenger/tracing"
+	"github.com/bnb-chain/greenfield-challenger/version"
 )
 
-func initFlags() {
-	flag.String(config.FlagConfigPath, "", "config file path")
-	flag.String(config.FlagConfigType, "", "config type, local_private_key or aws_private_key")
-	flag.String(config.FlagConfigAwsRegion, "", "aws region")
-	flag.String(config.FlagConfigAwsSecretKey, "", "aws secret key")
-	flag.String(config.FlagConfigPrivateKey, "", "challenger private key")
-	flag.String(config.FlagConfigBlsPrivateKey, "", "challenger bls private key")
-	flag.String(config.FlagConfigDbPass, "", "challenger db password")
-
-	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
-	pflag.Parse()
-	err := viper.BindPFlags(pflag.CommandLine)
-	if err != nil {
-		panic(err)
-	}
+var errNoConfigSource = errors.New("no config source, set --config-type local --config-path configFile, " +
+	"--config-type aws --aws-region awsRegion --aws-secret-key awsSecretKey, or " +
+	"--config-type etcd|consul --remote-config-addr addr --remote-config-key key")
+
+func initFlags(flags *pflag.FlagSet) {
+	flags.String(config.FlagConfigPath, "", "config file path")
+	flags.String(config.FlagConfigType, "", "config type, local, aws, etcd or consul")
+	flags.String(config.FlagConfigAwsRegion, "", "aws region")
+	flags.String(config.FlagConfigAwsSecretKey, "", "aws secret key")
+	flags.String(config.FlagConfigPrivateKey, "", "challenger private key")
+	flags.String(config.FlagConfigBlsPrivateKey, "", "challenger bls private key")
+	flags.String(config.FlagConfigDbPass, "", "challenger db password")
+	flags.String(config.FlagRemoteConfigAddr, "", "http address of etcd or consul")
+	flags.String(config.FlagRemoteConfigKey, "", "etcd or consul key holding the config")
+	flags.String(config.FlagLogLevel, "", "log level, overrides log_config.level")
+	flags.Uint(config.FlagMetricsPort, 0, "metrics port, overrides metrics_config.port")
+	flags.Uint64(config.FlagStartHeight, 0, "first block to poll with a fresh database, overrides greenfield_config.start_height")
 }
 
-func printUsage() {
-	fmt.Print("usage: ./greenfield-challenger --config-type local --config-path configFile\n")
-	fmt.Print("usage: ./greenfield-challenger --config-type aws --aws-region awsRegion --aws-secret-key awsSecretKey\n")
+func initRunFlags(flags *pflag.FlagSet) {
+	flags.String(config.FlagSnapshotPath, "", "write a snapshot of the challenger database to this file and exit")
+	flags.String(config.FlagMigrate, "", "plan: print the pending database migrations and exit, apply: apply them and exit")
+	flags.Bool(config.FlagDryRun, false, "do not broadcast votes nor send attest transactions, overrides pipeline_config.dry_run")
 }
 
-func main() {
-	var (
-		cfg                        *config.Config
-		configType, configFilePath string
-	)
-	initFlags()
-	configType = viper.GetString(config.FlagConfigType)
+// configSource is where the config was loaded from, it is read again from there when the config is reloaded.
+type configSource struct {
+	cfg            *config.Config
+	load           func() *config.Config
+	filePath       string // set for local configs, which are reloaded when the file changes
+	remoteSource   config.RemoteSource
+	remoteRevision uint64
+}
+
+// loadConfig loads the config from the source selected by the flags or the environment.
+func loadConfig() (*configSource, error) {
+	configType := viper.GetString(config.FlagConfigType)
 	if configType == "" {
 		configType = os.Getenv(config.ConfigType)
 	}
-	if configType != config.AWSConfig && configType != config.LocalConfig {
-		printUsage()
-		return
-	}
 
-	if configType == config.AWSConfig {
-		awsSecretKey := viper.GetString(config.FlagConfigAwsSecretKey)
-		if awsSecretKey == "" {
-			printUsage()
-			return
+	switch configType {
+	case config.EtcdConfig, config.ConsulConfig:
+		remoteAddr := viper.GetString(config.FlagRemoteConfigAddr)
+		if remoteAddr == "" {
+			remoteAddr = os.Getenv(config.RemoteConfigAddr)
 		}
-
+		remoteKey := viper.GetString(config.FlagRemoteConfigKey)
+		if remoteKey == "" {
+			remoteKey = os.Getenv(config.RemoteConfigKey)
+		}
+		if remoteAddr == "" || remoteKey == "" {
+			return nil, errNoConfigSource
+		}
+		remoteSource, err := config.NewRemoteSource(configType, remoteAddr, remoteKey)
+		if err != nil {
+			return nil, fmt.Errorf("remote config error, err=%+v", err.Error())
+		}
+		configContent, remoteRevision, err := remoteSource.Get()
+		if err != nil {
+			return nil, fmt.Errorf("get %s config error, err=%+v", configType, err.Error())
+		}
+		return &configSource{
+			cfg: config.ParseConfigFromJson(configContent),
+			load: func() *config.Config {
+				configContent, _, err := remoteSource.Get()
+				if err != nil {
+					panic(fmt.Sprintf("get %s config error, err=%+v", configType, err.Error()))
+				}
+				return config.ParseConfigFromJson(configContent)
+			},
+			remoteSource:   remoteSource,
+			remoteRevision: remoteRevision,
+		}, nil
+	case config.AWSConfig:
+		awsSecretKey := viper.GetString(config.FlagConfigAwsSecretKey)
 		awsRegion := viper.GetString(config.FlagConfigAwsRegion)
-		if awsRegion == "" {
-			printUsage()
-			return
+		if awsSecretKey == "" || awsRegion == "" {
+			return nil, errNoConfigSource
 		}
-
 		configContent, err := config.GetSecret(awsSecretKey, awsRegion)
 		if err != nil {
-			fmt.Printf("get aws config error, err=%+v", err.Error())
-			return
+			return nil, fmt.Errorf("get aws config error, err=%+v", err.Error())
 		}
-		cfg = config.ParseConfigFromJson(configContent)
-	} else {
-		configFilePath = viper.GetString(config.FlagConfigPath)
+		return &configSource{
+			cfg: config.ParseConfigFromJson(configContent),
+			load: func() *config.Config {
+				configContent, err := config.GetSecret(awsSecretKey, awsRegion)
+				if err != nil {
+					panic(fmt.Sprintf("get aws config error, err=%+v", err.Error()))
+				}
+				return config.ParseConfigFromJson(configContent)
+			},
+		}, nil
+	case config.LocalConfig:
+		configFilePath := viper.GetString(config.FlagConfigPath)
 		if configFilePath == "" {
 			configFilePath = os.Getenv(config.ConfigFilePath)
-			if configFilePath == "" {
-				printUsage()
-				return
-			}
 		}
-		cfg = config.ParseConfigFromFile(configFilePath)
+		if configFilePath == "" {
+			return nil, errNoConfigSource
+		}
+		return &configSource{
+			cfg: config.ParseConfigFromFile(configFilePath),
+			load: func() *config.Config {
+				return config.ParseConfigFromFile(configFilePath)
+			},
+			filePath: configFilePath,
+		}, nil
+	}
+	return nil, errNoConfigSource
+}
+
+// loadConfigAndLogger loads the config and sets the logger up as configured.
+func loadConfigAndLogger() (*configSource, error) {
+	source, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	logging.InitLogger(&source.cfg.LogConfig)
+	for _, warning := range source.cfg.Warnings() {
+		logging.Logger.Warningf("config warning: %s", warning)
 	}
+	return source, nil
+}
 
-	if cfg == nil {
-		fmt.Println("failed to get configuration")
-		return
+// initConfig writes the annotated default config to path, or to stdout if path is empty.
+func initConfig(path string) error {
+	if path == "" {
+		return config.WriteConfig(os.Stdout, config.DefaultConfig(), false)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
 	}
+	defer f.Close()
+	return config.WriteConfig(f, config.DefaultConfig(), false)
+}
 
-	logging.InitLogger(&cfg.LogConfig)
+// runChallenger starts the challenger, or takes a snapshot or migrates the database if asked to by the flags.
+func runChallenger(cmd *cobra.Command, _ []string) error {
+	source, err := loadConfigAndLogger()
+	if err != nil {
+		return err
+	}
+	cfg := source.cfg
+	logging.Logger.Infof("starting %s", version.Get())
 
-	app.NewApp(cfg).Start()
-	select {}
+	if snapshotPath := viper.GetString(config.FlagSnapshotPath); snapshotPath != "" {
+		if err := app.Snapshot(cfg, snapshotPath); err != nil {
+			return fmt.Errorf("snapshot error, err=%+v", err.Error())
+		}
+		return nil
+	}
+
+	switch migrate := viper.GetString(config.FlagMigrate); migrate {
+	case "":
+	case config.MigratePlan:
+		app.PlanMigrations(cfg, cmd.OutOrStdout())
+		return nil
+	case config.MigrateApply:
+		app.ApplyMigrations(cfg)
+		return nil
+	default:
+		return fmt.Errorf("--%s should be %s or %s, got %q", config.FlagMigrate, config.MigratePlan, config.MigrateApply, migrate)
+	}
+
+	if err = sentry.Init(&cfg.SentryConfig); err != nil {
+		return fmt.Errorf("init sentry error, err=%+v", err.Error())
+	}
+	defer sentry.Flush()
+	defer sentry.Recover(sentry.ComponentMain)
+
+	flushTraces, err := tracing.Init(&cfg.TracingConfig)
+	if err != nil {
+		return fmt.Errorf("init tracing error, err=%+v", err.Error())
+	}
+	defer flushTraces()
+
+	reloader := app.NewConfigReloader(cfg, source.load, source.filePath)
+	go reloader.ReloadLoop()
+	if source.remoteSource != nil {
+		go reloader.RemoteWatchLoop(source.remoteSource, source.remoteRevision)
+	}
+	challenger := app.NewApp(cfg)
+	challenger.Start()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	var fatalErr error
+	select {
+	case sig := <-stop:
+		logging.Logger.Infof("received %s, shutting down", sig)
+	case fatalErr = <-challenger.Fatal():
+		logging.Logger.Errorf("shutting down, err=%+v", fatalErr.Error())
+	}
+	if err := challenger.Shutdown(); err != nil {
+		return fmt.Errorf("shutdown error, err=%+v", err.Error())
+	}
+	if fatalErr != nil {
+		return fatalErr
+	}
+	logging.Logger.Infof("challenger stopped")
+	return nil
+}
+
+func newRunCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   config.CommandRun,
+		Short: "Start the challenger",
+		Args:  cobra.NoArgs,
+		RunE:  runChallenger,
+	}
+	initRunFlags(cmd.Flags())
+	return cmd
+}
+
+func newStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   config.CommandStatus,
+		Short: "Print the latest polled block and the number of events in each status from the database",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			source, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			return app.Status(source.cfg, cmd.OutOrStdout())
+		},
+	}
+}
+
+func newReplayCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   config.CommandReplay,
+		Short: "Reset the events emitted between two heights, so that the pipeline handles them again",
+		Long: "Reset the events emitted between --from and --to, both inclusive, to --status. A running challenger " +
+			"keeps the recently handled challenges cached, restart it after the replay.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			status, err := model.ParseEventStatus(viper.GetString(config.FlagReplayStatus))
+			if err != nil {
+				return err
+			}
+			source, err := loadConfigAndLogger()
+			if err != nil {
+				return err
+			}
+			replayed, err := app.Replay(source.cfg, viper.GetUint64(config.FlagReplayFrom),
+				viper.GetUint64(config.FlagReplayTo), status)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "replayed %d events\n", replayed)
+			return nil
+		},
+	}
+	cmd.Flags().Uint64(config.FlagReplayFrom, 0, "first height of the replayed events")
+	cmd.Flags().Uint64(config.FlagReplayTo, 0, "last height of the replayed events, 0 replays up to the latest event")
+	cmd.Flags().String(config.FlagReplayStatus, model.Unprocessed.String(),
+		"status the events are reset to, unprocessed verifies them again")
+	_ = cmd.MarkFlagRequired(config.FlagReplayFrom)
+	cmd.AddCommand(newReplayVerifyCmd())
+	return cmd
+}
+
+func newReplayVerifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   config.CommandReplayVerify,
+		Short: "Verify again the challenges started between two heights and compare the votes with the attestations",
+		Long: "Verify again the challenges started between --from and --to, both inclusive, as the monitor and the " +
+			"verifier would, and compare the votes with the results attested on chain. The challenges are verified " +
+			"against the storage providers, or against the --fixtures recorded by an earlier replay with --record. " +
+			"The database is not touched. The command fails if a vote diverges from an attestation.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			source, err := loadConfigAndLogger()
+			if err != nil {
+				return err
+			}
+			report, err := app.ReplayVerify(cmd.Context(), source.cfg, viper.GetUint64(config.FlagReplayFrom),
+				viper.GetUint64(config.FlagReplayTo), viper.GetString(config.FlagReplayFixtures),
+				viper.GetString(config.FlagReplayRecord))
+			if err != nil {
+				return err
+			}
+			if err = report.Write(cmd.OutOrStdout()); err != nil {
+				return err
+			}
+			if diverged := report.Diverged(); diverged != 0 {
+				return fmt.Errorf("%d of %d replayed challenges diverged from the attestations", diverged,
+					len(report.Results))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Uint64(config.FlagReplayFrom, 0, "first height of the replayed challenges")
+	cmd.Flags().Uint64(config.FlagReplayTo, 0, "last height of the replayed challenges, 0 replays up to the latest block")
+	cmd.Flags().String(config.FlagReplayFixtures, "", "verify against the fixtures of this file instead of the sps")
+	cmd.Flags().String(config.FlagReplayRecord, "", "record the fixtures of the verified challenges to this file")
+	_ = cmd.MarkFlagRequired(config.FlagReplayFrom)
+	return cmd
+}
+
+func newBudgetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   config.CommandBudget,
+		Short: "Project the monthly fees and rewards of the submission strategies",
+		Long: "Project the monthly attestation fees and challenge rewards of the challenger attesting in its turn only, " +
+			"attesting every challenge without waiting for its turn, and submitting challenges of its own as the " +
+			"proactive config does. The projection scales the challenges saved in the database over the last --days, " +
+			"with the rewards of the challenges attested in the last --sample-blocks blocks. The database is only read.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			source, err := loadConfigAndLogger()
+			if err != nil {
+				return err
+			}
+			report, err := app.Budget(cmd.Context(), source.cfg, viper.GetInt(config.FlagBudgetDays),
+				viper.GetUint64(config.FlagBudgetSampleBlocks), viper.GetString(config.FlagBudgetGasPrice))
+			if err != nil {
+				return err
+			}
+			return report.Write(cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().Int(config.FlagBudgetDays, budget.DefaultDays, "days of challenges the month is projected from")
+	cmd.Flags().Uint64(config.FlagBudgetSampleBlocks, budget.DefaultSampleBlocks,
+		"latest blocks the rewards of the attestations are sampled from")
+	cmd.Flags().String(config.FlagBudgetGasPrice, "", "gas price in wei, the configured fee_amount is paid if empty")
+	return cmd
+}
+
+func newMigrateDBCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   config.CommandMigrateDB,
+		Short: "Copy the challenger state to the database of another config, which may run another backend",
+		Long: "Copy the blocks, events, votes, sp stats, verification results, sampling failures, ledger entries and " +
+			"rollups from the database of the loaded config to the database of the config at --target-config-path, then " +
+			"verify that the row counts and digests of all tables match. The target tables must be empty. Stop the " +
+			"challengers using the source database first, so that their in-flight challenges are copied, and start " +
+			"them against the target once the copy is verified. The --db-pass flag only applies to the source database.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			source, err := loadConfigAndLogger()
+			if err != nil {
+				return err
+			}
+			target := config.ParseConfigFromFile(viper.GetString(config.FlagTargetConfigPath))
+			return app.MigrateDB(cmd.Context(), source.cfg, target, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().String(config.FlagTargetConfigPath, "", "config file of the target database")
+	_ = cmd.MarkFlagRequired(config.FlagTargetConfigPath)
+	return cmd
+}
+
+func newRecoveryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   config.CommandRecovery,
+		Short: "Export and import the in-flight challenges to recover them on another host",
+	}
+	export := &cobra.Command{
+		Use:   config.CommandRecoveryExport,
+		Short: "Export the unattested and unexpired events with their collected votes to a signed bundle",
+		Long: "Write the events which are neither attested nor expired at the latest polled block, with the votes " +
+			"collected for them, to --file as a bundle signed by the challenger tx key. The export reads the database " +
+			"consistently and can run while the challenger is running, e.g. periodically to storage off the host.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			source, err := loadConfigAndLogger()
+			if err != nil {
+				return err
+			}
+			return app.ExportRecovery(cmd.Context(), source.cfg, viper.GetString(config.FlagRecoveryFile),
+				cmd.OutOrStdout())
+		},
+	}
+	imp := &cobra.Command{
+		Use:   config.CommandRecoveryImport,
+		Short: "Import a signed bundle into the database of a fresh challenger",
+		Long: "Save the events and votes of the bundle at --file into the database of the loaded config, which must not " +
+			"have polled any block yet. The bundle must be signed by the tx key of the loaded config. Make sure the " +
+			"challenger which exported the bundle is not running anymore, then start the challenger, which resumes the " +
+			"in-flight challenges and polls the blocks after the bundle.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			source, err := loadConfigAndLogger()
+			if err != nil {
+				return err
+			}
+			return app.ImportRecovery(cmd.Context(), source.cfg, viper.GetString(config.FlagRecoveryFile),
+				cmd.OutOrStdout())
+		},
+	}
+	for _, c := range []*cobra.Command{export, imp} {
+		c.Flags().String(config.FlagRecoveryFile, "", "path of the recovery bundle")
+		_ = c.MarkFlagRequired(config.FlagRecoveryFile)
+	}
+	cmd.AddCommand(export, imp)
+	return cmd
+}
+
+func newKeysCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   config.CommandKeys,
+		Short: "Inspect, generate, rotate and export the challenger keys",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   config.CommandKeysShow,
+		Short: "Print the challenger address and bls public key, the private keys are never printed",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			source, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			keys, err := executor.LoadKeys(source.cfg)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "address:        %s\nbls public key: %s\n", keys.Address, keys.BlsPubKey)
+			return nil
+		},
+	})
+
+	generate := &cobra.Command{
+		Use:   config.CommandKeysGenerate,
+		Short: "Generate the challenger keys and store them in the configured keystores or secret manager",
+		Long: "Generate the keys selected by --key and store them according to the key_type of the config, the keystores " +
+			"being encrypted with the keystore passphrase. Stored keys are never overwritten, rotate them instead. The " +
+			"public parts of the keys are printed with the unsigned transaction registering them as the challenger keys " +
+			"of --validator-address, to be signed by the validator operator.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			source, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			kind, err := keysKind()
+			if err != nil {
+				return err
+			}
+			return app.GenerateKeys(source.cfg, viper.GetString(config.FlagKeysIdentity), kind,
+				viper.GetString(config.FlagKeysValidator), cmd.OutOrStdout())
+		},
+	}
+	rotate := &cobra.Command{
+		Use:   config.CommandKeysRotate,
+		Short: "Replace the stored challenger keys by new keys",
+		Long: "Replace the keys selected by --key by new keys, keeping the previous keystores as backups. Challengers " +
+			"reading the keys from a secret manager pick the new keys up on their next key refresh, challengers reading " +
+			"keystores must be restarted. Sign and broadcast the printed registration transaction of --validator-address " +
+			"right away, the votes signed with a bls key which is not registered on chain are not counted. The balance " +
+			"of a rotated challenger address is not moved.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			source, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			kind, err := keysKind()
+			if err != nil {
+				return err
+			}
+			return app.RotateKeys(source.cfg, viper.GetString(config.FlagKeysIdentity), kind,
+				viper.GetString(config.FlagKeysValidator), cmd.OutOrStdout())
+		},
+	}
+	export := &cobra.Command{
+		Use:   config.CommandKeysExport,
+		Short: "Export the stored challenger keys as keystores encrypted with the keystore passphrase",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			source, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			kind, err := keysKind()
+			if err != nil {
+				return err
+			}
+			return app.ExportKeys(source.cfg, viper.GetString(config.FlagKeysIdentity), kind,
+				viper.GetString(config.FlagKeysOutputDir), cmd.OutOrStdout())
+		},
+	}
+	export.Flags().String(config.FlagKeysOutputDir, ".", "directory the keystores are written to")
+	for _, c := range []*cobra.Command{generate, rotate, export} {
+		c.Flags().String(config.FlagKeysKind, executor.KeyKindAll, fmt.Sprintf("keys to handle, one of %s, %s and %s",
+			executor.KeyKindAll, executor.KeyKindTx, executor.KeyKindBls))
+		c.Flags().String(config.FlagKeysIdentity, "", "name of the identity whose keys are handled, the keys of the "+
+			"challenger if empty")
+	}
+	for _, c := range []*cobra.Command{generate, rotate} {
+		c.Flags().String(config.FlagKeysValidator, "", "operator address of the validator registering the keys")
+	}
+	cmd.AddCommand(generate, rotate, export)
+	return cmd
+}
+
+func keysKind() (string, error) {
+	kind := viper.GetString(config.FlagKeysKind)
+	switch kind {
+	case executor.KeyKindAll, executor.KeyKindTx, executor.KeyKindBls:
+		return kind, nil
+	default:
+		return "", fmt.Errorf("--%s should be one of %s, %s and %s", config.FlagKeysKind, executor.KeyKindAll,
+			executor.KeyKindTx, executor.KeyKindBls)
+	}
+}
+
+func newAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   config.CommandAudit,
+		Short: "Inspect the audit file of the votes and attestations",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   config.CommandAuditCheck + " [file]",
+		Short: "Verify the hash chain of an audit file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			count, lastHash, err := audit.Verify(f)
+			if err != nil {
+				return fmt.Errorf("audit file is corrupted after %d intact records, err=%+v", count, err.Error())
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "records:   %d\nlast hash: %s\n", count, lastHash)
+			return nil
+		},
+	})
+	return cmd
+}
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   config.CommandConfig,
+		Short: "Write the default config or dump the loaded one",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   config.CommandConfigInit + " [configFile]",
+		Short: "Write the annotated default config to configFile, or to stdout",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			path := ""
+			if len(args) > 0 {
+				path = args[0]
+			}
+			if err := initConfig(path); err != nil {
+				return fmt.Errorf("config init error, err=%+v", err.Error())
+			}
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   config.CommandConfigDump,
+		Short: "Print the loaded config with the secrets redacted",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			source, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if err = config.WriteConfig(cmd.OutOrStdout(), source.cfg, true); err != nil {
+				return fmt.Errorf("config dump error, err=%+v", err.Error())
+			}
+			return nil
+		},
+	})
+	return cmd
+}
+
+func newVersionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   config.CommandVersion,
+		Short: "Print the version, the commit and the supported chain versions of the binary",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			_, err := fmt.Fprintln(cmd.OutOrStdout(), version.Get())
+			return err
+		},
+	}
+}
+
+func newDashboardCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   config.CommandDashboard,
+		Short: "Print the Grafana dashboard of the challenger metrics",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(metrics.NewDashboard())
+		},
+	}
+}
+
+func newRootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:   "greenfield-challenger",
+		Short: "Greenfield data availability challenger",
+		// running without a subcommand starts the challenger, as before the subcommands were introduced
+		Args:          cobra.NoArgs,
+		RunE:          runChallenger,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	initFlags(root.PersistentFlags())
+	initRunFlags(root.Flags())
+	root.AddCommand(newRunCmd(), newStatusCmd(), newReplayCmd(), newKeysCmd(), newConfigCmd(), newAuditCmd(),
+		newVersionCmd(), newDashboardCmd(), newBudgetCmd(), newMigrateDBCmd(), newRecoveryCmd())
+	// the flags of the executed command are bound, so that the shared flag names resolve to the command being run
+	root.PersistentPreRunE = func(cmd *cobra.Command, _ []string) error {
+		return viper.BindPFlags(cmd.Flags())
+	}
+	return root
+}
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		os.Exit(1)
+	}
 }
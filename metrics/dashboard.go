@@ -0,0 +1,203 @@
+package metrics
+
+import (
+	"fmt"
+)
+
+const (
+	DashboardUID   = "greenfield-challenger"
+	DashboardTitle = "Greenfield Challenger"
+	// dashboardFilter selects the series of the challengers picked in the job variable of the dashboard
+	dashboardFilter = `{job=~"$job"}`
+	dashboardWidth  = 24
+	panelHeight     = 8
+)
+
+// The Grafana dashboard of the metrics, generated so that its queries follow the metric names. The types only hold
+// the fields the dashboard sets, Grafana fills in the rest on import.
+type Dashboard struct {
+	UID           string     `json:"uid"`
+	Title         string     `json:"title"`
+	Tags          []string   `json:"tags"`
+	Editable      bool       `json:"editable"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Refresh       string     `json:"refresh"`
+	Time          TimeRange  `json:"time"`
+	Templating    Templating `json:"templating"`
+	Panels        []*Panel   `json:"panels"`
+}
+
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Templating struct {
+	List []*Variable `json:"list"`
+}
+
+type Variable struct {
+	Name       string      `json:"name"`
+	Label      string      `json:"label"`
+	Type       string      `json:"type"`
+	Query      string      `json:"query"`
+	Datasource *Datasource `json:"datasource,omitempty"`
+	Multi      bool        `json:"multi,omitempty"`
+	IncludeAll bool        `json:"includeAll,omitempty"`
+	AllValue   string      `json:"allValue,omitempty"`
+	Refresh    int         `json:"refresh,omitempty"`
+}
+
+type Datasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type Panel struct {
+	ID          int          `json:"id"`
+	Title       string       `json:"title"`
+	Type        string       `json:"type"`
+	Description string       `json:"description,omitempty"`
+	GridPos     GridPos      `json:"gridPos"`
+	Datasource  *Datasource  `json:"datasource,omitempty"`
+	FieldConfig *FieldConfig `json:"fieldConfig,omitempty"`
+	Targets     []*Target    `json:"targets,omitempty"`
+}
+
+type GridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type FieldConfig struct {
+	Defaults FieldDefaults `json:"defaults"`
+}
+
+type FieldDefaults struct {
+	Unit string `json:"unit"`
+}
+
+type Target struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+}
+
+var prometheusDatasource = &Datasource{Type: "prometheus", UID: "${datasource}"}
+
+// NewDashboard returns the Grafana dashboard of the challenger metrics, grouped in rows by pipeline.
+func NewDashboard() *Dashboard {
+	rows := []struct {
+		title  string
+		panels []*Panel
+	}{
+		{"Pipeline", []*Panel{
+			quantilePanel("Stage duration p50", MetricStageDuration, "stage", 0.5),
+			quantilePanel("Stage duration p95", MetricStageDuration, "stage", 0.95),
+			timeSeriesPanel("Completed stages", "ops", &Target{
+				Expr:         fmt.Sprintf("sum by (stage) (rate(%s_count%s[$__rate_interval]))", MetricStageDuration, dashboardFilter),
+				LegendFormat: "{{stage}}",
+			}),
+			timeSeriesPanel("Events by status", "short", &Target{
+				Expr:         fmt.Sprintf("sum by (status) (%s%s)", MetricEventsByStatus, dashboardFilter),
+				LegendFormat: "{{status}}",
+			}),
+			timeSeriesPanel("Queued items", "short", &Target{
+				Expr:         fmt.Sprintf("sum by (stage) (%s%s)", MetricQueueLength, dashboardFilter),
+				LegendFormat: "{{stage}}",
+			}),
+			timeSeriesPanel("Processed items", "ops", &Target{
+				Expr:         fmt.Sprintf("sum by (stage, outcome) (rate(%s%s[$__rate_interval]))", MetricPipelineItems, dashboardFilter),
+				LegendFormat: "{{stage}} {{outcome}}",
+			}),
+		}},
+		{"Chain", []*Panel{
+			timeSeriesPanel("Saved block", "none", &Target{
+				Expr:         fmt.Sprintf("max by (instance) (%s%s)", MetricGnfdSavedBlock, dashboardFilter),
+				LegendFormat: "{{instance}}",
+			}),
+			quantilePanel("Greenfield rpc p95", MetricRpcRequestDuration, "method", 0.95),
+			quantilePanel("Storage provider request p95", MetricSpRequestDuration, "instance", 0.95),
+			timeSeriesPanel("Attested", "ops", &Target{
+				Expr:         fmt.Sprintf("sum by (instance) (rate(%s%s[$__rate_interval]))", MetricAttestedCount, dashboardFilter),
+				LegendFormat: "{{instance}}",
+			}),
+		}},
+		{"Votepool", []*Panel{
+			timeSeriesPanel("Broadcasts and rejections", "ops", &Target{
+				Expr:         fmt.Sprintf("sum by (event_type) (rate(%s%s[$__rate_interval]))", MetricVotePoolBroadcasts, dashboardFilter),
+				LegendFormat: "broadcast {{event_type}}",
+			}, &Target{
+				Expr:         fmt.Sprintf("sum by (event_type) (rate(%s%s[$__rate_interval]))", MetricVotePoolRejections, dashboardFilter),
+				LegendFormat: "rejected {{event_type}}",
+			}),
+			quantilePanel("Votepool request p95", MetricVotePoolRequestDuration, "method", 0.95),
+		}},
+		{"Runtime", []*Panel{
+			timeSeriesPanel("Loop restarts", "short", &Target{
+				Expr:         fmt.Sprintf("sum by (loop) (increase(%s%s[$__rate_interval]))", MetricLoopRestarts, dashboardFilter),
+				LegendFormat: "{{loop}}",
+			}),
+			timeSeriesPanel("Versions", "short", &Target{
+				Expr:         fmt.Sprintf("count by (version) (%s%s)", MetricBuildInfo, dashboardFilter),
+				LegendFormat: "{{version}}",
+			}),
+		}},
+	}
+
+	dashboard := &Dashboard{
+		UID:           DashboardUID,
+		Title:         DashboardTitle,
+		Tags:          []string{"greenfield", "challenger"},
+		Editable:      true,
+		SchemaVersion: 38,
+		Refresh:       "30s",
+		Time:          TimeRange{From: "now-6h", To: "now"},
+		Templating: Templating{List: []*Variable{
+			{Name: "datasource", Label: "Data source", Type: "datasource", Query: "prometheus"},
+			{Name: "job", Label: "Job", Type: "query", Datasource: prometheusDatasource, Multi: true, IncludeAll: true,
+				AllValue: ".*", Refresh: 2, Query: fmt.Sprintf("label_values(%s, job)", MetricBuildInfo)},
+		}},
+		Panels: make([]*Panel, 0),
+	}
+	y := 0
+	for _, row := range rows {
+		dashboard.Panels = append(dashboard.Panels, &Panel{Title: row.title, Type: "row",
+			GridPos: GridPos{H: 1, W: dashboardWidth, Y: y}})
+		y++
+		width := dashboardWidth / 2
+		for i, panel := range row.panels {
+			panel.GridPos = GridPos{H: panelHeight, W: width, X: (i % 2) * width, Y: y + (i/2)*panelHeight}
+			dashboard.Panels = append(dashboard.Panels, panel)
+		}
+		y += (len(row.panels) + 1) / 2 * panelHeight
+	}
+	for i, panel := range dashboard.Panels {
+		panel.ID = i + 1
+	}
+	return dashboard
+}
+
+func timeSeriesPanel(title, unit string, targets ...*Target) *Panel {
+	for i, target := range targets {
+		target.RefID = string(rune('A' + i))
+	}
+	return &Panel{
+		Title:       title,
+		Type:        "timeseries",
+		Datasource:  prometheusDatasource,
+		FieldConfig: &FieldConfig{Defaults: FieldDefaults{Unit: unit}},
+		Targets:     targets,
+	}
+}
+
+// quantilePanel charts a quantile of a histogram in seconds, by label.
+func quantilePanel(title, histogram, by string, quantile float64) *Panel {
+	return timeSeriesPanel(title, "s", &Target{
+		Expr: fmt.Sprintf("histogram_quantile(%g, sum by (le, %s) (rate(%s_bucket%s[$__rate_interval])))",
+			quantile, by, histogram, dashboardFilter),
+		LegendFormat: fmt.Sprintf("{{%s}}", by),
+	})
+}
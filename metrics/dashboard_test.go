@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+)
+
+func TestNewDashboard(t *testing.T) {
+	dashboard := NewDashboard()
+	_, err := json.Marshal(dashboard)
+	require.NoError(t, err)
+
+	// every query refers to a metric the challenger exports
+	metricService := NewMetricService(&config.Config{})
+	seriesName := regexp.MustCompile(`([a-z_]+)\{job=~"\$job"\}`)
+	ids := make(map[int]bool)
+	queries := 0
+	for _, panel := range dashboard.Panels {
+		require.False(t, ids[panel.ID], "duplicated panel id %d", panel.ID)
+		ids[panel.ID] = true
+		require.LessOrEqual(t, panel.GridPos.X+panel.GridPos.W, dashboardWidth)
+		for _, target := range panel.Targets {
+			matches := seriesName.FindAllStringSubmatch(target.Expr, -1)
+			require.NotEmpty(t, matches, target.Expr)
+			for _, match := range matches {
+				name := match[1]
+				if _, ok := metricService.MetricsMap[name]; !ok {
+					// the series of the histograms are suffixed
+					name = strings.TrimSuffix(strings.TrimSuffix(name, "_bucket"), "_count")
+				}
+				require.Contains(t, metricService.MetricsMap, name, target.Expr)
+				queries++
+			}
+		}
+	}
+	require.Greater(t, queries, 0)
+}
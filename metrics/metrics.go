@@ -1,14 +1,24 @@
 package metrics
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"github.com/bnb-chain/greenfield-challenger/logging"
+	"math/big"
 	"net/http"
+	"strings"
 	"time"
 
+	sdkmath "cosmossdk.io/math"
+
 	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/version"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 )
 
 const (
@@ -17,6 +27,7 @@ const (
 	MetricGnfdSavedBlockCount = "gnfd_saved_block_count"
 	MetricGnfdSavedEvent      = "gnfd_saved_event"
 	MetricGnfdSavedEventCount = "gnfd_saved_event_count"
+	MetricGnfdSourceBlocks    = "gnfd_source_blocks_total"
 
 	// Verifier
 	MetricVerifiedChallenges       = "verified_challenges"
@@ -48,15 +59,110 @@ const (
 
 	// Attest Monitor
 	MetricAttestedCount = "attested_count"
+
+	// Pipeline
+	MetricEventsByStatus     = "events_by_status"
+	MetricRpcRequestDuration = "rpc_request_duration_seconds"
+	MetricSpRequestDuration  = "sp_request_duration_seconds"
+	MetricSpRequestsInFlight = "sp_requests_in_flight"
+	MetricStageDuration      = "pipeline_stage_duration_seconds"
+	MetricPipelineItems      = "pipeline_items_total"
+	MetricItemDuration       = "pipeline_item_duration_seconds"
+	MetricQueueLength        = "pipeline_queue_length"
+
+	// Sla
+	MetricAttestFeesSpent       = "attest_fees_spent_wei_total"
+	MetricAttestRewardsEarned   = "attest_rewards_earned_wei_total"
+	MetricSlaEligibleChallenges = "sla_eligible_challenges"
+	MetricSlaAttestedChallenges = "sla_attested_challenges"
+	MetricSlaAttestSuccessRatio = "sla_attest_success_ratio"
+	MetricSlaTimeToAttestMedian = "sla_time_to_attest_median_seconds"
+	MetricSlaFeesSpent          = "sla_fees_spent_wei"
+	MetricSlaRewardsEarned      = "sla_rewards_earned_wei"
+
+	// Proactive challenges
+	MetricProactiveSamples             = "proactive_samples_total"
+	MetricProactiveChallengesSubmitted = "proactive_challenges_submitted_total"
+	MetricSamplingSamples              = "sampling_samples_total"
+
+	// Verifier plugins
+	MetricVerifierPluginVerdicts = "verifier_plugin_verdicts_total"
+
+	// Verifier sp response cache
+	MetricSpResponseCacheHits = "verifier_sp_response_cache_hits_total"
+
+	// Federation
+	MetricFederationPeerResults = "federation_peer_results_total"
+	MetricFederationSkipped     = "federation_skipped_verifications_total"
+
+	// Chain compatibility
+	MetricChainCompatible = "chain_compatible"
+
+	// Economics
+	MetricEconomicsFeesSpent     = "economics_fees_spent_wei"
+	MetricEconomicsRewardsEarned = "economics_rewards_earned_wei"
+	MetricEconomicsNetProfit     = "economics_net_profit_wei"
+	MetricEconomicsChallenges    = "economics_challenges"
+
+	// Cross-chain relay
+	MetricRelayPackages        = "relay_packages_total"
+	MetricRelayPendingPackages = "relay_pending_packages"
+
+	// Indexer cross-check
+	MetricIndexerDiscrepancies = "indexer_discrepancies_total"
+	MetricIndexerCheckedHeight = "indexer_checked_height"
+
+	// Scanner intake
+	MetricScannerSuspects       = "scanner_suspects_total"
+	MetricScannerQueuedSuspects = "scanner_queued_suspects"
+
+	// Vote relay proxy
+	MetricVoteProxyVotes = "vote_proxy_votes_total"
+
+	// Storage provider reputation
+	MetricSpReputationScore = "sp_reputation_score"
+
+	// High availability
+	MetricHaLeader     = "ha_leader"
+	MetricHaHeldShards = "ha_held_shards"
+
+	// Votepool
+	MetricVotePoolBroadcasts      = "votepool_broadcasts_total"
+	MetricVotePoolRejections      = "votepool_broadcast_rejections_total"
+	MetricVotePoolQueryErrors     = "votepool_query_errors_total"
+	MetricVotePoolQuerySize       = "votepool_query_size"
+	MetricVotePoolRequestDuration = "votepool_request_duration_seconds"
+
+	// Supervisor
+	MetricLoopRestarts      = "loop_restarts_total"
+	MetricLoopLastHeartbeat = "loop_last_heartbeat_timestamp_seconds"
+
+	// Build
+	MetricBuildInfo = "build_info"
+
+	// EventStatsInterval is how often the events by status are counted in the database.
+	EventStatsInterval = 30 * time.Second
 )
 
+// EventCounter counts the events in the database by their status.
+type EventCounter interface {
+	CountEventsByStatus(ctx context.Context) (map[model.EventStatus]int64, error)
+}
+
 type MetricService struct {
-	MetricsMap map[string]prometheus.Metric
+	MetricsMap map[string]prometheus.Collector
 	cfg        *config.Config
+	// registry holds the metrics of all components, which are exposed at /metrics
+	registry *prometheus.Registry
+	// mux serves /metrics and the endpoints added with Handle on the metrics port
+	mux *http.ServeMux
 }
 
 func NewMetricService(config *config.Config) *MetricService {
-	ms := make(map[string]prometheus.Metric, 0)
+	ms := make(map[string]prometheus.Collector, 0)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector())
+	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 
 	// Monitor
 	gnfdSavedBlockMetric := prometheus.NewGauge(prometheus.GaugeOpts{
@@ -64,28 +170,35 @@ func NewMetricService(config *config.Config) *MetricService {
 		Help: "Saved block height for Greenfield in database",
 	})
 	ms[MetricGnfdSavedBlock] = gnfdSavedBlockMetric
-	prometheus.MustRegister(gnfdSavedBlockMetric)
+	registry.MustRegister(gnfdSavedBlockMetric)
 
 	gnfdSavedBlockCountMetric := prometheus.NewCounter(prometheus.CounterOpts{
 		Name: MetricGnfdSavedBlockCount,
 		Help: "Saved block count for Greenfield in database",
 	})
 	ms[MetricGnfdSavedBlockCount] = gnfdSavedBlockCountMetric
-	prometheus.MustRegister(gnfdSavedBlockCountMetric)
+	registry.MustRegister(gnfdSavedBlockCountMetric)
 
 	gnfdSavedEventMetric := prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: MetricGnfdSavedEvent,
 		Help: "Saved event challengeId in database",
 	})
 	ms[MetricGnfdSavedEvent] = gnfdSavedEventMetric
-	prometheus.MustRegister(gnfdSavedEventMetric)
+	registry.MustRegister(gnfdSavedEventMetric)
 
 	gnfdSavedEventCountMetric := prometheus.NewCounter(prometheus.CounterOpts{
 		Name: MetricGnfdSavedEventCount,
 		Help: "Saved gnfd event count in database",
 	})
 	ms[MetricGnfdSavedEventCount] = gnfdSavedEventCountMetric
-	prometheus.MustRegister(gnfdSavedEventCountMetric)
+	registry.MustRegister(gnfdSavedEventCountMetric)
+
+	gnfdSourceBlocksMetric := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: MetricGnfdSourceBlocks,
+		Help: "Blocks polled from the event source while the rpc endpoints were down",
+	})
+	ms[MetricGnfdSourceBlocks] = gnfdSourceBlocksMetric
+	registry.MustRegister(gnfdSourceBlocksMetric)
 
 	// Hash Verifier
 	verifiedChallengesMetric := prometheus.NewCounter(prometheus.CounterOpts{
@@ -93,49 +206,49 @@ func NewMetricService(config *config.Config) *MetricService {
 		Help: "Verified challenge count",
 	})
 	ms[MetricVerifiedChallenges] = verifiedChallengesMetric
-	prometheus.MustRegister(verifiedChallengesMetric)
+	registry.MustRegister(verifiedChallengesMetric)
 
 	hashVerifierDurationMetric := prometheus.NewHistogram(prometheus.HistogramOpts{
 		Name: MetricHashVerifierDuration,
 		Help: "Duration of the hash verifier process for each challenge ID",
 	})
 	ms[MetricHashVerifierDuration] = hashVerifierDurationMetric
-	prometheus.MustRegister(hashVerifierDurationMetric)
+	registry.MustRegister(hashVerifierDurationMetric)
 
 	challengeFailedMetric := prometheus.NewCounter(prometheus.CounterOpts{
 		Name: MetricVerifiedChallengeFailed,
 		Help: "Failed challenges in database",
 	})
 	ms[MetricVerifiedChallengeFailed] = challengeFailedMetric
-	prometheus.MustRegister(challengeFailedMetric)
+	registry.MustRegister(challengeFailedMetric)
 
 	challengeSuccessMetric := prometheus.NewCounter(prometheus.CounterOpts{
 		Name: MetricVerifiedChallengeSuccess,
 		Help: "Succeeded challenges in database",
 	})
 	ms[MetricVerifiedChallengeSuccess] = challengeSuccessMetric
-	prometheus.MustRegister(challengeSuccessMetric)
+	registry.MustRegister(challengeSuccessMetric)
 
 	heartbeatEventsMetric := prometheus.NewCounter(prometheus.CounterOpts{
 		Name: MetricHeartbeatEvents,
 		Help: "Heartbeat challenges",
 	})
 	ms[MetricHeartbeatEvents] = heartbeatEventsMetric
-	prometheus.MustRegister(heartbeatEventsMetric)
+	registry.MustRegister(heartbeatEventsMetric)
 
 	hashVerifierErrCountMetric := prometheus.NewCounter(prometheus.CounterOpts{
 		Name: MetricHashVerifierErr,
 		Help: "Hash verifier error count",
 	})
 	ms[MetricHashVerifierErr] = hashVerifierErrCountMetric
-	prometheus.MustRegister(hashVerifierErrCountMetric)
+	registry.MustRegister(hashVerifierErrCountMetric)
 
 	hashVerifierSpApiErrCountMetric := prometheus.NewCounter(prometheus.CounterOpts{
 		Name: MetricSpAPIErr,
 		Help: "Hash verifier SP API error count",
 	})
 	ms[MetricSpAPIErr] = hashVerifierSpApiErrCountMetric
-	prometheus.MustRegister(hashVerifierSpApiErrCountMetric)
+	registry.MustRegister(hashVerifierSpApiErrCountMetric)
 
 	// Broadcaster
 	broadcasterErrCountMetric := prometheus.NewCounter(prometheus.CounterOpts{
@@ -143,21 +256,21 @@ func NewMetricService(config *config.Config) *MetricService {
 		Help: "Broadcaster error count",
 	})
 	ms[MetricBroadcasterErr] = broadcasterErrCountMetric
-	prometheus.MustRegister(broadcasterErrCountMetric)
+	registry.MustRegister(broadcasterErrCountMetric)
 
 	broadcastedChallengesMetric := prometheus.NewCounter(prometheus.CounterOpts{
 		Name: MetricBroadcastedChallenges,
 		Help: "Broadcasted challenge count",
 	})
 	ms[MetricBroadcastedChallenges] = broadcastedChallengesMetric
-	prometheus.MustRegister(broadcastedChallengesMetric)
+	registry.MustRegister(broadcastedChallengesMetric)
 
 	broadcastedDurationMetric := prometheus.NewHistogram(prometheus.HistogramOpts{
 		Name: MetricBroadcasterDuration,
 		Help: "Broadcaster duration for 1 challenge",
 	})
 	ms[MetricBroadcasterDuration] = broadcastedDurationMetric
-	prometheus.MustRegister(broadcastedDurationMetric)
+	registry.MustRegister(broadcastedDurationMetric)
 
 	// Vote Collector
 	voteCollectorErrCountMetric := prometheus.NewCounter(prometheus.CounterOpts{
@@ -165,14 +278,14 @@ func NewMetricService(config *config.Config) *MetricService {
 		Help: "Vote Collector error count",
 	})
 	ms[MetricsVoteCollectorErr] = voteCollectorErrCountMetric
-	prometheus.MustRegister(voteCollectorErrCountMetric)
+	registry.MustRegister(voteCollectorErrCountMetric)
 
 	votesCollectedMetric := prometheus.NewCounter(prometheus.CounterOpts{
 		Name: MetricsVotesCollected,
 		Help: "Votes collected count",
 	})
 	ms[MetricsVotesCollected] = votesCollectedMetric
-	prometheus.MustRegister(votesCollectedMetric)
+	registry.MustRegister(votesCollectedMetric)
 
 	// Collator
 	collatorErrCountMetric := prometheus.NewCounter(prometheus.CounterOpts{
@@ -180,21 +293,21 @@ func NewMetricService(config *config.Config) *MetricService {
 		Help: "Collator error count",
 	})
 	ms[MetricCollatorErr] = collatorErrCountMetric
-	prometheus.MustRegister(collatorErrCountMetric)
+	registry.MustRegister(collatorErrCountMetric)
 
 	collatedChallengesMetric := prometheus.NewCounter(prometheus.CounterOpts{
 		Name: MetricCollatedChallenges,
 		Help: "Collated challenge count",
 	})
 	ms[MetricCollatedChallenges] = collatedChallengesMetric
-	prometheus.MustRegister(collatedChallengesMetric)
+	registry.MustRegister(collatedChallengesMetric)
 
 	collatedDurationMetric := prometheus.NewHistogram(prometheus.HistogramOpts{
 		Name: MetricCollatorDuration,
 		Help: "Collator duration for 1 challenge",
 	})
 	ms[MetricCollatorDuration] = collatedDurationMetric
-	prometheus.MustRegister(collatedDurationMetric)
+	registry.MustRegister(collatedDurationMetric)
 
 	// Submitter
 	submitterErrCountMetric := prometheus.NewCounter(prometheus.CounterOpts{
@@ -202,21 +315,21 @@ func NewMetricService(config *config.Config) *MetricService {
 		Help: "Submitter error count",
 	})
 	ms[MetricSubmitterErr] = submitterErrCountMetric
-	prometheus.MustRegister(submitterErrCountMetric)
+	registry.MustRegister(submitterErrCountMetric)
 
 	submitterChallengesMetric := prometheus.NewCounter(prometheus.CounterOpts{
 		Name: MetricSubmittedChallenges,
 		Help: "Submitted challenge count",
 	})
 	ms[MetricSubmittedChallenges] = submitterChallengesMetric
-	prometheus.MustRegister(submitterChallengesMetric)
+	registry.MustRegister(submitterChallengesMetric)
 
 	submitterDurationMetric := prometheus.NewHistogram(prometheus.HistogramOpts{
 		Name: MetricSubmitterDuration,
 		Help: "Submitter duration for 1 challengeID",
 	})
 	ms[MetricSubmitterDuration] = submitterDurationMetric
-	prometheus.MustRegister(submitterDurationMetric)
+	registry.MustRegister(submitterDurationMetric)
 
 	// Attest Monitor
 	challengeAttestedCountMetric := prometheus.NewCounter(prometheus.CounterOpts{
@@ -224,22 +337,416 @@ func NewMetricService(config *config.Config) *MetricService {
 		Help: "Attested challenges count",
 	})
 	ms[MetricAttestedCount] = challengeAttestedCountMetric
-	prometheus.MustRegister(challengeAttestedCountMetric)
+	registry.MustRegister(challengeAttestedCountMetric)
+
+	// Pipeline
+	eventsByStatusMetric := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: MetricEventsByStatus,
+		Help: "Events in database by status",
+	}, []string{"status"})
+	ms[MetricEventsByStatus] = eventsByStatusMetric
+	registry.MustRegister(eventsByStatusMetric)
+
+	rpcRequestDurationMetric := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: MetricRpcRequestDuration,
+		Help: "Duration of the greenfield rpc requests by method",
+	}, []string{"method"})
+	ms[MetricRpcRequestDuration] = rpcRequestDurationMetric
+	registry.MustRegister(rpcRequestDurationMetric)
+
+	spRequestDurationMetric := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: MetricSpRequestDuration,
+		Help: "Duration of the challenge requests to storage providers",
+	})
+	ms[MetricSpRequestDuration] = spRequestDurationMetric
+	registry.MustRegister(spRequestDurationMetric)
+
+	spRequestsInFlightMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: MetricSpRequestsInFlight,
+		Help: "Challenge requests in flight to storage providers, capped by max_sp_requests",
+	})
+	ms[MetricSpRequestsInFlight] = spRequestsInFlightMetric
+	registry.MustRegister(spRequestsInFlightMetric)
+
+	stageDurationMetric := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: MetricStageDuration,
+		Help: "Time the challenges took to complete each pipeline stage, by stage",
+		// from sub-second verifications to attestations close to the expiry of the challenges
+		Buckets: prometheus.ExponentialBuckets(0.5, 2, 13),
+	}, []string{"stage"})
+	ms[MetricStageDuration] = stageDurationMetric
+	registry.MustRegister(stageDurationMetric)
+	pipelineItemsMetric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricPipelineItems,
+		Help: "Items processed by the workers of the monitor, broadcaster and collator, by stage and outcome",
+	}, []string{"stage", "outcome"})
+	ms[MetricPipelineItems] = pipelineItemsMetric
+	registry.MustRegister(pipelineItemsMetric)
+	itemDurationMetric := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: MetricItemDuration,
+		Help: "Time a worker took to process an item, by stage",
+	}, []string{"stage"})
+	ms[MetricItemDuration] = itemDurationMetric
+	registry.MustRegister(itemDurationMetric)
+	queueLengthMetric := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: MetricQueueLength,
+		Help: "Items fetched and waiting for a worker, by stage",
+	}, []string{"stage"})
+	ms[MetricQueueLength] = queueLengthMetric
+	registry.MustRegister(queueLengthMetric)
+
+	// Sla
+	attestFeesSpentMetric := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: MetricAttestFeesSpent,
+		Help: "Fees paid by the attest transactions sent, in wei",
+	})
+	ms[MetricAttestFeesSpent] = attestFeesSpentMetric
+	registry.MustRegister(attestFeesSpentMetric)
+
+	attestRewardsEarnedMetric := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: MetricAttestRewardsEarned,
+		Help: "Rewards of the attested challenges paid to the challenger account, in wei",
+	})
+	ms[MetricAttestRewardsEarned] = attestRewardsEarnedMetric
+	registry.MustRegister(attestRewardsEarnedMetric)
 
-	return &MetricService{
+	slaEligibleChallengesMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: MetricSlaEligibleChallenges,
+		Help: "Challenges verified as mismatched which were attested or expired within the sla window",
+	})
+	ms[MetricSlaEligibleChallenges] = slaEligibleChallengesMetric
+	registry.MustRegister(slaEligibleChallengesMetric)
+
+	slaAttestedChallengesMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: MetricSlaAttestedChallenges,
+		Help: "Eligible challenges attested before their expiry within the sla window",
+	})
+	ms[MetricSlaAttestedChallenges] = slaAttestedChallengesMetric
+	registry.MustRegister(slaAttestedChallengesMetric)
+
+	slaAttestSuccessRatioMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: MetricSlaAttestSuccessRatio,
+		Help: "Share of the eligible challenges attested before their expiry within the sla window",
+	})
+	ms[MetricSlaAttestSuccessRatio] = slaAttestSuccessRatioMetric
+	registry.MustRegister(slaAttestSuccessRatioMetric)
+
+	slaTimeToAttestMedianMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: MetricSlaTimeToAttestMedian,
+		Help: "Median time from ingestion to attestation within the sla window",
+	})
+	ms[MetricSlaTimeToAttestMedian] = slaTimeToAttestMedianMetric
+	registry.MustRegister(slaTimeToAttestMedianMetric)
+
+	slaFeesSpentMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: MetricSlaFeesSpent,
+		Help: "Fees paid by the attest transactions within the sla window, in wei",
+	})
+	ms[MetricSlaFeesSpent] = slaFeesSpentMetric
+	registry.MustRegister(slaFeesSpentMetric)
+
+	slaRewardsEarnedMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: MetricSlaRewardsEarned,
+		Help: "Rewards earned within the sla window, in wei",
+	})
+	ms[MetricSlaRewardsEarned] = slaRewardsEarnedMetric
+	registry.MustRegister(slaRewardsEarnedMetric)
+
+	// Proactive challenges
+	proactiveSamplesMetric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricProactiveSamples,
+		Help: "Objects sampled for proactive challenges, by result",
+	}, []string{"result"})
+	ms[MetricProactiveSamples] = proactiveSamplesMetric
+	registry.MustRegister(proactiveSamplesMetric)
+
+	proactiveChallengesSubmittedMetric := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: MetricProactiveChallengesSubmitted,
+		Help: "Challenges submitted for the sampled objects",
+	})
+	ms[MetricProactiveChallengesSubmitted] = proactiveChallengesSubmittedMetric
+	registry.MustRegister(proactiveChallengesSubmittedMetric)
+
+	samplingSamplesMetric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricSamplingSamples,
+		Help: "Pieces sampled for availability in the background, by result",
+	}, []string{"result"})
+	ms[MetricSamplingSamples] = samplingSamplesMetric
+	registry.MustRegister(samplingSamplesMetric)
+
+	// Verifier plugins
+	verifierPluginVerdictsMetric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricVerifierPluginVerdicts,
+		Help: "Pieces checked by the verifier plugins, by plugin and verdict",
+	}, []string{"plugin", "verdict"})
+	ms[MetricVerifierPluginVerdicts] = verifierPluginVerdictsMetric
+	registry.MustRegister(verifierPluginVerdictsMetric)
+
+	// Verifier sp response cache
+	spResponseCacheHitsMetric := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: MetricSpResponseCacheHits,
+		Help: "Pieces not requested from the storage providers as their response to the same piece was cached",
+	})
+	ms[MetricSpResponseCacheHits] = spResponseCacheHitsMetric
+	registry.MustRegister(spResponseCacheHitsMetric)
+
+	// Federation
+	federationPeerResultsMetric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricFederationPeerResults,
+		Help: "Verify results of the trusted challengers compared to this challenger, by peer and verdict",
+	}, []string{"peer", "verdict"})
+	ms[MetricFederationPeerResults] = federationPeerResultsMetric
+	registry.MustRegister(federationPeerResultsMetric)
+	federationSkippedMetric := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: MetricFederationSkipped,
+		Help: "Pieces not requested from the storage providers as enough trusted challengers found them matched",
+	})
+	ms[MetricFederationSkipped] = federationSkippedMetric
+	registry.MustRegister(federationSkippedMetric)
+
+	// Chain compatibility
+	chainCompatibleMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: MetricChainCompatible,
+		Help: "1 if the versions of the chain are in the compatibility matrix, 0 if they are not",
+	})
+	ms[MetricChainCompatible] = chainCompatibleMetric
+	registry.MustRegister(chainCompatibleMetric)
+
+	// Economics
+	economicsFeesSpentMetric := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: MetricEconomicsFeesSpent,
+		Help: "Fees paid by the challenger account over the economics window, in wei, by kind of transaction",
+	}, []string{"kind"})
+	ms[MetricEconomicsFeesSpent] = economicsFeesSpentMetric
+	registry.MustRegister(economicsFeesSpentMetric)
+	economicsRewardsEarnedMetric := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: MetricEconomicsRewardsEarned,
+		Help: "Rewards credited to the challenger account over the economics window, in wei, by kind of transaction",
+	}, []string{"kind"})
+	ms[MetricEconomicsRewardsEarned] = economicsRewardsEarnedMetric
+	registry.MustRegister(economicsRewardsEarnedMetric)
+	economicsNetProfitMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: MetricEconomicsNetProfit,
+		Help: "Rewards earned minus fees spent by the challenger account over the economics window, in wei",
+	})
+	ms[MetricEconomicsNetProfit] = economicsNetProfitMetric
+	registry.MustRegister(economicsNetProfitMetric)
+	economicsChallengesMetric := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: MetricEconomicsChallenges,
+		Help: "Challenges the challenger account paid for or was rewarded by over the economics window, by outcome",
+	}, []string{"outcome"})
+	ms[MetricEconomicsChallenges] = economicsChallengesMetric
+	registry.MustRegister(economicsChallengesMetric)
+
+	// Cross-chain relay
+	relayPackagesMetric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricRelayPackages,
+		Help: "Cross-chain packages of the attestations followed to BSC, by outcome",
+	}, []string{"outcome"})
+	ms[MetricRelayPackages] = relayPackagesMetric
+	registry.MustRegister(relayPackagesMetric)
+	relayPendingPackagesMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: MetricRelayPendingPackages,
+		Help: "Cross-chain packages of the attestations not relayed to BSC yet",
+	})
+	ms[MetricRelayPendingPackages] = relayPendingPackagesMetric
+	registry.MustRegister(relayPendingPackagesMetric)
+
+	// Indexer cross-check
+	indexerDiscrepanciesMetric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricIndexerDiscrepancies,
+		Help: "Challenges the saved events disagree on with the indexer, by kind",
+	}, []string{"kind"})
+	ms[MetricIndexerDiscrepancies] = indexerDiscrepanciesMetric
+	registry.MustRegister(indexerDiscrepanciesMetric)
+	indexerCheckedHeightMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: MetricIndexerCheckedHeight,
+		Help: "Latest block cross-checked with the indexer",
+	})
+	ms[MetricIndexerCheckedHeight] = indexerCheckedHeightMetric
+	registry.MustRegister(indexerCheckedHeightMetric)
+
+	// Scanner intake
+	scannerSuspectsMetric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricScannerSuspects,
+		Help: "Pieces suspected by the external scanner which were verified, by result",
+	}, []string{"result"})
+	ms[MetricScannerSuspects] = scannerSuspectsMetric
+	registry.MustRegister(scannerSuspectsMetric)
+	scannerQueuedSuspectsMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: MetricScannerQueuedSuspects,
+		Help: "Suspects of the external scanner waiting to be verified",
+	})
+	ms[MetricScannerQueuedSuspects] = scannerQueuedSuspectsMetric
+	registry.MustRegister(scannerQueuedSuspectsMetric)
+
+	// Vote relay proxy
+	voteProxyVotesMetric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricVoteProxyVotes,
+		Help: "Votes submitted to the vote relay proxy, by client and result",
+	}, []string{"client", "result"})
+	ms[MetricVoteProxyVotes] = voteProxyVotesMetric
+	registry.MustRegister(voteProxyVotesMetric)
+
+	// Storage provider reputation
+	spReputationScoreMetric := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: MetricSpReputationScore,
+		Help: "Reputation score of the storage providers, from 0 to 1",
+	}, []string{"sp"})
+	ms[MetricSpReputationScore] = spReputationScoreMetric
+	registry.MustRegister(spReputationScoreMetric)
+
+	// High availability
+	haLeaderMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: MetricHaLeader,
+		Help: "1 if the challenger is the leader of the challengers sharing its database, 0 if it stands by",
+	})
+	ms[MetricHaLeader] = haLeaderMetric
+	registry.MustRegister(haLeaderMetric)
+
+	haHeldShardsMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: MetricHaHeldShards,
+		Help: "Shards of the events held by the challenger",
+	})
+	ms[MetricHaHeldShards] = haHeldShardsMetric
+	registry.MustRegister(haHeldShardsMetric)
+
+	// Votepool
+	votePoolBroadcastsMetric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricVotePoolBroadcasts,
+		Help: "Votes broadcast to the votepool, by event type",
+	}, []string{"event_type"})
+	ms[MetricVotePoolBroadcasts] = votePoolBroadcastsMetric
+	registry.MustRegister(votePoolBroadcastsMetric)
+
+	votePoolRejectionsMetric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricVotePoolRejections,
+		Help: "Votes which the votepool did not accept, by event type",
+	}, []string{"event_type"})
+	ms[MetricVotePoolRejections] = votePoolRejectionsMetric
+	registry.MustRegister(votePoolRejectionsMetric)
+
+	votePoolQueryErrorsMetric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricVotePoolQueryErrors,
+		Help: "Failed votepool queries, by event type",
+	}, []string{"event_type"})
+	ms[MetricVotePoolQueryErrors] = votePoolQueryErrorsMetric
+	registry.MustRegister(votePoolQueryErrorsMetric)
+
+	votePoolQuerySizeMetric := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    MetricVotePoolQuerySize,
+		Help:    "Votes returned by a votepool query, by event type",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 8),
+	}, []string{"event_type"})
+	ms[MetricVotePoolQuerySize] = votePoolQuerySizeMetric
+	registry.MustRegister(votePoolQuerySizeMetric)
+
+	votePoolRequestDurationMetric := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: MetricVotePoolRequestDuration,
+		Help: "Duration of the votepool requests, by method and event type",
+	}, []string{"method", "event_type"})
+	ms[MetricVotePoolRequestDuration] = votePoolRequestDurationMetric
+	registry.MustRegister(votePoolRequestDurationMetric)
+
+	// Supervisor
+	loopRestartsMetric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricLoopRestarts,
+		Help: "Restarts of the loops which crashed, by loop",
+	}, []string{"loop"})
+	ms[MetricLoopRestarts] = loopRestartsMetric
+	registry.MustRegister(loopRestartsMetric)
+
+	loopLastHeartbeatMetric := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: MetricLoopLastHeartbeat,
+		Help: "Unix time of the last iteration of the loops, by loop",
+	}, []string{"loop"})
+	ms[MetricLoopLastHeartbeat] = loopLastHeartbeatMetric
+	registry.MustRegister(loopLastHeartbeatMetric)
+
+	// Build
+	info := version.Get()
+	buildInfoMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: MetricBuildInfo,
+		Help: "Always 1, labelled with the build information of the binary",
+		ConstLabels: prometheus.Labels{
+			"version":        info.Version,
+			"git_commit":     info.GitCommit,
+			"build_date":     info.BuildDate,
+			"chain_versions": strings.Join(info.ChainVersions, ","),
+		},
+	})
+	buildInfoMetric.Set(1)
+	ms[MetricBuildInfo] = buildInfoMetric
+	registry.MustRegister(buildInfoMetric)
+
+	metricService := &MetricService{
 		MetricsMap: ms,
 		cfg:        config,
+		registry:   registry,
+		mux:        http.NewServeMux(),
 	}
+	metricService.mux.Handle("/metrics", metricService.Handler())
+	return metricService
 }
 
-func (m *MetricService) Start() {
-	http.Handle("/metrics", promhttp.Handler())
-	err := http.ListenAndServe(fmt.Sprintf(":%d", m.cfg.MetricsConfig.Port), nil)
-	if err != nil {
+// Register adds the collector of a component to the metrics exposed at /metrics.
+func (m *MetricService) Register(collector prometheus.Collector) {
+	m.registry.MustRegister(collector)
+}
+
+// RegisterDB exposes the connection pool stats of the database.
+func (m *MetricService) RegisterDB(db *sql.DB) {
+	m.Register(collectors.NewDBStatsCollector(db, "challenger"))
+}
+
+// Handler serves the registered metrics.
+func (m *MetricService) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Gatherer returns the registry of the metrics, e.g. to push them elsewhere.
+func (m *MetricService) Gatherer() prometheus.Gatherer {
+	return m.registry
+}
+
+// Handle serves handler at pattern on the metrics port, it must be called before Start.
+func (m *MetricService) Handle(pattern string, handler http.Handler) {
+	m.mux.Handle(pattern, handler)
+}
+
+// Start serves the metrics port until ctx is done.
+func (m *MetricService) Start(ctx context.Context) {
+	server := &http.Server{Addr: fmt.Sprintf(":%d", m.cfg.MetricsConfig.Port), Handler: m.mux}
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+	err := server.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
 		panic(err)
 	}
 }
 
+// EventStatsLoop counts the events by status periodically, the counts come from the database so that they survive
+// restarts.
+func (m *MetricService) EventStatsLoop(ctx context.Context, counter EventCounter) {
+	ticker := time.NewTicker(EventStatsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		counts, err := counter.CountEventsByStatus(ctx)
+		if err != nil {
+			logging.Logger.Errorf("failed to count events by status, err=%+v", err.Error())
+			continue
+		}
+		m.SetEventsByStatus(counts)
+	}
+}
+
 // Monitor
 func (m *MetricService) SetGnfdSavedBlock(height uint64) {
 	m.MetricsMap[MetricGnfdSavedBlock].(prometheus.Gauge).Set(float64(height))
@@ -249,6 +756,10 @@ func (m *MetricService) IncGnfdSavedBlockCount() {
 	m.MetricsMap[MetricGnfdSavedBlockCount].(prometheus.Counter).Inc()
 }
 
+func (m *MetricService) AddGnfdSourceBlockCount(count int) {
+	m.MetricsMap[MetricGnfdSourceBlocks].(prometheus.Counter).Add(float64(count))
+}
+
 func (m *MetricService) SetGnfdSavedEvent(challengeId uint64) {
 	m.MetricsMap[MetricGnfdSavedEvent].(prometheus.Gauge).Set(float64(challengeId))
 }
@@ -354,3 +865,222 @@ func (m *MetricService) IncSubmitterErr(err error) {
 func (m *MetricService) IncAttestedChallenges() {
 	m.MetricsMap[MetricAttestedCount].(prometheus.Counter).Inc()
 }
+
+// Pipeline
+func (m *MetricService) SetEventsByStatus(counts map[model.EventStatus]int64) {
+	gauge := m.MetricsMap[MetricEventsByStatus].(*prometheus.GaugeVec)
+	gauge.Reset()
+	for status, count := range counts {
+		gauge.WithLabelValues(status.String()).Set(float64(count))
+	}
+}
+
+func (m *MetricService) ObserveRpcRequest(method string, duration time.Duration) {
+	m.MetricsMap[MetricRpcRequestDuration].(*prometheus.HistogramVec).WithLabelValues(method).Observe(duration.Seconds())
+}
+
+func (m *MetricService) ObserveSpRequest(duration time.Duration) {
+	m.MetricsMap[MetricSpRequestDuration].(prometheus.Histogram).Observe(duration.Seconds())
+}
+
+func (m *MetricService) SetSpRequestsInFlight(count int) {
+	m.MetricsMap[MetricSpRequestsInFlight].(prometheus.Gauge).Set(float64(count))
+}
+
+func (m *MetricService) ObserveStageDuration(stage string, duration time.Duration) {
+	m.MetricsMap[MetricStageDuration].(*prometheus.HistogramVec).WithLabelValues(stage).Observe(duration.Seconds())
+}
+
+// ObservePipelineItem records an item processed by a worker of the stage.
+func (m *MetricService) ObservePipelineItem(stage string, duration time.Duration, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	m.MetricsMap[MetricPipelineItems].(*prometheus.CounterVec).WithLabelValues(stage, outcome).Inc()
+	m.MetricsMap[MetricItemDuration].(*prometheus.HistogramVec).WithLabelValues(stage).Observe(duration.Seconds())
+}
+
+func (m *MetricService) SetPipelineQueueLength(stage string, length int) {
+	m.MetricsMap[MetricQueueLength].(*prometheus.GaugeVec).WithLabelValues(stage).Set(float64(length))
+}
+
+// Sla
+func (m *MetricService) AddAttestFees(amount sdkmath.Int) {
+	m.MetricsMap[MetricAttestFeesSpent].(prometheus.Counter).Add(intToFloat(amount))
+}
+
+func (m *MetricService) AddAttestRewards(amount sdkmath.Int) {
+	m.MetricsMap[MetricAttestRewardsEarned].(prometheus.Counter).Add(intToFloat(amount))
+}
+
+// AttestFeesAndRewards returns the fees spent and the rewards earned since the start.
+func (m *MetricService) AttestFeesAndRewards() (float64, float64) {
+	return counterValue(m.MetricsMap[MetricAttestFeesSpent].(prometheus.Counter)),
+		counterValue(m.MetricsMap[MetricAttestRewardsEarned].(prometheus.Counter))
+}
+
+// SetSlaReport sets the sla gauges, the success ratio is only set if a challenge was eligible.
+func (m *MetricService) SetSlaReport(eligible, attested int, medianTimeToAttest time.Duration, fees, rewards float64) {
+	m.MetricsMap[MetricSlaEligibleChallenges].(prometheus.Gauge).Set(float64(eligible))
+	m.MetricsMap[MetricSlaAttestedChallenges].(prometheus.Gauge).Set(float64(attested))
+	if eligible > 0 {
+		m.MetricsMap[MetricSlaAttestSuccessRatio].(prometheus.Gauge).Set(float64(attested) / float64(eligible))
+	}
+	m.MetricsMap[MetricSlaTimeToAttestMedian].(prometheus.Gauge).Set(medianTimeToAttest.Seconds())
+	m.MetricsMap[MetricSlaFeesSpent].(prometheus.Gauge).Set(fees)
+	m.MetricsMap[MetricSlaRewardsEarned].(prometheus.Gauge).Set(rewards)
+}
+
+// intToFloat converts the amounts in wei, which overflow the integers, the precision lost does not matter to the
+// metrics.
+func intToFloat(amount sdkmath.Int) float64 {
+	if amount.IsNil() || !amount.IsPositive() {
+		return 0
+	}
+	f, _ := new(big.Float).SetInt(amount.BigInt()).Float64()
+	return f
+}
+
+func counterValue(counter prometheus.Counter) float64 {
+	metric := &dto.Metric{}
+	if err := counter.Write(metric); err != nil {
+		return 0
+	}
+	return metric.GetCounter().GetValue()
+}
+
+// Proactive challenges
+func (m *MetricService) IncProactiveSamples(result string) {
+	m.MetricsMap[MetricProactiveSamples].(*prometheus.CounterVec).WithLabelValues(result).Inc()
+}
+
+func (m *MetricService) IncProactiveChallengesSubmitted() {
+	m.MetricsMap[MetricProactiveChallengesSubmitted].(prometheus.Counter).Inc()
+}
+
+func (m *MetricService) IncSamplingSamples(result string) {
+	m.MetricsMap[MetricSamplingSamples].(*prometheus.CounterVec).WithLabelValues(result).Inc()
+}
+
+// Verifier plugins
+func (m *MetricService) IncVerifierPluginVerdicts(plugin, verdict string) {
+	m.MetricsMap[MetricVerifierPluginVerdicts].(*prometheus.CounterVec).WithLabelValues(plugin, verdict).Inc()
+}
+
+// Verifier sp response cache
+func (m *MetricService) IncSpResponseCacheHits() {
+	m.MetricsMap[MetricSpResponseCacheHits].(prometheus.Counter).Inc()
+}
+
+// Federation
+func (m *MetricService) IncFederationPeerResults(peer, verdict string) {
+	m.MetricsMap[MetricFederationPeerResults].(*prometheus.CounterVec).WithLabelValues(peer, verdict).Inc()
+}
+
+func (m *MetricService) IncFederationSkipped() {
+	m.MetricsMap[MetricFederationSkipped].(prometheus.Counter).Inc()
+}
+
+// Chain compatibility
+func (m *MetricService) SetChainCompatible(compatible bool) {
+	value := 0.0
+	if compatible {
+		value = 1
+	}
+	m.MetricsMap[MetricChainCompatible].(prometheus.Gauge).Set(value)
+}
+
+// Economics
+func (m *MetricService) SetEconomics(kind string, feesSpent, rewardsEarned sdkmath.Int) {
+	m.MetricsMap[MetricEconomicsFeesSpent].(*prometheus.GaugeVec).WithLabelValues(kind).Set(intToFloat(feesSpent))
+	m.MetricsMap[MetricEconomicsRewardsEarned].(*prometheus.GaugeVec).WithLabelValues(kind).Set(intToFloat(rewardsEarned))
+}
+
+func (m *MetricService) SetEconomicsNet(net sdkmath.Int, challenges, profitableChallenges int) {
+	m.MetricsMap[MetricEconomicsNetProfit].(prometheus.Gauge).Set(intToFloat(net))
+	challengesMetric := m.MetricsMap[MetricEconomicsChallenges].(*prometheus.GaugeVec)
+	challengesMetric.WithLabelValues("profitable").Set(float64(profitableChallenges))
+	challengesMetric.WithLabelValues("unprofitable").Set(float64(challenges - profitableChallenges))
+}
+
+// Cross-chain relay
+func (m *MetricService) IncRelayPackages(outcome string) {
+	m.MetricsMap[MetricRelayPackages].(*prometheus.CounterVec).WithLabelValues(outcome).Inc()
+}
+
+func (m *MetricService) SetRelayPendingPackages(packages int) {
+	m.MetricsMap[MetricRelayPendingPackages].(prometheus.Gauge).Set(float64(packages))
+}
+
+// Indexer cross-check
+func (m *MetricService) IncIndexerDiscrepancies(kind string) {
+	m.MetricsMap[MetricIndexerDiscrepancies].(*prometheus.CounterVec).WithLabelValues(kind).Inc()
+}
+
+func (m *MetricService) SetIndexerCheckedHeight(height uint64) {
+	m.MetricsMap[MetricIndexerCheckedHeight].(prometheus.Gauge).Set(float64(height))
+}
+
+// Scanner intake
+func (m *MetricService) IncScannerSuspects(result string) {
+	m.MetricsMap[MetricScannerSuspects].(*prometheus.CounterVec).WithLabelValues(result).Inc()
+}
+
+func (m *MetricService) SetScannerQueuedSuspects(queued int) {
+	m.MetricsMap[MetricScannerQueuedSuspects].(prometheus.Gauge).Set(float64(queued))
+}
+
+// Vote relay proxy
+func (m *MetricService) AddVoteProxyVotes(client, result string, votes int) {
+	m.MetricsMap[MetricVoteProxyVotes].(*prometheus.CounterVec).WithLabelValues(client, result).Add(float64(votes))
+}
+
+// Storage provider reputation
+func (m *MetricService) SetSpReputationScore(spOperatorAddress string, score float64) {
+	m.MetricsMap[MetricSpReputationScore].(*prometheus.GaugeVec).WithLabelValues(spOperatorAddress).Set(score)
+}
+
+// High availability
+func (m *MetricService) SetHaLeader(leader bool) {
+	value := 0.0
+	if leader {
+		value = 1
+	}
+	m.MetricsMap[MetricHaLeader].(prometheus.Gauge).Set(value)
+}
+
+func (m *MetricService) SetHaHeldShards(shards int) {
+	m.MetricsMap[MetricHaHeldShards].(prometheus.Gauge).Set(float64(shards))
+}
+
+// Votepool
+func (m *MetricService) IncVotePoolBroadcasts(eventType string) {
+	m.MetricsMap[MetricVotePoolBroadcasts].(*prometheus.CounterVec).WithLabelValues(eventType).Inc()
+}
+
+func (m *MetricService) IncVotePoolRejections(eventType string) {
+	m.MetricsMap[MetricVotePoolRejections].(*prometheus.CounterVec).WithLabelValues(eventType).Inc()
+}
+
+func (m *MetricService) IncVotePoolQueryErrors(eventType string) {
+	m.MetricsMap[MetricVotePoolQueryErrors].(*prometheus.CounterVec).WithLabelValues(eventType).Inc()
+}
+
+func (m *MetricService) ObserveVotePoolQuerySize(eventType string, votes int) {
+	m.MetricsMap[MetricVotePoolQuerySize].(*prometheus.HistogramVec).WithLabelValues(eventType).Observe(float64(votes))
+}
+
+func (m *MetricService) ObserveVotePoolRequest(method, eventType string, duration time.Duration) {
+	m.MetricsMap[MetricVotePoolRequestDuration].(*prometheus.HistogramVec).WithLabelValues(method, eventType).
+		Observe(duration.Seconds())
+}
+
+// Supervisor
+func (m *MetricService) IncLoopRestarts(loop string) {
+	m.MetricsMap[MetricLoopRestarts].(*prometheus.CounterVec).WithLabelValues(loop).Inc()
+}
+
+func (m *MetricService) SetLoopHeartbeat(loop string, t time.Time) {
+	m.MetricsMap[MetricLoopLastHeartbeat].(*prometheus.GaugeVec).WithLabelValues(loop).Set(float64(t.Unix()))
+}
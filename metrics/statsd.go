@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+// StatsdMaxPacketSize keeps the udp packets below the usual mtu, the lines are split over several packets.
+const StatsdMaxPacketSize = 1432
+
+// statsdReplacer replaces the characters which are part of the statsd line protocol.
+var statsdReplacer = strings.NewReplacer(":", "_", "|", "_", "@", "_", "#", "_", ",", "_", " ", "_", "\n", "_")
+
+// StatsdExporter pushes the metrics of the registry to a statsd or dogstatsd agent, for the stacks which do not
+// scrape prometheus. The counters are pushed as the increase since the last push, the gauges as their value and the
+// histograms and summaries as the increase of their count and sum.
+type StatsdExporter struct {
+	cfg      *config.MetricsConfig
+	gatherer prometheus.Gatherer
+	conn     net.Conn
+	// last pushed values of the counters by series, statsd counters are increments
+	counters map[string]float64
+}
+
+func NewStatsdExporter(cfg *config.MetricsConfig, gatherer prometheus.Gatherer) (*StatsdExporter, error) {
+	conn, err := net.Dial("udp", cfg.StatsdAddress)
+	if err != nil {
+		return nil, err
+	}
+	return &StatsdExporter{
+		cfg:      cfg,
+		gatherer: gatherer,
+		conn:     conn,
+		counters: make(map[string]float64),
+	}, nil
+}
+
+// ExportLoop pushes the metrics periodically until ctx is done.
+func (e *StatsdExporter) ExportLoop(ctx context.Context) {
+	defer e.conn.Close()
+	ticker := time.NewTicker(time.Duration(e.cfg.StatsdIntervalInSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := e.export(); err != nil {
+			logging.Logger.Errorf("failed to push metrics to statsd, err=%+v", err.Error())
+		}
+	}
+}
+
+func (e *StatsdExporter) export() error {
+	families, err := e.gatherer.Gather()
+	if err != nil {
+		return err
+	}
+	packet := &bytes.Buffer{}
+	for _, line := range e.lines(families) {
+		if packet.Len() > 0 && packet.Len()+len(line)+1 > StatsdMaxPacketSize {
+			if _, err = e.conn.Write(packet.Bytes()); err != nil {
+				return err
+			}
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if packet.Len() > 0 {
+		_, err = e.conn.Write(packet.Bytes())
+	}
+	return err
+}
+
+// lines formats the gathered metrics in the statsd line protocol.
+func (e *StatsdExporter) lines(families []*dto.MetricFamily) []string {
+	lines := make([]string, 0)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			name, tags := e.series(family.GetName(), metric.GetLabel())
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				lines = e.appendCounter(lines, name, tags, metric.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				lines = append(lines, formatStatsdLine(name, metric.GetGauge().GetValue(), "g", tags))
+			case dto.MetricType_UNTYPED:
+				lines = append(lines, formatStatsdLine(name, metric.GetUntyped().GetValue(), "g", tags))
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				lines = e.appendCounter(lines, name+".count", tags, float64(histogram.GetSampleCount()))
+				lines = e.appendCounter(lines, name+".sum", tags, histogram.GetSampleSum())
+			case dto.MetricType_SUMMARY:
+				summary := metric.GetSummary()
+				lines = e.appendCounter(lines, name+".count", tags, float64(summary.GetSampleCount()))
+				lines = e.appendCounter(lines, name+".sum", tags, summary.GetSampleSum())
+			}
+		}
+	}
+	return lines
+}
+
+// appendCounter appends the increase of the counter since the last push, nothing if it did not increase.
+func (e *StatsdExporter) appendCounter(lines []string, name, tags string, value float64) []string {
+	key := name + "|" + tags
+	delta := value - e.counters[key]
+	e.counters[key] = value
+	if delta <= 0 {
+		// a counter which went down was reset, its next increase is pushed
+		return lines
+	}
+	return append(lines, formatStatsdLine(name, delta, "c", tags))
+}
+
+// series returns the prefixed metric name and the dogstatsd tags of a metric. The labels are folded into the name
+// for plain statsd, which has no tags.
+func (e *StatsdExporter) series(name string, labels []*dto.LabelPair) (string, string) {
+	if e.cfg.StatsdPrefix != "" {
+		name = e.cfg.StatsdPrefix + "." + name
+	}
+	sorted := make([]*dto.LabelPair, len(labels))
+	copy(sorted, labels)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].GetName() < sorted[j].GetName() })
+	if e.cfg.StatsdFlavor == config.StatsdFlavorDogStatsd {
+		tags := make([]string, 0, len(sorted))
+		for _, label := range sorted {
+			tags = append(tags, statsdReplacer.Replace(label.GetName())+":"+statsdReplacer.Replace(label.GetValue()))
+		}
+		return statsdReplacer.Replace(name), strings.Join(tags, ",")
+	}
+	for _, label := range sorted {
+		name += "." + label.GetName() + "_" + label.GetValue()
+	}
+	return statsdReplacer.Replace(name), ""
+}
+
+func formatStatsdLine(name string, value float64, metricType, tags string) string {
+	line := fmt.Sprintf("%s:%s|%s", name, strconv.FormatFloat(value, 'g', -1, 64), metricType)
+	if tags != "" {
+		line += "|#" + tags
+	}
+	return line
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+)
+
+func TestStatsdExporter(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	read := func() []string {
+		buf := make([]byte, StatsdMaxPacketSize)
+		n, _, err := listener.ReadFrom(buf)
+		require.NoError(t, err)
+		return strings.Split(string(buf[:n]), "\n")
+	}
+
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "votes_total"}, []string{"event_type"})
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "saved_block"})
+	registry.MustRegister(counter, gauge)
+	counter.WithLabelValues("challenge").Add(3)
+	gauge.Set(42)
+
+	cfg := &config.MetricsConfig{StatsdAddress: listener.LocalAddr().String(), StatsdFlavor: config.StatsdFlavorDogStatsd,
+		StatsdPrefix: "challenger", StatsdIntervalInSeconds: 1}
+	exporter, err := NewStatsdExporter(cfg, registry)
+	require.NoError(t, err)
+	defer exporter.conn.Close()
+
+	require.NoError(t, exporter.export())
+	require.Equal(t, []string{"challenger.saved_block:42|g", "challenger.votes_total:3|c|#event_type:challenge"}, read())
+
+	// the counters are pushed as their increase
+	counter.WithLabelValues("challenge").Add(2)
+	require.NoError(t, exporter.export())
+	require.Equal(t, []string{"challenger.saved_block:42|g", "challenger.votes_total:2|c|#event_type:challenge"}, read())
+
+	// plain statsd folds the labels into the name
+	cfg.StatsdFlavor = config.StatsdFlavorStatsd
+	exporter, err = NewStatsdExporter(cfg, registry)
+	require.NoError(t, err)
+	defer exporter.conn.Close()
+	require.NoError(t, exporter.export())
+	require.Equal(t, []string{"challenger.saved_block:42|g", "challenger.votes_total.event_type_challenge:5|c"}, read())
+}
@@ -0,0 +1,8 @@
+package monitor
+
+import "time"
+
+const (
+	ExpireEventsInterval = 1 * time.Minute // mark events which are expired before being finished
+	MaxSourceBlocks      = 100             // blocks fetched from the event source at once while the rpc endpoints are down
+)
@@ -8,6 +8,7 @@ import (
 )
 
 func EntityToDto(height uint64, from *challengetypes.EventStartChallenge) *model.Event {
+	now := time.Now()
 	to := model.Event{
 		ChallengeId:       from.ChallengeId,
 		ObjectId:          from.ObjectId.String(),
@@ -18,7 +19,8 @@ func EntityToDto(height uint64, from *challengetypes.EventStartChallenge) *model
 		Height:            height,
 		Status:            model.Unprocessed,
 		VerifyResult:      model.Unknown,
-		CreatedTime:       time.Now().Unix(),
+		CreatedTime:       now.Unix(),
+		StageTime:         now.UnixMilli(),
 		ExpiredHeight:     from.ExpiredHeight,
 	}
 	return &to
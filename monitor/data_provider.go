@@ -1,13 +1,16 @@
 package monitor
 
 import (
+	"context"
+
 	"github.com/bnb-chain/greenfield-challenger/db/dao"
 	"github.com/bnb-chain/greenfield-challenger/db/model"
 )
 
 type DataProvider interface {
-	SaveBlockAndEvents(block *model.Block, events []*model.Event) error
-	GetLatestBlock() (*model.Block, error)
+	SaveBlockAndEvents(ctx context.Context, block *model.Block, events []*model.Event) error
+	GetLatestBlock(ctx context.Context) (*model.Block, error)
+	ExpireEvents(ctx context.Context, currentHeight uint64) ([]*model.Event, error)
 }
 
 type DataHandler struct {
@@ -20,10 +23,14 @@ func NewDataHandler(daoManager *dao.DaoManager) *DataHandler {
 	}
 }
 
-func (h *DataHandler) SaveBlockAndEvents(block *model.Block, events []*model.Event) error {
-	return h.daoManager.SaveBlockAndEvents(block, events)
+func (h *DataHandler) SaveBlockAndEvents(ctx context.Context, block *model.Block, events []*model.Event) error {
+	return h.daoManager.SaveBlockAndEvents(ctx, block, events)
+}
+
+func (h *DataHandler) GetLatestBlock(ctx context.Context) (*model.Block, error) {
+	return h.daoManager.GetLatestBlock(ctx)
 }
 
-func (h *DataHandler) GetLatestBlock() (*model.Block, error) {
-	return h.daoManager.GetLatestBlock()
+func (h *DataHandler) ExpireEvents(ctx context.Context, currentHeight uint64) ([]*model.Event, error) {
+	return h.daoManager.ExpireEvents(ctx, currentHeight, model.UnfinishedEventStatuses)
 }
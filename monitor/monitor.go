@@ -1,6 +1,9 @@
 package monitor
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -8,36 +11,113 @@ import (
 	"github.com/bnb-chain/greenfield-challenger/metrics"
 
 	sdkmath "cosmossdk.io/math"
-	"github.com/bnb-chain/greenfield-challenger/common"
+	"github.com/bnb-chain/greenfield-challenger/db/dao"
 	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/economics"
 	"github.com/bnb-chain/greenfield-challenger/executor"
+	"github.com/bnb-chain/greenfield-challenger/health"
 	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/pipeline"
+	"github.com/bnb-chain/greenfield-challenger/tracing"
+	"github.com/bnb-chain/greenfield-challenger/webhook"
 	challengetypes "github.com/bnb-chain/greenfield/x/challenge/types"
 	abci "github.com/cometbft/cometbft/abci/types"
 	ctypes "github.com/cometbft/cometbft/rpc/core/types"
 	tmtypes "github.com/cometbft/cometbft/types"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 )
 
+// RelayTracker follows the cross-chain packages emitted by the attestations of the polled blocks.
+type RelayTracker interface {
+	Observe(blockRes *ctypes.ResultBlockResults, blockTime time.Time)
+}
+
+// LedgerRecorder records the fees paid and the rewards credited to the challenger account by the polled blocks.
+type LedgerRecorder interface {
+	Record(ctx context.Context, block *tmtypes.Block, blockRes *ctypes.ResultBlockResults) error
+}
+
+// EventSource serves the challenges of the blocks while the rpc endpoints are down, e.g. an indexer.
+type EventSource interface {
+	// Blocks returns in order the blocks from fromHeight up to toHeight which the source can serve, with the
+	// challenges started in them.
+	Blocks(ctx context.Context, fromHeight, toHeight uint64) ([]*SourceBlock, error)
+}
+
+// SourceBlock is a block served by an EventSource, with its challenges only.
+type SourceBlock struct {
+	Height uint64
+	Time   time.Time
+	Events []*model.Event
+}
+
+// polledBlock is a block fetched by the monitor, ahead of saving it. A block served by the event source has no block
+// nor results.
+type polledBlock struct {
+	height       uint64
+	block        *tmtypes.Block
+	blockResults *ctypes.ResultBlockResults
+	source       *SourceBlock
+}
+
 type Monitor struct {
 	executor      *executor.Executor
 	dataProvider  DataProvider
 	metricService *metrics.MetricService
+	startHeight   uint64       // first block polled with a fresh database, 0 for the latest block
+	relayTracker  RelayTracker // nil unless the relay of the attestations is followed
+	ledger        LedgerRecorder
+	eventSource   EventSource // nil unless the challenges are polled from another source while the rpc endpoints are down
+	stage         *pipeline.Stage[*polledBlock]
+	nextHeight    uint64 // next block to fetch, 0 until it is read from the database when the loop starts
 }
 
-func NewMonitor(executor *executor.Executor, dataProvider DataProvider, metricService *metrics.MetricService) *Monitor {
-	return &Monitor{
+// NewMonitor returns a monitor fetching up to queueSize blocks ahead of the saved ones.
+func NewMonitor(executor *executor.Executor, dataProvider DataProvider, metricService *metrics.MetricService,
+	startHeight uint64, queueSize int,
+) *Monitor {
+	m := &Monitor{
 		executor:      executor,
 		dataProvider:  dataProvider,
 		metricService: metricService,
+		startHeight:   startHeight,
 	}
+	// the blocks are saved in order by a single worker, a block which failed to save is saved again until it succeeds
+	m.stage = pipeline.NewStage[*polledBlock](logging.StageMonitor, pipeline.Funcs[*polledBlock]{
+		FetchFunc:   m.fetchBlock,
+		ProcessFunc: m.saveBlock,
+		KeyFunc:     func(b *polledBlock) uint64 { return b.height },
+	}, pipeline.Options{
+		Workers:   1,
+		QueueSize: queueSize,
+		Retry:     true,
+		Beat:      func() { health.Beat(health.LoopMonitor) },
+	}, metricService)
+	return m
 }
 
-func (m Monitor) parseEvents(blockRes *ctypes.ResultBlockResults) ([]*challengetypes.EventStartChallenge, error) {
+// SetRelayTracker follows the cross-chain packages of the attestations of the polled blocks with tracker.
+func (m *Monitor) SetRelayTracker(tracker RelayTracker) {
+	m.relayTracker = tracker
+}
+
+// SetLedgerRecorder records the fees and the rewards of the polled blocks with ledger.
+func (m *Monitor) SetLedgerRecorder(ledger LedgerRecorder) {
+	m.ledger = ledger
+}
+
+// SetEventSource polls the challenges from source while the rpc endpoints are down.
+func (m *Monitor) SetEventSource(source EventSource) {
+	m.eventSource = source
+}
+
+// ParseEvents returns the challenges started in the block, emitted by its transactions or at its end.
+func ParseEvents(blockRes *ctypes.ResultBlockResults) ([]*challengetypes.EventStartChallenge, error) {
 	events := make([]*challengetypes.EventStartChallenge, 0)
 	for _, tx := range blockRes.TxsResults {
 		for _, event := range tx.Events {
-			e, err := m.parseEvent(event)
+			e, err := parseEvent(event)
 			if err != nil {
 				return nil, err
 			}
@@ -48,7 +128,7 @@ func (m Monitor) parseEvents(blockRes *ctypes.ResultBlockResults) ([]*challenget
 	}
 
 	for _, event := range blockRes.EndBlockEvents {
-		e, err := m.parseEvent(event)
+		e, err := parseEvent(event)
 		if err != nil {
 			return nil, err
 		}
@@ -59,7 +139,7 @@ func (m Monitor) parseEvents(blockRes *ctypes.ResultBlockResults) ([]*challenget
 	return events, nil
 }
 
-func (m Monitor) parseEvent(event abci.Event) (*challengetypes.EventStartChallenge, error) {
+func parseEvent(event abci.Event) (*challengetypes.EventStartChallenge, error) {
 	if event.Type == "greenfield.challenge.EventStartChallenge" {
 		challengeIdStr, objectIdStr, redundancyIndexStr, segmentIndexStr, spOpAddress, challengerAddress, expiredHeightStr := "", "", "", "", "", "", ""
 		for _, attr := range event.Attributes {
@@ -109,34 +189,102 @@ func (m Monitor) parseEvent(event abci.Event) (*challengetypes.EventStartChallen
 	return nil, nil
 }
 
-func (m *Monitor) ListenEventLoop() {
-	for {
-		err := m.poll()
+// parseRewards returns the rewards of the challenges attested in the block which were paid to address, as the
+// submitter of the attestation or as the challenger.
+func (m Monitor) parseRewards(blockRes *ctypes.ResultBlockResults, address string) sdkmath.Int {
+	events := make([]abci.Event, 0)
+	for _, tx := range blockRes.TxsResults {
+		events = append(events, tx.Events...)
+	}
+	events = append(events, blockRes.EndBlockEvents...)
+
+	total := sdkmath.ZeroInt()
+	for _, reward := range economics.ParseRewards(events, address) {
+		total = total.Add(reward.Amount)
+	}
+	return total
+}
+
+// ListenEventLoop fetches the blocks and saves their events until ctx is done, the next block is fetched while the
+// previous ones are saved.
+func (m *Monitor) ListenEventLoop(ctx context.Context) {
+	m.nextHeight = 0
+	m.stage.Run(ctx)
+}
+
+// fetchBlock fetches the next block, nothing once the latest block is fetched.
+func (m *Monitor) fetchBlock(ctx context.Context) ([]*polledBlock, error) {
+	if m.nextHeight == 0 {
+		nextHeight, err := m.calNextHeight(ctx)
 		if err != nil {
-			time.Sleep(common.RetryInterval)
-			continue
+			return nil, err
 		}
+		m.nextHeight = nextHeight
+	}
+	latestHeight, err := m.executor.GetLatestBlockHeight()
+	if err != nil {
+		return m.fetchFromSource(ctx, err)
 	}
+	if m.nextHeight > latestHeight {
+		return nil, nil
+	}
+	blockResults, block, err := m.getBlockAndBlockResult(m.nextHeight)
+	if err != nil {
+		return m.fetchFromSource(ctx, err)
+	}
+	m.nextHeight++
+	return []*polledBlock{{height: uint64(block.Height), block: block, blockResults: blockResults}}, nil
 }
 
-func (m *Monitor) poll() error {
-	nextHeight, err := m.calNextHeight()
-	if err != nil {
-		return err
+// fetchFromSource fetches the next blocks from the event source once the rpc endpoints failed with rpcErr. The blocks
+// must follow the saved ones without a gap, else the challenges of the missing blocks would be lost.
+func (m *Monitor) fetchFromSource(ctx context.Context, rpcErr error) ([]*polledBlock, error) {
+	if m.eventSource == nil {
+		return nil, rpcErr
 	}
-	blockResults, block, err := m.getBlockAndBlockResult(nextHeight)
+	blocks, err := m.eventSource.Blocks(ctx, m.nextHeight, m.nextHeight+MaxSourceBlocks-1)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("%v, and the event source failed, err=%v", rpcErr, err)
+	}
+	if len(blocks) == 0 {
+		return nil, rpcErr
 	}
-	if err = m.monitorChallengeEvents(block, blockResults); err != nil {
-		logging.Logger.Errorf("encounter error when monitor challenge events at blockHeight=%d, err=%+v", nextHeight, err.Error())
+	polled := make([]*polledBlock, 0, len(blocks))
+	for i, b := range blocks {
+		height := m.nextHeight + uint64(i)
+		if b.Height != height {
+			return nil, fmt.Errorf("the event source served block %d instead of %d", b.Height, height)
+		}
+		for _, event := range b.Events {
+			if event.Height != height {
+				return nil, fmt.Errorf("the event source served challenge %d at height %d in block %d", event.ChallengeId, event.Height, height)
+			}
+		}
+		polled = append(polled, &polledBlock{height: height, source: b})
+	}
+	logging.MonitorLogger.Errorf("rpc endpoints failed, polled blocks %d to %d from the event source, err=%+v",
+		m.nextHeight, m.nextHeight+uint64(len(polled))-1, rpcErr.Error())
+	m.nextHeight += uint64(len(polled))
+	m.metricService.AddGnfdSourceBlockCount(len(polled))
+	return polled, nil
+}
+
+func (m *Monitor) saveBlock(ctx context.Context, b *polledBlock) error {
+	var err error
+	if b.source != nil {
+		err = m.saveSourceBlock(ctx, b.source)
+	} else {
+		err = m.monitorChallengeEvents(ctx, b.block, b.blockResults)
+	}
+	if err != nil {
+		logging.MonitorLogger.Errorf("encounter error when monitor challenge events at blockHeight=%d, err=%+v", b.height, err.Error())
 		return err
 	}
 	return nil
 }
 
 func (m *Monitor) getBlockAndBlockResult(height uint64) (*ctypes.ResultBlockResults, *tmtypes.Block, error) {
-	logging.Logger.Infof("retrieve greenfield block at height=%d", height)
+	logging.MonitorLogger.Infof("retrieve greenfield block at height=%d", height)
 	block, blockResults, err := m.executor.GetBlockAndBlockResultAtHeight(int64(height))
 	if err != nil {
 		return nil, nil, err
@@ -144,8 +292,8 @@ func (m *Monitor) getBlockAndBlockResult(height uint64) (*ctypes.ResultBlockResu
 	return blockResults, block, nil
 }
 
-func (m *Monitor) monitorChallengeEvents(block *tmtypes.Block, blockResults *ctypes.ResultBlockResults) error {
-	parsedEvents, err := m.parseEvents(blockResults)
+func (m *Monitor) monitorChallengeEvents(ctx context.Context, block *tmtypes.Block, blockResults *ctypes.ResultBlockResults) error {
+	parsedEvents, err := ParseEvents(blockResults)
 	if err != nil {
 		return err
 	}
@@ -155,9 +303,46 @@ func (m *Monitor) monitorChallengeEvents(block *tmtypes.Block, blockResults *cty
 		CreatedTime: time.Now().Unix(),
 	}
 	events := EntitiesToDtos(uint64(block.Height), parsedEvents)
-	err = m.dataProvider.SaveBlockAndEvents(b, events)
+	if m.ledger != nil {
+		// recorded before the block is saved, so that the block is polled again if it fails
+		if err = m.ledger.Record(ctx, block, blockResults); err != nil {
+			return err
+		}
+	}
+	if err = m.save(ctx, b, events, block.Time); err != nil {
+		return err
+	}
+	// a block which failed to save is polled again, its rewards are counted once saved
+	m.metricService.AddAttestRewards(m.parseRewards(blockResults, m.executor.GetAddr()))
+	if m.relayTracker != nil {
+		m.relayTracker.Observe(blockResults, block.Time)
+	}
+	return nil
+}
+
+// saveSourceBlock saves a block served by the event source, its fees, rewards and relayed packages are not known.
+func (m *Monitor) saveSourceBlock(ctx context.Context, source *SourceBlock) error {
+	b := &model.Block{
+		Height:      source.Height,
+		BlockTime:   source.Time.Unix(),
+		CreatedTime: time.Now().Unix(),
+	}
+	return m.save(ctx, b, source.Events, source.Time)
+}
+
+// save saves the block with the challenges started in it at blockTime. A block already saved is skipped, its
+// challenges were saved with it.
+func (m *Monitor) save(ctx context.Context, b *model.Block, events []*model.Event, blockTime time.Time) error {
+	err := m.dataProvider.SaveBlockAndEvents(ctx, b, events)
+	if errors.Is(err, dao.ErrBlockSaved) {
+		logging.MonitorLogger.Infof("block at height=%d already saved, skipped", b.Height)
+		return nil
+	}
 	for _, event := range events {
-		logging.Logger.Debugf("monitor event saved for challengeId: %d %s", event.ChallengeId, time.Now().Format("15:04:05.000000"))
+		// the challenge trace starts when the challenge is emitted on chain
+		_, span := tracing.StartEventSpan(ctx, event, tracing.SpanIngest, trace.WithTimestamp(blockTime))
+		tracing.EndSpan(span, err)
+		logging.WithFields(logging.MonitorLogger, event.LogFields(logging.StageMonitor)).Debugf("monitor event saved")
 		m.metricService.SetGnfdSavedEvent(event.ChallengeId)
 		m.metricService.IncGnfdSavedEventCount()
 	}
@@ -166,11 +351,14 @@ func (m *Monitor) monitorChallengeEvents(block *tmtypes.Block, blockResults *cty
 	if err != nil {
 		return err
 	}
+	for _, event := range events {
+		webhook.EventIngested(event)
+	}
 	return nil
 }
 
-func (m *Monitor) calNextHeight() (uint64, error) {
-	latestPolledBlock, err := m.dataProvider.GetLatestBlock()
+func (m *Monitor) calNextHeight(ctx context.Context) (uint64, error) {
+	latestPolledBlock, err := m.dataProvider.GetLatestBlock(ctx)
 	if err != nil && err != gorm.ErrRecordNotFound {
 		latestHeight, err := m.executor.GetLatestBlockHeight()
 		if err != nil {
@@ -179,22 +367,55 @@ func (m *Monitor) calNextHeight() (uint64, error) {
 		return latestHeight, err
 	}
 	if latestPolledBlock.Height == 0 { // a fresh database
+		if m.startHeight != 0 {
+			return m.startHeight, nil
+		}
 		latestHeight, err := m.executor.GetLatestBlockHeight()
 		if err != nil {
 			return m.executor.GetCachedBlockHeight(), err
 		}
 		return latestHeight, nil
 	}
-	nextHeight := latestPolledBlock.Height + 1
+	return latestPolledBlock.Height + 1, nil
+}
 
-	latestBlockHeight, err := m.executor.GetLatestBlockHeight()
-	if err != nil {
-		return 0, err
+// ExpireEventsLoop marks the events which expired before reaching a final status, so they are accounted in the
+// storage provider stats.
+func (m *Monitor) ExpireEventsLoop(ctx context.Context) {
+	ticker := time.NewTicker(ExpireEventsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := m.ExpireEvents(ctx); err != nil {
+			logging.MonitorLogger.Errorf("monitor failed to expire events, err=%+v", err.Error())
+		}
 	}
-	// pauses challenger for a bit since it already caught the newest block
-	if int64(nextHeight) == int64(latestBlockHeight) {
-		time.Sleep(common.RetryInterval)
-		return nextHeight, nil
+}
+
+// ExpireEvents marks the events which expired before reaching a final status and returns how many were marked. They
+// are marked by batches of dao.ExpireBatchSize events, each with a single update.
+func (m *Monitor) ExpireEvents(ctx context.Context) (int, error) {
+	currentHeight := m.executor.GetCachedBlockHeight()
+	if currentHeight == 0 {
+		return 0, nil
+	}
+	expired := 0
+	for {
+		events, err := m.dataProvider.ExpireEvents(ctx, currentHeight)
+		if err != nil {
+			return expired, err
+		}
+		expired += len(events)
+		for _, event := range events {
+			webhook.Expired(event)
+			logging.WithFields(logging.MonitorLogger, event.LogFields(logging.StageMonitor)).Debugf("monitor expired event, expired height: %d, current height: %d", event.ExpiredHeight, currentHeight)
+		}
+		if len(events) < dao.ExpireBatchSize {
+			return expired, nil
+		}
 	}
-	return nextHeight, nil
 }
@@ -0,0 +1,281 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/common"
+)
+
+// Handler fetches the items of a stage and processes them.
+type Handler[T any] interface {
+	// Fetch returns the items to process, it is called again as soon as the fetched items are queued
+	Fetch(ctx context.Context) ([]T, error)
+	// Process handles a single item, ctx is done once the stage stops
+	Process(ctx context.Context, item T) error
+	// Key identifies an item, an item is not queued again while it is queued or processed
+	Key(item T) uint64
+}
+
+// Expiring is implemented by the handlers whose items expire, a failed item is retried sooner as it gets close to
+// its expiry.
+type Expiring[T any] interface {
+	// Expiry estimates when the item expires, zero if it does not
+	Expiry(item T) time.Time
+}
+
+// Funcs adapts functions to a Handler, the items expire by ExpiryFunc if it is set.
+type Funcs[T any] struct {
+	FetchFunc   func(ctx context.Context) ([]T, error)
+	ProcessFunc func(ctx context.Context, item T) error
+	KeyFunc     func(item T) uint64
+	ExpiryFunc  func(item T) time.Time
+}
+
+func (f Funcs[T]) Fetch(ctx context.Context) ([]T, error) {
+	return f.FetchFunc(ctx)
+}
+
+func (f Funcs[T]) Process(ctx context.Context, item T) error {
+	return f.ProcessFunc(ctx, item)
+}
+
+func (f Funcs[T]) Key(item T) uint64 {
+	return f.KeyFunc(item)
+}
+
+func (f Funcs[T]) Expiry(item T) time.Time {
+	if f.ExpiryFunc == nil {
+		return time.Time{}
+	}
+	return f.ExpiryFunc(item)
+}
+
+// Observer receives the measurements of the stages, e.g. to export them as metrics.
+type Observer interface {
+	ObservePipelineItem(stage string, duration time.Duration, err error)
+	SetPipelineQueueLength(stage string, length int)
+}
+
+// Options tune a stage.
+type Options struct {
+	Workers   int // items processed concurrently, 1 processes them in the fetched order
+	QueueSize int // items fetched ahead of the workers
+	// Idle is how long the stage waits before fetching again when nothing new was fetched or fetching failed, nil
+	// adapts it to the chain and the items held by the stage
+	Idle func() time.Duration
+	// Backoff is how long a failed item is held back before it may be queued again, nil adapts it to the chain, the
+	// items held by the stage and the expiry of the item
+	Backoff func() time.Duration
+	// Pace is the least time between two items of a worker, to spread the load on the services they call, nil for none
+	Pace func() time.Duration
+	// Retry processes a failed item again after Backoff until it succeeds, for the stages which have to process the
+	// items in order, e.g. the blocks
+	Retry bool
+	// Beat is called on every fetch and after every item, e.g. to beat the liveness check, nil for none
+	Beat func()
+}
+
+// Stage fetches items and hands them over to a pool of workers through a bounded queue. The fetching blocks while the
+// queue is full, so a slow stage holds its producer back instead of piling work up, and the throughput is set by the
+// workers and the queue size rather than by sleeps between the items.
+type Stage[T any] struct {
+	name     string
+	handler  Handler[T]
+	opts     Options
+	observer Observer
+	mtx      sync.Mutex
+	held     map[uint64]time.Time // the keys not to queue, until when, zero while the item is queued or processed
+}
+
+func NewStage[T any](name string, handler Handler[T], opts Options, observer Observer) *Stage[T] {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = opts.Workers
+	}
+	return &Stage[T]{
+		name:     name,
+		handler:  handler,
+		opts:     opts,
+		observer: observer,
+		held:     make(map[uint64]time.Time),
+	}
+}
+
+// Run fetches and processes the items until ctx is done, and returns once the items being processed are done. The
+// queued items are dropped then, they are fetched again by the next run.
+func (s *Stage[T]) Run(ctx context.Context) {
+	queue := make(chan T, s.opts.QueueSize)
+	wg := new(sync.WaitGroup)
+	wg.Add(s.opts.Workers)
+	for i := 0; i < s.opts.Workers; i++ {
+		go func() {
+			defer wg.Done()
+			s.work(ctx, queue)
+		}()
+	}
+	s.produce(ctx, queue)
+	wg.Wait()
+
+	s.mtx.Lock()
+	s.held = make(map[uint64]time.Time)
+	s.mtx.Unlock()
+	s.setQueueLength(0)
+}
+
+func (s *Stage[T]) produce(ctx context.Context, queue chan<- T) {
+	for {
+		s.beat()
+		items, err := s.handler.Fetch(ctx)
+		if err != nil {
+			if !common.Sleep(ctx, s.idle()) {
+				return
+			}
+			continue
+		}
+		queued := 0
+		for _, item := range items {
+			if !s.hold(s.handler.Key(item)) {
+				continue
+			}
+			select {
+			case queue <- item:
+				queued++
+				s.setQueueLength(len(queue))
+			case <-ctx.Done():
+				return
+			}
+		}
+		if queued == 0 && !common.Sleep(ctx, s.idle()) {
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (s *Stage[T]) work(ctx context.Context, queue <-chan T) {
+	for {
+		var item T
+		select {
+		case item = <-queue:
+		case <-ctx.Done():
+			return
+		}
+		s.setQueueLength(len(queue))
+		for {
+			start := time.Now()
+			err := s.handler.Process(ctx, item)
+			if s.observer != nil {
+				s.observer.ObservePipelineItem(s.name, time.Since(start), err)
+			}
+			s.beat()
+			if err == nil || !s.opts.Retry {
+				s.release(item, err)
+				break
+			}
+			if !common.Sleep(ctx, s.backoff(item)) {
+				return
+			}
+		}
+		if s.opts.Pace != nil && !common.Sleep(ctx, s.opts.Pace()) {
+			return
+		}
+	}
+}
+
+// hold reports whether the item of key may be queued, and holds it if so.
+func (s *Stage[T]) hold(key uint64) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if until, ok := s.held[key]; ok && (until.IsZero() || time.Now().Before(until)) {
+		return false
+	}
+	s.held[key] = time.Time{}
+	return true
+}
+
+// release lets the item be queued again, after its backoff if it failed.
+func (s *Stage[T]) release(item T, err error) {
+	key := s.handler.Key(item)
+	if err != nil {
+		backoff := s.backoff(item)
+		s.mtx.Lock()
+		s.held[key] = time.Now().Add(backoff)
+		s.mtx.Unlock()
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.held, key)
+	// the failed items which may be queued again are dropped, so that the held keys stay bounded
+	now := time.Now()
+	for k, until := range s.held {
+		if !until.IsZero() && now.After(until) {
+			delete(s.held, k)
+		}
+	}
+}
+
+// RetryHeld lets the failed items held back be queued again on the next fetch, e.g. once what they failed on changed.
+func (s *Stage[T]) RetryHeld() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for key, until := range s.held {
+		if !until.IsZero() {
+			delete(s.held, key)
+		}
+	}
+}
+
+// idle is how long the stage waits before fetching again, the stage is idle unless it holds items.
+func (s *Stage[T]) idle() time.Duration {
+	if s.opts.Idle != nil {
+		return s.opts.Idle()
+	}
+	return common.AdaptiveRetryInterval(s.backlog(), 0)
+}
+
+// backoff is how long the failed item is held back, the item itself is part of the backlog.
+func (s *Stage[T]) backoff(item T) time.Duration {
+	if s.opts.Backoff != nil {
+		return s.opts.Backoff()
+	}
+	if s.opts.Idle != nil {
+		return s.opts.Idle()
+	}
+	var expiresIn time.Duration
+	if expiring, ok := s.handler.(Expiring[T]); ok {
+		if expiry := expiring.Expiry(item); !expiry.IsZero() {
+			// an expired item is held back for the least interval, it is not fetched again anyway
+			expiresIn = time.Until(expiry)
+			if expiresIn <= 0 {
+				expiresIn = time.Nanosecond
+			}
+		}
+	}
+	return common.AdaptiveRetryInterval(s.backlog(), expiresIn)
+}
+
+// backlog counts the items held by the stage, i.e. queued, processed or held back after they failed.
+func (s *Stage[T]) backlog() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return len(s.held)
+}
+
+func (s *Stage[T]) beat() {
+	if s.opts.Beat != nil {
+		s.opts.Beat()
+	}
+}
+
+func (s *Stage[T]) setQueueLength(length int) {
+	if s.observer != nil {
+		s.observer.SetPipelineQueueLength(s.name, length)
+	}
+}
@@ -0,0 +1,195 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeObserver struct {
+	mtx       sync.Mutex
+	processed map[bool]int // by failed
+	maxQueued int
+}
+
+func (o *fakeObserver) ObservePipelineItem(_ string, _ time.Duration, err error) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.processed[err != nil]++
+}
+
+func (o *fakeObserver) SetPipelineQueueLength(_ string, length int) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	if length > o.maxQueued {
+		o.maxQueued = length
+	}
+}
+
+func interval(d time.Duration) func() time.Duration {
+	return func() time.Duration { return d }
+}
+
+func TestStage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mtx sync.Mutex
+	pending := map[uint64]bool{1: true, 2: true, 3: true, 4: true, 5: true}
+	attempts := make(map[uint64]int)
+	inFlight, maxInFlight := 0, 0
+	handler := Funcs[uint64]{
+		FetchFunc: func(context.Context) ([]uint64, error) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			items := make([]uint64, 0)
+			for item := range pending {
+				items = append(items, item)
+			}
+			return items, nil
+		},
+		ProcessFunc: func(_ context.Context, item uint64) error {
+			mtx.Lock()
+			attempts[item]++
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			failed := item == 3 && attempts[item] == 1
+			mtx.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mtx.Lock()
+			defer mtx.Unlock()
+			inFlight--
+			if failed {
+				return errors.New("not enough votes")
+			}
+			delete(pending, item)
+			if len(pending) == 0 {
+				cancel()
+			}
+			return nil
+		},
+		KeyFunc: func(item uint64) uint64 { return item },
+	}
+	observer := &fakeObserver{processed: make(map[bool]int)}
+	stage := NewStage[uint64]("test", handler, Options{Workers: 2, QueueSize: 1, Idle: interval(time.Millisecond),
+		Backoff: interval(50 * time.Millisecond)}, observer)
+
+	done := make(chan struct{})
+	go func() {
+		stage.Run(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stage did not process all items")
+	}
+
+	// every item is processed once while it is queued or processed, the failed one once more after its backoff
+	require.Equal(t, map[uint64]int{1: 1, 2: 1, 3: 2, 4: 1, 5: 1}, attempts)
+	require.Equal(t, 2, maxInFlight)
+	require.Equal(t, map[bool]int{false: 5, true: 1}, observer.processed)
+	require.LessOrEqual(t, observer.maxQueued, 1)
+}
+
+func TestStageSlowItem(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mtx sync.Mutex
+	fetched := false
+	others := make(chan uint64, 3)
+	handler := Funcs[uint64]{
+		FetchFunc: func(context.Context) ([]uint64, error) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			if fetched {
+				return nil, nil
+			}
+			fetched = true
+			return []uint64{1, 2, 3, 4}, nil
+		},
+		ProcessFunc: func(ctx context.Context, item uint64) error {
+			if item == 1 {
+				// the slow item, e.g. a vote count query stuck on the database, waits for the others
+				for i := 0; i < 3; i++ {
+					select {
+					case <-others:
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+				cancel()
+				return nil
+			}
+			others <- item
+			return nil
+		},
+		KeyFunc: func(item uint64) uint64 { return item },
+	}
+	stage := NewStage[uint64]("test", handler, Options{Workers: 2, QueueSize: 4, Idle: interval(time.Millisecond)},
+		nil)
+	done := make(chan struct{})
+	go func() {
+		stage.Run(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the slow item delayed the other items")
+	}
+}
+
+func TestStageRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	next := uint64(1)
+	saved := make([]uint64, 0)
+	failures := 2
+	handler := Funcs[uint64]{
+		FetchFunc: func(context.Context) ([]uint64, error) {
+			if next > 3 {
+				return nil, nil
+			}
+			next++
+			return []uint64{next - 1}, nil
+		},
+		ProcessFunc: func(_ context.Context, item uint64) error {
+			if item == 2 && failures > 0 {
+				failures--
+				return errors.New("db error")
+			}
+			saved = append(saved, item)
+			if len(saved) == 3 {
+				cancel()
+			}
+			return nil
+		},
+		KeyFunc: func(item uint64) uint64 { return item },
+	}
+	stage := NewStage[uint64]("test", handler, Options{QueueSize: 2, Idle: interval(time.Millisecond), Retry: true},
+		nil)
+	stage.Run(ctx)
+
+	// the failed item is processed again until it succeeds, before the items behind it
+	require.Equal(t, []uint64{1, 2, 3}, saved)
+}
+
+func TestStageRetryHeld(t *testing.T) {
+	handler := Funcs[uint64]{KeyFunc: func(item uint64) uint64 { return item }}
+	stage := NewStage[uint64]("test", handler, Options{Backoff: interval(time.Hour)}, nil)
+	require.True(t, stage.hold(1))
+	require.True(t, stage.hold(2))
+	stage.release(1, errors.New("not enough votes"))
+
+	// the failed item is held back for its backoff, the processed one until it is done
+	require.False(t, stage.hold(1))
+	stage.RetryHeld()
+	require.True(t, stage.hold(1))
+	require.False(t, stage.hold(2))
+}
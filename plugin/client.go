@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Client calls a verifier plugin served by a sidecar of the challenger. The sidecar runs next to the challenger, so the
+// connection is not encrypted.
+type Client struct {
+	addr    string
+	timeout time.Duration
+	conn    *grpc.ClientConn
+	client  VerifierPluginClient
+}
+
+// Dial connects to the plugin at addr, host:port or unix:///path/to/socket, the connection is established lazily.
+// A request times out after timeout.
+func Dial(addr string, timeout time.Duration) (*Client, error) {
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(MaxMessageSize)))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{addr: addr, timeout: timeout, conn: conn, client: NewVerifierPluginClient(conn)}, nil
+}
+
+// Name returns the address of the plugin, which names it in the logs and the metrics.
+func (c *Client) Name() string {
+	return c.addr
+}
+
+// Verify asks the plugin whether the piece of the challenge is mismatched.
+func (c *Client) Verify(ctx context.Context, req *VerifyRequest) (*VerifyResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.client.Verify(ctx, req)
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,66 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type mirrorPlugin struct {
+	UnimplementedVerifierPluginServer
+	mirror []byte
+	delay  time.Duration
+}
+
+func (p *mirrorPlugin) Verify(ctx context.Context, req *VerifyRequest) (*VerifyResponse, error) {
+	select {
+	case <-time.After(p.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if !bytes.Equal(p.mirror, req.PieceData) {
+		return &VerifyResponse{Mismatched: true, Reason: "differs from the mirror"}, nil
+	}
+	return &VerifyResponse{}, nil
+}
+
+func serve(t *testing.T, impl VerifierPluginServer, timeout time.Duration) *Client {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := NewServer(impl)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	client, err := Dial(listener.Addr().String(), timeout)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	require.Equal(t, listener.Addr().String(), client.Name())
+	return client
+}
+
+func TestClient_Verify(t *testing.T) {
+	// a piece is larger than the default max message size of grpc
+	piece := bytes.Repeat([]byte{1}, 16*1024*1024)
+	client := serve(t, &mirrorPlugin{mirror: piece}, time.Second)
+
+	res, err := client.Verify(context.Background(), &VerifyRequest{ChallengeId: 1, PieceData: piece})
+	require.NoError(t, err)
+	require.False(t, res.Mismatched)
+
+	res, err = client.Verify(context.Background(), &VerifyRequest{ChallengeId: 2, PieceData: piece[1:]})
+	require.NoError(t, err)
+	require.True(t, res.Mismatched)
+	require.Equal(t, "differs from the mirror", res.Reason)
+}
+
+func TestClient_Timeout(t *testing.T) {
+	client := serve(t, &mirrorPlugin{delay: time.Second}, 50*time.Millisecond)
+	_, err := client.Verify(context.Background(), &VerifyRequest{ChallengeId: 1})
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
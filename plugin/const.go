@@ -0,0 +1,5 @@
+package plugin
+
+// MaxMessageSize bounds the requests to the plugins, a request holds a whole piece of up to the max segment size of
+// greenfield and its piece hashes.
+const MaxMessageSize = 20 * 1024 * 1024
@@ -0,0 +1,11 @@
+package plugin
+
+import "google.golang.org/grpc"
+
+// NewServer returns a grpc server serving impl as a verifier plugin, which accepts requests holding a whole piece. It
+// is meant for the sidecars implementing a plugin.
+func NewServer(impl VerifierPluginServer) *grpc.Server {
+	server := grpc.NewServer(grpc.MaxRecvMsgSize(MaxMessageSize))
+	RegisterVerifierPluginServer(server, impl)
+	return server
+}
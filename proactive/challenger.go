@@ -0,0 +1,188 @@
+package proactive
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bnb-chain/greenfield-go-sdk/types"
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+// Executor queries the objects and the storage providers and submits the challenges.
+type Executor interface {
+	ListBucketNames(account string) ([]string, error)
+	ListObjectIds(bucketName string, limit uint64) ([]string, error)
+	GetObjectDetail(objectId string) (*types.ObjectDetail, error)
+	GetStorageProviderAddresses() (map[uint32]string, error)
+	GetStorageProviderEndpoint(address string) (string, error)
+	GetChallengeResultFromSp(ctx context.Context, objectId, endpoint string, segmentIndex, redundancyIndex int) (*types.ChallengeResult, error)
+	SubmitChallenge(spOperatorAddress, bucketName, objectName string, segmentIndex uint32, randomIndex bool) (string, error)
+}
+
+// Reputation scores the storage providers, from 0 for the least reliable to 1.
+type Reputation interface {
+	Score(spOperatorAddress string) float64
+}
+
+// FailureProvider lists the pieces the sampler found unavailable, which are challenged first.
+type FailureProvider interface {
+	ListSamplingFailures(ctx context.Context, limit int) ([]*model.SamplingFailure, error)
+	MarkSamplingFailureChallenged(ctx context.Context, id int64) error
+}
+
+// Challenger samples stored objects and submits challenges for them, so that unavailable data is found without
+// waiting for the random challenges of the chain. The failures recorded by the Sampler are challenged first.
+type Challenger struct {
+	*picker
+	cfg           *config.ProactiveConfig
+	failures      FailureProvider
+	metricService *metrics.MetricService
+	// recently challenged objects by sp, which are not challenged again while cached
+	recent *lru.Cache
+}
+
+func NewChallenger(cfg *config.ProactiveConfig, executor Executor, failures FailureProvider, reputation Reputation,
+	metricService *metrics.MetricService,
+) *Challenger {
+	recent, _ := lru.New(RecentChallengesCacheSize)
+	return &Challenger{
+		picker:        newPicker(executor, reputation, cfg.SpOperatorAddresses),
+		cfg:           cfg,
+		failures:      failures,
+		metricService: metricService,
+		recent:        recent,
+	}
+}
+
+// ChallengeLoop runs a round of sampling every interval until ctx is done.
+func (c *Challenger) ChallengeLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(c.cfg.IntervalInMinutes) * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		submitted, err := c.round(ctx)
+		if err != nil {
+			logging.Logger.Errorf("proactive challenge round failed, err=%+v", err.Error())
+			continue
+		}
+		logging.Logger.Infof("proactive challenge round completed, submitted challenges: %d", submitted)
+	}
+}
+
+// round challenges the sampling failures, then samples the objects, up to the max challenges per round. It returns
+// the challenges submitted.
+func (c *Challenger) round(ctx context.Context) (int, error) {
+	submitted, err := c.challengeFailures(ctx)
+	if err != nil {
+		return submitted, err
+	}
+	objectIds, err := c.sample()
+	if err != nil || len(objectIds) == 0 {
+		return submitted, err
+	}
+	sps, err := c.executor.GetStorageProviderAddresses()
+	if err != nil {
+		return submitted, err
+	}
+	for _, objectId := range objectIds {
+		if submitted >= c.cfg.MaxChallengesPerRound || ctx.Err() != nil {
+			break
+		}
+		t, err := c.pick(objectId, sps)
+		if err != nil || t == nil || c.recent.Contains(t.key()) {
+			c.metricService.IncProactiveSamples(SampleSkipped)
+			continue
+		}
+		result := SampleUnverified
+		if c.cfg.PreVerify {
+			result = c.verify(ctx, t)
+		}
+		c.metricService.IncProactiveSamples(result)
+		if result == SampleAvailable || result == SampleError {
+			continue
+		}
+		// without pre-verification the chain picks the piece, as the challenges it emits itself
+		if err = c.submit(t, result, !c.cfg.PreVerify); err == nil {
+			submitted++
+		}
+	}
+	return submitted, nil
+}
+
+// challengeFailures challenges the pieces the sampler found unavailable, the oldest first.
+func (c *Challenger) challengeFailures(ctx context.Context) (int, error) {
+	failures, err := c.failures.ListSamplingFailures(ctx, c.cfg.MaxChallengesPerRound)
+	if err != nil {
+		return 0, err
+	}
+	submitted := 0
+	for _, f := range failures {
+		t := &target{
+			objectId:          f.ObjectId,
+			bucketName:        f.BucketName,
+			objectName:        f.ObjectName,
+			spOperatorAddress: f.SpOperatorAddress,
+			redundancyIndex:   f.RedundancyIndex,
+			segmentIndex:      f.SegmentIndex,
+		}
+		// a failure whose challenge cannot be submitted is retried in the next round
+		if !c.recent.Contains(t.key()) {
+			if err = c.submit(t, fmt.Sprintf("sampled %s", f.Result), false); err != nil {
+				continue
+			}
+			submitted++
+		}
+		if err = c.failures.MarkSamplingFailureChallenged(ctx, f.Id); err != nil {
+			return submitted, err
+		}
+	}
+	return submitted, nil
+}
+
+func (c *Challenger) submit(t *target, result string, randomIndex bool) error {
+	txHash, err := c.executor.SubmitChallenge(t.spOperatorAddress, t.bucketName, t.objectName, t.segmentIndex, randomIndex)
+	if err != nil {
+		logging.Logger.Errorf("failed to submit the challenge of object %s on sp %s, err=%+v", t.objectId,
+			t.spOperatorAddress, err.Error())
+		return err
+	}
+	logging.Logger.Infof("submitted the challenge of object %s on sp %s, sample: %s, txhash: %s", t.objectId,
+		t.spOperatorAddress, result, txHash)
+	c.recent.Add(t.key(), true)
+	c.metricService.IncProactiveChallengesSubmitted()
+	return nil
+}
+
+// sample returns up to samples per round object ids, picked from the listed objects of the buckets and the object id
+// range.
+func (c *Challenger) sample() ([]string, error) {
+	candidates := make([]string, 0)
+	for _, bucket := range c.cfg.Buckets {
+		objectIds, err := c.executor.ListObjectIds(bucket, MaxListedObjects)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, objectIds...)
+	}
+	if c.cfg.MaxObjectId != 0 {
+		for i := 0; i < c.cfg.SamplesPerRound; i++ {
+			objectId := c.cfg.MinObjectId + uint64(c.rand.Int63n(int64(c.cfg.MaxObjectId-c.cfg.MinObjectId+1)))
+			candidates = append(candidates, fmt.Sprintf("%d", objectId))
+		}
+	}
+	c.rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	if len(candidates) > c.cfg.SamplesPerRound {
+		candidates = candidates[:c.cfg.SamplesPerRound]
+	}
+	return candidates, nil
+}
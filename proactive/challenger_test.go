@@ -0,0 +1,224 @@
+package proactive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/bnb-chain/greenfield-go-sdk/types"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+	vgtypes "github.com/bnb-chain/greenfield/x/virtualgroup/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+	"github.com/bnb-chain/greenfield-challenger/verifier"
+)
+
+const (
+	primarySp   = "0x1111111111111111111111111111111111111111"
+	secondarySp = "0x2222222222222222222222222222222222222222"
+)
+
+type submission struct {
+	sp          string
+	object      string
+	segment     uint32
+	randomIndex bool
+}
+
+type mockExecutor struct {
+	objects     map[string]*types.ObjectDetail
+	piece       []byte // served by the sps, nil if they are unavailable
+	submissions []submission
+}
+
+func (e *mockExecutor) ListBucketNames(_ string) ([]string, error) {
+	return []string{"bucket"}, nil
+}
+
+func (e *mockExecutor) ListObjectIds(_ string, _ uint64) ([]string, error) {
+	objectIds := make([]string, 0, len(e.objects))
+	for objectId := range e.objects {
+		objectIds = append(objectIds, objectId)
+	}
+	return objectIds, nil
+}
+
+func (e *mockExecutor) GetObjectDetail(objectId string) (*types.ObjectDetail, error) {
+	detail, ok := e.objects[objectId]
+	if !ok {
+		return nil, errors.New("no such object")
+	}
+	return detail, nil
+}
+
+func (e *mockExecutor) GetStorageProviderAddresses() (map[uint32]string, error) {
+	return map[uint32]string{1: primarySp, 2: secondarySp}, nil
+}
+
+func (e *mockExecutor) GetStorageProviderEndpoint(address string) (string, error) {
+	return "https://" + address, nil
+}
+
+func (e *mockExecutor) GetChallengeResultFromSp(_ context.Context, _, _ string, _, _ int) (*types.ChallengeResult, error) {
+	if e.piece == nil {
+		return nil, errors.New("sp unavailable")
+	}
+	hash := sha256.Sum256([]byte("piece"))
+	return &types.ChallengeResult{PieceData: io.NopCloser(bytes.NewReader(e.piece)),
+		PiecesHash: []string{hex.EncodeToString(hash[:])}}, nil
+}
+
+func (e *mockExecutor) SubmitChallenge(sp, _, objectName string, segmentIndex uint32, randomIndex bool) (string, error) {
+	e.submissions = append(e.submissions, submission{sp, objectName, segmentIndex, randomIndex})
+	return "txhash", nil
+}
+
+func sealedObject(name string, payloadSize uint64) *types.ObjectDetail {
+	hash := sha256.Sum256([]byte("piece"))
+	rootHash := verifier.ComputeRootHash(0, []byte("piece"), [][]byte{hash[:]})
+	return &types.ObjectDetail{
+		ObjectInfo: &storagetypes.ObjectInfo{BucketName: "bucket", ObjectName: name, PayloadSize: payloadSize,
+			ObjectStatus: storagetypes.OBJECT_STATUS_SEALED, Checksums: [][]byte{rootHash, rootHash}},
+		GlobalVirtualGroup: &vgtypes.GlobalVirtualGroup{PrimarySpId: 1, SecondarySpIds: []uint32{2}},
+	}
+}
+
+// mockFailures keeps the sampling failures in memory.
+type mockFailures struct {
+	failures []*model.SamplingFailure
+}
+
+func (f *mockFailures) RecordSamplingFailure(_ context.Context, failure *model.SamplingFailure) error {
+	failure.Id = int64(len(f.failures) + 1)
+	f.failures = append(f.failures, failure)
+	return nil
+}
+
+func (f *mockFailures) ListSamplingFailures(_ context.Context, limit int) ([]*model.SamplingFailure, error) {
+	pending := make([]*model.SamplingFailure, 0)
+	for _, failure := range f.failures {
+		if !failure.Challenged && len(pending) < limit {
+			pending = append(pending, failure)
+		}
+	}
+	return pending, nil
+}
+
+func (f *mockFailures) MarkSamplingFailureChallenged(_ context.Context, id int64) error {
+	f.failures[id-1].Challenged = true
+	return nil
+}
+
+// scores is the reputation of the sps, the missing ones have the max score.
+type scores map[string]float64
+
+func (s scores) Score(spOperatorAddress string) float64 {
+	if score, ok := s[spOperatorAddress]; ok {
+		return score
+	}
+	return 1
+}
+
+func newTestChallenger(cfg *config.ProactiveConfig, executor Executor) *Challenger {
+	c := NewChallenger(cfg, executor, &mockFailures{}, scores{}, metrics.NewMetricService(&config.Config{}))
+	c.rand = rand.New(rand.NewSource(1))
+	return c
+}
+
+func TestChallenger_Round(t *testing.T) {
+	executor := &mockExecutor{objects: map[string]*types.ObjectDetail{
+		"1": sealedObject("a", 100),
+		"2": sealedObject("b", 100),
+		"3": {ObjectInfo: &storagetypes.ObjectInfo{ObjectStatus: storagetypes.OBJECT_STATUS_CREATED}},
+	}}
+	cfg := &config.ProactiveConfig{SamplesPerRound: 10, MaxChallengesPerRound: 1, Buckets: []string{"bucket"},
+		SpOperatorAddresses: []string{secondarySp}}
+	c := newTestChallenger(cfg, executor)
+
+	// without pre-verification the sampled objects are challenged, up to the max per round
+	submitted, err := c.round(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, submitted)
+	require.Len(t, executor.submissions, 1)
+	require.Equal(t, secondarySp, executor.submissions[0].sp)
+	require.True(t, executor.submissions[0].randomIndex)
+
+	// the challenged objects are not challenged again, the unsealed object is skipped
+	cfg.MaxChallengesPerRound = 10
+	submitted, err = c.round(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, submitted)
+	require.NotEqual(t, executor.submissions[0].object, executor.submissions[1].object)
+	submitted, err = c.round(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0, submitted)
+}
+
+func TestChallenger_PreVerify(t *testing.T) {
+	executor := &mockExecutor{objects: map[string]*types.ObjectDetail{"1": sealedObject("a", 100)}, piece: []byte("piece")}
+	cfg := &config.ProactiveConfig{SamplesPerRound: 10, MaxChallengesPerRound: 10, Buckets: []string{"bucket"},
+		PreVerify: true}
+	c := newTestChallenger(cfg, executor)
+
+	// the sp serves the piece, nothing to challenge
+	submitted, err := c.round(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0, submitted)
+
+	// the sp serves another piece
+	executor.piece = []byte("corrupted")
+	submitted, err = c.round(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, submitted)
+	require.False(t, executor.submissions[0].randomIndex)
+
+	// the other sp does not answer
+	executor.piece = nil
+	for _, sp := range []string{primarySp, secondarySp} {
+		if sp != executor.submissions[0].sp {
+			c.spOperatorAddresses = []string{sp}
+		}
+	}
+	submitted, err = c.round(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, submitted)
+	require.NotEqual(t, executor.submissions[0].sp, executor.submissions[1].sp)
+}
+
+func TestChallenger_PickSegment(t *testing.T) {
+	executor := &mockExecutor{objects: map[string]*types.ObjectDetail{"1": sealedObject("a", 3*SegmentSize+1)}}
+	c := newTestChallenger(&config.ProactiveConfig{}, executor)
+	sps, _ := executor.GetStorageProviderAddresses()
+	segments := make(map[uint32]bool)
+	for i := 0; i < 100; i++ {
+		target, err := c.pick("1", sps)
+		require.NoError(t, err)
+		require.Less(t, target.segmentIndex, uint32(4))
+		segments[target.segmentIndex] = true
+	}
+	require.Len(t, segments, 4)
+}
+
+func TestChallenger_PickByReputation(t *testing.T) {
+	executor := &mockExecutor{objects: map[string]*types.ObjectDetail{"1": sealedObject("a", 100)}}
+	c := newTestChallenger(&config.ProactiveConfig{}, executor)
+	c.reputation = scores{secondarySp: 0.1}
+	sps, _ := executor.GetStorageProviderAddresses()
+	picked := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		target, err := c.pick("1", sps)
+		require.NoError(t, err)
+		picked[target.spOperatorAddress]++
+	}
+	// the weights are 0.1 and 1, the less reliable sp is challenged about 9 times in 10
+	require.Greater(t, picked[secondarySp], 850)
+	require.Greater(t, picked[primarySp], 0)
+}
@@ -0,0 +1,28 @@
+package proactive
+
+import "time"
+
+const (
+	// SegmentSize is the max segment size of greenfield, the objects are split in pieces of this size
+	SegmentSize = 16 * 1024 * 1024
+	// MaxListedObjects is the number of objects listed from each bucket in a round
+	MaxListedObjects = 1000
+	// RecentChallengesCacheSize is the number of challenged objects by sp which are not challenged again
+	RecentChallengesCacheSize = 10000
+	// MinTargetWeight is the weight of the best scored sps when picking the sp to challenge, the sps are picked with
+	// the weight of their missing score on top of it
+	MinTargetWeight = 0.1
+
+	// the results of the sampled objects
+	SampleAvailable   = "available"
+	SampleUnavailable = "unavailable"
+	SampleMismatched  = "mismatched"
+	SampleUnverified  = "unverified" // submitted without pre-verification
+	SampleSkipped     = "skipped"    // missing, not sealed or not stored by a challenged sp
+	SampleError       = "error"
+)
+
+const (
+	// SpListInterval is how often the intake lists the sps again
+	SpListInterval = 10 * time.Minute
+)
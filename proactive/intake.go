@@ -0,0 +1,139 @@
+package proactive
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+// ErrIntakeFull is returned when the queue of the intake has no room for the submitted suspects.
+var ErrIntakeFull = errors.New("the suspects queue is full")
+
+// Suspect is an object an external scanner suspects to be unavailable or corrupted.
+type Suspect struct {
+	ObjectId string
+	// SpOperatorAddress is the suspected sp, all the picked sps storing the object are verified if it is empty
+	SpOperatorAddress string
+	// SegmentIndex is the suspected segment, a random segment is verified if it is nil
+	SegmentIndex *uint32
+	Reason       string
+}
+
+// Intake verifies the objects suspected by an external scanner, the pieces found unavailable or mismatched are
+// recorded as failures, which the Challenger challenges like the sampled ones.
+type Intake struct {
+	*picker
+	failures      FailureRecorder
+	metricService *metrics.MetricService
+	queue         chan *Suspect
+	mtx           sync.Mutex // serializes the submissions, which are queued all or none
+	// the sps, listed every SpListInterval
+	sps      map[uint32]string
+	listedAt time.Time
+}
+
+func NewIntake(queueSize int, spOperatorAddresses []string, executor Executor, failures FailureRecorder,
+	reputation Reputation, metricService *metrics.MetricService,
+) *Intake {
+	return &Intake{
+		picker:        newPicker(executor, reputation, spOperatorAddresses),
+		failures:      failures,
+		metricService: metricService,
+		queue:         make(chan *Suspect, queueSize),
+	}
+}
+
+// Submit queues the suspects to be verified, all of them or none if they do not fit in the queue.
+func (i *Intake) Submit(suspects []*Suspect) error {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	if len(suspects) > cap(i.queue)-len(i.queue) {
+		return ErrIntakeFull
+	}
+	for _, s := range suspects {
+		i.queue <- s
+	}
+	i.metricService.SetScannerQueuedSuspects(len(i.queue))
+	return nil
+}
+
+// Queued returns the number of suspects waiting to be verified.
+func (i *Intake) Queued() int {
+	return len(i.queue)
+}
+
+// IntakeLoop verifies the queued suspects until ctx is done.
+func (i *Intake) IntakeLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case s := <-i.queue:
+			i.metricService.SetScannerQueuedSuspects(len(i.queue))
+			if err := i.check(ctx, s, time.Now()); err != nil {
+				logging.Logger.Errorf("failed to verify object %s suspected by the scanner, err=%+v", s.ObjectId,
+					err.Error())
+			}
+		}
+	}
+}
+
+// check verifies the suspected pieces of the object and records the ones found unavailable or mismatched.
+func (i *Intake) check(ctx context.Context, s *Suspect, now time.Time) error {
+	if i.sps == nil || now.Sub(i.listedAt) >= SpListInterval {
+		sps, err := i.executor.GetStorageProviderAddresses()
+		if err != nil {
+			i.metricService.IncScannerSuspects(SampleError)
+			return err
+		}
+		i.sps, i.listedAt = sps, now
+	}
+	candidates, object, err := i.candidates(s.ObjectId, i.sps)
+	if err != nil {
+		i.metricService.IncScannerSuspects(SampleError)
+		return err
+	}
+	targets := make([]*target, 0, len(candidates))
+	for _, t := range candidates {
+		if s.SpOperatorAddress == "" || strings.EqualFold(t.spOperatorAddress, s.SpOperatorAddress) {
+			targets = append(targets, t)
+		}
+	}
+	if len(targets) == 0 {
+		i.metricService.IncScannerSuspects(SampleSkipped)
+		logging.Logger.Infof("object %s suspected by the scanner is not challenged, it is missing, not sealed or "+
+			"not stored by a challenged sp", s.ObjectId)
+		return nil
+	}
+	for _, t := range targets {
+		if err = i.piece(t, object, s.SegmentIndex); err != nil {
+			i.metricService.IncScannerSuspects(SampleError)
+			return err
+		}
+		result := i.verify(ctx, t)
+		i.metricService.IncScannerSuspects(result)
+		if result != SampleUnavailable && result != SampleMismatched {
+			continue
+		}
+		logging.Logger.Infof("piece %d of object %s suspected by the scanner (%s) is %s on sp %s", t.segmentIndex,
+			t.objectId, s.Reason, result, t.spOperatorAddress)
+		if err = i.failures.RecordSamplingFailure(ctx, &model.SamplingFailure{
+			ObjectId:          t.objectId,
+			SpOperatorAddress: t.spOperatorAddress,
+			BucketName:        t.bucketName,
+			ObjectName:        t.objectName,
+			RedundancyIndex:   t.redundancyIndex,
+			SegmentIndex:      t.segmentIndex,
+			Result:            result,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
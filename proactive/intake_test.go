@@ -0,0 +1,57 @@
+package proactive
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bnb-chain/greenfield-go-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+func TestIntake_Submit(t *testing.T) {
+	intake := NewIntake(2, nil, &mockExecutor{}, &mockFailures{}, scores{}, metrics.NewMetricService(&config.Config{}))
+	require.NoError(t, intake.Submit([]*Suspect{{ObjectId: "1"}}))
+	// the suspects are queued all or none
+	require.ErrorIs(t, intake.Submit([]*Suspect{{ObjectId: "2"}, {ObjectId: "3"}}), ErrIntakeFull)
+	require.Equal(t, 1, intake.Queued())
+	require.NoError(t, intake.Submit([]*Suspect{{ObjectId: "2"}}))
+	require.Equal(t, 2, intake.Queued())
+}
+
+func TestIntake_RecordsFailures(t *testing.T) {
+	executor := &mockExecutor{objects: map[string]*types.ObjectDetail{"1": sealedObject("a", 100)}, piece: []byte("piece")}
+	failures := &mockFailures{}
+	intake := NewIntake(10, nil, executor, failures, scores{}, metrics.NewMetricService(&config.Config{}))
+	now := time.Now()
+
+	// the sps serve the piece, nothing is recorded
+	require.NoError(t, intake.check(context.Background(), &Suspect{ObjectId: "1"}, now))
+	require.Empty(t, failures.failures)
+
+	// the suspected sp fails to serve it, the piece is a candidate for a challenge
+	executor.piece = nil
+	require.NoError(t, intake.check(context.Background(), &Suspect{ObjectId: "1",
+		SpOperatorAddress: "0x2222222222222222222222222222222222222222", Reason: "timeout"}, now))
+	require.Len(t, failures.failures, 1)
+	require.Equal(t, secondarySp, failures.failures[0].SpOperatorAddress)
+	require.Equal(t, int32(0), failures.failures[0].RedundancyIndex)
+	require.Equal(t, SampleUnavailable, failures.failures[0].Result)
+
+	// all the sps are verified without a suspected sp
+	failures.failures = nil
+	require.NoError(t, intake.check(context.Background(), &Suspect{ObjectId: "1"}, now))
+	require.Len(t, failures.failures, 2)
+	require.Equal(t, primarySp, failures.failures[0].SpOperatorAddress)
+
+	// the suspects which cannot be verified are not recorded
+	failures.failures = nil
+	segment := uint32(3)
+	require.Error(t, intake.check(context.Background(), &Suspect{ObjectId: "1", SegmentIndex: &segment}, now))
+	require.Error(t, intake.check(context.Background(), &Suspect{ObjectId: "2"}, now))
+	require.NoError(t, intake.check(context.Background(), &Suspect{ObjectId: "1", SpOperatorAddress: "0x3"}, now))
+	require.Empty(t, failures.failures)
+}
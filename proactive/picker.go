@@ -0,0 +1,174 @@
+package proactive
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+
+	"github.com/bnb-chain/greenfield-challenger/verifier"
+)
+
+// target is a piece of a sampled object and the sp storing it.
+type target struct {
+	objectId          string
+	bucketName        string
+	objectName        string
+	spOperatorAddress string
+	redundancyIndex   int32
+	segmentIndex      uint32
+	rootHash          []byte
+}
+
+func (t *target) key() string {
+	return t.objectId + "/" + t.spOperatorAddress
+}
+
+// picker picks a random piece of a sampled object and one of the sps storing it, and verifies the piece against the
+// sp.
+type picker struct {
+	executor   Executor
+	reputation Reputation
+	// spOperatorAddresses limits the picked sps, all are picked if empty
+	spOperatorAddresses []string
+	rand                *rand.Rand
+}
+
+func newPicker(executor Executor, reputation Reputation, spOperatorAddresses []string) *picker {
+	return &picker{
+		executor:            executor,
+		reputation:          reputation,
+		spOperatorAddresses: spOperatorAddresses,
+		rand:                rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// pick returns a random piece of the object stored by one of the picked sps, nil if the object cannot be challenged.
+func (p *picker) pick(objectId string, sps map[uint32]string) (*target, error) {
+	candidates, object, err := p.candidates(objectId, sps)
+	if err != nil || len(candidates) == 0 {
+		return nil, err
+	}
+	t := p.target(candidates)
+	if err = p.piece(t, object, nil); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// candidates returns the object stored by each of the picked sps, their piece is not set yet. It returns no candidates
+// if the object cannot be challenged.
+func (p *picker) candidates(objectId string, sps map[uint32]string) ([]*target, *storagetypes.ObjectInfo, error) {
+	detail, err := p.executor.GetObjectDetail(objectId)
+	if err != nil {
+		return nil, nil, err
+	}
+	object, group := detail.ObjectInfo, detail.GlobalVirtualGroup
+	if object == nil || group == nil || object.ObjectStatus != storagetypes.OBJECT_STATUS_SEALED {
+		return nil, nil, nil
+	}
+	// the primary sp stores the object with redundancy index -1, the secondary sps with their index
+	candidates := make([]*target, 0, len(group.SecondarySpIds)+1)
+	for i, spId := range append([]uint32{group.PrimarySpId}, group.SecondarySpIds...) {
+		address, ok := sps[spId]
+		if !ok || !p.picked(address) {
+			continue
+		}
+		candidates = append(candidates, &target{
+			objectId:          objectId,
+			bucketName:        object.BucketName,
+			objectName:        object.ObjectName,
+			spOperatorAddress: address,
+			redundancyIndex:   int32(i - 1),
+		})
+	}
+	return candidates, object, nil
+}
+
+// piece sets the piece of the object t is verified on, the segment segmentIndex or a random segment if it is nil.
+func (p *picker) piece(t *target, object *storagetypes.ObjectInfo, segmentIndex *uint32) error {
+	if int(t.redundancyIndex+1) >= len(object.Checksums) {
+		return fmt.Errorf("object %s has no checksum for redundancy index %d", t.objectId, t.redundancyIndex)
+	}
+	t.rootHash = object.Checksums[t.redundancyIndex+1]
+	segments := (object.PayloadSize + SegmentSize - 1) / SegmentSize
+	switch {
+	case segmentIndex != nil:
+		if uint64(*segmentIndex) >= segments {
+			return fmt.Errorf("object %s has no segment %d, it has %d segments", t.objectId, *segmentIndex, segments)
+		}
+		t.segmentIndex = *segmentIndex
+	case segments > 1:
+		t.segmentIndex = uint32(p.rand.Int63n(int64(segments)))
+	}
+	return nil
+}
+
+// target picks one of the candidates at random, the sps with a lower reputation score are more likely to be picked.
+func (p *picker) target(candidates []*target) *target {
+	weights := make([]float64, len(candidates))
+	total := 0.0
+	for i, t := range candidates {
+		weights[i] = MinTargetWeight + math.Max(0, 1-p.reputation.Score(t.spOperatorAddress))
+		total += weights[i]
+	}
+	pick := p.rand.Float64() * total
+	for i, weight := range weights {
+		if pick < weight {
+			return candidates[i]
+		}
+		pick -= weight
+	}
+	return candidates[len(candidates)-1]
+}
+
+// picked reports whether the sp is one of the picked sps.
+func (p *picker) picked(address string) bool {
+	if len(p.spOperatorAddresses) == 0 {
+		return true
+	}
+	for _, addr := range p.spOperatorAddresses {
+		if strings.EqualFold(addr, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// verify challenges the sp for the piece, as the verifier does for the challenges of the chain.
+func (p *picker) verify(ctx context.Context, t *target) string {
+	endpoint, err := p.executor.GetStorageProviderEndpoint(t.spOperatorAddress)
+	if err != nil {
+		return SampleError
+	}
+	res, err := p.executor.GetChallengeResultFromSp(ctx, t.objectId, endpoint, int(t.segmentIndex), int(t.redundancyIndex))
+	if err != nil {
+		return SampleUnavailable
+	}
+	pieceData, err := io.ReadAll(res.PieceData)
+	if err != nil {
+		return SampleUnavailable
+	}
+	checksums := make([][]byte, 0, len(res.PiecesHash))
+	for _, h := range res.PiecesHash {
+		checksum, err := hex.DecodeString(h)
+		if err != nil {
+			return SampleMismatched
+		}
+		checksums = append(checksums, checksum)
+	}
+	if int(t.segmentIndex) >= len(checksums) {
+		return SampleMismatched
+	}
+	if !bytes.Equal(verifier.ComputeRootHash(t.segmentIndex, pieceData, checksums), t.rootHash) {
+		return SampleMismatched
+	}
+	return SampleAvailable
+}
@@ -0,0 +1,127 @@
+package proactive
+
+import (
+	"context"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+// FailureRecorder records the pieces the sampler found unavailable.
+type FailureRecorder interface {
+	RecordSamplingFailure(ctx context.Context, failure *model.SamplingFailure) error
+}
+
+// Sampler verifies the availability of a random piece of the objects of the sampled buckets in the background,
+// independently of the challenges on chain. The pieces a sp fails to serve are recorded as failures, which the
+// Challenger challenges.
+type Sampler struct {
+	*picker
+	cfg           *config.SamplingConfig
+	failures      FailureRecorder
+	metricService *metrics.MetricService
+	// the objects of the buckets and the sps, listed every list interval
+	objectIds []string
+	sps       map[uint32]string
+	listedAt  time.Time
+}
+
+func NewSampler(cfg *config.SamplingConfig, spOperatorAddresses []string, executor Executor, failures FailureRecorder,
+	reputation Reputation, metricService *metrics.MetricService,
+) *Sampler {
+	return &Sampler{
+		picker:        newPicker(executor, reputation, spOperatorAddresses),
+		cfg:           cfg,
+		failures:      failures,
+		metricService: metricService,
+	}
+}
+
+// SampleLoop samples a piece every interval until ctx is done.
+func (s *Sampler) SampleLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(s.cfg.IntervalInSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.sampleOnce(ctx, time.Now()); err != nil {
+			logging.Logger.Errorf("availability sampling failed, err=%+v", err.Error())
+		}
+	}
+}
+
+// sampleOnce verifies a random piece of a random object, the objects are listed again when they are stale.
+func (s *Sampler) sampleOnce(ctx context.Context, now time.Time) error {
+	if len(s.objectIds) == 0 || now.Sub(s.listedAt) >= time.Duration(s.cfg.ListIntervalInMinutes)*time.Minute {
+		if err := s.list(); err != nil {
+			return err
+		}
+		s.listedAt = now
+	}
+	if len(s.objectIds) == 0 {
+		return nil
+	}
+	t, err := s.pick(s.objectIds[s.rand.Intn(len(s.objectIds))], s.sps)
+	if err != nil || t == nil {
+		s.metricService.IncSamplingSamples(SampleSkipped)
+		return err
+	}
+	result := s.verify(ctx, t)
+	s.metricService.IncSamplingSamples(result)
+	if result != SampleUnavailable && result != SampleMismatched {
+		return nil
+	}
+	logging.Logger.Infof("sampled piece %d of object %s is %s on sp %s", t.segmentIndex, t.objectId, result,
+		t.spOperatorAddress)
+	return s.failures.RecordSamplingFailure(ctx, &model.SamplingFailure{
+		ObjectId:          t.objectId,
+		SpOperatorAddress: t.spOperatorAddress,
+		BucketName:        t.bucketName,
+		ObjectName:        t.objectName,
+		RedundancyIndex:   t.redundancyIndex,
+		SegmentIndex:      t.segmentIndex,
+		Result:            result,
+	})
+}
+
+// list lists the objects of the sampled buckets and of the buckets owned by the sampled accounts.
+func (s *Sampler) list() error {
+	buckets := make([]string, 0, len(s.cfg.Buckets))
+	seen := make(map[string]bool)
+	add := func(names ...string) {
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				buckets = append(buckets, name)
+			}
+		}
+	}
+	add(s.cfg.Buckets...)
+	for _, account := range s.cfg.Accounts {
+		names, err := s.executor.ListBucketNames(account)
+		if err != nil {
+			return err
+		}
+		add(names...)
+	}
+	objectIds := make([]string, 0)
+	for _, bucket := range buckets {
+		ids, err := s.executor.ListObjectIds(bucket, MaxListedObjects)
+		if err != nil {
+			return err
+		}
+		objectIds = append(objectIds, ids...)
+	}
+	sps, err := s.executor.GetStorageProviderAddresses()
+	if err != nil {
+		return err
+	}
+	s.objectIds, s.sps = objectIds, sps
+	return nil
+}
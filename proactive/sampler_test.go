@@ -0,0 +1,51 @@
+package proactive
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/bnb-chain/greenfield-go-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+func TestSampler_RecordsFailures(t *testing.T) {
+	executor := &mockExecutor{objects: map[string]*types.ObjectDetail{"1": sealedObject("a", 100)}, piece: []byte("piece")}
+	failures := &mockFailures{}
+	metricService := metrics.NewMetricService(&config.Config{})
+	cfg := &config.SamplingConfig{IntervalInSeconds: 1, Accounts: []string{"0x1111111111111111111111111111111111111111"},
+		ListIntervalInMinutes: 60}
+	sampler := NewSampler(cfg, []string{secondarySp}, executor, failures, scores{}, metricService)
+	sampler.rand = rand.New(rand.NewSource(1))
+
+	// the sp serves the piece, nothing is recorded
+	now := time.Now()
+	require.NoError(t, sampler.sampleOnce(context.Background(), now))
+	require.Len(t, sampler.objectIds, 1)
+	require.Empty(t, failures.failures)
+
+	// the sp fails to serve it, the piece is a candidate for a challenge
+	executor.piece = nil
+	require.NoError(t, sampler.sampleOnce(context.Background(), now.Add(time.Second)))
+	require.Len(t, failures.failures, 1)
+	require.Equal(t, secondarySp, failures.failures[0].SpOperatorAddress)
+	require.Equal(t, SampleUnavailable, failures.failures[0].Result)
+
+	// which the challenger challenges first, on the sampled piece
+	challenger := NewChallenger(&config.ProactiveConfig{MaxChallengesPerRound: 1}, executor, failures, scores{},
+		metricService)
+	submitted, err := challenger.round(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, submitted)
+	require.Equal(t, submission{secondarySp, "a", 0, false}, executor.submissions[0])
+	require.True(t, failures.failures[0].Challenged)
+
+	// once challenged it is not challenged again
+	submitted, err = challenger.round(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0, submitted)
+}
@@ -0,0 +1,101 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// BscClient follows the packages received by the CrossChain contract on BSC.
+type BscClient struct {
+	client   *ethclient.Client
+	contract ethcommon.Address
+	abi      abi.ABI
+}
+
+func NewBscClient(cfg *config.RelayConfig) (*BscClient, error) {
+	client, err := ethclient.Dial(cfg.BscRpcAddr)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := abi.JSON(strings.NewReader(crossChainABI))
+	if err != nil {
+		return nil, err
+	}
+	return &BscClient{
+		client:   client,
+		contract: ethcommon.HexToAddress(cfg.CrossChainContract),
+		abi:      parsed,
+	}, nil
+}
+
+func (c *BscClient) LatestBlock(ctx context.Context) (uint64, error) {
+	return c.client.BlockNumber(ctx)
+}
+
+// ReceiveSequence returns the sequence of the next package the CrossChain contract receives on the channel.
+func (c *BscClient) ReceiveSequence(ctx context.Context, channelId uint8) (uint64, error) {
+	data, err := c.abi.Pack("channelReceiveSequenceMap", channelId)
+	if err != nil {
+		return 0, err
+	}
+	out, err := c.client.CallContract(ctx, ethereum.CallMsg{To: &c.contract, Data: data}, nil)
+	if err != nil {
+		return 0, err
+	}
+	values, err := c.abi.Unpack("channelReceiveSequenceMap", out)
+	if err != nil {
+		return 0, err
+	}
+	sequence, ok := values[0].(uint64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected receive sequence %v", values[0])
+	}
+	return sequence, nil
+}
+
+// Execution searches the receipt of the package from fromBlock on, and reports whether its handler failed. The handler
+// failures are logged by the CrossChain contract before the package is marked received.
+func (c *BscClient) Execution(ctx context.Context, channelId uint8, sequence uint64, fromBlock uint64,
+) (found bool, failed bool, err error) {
+	received := c.abi.Events["ReceivedPackage"].ID
+	logs, err := c.client.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		Addresses: []ethcommon.Address{c.contract},
+		Topics: [][]ethcommon.Hash{{received}, {ethcommon.BigToHash(new(big.Int).SetUint64(sequence))},
+			{ethcommon.BigToHash(big.NewInt(int64(channelId)))}},
+	})
+	if err != nil || len(logs) == 0 {
+		return false, false, err
+	}
+	receipt, err := c.client.TransactionReceipt(ctx, logs[0].TxHash)
+	if err != nil {
+		return false, false, err
+	}
+	failures := map[ethcommon.Hash]bool{
+		c.abi.Events["UnexpectedRevertInPackageHandler"].ID:           true,
+		c.abi.Events["UnexpectedFailureAssertionInPackageHandler"].ID: true,
+	}
+	for _, l := range receipt.Logs {
+		if l.Address != c.contract || len(l.Topics) == 0 {
+			continue
+		}
+		switch {
+		case l.Index == logs[0].Index:
+			return true, failed, nil
+		case l.Topics[0] == received:
+			// the failures logged before belong to another package of the transaction
+			failed = false
+		case failures[l.Topics[0]]:
+			failed = true
+		}
+	}
+	return true, false, nil
+}
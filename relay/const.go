@@ -0,0 +1,33 @@
+package relay
+
+const (
+	EventAttestChallenge = "greenfield.challenge.EventAttestChallenge"
+	EventCrossChain      = "cosmos.crosschain.v1.EventCrossChain"
+
+	// MaxPendingPackages bounds the packages followed at once, the packages emitted beyond it are not followed
+	MaxPendingPackages = 10000
+	// ExecutionLookbackBlocks is how far before the first check of a package its execution is searched on BSC, about
+	// an hour of BSC blocks
+	ExecutionLookbackBlocks = 1200
+
+	// the outcomes of the followed packages
+	OutcomeExecuted = "executed"
+	OutcomeFailed   = "failed"
+	OutcomeTimedOut = "timed_out"
+)
+
+// crossChainABI is the part of the abi of the CrossChain contract on BSC the packages are followed with.
+const crossChainABI = `[
+	{"type":"function","name":"channelReceiveSequenceMap","stateMutability":"view",
+		"inputs":[{"name":"","type":"uint8"}],"outputs":[{"name":"","type":"uint64"}]},
+	{"type":"event","name":"ReceivedPackage","anonymous":false,"inputs":[
+		{"name":"packageType","type":"uint8","indexed":false},
+		{"name":"packageSequence","type":"uint64","indexed":true},
+		{"name":"channelId","type":"uint8","indexed":true}]},
+	{"type":"event","name":"UnexpectedRevertInPackageHandler","anonymous":false,"inputs":[
+		{"name":"contractAddr","type":"address","indexed":true},
+		{"name":"reason","type":"string","indexed":false}]},
+	{"type":"event","name":"UnexpectedFailureAssertionInPackageHandler","anonymous":false,"inputs":[
+		{"name":"contractAddr","type":"address","indexed":true},
+		{"name":"lowLevelData","type":"bytes","indexed":false}]}
+]`
@@ -0,0 +1,223 @@
+package relay
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/alert"
+	"github.com/bnb-chain/greenfield-challenger/common"
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+	abci "github.com/cometbft/cometbft/abci/types"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+)
+
+// Destination is the chain the packages are relayed to.
+type Destination interface {
+	LatestBlock(ctx context.Context) (uint64, error)
+	ReceiveSequence(ctx context.Context, channelId uint8) (uint64, error)
+	Execution(ctx context.Context, channelId uint8, sequence uint64, fromBlock uint64) (found bool, failed bool, err error)
+}
+
+// Package is a cross-chain package emitted by the transaction attesting a challenge.
+type Package struct {
+	ChallengeId uint64
+	Height      uint64 // greenfield height of the attestation
+	ChannelId   uint8
+	Sequence    uint64
+	EmittedTime time.Time
+	fromBlock   uint64 // destination block the execution is searched from, set on the first check
+}
+
+// ParsePackages returns the packages to destChainId emitted by the transactions of the block which attest a challenge.
+func ParsePackages(blockRes *ctypes.ResultBlockResults, destChainId uint32, blockTime time.Time) []*Package {
+	packages := make([]*Package, 0)
+	for _, tx := range blockRes.TxsResults {
+		var challengeId uint64
+		attested := false
+		txPackages := make([]*Package, 0)
+		for _, event := range tx.Events {
+			attrs := eventAttributes(event)
+			switch event.Type {
+			case EventAttestChallenge:
+				if id, err := strconv.ParseUint(attrs["challenge_id"], 10, 64); err == nil && !attested {
+					challengeId, attested = id, true
+				}
+			case EventCrossChain:
+				dest, err := strconv.ParseUint(attrs["dest_chain_id"], 10, 32)
+				if err != nil || uint32(dest) != destChainId {
+					continue
+				}
+				channelId, err := strconv.ParseUint(attrs["channel_id"], 10, 8)
+				if err != nil {
+					continue
+				}
+				sequence, err := strconv.ParseUint(attrs["sequence"], 10, 64)
+				if err != nil {
+					continue
+				}
+				txPackages = append(txPackages, &Package{
+					Height:      uint64(blockRes.Height),
+					ChannelId:   uint8(channelId),
+					Sequence:    sequence,
+					EmittedTime: blockTime,
+				})
+			}
+		}
+		if !attested {
+			continue
+		}
+		for _, p := range txPackages {
+			p.ChallengeId = challengeId
+		}
+		packages = append(packages, txPackages...)
+	}
+	return packages
+}
+
+func eventAttributes(event abci.Event) map[string]string {
+	attrs := make(map[string]string, len(event.Attributes))
+	for _, attr := range event.Attributes {
+		attrs[string(attr.Key)] = strings.Trim(string(attr.Value), `"`)
+	}
+	return attrs
+}
+
+// Tracker follows the packages emitted by the attestations until they are executed on the destination chain, and
+// alerts on the ones which fail or are not relayed within the timeout. The packages are followed in memory, the ones
+// pending when the challenger stops are not followed after a restart.
+type Tracker struct {
+	cfg           *config.RelayConfig
+	dest          Destination
+	metricService *metrics.MetricService
+	mtx           sync.Mutex
+	pending       []*Package
+}
+
+func NewTracker(cfg *config.RelayConfig, dest Destination, metricService *metrics.MetricService) *Tracker {
+	return &Tracker{
+		cfg:           cfg,
+		dest:          dest,
+		metricService: metricService,
+		pending:       make([]*Package, 0),
+	}
+}
+
+// Observe follows the packages emitted by the attestations of a polled block.
+func (t *Tracker) Observe(blockRes *ctypes.ResultBlockResults, blockTime time.Time) {
+	packages := ParsePackages(blockRes, t.cfg.DestChainId, blockTime)
+	if len(packages) == 0 {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	for _, p := range packages {
+		if len(t.pending) >= MaxPendingPackages {
+			logging.AttestLogger.Errorf("relay tracker follows %d packages, package %d on channel %d of challenge %d is "+
+				"not followed", len(t.pending), p.Sequence, p.ChannelId, p.ChallengeId)
+			continue
+		}
+		t.pending = append(t.pending, p)
+	}
+	t.metricService.SetRelayPendingPackages(len(t.pending))
+}
+
+// Pending returns the number of packages not relayed yet.
+func (t *Tracker) Pending() int {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return len(t.pending)
+}
+
+// Check checks the pending packages against the destination chain. A package is done once it is executed, failed, or
+// timed out, the last two are alerted.
+func (t *Tracker) Check(ctx context.Context, now time.Time) error {
+	t.mtx.Lock()
+	pending := append([]*Package(nil), t.pending...)
+	t.mtx.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	latestBlock, err := t.dest.LatestBlock(ctx)
+	if err != nil {
+		return err
+	}
+	sequences := make(map[uint8]uint64)
+	done := make(map[*Package]bool)
+	for _, p := range pending {
+		if p.fromBlock == 0 {
+			p.fromBlock = 1
+			if latestBlock > ExecutionLookbackBlocks {
+				p.fromBlock = latestBlock - ExecutionLookbackBlocks
+			}
+		}
+		receiveSequence, ok := sequences[p.ChannelId]
+		if !ok {
+			if receiveSequence, err = t.dest.ReceiveSequence(ctx, p.ChannelId); err != nil {
+				return err
+			}
+			sequences[p.ChannelId] = receiveSequence
+		}
+		if receiveSequence <= p.Sequence {
+			if age := now.Sub(p.EmittedTime); age > t.cfg.Timeout() {
+				logging.AttestLogger.Errorf("package %d on channel %d of challenge %d is not relayed after %s, "+
+					"receive sequence: %d", p.Sequence, p.ChannelId, p.ChallengeId, age.Truncate(time.Second),
+					receiveSequence)
+				alert.RelayGap(p.ChallengeId, p.ChannelId, p.Sequence, age)
+				t.metricService.IncRelayPackages(OutcomeTimedOut)
+				done[p] = true
+			}
+			continue
+		}
+
+		found, failed, err := t.dest.Execution(ctx, p.ChannelId, p.Sequence, p.fromBlock)
+		if err != nil {
+			return err
+		}
+		if !found {
+			// received before the searched blocks, the outcome of its handler is unknown
+			logging.AttestLogger.Warningf("package %d on channel %d of challenge %d was received but not found after "+
+				"block %d", p.Sequence, p.ChannelId, p.ChallengeId, p.fromBlock)
+		}
+		if failed {
+			logging.AttestLogger.Errorf("package %d on channel %d of challenge %d failed in its handler", p.Sequence,
+				p.ChannelId, p.ChallengeId)
+			alert.RelayFailed(p.ChallengeId, p.ChannelId, p.Sequence)
+			t.metricService.IncRelayPackages(OutcomeFailed)
+		} else {
+			logging.AttestLogger.Infof("package %d on channel %d of challenge %d executed", p.Sequence, p.ChannelId,
+				p.ChallengeId)
+			t.metricService.IncRelayPackages(OutcomeExecuted)
+		}
+		done[p] = true
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	remaining := t.pending[:0]
+	for _, p := range t.pending {
+		if !done[p] {
+			remaining = append(remaining, p)
+		}
+	}
+	t.pending = remaining
+	t.metricService.SetRelayPendingPackages(len(t.pending))
+	return nil
+}
+
+// CheckLoop checks the pending packages every interval until ctx is done.
+func (t *Tracker) CheckLoop(ctx context.Context) {
+	for {
+		if !common.Sleep(ctx, t.cfg.Interval()) {
+			return
+		}
+		if err := t.Check(ctx, time.Now()); err != nil {
+			logging.AttestLogger.Errorf("relay tracker failed to check the pending packages, err=%+v", err.Error())
+		}
+	}
+}
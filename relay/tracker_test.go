@@ -0,0 +1,96 @@
+package relay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+	abci "github.com/cometbft/cometbft/abci/types"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDestination struct {
+	sequences map[uint8]uint64
+	failed    map[uint64]bool // by sequence
+	searched  []uint64        // the blocks the executions were searched from
+}
+
+func (d *fakeDestination) LatestBlock(context.Context) (uint64, error) {
+	return 5000, nil
+}
+
+func (d *fakeDestination) ReceiveSequence(_ context.Context, channelId uint8) (uint64, error) {
+	return d.sequences[channelId], nil
+}
+
+func (d *fakeDestination) Execution(_ context.Context, _ uint8, sequence uint64, fromBlock uint64) (bool, bool, error) {
+	d.searched = append(d.searched, fromBlock)
+	return true, d.failed[sequence], nil
+}
+
+func attr(key, value string) abci.EventAttribute {
+	return abci.EventAttribute{Key: key, Value: value}
+}
+
+func crossChainEvent(dest, channel, sequence string) abci.Event {
+	return abci.Event{Type: EventCrossChain, Attributes: []abci.EventAttribute{
+		attr("dest_chain_id", dest), attr("channel_id", channel), attr("sequence", `"`+sequence+`"`),
+	}}
+}
+
+func attestEvent(challengeId string) abci.Event {
+	return abci.Event{Type: EventAttestChallenge, Attributes: []abci.EventAttribute{attr("challenge_id", `"`+challengeId+`"`)}}
+}
+
+func TestParsePackages(t *testing.T) {
+	blockTime := time.Unix(1000, 0)
+	blockRes := &ctypes.ResultBlockResults{Height: 100, TxsResults: []*abci.ResponseDeliverTx{
+		{Events: []abci.Event{attestEvent("7"), crossChainEvent("56", "4", "10"), crossChainEvent("97", "4", "11")}},
+		// packages of transactions which attest nothing are not followed
+		{Events: []abci.Event{crossChainEvent("56", "4", "12")}},
+	}}
+
+	packages := ParsePackages(blockRes, 56, blockTime)
+	require.Equal(t, []*Package{{ChallengeId: 7, Height: 100, ChannelId: 4, Sequence: 10, EmittedTime: blockTime}},
+		packages)
+}
+
+func TestCheck(t *testing.T) {
+	cfg := &config.RelayConfig{DestChainId: 56}
+	dest := &fakeDestination{sequences: map[uint8]uint64{4: 12, 5: 3}, failed: map[uint64]bool{11: true}}
+	tracker := NewTracker(cfg, dest, metrics.NewMetricService(&config.Config{}))
+
+	emitted := time.Unix(1000, 0)
+	blockRes := &ctypes.ResultBlockResults{Height: 100, TxsResults: []*abci.ResponseDeliverTx{
+		{Events: []abci.Event{attestEvent("1"), crossChainEvent("56", "4", "10")}},
+		{Events: []abci.Event{attestEvent("2"), crossChainEvent("56", "4", "11")}},
+		{Events: []abci.Event{attestEvent("3"), crossChainEvent("56", "5", "3")}},
+	}}
+	tracker.Observe(blockRes, emitted)
+	require.Equal(t, 3, tracker.Pending())
+
+	// the executed and the failed packages are done, the one not received yet keeps pending within the timeout
+	require.NoError(t, tracker.Check(context.Background(), emitted.Add(time.Minute)))
+	require.Equal(t, 1, tracker.Pending())
+	require.Equal(t, []uint64{5000 - ExecutionLookbackBlocks, 5000 - ExecutionLookbackBlocks}, dest.searched)
+
+	// it is alerted as a relay gap once timed out
+	require.NoError(t, tracker.Check(context.Background(), emitted.Add(cfg.Timeout()+time.Minute)))
+	require.Equal(t, 0, tracker.Pending())
+}
+
+func TestNewBscClient(t *testing.T) {
+	client, err := NewBscClient(&config.RelayConfig{BscRpcAddr: "http://127.0.0.1:8545",
+		CrossChainContract: "0x77e719b714be09F70D484AB81F70D02B0E182f7d"})
+	require.NoError(t, err)
+	for _, event := range []string{"ReceivedPackage", "UnexpectedRevertInPackageHandler",
+		"UnexpectedFailureAssertionInPackageHandler"} {
+		require.Contains(t, client.abi.Events, event)
+	}
+	data, err := client.abi.Pack("channelReceiveSequenceMap", uint8(4))
+	require.NoError(t, err)
+	require.Len(t, data, 36)
+}
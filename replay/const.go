@@ -0,0 +1,12 @@
+package replay
+
+const (
+	// EventAttestChallenge is the type of the events of the challenges attested on chain
+	EventAttestChallenge = "greenfield.challenge.EventAttestChallenge"
+	// MaxAttestBlocks bounds the blocks scanned for the attestations after the replayed range, the challenges are
+	// attested before they expire
+	MaxAttestBlocks = 1000
+
+	// NoVote is the vote of a challenge the challenger does not vote for, or the attestation of one not attested
+	NoVote = "-"
+)
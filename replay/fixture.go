@@ -0,0 +1,52 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Fixture is what a challenge is verified against: the checksums of the object on chain and the piece served by the
+// storage provider. Fixtures are recorded while replaying against the chain and the storage providers, so that an
+// incident can be replayed again once the objects are gone.
+type Fixture struct {
+	ChallengeId uint64   `json:"challenge_id"`
+	Checksums   []string `json:"checksums"`              // hex encoded, the primary one first
+	PieceHashes []string `json:"piece_hashes,omitempty"` // hex encoded, as served by the sp
+	PieceData   string   `json:"piece_data,omitempty"`   // hex encoded
+	// SpError is the error of the sp if it did not serve the piece, the challenge succeeds then
+	SpError string `json:"sp_error,omitempty"`
+}
+
+// LoadFixtures reads the fixtures from r, one json encoded fixture per line.
+func LoadFixtures(r io.Reader) (map[uint64]*Fixture, error) {
+	fixtures := make(map[uint64]*Fixture)
+	scanner := bufio.NewScanner(r)
+	// a line holds a whole piece, up to the max segment size hex encoded
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		fixture := &Fixture{}
+		if err := json.Unmarshal(scanner.Bytes(), fixture); err != nil {
+			return nil, fmt.Errorf("invalid fixture on line %d, err=%+v", line, err.Error())
+		}
+		fixtures[fixture.ChallengeId] = fixture
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return fixtures, nil
+}
+
+// writeFixture appends the fixture to w as a line.
+func writeFixture(w io.Writer, fixture *Fixture) error {
+	line, err := json.Marshal(fixture)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
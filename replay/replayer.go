@@ -0,0 +1,328 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/avast/retry-go/v4"
+	"github.com/bnb-chain/greenfield-go-sdk/types"
+	challengetypes "github.com/bnb-chain/greenfield/x/challenge/types"
+	abci "github.com/cometbft/cometbft/abci/types"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+
+	"github.com/bnb-chain/greenfield-challenger/common"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/monitor"
+	"github.com/bnb-chain/greenfield-challenger/verifier"
+)
+
+var errNoFixture = errors.New("no fixture")
+
+// Executor is the chain and the storage providers the challenges are replayed against.
+type Executor interface {
+	GetLatestBlockHeight() (uint64, error)
+	GetBlockAndBlockResultAtHeight(height int64) (*tmtypes.Block, *ctypes.ResultBlockResults, error)
+	QueryChallengeHeartbeatInterval() (uint64, error)
+	GetObjectInfoChecksums(objectId string) ([][]byte, error)
+	GetStorageProviderEndpoint(address string) (string, error)
+	GetChallengeResultFromSp(ctx context.Context, objectId, endpoint string, segmentIndex, redundancyIndex int) (*types.ChallengeResult, error)
+}
+
+// Result is the replay of a challenge: the vote the challenger produces for it today and the result attested on
+// chain back then.
+type Result struct {
+	ChallengeId       uint64
+	Height            uint64
+	ObjectId          string
+	SpOperatorAddress string
+	SegmentIndex      uint32
+	RedundancyIndex   int32
+	VerifyResult      model.VerifyResult
+	Vote              string // NoVote if the challenger does not vote
+	Attested          string // NoVote if it was not attested
+	// Error is why the challenge could not be verified, e.g. the object was deleted since
+	Error string
+}
+
+// Diverged reports whether the vote of the challenger differs from the attestation.
+func (r *Result) Diverged() bool {
+	return r.Error == "" && r.Vote != r.Attested
+}
+
+// Report is the replay of the challenges started within a block range.
+type Report struct {
+	FromHeight uint64
+	ToHeight   uint64
+	Results    []*Result
+}
+
+// Diverged returns the number of challenges whose vote differs from the attestation.
+func (r *Report) Diverged() int {
+	diverged := 0
+	for _, result := range r.Results {
+		if result.Diverged() {
+			diverged++
+		}
+	}
+	return diverged
+}
+
+// Write writes the report to w as a table, the diverged challenges are flagged.
+func (r *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHALLENGE\tHEIGHT\tOBJECT\tSP\tSEGMENT\tREDUNDANCY\tVERIFIED\tVOTE\tATTESTED\t")
+	unverified := 0
+	for _, result := range r.Results {
+		flag := ""
+		if result.Diverged() {
+			flag = "DIVERGED"
+		} else if result.Error != "" {
+			flag = result.Error
+			unverified++
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\n", result.ChallengeId, result.Height, result.ObjectId,
+			result.SpOperatorAddress, result.SegmentIndex, result.RedundancyIndex, result.VerifyResult, result.Vote,
+			result.Attested, flag)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "replayed %d challenges between heights %d and %d: %d diverged, %d unverified\n",
+		len(r.Results), r.FromHeight, r.ToHeight, r.Diverged(), unverified)
+	return err
+}
+
+// Replayer replays the monitor and the verifier over past blocks, without touching the database, and compares the
+// votes it produces with the attestations on chain. The challenges are verified against the storage providers, or
+// against recorded fixtures. The deduplication of the challenges by the verifier is not replayed.
+type Replayer struct {
+	executor Executor
+	fixtures map[uint64]*Fixture // nil if the challenges are verified against the sps
+	recorder io.Writer           // nil unless the fixtures are recorded
+}
+
+func NewReplayer(executor Executor) *Replayer {
+	return &Replayer{executor: executor}
+}
+
+// SetFixtures verifies the challenges against the fixtures, the ones without a fixture are not verified.
+func (r *Replayer) SetFixtures(fixtures map[uint64]*Fixture) {
+	r.fixtures = fixtures
+}
+
+// SetRecorder records the fixtures of the challenges verified against the chain and the sps to w.
+func (r *Replayer) SetRecorder(w io.Writer) {
+	r.recorder = w
+}
+
+// Replay replays the challenges started between the heights, both inclusive. The attestations are looked up until
+// the last of the challenges expires.
+func (r *Replayer) Replay(ctx context.Context, fromHeight, toHeight uint64) (*Report, error) {
+	latestHeight, err := r.executor.GetLatestBlockHeight()
+	if err != nil {
+		return nil, err
+	}
+	if toHeight == 0 || toHeight > latestHeight {
+		toHeight = latestHeight
+	}
+	if fromHeight > toHeight {
+		return nil, fmt.Errorf("from height %d is above to height %d", fromHeight, toHeight)
+	}
+	heartbeatInterval, err := r.executor.QueryChallengeHeartbeatInterval()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{FromHeight: fromHeight, ToHeight: toHeight}
+	attested := make(map[uint64]string)
+	lastHeight := toHeight
+	for height := fromHeight; height <= lastHeight; height++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		_, blockResults, err := r.executor.GetBlockAndBlockResultAtHeight(int64(height))
+		if err != nil {
+			return nil, fmt.Errorf("get block %d error, err=%+v", height, err.Error())
+		}
+		if err = parseAttestations(blockResults, attested); err != nil {
+			return nil, fmt.Errorf("parse attestations of block %d error, err=%+v", height, err.Error())
+		}
+		if height > toHeight {
+			continue
+		}
+		events, err := monitor.ParseEvents(blockResults)
+		if err != nil {
+			return nil, fmt.Errorf("parse challenges of block %d error, err=%+v", height, err.Error())
+		}
+		for _, event := range events {
+			report.Results = append(report.Results, r.replayEvent(ctx, height, event, heartbeatInterval))
+			if event.ExpiredHeight > lastHeight {
+				lastHeight = event.ExpiredHeight
+			}
+		}
+		if lastHeight > latestHeight {
+			lastHeight = latestHeight
+		}
+		if lastHeight > toHeight+MaxAttestBlocks {
+			lastHeight = toHeight + MaxAttestBlocks
+		}
+	}
+	for _, result := range report.Results {
+		if vote, ok := attested[result.ChallengeId]; ok {
+			result.Attested = vote
+		}
+	}
+	return report, nil
+}
+
+// replayEvent verifies the challenge and returns the vote of the challenger for it: the challenges which fail are
+// voted, the heartbeat challenges are voted whatever their result.
+func (r *Replayer) replayEvent(ctx context.Context, height uint64, event *challengetypes.EventStartChallenge,
+	heartbeatInterval uint64,
+) *Result {
+	result := &Result{
+		ChallengeId:       event.ChallengeId,
+		Height:            height,
+		ObjectId:          event.ObjectId.String(),
+		SpOperatorAddress: event.SpOperatorAddress,
+		SegmentIndex:      event.SegmentIndex,
+		RedundancyIndex:   event.RedundancyIndex,
+		Vote:              NoVote,
+		Attested:          NoVote,
+	}
+	verifyResult, err := r.verify(ctx, event)
+	if err != nil {
+		logging.Logger.Errorf("failed to replay challenge %d, err=%+v", event.ChallengeId, err.Error())
+		result.Error = err.Error()
+		return result
+	}
+	result.VerifyResult = verifyResult
+	if verifyResult == model.HashMismatched {
+		result.Vote = challengetypes.CHALLENGE_SUCCEED.String()
+	} else if heartbeatInterval != 0 && event.ChallengeId%heartbeatInterval == 0 {
+		result.Vote = challengetypes.CHALLENGE_FAILED.String()
+	}
+	return result
+}
+
+// verify verifies the challenge against its fixture, or against the chain and the sp.
+func (r *Replayer) verify(ctx context.Context, event *challengetypes.EventStartChallenge) (model.VerifyResult, error) {
+	fixture, ok := r.fixtures[event.ChallengeId]
+	if r.fixtures != nil && !ok {
+		return model.Unknown, errNoFixture
+	}
+	if !ok {
+		var err error
+		if fixture, err = r.fetch(ctx, event); err != nil {
+			return model.Unknown, err
+		}
+		if r.recorder != nil {
+			if err = writeFixture(r.recorder, fixture); err != nil {
+				return model.Unknown, fmt.Errorf("record fixture error, err=%+v", err.Error())
+			}
+		}
+	}
+	return judge(event, fixture)
+}
+
+// fetch reads the checksums of the object from the chain and challenges the sp for the piece, as the verifier does.
+func (r *Replayer) fetch(ctx context.Context, event *challengetypes.EventStartChallenge) (*Fixture, error) {
+	fixture := &Fixture{ChallengeId: event.ChallengeId}
+	checksums, err := r.executor.GetObjectInfoChecksums(event.ObjectId.String())
+	if err != nil {
+		return nil, fmt.Errorf("get object checksums error, err=%+v", err.Error())
+	}
+	for _, checksum := range checksums {
+		fixture.Checksums = append(fixture.Checksums, hex.EncodeToString(checksum))
+	}
+	endpoint, err := r.executor.GetStorageProviderEndpoint(event.SpOperatorAddress)
+	if err != nil {
+		return nil, fmt.Errorf("get sp endpoint error, err=%+v", err.Error())
+	}
+
+	var res *types.ChallengeResult
+	err = retry.Do(func() error {
+		res, err = r.executor.GetChallengeResultFromSp(ctx, event.ObjectId.String(), endpoint,
+			int(event.SegmentIndex), int(event.RedundancyIndex))
+		return err
+	}, retry.Context(ctx), common.RtyAttem, common.RtyDelay, common.RtyErr)
+	if err != nil {
+		fixture.SpError = err.Error()
+		return fixture, nil
+	}
+	pieceData, err := io.ReadAll(res.PieceData)
+	if err != nil {
+		fixture.SpError = err.Error()
+		return fixture, nil
+	}
+	fixture.PieceHashes = res.PiecesHash
+	fixture.PieceData = hex.EncodeToString(pieceData)
+	return fixture, nil
+}
+
+// judge compares the root hash of the piece served by the sp with the checksum of the object on chain.
+func judge(event *challengetypes.EventStartChallenge, fixture *Fixture) (model.VerifyResult, error) {
+	index := int(event.RedundancyIndex) + 1
+	if index < 0 || index >= len(fixture.Checksums) {
+		return model.Unknown, fmt.Errorf("no checksum for redundancy index %d", event.RedundancyIndex)
+	}
+	chainRootHash, err := hex.DecodeString(fixture.Checksums[index])
+	if err != nil {
+		return model.Unknown, fmt.Errorf("invalid checksum, err=%+v", err.Error())
+	}
+	// the verifier votes against a sp which does not serve the piece
+	if fixture.SpError != "" {
+		return model.HashMismatched, nil
+	}
+	pieceData, err := hex.DecodeString(fixture.PieceData)
+	if err != nil {
+		return model.HashMismatched, nil
+	}
+	spChecksums := make([][]byte, 0, len(fixture.PieceHashes))
+	for _, h := range fixture.PieceHashes {
+		checksum, err := hex.DecodeString(h)
+		if err != nil {
+			return model.HashMismatched, nil
+		}
+		spChecksums = append(spChecksums, checksum)
+	}
+	if int(event.SegmentIndex) >= len(spChecksums) {
+		return model.HashMismatched, nil
+	}
+	if !bytes.Equal(verifier.ComputeRootHash(event.SegmentIndex, pieceData, spChecksums), chainRootHash) {
+		return model.HashMismatched, nil
+	}
+	return model.HashMatched, nil
+}
+
+// parseAttestations adds the results of the challenges attested in the block to attested.
+func parseAttestations(blockResults *ctypes.ResultBlockResults, attested map[uint64]string) error {
+	events := append([]abci.Event(nil), blockResults.EndBlockEvents...)
+	for _, tx := range blockResults.TxsResults {
+		events = append(events, tx.Events...)
+	}
+	for _, event := range events {
+		if event.Type != EventAttestChallenge {
+			continue
+		}
+		attrs := make(map[string]string, len(event.Attributes))
+		for _, attr := range event.Attributes {
+			attrs[attr.Key] = strings.Trim(attr.Value, `"`)
+		}
+		challengeId, err := strconv.ParseUint(attrs["challenge_id"], 10, 64)
+		if err != nil {
+			return err
+		}
+		attested[challengeId] = attrs["result"]
+	}
+	return nil
+}
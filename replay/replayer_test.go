@@ -0,0 +1,181 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/bnb-chain/greenfield-common/go/hash"
+	"github.com/bnb-chain/greenfield-go-sdk/types"
+	challengetypes "github.com/bnb-chain/greenfield/x/challenge/types"
+	abci "github.com/cometbft/cometbft/abci/types"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+)
+
+var segments = [][]byte{[]byte("segment0"), []byte("segment1")}
+
+func pieceHashes() [][]byte {
+	hashes := make([][]byte, 0, len(segments))
+	for _, segment := range segments {
+		hashes = append(hashes, hash.GenerateChecksum(segment))
+	}
+	return hashes
+}
+
+type mockExecutor struct {
+	blocks  map[int64]*ctypes.ResultBlockResults
+	latest  uint64
+	corrupt map[string]bool // objects served corrupted
+	sps     int             // sp requests
+}
+
+func (e *mockExecutor) GetLatestBlockHeight() (uint64, error) {
+	return e.latest, nil
+}
+
+func (e *mockExecutor) GetBlockAndBlockResultAtHeight(height int64) (*tmtypes.Block, *ctypes.ResultBlockResults, error) {
+	if results, ok := e.blocks[height]; ok {
+		return nil, results, nil
+	}
+	return nil, &ctypes.ResultBlockResults{Height: height}, nil
+}
+
+func (e *mockExecutor) QueryChallengeHeartbeatInterval() (uint64, error) {
+	return 100, nil
+}
+
+func (e *mockExecutor) GetObjectInfoChecksums(objectId string) ([][]byte, error) {
+	if objectId == "404" {
+		return nil, errors.New("No such object")
+	}
+	return [][]byte{hash.GenerateChecksum(bytes.Join(pieceHashes(), nil))}, nil
+}
+
+func (e *mockExecutor) GetStorageProviderEndpoint(_ string) (string, error) {
+	return "https://sp", nil
+}
+
+func (e *mockExecutor) GetChallengeResultFromSp(_ context.Context, objectId, _ string, segmentIndex, _ int) (*types.ChallengeResult, error) {
+	e.sps++
+	piece := segments[segmentIndex]
+	if e.corrupt[objectId] {
+		piece = []byte("corrupted")
+	}
+	hashes := make([]string, 0, len(segments))
+	for _, h := range pieceHashes() {
+		hashes = append(hashes, hex.EncodeToString(h))
+	}
+	return &types.ChallengeResult{PieceData: io.NopCloser(bytes.NewReader(piece)), PiecesHash: hashes}, nil
+}
+
+func startEvent(challengeId uint64, objectId string, expiredHeight uint64) abci.Event {
+	attrs := map[string]string{
+		"challenge_id":        strconv.FormatUint(challengeId, 10),
+		"object_id":           objectId,
+		"segment_index":       "1",
+		"sp_operator_address": "0x1111111111111111111111111111111111111111",
+		"redundancy_index":    "-1",
+		"expired_height":      strconv.FormatUint(expiredHeight, 10),
+	}
+	event := abci.Event{Type: "greenfield.challenge.EventStartChallenge"}
+	for key, value := range attrs {
+		event.Attributes = append(event.Attributes, abci.EventAttribute{Key: key, Value: strconv.Quote(value)})
+	}
+	return event
+}
+
+func attestEvent(challengeId uint64, result challengetypes.VoteResult) abci.Event {
+	return abci.Event{Type: EventAttestChallenge, Attributes: []abci.EventAttribute{
+		{Key: "challenge_id", Value: strconv.Quote(strconv.FormatUint(challengeId, 10))},
+		{Key: "result", Value: strconv.Quote(result.String())},
+	}}
+}
+
+func newMockExecutor() *mockExecutor {
+	return &mockExecutor{
+		latest:  20,
+		corrupt: map[string]bool{"2": true, "3": true},
+		blocks: map[int64]*ctypes.ResultBlockResults{
+			// 1 is served, 2 and 3 are corrupted, 100 is a heartbeat and 101 a deleted object
+			10: {EndBlockEvents: []abci.Event{startEvent(1, "1", 15), startEvent(2, "2", 15), startEvent(3, "3", 15),
+				startEvent(100, "1", 15), startEvent(101, "404", 15)}},
+			// attested after the replayed range, but before the challenges expire
+			12: {TxsResults: []*abci.ResponseDeliverTx{{Events: []abci.Event{
+				attestEvent(2, challengetypes.CHALLENGE_SUCCEED), attestEvent(100, challengetypes.CHALLENGE_FAILED),
+				// the challenge of a healthy sp was attested as succeeded by the other validators
+				attestEvent(1, challengetypes.CHALLENGE_SUCCEED),
+			}}}},
+			// attested after the challenges expire, it is not looked up
+			16: {EndBlockEvents: []abci.Event{attestEvent(3, challengetypes.CHALLENGE_SUCCEED)}},
+		},
+	}
+}
+
+func TestReplayer_Replay(t *testing.T) {
+	executor := newMockExecutor()
+	report, err := NewReplayer(executor).Replay(context.Background(), 10, 10)
+	require.NoError(t, err)
+	require.Len(t, report.Results, 5)
+
+	byId := make(map[uint64]*Result)
+	for _, result := range report.Results {
+		byId[result.ChallengeId] = result
+	}
+	require.Equal(t, model.HashMatched, byId[1].VerifyResult)
+	require.Equal(t, NoVote, byId[1].Vote)
+	require.Equal(t, challengetypes.CHALLENGE_SUCCEED.String(), byId[1].Attested)
+	require.True(t, byId[1].Diverged())
+
+	require.Equal(t, challengetypes.CHALLENGE_SUCCEED.String(), byId[2].Vote)
+	require.False(t, byId[2].Diverged())
+	// the challenger votes, but the challenge was not attested
+	require.Equal(t, NoVote, byId[3].Attested)
+	require.True(t, byId[3].Diverged())
+	require.Equal(t, challengetypes.CHALLENGE_FAILED.String(), byId[100].Vote)
+	require.False(t, byId[100].Diverged())
+	require.NotEmpty(t, byId[101].Error)
+	require.False(t, byId[101].Diverged())
+	require.Equal(t, 2, report.Diverged())
+
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out))
+	require.Contains(t, out.String(), "replayed 5 challenges between heights 10 and 10: 2 diverged, 1 unverified")
+}
+
+func TestReplayer_Fixtures(t *testing.T) {
+	executor := newMockExecutor()
+	var recorded bytes.Buffer
+	replayer := NewReplayer(executor)
+	replayer.SetRecorder(&recorded)
+	report, err := replayer.Replay(context.Background(), 10, 10)
+	require.NoError(t, err)
+	require.Equal(t, 4, executor.sps)
+
+	// the fixtures give the same votes without asking the sps
+	fixtures, err := LoadFixtures(&recorded)
+	require.NoError(t, err)
+	require.Len(t, fixtures, 4)
+	executor.corrupt = nil
+	replayer = NewReplayer(executor)
+	replayer.SetFixtures(fixtures)
+	replayed, err := replayer.Replay(context.Background(), 10, 10)
+	require.NoError(t, err)
+	require.Equal(t, 4, executor.sps)
+	for i, result := range replayed.Results {
+		require.Equal(t, report.Results[i].Vote, result.Vote)
+	}
+	require.Equal(t, errNoFixture.Error(), replayed.Results[4].Error)
+}
+
+func TestReplayer_InvalidRange(t *testing.T) {
+	_, err := NewReplayer(newMockExecutor()).Replay(context.Background(), 30, 25)
+	require.EqualError(t, err, "from height 30 is above to height 20")
+}
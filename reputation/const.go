@@ -0,0 +1,15 @@
+package reputation
+
+const (
+	// weights of the signals in the score, they add up to 1
+	MismatchWeight = 0.35
+	SlashWeight    = 0.35
+	ExpiryWeight   = 0.1
+	LatencyWeight  = 0.2
+
+	// PriorChallenges damps the rates of the sps with few challenges, as if they had this many more clean challenges
+	PriorChallenges = 10
+
+	// MaxScore is the score of the sps without any stats
+	MaxScore = 1.0
+)
@@ -0,0 +1,142 @@
+package reputation
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+// StatsProvider lists the challenge stats of the storage providers.
+type StatsProvider interface {
+	ListSpStats(ctx context.Context) ([]*model.SpStats, error)
+}
+
+// Score rates a storage provider from 0, the least reliable, to MaxScore, along with the signals it is computed from.
+type Score struct {
+	SpOperatorAddress string  `json:"sp_operator_address"`
+	Score             float64 `json:"score"`
+	Challenges        uint64  `json:"challenges"`
+	MismatchRate      float64 `json:"mismatch_rate"`
+	SlashRate         float64 `json:"slash_rate"`
+	ExpiryRate        float64 `json:"expiry_rate"`
+	AvgLatencyMs      uint64  `json:"avg_latency_ms"`
+}
+
+// Scorer scores the storage providers from the challenge stats observed by the challenger: the mismatched
+// verifications, the slashes, the challenges which expired and the response latency.
+type Scorer struct {
+	cfg           *config.ReputationConfig
+	stats         StatsProvider
+	metricService *metrics.MetricService
+	mtx           sync.RWMutex
+	scores        map[string]*Score // by lower case sp operator address
+}
+
+func NewScorer(cfg *config.ReputationConfig, stats StatsProvider, metricService *metrics.MetricService) *Scorer {
+	return &Scorer{
+		cfg:           cfg,
+		stats:         stats,
+		metricService: metricService,
+		scores:        make(map[string]*Score),
+	}
+}
+
+// ScoreLoop scores the storage providers every interval until ctx is done.
+func (s *Scorer) ScoreLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval())
+	defer ticker.Stop()
+	for {
+		if err := s.Refresh(ctx); err != nil {
+			logging.Logger.Errorf("failed to score the storage providers, err=%+v", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh scores the storage providers from their latest stats.
+func (s *Scorer) Refresh(ctx context.Context) error {
+	stats, err := s.stats.ListSpStats(ctx)
+	if err != nil {
+		return err
+	}
+	scores := make(map[string]*Score, len(stats))
+	for _, st := range stats {
+		score := s.score(st)
+		scores[strings.ToLower(st.SpOperatorAddress)] = score
+		s.metricService.SetSpReputationScore(st.SpOperatorAddress, score.Score)
+	}
+	s.mtx.Lock()
+	s.scores = scores
+	s.mtx.Unlock()
+	return nil
+}
+
+func (s *Scorer) score(st *model.SpStats) *Score {
+	// the rates are damped by the prior, a sp is not scored down for its first failed challenge
+	rate := func(count, total uint64) float64 {
+		return float64(count) / float64(total+PriorChallenges)
+	}
+	score := &Score{
+		SpOperatorAddress: st.SpOperatorAddress,
+		Challenges:        st.Received,
+		MismatchRate:      rate(st.Succeeded, st.Succeeded+st.Failed),
+		SlashRate:         rate(st.Slashed, st.Received),
+		ExpiryRate:        rate(st.Expired, st.Received),
+		AvgLatencyMs:      st.AvgResponseLatencyMs(),
+	}
+	latency := math.Min(1, float64(score.AvgLatencyMs)/float64(s.cfg.SlowLatency().Milliseconds()))
+	penalty := MismatchWeight*score.MismatchRate + SlashWeight*score.SlashRate + ExpiryWeight*score.ExpiryRate +
+		LatencyWeight*latency
+	score.Score = math.Max(0, MaxScore-penalty)
+	return score
+}
+
+// Score returns the score of the storage provider, MaxScore if it has no stats yet.
+func (s *Scorer) Score(spOperatorAddress string) float64 {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	score, ok := s.scores[strings.ToLower(spOperatorAddress)]
+	if !ok {
+		return MaxScore
+	}
+	return score.Score
+}
+
+// Scores returns the scores of all storage providers, the least reliable first.
+func (s *Scorer) Scores() []*Score {
+	s.mtx.RLock()
+	scores := make([]*Score, 0, len(s.scores))
+	for _, score := range s.scores {
+		scores = append(scores, score)
+	}
+	s.mtx.RUnlock()
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score < scores[j].Score
+		}
+		return scores[i].SpOperatorAddress < scores[j].SpOperatorAddress
+	})
+	return scores
+}
+
+// RetryAttempts scales the retry attempts of the requests to the storage provider with its score, from the min retry
+// attempts for the worst scored sps to attempts for the best scored.
+func (s *Scorer) RetryAttempts(spOperatorAddress string, attempts uint) uint {
+	floor := s.cfg.RetryAttemptsFloor()
+	if attempts <= floor {
+		return attempts
+	}
+	return floor + uint(math.Round(float64(attempts-floor)*s.Score(spOperatorAddress)/MaxScore))
+}
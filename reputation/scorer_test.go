@@ -0,0 +1,51 @@
+package reputation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+type mockStatsProvider struct {
+	stats []*model.SpStats
+}
+
+func (p *mockStatsProvider) ListSpStats(_ context.Context) ([]*model.SpStats, error) {
+	return p.stats, nil
+}
+
+func TestScorer_Refresh(t *testing.T) {
+	stats := &mockStatsProvider{stats: []*model.SpStats{
+		{SpOperatorAddress: "0xAAAA", Received: 100, Failed: 100, TotalLatencyMs: 100 * 500, LatencySamples: 100},
+		{SpOperatorAddress: "0xBBBB", Received: 100, Succeeded: 40, Failed: 50, Slashed: 30, Expired: 10,
+			TotalLatencyMs: 90 * 5000, LatencySamples: 90},
+		{SpOperatorAddress: "0xCCCC", Received: 1, Succeeded: 1, Slashed: 1},
+	}}
+	scorer := NewScorer(&config.ReputationConfig{}, stats, metrics.NewMetricService(&config.Config{}))
+	require.Equal(t, MaxScore, scorer.Score("0xAAAA"))
+	require.NoError(t, scorer.Refresh(context.Background()))
+
+	// the reliable sp loses only its latency
+	require.InDelta(t, 1-LatencyWeight*0.1, scorer.Score("0xaaaa"), 1e-9)
+	// the unreliable sp is scored down by every signal
+	require.InDelta(t, 1-MismatchWeight*40/100-SlashWeight*30/110-ExpiryWeight*10/110-LatencyWeight,
+		scorer.Score("0xBBBB"), 1e-9)
+	// a single failed challenge is damped by the prior
+	require.Greater(t, scorer.Score("0xCCCC"), 0.9)
+	require.Equal(t, MaxScore, scorer.Score("0xDDDD"))
+
+	scores := scorer.Scores()
+	require.Len(t, scores, 3)
+	require.Equal(t, "0xBBBB", scores[0].SpOperatorAddress)
+
+	// the retry budget follows the score, down to the min retry attempts
+	require.Equal(t, uint(5), scorer.RetryAttempts("0xDDDD", 5))
+	require.Less(t, scorer.RetryAttempts("0xBBBB", 5), uint(5))
+	require.GreaterOrEqual(t, scorer.RetryAttempts("0xBBBB", 5), uint(1))
+	require.Equal(t, uint(1), scorer.RetryAttempts("0xBBBB", 1))
+}
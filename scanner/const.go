@@ -0,0 +1,13 @@
+package scanner
+
+import "time"
+
+const (
+	SuspectsPath = "/scanner/suspects"
+
+	RequestTimeout = 10 * time.Second
+	// MaxRequestBytes bounds the body of a submission
+	MaxRequestBytes = 1 << 20
+	// RetryAfterSeconds is suggested to the scanner when the queue is full
+	RetryAfterSeconds = 60
+)
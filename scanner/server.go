@@ -0,0 +1,163 @@
+package scanner
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/proactive"
+)
+
+var (
+	objectIdRegexp = regexp.MustCompile(`^[0-9]{1,78}$`)
+	addressRegexp  = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+)
+
+// Intake queues the suspects to be verified.
+type Intake interface {
+	Submit(suspects []*proactive.Suspect) error
+	Queued() int
+}
+
+type suspect struct {
+	ObjectId          string  `json:"object_id"`
+	SpOperatorAddress string  `json:"sp_operator_address"`
+	SegmentIndex      *uint32 `json:"segment_index"`
+	Reason            string  `json:"reason"`
+}
+
+type suspectsRequest struct {
+	Suspects []suspect `json:"suspects"`
+}
+
+type suspectsResponse struct {
+	Accepted int `json:"accepted"`
+	Queued   int `json:"queued"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server serves the api of the external scanner: POST /scanner/suspects with
+// {"suspects": [{"object_id": "...", "sp_operator_address": "...", "segment_index": 0, "reason": "..."}]}
+// queues the suspects, the sp and the segment being optional. The suspects of a request are accepted all or none, 429
+// is returned when the queue is full.
+type Server struct {
+	cfg    *config.ScannerConfig
+	intake Intake
+	mux    *http.ServeMux
+}
+
+func NewServer(cfg *config.ScannerConfig, intake Intake) *Server {
+	s := &Server{
+		cfg:    cfg,
+		intake: intake,
+		mux:    http.NewServeMux(),
+	}
+	s.mux.Handle(SuspectsPath, s.authorize(http.HandlerFunc(s.serveSuspects)))
+	return s
+}
+
+// Start serves the api, it blocks until ctx is done.
+func (s *Server) Start(ctx context.Context) {
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", s.cfg.Port),
+		Handler:           s.mux,
+		ReadHeaderTimeout: RequestTimeout,
+		ReadTimeout:       RequestTimeout,
+		WriteTimeout:      RequestTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		panic(err)
+	}
+}
+
+// authorize requires the bearer token.
+func (s *Server) authorize(handler http.Handler) http.Handler {
+	expected := []byte("Bearer " + s.cfg.AuthToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// serveSuspects serves POST /scanner/suspects.
+func (s *Server) serveSuspects(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	var req suspectsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxRequestBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body, %v", err))
+		return
+	}
+	suspects, err := s.parse(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err = s.intake.Submit(suspects); err != nil {
+		if errors.Is(err, proactive.ErrIntakeFull) {
+			w.Header().Set("Retry-After", strconv.Itoa(RetryAfterSeconds))
+			writeError(w, http.StatusTooManyRequests, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	logging.Logger.Infof("queued %d objects suspected by the scanner", len(suspects))
+	writeJson(w, http.StatusAccepted, suspectsResponse{Accepted: len(suspects), Queued: s.intake.Queued()})
+}
+
+func (s *Server) parse(req suspectsRequest) ([]*proactive.Suspect, error) {
+	if len(req.Suspects) == 0 {
+		return nil, errors.New("no suspects")
+	}
+	if len(req.Suspects) > s.cfg.MaxSuspects() {
+		return nil, fmt.Errorf("at most %d suspects per request", s.cfg.MaxSuspects())
+	}
+	suspects := make([]*proactive.Suspect, 0, len(req.Suspects))
+	for i, sus := range req.Suspects {
+		if !objectIdRegexp.MatchString(sus.ObjectId) {
+			return nil, fmt.Errorf("suspect %d: invalid object_id %q", i, sus.ObjectId)
+		}
+		if sus.SpOperatorAddress != "" && !addressRegexp.MatchString(sus.SpOperatorAddress) {
+			return nil, fmt.Errorf("suspect %d: invalid sp_operator_address %q", i, sus.SpOperatorAddress)
+		}
+		suspects = append(suspects, &proactive.Suspect{
+			ObjectId:          sus.ObjectId,
+			SpOperatorAddress: sus.SpOperatorAddress,
+			SegmentIndex:      sus.SegmentIndex,
+			Reason:            sus.Reason,
+		})
+	}
+	return suspects, nil
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logging.Logger.Errorf("failed to write the scanner response, err=%+v", err.Error())
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJson(w, status, errorResponse{Error: err.Error()})
+}
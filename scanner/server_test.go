@@ -0,0 +1,55 @@
+package scanner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+	"github.com/bnb-chain/greenfield-challenger/proactive"
+)
+
+func serve(s *Server, method, token, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(method, SuspectsPath, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	s.mux.ServeHTTP(w, r)
+	return w
+}
+
+func TestServer_Suspects(t *testing.T) {
+	cfg := &config.ScannerConfig{Port: 8095, AuthToken: "token", QueueSize: 3, MaxSuspectsPerRequest: 2}
+	intake := proactive.NewIntake(cfg.Queue(), nil, nil, nil, nil, metrics.NewMetricService(&config.Config{}))
+	s := NewServer(cfg, intake)
+
+	w := serve(s, http.MethodPost, "token", `{"suspects":[{"object_id":"1","segment_index":2,"reason":"timeout"},
+		{"object_id":"2","sp_operator_address":"0x2222222222222222222222222222222222222222"}]}`)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.JSONEq(t, `{"accepted":2,"queued":2}`, w.Body.String())
+	require.Equal(t, 2, intake.Queued())
+
+	// the suspects are accepted all or none
+	w = serve(s, http.MethodPost, "token", `{"suspects":[{"object_id":"3"},{"object_id":"4"}]}`)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.NotEmpty(t, w.Header().Get("Retry-After"))
+	require.Equal(t, 2, intake.Queued())
+
+	for _, body := range []string{
+		`{"suspects":[]}`,
+		`{"suspects":[{"object_id":"1"},{"object_id":"2"},{"object_id":"3"}]}`,
+		`{"suspects":[{"object_id":"bucket/object"}]}`,
+		`{"suspects":[{"object_id":"1","sp_operator_address":"sp"}]}`,
+		`not json`,
+	} {
+		require.Equal(t, http.StatusBadRequest, serve(s, http.MethodPost, "token", body).Code, body)
+	}
+	require.Equal(t, http.StatusUnauthorized, serve(s, http.MethodPost, "", `{}`).Code)
+	require.Equal(t, http.StatusUnauthorized, serve(s, http.MethodPost, "other", `{}`).Code)
+	require.Equal(t, http.StatusMethodNotAllowed, serve(s, http.MethodGet, "token", "").Code)
+}
@@ -0,0 +1,26 @@
+package sentry
+
+import "time"
+
+const (
+	ClientName = "greenfield-challenger"
+	Platform   = "go"
+
+	LevelError = "error"
+	LevelFatal = "fatal"
+
+	// ComponentMain is the component of the panics outside of the supervised loops
+	ComponentMain = "main"
+
+	TagComponent   = "component"
+	TagChallengeId = "challenge_id"
+	TagStage       = "stage"
+	TagSp          = "sp"
+
+	SendTimeout = 10 * time.Second
+	// MaxPendingEvents bounds the events being sent, further events are dropped until they are sent
+	MaxPendingEvents = 100
+	// MaxEventsPerMinute bounds the events sent by a challenger whose errors repeat, e.g. while the rpc is down
+	MaxEventsPerMinute = 60
+	FlushTimeout       = 5 * time.Second
+)
@@ -0,0 +1,230 @@
+package sentry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/op/go-logging"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	challengerlogging "github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/version"
+)
+
+var httpClient = &http.Client{Timeout: SendTimeout}
+
+// event is the payload of the sentry store api.
+type event struct {
+	EventId     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Platform    string            `json:"platform"`
+	Level       string            `json:"level"`
+	Logger      string            `json:"logger,omitempty"`
+	Message     string            `json:"message"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]string `json:"extra,omitempty"`
+}
+
+// Client sends the events to the store api of a sentry project.
+type Client struct {
+	storeUrl    string
+	auth        string
+	environment string
+	serverName  string
+	pending     sync.WaitGroup
+	slots       chan struct{}
+
+	mtx         sync.Mutex
+	windowStart time.Time
+	windowCount int
+}
+
+// NewClient creates a client of the project of dsn, e.g. https://key@sentry.example.com/42.
+func NewClient(cfg *config.SentryConfig) (*Client, error) {
+	dsn, err := url.Parse(cfg.Dsn)
+	if err != nil {
+		return nil, err
+	}
+	path := strings.Trim(dsn.Path, "/")
+	idx := strings.LastIndex(path, "/")
+	prefix, projectId := "", path
+	if idx >= 0 {
+		prefix, projectId = "/"+path[:idx], path[idx+1:]
+	}
+	serverName, _ := os.Hostname()
+	return &Client{
+		storeUrl: fmt.Sprintf("%s://%s%s/api/%s/store/", dsn.Scheme, dsn.Host, prefix, projectId),
+		auth: fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s/%s, sentry_key=%s", ClientName,
+			version.AppVersion, dsn.User.Username()),
+		environment: cfg.Environment,
+		serverName:  serverName,
+		slots:       make(chan struct{}, MaxPendingEvents),
+	}, nil
+}
+
+// allow counts the event against the per minute limit.
+func (c *Client) allow() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	now := time.Now()
+	if now.Sub(c.windowStart) > time.Minute {
+		c.windowStart, c.windowCount = now, 0
+	}
+	c.windowCount++
+	return c.windowCount <= MaxEventsPerMinute
+}
+
+// capture sends the event in the background, it is dropped if too many events are being sent.
+func (c *Client) capture(e *event) {
+	if !c.allow() {
+		return
+	}
+	select {
+	case c.slots <- struct{}{}:
+	default:
+		return
+	}
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	e.EventId = hex.EncodeToString(id)
+	e.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	e.Platform = Platform
+	e.ServerName = c.serverName
+	e.Environment = c.environment
+	e.Release = version.AppVersion
+	c.pending.Add(1)
+	go func() {
+		defer func() {
+			<-c.slots
+			c.pending.Done()
+		}()
+		if err := c.send(e); err != nil {
+			// logged below the error level, so that the failure is not reported again
+			challengerlogging.Logger.Warningf("failed to report to sentry, err=%+v", err.Error())
+		}
+	}()
+}
+
+func (c *Client) send(e *event) error {
+	bz, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.storeUrl, bytes.NewReader(bz))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", c.auth)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("sentry responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Flush waits until the pending events are sent, or until timeout passes.
+func (c *Client) Flush(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		c.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// Report sends an error record of the logger, tagged with its module and challenge.
+func (c *Client) Report(module string, level logging.Level, msg string, fields challengerlogging.Fields) {
+	e := &event{
+		Level:   LevelError,
+		Logger:  module,
+		Message: msg,
+		Tags:    map[string]string{TagComponent: module},
+	}
+	if level == logging.CRITICAL {
+		e.Level = LevelFatal
+	}
+	if fields.ChallengeId != 0 {
+		e.Tags[TagChallengeId] = strconv.FormatUint(fields.ChallengeId, 10)
+	}
+	if fields.Stage != "" {
+		e.Tags[TagStage] = fields.Stage
+	}
+	if fields.Sp != "" {
+		e.Tags[TagSp] = fields.Sp
+	}
+	c.capture(e)
+}
+
+// CapturePanic sends a panic recovered in component with its stack.
+func (c *Client) CapturePanic(component string, recovered interface{}, stack []byte) {
+	c.capture(&event{
+		Level:   LevelFatal,
+		Logger:  component,
+		Message: fmt.Sprintf("panic: %v", recovered),
+		Tags:    map[string]string{TagComponent: component},
+		Extra:   map[string]string{"stack": string(stack)},
+	})
+}
+
+// client reports the errors of the challenger, nothing is reported while it is nil.
+var client atomic.Pointer[Client]
+
+// Init reports the panics and the error records of the loggers to the sentry project of cfg, if it is enabled.
+func Init(cfg *config.SentryConfig) error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	c, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	client.Store(c)
+	challengerlogging.SetReporter(c)
+	return nil
+}
+
+// CapturePanic reports a panic recovered in component.
+func CapturePanic(component string, recovered interface{}, stack []byte) {
+	if c := client.Load(); c != nil {
+		c.CapturePanic(component, recovered, stack)
+	}
+}
+
+// Recover reports a panic of the calling goroutine and panics again, it must be deferred.
+func Recover(component string) {
+	if r := recover(); r != nil {
+		CapturePanic(component, r, debug.Stack())
+		Flush()
+		panic(r)
+	}
+}
+
+// Flush waits until the pending events are sent, so that they are not lost on exit.
+func Flush() {
+	if c := client.Load(); c != nil {
+		c.Flush(FlushTimeout)
+	}
+}
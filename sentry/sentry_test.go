@@ -0,0 +1,52 @@
+package sentry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/op/go-logging"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	challengerlogging "github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+func TestClient_Report(t *testing.T) {
+	var mtx sync.Mutex
+	events := make([]event, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/prefix/api/42/store/", r.URL.Path)
+		require.True(t, strings.HasSuffix(r.Header.Get("X-Sentry-Auth"), "sentry_key=public"))
+		e := event{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+		mtx.Lock()
+		events = append(events, e)
+		mtx.Unlock()
+	}))
+	defer server.Close()
+
+	dsn := strings.Replace(server.URL, "http://", "http://public@", 1) + "/prefix/42"
+	cfg := &config.SentryConfig{Dsn: dsn, Environment: "testnet"}
+	require.NotPanics(t, cfg.Validate)
+	c, err := NewClient(cfg)
+	require.NoError(t, err)
+
+	c.Report("verifier", logging.ERROR, "verifier failed to verify", challengerlogging.Fields{ChallengeId: 7, Stage: "verify"})
+	c.CapturePanic("vote_collector", "boom", []byte("goroutine 1"))
+	c.Flush(FlushTimeout)
+
+	require.Len(t, events, 2)
+	byLevel := map[string]event{events[0].Level: events[0], events[1].Level: events[1]}
+	reported := byLevel[LevelError]
+	require.Equal(t, "verifier failed to verify", reported.Message)
+	require.Equal(t, "testnet", reported.Environment)
+	require.Equal(t, map[string]string{TagComponent: "verifier", TagChallengeId: "7", TagStage: "verify"}, reported.Tags)
+	panicked := byLevel[LevelFatal]
+	require.Equal(t, "panic: boom", panicked.Message)
+	require.Equal(t, "vote_collector", panicked.Tags[TagComponent])
+	require.Equal(t, "goroutine 1", panicked.Extra["stack"])
+}
@@ -0,0 +1,151 @@
+package sla
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/alert"
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+// EventProvider lists the events settled within the window of the report.
+type EventProvider interface {
+	ListSettledEvents(ctx context.Context, since int64) ([]*model.Event, error)
+}
+
+// Report is the attestation sla over the rolling window. The challenges verified as mismatched are eligible once they
+// are attested or expired, the ones still in the pipeline are not counted yet.
+type Report struct {
+	Window             time.Duration
+	Eligible           int
+	Attested           int
+	MedianTimeToAttest time.Duration
+	// FeesSpent and RewardsEarned are in wei, they only cover the time since the challenger started
+	FeesSpent     float64
+	RewardsEarned float64
+}
+
+// SuccessRatio returns the share of the eligible challenges attested before their expiry, 1 without any.
+func (r *Report) SuccessRatio() float64 {
+	if r.Eligible == 0 {
+		return 1
+	}
+	return float64(r.Attested) / float64(r.Eligible)
+}
+
+func (r *Report) String() string {
+	return fmt.Sprintf("sla of the last %s: %d of %d eligible challenges attested before expiry (%.2f%%), median "+
+		"time to attest %s, fees spent %.0f wei, rewards earned %.0f wei", r.Window, r.Attested, r.Eligible,
+		r.SuccessRatio()*100, r.MedianTimeToAttest, r.FeesSpent, r.RewardsEarned)
+}
+
+// Reporter computes the sla report periodically, exposes it as metrics and logs it.
+type Reporter struct {
+	cfg           *config.SlaConfig
+	events        EventProvider
+	metricService *metrics.MetricService
+	ledger        ledger
+}
+
+func NewReporter(cfg *config.SlaConfig, events EventProvider, metricService *metrics.MetricService) *Reporter {
+	return &Reporter{
+		cfg:           cfg,
+		events:        events,
+		metricService: metricService,
+	}
+}
+
+// ReportLoop reports the sla every report interval until ctx is done.
+func (r *Reporter) ReportLoop(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.ReportInterval())
+	defer ticker.Stop()
+	// the fees and rewards of the window are counted from the start
+	r.ledger.add(time.Now(), 0, 0, r.cfg.Window())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		report, err := r.Report(ctx, time.Now())
+		if err != nil {
+			logging.Logger.Errorf("failed to compute the sla report, err=%+v", err.Error())
+			continue
+		}
+		r.metricService.SetSlaReport(report.Eligible, report.Attested, report.MedianTimeToAttest, report.FeesSpent,
+			report.RewardsEarned)
+		logging.Logger.Infof("%s", report)
+		if r.cfg.Notify {
+			alert.Raise(alert.Alert{Name: alert.AlertSlaReport, Severity: alert.SeverityInfo, Message: report.String()})
+		}
+	}
+}
+
+// Report computes the sla of the window ending at now.
+func (r *Reporter) Report(ctx context.Context, now time.Time) (*Report, error) {
+	window := r.cfg.Window()
+	events, err := r.events.ListSettledEvents(ctx, now.Add(-window).Unix())
+	if err != nil {
+		return nil, err
+	}
+	report := summarize(events, window)
+	fees, rewards := r.metricService.AttestFeesAndRewards()
+	report.FeesSpent, report.RewardsEarned = r.ledger.add(now, fees, rewards, window)
+	return report, nil
+}
+
+// summarize counts the settled events, the time to attest runs from the ingestion to the attestation.
+func summarize(events []*model.Event, window time.Duration) *Report {
+	report := &Report{Window: window, Eligible: len(events)}
+	timesToAttest := make([]time.Duration, 0, len(events))
+	for _, event := range events {
+		if event.Status != model.SelfAttested && event.Status != model.Attested {
+			continue
+		}
+		report.Attested++
+		if event.StageTime != 0 && event.CreatedTime != 0 {
+			timesToAttest = append(timesToAttest, time.UnixMilli(event.StageTime).Sub(time.Unix(event.CreatedTime, 0)))
+		}
+	}
+	report.MedianTimeToAttest = median(timesToAttest)
+	return report
+}
+
+func median(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	middle := len(durations) / 2
+	if len(durations)%2 == 0 {
+		return (durations[middle-1] + durations[middle]) / 2
+	}
+	return durations[middle]
+}
+
+// ledger samples the cumulative fees and rewards, their increase over the window is the difference to the latest
+// sample taken before the window started.
+type ledger struct {
+	samples []ledgerSample
+}
+
+type ledgerSample struct {
+	time    time.Time
+	fees    float64
+	rewards float64
+}
+
+// add records the cumulative fees and rewards at now and returns their increase over the window.
+func (l *ledger) add(now time.Time, fees, rewards float64, window time.Duration) (float64, float64) {
+	l.samples = append(l.samples, ledgerSample{time: now, fees: fees, rewards: rewards})
+	for len(l.samples) > 1 && now.Sub(l.samples[1].time) >= window {
+		l.samples = l.samples[1:]
+	}
+	oldest := l.samples[0]
+	return fees - oldest.fees, rewards - oldest.rewards
+}
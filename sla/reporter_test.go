@@ -0,0 +1,67 @@
+package sla
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+type mockEventProvider struct {
+	events []*model.Event
+	since  int64
+}
+
+func (p *mockEventProvider) ListSettledEvents(_ context.Context, since int64) ([]*model.Event, error) {
+	p.since = since
+	return p.events, nil
+}
+
+func TestReporter_Report(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	attested := func(created int64, timeToAttest time.Duration, status model.EventStatus) *model.Event {
+		return &model.Event{Status: status, CreatedTime: created,
+			StageTime: time.Unix(created, 0).Add(timeToAttest).UnixMilli()}
+	}
+	events := &mockEventProvider{events: []*model.Event{
+		attested(now.Unix()-600, time.Minute, model.SelfAttested),
+		attested(now.Unix()-500, 3*time.Minute, model.Attested),
+		attested(now.Unix()-400, 2*time.Minute, model.Attested),
+		{Status: model.Expired, CreatedTime: now.Unix() - 300},
+	}}
+	metricService := metrics.NewMetricService(&config.Config{})
+	reporter := NewReporter(&config.SlaConfig{WindowInHours: 1, ReportIntervalInMinutes: 10}, events, metricService)
+
+	reporter.ledger.add(now.Add(-2*time.Hour), 0, 0, time.Hour)
+	metricService.AddAttestFees(sdkmath.NewInt(100))
+	metricService.AddAttestRewards(sdkmath.NewInt(1000))
+	report, err := reporter.Report(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(-time.Hour).Unix(), events.since)
+	require.Equal(t, 4, report.Eligible)
+	require.Equal(t, 3, report.Attested)
+	require.Equal(t, 0.75, report.SuccessRatio())
+	require.Equal(t, 2*time.Minute, report.MedianTimeToAttest)
+	// the sample taken before the window is the baseline
+	require.Equal(t, float64(100), report.FeesSpent)
+	require.Equal(t, float64(1000), report.RewardsEarned)
+
+	// the fees and rewards older than the window are not counted
+	metricService.AddAttestFees(sdkmath.NewInt(50))
+	report, err = reporter.Report(context.Background(), now.Add(90*time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, float64(50), report.FeesSpent)
+	require.Equal(t, float64(0), report.RewardsEarned)
+}
+
+func TestMedian(t *testing.T) {
+	require.Equal(t, time.Duration(0), median(nil))
+	require.Equal(t, 2*time.Second, median([]time.Duration{3 * time.Second, time.Second, 2 * time.Second}))
+	require.Equal(t, 1500*time.Millisecond, median([]time.Duration{2 * time.Second, time.Second}))
+}
@@ -0,0 +1,16 @@
+package statuspage
+
+import "time"
+
+const (
+	PagePath = "/"
+	JsonPath = "/status.json"
+
+	// CacheTtl is how long a page is served before it is read from the database again, whatever the traffic
+	CacheTtl       = 10 * time.Second
+	RequestTimeout = 10 * time.Second
+	// RefreshTimeout bounds a read of the page from the database, whichever client it was read for
+	RefreshTimeout = 5 * time.Second
+	// MaxClients bounds the clients whose request rates are tracked, they are forgotten all at once beyond it
+	MaxClients = 10000
+)
@@ -0,0 +1,81 @@
+package statuspage
+
+import (
+	"context"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/version"
+)
+
+type DataProvider interface {
+	GetLatestBlock(ctx context.Context) (*model.Block, error)
+	ListRecentEvents(ctx context.Context, limit int) ([]*model.Event, error)
+	GetVerificationResultsByChallengeIds(ctx context.Context, challengeIds []uint64) (map[uint64]*model.VerificationResult, error)
+}
+
+// HeightProvider returns the latest height of the chain cached by the executor.
+type HeightProvider interface {
+	GetCachedBlockHeight() uint64
+}
+
+// Page is the sanitized view of the challenger served to the public: it holds what can be read from the chain, along
+// with the uptime, and nothing about the keys, the balance, the peers or the errors.
+type Page struct {
+	Version          string       `json:"version"`
+	ChainHeight      uint64       `json:"chain_height"`
+	SavedHeight      uint64       `json:"saved_height"`
+	UptimeSeconds    int64        `json:"uptime_seconds"`
+	RecentChallenges []*Challenge `json:"recent_challenges"`
+	UpdatedAt        time.Time    `json:"updated_at"`
+}
+
+// Challenge is a recent challenge and its outcome, the outcome is empty until the challenge is verified.
+type Challenge struct {
+	ChallengeId       uint64 `json:"challenge_id"`
+	Height            uint64 `json:"height"`
+	SpOperatorAddress string `json:"sp_operator_address"`
+	Status            string `json:"status"`
+	Outcome           string `json:"outcome,omitempty"`
+}
+
+// BuildPage reads the page from the database, the uptime is set when it is served.
+func BuildPage(ctx context.Context, dataProvider DataProvider, heights HeightProvider, recent int, now time.Time,
+) (*Page, error) {
+	block, err := dataProvider.GetLatestBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events, err := dataProvider.ListRecentEvents(ctx, recent)
+	if err != nil {
+		return nil, err
+	}
+	challengeIds := make([]uint64, 0, len(events))
+	for _, e := range events {
+		challengeIds = append(challengeIds, e.ChallengeId)
+	}
+	results, err := dataProvider.GetVerificationResultsByChallengeIds(ctx, challengeIds)
+	if err != nil {
+		return nil, err
+	}
+	page := &Page{
+		Version:          version.AppVersion,
+		ChainHeight:      heights.GetCachedBlockHeight(),
+		SavedHeight:      block.Height,
+		RecentChallenges: make([]*Challenge, 0, len(events)),
+		UpdatedAt:        now.UTC(),
+	}
+	for _, e := range events {
+		challenge := &Challenge{
+			ChallengeId:       e.ChallengeId,
+			Height:            e.Height,
+			SpOperatorAddress: e.SpOperatorAddress,
+			Status:            e.Status.String(),
+		}
+		if result, ok := results[e.ChallengeId]; ok {
+			challenge.Outcome = result.Outcome.String()
+		}
+		page.RecentChallenges = append(page.RecentChallenges, challenge)
+	}
+	return page, nil
+}
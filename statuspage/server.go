@@ -0,0 +1,219 @@
+package statuspage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+var pageTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Greenfield Challenger Status</title>
+<style>body{font-family:sans-serif;margin:2em}table{border-collapse:collapse}th,td{padding:2px 12px;text-align:left}</style>
+</head>
+<body>
+<h1>Greenfield Challenger</h1>
+<p>Version {{.Version}}, up for {{.Uptime}}.</p>
+<p>Chain height {{.ChainHeight}}, challenges processed up to height {{.SavedHeight}}.</p>
+<h2>Recent challenges</h2>
+<table>
+<tr><th>Challenge</th><th>Height</th><th>Storage provider</th><th>Status</th><th>Outcome</th></tr>
+{{range .RecentChallenges}}<tr><td>{{.ChallengeId}}</td><td>{{.Height}}</td><td>{{.SpOperatorAddress}}</td><td>{{.Status}}</td><td>{{.Outcome}}</td></tr>
+{{end}}</table>
+<p>Updated at {{.UpdatedAt.Format "2006-01-02 15:04:05"}} UTC, also served as <a href="status.json">json</a>.</p>
+</body>
+</html>
+`))
+
+// Server serves the status page to the public, read-only and rate limited by client. The page is cached, so that the
+// database is read at most once per CacheTtl.
+type Server struct {
+	cfg          *config.StatusPageConfig
+	dataProvider DataProvider
+	heights      HeightProvider
+	startedAt    time.Time
+	mux          *http.ServeMux
+
+	mtx     sync.Mutex // guards clients
+	clients map[string]*rate.Limiter
+
+	pageMtx  sync.Mutex // guards page, pageErr and pageTime, it is not held while the page is read
+	page     *Page
+	pageErr  error     // the error of the last read, as long as no page was read
+	pageTime time.Time // when the page was last read, successfully or not
+	refresh  singleflight.Group
+}
+
+func NewServer(cfg *config.StatusPageConfig, dataProvider DataProvider, heights HeightProvider) *Server {
+	s := &Server{
+		cfg:          cfg,
+		dataProvider: dataProvider,
+		heights:      heights,
+		startedAt:    time.Now(),
+		mux:          http.NewServeMux(),
+		clients:      make(map[string]*rate.Limiter),
+	}
+	s.mux.HandleFunc(PagePath, s.limited(s.servePage))
+	s.mux.HandleFunc(JsonPath, s.limited(s.serveJson))
+	return s
+}
+
+// Start serves the status page, it blocks until ctx is done.
+func (s *Server) Start(ctx context.Context) {
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", s.cfg.Port),
+		Handler:           s.mux,
+		ReadHeaderTimeout: RequestTimeout,
+		WriteTimeout:      RequestTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		panic(err)
+	}
+}
+
+// limited only serves GET requests within the rate of their client.
+func (s *Server) limited(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.allow(s.client(r)) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Minute/time.Second)/int(s.cfg.Rate())+1))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// client returns the address of the client, the last address of X-Forwarded-For is the one the proxy was connected
+// from, the ones before it are set by the client.
+func (s *Server) client(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); s.cfg.TrustForwardedFor && forwarded != "" {
+		addresses := strings.Split(forwarded, ",")
+		return strings.TrimSpace(addresses[len(addresses)-1])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (s *Server) allow(client string) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	limiter, ok := s.clients[client]
+	if !ok {
+		if len(s.clients) >= MaxClients {
+			s.clients = make(map[string]*rate.Limiter)
+		}
+		perMinute := int(s.cfg.Rate())
+		limiter = rate.NewLimiter(rate.Every(time.Minute/time.Duration(perMinute)), perMinute)
+		s.clients[client] = limiter
+	}
+	return limiter.Allow()
+}
+
+// currentPage returns the cached page, read again once it is older than CacheTtl. The stale page is served while it
+// is read again, and while the database cannot be read. The first page is waited for.
+func (s *Server) currentPage() (Page, error) {
+	now := time.Now()
+	s.pageMtx.Lock()
+	stale, first := now.Sub(s.pageTime) >= CacheTtl, s.page == nil
+	s.pageMtx.Unlock()
+	if stale {
+		read := s.refresh.DoChan("page", func() (interface{}, error) {
+			s.readPage()
+			return nil, nil
+		})
+		if first {
+			<-read
+		}
+	}
+
+	s.pageMtx.Lock()
+	defer s.pageMtx.Unlock()
+	if s.page == nil {
+		return Page{}, s.pageErr
+	}
+	page := *s.page
+	page.UptimeSeconds = int64(now.Sub(s.startedAt) / time.Second)
+	return page, nil
+}
+
+// readPage reads the page from the database within RefreshTimeout, on a context of its own so that a client going
+// away does not cancel it for the others. A failed read keeps the previous page and is not retried before CacheTtl
+// either, so that a database which is down is not read for every request.
+func (s *Server) readPage() {
+	ctx, cancel := context.WithTimeout(context.Background(), RefreshTimeout)
+	defer cancel()
+	now := time.Now()
+	page, err := BuildPage(ctx, s.dataProvider, s.heights, s.cfg.Recent(), now)
+	s.pageMtx.Lock()
+	defer s.pageMtx.Unlock()
+	s.pageTime = now
+	if err != nil {
+		logging.Logger.Errorf("status page failed to read the database, err=%+v", err.Error())
+		s.pageErr = err
+		return
+	}
+	s.page, s.pageErr = page, nil
+}
+
+// servePage serves GET / as html.
+func (s *Server) servePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != PagePath {
+		http.NotFound(w, r)
+		return
+	}
+	page, err := s.currentPage()
+	if err != nil {
+		http.Error(w, "status unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	view := struct {
+		Page
+		Uptime time.Duration
+	}{page, time.Duration(page.UptimeSeconds) * time.Second}
+	if err = pageTemplate.Execute(w, view); err != nil {
+		logging.Logger.Errorf("failed to write the status page, err=%+v", err.Error())
+	}
+}
+
+// serveJson serves GET /status.json, it may be fetched by the pages of other origins.
+func (s *Server) serveJson(w http.ResponseWriter, r *http.Request) {
+	page, err := s.currentPage()
+	if err != nil {
+		http.Error(w, "status unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if err = json.NewEncoder(w).Encode(&page); err != nil {
+		logging.Logger.Errorf("failed to write the status page, err=%+v", err.Error())
+	}
+}
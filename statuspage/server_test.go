@@ -0,0 +1,169 @@
+package statuspage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+)
+
+type fakeDataProvider struct {
+	reads int
+	err   error
+	// blocked, if set, is sent to when a read starts, and holds the read until it is sent to in turn
+	blocked chan struct{}
+}
+
+func (p *fakeDataProvider) GetLatestBlock(ctx context.Context) (*model.Block, error) {
+	if p.blocked != nil {
+		p.blocked <- struct{}{}
+		<-p.blocked
+	}
+	p.reads++
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return &model.Block{Height: 90}, p.err
+}
+
+func (p *fakeDataProvider) ListRecentEvents(_ context.Context, limit int) ([]*model.Event, error) {
+	events := []*model.Event{
+		{ChallengeId: 2, Height: 90, SpOperatorAddress: "sp1", ChallengerAddress: "0xchallenger", Status: model.Attested},
+		{ChallengeId: 1, Height: 80, SpOperatorAddress: "sp2", Status: model.Unprocessed},
+	}
+	return events[:limit], nil
+}
+
+func (p *fakeDataProvider) GetVerificationResultsByChallengeIds(context.Context, []uint64,
+) (map[uint64]*model.VerificationResult, error) {
+	return map[uint64]*model.VerificationResult{2: {ChallengeId: 2, ExpectedHash: "aa", ActualHash: "bb",
+		Outcome: model.OutcomeHashMismatched}}, nil
+}
+
+type fakeHeights struct{}
+
+func (fakeHeights) GetCachedBlockHeight() uint64 {
+	return 100
+}
+
+func serve(s *Server, method, target, remoteAddr string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(method, target, nil)
+	r.RemoteAddr = remoteAddr
+	s.mux.ServeHTTP(w, r)
+	return w
+}
+
+func TestServer_Page(t *testing.T) {
+	dataProvider := &fakeDataProvider{}
+	s := NewServer(&config.StatusPageConfig{Port: 8090, RecentChallenges: 2}, dataProvider, fakeHeights{})
+
+	w := serve(s, http.MethodGet, JsonPath, "10.0.0.1:1234")
+	require.Equal(t, http.StatusOK, w.Code)
+	page := &Page{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), page))
+	require.Equal(t, uint64(100), page.ChainHeight)
+	require.Equal(t, uint64(90), page.SavedHeight)
+	require.Len(t, page.RecentChallenges, 2)
+	require.Equal(t, &Challenge{ChallengeId: 2, Height: 90, SpOperatorAddress: "sp1", Status: "attested",
+		Outcome: "hash_mismatched"}, page.RecentChallenges[0])
+	// nothing but the public fields is served
+	require.NotContains(t, w.Body.String(), "0xchallenger")
+	require.NotContains(t, w.Body.String(), `"bb"`)
+
+	w = serve(s, http.MethodGet, PagePath, "10.0.0.1:1234")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "<td>hash_mismatched</td>")
+	// the page is cached
+	require.Equal(t, 1, dataProvider.reads)
+
+	require.Equal(t, http.StatusNotFound, serve(s, http.MethodGet, "/admin", "10.0.0.1:1234").Code)
+	require.Equal(t, http.StatusMethodNotAllowed, serve(s, http.MethodPost, JsonPath, "10.0.0.1:1234").Code)
+}
+
+func TestServer_Unavailable(t *testing.T) {
+	s := NewServer(&config.StatusPageConfig{Port: 8090}, &fakeDataProvider{err: errors.New("db down")}, fakeHeights{})
+	w := serve(s, http.MethodGet, JsonPath, "10.0.0.1:1234")
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.NotContains(t, w.Body.String(), "db down")
+
+	// the failed read is not retried for every request
+	require.Equal(t, http.StatusServiceUnavailable, serve(s, http.MethodGet, JsonPath, "10.0.0.1:1234").Code)
+	require.Equal(t, 1, s.dataProvider.(*fakeDataProvider).reads)
+}
+
+func TestServer_StalePage(t *testing.T) {
+	dataProvider := &fakeDataProvider{}
+	s := NewServer(&config.StatusPageConfig{Port: 8090, RecentChallenges: 1}, dataProvider, fakeHeights{})
+	require.Equal(t, http.StatusOK, serve(s, http.MethodGet, JsonPath, "10.0.0.1:1234").Code)
+
+	dataProvider.err = errors.New("db down")
+	s.pageMtx.Lock()
+	readAt := s.pageTime.Add(-CacheTtl)
+	s.pageTime = readAt
+	s.pageMtx.Unlock()
+
+	// the stale page is served while it is read again, and after the read failed
+	require.Equal(t, http.StatusOK, serve(s, http.MethodGet, JsonPath, "10.0.0.1:1234").Code)
+	require.Eventually(t, func() bool {
+		s.pageMtx.Lock()
+		defer s.pageMtx.Unlock()
+		return s.pageTime.After(readAt)
+	}, time.Second, time.Millisecond)
+	require.Equal(t, http.StatusOK, serve(s, http.MethodGet, JsonPath, "10.0.0.1:1234").Code)
+	require.Equal(t, 2, dataProvider.reads)
+}
+
+func TestServer_ReadContext(t *testing.T) {
+	dataProvider := &fakeDataProvider{blocked: make(chan struct{})}
+	cfg := &config.StatusPageConfig{Port: 8090, RequestsPerMinute: 1, RecentChallenges: 1}
+	s := NewServer(cfg, dataProvider, fakeHeights{})
+
+	// the client goes away while the page is read, which does not fail the read
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, JsonPath, nil).WithContext(ctx)
+		r.RemoteAddr = "10.0.0.1:1234"
+		s.mux.ServeHTTP(w, r)
+		done <- w.Code
+	}()
+	<-dataProvider.blocked
+	cancel()
+
+	// the rate limit does not wait for the read
+	require.Equal(t, http.StatusTooManyRequests, serve(s, http.MethodGet, JsonPath, "10.0.0.1:1234").Code)
+
+	dataProvider.blocked <- struct{}{}
+	require.Equal(t, http.StatusOK, <-done)
+	require.Equal(t, 1, dataProvider.reads)
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	cfg := &config.StatusPageConfig{Port: 8090, RequestsPerMinute: 2, RecentChallenges: 1}
+	s := NewServer(cfg, &fakeDataProvider{}, fakeHeights{})
+	for i := 0; i < 2; i++ {
+		require.Equal(t, http.StatusOK, serve(s, http.MethodGet, JsonPath, "10.0.0.1:1234").Code)
+	}
+	w := serve(s, http.MethodGet, JsonPath, "10.0.0.1:5678")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "31", w.Header().Get("Retry-After"))
+	// the other clients are served
+	require.Equal(t, http.StatusOK, serve(s, http.MethodGet, JsonPath, "10.0.0.2:1234").Code)
+
+	// behind a proxy, the clients are told apart by the address the proxy appended
+	cfg.TrustForwardedFor = true
+	r := httptest.NewRequest(http.MethodGet, JsonPath, nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4, 10.0.0.3")
+	require.Equal(t, "10.0.0.3", s.client(r))
+}
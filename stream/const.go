@@ -0,0 +1,15 @@
+package stream
+
+import "time"
+
+const (
+	ContentTypeJson     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+
+	QueueSize      = 10000 // transitions waiting to be published, the later ones are dropped
+	PublishTimeout = 10 * time.Second
+	// DrainTimeout bounds the publishing of the transitions still queued on shutdown
+	DrainTimeout = 10 * time.Second
+
+	ClientName = "greenfield-challenger"
+)
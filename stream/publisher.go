@@ -0,0 +1,84 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+)
+
+// Publisher publishes the encoded transitions to a broker, the messages of a key are kept in order.
+type Publisher interface {
+	Publish(ctx context.Context, key string, value []byte, contentType string) error
+	Close() error
+}
+
+// NewPublisher returns the publisher of the backend of cfg.
+func NewPublisher(cfg *config.StreamConfig) (Publisher, error) {
+	switch cfg.Backend {
+	case config.StreamBackendKafka:
+		return NewKafkaPublisher(cfg.KafkaBrokers, cfg.KafkaTopic), nil
+	case config.StreamBackendNats:
+		return NewNatsPublisher(cfg.NatsUrl, cfg.NatsSubject)
+	}
+	return nil, fmt.Errorf("unknown stream backend %s", cfg.Backend)
+}
+
+// KafkaPublisher writes the transitions to a kafka topic, the messages are partitioned by key.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaPublisher(brokers []string, topic string) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+		},
+	}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, key string, value []byte, contentType string) error {
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:     []byte(key),
+		Value:   value,
+		Headers: []kafka.Header{{Key: "content-type", Value: []byte(contentType)}},
+	})
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
+
+// NatsPublisher publishes the transitions to a nats subject. The connection is retried in the background, so that the
+// challenger starts while the nats server is down.
+type NatsPublisher struct {
+	conn    *nats.Conn
+	subject string
+}
+
+func NewNatsPublisher(url, subject string) (*NatsPublisher, error) {
+	conn, err := nats.Connect(url, nats.Name(ClientName), nats.RetryOnFailedConnect(true), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	return &NatsPublisher{conn: conn, subject: subject}, nil
+}
+
+func (p *NatsPublisher) Publish(_ context.Context, key string, value []byte, contentType string) error {
+	msg := nats.NewMsg(p.subject)
+	msg.Data = value
+	msg.Header.Set("Content-Type", contentType)
+	msg.Header.Set("Challenge-Id", key)
+	return p.conn.PublishMsg(msg)
+}
+
+// Close flushes the messages buffered by the connection before closing it.
+func (p *NatsPublisher) Close() error {
+	return p.conn.Drain()
+}
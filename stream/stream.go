@@ -0,0 +1,128 @@
+package stream
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+// NewTransition returns the transition of an event from previous, previous is nil for an event just ingested.
+func NewTransition(previous, event *model.Event) *Transition {
+	t := &Transition{
+		ChallengeId:       event.ChallengeId,
+		ObjectId:          event.ObjectId,
+		SegmentIndex:      event.SegmentIndex,
+		SpOperatorAddress: event.SpOperatorAddress,
+		ChallengerAddress: event.ChallengerAddress,
+		Height:            event.Height,
+		ExpiredHeight:     event.ExpiredHeight,
+		Status:            event.Status.String(),
+		VerifyResult:      event.VerifyResult.String(),
+		Timestamp:         time.Now().UnixMilli(),
+	}
+	if previous != nil {
+		t.FromStatus = previous.Status.String()
+	}
+	return t
+}
+
+// Encode serializes t, it returns the encoded transition and its content type. The json follows the proto3 json
+// mapping with the field names of the proto, so the 64 bit integers are strings.
+func Encode(t *Transition, serialization string) ([]byte, string, error) {
+	if serialization == config.StreamSerializationProtobuf {
+		bz, err := proto.Marshal(t)
+		return bz, ContentTypeProtobuf, err
+	}
+	bz, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(t)
+	return bz, ContentTypeJson, err
+}
+
+// Streamer publishes the status transitions of the events in the background, so that a slow broker does not slow
+// down the pipeline. The transitions beyond the queue are dropped.
+type Streamer struct {
+	publisher     Publisher
+	serialization string
+	queue         chan *Transition
+	done          chan struct{}
+	stopped       chan struct{}
+}
+
+func NewStreamer(publisher Publisher, serialization string) *Streamer {
+	s := &Streamer{
+		publisher:     publisher,
+		serialization: serialization,
+		queue:         make(chan *Transition, QueueSize),
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Observe queues the transition of event, it is the transition observer of the daos.
+func (s *Streamer) Observe(previous, event *model.Event) {
+	select {
+	case s.queue <- NewTransition(previous, event):
+	default:
+		logging.Logger.Errorf("stream queue is full, transition dropped, challengeId=%d, status=%s", event.ChallengeId,
+			event.Status)
+	}
+}
+
+func (s *Streamer) run() {
+	defer close(s.stopped)
+	for {
+		select {
+		case t := <-s.queue:
+			ctx, cancel := context.WithTimeout(context.Background(), PublishTimeout)
+			s.publish(ctx, t)
+			cancel()
+		case <-s.done:
+			s.drain()
+			return
+		}
+	}
+}
+
+// drain publishes the transitions still queued, until DrainTimeout.
+func (s *Streamer) drain() {
+	ctx, cancel := context.WithTimeout(context.Background(), DrainTimeout)
+	defer cancel()
+	for {
+		select {
+		case t := <-s.queue:
+			s.publish(ctx, t)
+		default:
+			return
+		}
+		if ctx.Err() != nil {
+			logging.Logger.Errorf("stream drain timed out, %d transitions dropped", len(s.queue))
+			return
+		}
+	}
+}
+
+func (s *Streamer) publish(ctx context.Context, t *Transition) {
+	bz, contentType, err := Encode(t, s.serialization)
+	if err == nil {
+		err = s.publisher.Publish(ctx, strconv.FormatUint(t.ChallengeId, 10), bz, contentType)
+	}
+	if err != nil {
+		logging.Logger.Errorf("failed to publish transition, challengeId=%d, status=%s, err=%+v", t.ChallengeId, t.Status,
+			err.Error())
+	}
+}
+
+// Close publishes the transitions still queued and closes the publisher.
+func (s *Streamer) Close() error {
+	close(s.done)
+	<-s.stopped
+	return s.publisher.Close()
+}
@@ -0,0 +1,88 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+)
+
+type message struct {
+	key         string
+	value       []byte
+	contentType string
+}
+
+type mockPublisher struct {
+	mtx      sync.Mutex
+	messages []message
+	block    chan struct{}
+	closed   bool
+}
+
+func (p *mockPublisher) Publish(_ context.Context, key string, value []byte, contentType string) error {
+	if p.block != nil {
+		<-p.block
+	}
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.messages = append(p.messages, message{key: key, value: value, contentType: contentType})
+	return nil
+}
+
+func (p *mockPublisher) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestEncode(t *testing.T) {
+	previous := &model.Event{ChallengeId: 7, ObjectId: "42", SpOperatorAddress: "0x01", Status: model.Unprocessed}
+	event := *previous
+	event.Status, event.VerifyResult = model.Verified, model.HashMismatched
+	transition := NewTransition(previous, &event)
+
+	bz, contentType, err := Encode(transition, config.StreamSerializationJson)
+	require.NoError(t, err)
+	require.Equal(t, ContentTypeJson, contentType)
+	decoded := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(bz, &decoded))
+	require.Equal(t, "7", decoded["challenge_id"])
+	require.Equal(t, "unprocessed", decoded["from_status"])
+	require.Equal(t, "verified", decoded["status"])
+	require.Equal(t, "hash_mismatched", decoded["verify_result"])
+	// the zero values are kept, so consumers see every field
+	require.Contains(t, decoded, "segment_index")
+
+	bz, contentType, err = Encode(transition, config.StreamSerializationProtobuf)
+	require.NoError(t, err)
+	require.Equal(t, ContentTypeProtobuf, contentType)
+	parsed := &Transition{}
+	require.NoError(t, proto.Unmarshal(bz, parsed))
+	require.True(t, proto.Equal(transition, parsed))
+
+	require.Empty(t, NewTransition(nil, &event).FromStatus)
+}
+
+func TestStreamer(t *testing.T) {
+	publisher := &mockPublisher{block: make(chan struct{})}
+	s := NewStreamer(publisher, config.StreamSerializationJson)
+	s.Observe(nil, &model.Event{ChallengeId: 1})
+	require.Eventually(t, func() bool { return len(s.queue) == 0 }, time.Second, time.Millisecond)
+	for i := uint64(2); i <= QueueSize+2; i++ {
+		s.Observe(nil, &model.Event{ChallengeId: i})
+	}
+	// one transition is being published, the one beyond the queue is dropped
+	close(publisher.block)
+	require.NoError(t, s.Close())
+	require.True(t, publisher.closed)
+	require.Len(t, publisher.messages, QueueSize+1)
+	require.Equal(t, "1", publisher.messages[0].key)
+	require.Equal(t, ContentTypeJson, publisher.messages[0].contentType)
+}
@@ -1,15 +1,17 @@
 package submitter
 
 import (
+	"context"
+
 	"github.com/bnb-chain/greenfield-challenger/db/dao"
 	"github.com/bnb-chain/greenfield-challenger/db/model"
 	"github.com/bnb-chain/greenfield-challenger/executor"
 )
 
 type DataProvider interface {
-	FetchEventsForSubmit(currentHeight uint64) ([]*model.Event, error)
-	FetchVotesForAggregation(eventHash string) ([]*model.Vote, error)
-	UpdateEventStatus(challengeId uint64, status model.EventStatus) error
+	FetchEventsForSubmit(ctx context.Context, currentHeight uint64) ([]*model.Event, error)
+	FetchVotesForAggregation(ctx context.Context, eventHash string) ([]*model.Vote, error)
+	UpdateEventStatus(ctx context.Context, challengeId uint64, status model.EventStatus) error
 }
 
 type DataHandler struct {
@@ -24,14 +26,14 @@ func NewDataHandler(daoManager *dao.DaoManager, executor *executor.Executor) *Da
 	}
 }
 
-func (h *DataHandler) FetchEventsForSubmit(currentHeight uint64) ([]*model.Event, error) {
-	return h.daoManager.GetUnexpiredEventsByStatus(currentHeight, model.EnoughVotesCollected)
+func (h *DataHandler) FetchEventsForSubmit(ctx context.Context, currentHeight uint64) ([]*model.Event, error) {
+	return h.daoManager.GetUnexpiredEventsByStatus(ctx, currentHeight, model.EnoughVotesCollected)
 }
 
-func (h *DataHandler) FetchVotesForAggregation(eventHash string) ([]*model.Vote, error) {
-	return h.daoManager.GetVotesByEventHash(eventHash)
+func (h *DataHandler) FetchVotesForAggregation(ctx context.Context, eventHash string) ([]*model.Vote, error) {
+	return h.daoManager.GetVotesByEventHash(ctx, eventHash)
 }
 
-func (h *DataHandler) UpdateEventStatus(challengeId uint64, status model.EventStatus) error {
-	return h.daoManager.UpdateEventStatusByChallengeId(challengeId, status)
+func (h *DataHandler) UpdateEventStatus(ctx context.Context, challengeId uint64, status model.EventStatus) error {
+	return h.daoManager.UpdateEventStatusByChallengeId(ctx, challengeId, status)
 }
@@ -1,31 +1,36 @@
 package submitter
 
 import (
+	"context"
 	"cosmossdk.io/math"
 	"encoding/hex"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/bnb-chain/greenfield-challenger/alert"
+	"github.com/bnb-chain/greenfield-challenger/audit"
 	"github.com/bnb-chain/greenfield-challenger/common"
 	"github.com/bnb-chain/greenfield-challenger/config"
 	"github.com/bnb-chain/greenfield-challenger/db/model"
 	"github.com/bnb-chain/greenfield-challenger/executor"
+	"github.com/bnb-chain/greenfield-challenger/health"
 	"github.com/bnb-chain/greenfield-challenger/logging"
 	"github.com/bnb-chain/greenfield-challenger/metrics"
+	"github.com/bnb-chain/greenfield-challenger/tracing"
 	"github.com/bnb-chain/greenfield-challenger/vote"
 	"github.com/bnb-chain/greenfield/sdk/types"
 	challengetypes "github.com/bnb-chain/greenfield/x/challenge/types"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/types/tx"
-	lru "github.com/hashicorp/golang-lru"
 	"github.com/willf/bitset"
 )
 
 type TxSubmitter struct {
 	config          *config.Config
 	executor        *executor.Executor
-	cachedEventHash *lru.Cache
+	identities      []*executor.Executor // executors of the other identities run by the challenger
+	cachedEventHash *common.EventCache
 	feeAmount       sdk.Coins
 	DataProvider
 	metricService *metrics.MetricService
@@ -39,85 +44,134 @@ func NewTxSubmitter(cfg *config.Config, executor *executor.Executor, submitterDa
 	// else set default value
 	feeCoins := sdk.NewCoins(sdk.NewCoin(cfg.GreenfieldConfig.FeeDenom, feeAmount))
 
-	cacheSize := 1000
-	lruCache, _ := lru.New(cacheSize)
-
 	return &TxSubmitter{
 		config:          cfg,
 		executor:        executor,
 		feeAmount:       feeCoins,
-		cachedEventHash: lruCache,
+		cachedEventHash: common.NewEventCache(cfg.PipelineConfig.CacheSize),
 		DataProvider:    submitterDataProvider,
 		metricService:   metricService,
 	}
 }
 
-// SubmitTransactionLoop polls for submitter inturn and fetches events for submit.
-func (s *TxSubmitter) SubmitTransactionLoop() {
+// AddIdentity adds the executor of another identity, the attestations are submitted by the identity in turn.
+func (s *TxSubmitter) AddIdentity(identity *executor.Executor) {
+	s.identities = append(s.identities, identity)
+}
+
+// SubmitterCache is the state of the tx submitter, as dumped by the admin api.
+type SubmitterCache struct {
+	EventHashes []uint64 `json:"event_hashes"` // challenges whose event hash is cached
+}
+
+// DumpCache returns the challenges whose event hash is cached.
+func (s *TxSubmitter) DumpCache() interface{} {
+	return SubmitterCache{EventHashes: s.cachedEventHash.ChallengeIds()}
+}
+
+// SubmitTransactionLoop polls for submitter inturn and fetches events for submit until ctx is done. An attestation
+// which is already being submitted is not cancelled with ctx.
+func (s *TxSubmitter) SubmitTransactionLoop(ctx context.Context) {
 	ticker := time.NewTicker(TxSubmitLoopInterval)
-	for range ticker.C {
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		health.Beat(health.LoopSubmitter)
 		// Loop until submitter is inturn to submit
-		attestPeriodEnd := s.queryAttestPeriodLoop()
+		attester, attestPeriodEnd, ok := s.queryAttestPeriodLoop(ctx)
+		if !ok {
+			return
+		}
 		// Fetch events for submit
 		currentHeight := s.executor.GetCachedBlockHeight()
-		events, err := s.FetchEventsForSubmit(currentHeight)
+		s.cachedEventHash.EvictExpired(currentHeight)
+		events, err := s.FetchEventsForSubmit(ctx, currentHeight)
 		if err != nil {
 			s.metricService.IncSubmitterErr(err)
 			logging.Logger.Errorf("tx submitter failed to fetch events for submitting", err)
 			continue
 		}
 		if len(events) == 0 {
-			time.Sleep(common.RetryInterval)
+			if !common.Sleep(ctx, common.IdleRetryInterval()) {
+				return
+			}
 			continue
 		}
 		// Submit events
 		for _, event := range events {
 			// Submitter no longer in-turn
-			if time.Now().Unix() > int64(attestPeriodEnd) {
+			if time.Now().Unix() > int64(attestPeriodEnd) || ctx.Err() != nil {
 				break
 			}
-			err = s.submitForSingleEvent(event, attestPeriodEnd)
+			err = s.submitForSingleEvent(context.Background(), attester, event, attestPeriodEnd)
 			if err != nil {
 				logging.Logger.Errorf("tx submitter ran into an error while trying to attest, err=%+v", err.Error())
 				continue
 			}
-			time.Sleep(TxSubmitInterval)
+			if !common.Sleep(ctx, TxSubmitInterval) {
+				return
+			}
 		}
 	}
 }
 
-// queryAttestPeriodLoop loops until submitter is inturn and return the end time of the current attestation period.
-func (s *TxSubmitter) queryAttestPeriodLoop() uint64 {
+// queryAttestPeriodLoop loops until one of the identities is inturn and returns its executor and the end time of the
+// current attestation period, it returns false if ctx is done before.
+func (s *TxSubmitter) queryAttestPeriodLoop(ctx context.Context) (*executor.Executor, uint64, bool) {
 	for {
+		// waiting for the turn is part of the loop, so it beats as well
+		health.Beat(health.LoopSubmitter)
 		res, err := s.executor.QueryInturnAttestationSubmitter()
 		if err != nil {
+			if !common.Sleep(ctx, common.RetryInterval()) {
+				return nil, 0, false
+			}
 			continue
 		}
 		// Submitter is inturn if bls key matches
-		if res.BlsPubKey == hex.EncodeToString(s.executor.BlsPubKey) {
-			logging.Logger.Infof("tx submitter is currently inturn for submitting until %s", time.Unix(int64(res.SubmitInterval.GetEnd()), 0).Format(TimeFormat))
-			return res.SubmitInterval.GetEnd()
+		for _, attester := range append([]*executor.Executor{s.executor}, s.identities...) {
+			if res.BlsPubKey == hex.EncodeToString(attester.GetBlsPubKey()) {
+				logging.Logger.Infof("tx submitter %s is currently inturn for submitting until %s", attester.GetAddr(),
+					time.Unix(int64(res.SubmitInterval.GetEnd()), 0).Format(TimeFormat))
+				return attester, res.SubmitInterval.GetEnd(), true
+			}
 		}
 
-		time.Sleep(common.RetryInterval)
+		if !common.Sleep(ctx, common.RetryInterval()) {
+			return nil, 0, false
+		}
 	}
 }
 
 // submitForSingleEvent fetches required data and submits a single event.
-func (s *TxSubmitter) submitForSingleEvent(event *model.Event, attestPeriodEnd uint64) error {
-	logging.Logger.Infof("submitter started for challengeId: %d", event.ChallengeId)
+func (s *TxSubmitter) submitForSingleEvent(ctx context.Context, attester *executor.Executor, event *model.Event, attestPeriodEnd uint64) (err error) {
+	ctx, span := tracing.StartEventSpan(ctx, event, tracing.SpanAttestSubmit)
+	defer func() { tracing.EndSpan(span, err) }()
+	eventLogger(event).Infof("submitter started")
 	// Check if events expired
-	err := s.preCheck(event)
+	err = s.preCheck(event)
 	if err != nil {
 		return err
 	}
+	// queries still running for the event are cancelled once the submitter gives up on it
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	// Calculate event hash and use it to fetch votes and validator bitset
-	aggregatedSignature, valBitSet, err := s.getSignatureAndBitSet(event)
+	aggregatedSignature, valBitSet, err := s.getSignatureAndBitSet(ctx, event)
 	if err != nil {
 		s.metricService.IncSubmitterErr(err)
 		return err
 	}
-	return s.submitTransactionLoop(event, attestPeriodEnd, aggregatedSignature, valBitSet)
+	return s.submitTransactionLoop(ctx, attester, event, attestPeriodEnd, aggregatedSignature, valBitSet)
+}
+
+// Forget drops the cached event hash of the challenge, the hash changes with the verify result of a reset event.
+func (s *TxSubmitter) Forget(challengeId uint64) {
+	s.cachedEventHash.Remove(challengeId)
 }
 
 // getEventHash gets the event hash from the cache or calculates it if not present.
@@ -126,33 +180,34 @@ func (s *TxSubmitter) getEventHash(event *model.Event) []byte {
 	if found {
 		return eventHash.([]byte)
 	}
-	calculatedEventHash := vote.CalculateEventHash(event, s.config.GreenfieldConfig.ChainIdString)
-	s.cachedEventHash.Add(event.ChallengeId, calculatedEventHash)
+	calculatedEventHash := vote.EventHash(event, s.config.GreenfieldConfig.ChainIdString)
+	s.cachedEventHash.Add(event.ChallengeId, event.ExpiredHeight, calculatedEventHash)
 	return calculatedEventHash
 }
 
-func (s *TxSubmitter) getSignatureAndBitSet(event *model.Event) ([]byte, *bitset.BitSet, error) {
+func (s *TxSubmitter) getSignatureAndBitSet(ctx context.Context, event *model.Event) ([]byte, *bitset.BitSet, error) {
 	eventHash := s.getEventHash(event)
-	votes, err := s.FetchVotesForAggregation(hex.EncodeToString(eventHash))
+	votes, err := s.FetchVotesForAggregation(ctx, hex.EncodeToString(eventHash))
 	if err != nil {
-		logging.Logger.Errorf("submitter failed to get votes for event with challengeId", event.ChallengeId, err)
+		eventLogger(event).Errorf("submitter failed to get votes, err=%+v", err.Error())
 		return nil, nil, err
 	}
-	validators, err := s.executor.QueryCachedLatestValidators()
+	// the bitset must follow the validators on chain, they are queried again if the update loop missed an update
+	validators, err := s.executor.QueryFreshValidators(2 * common.UpdateValidatorsInterval())
 	if err != nil {
-		logging.Logger.Errorf("submitter failed to query validators for event with challenge id", event.ChallengeId, err)
+		eventLogger(event).Errorf("submitter failed to query validators, err=%+v", err.Error())
 		return nil, nil, err
 	}
 	aggregatedSignature, valBitSet, err := vote.AggregateSignatureAndValidatorBitSet(votes, validators)
 	if err != nil {
-		logging.Logger.Errorf("submitter failed to aggregate signature for event with challenge id", event.ChallengeId, err)
+		eventLogger(event).Errorf("submitter failed to aggregate signature, err=%+v", err.Error())
 		return nil, nil, err
 	}
 	return aggregatedSignature, valBitSet, nil
 }
 
-// submitTransaction creates and submits the transaction.
-func (s *TxSubmitter) submitTransactionLoop(event *model.Event, attestPeriodEnd uint64, aggregatedSignature []byte, valBitSet *bitset.BitSet) error {
+// submitTransaction creates and submits the transaction with the account of attester.
+func (s *TxSubmitter) submitTransactionLoop(ctx context.Context, attester *executor.Executor, event *model.Event, attestPeriodEnd uint64, aggregatedSignature []byte, valBitSet *bitset.BitSet) error {
 	startTime := time.Now()
 	submittedAttempts := 0
 	for {
@@ -170,9 +225,9 @@ func (s *TxSubmitter) submitTransactionLoop(event *model.Event, attestPeriodEnd
 			voteResult = challengetypes.CHALLENGE_SUCCEED
 		}
 		// Create transaction options
-		nonce, err := s.executor.GetNonce()
+		nonce, err := attester.GetNonce()
 		if err != nil {
-			logging.Logger.Errorf("submitter failed to get nonce for challengeId", event.ChallengeId, err)
+			eventLogger(event).Errorf("submitter failed to get nonce, err=%+v", err.Error())
 			continue
 		}
 		mode := tx.BroadcastMode_BROADCAST_MODE_SYNC
@@ -183,37 +238,45 @@ func (s *TxSubmitter) submitTransactionLoop(event *model.Event, attestPeriodEnd
 			Mode:      &mode,
 		}
 		// Submit transaction
-		attestRes, err := s.executor.AttestChallenge(s.executor.GetAddr(), event.ChallengerAddress, event.SpOperatorAddress, event.ChallengeId, math.NewUintFromString(event.ObjectId), voteResult, valBitSet.Bytes(), aggregatedSignature, txOpts)
+		txHash, attestRes, err := attester.AttestChallenge(attester.GetAddr(), event.ChallengerAddress, event.SpOperatorAddress, event.ChallengeId, math.NewUintFromString(event.ObjectId), voteResult, valBitSet.Bytes(), aggregatedSignature, txOpts)
+		audit.RecordAttest(event.ChallengeId, s.getEventHash(event), voteResult.String(), aggregatedSignature, txHash, attestRes, err)
+		if txHash != "" {
+			// a transaction accepted to the mempool pays its fee whether it attests the challenge or not
+			s.metricService.AddAttestFees(s.feeAmount.AmountOf(s.config.GreenfieldConfig.FeeDenom))
+		}
 		if err != nil || !attestRes {
 			// Handle cases where the challenge wasn't successfully attested but no error was returned
 			if err != nil {
-				logging.Logger.Errorf("submitter failed for challengeId: %d, attempts: %d, err=%+v", event.ChallengeId, submittedAttempts, err.Error())
+				eventLogger(event).Errorf("submitter failed, attempts: %d, err=%+v", submittedAttempts, err.Error())
 				// Handle cases where a storage provider was recently slashed
 				if strings.Contains(err.Error(), "duplicated slash") {
-					dbErr := s.DataProvider.UpdateEventStatus(event.ChallengeId, model.DuplicatedSlash)
+					dbErr := s.DataProvider.UpdateEventStatus(ctx, event.ChallengeId, model.DuplicatedSlash)
 					if dbErr != nil {
 						return dbErr
 					}
 					return err
 				}
+				alert.AttestFailures.Fail(fmt.Sprintf("challenge %d, err=%s", event.ChallengeId, err.Error()))
 			} else {
-				logging.Logger.Errorf("submitter failed for challengeId: %d, attempts: %d", event.ChallengeId, submittedAttempts)
+				eventLogger(event).Errorf("submitter failed, attempts: %d", submittedAttempts)
+				alert.AttestFailures.Fail(fmt.Sprintf("challenge %d was not attested", event.ChallengeId))
 			}
 			submittedAttempts++
 			time.Sleep(TxSubmitInterval)
 			continue
 		}
+		alert.AttestFailures.Succeed()
 		// Update event status to include in Attest Monitor
-		err = s.DataProvider.UpdateEventStatus(event.ChallengeId, model.Submitted)
+		err = s.DataProvider.UpdateEventStatus(ctx, event.ChallengeId, model.Submitted)
 		if err != nil {
-			logging.Logger.Errorf("submitter succeeded in attesting but failed to update database, err=%+v", err.Error())
+			eventLogger(event).Errorf("submitter succeeded in attesting but failed to update database, err=%+v", err.Error())
 			continue
 		}
 
 		elaspedTime := time.Since(startTime)
 		s.metricService.SetSubmitterDuration(elaspedTime)
 		s.metricService.IncSubmittedChallenges()
-		logging.Logger.Infof("submitter metrics increased for challengeId %d, elasped time %+v", event.ChallengeId, elaspedTime)
+		eventLogger(event).Infof("submitter metrics increased, elasped time %+v", elaspedTime)
 		return err
 	}
 }
@@ -222,8 +285,13 @@ func (s *TxSubmitter) submitTransactionLoop(event *model.Event, attestPeriodEnd
 func (s *TxSubmitter) preCheck(event *model.Event) error {
 	currentHeight := s.executor.GetCachedBlockHeight()
 	if event.ExpiredHeight < currentHeight {
-		logging.Logger.Infof("submitter for challengeId has expired. expired height, current height", event.ChallengeId, event.ExpiredHeight, currentHeight)
+		eventLogger(event).Infof("submitter for challenge has expired, expired height: %d, current height: %d", event.ExpiredHeight, currentHeight)
 		return common.ErrEventExpired
 	}
 	return nil
 }
+
+// eventLogger logs with the fields of event, so that the records of a challenge can be correlated.
+func eventLogger(event *model.Event) *logging.FieldLogger {
+	return logging.WithFields(logging.Logger, event.LogFields(logging.StageSubmit))
+}
@@ -0,0 +1,15 @@
+package supervisor
+
+import "time"
+
+const (
+	MinRestartBackoff = 1 * time.Second
+	MaxRestartBackoff = 1 * time.Minute
+	// MaxRestarts is how often a loop may crash within RestartWindow, a critical loop crashing more often stops the
+	// challenger
+	MaxRestarts   = 5
+	RestartWindow = 10 * time.Minute
+
+	// HeartbeatMetricsInterval is how often the last beats of the loops are exported as metrics.
+	HeartbeatMetricsInterval = 15 * time.Second
+)
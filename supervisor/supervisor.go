@@ -0,0 +1,209 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/common"
+	"github.com/bnb-chain/greenfield-challenger/health"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/sentry"
+)
+
+// Metrics records the crashes and the heartbeats of the supervised loops.
+type Metrics interface {
+	IncLoopRestarts(loop string)
+	SetLoopHeartbeat(loop string, t time.Time)
+}
+
+// Killer crashes the supervised loops on demand, the fault injection of the e2e tests uses it to exercise the
+// restarts of the loops.
+type Killer interface {
+	// Kills returns a channel which receives when the running loop should crash, nil if it never does
+	Kills(loop string) <-chan struct{}
+}
+
+// errKilled is the crash of a loop killed by the Killer.
+var errKilled = errors.New("killed by the fault injection")
+
+// Supervisor owns the long-running goroutines of the challenger. A loop which panics is restarted with backoff, a
+// critical loop which keeps crashing is reported on Fatal so that the challenger exits rather than running without it.
+// A loop which returns is done, the loops return once their context is done or if they are disabled.
+type Supervisor struct {
+	metrics    Metrics
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	fatal      chan error
+	fatalOnce  sync.Once
+	killer     Killer // nil unless faults are injected
+}
+
+func NewSupervisor(metrics Metrics) *Supervisor {
+	return &Supervisor{
+		metrics:    metrics,
+		minBackoff: MinRestartBackoff,
+		maxBackoff: MaxRestartBackoff,
+		fatal:      make(chan error, 1),
+	}
+}
+
+// Fatal receives the error of the first critical loop which could not be revived.
+func (s *Supervisor) Fatal() <-chan error {
+	return s.fatal
+}
+
+// SetKiller makes the loops crash on the demand of killer, it must be set before the loops are started.
+func (s *Supervisor) SetKiller(killer Killer) {
+	s.killer = killer
+}
+
+// HeartbeatLoop exports the last beat of each loop as a metric until ctx is done.
+func (s *Supervisor) HeartbeatLoop(ctx context.Context) {
+	ticker := time.NewTicker(HeartbeatMetricsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for loop, t := range health.Beats() {
+			s.metrics.SetLoopHeartbeat(loop, t)
+		}
+	}
+}
+
+// Group is a set of loops which are stopped together.
+type Group struct {
+	supervisor *Supervisor
+	name       string
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+func (s *Supervisor) NewGroup(name string) *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{supervisor: s, name: name, ctx: ctx, cancel: cancel}
+}
+
+// Go runs loop in a goroutine until the group is stopped, restarting it if it panics.
+func (g *Group) Go(name string, critical bool, loop func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		g.supervise(name, critical, loop)
+	}()
+}
+
+func (g *Group) supervise(name string, critical bool, loop func(ctx context.Context)) {
+	backoff := g.supervisor.minBackoff
+	var crashes []time.Time
+	for {
+		err := g.runOnce(name, loop)
+		if err == nil || g.ctx.Err() != nil {
+			return
+		}
+		logging.Logger.Errorf("loop %s crashed, err=%+v", name, err.Error())
+		g.supervisor.metrics.IncLoopRestarts(name)
+
+		now := time.Now()
+		crashes = append(crashes, now)
+		for len(crashes) > 0 && now.Sub(crashes[0]) > RestartWindow {
+			crashes = crashes[1:]
+		}
+		if len(crashes) == 1 {
+			// the loop ran fine for a while, so the backoff starts over
+			backoff = g.supervisor.minBackoff
+		}
+		if len(crashes) > MaxRestarts && critical {
+			g.supervisor.fail(fmt.Errorf("critical loop %s crashed %d times within %s, last err=%s", name,
+				len(crashes), RestartWindow, err.Error()))
+			return
+		}
+		logging.Logger.Infof("restarting loop %s in %s", name, backoff)
+		if !common.Sleep(g.ctx, backoff) {
+			return
+		}
+		backoff *= 2
+		if backoff > g.supervisor.maxBackoff {
+			backoff = g.supervisor.maxBackoff
+		}
+	}
+}
+
+// runOnce runs loop until it returns or the killer of the supervisor kills it, the loop is killed by cancelling its
+// context since a goroutine cannot be stopped from the outside.
+func (g *Group) runOnce(name string, loop func(ctx context.Context)) error {
+	var kill <-chan struct{}
+	if g.supervisor.killer != nil {
+		kill = g.supervisor.killer.Kills(name)
+	}
+	if kill == nil {
+		return run(g.ctx, name, loop)
+	}
+	ctx, cancel := context.WithCancel(g.ctx)
+	defer cancel()
+	killed := make(chan struct{})
+	go func() {
+		select {
+		case <-kill:
+			close(killed)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	err := run(ctx, name, loop)
+	select {
+	case <-killed:
+		if err == nil {
+			err = errKilled
+		}
+	default:
+	}
+	return err
+}
+
+// run runs loop once and turns a panic into an error, the panic is reported to sentry.
+func run(ctx context.Context, name string, loop func(ctx context.Context)) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			sentry.CapturePanic(name, r, stack)
+			err = fmt.Errorf("panic: %v\n%s", r, stack)
+		}
+	}()
+	loop(ctx)
+	return nil
+}
+
+func (s *Supervisor) fail(err error) {
+	s.fatalOnce.Do(func() {
+		s.fatal <- err
+	})
+}
+
+// Stop cancels the loops of the group and waits until they return, or until ctx is done.
+func (g *Group) Stop(ctx context.Context) error {
+	g.cancel()
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s loops did not stop in time", g.name)
+	}
+}
+
+// Name returns the name of the group.
+func (g *Group) Name() string {
+	return g.name
+}
@@ -0,0 +1,106 @@
+package supervisor
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMetrics struct {
+	mtx      sync.Mutex
+	restarts map[string]int
+}
+
+func (m *fakeMetrics) IncLoopRestarts(loop string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.restarts[loop]++
+}
+
+func (m *fakeMetrics) SetLoopHeartbeat(_ string, _ time.Time) {}
+
+func newTestSupervisor() (*Supervisor, *fakeMetrics) {
+	metrics := &fakeMetrics{restarts: make(map[string]int)}
+	s := NewSupervisor(metrics)
+	s.minBackoff = time.Millisecond
+	s.maxBackoff = time.Millisecond
+	return s, metrics
+}
+
+func TestGroup_RestartsCrashedLoop(t *testing.T) {
+	s, metrics := newTestSupervisor()
+	g := s.NewGroup("test")
+	runs := int32(0)
+	g.Go("flaky", true, func(ctx context.Context) {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			panic("boom")
+		}
+		<-ctx.Done()
+	})
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&runs) == 2 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, g.Stop(context.Background()))
+	require.Equal(t, 1, metrics.restarts["flaky"])
+	select {
+	case err := <-s.Fatal():
+		t.Fatalf("unexpected fatal error %v", err)
+	default:
+	}
+}
+
+func TestGroup_CriticalLoopFails(t *testing.T) {
+	s, _ := newTestSupervisor()
+	g := s.NewGroup("test")
+	// supervise returns once it gives up on the loop
+	g.supervise("broken", true, func(ctx context.Context) { panic("boom") })
+	select {
+	case err := <-s.Fatal():
+		require.Contains(t, err.Error(), "critical loop broken crashed")
+	default:
+		t.Fatal("expected a fatal error")
+	}
+}
+
+func TestGroup_StopTimeout(t *testing.T) {
+	s, _ := newTestSupervisor()
+	g := s.NewGroup("stuck")
+	release := make(chan struct{})
+	g.Go("stuck", false, func(_ context.Context) { <-release })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.EqualError(t, g.Stop(ctx), "stuck loops did not stop in time")
+	close(release)
+}
+
+type fakeKiller struct {
+	kill chan struct{}
+}
+
+func (k *fakeKiller) Kills(loop string) <-chan struct{} {
+	if loop != "victim" {
+		return nil
+	}
+	return k.kill
+}
+
+func TestGroup_RestartsKilledLoop(t *testing.T) {
+	s, metrics := newTestSupervisor()
+	killer := &fakeKiller{kill: make(chan struct{})}
+	s.SetKiller(killer)
+	g := s.NewGroup("test")
+	runs := int32(0)
+	g.Go("victim", true, func(ctx context.Context) {
+		atomic.AddInt32(&runs, 1)
+		<-ctx.Done()
+	})
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&runs) == 1 }, 5*time.Second, 10*time.Millisecond)
+	killer.kill <- struct{}{}
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&runs) == 2 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, g.Stop(context.Background()))
+	metrics.mtx.Lock()
+	defer metrics.mtx.Unlock()
+	require.Equal(t, 1, metrics.restarts["victim"])
+}
@@ -0,0 +1,24 @@
+package tracing
+
+import "time"
+
+const (
+	ServiceName    = "greenfield-challenger"
+	InstrumentName = "github.com/bnb-chain/greenfield-challenger/tracing"
+
+	// spans of the pipeline stages of a challenge, SpanIngest is the root of the challenge trace
+	SpanIngest          = "monitor.ingest"
+	SpanVerify          = "verifier.verify"
+	SpanVoteSign        = "vote.sign"
+	SpanVoteBroadcast   = "vote.broadcast"
+	SpanVoteCollate     = "vote.collate"
+	SpanAttestSubmit    = "submitter.attest"
+	SpanAttestInclusion = "attest.inclusion"
+
+	AttrChallengeId       = "challenge.id"
+	AttrObjectId          = "challenge.object_id"
+	AttrSpOperatorAddress = "challenge.sp_operator_address"
+	AttrVerifyResult      = "challenge.verify_result"
+
+	ShutdownTimeout = 5 * time.Second // flush the pending spans on exit
+)
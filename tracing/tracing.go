@@ -0,0 +1,137 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+// tracer delegates to the global tracer provider, it does nothing until Init sets up the export.
+var tracer = otel.Tracer(InstrumentName)
+
+// challengeIdKey carries the challenge id of a root span to the idGenerator.
+type challengeIdKey struct{}
+
+// Init exports the challenge traces to the otlp collector of cfg, the returned func flushes the pending spans.
+func Init(cfg *config.TracingConfig) (func(), error) {
+	if !cfg.Enabled() {
+		return func() {}, nil
+	}
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OtlpEndpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logging.Logger.Errorf("export traces error, err=%+v", err.Error())
+	}))
+	provider := newTracerProvider(sdktrace.NewBatchSpanProcessor(exporter), cfg.SampleRatio)
+	otel.SetTracerProvider(provider)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			logging.Logger.Errorf("flush traces error, err=%+v", err.Error())
+		}
+	}, nil
+}
+
+func newTracerProvider(processor sdktrace.SpanProcessor, sampleRatio float64) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName))),
+		sdktrace.WithIDGenerator(idGenerator{}),
+		// the ratio sampler only looks at the trace id, so all stages of a challenge are sampled alike
+		sdktrace.WithSampler(sdktrace.TraceIDRatioBased(sampleRatio)),
+	)
+}
+
+// StartChallengeSpan starts the span of a pipeline stage of a challenge. The trace of a challenge is derived from
+// its id, so the stages running in separate loops join the same trace without passing a context around.
+func StartChallengeSpan(ctx context.Context, challengeId uint64, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	opts = append(opts, trace.WithAttributes(attribute.Int64(AttrChallengeId, int64(challengeId))))
+	if name == SpanIngest {
+		ctx = context.WithValue(ctx, challengeIdKey{}, challengeId)
+		return tracer.Start(ctx, name, append(opts, trace.WithNewRoot())...)
+	}
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    challengeTraceId(challengeId),
+		SpanID:     challengeRootSpanId(challengeId),
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	return tracer.Start(trace.ContextWithRemoteSpanContext(ctx, parent), name, opts...)
+}
+
+// StartEventSpan starts the span of a pipeline stage of the challenge of event.
+func StartEventSpan(ctx context.Context, event *model.Event, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	opts = append(opts, trace.WithAttributes(
+		attribute.String(AttrObjectId, event.ObjectId),
+		attribute.String(AttrSpOperatorAddress, event.SpOperatorAddress)))
+	return StartChallengeSpan(ctx, event.ChallengeId, name, opts...)
+}
+
+// EndSpan ends span and marks it failed if err is set.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func challengeTraceId(challengeId uint64) trace.TraceID {
+	var traceId trace.TraceID
+	sum := challengeHash(challengeId)
+	copy(traceId[:], sum[:16])
+	return traceId
+}
+
+func challengeRootSpanId(challengeId uint64) trace.SpanID {
+	var spanId trace.SpanID
+	sum := challengeHash(challengeId)
+	copy(spanId[:], sum[16:24])
+	return spanId
+}
+
+func challengeHash(challengeId uint64) [32]byte {
+	bz := make([]byte, 8)
+	binary.BigEndian.PutUint64(bz, challengeId)
+	return sha256.Sum256(bz)
+}
+
+// idGenerator gives the root span of a challenge the ids derived from the challenge id, the other spans get random
+// ids.
+type idGenerator struct{}
+
+func (g idGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	if challengeId, ok := ctx.Value(challengeIdKey{}).(uint64); ok {
+		return challengeTraceId(challengeId), challengeRootSpanId(challengeId)
+	}
+	var traceId trace.TraceID
+	_, _ = rand.Read(traceId[:])
+	return traceId, g.NewSpanID(ctx, traceId)
+}
+
+func (g idGenerator) NewSpanID(_ context.Context, _ trace.TraceID) trace.SpanID {
+	var spanId trace.SpanID
+	_, _ = rand.Read(spanId[:])
+	return spanId
+}
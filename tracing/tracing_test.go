@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+)
+
+func TestChallengeTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(newTracerProvider(recorder, 1))
+
+	event := &model.Event{ChallengeId: 7, ObjectId: "1", SpOperatorAddress: "0x01"}
+	_, span := StartEventSpan(context.Background(), event, SpanIngest)
+	EndSpan(span, nil)
+	// the stages of the challenge join its trace from other loops without a shared context
+	_, span = StartChallengeSpan(context.Background(), event.ChallengeId, SpanAttestInclusion)
+	EndSpan(span, errors.New("attest failed"))
+	_, span = StartChallengeSpan(context.Background(), 8, SpanVerify)
+	EndSpan(span, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	ingest, inclusion, other := spans[0], spans[1], spans[2]
+	require.Equal(t, challengeTraceId(7), ingest.SpanContext().TraceID())
+	require.Equal(t, challengeRootSpanId(7), ingest.SpanContext().SpanID())
+	require.False(t, ingest.Parent().IsValid())
+
+	require.Equal(t, ingest.SpanContext().TraceID(), inclusion.SpanContext().TraceID())
+	require.Equal(t, ingest.SpanContext().SpanID(), inclusion.Parent().SpanID())
+	require.Equal(t, codes.Error, inclusion.Status().Code)
+	require.NotEqual(t, ingest.SpanContext().TraceID(), other.SpanContext().TraceID())
+}
+
+func TestChallengeTraceSampling(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(newTracerProvider(recorder, 0))
+
+	_, span := StartChallengeSpan(context.Background(), 7, SpanIngest)
+	EndSpan(span, nil)
+	_, span = StartChallengeSpan(context.Background(), 7, SpanVerify)
+	EndSpan(span, nil)
+	require.Empty(t, recorder.Ended())
+}
@@ -3,3 +3,13 @@ package verifier
 import "time"
 
 var VerifyHashLoopInterval = 2 * time.Second
+
+// MaxConcurrentVerifications is the number of events verified at a time.
+const MaxConcurrentVerifications = 20
+
+// the verdicts of the verifier plugins
+const (
+	PluginVerdictMatched    = "matched"
+	PluginVerdictMismatched = "mismatched"
+	PluginVerdictError      = "error"
+)
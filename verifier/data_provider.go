@@ -1,19 +1,34 @@
 package verifier
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/bnb-chain/greenfield-challenger/db/dao"
 	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"gorm.io/gorm"
 )
 
 type DataProvider interface {
-	FetchEventsForVerification(currentHeight uint64) ([]*model.Event, error)
-	UpdateEventStatusVerifyResult(challengeId uint64, status model.EventStatus, verifyResult model.VerifyResult) error
-	UpdateEventStatus(challengeId uint64, status model.EventStatus) error
-	IsEventExistsBetween(objectId string, spOperatorAddr string, fromChallengeId uint64, toChallengeId uint64) (bool, error)
+	FetchEventsForVerification(ctx context.Context, currentHeight uint64) ([]*model.Event, error)
+	// GetEvent returns the event of the challenge, nil if it was deleted
+	GetEvent(ctx context.Context, challengeId uint64) (*model.Event, error)
+	UpdateEventStatusVerifyResult(ctx context.Context, challengeId uint64, status model.EventStatus, verifyResult model.VerifyResult, eventHash string) error
+	UpdateEventStatus(ctx context.Context, challengeId uint64, status model.EventStatus) error
+	IsEventExistsBetween(ctx context.Context, objectId string, spOperatorAddr string, fromChallengeId uint64, toChallengeId uint64) (bool, error)
+	RecordSpVerifyResult(ctx context.Context, spOperatorAddr string, verifyResult model.VerifyResult, latency time.Duration) error
+	SaveVerificationResult(ctx context.Context, verificationResult *model.VerificationResult) error
+}
+
+// Sharder tells which shards of the events the challenger processes, when the events are sharded across challengers.
+type Sharder interface {
+	ShardFilter() dao.ShardFilter
 }
 
 type DataHandler struct {
 	daoManager *dao.DaoManager
+	sharder    Sharder
 }
 
 func NewDataHandler(daoManager *dao.DaoManager) *DataHandler {
@@ -22,22 +37,46 @@ func NewDataHandler(daoManager *dao.DaoManager) *DataHandler {
 	}
 }
 
-func (h *DataHandler) FetchEventsForVerification(currentHeight uint64) ([]*model.Event, error) {
-	return h.daoManager.EventDao.GetUnexpiredEventsByStatus(currentHeight, model.Unprocessed)
+// SetSharder restricts the fetched events to the shards held by the challenger.
+func (h *DataHandler) SetSharder(sharder Sharder) {
+	h.sharder = sharder
+}
+
+func (h *DataHandler) FetchEventsForVerification(ctx context.Context, currentHeight uint64) ([]*model.Event, error) {
+	if h.sharder != nil {
+		return h.daoManager.GetUnexpiredEventsByStatusInShards(ctx, currentHeight, model.Unprocessed, h.sharder.ShardFilter())
+	}
+	return h.daoManager.EventDao.GetUnexpiredEventsByStatus(ctx, currentHeight, model.Unprocessed)
+}
+
+func (h *DataHandler) GetEvent(ctx context.Context, challengeId uint64) (*model.Event, error) {
+	event, err := h.daoManager.GetEventByChallengeId(ctx, challengeId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return event, err
+}
+
+func (h *DataHandler) FetchVotesForAggregation(ctx context.Context, eventHash string) ([]*model.Vote, error) {
+	return h.daoManager.GetVotesByEventHash(ctx, eventHash)
+}
+
+func (h *DataHandler) UpdateEventStatusVerifyResult(ctx context.Context, challengeId uint64, status model.EventStatus, verifyResult model.VerifyResult, eventHash string) error {
+	return h.daoManager.UpdateEventStatusVerifyResultByChallengeId(ctx, challengeId, status, verifyResult, eventHash)
 }
 
-func (h *DataHandler) FetchVotesForAggregation(eventHash string) ([]*model.Vote, error) {
-	return h.daoManager.GetVotesByEventHash(eventHash)
+func (h *DataHandler) UpdateEventStatus(ctx context.Context, challengeId uint64, status model.EventStatus) error {
+	return h.daoManager.UpdateEventStatusByChallengeId(ctx, challengeId, status)
 }
 
-func (h *DataHandler) UpdateEventStatusVerifyResult(challengeId uint64, status model.EventStatus, verifyResult model.VerifyResult) error {
-	return h.daoManager.UpdateEventStatusVerifyResultByChallengeId(challengeId, status, verifyResult)
+func (h *DataHandler) IsEventExistsBetween(ctx context.Context, objectId string, spOperatorAddr string, fromChallengeId uint64, toChallengeId uint64) (bool, error) {
+	return h.daoManager.IsEventExistsBetween(ctx, objectId, spOperatorAddr, fromChallengeId, toChallengeId)
 }
 
-func (h *DataHandler) UpdateEventStatus(challengeId uint64, status model.EventStatus) error {
-	return h.daoManager.UpdateEventStatusByChallengeId(challengeId, status)
+func (h *DataHandler) RecordSpVerifyResult(ctx context.Context, spOperatorAddr string, verifyResult model.VerifyResult, latency time.Duration) error {
+	return h.daoManager.RecordSpVerifyResult(ctx, spOperatorAddr, verifyResult, latency)
 }
 
-func (h *DataHandler) IsEventExistsBetween(objectId string, spOperatorAddr string, fromChallengeId uint64, toChallengeId uint64) (bool, error) {
-	return h.daoManager.IsEventExistsBetween(objectId, spOperatorAddr, fromChallengeId, toChallengeId)
+func (h *DataHandler) SaveVerificationResult(ctx context.Context, verificationResult *model.VerificationResult) error {
+	return h.daoManager.SaveVerificationResult(ctx, verificationResult)
 }
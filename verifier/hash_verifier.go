@@ -4,87 +4,193 @@ import (
 	"bytes"
 	"context"
 	"encoding/hex"
-	lru "github.com/hashicorp/golang-lru"
 	"golang.org/x/sync/semaphore"
 	"io"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/avast/retry-go/v4"
+	"github.com/bnb-chain/greenfield-challenger/alert"
 	"github.com/bnb-chain/greenfield-challenger/common"
 	"github.com/bnb-chain/greenfield-challenger/config"
 	"github.com/bnb-chain/greenfield-challenger/db/model"
 	"github.com/bnb-chain/greenfield-challenger/executor"
+	"github.com/bnb-chain/greenfield-challenger/federation"
+	"github.com/bnb-chain/greenfield-challenger/health"
 	"github.com/bnb-chain/greenfield-challenger/logging"
 	"github.com/bnb-chain/greenfield-challenger/metrics"
+	"github.com/bnb-chain/greenfield-challenger/plugin"
+	"github.com/bnb-chain/greenfield-challenger/tracing"
+	"github.com/bnb-chain/greenfield-challenger/vote"
 	"github.com/bnb-chain/greenfield-common/go/hash"
 	"github.com/bnb-chain/greenfield-go-sdk/types"
 )
 
+// RetryBudget scales the retry attempts of the requests to a storage provider, e.g. with its reputation.
+type RetryBudget interface {
+	RetryAttempts(spOperatorAddress string, attempts uint) uint
+}
+
+// Plugin is a custom verification of the pieces served by the storage providers, e.g. a cross-check against a private
+// mirror, run by a sidecar of the challenger.
+type Plugin interface {
+	Name() string
+	Verify(ctx context.Context, req *plugin.VerifyRequest) (*plugin.VerifyResponse, error)
+}
+
+// Federation fetches the signed verify results of the trusted challengers.
+type Federation interface {
+	SkipThreshold() int
+	PeerResults(ctx context.Context, event *model.Event) []federation.PeerResult
+}
+
 type Verifier struct {
 	config                *config.Config
 	executor              *executor.Executor
 	deduplicationInterval uint64
-	cachedChallengeIds    *lru.Cache
+	cachedChallengeIds    *common.EventCache
+	inFlight              map[uint64]time.Time // challenge ids being verified and when their verification started
 	mtx                   sync.RWMutex
 	dataProvider          DataProvider
 	limiterSemaphore      *semaphore.Weighted
 	metricService         *metrics.MetricService
+	retryBudget           RetryBudget      // nil if the sp requests are retried with the tunable attempts
+	plugins               []Plugin         // asked about the pieces matching the checksums on chain
+	federation            Federation       // nil if the results of the trusted challengers are not fetched
+	jobQueue              JobQueue         // nil if the verifications are not queued in the database
+	spResponses           *spResponseCache // nil if every challenge is requested from its sp
+	holder                string           // instance id leasing the verification jobs
 	wg                    sync.WaitGroup
 }
 
 func NewHashVerifier(cfg *config.Config, executor *executor.Executor, dataProvider DataProvider, metricService *metrics.MetricService,
 ) *Verifier {
-	limiterSemaphore := semaphore.NewWeighted(20)
-
-	cacheSize := 1000
-	lruCache, _ := lru.New(cacheSize)
+	limiterSemaphore := semaphore.NewWeighted(MaxConcurrentVerifications)
 
 	deduplicationInterval, err := executor.QueryChallengeSlashCoolingOffPeriod()
 	if err != nil {
-		logging.Logger.Errorf("verifier failed to query slash cooling off period, err=%+v", err)
+		logging.VerifierLogger.Errorf("verifier failed to query slash cooling off period, err=%+v", err)
+	}
+
+	var spResponses *spResponseCache
+	if cfg.PipelineConfig.SpResponseCacheBlocks > 0 {
+		spResponses = newSpResponseCache(cfg.PipelineConfig.CacheSize, cfg.PipelineConfig.SpResponseCacheBlocks)
 	}
 
 	return &Verifier{
 		config:                cfg,
 		executor:              executor,
 		deduplicationInterval: deduplicationInterval,
-		cachedChallengeIds:    lruCache,
+		cachedChallengeIds:    common.NewEventCache(cfg.PipelineConfig.CacheSize),
+		inFlight:              make(map[uint64]time.Time),
 		mtx:                   sync.RWMutex{},
 		dataProvider:          dataProvider,
 		limiterSemaphore:      limiterSemaphore,
 		metricService:         metricService,
+		spResponses:           spResponses,
 	}
 }
 
-func (v *Verifier) VerifyHashLoop() {
+// SetRetryBudget sets the budget of the retries of the challenge requests to the storage providers.
+func (v *Verifier) SetRetryBudget(budget RetryBudget) {
+	v.retryBudget = budget
+}
+
+// AddPlugin adds a plugin which can find mismatched the pieces matching the checksums on chain.
+func (v *Verifier) AddPlugin(p Plugin) {
+	v.plugins = append(v.plugins, p)
+}
+
+// SetFederation sets the source of the results of the trusted challengers, which are compared to the verify results
+// and may skip requesting the sp.
+func (v *Verifier) SetFederation(f Federation) {
+	v.federation = f
+}
+
+// spRetryAttempts returns the retry attempts of the challenge requests to the storage provider.
+func (v *Verifier) spRetryAttempts(spOperatorAddress string) retry.Option {
+	attempts := common.RetryAttempts()
+	if v.retryBudget != nil {
+		attempts = v.retryBudget.RetryAttempts(spOperatorAddress, attempts)
+	}
+	return retry.Attempts(attempts)
+}
+
+// Forget drops the challenge from the cache, so that its event is verified again once it is reset.
+func (v *Verifier) Forget(challengeId uint64) {
+	v.mtx.Lock()
+	v.cachedChallengeIds.Remove(challengeId)
+	v.mtx.Unlock()
+}
+
+// InFlightVerification is a verification which is running.
+type InFlightVerification struct {
+	ChallengeId uint64    `json:"challenge_id"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+// VerifierCache is the state of the verifier, as dumped by the admin api.
+type VerifierCache struct {
+	CachedChallengeIds []uint64               `json:"cached_challenge_ids"`
+	InFlight           []InFlightVerification `json:"in_flight"`
+}
+
+// DumpCache returns the cached challenges and the verifications which are running, the oldest first.
+func (v *Verifier) DumpCache() interface{} {
+	v.mtx.RLock()
+	defer v.mtx.RUnlock()
+	dump := VerifierCache{
+		CachedChallengeIds: v.cachedChallengeIds.ChallengeIds(),
+		InFlight:           make([]InFlightVerification, 0, len(v.inFlight)),
+	}
+	for challengeId, startedAt := range v.inFlight {
+		dump.InFlight = append(dump.InFlight, InFlightVerification{ChallengeId: challengeId, StartedAt: startedAt})
+	}
+	sort.Slice(dump.InFlight, func(i, j int) bool { return dump.InFlight[i].StartedAt.Before(dump.InFlight[j].StartedAt) })
+	return dump
+}
+
+func (v *Verifier) VerifyHashLoop(ctx context.Context) {
 	for {
-		err := v.verifyHash()
+		health.Beat(health.LoopVerifier)
+		err := v.verifyHash(ctx)
 		if err != nil {
-			time.Sleep(common.RetryInterval)
+			if !common.Sleep(ctx, common.RetryInterval()) {
+				return
+			}
 			continue
 		}
-		time.Sleep(VerifyHashLoopInterval)
+		if !common.Sleep(ctx, VerifyHashLoopInterval) {
+			return
+		}
 	}
 }
-func (v *Verifier) verifyHash() error {
+func (v *Verifier) verifyHash(ctx context.Context) error {
 	// Read unprocessed event from db with lowest challengeId
 	currentHeight := v.executor.GetCachedBlockHeight()
-	events, err := v.dataProvider.FetchEventsForVerification(currentHeight)
+	v.mtx.Lock()
+	v.cachedChallengeIds.EvictExpired(currentHeight)
+	v.mtx.Unlock()
+	events, err := v.dataProvider.FetchEventsForVerification(ctx, currentHeight)
 	if err != nil {
 		v.metricService.IncHashVerifierErr(err)
-		logging.Logger.Errorf("verifier failed to retrieve the earliest events from db to begin verification, err=%+v", err.Error())
+		logging.VerifierLogger.Errorf("verifier failed to retrieve the earliest events from db to begin verification, err=%+v", err.Error())
 		return err
 	}
 	fetchedEvents := []uint64{}
 	for _, v := range events {
 		fetchedEvents = append(fetchedEvents, v.ChallengeId)
 	}
-	logging.Logger.Infof("verifier fetched these events for verification: %+v", fetchedEvents)
+	logging.VerifierLogger.Infof("verifier fetched these events for verification: %+v", fetchedEvents)
+
+	if v.jobQueue != nil {
+		return v.verifyJobs(ctx, events)
+	}
 
 	if len(events) == 0 {
-		time.Sleep(common.RetryInterval)
+		time.Sleep(common.IdleRetryInterval())
 		return nil
 	}
 
@@ -94,38 +200,39 @@ func (v *Verifier) verifyHash() error {
 		v.mtx.Unlock()
 
 		if isCached {
-			logging.Logger.Infof("challengeId: %d is cached", event.ChallengeId)
+			eventLogger(event).Infof("challenge is cached")
 			continue
 		}
 
-		logging.Logger.Infof("challengeId: %d is not cached", event.ChallengeId)
+		eventLogger(event).Infof("challenge is not cached")
 
-		if err = v.limiterSemaphore.Acquire(context.Background(), 1); err != nil {
-			logging.Logger.Errorf("failed to acquire semaphore: %v", err)
+		if err = v.limiterSemaphore.Acquire(ctx, 1); err != nil {
+			logging.VerifierLogger.Errorf("failed to acquire semaphore: %v", err)
 			continue
 		}
 		v.wg.Add(1)
+		v.mtx.Lock()
+		v.inFlight[event.ChallengeId] = time.Now()
+		// cached before the verification starts, so that forgetting a failed one is not undone
+		v.cachedChallengeIds.Add(event.ChallengeId, event.ExpiredHeight, true)
+		v.mtx.Unlock()
 		go func(event *model.Event) {
 			defer v.limiterSemaphore.Release(1)
 			defer v.wg.Done()
-			err = v.verifyForSingleEvent(event)
-		}(event)
-
-		if err != nil {
-			if err.Error() == common.ErrEventExpired.Error() {
+			defer func() {
 				v.mtx.Lock()
-				v.cachedChallengeIds.Remove(event.ChallengeId)
+				delete(v.inFlight, event.ChallengeId)
 				v.mtx.Unlock()
-				continue
+			}()
+			// a verification in flight is drained rather than cancelled on shutdown
+			if err := v.verifyForSingleEvent(context.Background(), event); err != nil {
+				if err.Error() != common.ErrEventExpired.Error() {
+					eventLogger(event).Errorf("verifier failed to verify, err=%+v", err.Error())
+				}
+				// an event still unprocessed is fetched and verified again
+				v.Forget(event.ChallengeId)
 			}
-			logging.Logger.Errorf("verifier failed to verify challengeId: %d, err=%+v", event.ChallengeId, err.Error())
-		}
-
-		if !isCached {
-			v.mtx.Lock()
-			v.cachedChallengeIds.Add(event.ChallengeId, true)
-			v.mtx.Unlock()
-		}
+		}(event)
 	}
 
 	v.wg.Wait()
@@ -133,33 +240,42 @@ func (v *Verifier) verifyHash() error {
 	return nil
 }
 
-func (v *Verifier) verifyForSingleEvent(event *model.Event) error {
+func (v *Verifier) verifyForSingleEvent(ctx context.Context, event *model.Event) error {
 	var err error
+	ctx, span := tracing.StartEventSpan(ctx, event, tracing.SpanVerify)
+	defer func() { tracing.EndSpan(span, err) }()
 	startTime := time.Now()
-	logging.Logger.Infof("verifier started for challengeId: %d %s", event.ChallengeId, time.Now().Format("15:04:05.000000"))
+	eventLogger(event).Infof("verifier started")
 	currentHeight := v.executor.GetCachedBlockHeight()
-	if err = v.preCheck(event, currentHeight); err != nil {
+	if err = v.preCheck(ctx, event, currentHeight); err != nil {
 		return err
 	}
+	verificationResult := &model.VerificationResult{
+		ChallengeId:       event.ChallengeId,
+		SpOperatorAddress: event.SpOperatorAddress,
+		SegmentIndex:      event.SegmentIndex,
+	}
 
-	// Retry GetStorageProviderEndpoint and GetObjectInfoChecksums up to 5 times
+	// Retry GetStorageProviderEndpoint and GetObjectDetail up to 5 times
 	var endpoint string
 	_ = retry.Do(
 		func() error {
 			endpoint, err = v.executor.GetStorageProviderEndpoint(event.SpOperatorAddress)
 			if err != nil {
-				logging.Logger.Errorf("verifier failed to get sp endpoint for challengeId: %s, objectId: %s, err=%+v", event.ChallengeId, event.ObjectId, err.Error())
+				eventLogger(event).Errorf("verifier failed to get sp endpoint, err=%+v", err.Error())
 			}
 			return err
-		}, retry.Context(context.Background()), common.RtyAttem, common.RtyDelay, common.RtyErr)
+		}, retry.Context(ctx), common.RtyAttem, common.RtyDelay, common.RtyErr)
 
 	if err != nil {
-		err = v.dataProvider.UpdateEventStatus(event.ChallengeId, model.VerificationFailed)
+		err = v.dataProvider.UpdateEventStatus(ctx, event.ChallengeId, model.VerificationFailed)
 		v.metricService.IncVerifiedChallenges()
 		v.metricService.IncChallengeFailed()
 		if err != nil {
 			return err
 		}
+		verificationResult.Outcome = model.OutcomeEndpointUnavailable
+		v.saveVerificationResult(ctx, verificationResult)
 		return err
 	}
 
@@ -167,85 +283,155 @@ func (v *Verifier) verifyForSingleEvent(event *model.Event) error {
 	var checksums [][]byte
 	_ = retry.Do(
 		func() error {
-			checksums, err = v.executor.GetObjectInfoChecksums(event.ObjectId)
+			var detail *types.ObjectDetail
+			detail, err = v.executor.GetObjectDetail(event.ObjectId)
+			if err == nil {
+				checksums = detail.ObjectInfo.GetChecksums()
+				verificationResult.BucketName = detail.ObjectInfo.GetBucketName()
+			}
 			if err != nil {
 				if strings.Contains(err.Error(), "No such object") {
-					logging.Logger.Errorf("No such object error for challengeId: %d", event.ChallengeId)
+					eventLogger(event).Errorf("no such object")
 				}
-				logging.Logger.Errorf("hash verifier error getting object checksums for challengeId: %d, err=%s", event.ChallengeId, err.Error())
+				eventLogger(event).Errorf("hash verifier error getting object checksums, err=%s", err.Error())
 			}
 			return err
-		}, retry.Context(context.Background()), common.RtyAttem, common.RtyDelay, common.RtyErr)
+		}, retry.Context(ctx), common.RtyAttem, common.RtyDelay, common.RtyErr)
 	if err != nil {
-		err = v.dataProvider.UpdateEventStatus(event.ChallengeId, model.VerificationFailed)
+		err = v.dataProvider.UpdateEventStatus(ctx, event.ChallengeId, model.VerificationFailed)
 		v.metricService.IncVerifiedChallenges()
 		v.metricService.IncChallengeFailed()
 		if err != nil {
 			return err
 		}
+		verificationResult.Outcome = model.OutcomeChecksumsUnavailable
+		v.saveVerificationResult(ctx, verificationResult)
 		return err
 	}
 	chainRootHash := checksums[event.RedundancyIndex+1]
-	logging.Logger.Infof("chainRootHash: %s for challengeId: %d", hex.EncodeToString(chainRootHash), event.ChallengeId)
+	verificationResult.ExpectedHash = hex.EncodeToString(chainRootHash)
+	eventLogger(event).Infof("chainRootHash: %s", hex.EncodeToString(chainRootHash))
 
-	// Call sp for challenge result
-	challengeRes := &types.ChallengeResult{}
-	var challengeResErr error
-	_ = retry.Do(func() error {
-		challengeRes, challengeResErr = v.executor.GetChallengeResultFromSp(event.ObjectId, endpoint, int(event.SegmentIndex), int(event.RedundancyIndex))
-		if challengeResErr != nil {
-			logging.Logger.Errorf("error getting challenge result from sp for challengeId: %d, objectId: %s, err=%s", event.ChallengeId, event.ObjectId, challengeResErr.Error())
-		}
-		return challengeResErr
-	}, retry.Context(context.Background()), common.RtyAttem, common.RtyDelay, common.RtyErr)
-	if challengeResErr != nil {
-		v.metricService.IncHashVerifierSpApiErr(err)
-		err = v.dataProvider.UpdateEventStatusVerifyResult(event.ChallengeId, model.Verified, model.HashMismatched)
+	if v.federatedMatched(ctx, event) {
+		err = v.updateVerifyResult(ctx, event, model.HashMatched)
 		if err != nil {
-			v.metricService.IncHashVerifierErr(err)
-			logging.Logger.Errorf("error updating event status for challengeId: %d", event.ChallengeId)
+			return err
 		}
 		v.metricService.IncVerifiedChallenges()
-		v.metricService.IncChallengeSuccess()
-		return err
+		v.metricService.IncChallengeFailed()
+		v.metricService.IncFederationSkipped()
+		verificationResult.Outcome = model.OutcomeFederatedMatched
+		v.saveVerificationResult(ctx, verificationResult)
+		eventLogger(event).Infof("verifier skipped the sp, the trusted challengers found the piece matched")
+		return nil
 	}
 
-	pieceData, err := io.ReadAll(challengeRes.PieceData)
-	piecesHash := challengeRes.PiecesHash
-	if err != nil {
-		logging.Logger.Errorf("verifier failed to read piece data for event %d, err=%+v", event.ChallengeId, err.Error())
-		return err
-	}
-	spChecksums := make([][]byte, 0)
-	for _, h := range piecesHash {
-		checksum, err := hex.DecodeString(h)
+	// a piece challenged again within sp_response_cache_blocks is not requested from its sp again
+	spKey := spResponseKey{objectId: event.ObjectId, segmentIndex: event.SegmentIndex,
+		spOperatorAddress: event.SpOperatorAddress}
+	var spRootHash []byte
+	var pluginRequest *plugin.VerifyRequest
+	if cached, ok := v.cachedSpResponse(spKey, currentHeight); ok {
+		spRootHash = cached.rootHash
+		verificationResult.LatencyMs = cached.latencyMs
+		v.metricService.IncSpResponseCacheHits()
+		eventLogger(event).Infof("verifier reused the sp response to the piece received at height %d", cached.height)
+	} else {
+		// Call sp for challenge result
+		challengeRes := &types.ChallengeResult{}
+		var challengeResErr error
+		spStartTime := time.Now()
+		spCtx := common.WithRequestId(ctx, common.RequestId(event.ChallengeId))
+		_ = retry.Do(func() error {
+			verificationResult.Attempts++
+			challengeRes, challengeResErr = v.executor.GetChallengeResultFromSp(spCtx, event.ObjectId, endpoint, int(event.SegmentIndex), int(event.RedundancyIndex))
+			if challengeResErr != nil {
+				eventLogger(event).Errorf("error getting challenge result from sp, err=%s", challengeResErr.Error())
+			}
+			return challengeResErr
+		}, retry.Context(ctx), v.spRetryAttempts(event.SpOperatorAddress), common.RtyDelay, common.RtyErr)
+		spLatency := time.Since(spStartTime)
+		verificationResult.LatencyMs = spLatency.Milliseconds()
+		if challengeResErr != nil {
+			v.metricService.IncHashVerifierSpApiErr(err)
+			err = v.updateVerifyResult(ctx, event, model.HashMismatched)
+			if err != nil {
+				v.metricService.IncHashVerifierErr(err)
+				eventLogger(event).Errorf("error updating event status")
+			} else {
+				v.recordSpVerifyResult(ctx, event, model.HashMismatched, spLatency)
+				verificationResult.Outcome = model.OutcomeSpUnavailable
+				v.saveVerificationResult(ctx, verificationResult)
+				v.comparePeerResults(ctx, event, model.HashMismatched)
+			}
+			v.metricService.IncVerifiedChallenges()
+			v.metricService.IncChallengeSuccess()
+			return err
+		}
+
+		var pieceData []byte
+		pieceData, err = io.ReadAll(challengeRes.PieceData)
+		piecesHash := challengeRes.PiecesHash
 		if err != nil {
-			panic(err)
+			eventLogger(event).Errorf("verifier failed to read piece data, err=%+v", err.Error())
+			return err
+		}
+		spChecksums := make([][]byte, 0)
+		for _, h := range piecesHash {
+			checksum, err := hex.DecodeString(h)
+			if err != nil {
+				panic(err)
+			}
+			spChecksums = append(spChecksums, checksum)
 		}
-		spChecksums = append(spChecksums, checksum)
+		if len(v.plugins) != 0 {
+			// the plugins get the piece hashes as served, the challenged one is replaced to compute the root hash
+			pluginRequest = &plugin.VerifyRequest{
+				ChallengeId:       event.ChallengeId,
+				ObjectId:          event.ObjectId,
+				SegmentIndex:      event.SegmentIndex,
+				SpOperatorAddress: event.SpOperatorAddress,
+				RedundancyIndex:   event.RedundancyIndex,
+				ChallengerAddress: event.ChallengerAddress,
+				ExpectedHash:      chainRootHash,
+				PieceData:         pieceData,
+				PieceHashes:       append([][]byte(nil), spChecksums...),
+			}
+		}
+		originalSpRootHash := hash.GenerateChecksum(bytes.Join(spChecksums, []byte("")))
+		eventLogger(event).Infof("SpRootHash before replacing: %s", hex.EncodeToString(originalSpRootHash))
+		spRootHash = v.computeRootHash(event.SegmentIndex, pieceData, spChecksums)
+		eventLogger(event).Infof("SpRootHash after replacing: %s", hex.EncodeToString(spRootHash))
+		v.cacheSpResponse(spKey, &spResponse{rootHash: spRootHash, latencyMs: verificationResult.LatencyMs,
+			height: currentHeight})
 	}
-	originalSpRootHash := hash.GenerateChecksum(bytes.Join(spChecksums, []byte("")))
-	logging.Logger.Infof("SpRootHash before replacing: %s for challengeId: %d", hex.EncodeToString(originalSpRootHash), event.ChallengeId)
-	spRootHash := v.computeRootHash(event.SegmentIndex, pieceData, spChecksums)
-	logging.Logger.Infof("SpRootHash after replacing: %s for challengeId: %d", hex.EncodeToString(spRootHash), event.ChallengeId)
 	// Update database after comparing
-	err = v.compareHashAndUpdate(event.ChallengeId, chainRootHash, spRootHash)
+	verificationResult.ActualHash = hex.EncodeToString(spRootHash)
+	if bytes.Equal(chainRootHash, spRootHash) && v.pluginMismatched(ctx, event, pluginRequest, spRootHash) {
+		err = v.updateMismatched(ctx, event, verificationResult, model.OutcomePluginMismatched)
+	} else {
+		err = v.compareHashAndUpdate(ctx, event, chainRootHash, spRootHash, verificationResult)
+	}
 	if err != nil {
-		logging.Logger.Errorf("failed to update event status, challenge id: %d, err: %s",
-			event.ChallengeId, err)
+		eventLogger(event).Errorf("failed to update event status, err=%+v", err.Error())
 		v.metricService.IncHashVerifierErr(err)
 		return err
 	}
+	if verificationResult.Outcome == model.OutcomeHashMatched {
+		v.comparePeerResults(ctx, event, model.HashMatched)
+	} else {
+		v.comparePeerResults(ctx, event, model.HashMismatched)
+	}
 	// Log duration
 	elaspedTime := time.Since(startTime)
 	v.metricService.SetHashVerifierDuration(elaspedTime)
-	logging.Logger.Infof("verifier completed time for challengeId: %d %s", event.ChallengeId, time.Now().Format("15:04:05.000000"))
+	eventLogger(event).Infof("verifier completed")
 	return nil
 }
 
-func (v *Verifier) preCheck(event *model.Event, currentHeight uint64) error {
+func (v *Verifier) preCheck(ctx context.Context, event *model.Event, currentHeight uint64) error {
 	if event.ExpiredHeight < currentHeight {
-		logging.Logger.Infof("verifier for challengeId: %d has expired. expired height: %d, current height: %d, timestamp: %s", event.ChallengeId, event.ExpiredHeight, currentHeight, time.Now().Format("15:04:05.000000"))
+		eventLogger(event).Infof("verifier for challenge has expired, expired height: %d, current height: %d", event.ExpiredHeight, currentHeight)
 		return common.ErrEventExpired
 	}
 	// event is duplicated if
@@ -260,21 +446,43 @@ func (v *Verifier) preCheck(event *model.Event, currentHeight uint64) error {
 		panic("heartbeat interval should not zero, potential bug")
 	}
 	if event.ChallengerAddress == "" && event.ChallengeId%heartbeatInterval != 0 && event.ChallengeId > v.deduplicationInterval {
-		found, err := v.dataProvider.IsEventExistsBetween(event.ObjectId, event.SpOperatorAddress,
+		found, err := v.dataProvider.IsEventExistsBetween(ctx, event.ObjectId, event.SpOperatorAddress,
 			event.ChallengeId-v.deduplicationInterval, event.ChallengeId-1)
 		if err != nil {
-			logging.Logger.Errorf("verifier failed to retrieve information for event %d, err=%+v", event.ChallengeId, err.Error())
+			eventLogger(event).Errorf("verifier failed to retrieve information, err=%+v", err.Error())
 			return err
 		}
 		if found {
-			return v.dataProvider.UpdateEventStatus(event.ChallengeId, model.Duplicated)
+			return v.dataProvider.UpdateEventStatus(ctx, event.ChallengeId, model.Duplicated)
 		}
 	}
 
 	return nil
 }
 
+// cachedSpResponse returns the response of the sp cached for the piece. The plugins verify the piece data, which is
+// not cached, so the pieces are always requested while plugins are set.
+func (v *Verifier) cachedSpResponse(key spResponseKey, currentHeight uint64) (*spResponse, bool) {
+	if v.spResponses == nil || len(v.plugins) != 0 {
+		return nil, false
+	}
+	return v.spResponses.get(key, currentHeight)
+}
+
+func (v *Verifier) cacheSpResponse(key spResponseKey, response *spResponse) {
+	if v.spResponses == nil || len(v.plugins) != 0 {
+		return
+	}
+	v.spResponses.add(key, response)
+}
+
 func (v *Verifier) computeRootHash(segmentIndex uint32, pieceData []byte, checksums [][]byte) []byte {
+	return ComputeRootHash(segmentIndex, pieceData, checksums)
+}
+
+// ComputeRootHash returns the root hash of the piece checksums returned by a sp, with the checksum of the challenged
+// segment replaced by the hash of its piece data.
+func ComputeRootHash(segmentIndex uint32, pieceData []byte, checksums [][]byte) []byte {
 	// Hash the piece that is challenged, replace original checksum, recompute new root hash
 	dataHash := hash.GenerateChecksum(pieceData)
 	checksums[segmentIndex] = dataHash
@@ -283,23 +491,144 @@ func (v *Verifier) computeRootHash(segmentIndex uint32, pieceData []byte, checks
 	return rootHash
 }
 
-func (v *Verifier) compareHashAndUpdate(challengeId uint64, chainRootHash []byte, spRootHash []byte) error {
+func (v *Verifier) compareHashAndUpdate(ctx context.Context, event *model.Event, chainRootHash []byte, spRootHash []byte, verificationResult *model.VerificationResult) error {
+	spLatency := time.Duration(verificationResult.LatencyMs) * time.Millisecond
 	if bytes.Equal(chainRootHash, spRootHash) {
-		err := v.dataProvider.UpdateEventStatusVerifyResult(challengeId, model.Verified, model.HashMatched)
+		err := v.updateVerifyResult(ctx, event, model.HashMatched)
 		if err != nil {
 			return err
 		}
 		// update metrics if no err
 		v.metricService.IncVerifiedChallenges()
 		v.metricService.IncChallengeFailed()
+		v.recordSpVerifyResult(ctx, event, model.HashMatched, spLatency)
+		verificationResult.Outcome = model.OutcomeHashMatched
+		v.saveVerificationResult(ctx, verificationResult)
 		return err
 	}
-	err := v.dataProvider.UpdateEventStatusVerifyResult(challengeId, model.Verified, model.HashMismatched)
+	return v.updateMismatched(ctx, event, verificationResult, model.OutcomeHashMismatched)
+}
+
+// updateMismatched records the piece of the event as mismatched, the challenge succeeds.
+func (v *Verifier) updateMismatched(ctx context.Context, event *model.Event, verificationResult *model.VerificationResult,
+	outcome model.VerificationOutcome,
+) error {
+	spLatency := time.Duration(verificationResult.LatencyMs) * time.Millisecond
+	err := v.updateVerifyResult(ctx, event, model.HashMismatched)
 	if err != nil {
 		return err
 	}
 	// update metrics if no err
 	v.metricService.IncVerifiedChallenges()
 	v.metricService.IncChallengeSuccess()
+	v.recordSpVerifyResult(ctx, event, model.HashMismatched, spLatency)
+	verificationResult.Outcome = outcome
+	v.saveVerificationResult(ctx, verificationResult)
 	return err
 }
+
+// updateVerifyResult records the verify result of the event with the hash voted for it, which is hashed once here so
+// that every later stage votes, collates and attests the same bytes.
+func (v *Verifier) updateVerifyResult(ctx context.Context, event *model.Event, verifyResult model.VerifyResult) error {
+	verified := *event
+	verified.VerifyResult = verifyResult
+	eventHash := hex.EncodeToString(vote.CalculateEventHash(&verified, v.config.GreenfieldConfig.ChainIdString))
+	return v.dataProvider.UpdateEventStatusVerifyResult(ctx, event.ChallengeId, model.Verified, verifyResult, eventHash)
+}
+
+// pluginMismatched asks the plugins about a piece which matched the checksums on chain, it is mismatched as soon as a
+// plugin finds it so. A plugin failing to answer leaves the piece matched.
+func (v *Verifier) pluginMismatched(ctx context.Context, event *model.Event, req *plugin.VerifyRequest,
+	spRootHash []byte,
+) bool {
+	if req == nil {
+		return false
+	}
+	req.ActualHash = spRootHash
+	for _, p := range v.plugins {
+		res, err := p.Verify(ctx, req)
+		if err != nil {
+			v.metricService.IncVerifierPluginVerdicts(p.Name(), PluginVerdictError)
+			eventLogger(event).Errorf("verifier plugin %s failed, err=%+v", p.Name(), err.Error())
+			continue
+		}
+		if res.Mismatched {
+			v.metricService.IncVerifierPluginVerdicts(p.Name(), PluginVerdictMismatched)
+			eventLogger(event).Infof("verifier plugin %s found the piece mismatched, reason: %s", p.Name(), res.Reason)
+			return true
+		}
+		v.metricService.IncVerifierPluginVerdicts(p.Name(), PluginVerdictMatched)
+	}
+	return false
+}
+
+// federatedMatched reports whether enough trusted challengers found the piece of the event matched, and none of them
+// mismatched, to skip requesting it from the sp. The heartbeats are always verified, they are the matched events which
+// are voted, so a vote is never cast from the results of the peers.
+func (v *Verifier) federatedMatched(ctx context.Context, event *model.Event) bool {
+	if v.federation == nil || v.federation.SkipThreshold() == 0 {
+		return false
+	}
+	heartbeatInterval, err := v.executor.QueryChallengeHeartbeatInterval()
+	if err != nil || heartbeatInterval == 0 || event.ChallengeId%heartbeatInterval == 0 {
+		return false
+	}
+	return peersMatched(v.federation.PeerResults(ctx, event), v.federation.SkipThreshold())
+}
+
+// peersMatched reports whether at least threshold of the results are matched and none of them mismatched.
+func peersMatched(results []federation.PeerResult, threshold int) bool {
+	matched := 0
+	for _, r := range results {
+		if r.VerifyResult != model.HashMatched {
+			return false
+		}
+		matched++
+	}
+	return matched >= threshold
+}
+
+// comparePeerResults compares the verify result of the event to the ones of the trusted challengers, a disagreement
+// is alerted as one of the challengers may vote wrong.
+func (v *Verifier) comparePeerResults(ctx context.Context, event *model.Event, verifyResult model.VerifyResult) {
+	if v.federation == nil {
+		return
+	}
+	for _, r := range v.federation.PeerResults(ctx, event) {
+		if r.VerifyResult == verifyResult {
+			v.metricService.IncFederationPeerResults(r.Peer, federation.VerdictAgreed)
+			continue
+		}
+		v.metricService.IncFederationPeerResults(r.Peer, federation.VerdictDisagreed)
+		eventLogger(event).Errorf("verifier found the piece %s, but peer %s found it %s", verifyResult, r.Peer,
+			r.VerifyResult)
+		alert.PeerDisagreement(event.ChallengeId, event.SpOperatorAddress, r.Peer, verifyResult.String(),
+			r.VerifyResult.String())
+	}
+}
+
+// recordSpVerifyResult updates the storage provider stats, failing to do so should not block the verification.
+func (v *Verifier) recordSpVerifyResult(ctx context.Context, event *model.Event, verifyResult model.VerifyResult, spLatency time.Duration) {
+	err := v.dataProvider.RecordSpVerifyResult(ctx, event.SpOperatorAddress, verifyResult, spLatency)
+	if err != nil {
+		eventLogger(event).Errorf("verifier failed to record sp stats, err=%+v", err.Error())
+	}
+}
+
+// saveVerificationResult persists the details of the verification, failing to do so should not block the verification.
+func (v *Verifier) saveVerificationResult(ctx context.Context, verificationResult *model.VerificationResult) {
+	verificationResult.CreatedTime = time.Now().Unix()
+	err := v.dataProvider.SaveVerificationResult(ctx, verificationResult)
+	if err != nil {
+		logging.WithFields(logging.VerifierLogger, logging.Fields{
+			ChallengeId: verificationResult.ChallengeId,
+			Sp:          verificationResult.SpOperatorAddress,
+			Stage:       logging.StageVerify,
+		}).Errorf("verifier failed to save verification result, err=%+v", err.Error())
+	}
+}
+
+// eventLogger logs with the fields of event, so that the records of a challenge can be correlated.
+func eventLogger(event *model.Event) *logging.FieldLogger {
+	return logging.WithFields(logging.VerifierLogger, event.LogFields(logging.StageVerify))
+}
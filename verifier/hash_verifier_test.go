@@ -2,10 +2,17 @@ package verifier
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
+	"errors"
 	"testing"
 
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/federation"
 	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+	"github.com/bnb-chain/greenfield-challenger/plugin"
 	"github.com/bnb-chain/greenfield-common/go/hash"
 	"github.com/bnb-chain/greenfield-go-sdk/pkg/utils"
 	"github.com/stretchr/testify/require"
@@ -35,3 +42,84 @@ func TestHashing(t *testing.T) {
 	invalidRootHash := verifier.computeRootHash(0, invalidStr, checksums)
 	require.NotEqual(t, validRootHash, invalidRootHash)
 }
+
+type fakePlugin struct {
+	name  string
+	res   *plugin.VerifyResponse
+	err   error
+	calls int
+}
+
+func (p *fakePlugin) Name() string {
+	return p.name
+}
+
+func (p *fakePlugin) Verify(_ context.Context, _ *plugin.VerifyRequest) (*plugin.VerifyResponse, error) {
+	p.calls++
+	return p.res, p.err
+}
+
+func TestVerifier_PluginMismatched(t *testing.T) {
+	v := &Verifier{metricService: metrics.NewMetricService(&config.Config{})}
+	event := &model.Event{ChallengeId: 1}
+	req := &plugin.VerifyRequest{ChallengeId: 1}
+	// without plugins there is no request
+	require.False(t, v.pluginMismatched(context.Background(), event, nil, []byte("root")))
+
+	failing := &fakePlugin{name: "failing", err: errors.New("mirror unavailable")}
+	matching := &fakePlugin{name: "matching", res: &plugin.VerifyResponse{}}
+	v.AddPlugin(failing)
+	v.AddPlugin(matching)
+	require.False(t, v.pluginMismatched(context.Background(), event, req, []byte("root")))
+	require.Equal(t, []byte("root"), req.ActualHash)
+
+	mismatching := &fakePlugin{name: "mismatching", res: &plugin.VerifyResponse{Mismatched: true, Reason: "differs"}}
+	last := &fakePlugin{name: "last", res: &plugin.VerifyResponse{}}
+	v.AddPlugin(mismatching)
+	v.AddPlugin(last)
+	require.True(t, v.pluginMismatched(context.Background(), event, req, []byte("root")))
+	// the plugins after the one finding the piece mismatched are not asked
+	require.Equal(t, 2, failing.calls)
+	require.Equal(t, 1, mismatching.calls)
+	require.Zero(t, last.calls)
+}
+
+func TestVerifier_CachedSpResponse(t *testing.T) {
+	v := &Verifier{}
+	key := spResponseKey{objectId: "1", segmentIndex: 2, spOperatorAddress: "sp1"}
+	// without the cache every challenge is requested
+	v.cacheSpResponse(key, &spResponse{rootHash: []byte("root"), height: 100})
+	_, ok := v.cachedSpResponse(key, 100)
+	require.False(t, ok)
+
+	v.spResponses = newSpResponseCache(10, 5)
+	v.cacheSpResponse(key, &spResponse{rootHash: []byte("root"), latencyMs: 20, height: 100})
+	cached, ok := v.cachedSpResponse(key, 104)
+	require.True(t, ok)
+	require.Equal(t, []byte("root"), cached.rootHash)
+	require.Equal(t, int64(20), cached.latencyMs)
+	// another segment or sp is requested
+	_, ok = v.cachedSpResponse(spResponseKey{objectId: "1", segmentIndex: 3, spOperatorAddress: "sp1"}, 104)
+	require.False(t, ok)
+	_, ok = v.cachedSpResponse(spResponseKey{objectId: "1", segmentIndex: 2, spOperatorAddress: "sp2"}, 104)
+	require.False(t, ok)
+	// the response is requested again once it is blocks old
+	_, ok = v.cachedSpResponse(key, 105)
+	require.False(t, ok)
+
+	// the plugins verify the piece data, which is not cached
+	v.cacheSpResponse(key, &spResponse{rootHash: []byte("root"), height: 105})
+	v.AddPlugin(&fakePlugin{name: "mirror"})
+	_, ok = v.cachedSpResponse(key, 105)
+	require.False(t, ok)
+}
+
+func TestPeersMatched(t *testing.T) {
+	matched := federation.PeerResult{Peer: "a", VerifyResult: model.HashMatched}
+	mismatched := federation.PeerResult{Peer: "b", VerifyResult: model.HashMismatched}
+	require.False(t, peersMatched(nil, 1))
+	require.True(t, peersMatched([]federation.PeerResult{matched}, 1))
+	require.False(t, peersMatched([]federation.PeerResult{matched}, 2))
+	// a single peer finding the piece mismatched is enough to request the sp
+	require.False(t, peersMatched([]federation.PeerResult{matched, matched, mismatched}, 2))
+}
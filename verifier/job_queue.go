@@ -0,0 +1,139 @@
+package verifier
+
+import (
+	"context"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/common"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+// JobQueue is the queue of the verifications in the database, shared by the challengers of the database.
+type JobQueue interface {
+	EnqueueVerificationJobs(ctx context.Context, challengeIds []uint64, now time.Time) error
+	LeaseVerificationJobs(ctx context.Context, holder string, limit int, maxAttempts uint32, now,
+		expireTime time.Time) ([]*model.VerificationJob, error)
+	AckVerificationJob(ctx context.Context, job *model.VerificationJob) error
+	NackVerificationJob(ctx context.Context, job *model.VerificationJob, jobErr string, maxAttempts uint32, now,
+		availableTime time.Time) error
+}
+
+// SetJobQueue queues the fetched events in queue and verifies the jobs leased from it as holder, instead of verifying
+// the fetched events right away.
+func (v *Verifier) SetJobQueue(queue JobQueue, holder string) {
+	v.jobQueue = queue
+	v.holder = holder
+}
+
+// Queued reports whether the verifications are leased from the job queue.
+func (v *Verifier) Queued() bool {
+	return v.jobQueue != nil
+}
+
+// verifyJobs queues the fetched events and verifies the jobs leased from the queue, which may have been queued by
+// another challenger or be the retries of failed verifications. The verified jobs are deleted, the failed ones are
+// given back to be retried after a backoff.
+func (v *Verifier) verifyJobs(ctx context.Context, events []*model.Event) error {
+	now := time.Now()
+	fetched := make(map[uint64]*model.Event, len(events))
+	challengeIds := make([]uint64, 0, len(events))
+	for _, event := range events {
+		fetched[event.ChallengeId] = event
+		challengeIds = append(challengeIds, event.ChallengeId)
+	}
+	if err := v.jobQueue.EnqueueVerificationJobs(ctx, challengeIds, now); err != nil {
+		v.metricService.IncHashVerifierErr(err)
+		logging.VerifierLogger.Errorf("verifier failed to queue the verification jobs, err=%+v", err.Error())
+		return err
+	}
+	cfg := &v.config.JobQueueConfig
+	jobs, err := v.jobQueue.LeaseVerificationJobs(ctx, v.holder, MaxConcurrentVerifications, cfg.Attempts(), now,
+		now.Add(cfg.LeaseTimeout()))
+	if err != nil {
+		v.metricService.IncHashVerifierErr(err)
+		logging.VerifierLogger.Errorf("verifier failed to lease the verification jobs, err=%+v", err.Error())
+		return err
+	}
+	if len(jobs) == 0 {
+		time.Sleep(common.IdleRetryInterval())
+		return nil
+	}
+
+	for _, job := range jobs {
+		if err = v.limiterSemaphore.Acquire(ctx, 1); err != nil {
+			// the lease of the job expires and it is taken over
+			logging.VerifierLogger.Errorf("failed to acquire semaphore: %v", err)
+			continue
+		}
+		v.wg.Add(1)
+		v.mtx.Lock()
+		v.inFlight[job.ChallengeId] = time.Now()
+		v.mtx.Unlock()
+		go func(job *model.VerificationJob) {
+			defer v.limiterSemaphore.Release(1)
+			defer v.wg.Done()
+			defer func() {
+				v.mtx.Lock()
+				delete(v.inFlight, job.ChallengeId)
+				v.mtx.Unlock()
+			}()
+			// a verification in flight is drained rather than cancelled on shutdown, like the ones not queued
+			v.verifyJob(context.Background(), job, fetched[job.ChallengeId])
+		}(job)
+	}
+
+	v.wg.Wait()
+
+	return nil
+}
+
+// verifyJob verifies the event of the job, which is loaded if it was not fetched, and acks or nacks the job.
+func (v *Verifier) verifyJob(ctx context.Context, job *model.VerificationJob, event *model.Event) {
+	logger := logging.WithFields(logging.VerifierLogger, logging.Fields{ChallengeId: job.ChallengeId,
+		Stage: logging.StageVerify})
+	var err error
+	if event == nil {
+		event, err = v.dataProvider.GetEvent(ctx, job.ChallengeId)
+		if err != nil {
+			logger.Errorf("verifier failed to get the event of the job, err=%+v", err.Error())
+			v.nackJob(ctx, job, err)
+			return
+		}
+	}
+	// the event was wiped, or it was verified after it was queued again, e.g. by a challenger which fetched it before
+	if event == nil || event.Status != model.Unprocessed {
+		v.ackJob(ctx, job)
+		return
+	}
+	err = v.verifyForSingleEvent(ctx, event)
+	if err != nil && err.Error() != common.ErrEventExpired.Error() {
+		eventLogger(event).Errorf("verifier failed to verify, attempt %d, err=%+v", job.Attempts, err.Error())
+		v.nackJob(ctx, job, err)
+		return
+	}
+	v.ackJob(ctx, job)
+}
+
+func (v *Verifier) ackJob(ctx context.Context, job *model.VerificationJob) {
+	if err := v.jobQueue.AckVerificationJob(ctx, job); err != nil {
+		// the job is leased again once its lease expires, and acked then as the event is not unprocessed anymore
+		logging.VerifierLogger.Errorf("verifier failed to ack the job of challenge %d, err=%+v", job.ChallengeId,
+			err.Error())
+	}
+}
+
+func (v *Verifier) nackJob(ctx context.Context, job *model.VerificationJob, jobErr error) {
+	cfg := &v.config.JobQueueConfig
+	now := time.Now()
+	err := v.jobQueue.NackVerificationJob(ctx, job, jobErr.Error(), cfg.Attempts(), now,
+		now.Add(cfg.Backoff(job.Attempts)))
+	if err != nil {
+		logging.VerifierLogger.Errorf("verifier failed to nack the job of challenge %d, err=%+v", job.ChallengeId,
+			err.Error())
+	}
+	if job.Attempts >= cfg.Attempts() {
+		logging.VerifierLogger.Errorf("verifier gave up the job of challenge %d after %d attempts", job.ChallengeId,
+			job.Attempts)
+	}
+}
@@ -0,0 +1,50 @@
+package verifier
+
+import (
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// spResponseKey identifies a piece served by a storage provider.
+type spResponseKey struct {
+	objectId          string
+	segmentIndex      uint32
+	spOperatorAddress string
+}
+
+// spResponse is the root hash computed from the response of a storage provider to a challenge, the piece data is not
+// kept.
+type spResponse struct {
+	rootHash  []byte
+	latencyMs int64
+	height    uint64 // the block height when the response was received
+}
+
+// spResponseCache reuses the responses of the storage providers to the challenges of the same piece for a number of
+// blocks, after which the piece is requested again.
+type spResponseCache struct {
+	cache  *lru.Cache
+	blocks uint64
+}
+
+func newSpResponseCache(size int, blocks uint64) *spResponseCache {
+	cache, _ := lru.New(size)
+	return &spResponseCache{cache: cache, blocks: blocks}
+}
+
+// get returns the response cached for the piece, unless it was received blocks or more before currentHeight.
+func (c *spResponseCache) get(key spResponseKey, currentHeight uint64) (*spResponse, bool) {
+	value, ok := c.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	response := value.(*spResponse)
+	if currentHeight >= response.height+c.blocks {
+		c.cache.Remove(key)
+		return nil, false
+	}
+	return response, true
+}
+
+func (c *spResponseCache) add(key spResponseKey, response *spResponse) {
+	c.cache.Add(key, response)
+}
@@ -0,0 +1,43 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// set with -ldflags at build time, see the Makefile
+var (
+	AppVersion    = "unknown"
+	GitCommit     = "unknown"
+	GitCommitDate = "unknown"
+	BuildDate     = "unknown"
+)
+
+// ChainVersions are the greenfield chain releases the challenger is built and tested against, comma separated.
+var ChainVersions = "v1.0"
+
+// Info is the build information of the binary.
+type Info struct {
+	Version       string   `json:"version"`
+	GitCommit     string   `json:"git_commit"`
+	GitCommitDate string   `json:"git_commit_date"`
+	BuildDate     string   `json:"build_date"`
+	ChainVersions []string `json:"chain_versions"`
+}
+
+// Get returns the build information of the binary.
+func Get() Info {
+	return Info{
+		Version:       AppVersion,
+		GitCommit:     GitCommit,
+		GitCommitDate: GitCommitDate,
+		BuildDate:     BuildDate,
+		ChainVersions: strings.Split(ChainVersions, ","),
+	}
+}
+
+// String formats the build information on a single line, for the startup banner and the version command.
+func (i Info) String() string {
+	return fmt.Sprintf("greenfield-challenger %s, commit %s (%s), built %s, supported chain versions %s",
+		i.Version, i.GitCommit, i.GitCommitDate, i.BuildDate, strings.Join(i.ChainVersions, ", "))
+}
@@ -5,9 +5,11 @@ import "time"
 const (
 	ValidatorsCapacity = 256
 
-	RetryInterval        = 1 * time.Second
 	BroadcastInterval    = 10 * time.Second
 	CollectVotesInterval = 5 * time.Second
 	CollateVotesInterval = 2 * time.Second
-	BatchSize            = 20 // to fetch records from database in batch
+	BatchSize            = 20     // to fetch records from database in batch
+	KnownVotesCacheSize  = 100000 // votes of about 1000 events voted by 100 validators
+
+	EstimatedBlockTime = 2 * time.Second // to estimate when an event expires until a block interval is observed
 )
@@ -2,6 +2,7 @@ package vote
 
 import (
 	"encoding/hex"
+	"sync"
 	"time"
 
 	"github.com/bnb-chain/greenfield-challenger/db/model"
@@ -17,22 +18,34 @@ func DtoToEntity(v *model.Vote) (*votepool.Vote, error) {
 	if err != nil {
 		return nil, err
 	}
-	res := votepool.Vote{}
-	res.EventType = votepool.EventType(v.EventType)
-	res.PubKey = append(res.PubKey, pubKeyBts...)
-	res.Signature = append(res.Signature, sigBts...)
-	res.EventHash = append(res.EventHash, v.EventHash...)
-	return &res, nil
+	eventHashBts, err := hex.DecodeString(v.EventHash)
+	if err != nil {
+		return nil, err
+	}
+	return &votepool.Vote{
+		EventType: votepool.EventType(v.EventType),
+		PubKey:    pubKeyBts,
+		Signature: sigBts,
+		EventHash: eventHashBts,
+	}, nil
 }
 
 func EntityToDto(from *votepool.Vote, challengeId uint64) *model.Vote {
-	v := model.Vote{
+	return fillDto(new(model.Vote), from, challengeId)
+}
+
+// dtoPool reuses the records of the votes which are only saved, e.g. the votes collected from the votepool.
+var dtoPool = sync.Pool{New: func() interface{} { return new(model.Vote) }}
+
+// fillDto sets v to the record of the vote and returns it.
+func fillDto(v *model.Vote, from *votepool.Vote, challengeId uint64) *model.Vote {
+	*v = model.Vote{
 		ChallengeId: challengeId,
 		PubKey:      hex.EncodeToString(from.PubKey),
-		Signature:   hex.EncodeToString(from.Signature[:]),
+		Signature:   hex.EncodeToString(from.Signature),
 		EventType:   uint32(from.EventType),
 		EventHash:   hex.EncodeToString(from.EventHash),
 		CreatedTime: time.Now().Unix(),
 	}
-	return &v
+	return v
 }
@@ -0,0 +1,22 @@
+package vote
+
+import (
+	"testing"
+
+	"github.com/cometbft/cometbft/votepool"
+	"github.com/prysmaticlabs/prysm/crypto/bls/blst"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDtoToEntity(t *testing.T) {
+	key, err := blst.RandKey()
+	require.NoError(t, err)
+	var v votepool.Vote
+	v.EventType = votepool.DataAvailabilityChallengeEvent
+	NewVoteSigner(key.Marshal()).SignVote(&v, CalculateEventHash(benchEvent(), benchChainId))
+
+	decoded, err := DtoToEntity(EntityToDto(&v, 1))
+	require.NoError(t, err)
+	require.Equal(t, &v, decoded)
+	require.NoError(t, VerifySignature(decoded, decoded.EventHash))
+}
@@ -1,6 +1,8 @@
 package vote
 
 import (
+	"context"
+
 	"github.com/bnb-chain/greenfield-challenger/db/dao"
 	"github.com/bnb-chain/greenfield-challenger/db/model"
 	"github.com/bnb-chain/greenfield-challenger/executor"
@@ -8,18 +10,27 @@ import (
 )
 
 type DataProvider interface {
-	FetchEventsForSelfVote(currentHeight uint64) ([]*model.Event, uint64, error)
-	FetchEventsForCollate(currentHeight uint64) ([]*model.Event, error)
-	FetchVotesForCollate(eventHash string) ([]*model.Vote, error)
-	UpdateEventStatus(challengeId uint64, status model.EventStatus) error
-	SaveVote(vote *model.Vote) error
-	SaveVoteAndUpdateEventStatus(vote *model.Vote, challengeId uint64) error
-	IsVoteExists(eventHash string, pubKey string) (bool, error)
+	FetchEventsForSelfVote(ctx context.Context, currentHeight uint64) ([]*model.Event, uint64, error)
+	FetchEventsForCollate(ctx context.Context, currentHeight uint64) ([]*model.Event, error)
+	CountVotesForCollate(ctx context.Context, eventHash string) (int64, error)
+	UpdateEventStatus(ctx context.Context, challengeId uint64, status model.EventStatus) error
+	ExpireEvent(ctx context.Context, event *model.Event) error
+	SaveVote(ctx context.Context, vote *model.Vote) error
+	SaveVoteAndUpdateEventStatus(ctx context.Context, vote *model.Vote, challengeId uint64) error
+	IsVoteExists(ctx context.Context, eventHash string, pubKey string) (bool, error)
+	FetchUnexpiredEvents(ctx context.Context, currentHeight uint64, status model.EventStatus) ([]*model.Event, error)
+	FetchVotesByEventHashes(ctx context.Context, eventHashes []string) ([]*model.Vote, error)
+}
+
+// Sharder tells which shards of the events the challenger processes, when the events are sharded across challengers.
+type Sharder interface {
+	ShardFilter() dao.ShardFilter
 }
 
 type DataHandler struct {
 	daoManager        *dao.DaoManager
 	executor          *executor.Executor
+	sharder           Sharder
 	lastIdForSelfVote uint64 // some events' status will do not change anymore, so we need to skip them
 }
 
@@ -30,16 +41,29 @@ func NewDataHandler(daoManager *dao.DaoManager, executor *executor.Executor) *Da
 	}
 }
 
-func (h *DataHandler) FetchEventsForSelfVote(currentHeight uint64) ([]*model.Event, uint64, error) {
-	events, err := h.daoManager.GetUnexpiredEventsByStatus(currentHeight, model.Verified)
+// SetSharder restricts the fetched events to the shards held by the challenger.
+func (h *DataHandler) SetSharder(sharder Sharder) {
+	h.sharder = sharder
+}
+
+// fetchEvents returns the unexpired events in the status, of the held shards if the events are sharded.
+func (h *DataHandler) fetchEvents(ctx context.Context, currentHeight uint64, status model.EventStatus) ([]*model.Event, error) {
+	if h.sharder != nil {
+		return h.daoManager.GetUnexpiredEventsByStatusInShards(ctx, currentHeight, status, h.sharder.ShardFilter())
+	}
+	return h.daoManager.GetUnexpiredEventsByStatus(ctx, currentHeight, status)
+}
+
+func (h *DataHandler) FetchEventsForSelfVote(ctx context.Context, currentHeight uint64) ([]*model.Event, uint64, error) {
+	events, err := h.fetchEvents(ctx, currentHeight, model.Verified)
 	if err != nil {
-		logging.Logger.Errorf("failed to fetch events for self vote, err=%+v", err.Error())
+		logging.VoteLogger.Errorf("failed to fetch events for self vote, err=%+v", err.Error())
 		return nil, 0, err
 	}
 	heartbeatInterval, err := h.executor.QueryChallengeHeartbeatInterval()
-	logging.Logger.Infof("heartbeat interval is %d", heartbeatInterval)
+	logging.VoteLogger.Infof("heartbeat interval is %d", heartbeatInterval)
 	if err != nil {
-		logging.Logger.Errorf("error querying heartbeat interval, err=%+v", err.Error())
+		logging.VoteLogger.Errorf("error querying heartbeat interval, err=%+v", err.Error())
 		return nil, 0, err
 	}
 	result := make([]*model.Event, 0)
@@ -57,26 +81,38 @@ func (h *DataHandler) FetchEventsForSelfVote(currentHeight uint64) ([]*model.Eve
 	return result, uint64(heartbeatEventCount), nil
 }
 
-func (h *DataHandler) FetchEventsForCollate(currentHeight uint64) ([]*model.Event, error) {
-	return h.daoManager.GetUnexpiredEventsByStatus(currentHeight, model.SelfVoted)
+func (h *DataHandler) FetchEventsForCollate(ctx context.Context, currentHeight uint64) ([]*model.Event, error) {
+	return h.fetchEvents(ctx, currentHeight, model.SelfVoted)
+}
+
+func (h *DataHandler) CountVotesForCollate(ctx context.Context, eventHash string) (int64, error) {
+	return h.daoManager.CountVotesByEventHash(ctx, eventHash)
+}
+
+func (h *DataHandler) UpdateEventStatus(ctx context.Context, challengeId uint64, status model.EventStatus) error {
+	return h.daoManager.UpdateEventStatusByChallengeId(ctx, challengeId, status)
+}
+
+func (h *DataHandler) ExpireEvent(ctx context.Context, event *model.Event) error {
+	return h.daoManager.ExpireEvent(ctx, event)
 }
 
-func (h *DataHandler) FetchVotesForCollate(eventHash string) ([]*model.Vote, error) {
-	return h.daoManager.GetVotesByEventHash(eventHash)
+func (h *DataHandler) SaveVote(ctx context.Context, vote *model.Vote) error {
+	return h.daoManager.SaveVote(ctx, vote)
 }
 
-func (h *DataHandler) UpdateEventStatus(challengeId uint64, status model.EventStatus) error {
-	return h.daoManager.UpdateEventStatusByChallengeId(challengeId, status)
+func (h *DataHandler) SaveVoteAndUpdateEventStatus(ctx context.Context, vote *model.Vote, challengeId uint64) error {
+	return h.daoManager.SaveVoteAndUpdateEventStatus(ctx, vote, challengeId)
 }
 
-func (h *DataHandler) SaveVote(vote *model.Vote) error {
-	return h.daoManager.SaveVote(vote)
+func (h *DataHandler) IsVoteExists(ctx context.Context, eventHash string, pubKey string) (bool, error) {
+	return h.daoManager.IsVoteExists(ctx, eventHash, pubKey)
 }
 
-func (h *DataHandler) SaveVoteAndUpdateEventStatus(vote *model.Vote, challengeId uint64) error {
-	return h.daoManager.SaveVoteAndUpdateEventStatus(vote, challengeId)
+func (h *DataHandler) FetchUnexpiredEvents(ctx context.Context, currentHeight uint64, status model.EventStatus) ([]*model.Event, error) {
+	return h.fetchEvents(ctx, currentHeight, status)
 }
 
-func (h *DataHandler) IsVoteExists(eventHash string, pubKey string) (bool, error) {
-	return h.daoManager.IsVoteExists(eventHash, pubKey)
+func (h *DataHandler) FetchVotesByEventHashes(ctx context.Context, eventHashes []string) ([]*model.Vote, error) {
+	return h.daoManager.GetVotesByEventHashes(ctx, eventHashes)
 }
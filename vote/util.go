@@ -3,8 +3,10 @@ package vote
 import (
 	"encoding/binary"
 	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
 
-	sdkmath "cosmossdk.io/math"
 	"github.com/bnb-chain/greenfield-challenger/db/model"
 	"github.com/bnb-chain/greenfield-challenger/logging"
 	challengetypes "github.com/bnb-chain/greenfield/x/challenge/types"
@@ -12,13 +14,14 @@ import (
 	"github.com/cometbft/cometbft/votepool"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/pkg/errors"
 	"github.com/prysmaticlabs/prysm/crypto/bls"
 	"github.com/willf/bitset"
 )
 
-// verifySignature verifies vote signature
-func verifySignature(vote *votepool.Vote, eventHash []byte) error {
+// VerifySignature verifies the bls signature of the vote on eventHash.
+func VerifySignature(vote *votepool.Vote, eventHash []byte) error {
 	blsPubKey, err := bls.PublicKeyFromBytes(vote.PubKey)
 	if err != nil {
 		return errors.Wrap(err, "convert public key from bytes to bls failed")
@@ -43,47 +46,117 @@ func AggregateSignatureAndValidatorBitSet(votes []*model.Vote, validators []*tmt
 		signatures = append(signatures, common.Hex2Bytes(v.Signature))
 	}
 
+	// the keys of the validators are encoded in a single buffer, the lookups do not allocate
+	var keyBuf []byte
 	for idx, valInfo := range validators {
-		if _, ok := voteAddrSet[hex.EncodeToString(valInfo.BlsKey[:])]; ok {
+		if len(keyBuf) < hex.EncodedLen(len(valInfo.BlsKey)) {
+			keyBuf = make([]byte, hex.EncodedLen(len(valInfo.BlsKey)))
+		}
+		n := hex.Encode(keyBuf, valInfo.BlsKey)
+		if _, ok := voteAddrSet[string(keyBuf[:n])]; ok {
 			valBitSet.Set(uint(idx))
 		}
 	}
 
 	sigs, err := bls.MultipleSignaturesFromBytes(signatures)
 	if err != nil {
-		logging.Logger.Errorf("signature aggregator failed to generate multiple signatures from bytes, err=%+v", err.Error())
+		logging.VoteLogger.Errorf("signature aggregator failed to generate multiple signatures from bytes, err=%+v", err.Error())
 		return nil, valBitSet, err
 	}
 	return bls.AggregateSignatures(sigs).Marshal(), valBitSet, nil
 }
 
+// EventHash returns the hash voted for the verified event, as saved with its verify result, or calculated for the
+// events verified before the hash was saved.
+func EventHash(event *model.Event, chainId string) []byte {
+	if event.EventHash != "" {
+		if eventHash, err := hex.DecodeString(event.EventHash); err == nil {
+			return eventHash
+		}
+	}
+	return CalculateEventHash(event, chainId)
+}
+
+// eventHasher holds the buffers of CalculateEventHash, they are pooled since an event hash is calculated for every
+// verified event and every vote.
+type eventHasher struct {
+	buf      []byte
+	hex      [2 * sdk.EthAddressLength]byte // the hex address being decoded
+	objectId big.Int
+	keccak   crypto.KeccakState
+}
+
+var eventHashers = sync.Pool{New: func() interface{} {
+	return &eventHasher{buf: make([]byte, 0, 128), keccak: crypto.NewKeccakState()}
+}}
+
+// CalculateEventHash hashes the chain id, the challenge id, the object id, the vote result, the sp and the challenger
+// of the verified event, the bytes are appended to a pooled buffer and hashed by a pooled hasher.
 func CalculateEventHash(event *model.Event, chainId string) []byte {
-	challengeIdBz := make([]byte, 8)
-	binary.BigEndian.PutUint64(challengeIdBz, event.ChallengeId)
-	objectIdBz := sdkmath.NewUintFromString(event.ObjectId).Bytes()
-	resultBz := make([]byte, 8)
+	var result challengetypes.VoteResult
 	if event.VerifyResult == model.HashMismatched {
-		binary.BigEndian.PutUint64(resultBz, uint64(challengetypes.CHALLENGE_SUCCEED))
+		result = challengetypes.CHALLENGE_SUCCEED
 	} else if event.VerifyResult == model.HashMatched {
-		binary.BigEndian.PutUint64(resultBz, uint64(challengetypes.CHALLENGE_FAILED))
+		result = challengetypes.CHALLENGE_FAILED
 	} else {
 		panic("cannot convert vote option")
 	}
+	h := eventHashers.Get().(*eventHasher)
+	defer eventHashers.Put(h)
 
-	spOperatorBz := sdk.MustAccAddressFromHex(event.SpOperatorAddress).Bytes()
-	challengerBz := make([]byte, 0)
+	bs := append(h.buf[:0], chainId...)
+	bs = binary.BigEndian.AppendUint64(bs, event.ChallengeId)
+	bs = appendObjectId(bs, &h.objectId, event.ObjectId)
+	bs = binary.BigEndian.AppendUint64(bs, uint64(result))
+	bs = appendHexAddress(bs, &h.hex, event.SpOperatorAddress)
 	if event.ChallengerAddress != "" {
-		challengerBz = sdk.MustAccAddressFromHex(event.ChallengerAddress).Bytes()
+		bs = appendHexAddress(bs, &h.hex, event.ChallengerAddress)
 	}
-	chainIdBz := []byte(chainId)
-
-	bs := make([]byte, 0)
-	bs = append(bs, chainIdBz...)
-	bs = append(bs, challengeIdBz...)
-	bs = append(bs, objectIdBz...)
-	bs = append(bs, resultBz...)
-	bs = append(bs, spOperatorBz...)
-	bs = append(bs, challengerBz...)
-	hash := sdk.Keccak256Hash(bs)
-	return hash[:]
+	h.buf = bs
+
+	h.keccak.Reset()
+	h.keccak.Write(bs) //nolint:errcheck
+	eventHash := make([]byte, 32)
+	h.keccak.Read(eventHash) //nolint:errcheck
+	return eventHash
+}
+
+// appendObjectId appends the big endian bytes of the object id, as sdkmath.Uint.Bytes, parsed into x. It panics on an
+// invalid object id like sdkmath.NewUintFromString.
+func appendObjectId(bs []byte, x *big.Int, objectId string) []byte {
+	if _, ok := x.SetString(objectId, 10); !ok || x.Sign() < 0 || x.BitLen() > 256 {
+		panic(fmt.Sprintf("invalid object id %q", objectId))
+	}
+	n := (x.BitLen() + 7) / 8
+	bs = append(bs, make([]byte, n)...)
+	x.FillBytes(bs[len(bs)-n:])
+	return bs
+}
+
+// appendHexAddress appends the bytes of the hex address, copied into buf to be decoded, it panics on an invalid
+// address like sdk.MustAccAddressFromHex.
+func appendHexAddress(bs []byte, buf *[2 * sdk.EthAddressLength]byte, address string) []byte {
+	if len(address) >= 2 && address[0] == '0' && (address[1] == 'x' || address[1] == 'X') {
+		address = address[2:]
+	}
+	if len(address) != len(buf) {
+		panic(fmt.Sprintf("invalid address hex length: %v != %v", len(address), len(buf)))
+	}
+	copy(buf[:], address)
+	n := len(bs)
+	bs = append(bs, make([]byte, sdk.EthAddressLength)...)
+	if _, err := hex.Decode(bs[n:], buf[:]); err != nil {
+		panic(fmt.Sprintf("invalid hex address %q", address))
+	}
+	return bs
+}
+
+// eventLogger logs with the fields of event in stage, so that the records of a challenge can be correlated.
+func eventLogger(event *model.Event, stage string) *logging.FieldLogger {
+	return logging.WithFields(logging.VoteLogger, event.LogFields(stage))
+}
+
+// challengeKey identifies the events of the pipeline stages by challenge.
+func challengeKey(event *model.Event) uint64 {
+	return event.ChallengeId
 }
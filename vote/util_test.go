@@ -0,0 +1,135 @@
+package vote
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	tmtypes "github.com/cometbft/cometbft/types"
+	"github.com/cometbft/cometbft/votepool"
+	"github.com/prysmaticlabs/prysm/crypto/bls/blst"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+)
+
+const benchChainId = "greenfield_1017-1"
+
+func benchEvent() *model.Event {
+	return &model.Event{
+		ChallengeId:       123456,
+		ObjectId:          "987654321",
+		SegmentIndex:      3,
+		SpOperatorAddress: "0x9A4B1c1dBA2A2AAd1F0d6Ac1A2b07D7cF0e3fd71",
+		ChallengerAddress: "0x76d244CE05c3De4BbC6fDd7F56379B145709ade9",
+		VerifyResult:      model.HashMismatched,
+	}
+}
+
+// benchVotes returns the signed votes of n validators on eventHash, and the validators.
+func benchVotes(t testing.TB, n int, eventHash []byte) ([]*model.Vote, []*tmtypes.Validator) {
+	votes := make([]*model.Vote, 0, n)
+	validators := make([]*tmtypes.Validator, 0, n)
+	for i := 0; i < n; i++ {
+		key, err := blst.RandKey()
+		require.NoError(t, err)
+		var v votepool.Vote
+		NewVoteSigner(key.Marshal()).SignVote(&v, eventHash)
+		votes = append(votes, EntityToDto(&v, 1))
+		validator := tmtypes.NewValidator(ed25519.GenPrivKey().PubKey(), 1)
+		validator.BlsKey = v.PubKey
+		validators = append(validators, validator)
+	}
+	return votes, validators
+}
+
+func TestCalculateEventHash(t *testing.T) {
+	event := benchEvent()
+	require.Equal(t, "ca68990f5d002a5cebc8f90e9f23edd5114b4fa1eda5a3f461c1d80f9b6a5403", hex.EncodeToString(CalculateEventHash(event, benchChainId)))
+	event.VerifyResult = model.HashMatched
+	require.Equal(t, "256de5a807c99d50db7f50737103fc5e5fc5e588d3a817f8d5cdc87204412412", hex.EncodeToString(CalculateEventHash(event, benchChainId)))
+	event.ChallengerAddress = ""
+	require.Equal(t, "ce94d03de8ae0d1e36d4198d4ceccc5f23bd2f4c75c75b1beced3c9c9a37284b", hex.EncodeToString(CalculateEventHash(event, benchChainId)))
+}
+
+func TestCalculateEventHash_Invalid(t *testing.T) {
+	event := benchEvent()
+	event.SpOperatorAddress = "0x9A4B1c1dBA2A2AAd1F0d6Ac1A2b07D7cF0e3fd7"
+	require.Panics(t, func() { CalculateEventHash(event, benchChainId) })
+	event = benchEvent()
+	event.ChallengerAddress = "0x76d244CE05c3De4BbC6fDd7F56379B145709adeZ"
+	require.Panics(t, func() { CalculateEventHash(event, benchChainId) })
+	event = benchEvent()
+	event.ObjectId = "-1"
+	require.Panics(t, func() { CalculateEventHash(event, benchChainId) })
+}
+
+func TestAggregateSignatureAndValidatorBitSet(t *testing.T) {
+	eventHash := CalculateEventHash(benchEvent(), benchChainId)
+	votes, validators := benchVotes(t, 4, eventHash)
+	// the third validator did not vote
+	_, bitSet, err := AggregateSignatureAndValidatorBitSet(append(votes[:2:2], votes[3]), validators)
+	require.NoError(t, err)
+	require.True(t, bitSet.Test(0))
+	require.True(t, bitSet.Test(1))
+	require.False(t, bitSet.Test(2))
+	require.True(t, bitSet.Test(3))
+	require.Equal(t, uint(3), bitSet.Count())
+}
+
+func BenchmarkCalculateEventHash(b *testing.B) {
+	event := benchEvent()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		CalculateEventHash(event, benchChainId)
+	}
+}
+
+func BenchmarkSignVote(b *testing.B) {
+	key, err := blst.RandKey()
+	require.NoError(b, err)
+	signer := NewVoteSigner(key.Marshal())
+	eventHash := CalculateEventHash(benchEvent(), benchChainId)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var v votepool.Vote
+		signer.SignVote(&v, eventHash)
+	}
+}
+
+func BenchmarkAggregateSignatureAndValidatorBitSet(b *testing.B) {
+	eventHash := CalculateEventHash(benchEvent(), benchChainId)
+	votes, validators := benchVotes(b, 21, eventHash)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := AggregateSignatureAndValidatorBitSet(votes, validators); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEntityToDto(b *testing.B) {
+	eventHash := CalculateEventHash(benchEvent(), benchChainId)
+	votes, _ := benchVotes(b, 1, eventHash)
+	v, err := DtoToEntity(votes[0])
+	require.NoError(b, err)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		EntityToDto(v, 1)
+	}
+}
+
+func BenchmarkDtoToEntity(b *testing.B) {
+	eventHash := CalculateEventHash(benchEvent(), benchChainId)
+	votes, _ := benchVotes(b, 1, eventHash)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DtoToEntity(votes[0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
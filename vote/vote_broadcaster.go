@@ -1,103 +1,169 @@
 package vote
 
 import (
+	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	lru "github.com/hashicorp/golang-lru"
 	"strings"
 	"time"
 
 	"github.com/bnb-chain/greenfield-challenger/metrics"
 
+	"github.com/avast/retry-go/v4"
+	"github.com/bnb-chain/greenfield-challenger/audit"
 	"github.com/bnb-chain/greenfield-challenger/common"
 	"github.com/bnb-chain/greenfield-challenger/config"
 	"github.com/bnb-chain/greenfield-challenger/db/model"
 	"github.com/bnb-chain/greenfield-challenger/executor"
+	"github.com/bnb-chain/greenfield-challenger/health"
 	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/pipeline"
+	"github.com/bnb-chain/greenfield-challenger/tracing"
+	"github.com/bnb-chain/greenfield-challenger/webhook"
 	"github.com/cometbft/cometbft/votepool"
 )
 
 type VoteBroadcaster struct {
 	config          *config.Config
 	signer          *VoteSigner
+	identitySigners []*VoteSigner // signers of the other identities run by the challenger
 	executor        *executor.Executor
 	blsPublicKey    []byte
-	cachedLocalVote *lru.Cache
+	cachedLocalVote *common.EventCache
 	dataProvider    DataProvider
 	metricService   *metrics.MetricService
+	stage           *pipeline.Stage[*model.Event]
 }
 
 func NewVoteBroadcaster(cfg *config.Config, signer *VoteSigner,
 	executor *executor.Executor, broadcasterDataProvider DataProvider, metricService *metrics.MetricService,
 ) *VoteBroadcaster {
-	cacheSize := 1000
-	lruCache, _ := lru.New(cacheSize)
-
-	return &VoteBroadcaster{
+	p := &VoteBroadcaster{
 		config:          cfg,
 		signer:          signer,
 		executor:        executor,
 		dataProvider:    broadcasterDataProvider,
-		cachedLocalVote: lruCache,
-		blsPublicKey:    executor.BlsPubKey,
+		cachedLocalVote: common.NewEventCache(cfg.PipelineConfig.CacheSize),
+		blsPublicKey:    executor.GetBlsPubKey(),
 		metricService:   metricService,
 	}
+	p.stage = pipeline.NewStage[*model.Event](logging.StageBroadcast, pipeline.Funcs[*model.Event]{
+		FetchFunc:   p.fetchEvents,
+		ProcessFunc: p.broadcast,
+		KeyFunc:     challengeKey,
+		ExpiryFunc:  p.expiry,
+	}, pipeline.Options{
+		Workers:   cfg.PipelineConfig.BroadcasterConcurrency(),
+		QueueSize: cfg.PipelineConfig.Queue(),
+		Beat:      func() { health.Beat(health.LoopBroadcaster) },
+	}, metricService)
+	return p
 }
 
-func (p *VoteBroadcaster) BroadcastVotesLoop() {
-	for {
-		currentHeight := p.executor.GetCachedBlockHeight()
-		events, heartbeatEventCount, err := p.dataProvider.FetchEventsForSelfVote(currentHeight)
-		if err != nil {
-			p.metricService.IncBroadcasterErr(err)
-			logging.Logger.Errorf("vote processor failed to fetch unexpired events to collate votes, err=%+v", err.Error())
-			continue
-		}
-		if len(events) == 0 {
-			time.Sleep(RetryInterval)
-			continue
-		}
-		if heartbeatEventCount != 0 {
-			for i := uint64(0); i < heartbeatEventCount; i++ {
-				p.metricService.IncHeartbeatEvents()
-			}
-		}
+// AddSigner adds the signer of another identity, every event is voted by each identity.
+func (p *VoteBroadcaster) AddSigner(signer *VoteSigner) {
+	p.identitySigners = append(p.identitySigners, signer)
+}
 
-		for _, event := range events {
-			localVote, found := p.cachedLocalVote.Get(event.ChallengeId)
-
-			if !found {
-				localVote, err = p.constructVoteAndSign(event)
-				if err != nil {
-					if strings.Contains(err.Error(), "Duplicate") {
-						logging.Logger.Errorf("[non-blocking error] broadcaster was trying to save a duplicated vote after clearing cache for challengeId: %d, err=%+v", event.ChallengeId, err.Error())
-					} else {
-						p.metricService.IncBroadcasterErr(err)
-						logging.Logger.Errorf("broadcaster ran into error trying to construct vote for challengeId: %d, err=%+v", event.ChallengeId, err.Error())
-						continue
-					}
-				}
-				p.cachedLocalVote.Add(event.ChallengeId, localVote)
-				// Incrementing this before broadcasting to prevent the same challengeID from being incremented multiple times
-				// does not mean that it has been successfully broadcasted, check error metrics for broadcast errors.
-				p.metricService.IncBroadcastedChallenges()
-				logging.Logger.Infof("broadcaster metrics increased for challengeId %d", event.ChallengeId)
-			}
+// Forget drops the cached local vote of the challenge, so that a reset event is voted on its new verify result.
+func (p *VoteBroadcaster) Forget(challengeId uint64) {
+	p.cachedLocalVote.Remove(challengeId)
+}
+
+// BroadcasterCache is the state of the vote broadcaster, as dumped by the admin api.
+type BroadcasterCache struct {
+	LocalVotes []uint64 `json:"local_votes"` // challenges whose signed votes are cached and broadcast again
+}
+
+// DumpCache returns the challenges whose local vote is cached.
+func (p *VoteBroadcaster) DumpCache() interface{} {
+	return BroadcasterCache{LocalVotes: p.cachedLocalVote.ChallengeIds()}
+}
 
-			err = p.broadcastForSingleEvent(localVote.(*votepool.Vote), event)
-			if err != nil {
+// BroadcastVotesLoop votes the verified events with the workers of the broadcaster until ctx is done, once the votes
+// saved before are warmed up.
+func (p *VoteBroadcaster) BroadcastVotesLoop(ctx context.Context) {
+	p.warmUp(ctx)
+	p.stage.Run(ctx)
+}
+
+func (p *VoteBroadcaster) fetchEvents(ctx context.Context) ([]*model.Event, error) {
+	currentHeight := p.executor.GetCachedBlockHeight()
+	p.cachedLocalVote.EvictExpired(currentHeight)
+	events, _, err := p.dataProvider.FetchEventsForSelfVote(ctx, currentHeight)
+	if err != nil {
+		p.metricService.IncBroadcasterErr(err)
+		logging.VoteLogger.Errorf("vote processor failed to fetch unexpired events to collate votes, err=%+v", err.Error())
+		return nil, err
+	}
+	return events, nil
+}
+
+// broadcast signs and saves the votes of the event unless they are cached, and broadcasts them. The votes are useless
+// once the event expired, so the event is given up at its estimated expiry rather than holding a worker.
+func (p *VoteBroadcaster) broadcast(ctx context.Context, event *model.Event) error {
+	ctx, cancel := context.WithDeadline(ctx, p.expiry(event))
+	defer cancel()
+	localVotes, found := p.cachedLocalVote.Get(event.ChallengeId)
+	if !found {
+		var err error
+		localVotes, err = p.constructVoteAndSign(ctx, event)
+		if err != nil {
+			if strings.Contains(err.Error(), "Duplicate") {
+				eventLogger(event, logging.StageBroadcast).Errorf("[non-blocking error] broadcaster was trying to save a duplicated vote after clearing cache, err=%+v", err.Error())
+			} else {
 				p.metricService.IncBroadcasterErr(err)
-				continue
+				eventLogger(event, logging.StageBroadcast).Errorf("broadcaster ran into error trying to construct vote, err=%+v", err.Error())
+				return err
 			}
-			time.Sleep(50 * time.Millisecond)
 		}
+		p.cachedLocalVote.Add(event.ChallengeId, event.ExpiredHeight, localVotes)
+		// Incrementing this before broadcasting to prevent the same challengeID from being incremented multiple times
+		// does not mean that it has been successfully broadcasted, check error metrics for broadcast errors.
+		p.metricService.IncBroadcastedChallenges()
+		if event.VerifyResult != model.HashMismatched {
+			p.metricService.IncHeartbeatEvents()
+		}
+		eventLogger(event, logging.StageBroadcast).Infof("broadcaster metrics increased")
+	}
+
+	err := p.broadcastForSingleEvent(ctx, localVotes.([]*votepool.Vote), event, !found)
+	if err != nil {
+		p.metricService.IncBroadcasterErr(err)
+		return err
+	}
+	if found {
+		// the votes were saved without the status, e.g. warmed up after a restart, the event is voted once broadcast
+		if err = p.dataProvider.UpdateEventStatus(ctx, event.ChallengeId, model.SelfVoted); err != nil {
+			p.metricService.IncBroadcasterErr(err)
+			return err
+		}
+	}
+	return nil
+}
 
-		time.Sleep(RetryInterval)
+// eventExpiry estimates when the event expires from the blocks left before its expired height, with the observed
+// block interval or EstimatedBlockTime until one is observed.
+func eventExpiry(currentHeight uint64, event *model.Event) time.Time {
+	if currentHeight >= event.ExpiredHeight {
+		return time.Now()
 	}
+	blockTime := common.BlockInterval()
+	if blockTime == 0 {
+		blockTime = EstimatedBlockTime
+	}
+	return time.Now().Add(time.Duration(event.ExpiredHeight-currentHeight) * blockTime)
 }
 
-func (p *VoteBroadcaster) broadcastForSingleEvent(localVote *votepool.Vote, event *model.Event) error {
+func (p *VoteBroadcaster) broadcastForSingleEvent(ctx context.Context, localVotes []*votepool.Vote, event *model.Event, traced bool) (err error) {
+	// only the first broadcast of a vote is traced, the later ones repeat it for the validators which missed it
+	if traced {
+		_, span := tracing.StartEventSpan(ctx, event, tracing.SpanVoteBroadcast)
+		defer func() { tracing.EndSpan(span, err) }()
+	}
 	startTime := time.Now()
-	err := p.preCheck(event)
+	err = p.preCheck(event)
 	if err != nil {
 		if err.Error() == common.ErrEventExpired.Error() {
 			p.cachedLocalVote.Remove(event.ChallengeId)
@@ -106,12 +172,38 @@ func (p *VoteBroadcaster) broadcastForSingleEvent(localVote *votepool.Vote, even
 		return err
 	}
 
-	logging.Logger.Infof("broadcaster starting time for challengeId: %d %s", event.ChallengeId, time.Now().Format("15:04:05.000000"))
-	err = p.executor.BroadcastVote(localVote)
-	if err != nil {
-		return fmt.Errorf("failed to broadcast vote for challengeId: %d", event.ChallengeId)
+	eventLogger(event, logging.StageBroadcast).Infof("broadcaster started")
+	// the event is self voted once its vote is saved, so a vote lost to a transient error is not broadcast again. A
+	// transport error is retried with a backoff, a rejected vote and an expired event are not.
+	for _, localVote := range localVotes {
+		err = retry.Do(func() error {
+			return p.executor.BroadcastVote(localVote)
+		}, retry.Context(ctx), common.RtyAttem, common.RtyDelay, common.RtyErr, retry.DelayType(retry.BackOffDelay),
+			retry.RetryIf(func(err error) bool {
+				return !errors.Is(err, common.ErrEventExpired) && !errors.Is(err, common.ErrVotePoolRejected)
+			}))
+		if errors.Is(err, common.ErrEventExpired) && p.preCheck(event) == nil {
+			// the event is only expired once the chain is past its expiry height, not on the word of the votepool
+			eventLogger(event, logging.StageBroadcast).Errorf("broadcaster ignored the expiry reported by the votepool, expired height: %d, current height: %d",
+				event.ExpiredHeight, p.executor.GetCachedBlockHeight())
+			return err
+		}
+		if errors.Is(err, common.ErrEventExpired) {
+			eventLogger(event, logging.StageBroadcast).Infof("broadcaster gave up the vote, the event expired")
+			p.cachedLocalVote.Remove(event.ChallengeId)
+			if expireErr := p.dataProvider.ExpireEvent(ctx, event); expireErr != nil {
+				eventLogger(event, logging.StageBroadcast).Errorf("broadcaster failed to expire the event, err=%+v", expireErr.Error())
+			}
+			return common.ErrEventExpired
+		}
+		if err != nil {
+			return fmt.Errorf("failed to broadcast vote for challengeId: %d", event.ChallengeId)
+		}
+	}
+	eventLogger(event, logging.StageBroadcast).Infof("vote broadcasted")
+	if traced {
+		webhook.VoteBroadcast(event)
 	}
-	logging.Logger.Infof("vote broadcasted for challengeId: %d, height: %d", event.ChallengeId, event.Height)
 
 	// Metrics
 	elaspedTime := time.Since(startTime)
@@ -122,21 +214,66 @@ func (p *VoteBroadcaster) broadcastForSingleEvent(localVote *votepool.Vote, even
 func (p *VoteBroadcaster) preCheck(event *model.Event) error {
 	currentHeight := p.executor.GetCachedBlockHeight()
 	if currentHeight > event.ExpiredHeight {
-		logging.Logger.Infof("broadcaster for challengeId: %d has expired. expired height: %d, current height: %d, timestamp: %s", event.ChallengeId, event.ExpiredHeight, currentHeight, time.Now().Format("15:04:05.000000"))
+		eventLogger(event, logging.StageBroadcast).Infof("broadcaster for challenge has expired, expired height: %d, current height: %d", event.ExpiredHeight, currentHeight)
 		return common.ErrEventExpired
 	}
 
 	return nil
 }
 
-func (p *VoteBroadcaster) constructVoteAndSign(event *model.Event) (*votepool.Vote, error) {
-	var v votepool.Vote
-	v.EventType = votepool.DataAvailabilityChallengeEvent
-	eventHash := CalculateEventHash(event, p.config.GreenfieldConfig.ChainIdString)
-	p.signer.SignVote(&v, eventHash[:])
-	err := p.dataProvider.SaveVoteAndUpdateEventStatus(EntityToDto(&v, event.ChallengeId), event.ChallengeId)
+// constructVoteAndSign signs the votes of all identities and saves them. The votes of the other identities are saved
+// first, so that the event is self voted only once all of its votes are saved.
+func (p *VoteBroadcaster) constructVoteAndSign(ctx context.Context, event *model.Event) (_ []*votepool.Vote, err error) {
+	ctx, span := tracing.StartEventSpan(ctx, event, tracing.SpanVoteSign)
+	defer func() { tracing.EndSpan(span, err) }()
+	if err = checkVerified(event); err != nil {
+		return nil, err
+	}
+	eventHash := EventHash(event, p.config.GreenfieldConfig.ChainIdString)
+	votes := make([]*votepool.Vote, 0, len(p.identitySigners)+1)
+	for _, signer := range p.identitySigners {
+		v := signVote(signer, event, eventHash)
+		votes = append(votes, v)
+		// the vote may be saved already, if the vote of the challenger failed to be saved after it
+		exists, err := p.dataProvider.IsVoteExists(ctx, hex.EncodeToString(v.EventHash), hex.EncodeToString(v.PubKey))
+		if err != nil {
+			return votes, err
+		}
+		if exists {
+			continue
+		}
+		if err = p.dataProvider.SaveVote(ctx, EntityToDto(v, event.ChallengeId)); err != nil {
+			return votes, err
+		}
+	}
+	v := signVote(p.signer, event, eventHash)
+	votes = append(votes, v)
+	err = p.dataProvider.SaveVoteAndUpdateEventStatus(ctx, EntityToDto(v, event.ChallengeId), event.ChallengeId)
 	if err != nil {
-		return &v, err
+		return votes, err
+	}
+	return votes, nil
+}
+
+// checkVerified guards the votes against the events whose verification did not produce a result, e.g. an event
+// fetched while it was replayed, so that a default result is never signed. The event is fetched again once verified.
+func checkVerified(event *model.Event) error {
+	if event.Status != model.Verified || !event.VerifyResult.Decided() {
+		return fmt.Errorf("%w: challengeId: %d, status: %s, verify result: %s", common.ErrEventNotVerified,
+			event.ChallengeId, event.Status, event.VerifyResult)
 	}
-	return &v, nil
+	return nil
+}
+
+func signVote(signer *VoteSigner, event *model.Event, eventHash []byte) *votepool.Vote {
+	var v votepool.Vote
+	v.EventType = votepool.DataAvailabilityChallengeEvent
+	signer.SignVote(&v, eventHash[:])
+	audit.RecordVote(event.ChallengeId, eventHash, event.VerifyResult.String(), v.PubKey, v.Signature)
+	return &v
+}
+
+// expiry estimates when the event expires, a failed event is retried sooner as it gets close to its expiry.
+func (p *VoteBroadcaster) expiry(event *model.Event) time.Time {
+	return eventExpiry(p.executor.GetCachedBlockHeight(), event)
 }
@@ -0,0 +1,46 @@
+package vote
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prysmaticlabs/prysm/crypto/bls/blst"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/common"
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+)
+
+func (d *savingDataProvider) SaveVoteAndUpdateEventStatus(ctx context.Context, vote *model.Vote, _ uint64) error {
+	return d.SaveVote(ctx, vote)
+}
+
+func TestConstructVoteAndSign_Unverified(t *testing.T) {
+	key, err := blst.RandKey()
+	require.NoError(t, err)
+	dataProvider := &savingDataProvider{saved: make(map[string]bool)}
+	p := &VoteBroadcaster{
+		config:       &config.Config{GreenfieldConfig: config.GreenfieldConfig{ChainIdString: benchChainId}},
+		signer:       NewVoteSigner(key.Marshal()),
+		dataProvider: dataProvider,
+	}
+	ctx := context.Background()
+
+	for _, event := range []*model.Event{
+		{ChallengeId: 1, Status: model.Unprocessed, VerifyResult: model.Unknown},
+		{ChallengeId: 2, Status: model.Verified, VerifyResult: model.Unknown},
+		{ChallengeId: 3, Status: model.Unprocessed, VerifyResult: model.HashMismatched},
+	} {
+		_, err = p.constructVoteAndSign(ctx, event)
+		require.ErrorIs(t, err, common.ErrEventNotVerified)
+	}
+	require.Empty(t, dataProvider.saved)
+
+	event := benchEvent()
+	event.Status = model.Verified
+	votes, err := p.constructVoteAndSign(ctx, event)
+	require.NoError(t, err)
+	require.Len(t, votes, 1)
+	require.Len(t, dataProvider.saved, 1)
+}
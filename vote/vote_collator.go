@@ -1,6 +1,7 @@
 package vote
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
 	"time"
@@ -8,89 +9,136 @@ import (
 	"github.com/bnb-chain/greenfield-challenger/common"
 	"github.com/bnb-chain/greenfield-challenger/config"
 	"github.com/bnb-chain/greenfield-challenger/db/model"
-	"github.com/bnb-chain/greenfield-challenger/executor"
+	"github.com/bnb-chain/greenfield-challenger/health"
 	"github.com/bnb-chain/greenfield-challenger/logging"
 	"github.com/bnb-chain/greenfield-challenger/metrics"
+	"github.com/bnb-chain/greenfield-challenger/pipeline"
+	"github.com/bnb-chain/greenfield-challenger/tracing"
+	"github.com/bnb-chain/greenfield-challenger/webhook"
 	tmtypes "github.com/cometbft/cometbft/types"
 )
 
 type VoteCollator struct {
 	config        *config.Config
 	signer        *VoteSigner
-	executor      *executor.Executor
+	executor      CollatorExecutor
 	blsPublicKey  []byte
 	dataProvider  DataProvider
 	metricService *metrics.MetricService
+	stage         *pipeline.Stage[*model.Event]
+	attestMarker  AttestMarker
+}
+
+// CollatorExecutor queries the chain for the collator, e.g. the executor.
+type CollatorExecutor interface {
+	GetBlsPubKey() []byte
+	GetCachedBlockHeight() uint64
+	QueryCachedLatestValidators() ([]*tmtypes.Validator, error)
+	IsChallengeAttested(challengeId uint64) (bool, error)
+	ValidatorsChanged() <-chan struct{}
+}
+
+// AttestMarker marks the event of a challenge attested on chain, e.g. the attest monitor.
+type AttestMarker interface {
+	MarkAttested(ctx context.Context, challengeId uint64) bool
 }
 
 func NewVoteCollator(cfg *config.Config, signer *VoteSigner,
-	executor *executor.Executor, collatorDataProvider DataProvider, metricService *metrics.MetricService,
+	executor CollatorExecutor, collatorDataProvider DataProvider, metricService *metrics.MetricService,
 ) *VoteCollator {
-	return &VoteCollator{
+	p := &VoteCollator{
 		config:        cfg,
 		signer:        signer,
 		executor:      executor,
 		dataProvider:  collatorDataProvider,
-		blsPublicKey:  executor.BlsPubKey,
+		blsPublicKey:  executor.GetBlsPubKey(),
 		metricService: metricService,
 	}
+	// an event without enough votes yet is held back for the retry interval before it is collated again
+	p.stage = pipeline.NewStage[*model.Event](logging.StageCollate, pipeline.Funcs[*model.Event]{
+		FetchFunc:   p.fetchEvents,
+		ProcessFunc: p.collateForSingleEvent,
+		KeyFunc:     challengeKey,
+		ExpiryFunc:  p.expiry,
+	}, pipeline.Options{
+		Workers:   cfg.PipelineConfig.CollatorConcurrency(),
+		QueueSize: cfg.PipelineConfig.Queue(),
+		Pace:      common.EventInterval,
+		Beat:      func() { health.Beat(health.LoopCollator) },
+	}, metricService)
+	return p
+}
+
+// SetAttestMarker marks the events attested on chain with marker when the collator finds them attested, their status
+// is set to attested otherwise.
+func (p *VoteCollator) SetAttestMarker(marker AttestMarker) {
+	p.attestMarker = marker
+}
+
+// CollateVotesLoop collates the votes of the self voted events with the workers of the collator until ctx is done.
+func (p *VoteCollator) CollateVotesLoop(ctx context.Context) {
+	go p.watchValidators(ctx)
+	p.stage.Run(ctx)
 }
 
-func (p *VoteCollator) CollateVotesLoop() {
+// watchValidators retries the events held back once the validators change until ctx is done, the votes they lacked
+// are counted against the new validators.
+func (p *VoteCollator) watchValidators(ctx context.Context) {
 	for {
-		currentHeight := p.executor.GetCachedBlockHeight()
-		events, err := p.dataProvider.FetchEventsForCollate(currentHeight)
-		logging.Logger.Infof("vote processor fetched %d events for collate", len(events))
-		if err != nil {
-			p.metricService.IncCollatorErr(err)
-			logging.Logger.Errorf("vote processor failed to fetch unexpired events to collate votes, err=%+v", err.Error())
-			time.Sleep(RetryInterval)
-			continue
-		}
-		if len(events) == 0 {
-			time.Sleep(RetryInterval)
-			continue
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.executor.ValidatorsChanged():
 		}
+		logging.VoteLogger.Infof("validators changed, retrying the events held back by the collator")
+		p.stage.RetryHeld()
+	}
+}
 
-		for _, event := range events {
-			err = p.collateForSingleEvent(event)
-			if err != nil {
-				time.Sleep(RetryInterval)
-				continue
-			}
-			time.Sleep(50 * time.Millisecond)
-		}
-		time.Sleep(RetryInterval)
+func (p *VoteCollator) fetchEvents(ctx context.Context) ([]*model.Event, error) {
+	currentHeight := p.executor.GetCachedBlockHeight()
+	events, err := p.dataProvider.FetchEventsForCollate(ctx, currentHeight)
+	if err != nil {
+		p.metricService.IncCollatorErr(err)
+		logging.VoteLogger.Errorf("vote processor failed to fetch unexpired events to collate votes, err=%+v", err.Error())
+		return nil, err
 	}
+	logging.VoteLogger.Infof("vote processor fetched %d events for collate", len(events))
+	return events, nil
 }
 
-func (p *VoteCollator) collateForSingleEvent(event *model.Event) error {
-	err := p.preCheck(event)
+func (p *VoteCollator) collateForSingleEvent(ctx context.Context, event *model.Event) (err error) {
+	ctx, span := tracing.StartEventSpan(ctx, event, tracing.SpanVoteCollate)
+	defer func() { tracing.EndSpan(span, err) }()
+	err = p.preCheck(event)
 	if err != nil {
 		return err
 	}
+	if p.attestedOnChain(ctx, event) {
+		return nil
+	}
 	startTime := time.Now()
-
-	err = p.prepareEnoughValidVotesForEvent(event)
+	err = p.prepareEnoughValidVotesForEvent(ctx, event)
 	if err != nil {
 		return err
 	}
-	err = p.dataProvider.UpdateEventStatus(event.ChallengeId, model.EnoughVotesCollected)
+	err = p.dataProvider.UpdateEventStatus(ctx, event.ChallengeId, model.EnoughVotesCollected)
 	if err != nil {
 		p.metricService.IncCollatorErr(err)
 		return err
 	}
+	webhook.VotesCollected(event)
 
 	elaspedTime := time.Since(startTime)
 	p.metricService.SetCollatorDuration(elaspedTime)
 	p.metricService.IncCollatedChallenges()
-	logging.Logger.Infof("collator metrics increased for challengeId %d, elasped time %+v", event.ChallengeId, elaspedTime)
-	logging.Logger.Infof("collator completed time for challengeId: %d %s", event.ChallengeId, time.Now().Format("15:04:05.000000"))
+	eventLogger(event, logging.StageCollate).Infof("collator metrics increased, elasped time %+v", elaspedTime)
+	eventLogger(event, logging.StageCollate).Infof("collator completed")
 	return nil
 }
 
 // prepareEnoughValidVotesForEvent fetches and validate votes result, store in vote table
-func (p *VoteCollator) prepareEnoughValidVotesForEvent(event *model.Event) error {
+func (p *VoteCollator) prepareEnoughValidVotesForEvent(ctx context.Context, event *model.Event) error {
 	validators, err := p.executor.QueryCachedLatestValidators()
 	if err != nil {
 		p.metricService.IncCollatorErr(err)
@@ -99,17 +147,44 @@ func (p *VoteCollator) prepareEnoughValidVotesForEvent(event *model.Event) error
 	if len(validators) == 1 {
 		return nil
 	}
-	err = p.queryMoreThanTwoThirdVotesForEvent(event, validators)
+	err = p.queryMoreThanTwoThirdVotesForEvent(ctx, event, validators)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// attestedOnChain reports whether the challenge was already attested on chain, e.g. by challengers which collected
+// the votes sooner, the event is marked attested then rather than collated again until it expires.
+func (p *VoteCollator) attestedOnChain(ctx context.Context, event *model.Event) bool {
+	attested, err := p.executor.IsChallengeAttested(event.ChallengeId)
+	if err != nil {
+		// the votes are collated anyway, the attestation is checked again on chain
+		eventLogger(event, logging.StageCollate).Errorf("collator failed to query the attested challenges, err=%+v", err.Error())
+		return false
+	}
+	if !attested {
+		return false
+	}
+	eventLogger(event, logging.StageCollate).Infof("collator skipped the challenge, it was already attested")
+	if p.attestMarker != nil {
+		if !p.attestMarker.MarkAttested(ctx, event.ChallengeId) {
+			p.metricService.IncCollatorErr(fmt.Errorf("mark challenge %d attested failed", event.ChallengeId))
+			return false
+		}
+		return true
+	}
+	if err = p.dataProvider.UpdateEventStatus(ctx, event.ChallengeId, model.Attested); err != nil {
+		p.metricService.IncCollatorErr(err)
+		return false
+	}
+	return true
+}
+
 func (p *VoteCollator) preCheck(event *model.Event) error {
 	currentHeight := p.executor.GetCachedBlockHeight()
 	if currentHeight > event.ExpiredHeight {
-		logging.Logger.Infof("collator for challengeId: %d has expired. expired height: %d, current height: %d, timestamp: %s", event.ChallengeId, event.ExpiredHeight, currentHeight, time.Now().Format("15:04:05.000000"))
+		eventLogger(event, logging.StageCollate).Infof("collator for challenge has expired, expired height: %d, current height: %d", event.ExpiredHeight, currentHeight)
 		return common.ErrEventExpired
 	}
 
@@ -117,22 +192,26 @@ func (p *VoteCollator) preCheck(event *model.Event) error {
 }
 
 // queryMoreThanTwoThirdVotesForEvent queries votes from votePool
-func (p *VoteCollator) queryMoreThanTwoThirdVotesForEvent(event *model.Event, validators []*tmtypes.Validator) error {
+func (p *VoteCollator) queryMoreThanTwoThirdVotesForEvent(ctx context.Context, event *model.Event, validators []*tmtypes.Validator) error {
 	err := p.preCheck(event)
 	if err != nil {
 		return err
 	}
-	eventHash := CalculateEventHash(event, p.config.GreenfieldConfig.ChainIdString)
-	queriedVotes, err := p.dataProvider.FetchVotesForCollate(hex.EncodeToString(eventHash))
+	eventHash := EventHash(event, p.config.GreenfieldConfig.ChainIdString)
+	voteCount, err := p.dataProvider.CountVotesForCollate(ctx, hex.EncodeToString(eventHash))
 	if err != nil {
 		p.metricService.IncCollatorErr(err)
-		logging.Logger.Errorf("failed to query votes for event %d, err=%+v", event.ChallengeId, err.Error())
+		eventLogger(event, logging.StageCollate).Errorf("failed to count votes, err=%+v", err.Error())
 		return err
 	}
-	logging.Logger.Infof("collating for challengeId: %d vote count %d, timestamp %s", event.ChallengeId, len(queriedVotes), time.Now().Format("15:04:05.000000"))
-	if len(queriedVotes) > len(validators)*2/3 {
+	eventLogger(event, logging.StageCollate).Infof("collating, vote count %d", voteCount)
+	if voteCount > int64(len(validators)*2/3) {
 		return nil
 	}
-	time.Sleep(RetryInterval)
 	return fmt.Errorf("failed to query enough votes for event %d", event.ChallengeId)
 }
+
+// expiry estimates when the event expires, a failed event is retried sooner as it gets close to its expiry.
+func (p *VoteCollator) expiry(event *model.Event) time.Time {
+	return eventExpiry(p.executor.GetCachedBlockHeight(), event)
+}
@@ -0,0 +1,104 @@
+package vote
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	tmtypes "github.com/cometbft/cometbft/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+// collatorExecutor is a chain of a single validator at height 100, on which the challenges are attested or not.
+type collatorExecutor struct {
+	attested bool
+}
+
+func (e *collatorExecutor) GetBlsPubKey() []byte {
+	return nil
+}
+
+func (e *collatorExecutor) GetCachedBlockHeight() uint64 {
+	return 100
+}
+
+func (e *collatorExecutor) QueryCachedLatestValidators() ([]*tmtypes.Validator, error) {
+	return []*tmtypes.Validator{tmtypes.NewValidator(ed25519.GenPrivKey().PubKey(), 1)}, nil
+}
+
+func (e *collatorExecutor) IsChallengeAttested(uint64) (bool, error) {
+	return e.attested, nil
+}
+
+func (e *collatorExecutor) ValidatorsChanged() <-chan struct{} {
+	return nil
+}
+
+// statusDataProvider keeps the statuses the events are updated to, the other methods are not used by the collator.
+type statusDataProvider struct {
+	DataProvider
+	statuses map[uint64]model.EventStatus
+}
+
+func (d *statusDataProvider) UpdateEventStatus(_ context.Context, challengeId uint64, status model.EventStatus) error {
+	d.statuses[challengeId] = status
+	return nil
+}
+
+// fakeMarker marks the challenges attested unless it fails.
+type fakeMarker struct {
+	fails  bool
+	marked []uint64
+}
+
+func (m *fakeMarker) MarkAttested(_ context.Context, challengeId uint64) bool {
+	m.marked = append(m.marked, challengeId)
+	return !m.fails
+}
+
+func newTestCollator(attested bool, marker AttestMarker) (*VoteCollator, *statusDataProvider) {
+	cfg := &config.Config{}
+	dataProvider := &statusDataProvider{statuses: make(map[uint64]model.EventStatus)}
+	p := NewVoteCollator(cfg, nil, &collatorExecutor{attested: attested}, dataProvider, metrics.NewMetricService(cfg))
+	if marker != nil {
+		p.SetAttestMarker(marker)
+	}
+	return p, dataProvider
+}
+
+func TestCollateForSingleEvent_Attested(t *testing.T) {
+	event := &model.Event{ChallengeId: 1, ExpiredHeight: 200, Status: model.SelfVoted}
+	ctx := context.Background()
+
+	// an event attested on chain is marked and not collated
+	marker := &fakeMarker{}
+	p, dataProvider := newTestCollator(true, marker)
+	require.NoError(t, p.collateForSingleEvent(ctx, event))
+	require.Equal(t, []uint64{1}, marker.marked)
+	require.Empty(t, dataProvider.statuses)
+
+	// an event which could not be marked is collated anyway
+	marker = &fakeMarker{fails: true}
+	p, dataProvider = newTestCollator(true, marker)
+	require.NoError(t, p.collateForSingleEvent(ctx, event))
+	require.Equal(t, []uint64{1}, marker.marked)
+	require.Equal(t, map[uint64]model.EventStatus{1: model.EnoughVotesCollected}, dataProvider.statuses)
+
+	// without a marker the status is set to attested
+	p, dataProvider = newTestCollator(true, nil)
+	require.NoError(t, p.collateForSingleEvent(ctx, event))
+	require.Equal(t, map[uint64]model.EventStatus{1: model.Attested}, dataProvider.statuses)
+}
+
+func TestCollateForSingleEvent_NotAttested(t *testing.T) {
+	event := &model.Event{ChallengeId: 1, ExpiredHeight: 200, Status: model.SelfVoted}
+	marker := &fakeMarker{}
+	p, dataProvider := newTestCollator(false, marker)
+	require.NoError(t, p.collateForSingleEvent(context.Background(), event))
+	require.Empty(t, marker.marked)
+	require.Equal(t, map[uint64]model.EventStatus{1: model.EnoughVotesCollected}, dataProvider.statuses)
+}
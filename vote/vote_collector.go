@@ -1,17 +1,22 @@
 package vote
 
 import (
-	"bytes"
+	"context"
 	"encoding/hex"
+	"errors"
 	"sync"
 	"time"
 
+	"github.com/bnb-chain/greenfield-challenger/common"
 	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
 	"github.com/bnb-chain/greenfield-challenger/executor"
+	"github.com/bnb-chain/greenfield-challenger/health"
 	"github.com/bnb-chain/greenfield-challenger/logging"
 	"github.com/bnb-chain/greenfield-challenger/metrics"
 	tmtypes "github.com/cometbft/cometbft/types"
 	"github.com/cometbft/cometbft/votepool"
+	lru "github.com/hashicorp/golang-lru"
 )
 
 type VoteCollector struct {
@@ -20,89 +25,135 @@ type VoteCollector struct {
 	mtx           sync.RWMutex
 	dataProvider  DataProvider
 	metricService *metrics.MetricService
+	knownVotes    *lru.Cache // votes known to be saved, keyed by voteKey
+	// validatorKeys indexes the bls keys of validatorsOf, the validators are indexed once per cached validator set
+	// rather than scanned for every vote
+	validatorKeys map[string]struct{}
+	validatorsOf  []*tmtypes.Validator
 }
 
 func NewVoteCollector(cfg *config.Config, executor *executor.Executor, collectorDataProvider DataProvider, metricService *metrics.MetricService) *VoteCollector {
+	knownVotes, _ := lru.New(KnownVotesCacheSize)
 	return &VoteCollector{
 		config:        cfg,
 		executor:      executor,
 		mtx:           sync.RWMutex{},
 		dataProvider:  collectorDataProvider,
 		metricService: metricService,
+		knownVotes:    knownVotes,
 	}
 }
 
-func (p *VoteCollector) CollectVotesLoop() {
+// CollectVotesLoop saves the votes of the validators from the votepool until ctx is done, once the votes saved before
+// are warmed up.
+func (p *VoteCollector) CollectVotesLoop(ctx context.Context) {
+	p.warmUp(ctx)
+	failures := 0
 	for {
-		err := p.collectVotes()
-		if err != nil {
-			time.Sleep(RetryInterval)
+		health.Beat(health.LoopCollector)
+		err := p.collectVotes(ctx)
+		switch {
+		case err == nil:
+			failures = 0
+		case errors.Is(err, common.ErrVotePoolRejected):
+			// backing off does not help a query the votepool rejects
+		default:
+			// the votepool is queried less often while its node fails
+			failures++
+			if !common.Sleep(ctx, common.BackoffRetryInterval(failures)) {
+				return
+			}
+		}
+		if !common.Sleep(ctx, CollectVotesInterval) {
+			return
 		}
-		time.Sleep(CollectVotesInterval)
 	}
 }
 
-func (p *VoteCollector) collectVotes() error {
+func (p *VoteCollector) collectVotes(ctx context.Context) error {
 	eventType := votepool.DataAvailabilityChallengeEvent
 	queriedVotes, err := p.executor.QueryVotes(eventType)
 	if err != nil {
 		p.metricService.IncVoteCollectorErr(err)
-		logging.Logger.Errorf("vote collector failed to query votes, err=%+v", err.Error())
+		logging.VoteLogger.Errorf("vote collector failed to query votes, err=%+v", err.Error())
 		return err
 	}
-	logging.Logger.Infof("number of votes collected: %d", len(queriedVotes))
+	logging.VoteLogger.Infof("number of votes collected: %d", len(queriedVotes))
 	for range queriedVotes {
 		p.metricService.IncVotesCollected()
 	}
 
 	if len(queriedVotes) == 0 {
-		time.Sleep(RetryInterval)
+		time.Sleep(common.IdleRetryInterval())
 		return nil
 	}
 
 	validators, err := p.executor.QueryCachedLatestValidators()
 	if err != nil {
 		p.metricService.IncVoteCollectorErr(err)
-		logging.Logger.Errorf("vote collector ran into error querying validators, err=%+v", err.Error())
+		logging.VoteLogger.Errorf("vote collector ran into error querying validators, err=%+v", err.Error())
 		return err
 	}
 
+	validatorKeys := p.indexValidatorKeys(validators)
 	for _, v := range queriedVotes {
-		exists, err := p.dataProvider.IsVoteExists(hex.EncodeToString(v.EventHash), hex.EncodeToString(v.PubKey))
-		if err != nil {
-			p.metricService.IncVoteCollectorErr(err)
-			logging.Logger.Errorf("vote collector ran into an error while checking if vote exists, err=%+v", err.Error())
-			continue
-		}
-		if exists {
-			continue
+		if err = p.collectVote(ctx, v, validatorKeys); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		if !p.isVotePubKeyValid(v, validators) {
-			logging.Logger.Errorf("vote's pub-key %s does not belong to any validator", hex.EncodeToString(v.PubKey))
-			continue
-		}
+// collectVote saves the vote unless it is known to be saved, it returns an error only if the vote could not be saved.
+func (p *VoteCollector) collectVote(ctx context.Context, v *votepool.Vote, validatorKeys map[string]struct{}) error {
+	key := voteKey(v.EventHash, v.PubKey)
+	if p.knownVotes.Contains(key) {
+		return nil
+	}
+	exists, err := p.dataProvider.IsVoteExists(ctx, hex.EncodeToString(v.EventHash), hex.EncodeToString(v.PubKey))
+	if err != nil {
+		p.metricService.IncVoteCollectorErr(err)
+		logging.VoteLogger.Errorf("vote collector ran into an error while checking if vote exists, err=%+v", err.Error())
+		return nil
+	}
+	if exists {
+		p.knownVotes.Add(key, true)
+		return nil
+	}
 
-		if err := verifySignature(v, v.EventHash); err != nil {
-			logging.Logger.Errorf("verify vote's signature failed,  err=%+v", err)
-			continue
-		}
+	if _, ok := validatorKeys[string(v.PubKey)]; !ok {
+		logging.VoteLogger.Errorf("vote's pub-key %s does not belong to any validator", hex.EncodeToString(v.PubKey))
+		return nil
+	}
 
-		err = p.dataProvider.SaveVote(EntityToDto(v, uint64(0)))
-		if err != nil {
-			p.metricService.IncVoteCollectorErr(err)
-			return err
-		}
-		logging.Logger.Infof("vote saved: %s", hex.EncodeToString(v.Signature))
+	if err := VerifySignature(v, v.EventHash); err != nil {
+		logging.VoteLogger.Errorf("verify vote's signature failed,  err=%+v", err)
+		return nil
 	}
+
+	// the record is only saved, so it is reused for the next vote
+	dto := dtoPool.Get().(*model.Vote)
+	err = p.dataProvider.SaveVote(ctx, fillDto(dto, v, 0))
+	dtoPool.Put(dto)
+	if err != nil {
+		p.metricService.IncVoteCollectorErr(err)
+		return err
+	}
+	p.knownVotes.Add(key, true)
+	logging.VoteLogger.Infof("vote saved: %s", hex.EncodeToString(v.Signature))
 	return nil
 }
 
-func (p *VoteCollector) isVotePubKeyValid(v *votepool.Vote, validators []*tmtypes.Validator) bool {
+// indexValidatorKeys returns the bls keys of the validators, indexed again only if the cached validator set changed.
+func (p *VoteCollector) indexValidatorKeys(validators []*tmtypes.Validator) map[string]struct{} {
+	if p.validatorKeys != nil && len(validators) == len(p.validatorsOf) &&
+		(len(validators) == 0 || &validators[0] == &p.validatorsOf[0]) {
+		return p.validatorKeys
+	}
+	keys := make(map[string]struct{}, len(validators))
 	for _, validator := range validators {
-		if bytes.Equal(v.PubKey[:], validator.BlsKey[:]) {
-			return true
-		}
+		keys[string(validator.BlsKey)] = struct{}{}
 	}
-	return false
+	p.validatorKeys, p.validatorsOf = keys, validators
+	return keys
 }
@@ -0,0 +1,102 @@
+package vote
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cometbft/cometbft/votepool"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+)
+
+// savingDataProvider saves the votes in memory, the other methods are not used by the collector.
+type savingDataProvider struct {
+	DataProvider
+	saved map[string]bool
+}
+
+func (d *savingDataProvider) IsVoteExists(_ context.Context, eventHash string, pubKey string) (bool, error) {
+	return d.saved[eventHash+pubKey], nil
+}
+
+func (d *savingDataProvider) SaveVote(_ context.Context, vote *model.Vote) error {
+	d.saved[vote.EventHash+vote.PubKey] = true
+	return nil
+}
+
+func benchCollector(t testing.TB) (*VoteCollector, *savingDataProvider) {
+	knownVotes, err := lru.New(KnownVotesCacheSize)
+	require.NoError(t, err)
+	dataProvider := &savingDataProvider{saved: make(map[string]bool)}
+	return &VoteCollector{dataProvider: dataProvider, knownVotes: knownVotes}, dataProvider
+}
+
+func TestCollectVote(t *testing.T) {
+	eventHash := CalculateEventHash(benchEvent(), benchChainId)
+	votes, validators := benchVotes(t, 2, eventHash)
+	p, dataProvider := benchCollector(t)
+	ctx := context.Background()
+
+	v, err := DtoToEntity(votes[0])
+	require.NoError(t, err)
+	require.NoError(t, p.collectVote(ctx, v, p.indexValidatorKeys(validators)))
+	require.Len(t, dataProvider.saved, 1)
+	require.True(t, dataProvider.saved[votes[0].EventHash+votes[0].PubKey])
+	// a known vote is not checked again
+	dataProvider.saved = make(map[string]bool)
+	require.NoError(t, p.collectVote(ctx, v, p.indexValidatorKeys(validators)))
+	require.Empty(t, dataProvider.saved)
+
+	// the votes of other keys or with an invalid signature are dropped
+	other, err := DtoToEntity(votes[1])
+	require.NoError(t, err)
+	require.NoError(t, p.collectVote(ctx, other, p.indexValidatorKeys(validators[:1])))
+	other.Signature = v.Signature
+	require.NoError(t, p.collectVote(ctx, other, p.indexValidatorKeys(validators)))
+	require.Empty(t, dataProvider.saved)
+}
+
+// BenchmarkCollectKnownVote measures the votes queried again from the votepool, most of the votes of every query.
+func BenchmarkCollectKnownVote(b *testing.B) {
+	eventHash := CalculateEventHash(benchEvent(), benchChainId)
+	votes, validators := benchVotes(b, 21, eventHash)
+	p, _ := benchCollector(b)
+	validatorKeys := p.indexValidatorKeys(validators)
+	ctx := context.Background()
+	queried := make([]*votepool.Vote, 0, len(votes))
+	for _, vote := range votes {
+		v, err := DtoToEntity(vote)
+		require.NoError(b, err)
+		require.NoError(b, p.collectVote(ctx, v, validatorKeys))
+		queried = append(queried, v)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := p.collectVote(ctx, queried[i%len(queried)], validatorKeys); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCollectNewVote measures the votes saved, mostly spent verifying their signature.
+func BenchmarkCollectNewVote(b *testing.B) {
+	eventHash := CalculateEventHash(benchEvent(), benchChainId)
+	votes, validators := benchVotes(b, 21, eventHash)
+	v, err := DtoToEntity(votes[20])
+	require.NoError(b, err)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		p, _ := benchCollector(b)
+		validatorKeys := p.indexValidatorKeys(validators)
+		b.StartTimer()
+		if err := p.collectVote(ctx, v, validatorKeys); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
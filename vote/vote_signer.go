@@ -1,6 +1,8 @@
 package vote
 
 import (
+	"sync"
+
 	"github.com/bnb-chain/greenfield-challenger/logging"
 	"github.com/cometbft/cometbft/votepool"
 	"github.com/prysmaticlabs/prysm/crypto/bls/blst"
@@ -8,27 +10,56 @@ import (
 )
 
 type VoteSigner struct {
+	mtx     sync.RWMutex
 	privKey blscmn.SecretKey
-	pubKey  blscmn.PublicKey
+	pubKey  []byte // marshaled once, it is sent with every vote
 }
 
 func NewVoteSigner(pk []byte) *VoteSigner {
 	privKey, err := blst.SecretKeyFromBytes(pk)
 	if err != nil {
-		logging.Logger.Errorf("vote signer failed to generate key from bytes, err=%+v", err.Error())
+		logging.VoteLogger.Errorf("vote signer failed to generate key from bytes, err=%+v", err.Error())
 		panic(err)
 	}
-	pubKey := privKey.PublicKey()
 	return &VoteSigner{
 		privKey: privKey,
-		pubKey:  pubKey,
+		pubKey:  privKey.PublicKey().Marshal(),
+	}
+}
+
+// SetKey replaces the signing key, it is used when the bls key is rotated.
+func (signer *VoteSigner) SetKey(pk []byte) error {
+	privKey, err := blst.SecretKeyFromBytes(pk)
+	if err != nil {
+		return err
 	}
+	signer.mtx.Lock()
+	defer signer.mtx.Unlock()
+	signer.privKey = privKey
+	signer.pubKey = privKey.PublicKey().Marshal()
+	return nil
+}
+
+// PubKey returns the public key the votes are signed with.
+func (signer *VoteSigner) PubKey() []byte {
+	signer.mtx.RLock()
+	defer signer.mtx.RUnlock()
+	return append([]byte(nil), signer.pubKey...)
+}
+
+// Sign signs data, it returns the public key and the signature.
+func (signer *VoteSigner) Sign(data []byte) ([]byte, []byte) {
+	signer.mtx.RLock()
+	defer signer.mtx.RUnlock()
+	return append([]byte(nil), signer.pubKey...), signer.privKey.Sign(data).Marshal()
 }
 
 // SignVote sign a vote, data is used to sign and generate the signature
 func (signer *VoteSigner) SignVote(vote *votepool.Vote, data []byte) {
-	signature := signer.privKey.Sign(data[:])
-	vote.EventHash = append(vote.EventHash, data[:]...)
-	vote.PubKey = append(vote.PubKey, signer.pubKey.Marshal()...)
+	signer.mtx.RLock()
+	defer signer.mtx.RUnlock()
+	signature := signer.privKey.Sign(data)
+	vote.EventHash = append(vote.EventHash, data...)
+	vote.PubKey = append(vote.PubKey, signer.pubKey...)
 	vote.Signature = append(vote.Signature, signature.Marshal()...)
 }
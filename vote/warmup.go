@@ -0,0 +1,128 @@
+package vote
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/executor"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/cometbft/cometbft/votepool"
+)
+
+// warmUpHeight returns the height the unexpired events are warmed up at, 0 if it is not known.
+func warmUpHeight(executor *executor.Executor) uint64 {
+	if height := executor.GetCachedBlockHeight(); height != 0 {
+		return height
+	}
+	height, err := executor.GetLatestBlockHeight()
+	if err != nil {
+		logging.VoteLogger.Errorf("failed to query the height to warm up at, err=%+v", err.Error())
+		return 0
+	}
+	return height
+}
+
+// fetchWarmUpVotes returns the unexpired events in the statuses with the hex hashes voted for them, and their saved
+// votes, with a single query of the votes.
+func fetchWarmUpVotes(ctx context.Context, dataProvider DataProvider, currentHeight uint64, chainId string,
+	statuses ...model.EventStatus,
+) ([]*model.Event, []string, []*model.Vote, error) {
+	events := make([]*model.Event, 0)
+	for _, status := range statuses {
+		fetched, err := dataProvider.FetchUnexpiredEvents(ctx, currentHeight, status)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		events = append(events, fetched...)
+	}
+	eventHashes := make([]string, 0, len(events))
+	for _, event := range events {
+		eventHashes = append(eventHashes, hex.EncodeToString(EventHash(event, chainId)))
+	}
+	votes, err := dataProvider.FetchVotesByEventHashes(ctx, eventHashes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return events, eventHashes, votes, nil
+}
+
+// warmUp caches the votes of all identities which were saved for the unexpired verified events, e.g. before a restart,
+// so that they are broadcast without being signed and saved again.
+func (p *VoteBroadcaster) warmUp(ctx context.Context) {
+	currentHeight := warmUpHeight(p.executor)
+	if currentHeight == 0 {
+		return
+	}
+	events, eventHashes, votes, err := fetchWarmUpVotes(ctx, p.dataProvider, currentHeight,
+		p.config.GreenfieldConfig.ChainIdString, model.Verified)
+	if err != nil {
+		logging.VoteLogger.Errorf("broadcaster failed to warm up the local votes, err=%+v", err.Error())
+		return
+	}
+	signers := append([]*VoteSigner{p.signer}, p.identitySigners...)
+	pubKeys := make(map[string]bool, len(signers))
+	for _, signer := range signers {
+		pubKeys[hex.EncodeToString(signer.PubKey())] = true
+	}
+	localVotes := make(map[string][]*votepool.Vote)
+	for _, v := range votes {
+		if !pubKeys[v.PubKey] {
+			continue
+		}
+		localVote, err := DtoToEntity(v)
+		if err != nil {
+			logging.VoteLogger.Errorf("broadcaster failed to decode the local vote of challenge %d, err=%+v", v.ChallengeId, err.Error())
+			continue
+		}
+		localVotes[v.EventHash] = append(localVotes[v.EventHash], localVote)
+	}
+	warmed := 0
+	for i, event := range events {
+		// an event voted by some of the identities only is signed again, the saved votes are kept
+		if len(localVotes[eventHashes[i]]) != len(signers) {
+			continue
+		}
+		p.cachedLocalVote.Add(event.ChallengeId, event.ExpiredHeight, localVotes[eventHashes[i]])
+		warmed++
+	}
+	logging.VoteLogger.Infof("broadcaster warmed up the local votes of %d events", warmed)
+}
+
+// warmUp marks the saved votes of the unexpired events being voted as known, so that they are not checked in the
+// database again when they are collected from the votepool.
+func (p *VoteCollector) warmUp(ctx context.Context) {
+	currentHeight := warmUpHeight(p.executor)
+	if currentHeight == 0 {
+		return
+	}
+	_, _, votes, err := fetchWarmUpVotes(ctx, p.dataProvider, currentHeight, p.config.GreenfieldConfig.ChainIdString,
+		model.Verified, model.SelfVoted)
+	if err != nil {
+		logging.VoteLogger.Errorf("vote collector failed to warm up the saved votes, err=%+v", err.Error())
+		return
+	}
+	for _, v := range votes {
+		eventHash, err := hex.DecodeString(v.EventHash)
+		if err != nil {
+			continue
+		}
+		pubKey, err := hex.DecodeString(v.PubKey)
+		if err != nil {
+			continue
+		}
+		p.knownVotes.Add(voteKey(eventHash, pubKey), true)
+	}
+	logging.VoteLogger.Infof("vote collector warmed up %d saved votes", len(votes))
+}
+
+// voteKey identifies a vote by its event hash and public key, the raw bytes are half as long as the hex ones and
+// are not encoded for every vote queried from the votepool.
+func voteKey(eventHash, pubKey []byte) string {
+	var key strings.Builder
+	key.Grow(len(eventHash) + len(pubKey))
+	key.Write(eventHash)
+	key.Write(pubKey)
+	return key.String()
+}
@@ -0,0 +1,19 @@
+package voteproxy
+
+import "time"
+
+const (
+	VotesPath = "/votes"
+
+	RequestTimeout = 10 * time.Second
+	// MaxRequestBytes bounds the body of a request
+	MaxRequestBytes = 1 << 20
+	// MaxVotesPerRequest bounds the votes of a request, whatever the rate of the client
+	MaxVotesPerRequest = 100
+
+	// the results of the submitted votes
+	ResultRelayed  = "relayed"
+	ResultRejected = "rejected" // invalid, badly signed or signed by a key of another validator
+	ResultLimited  = "limited"  // beyond the rate of the client
+	ResultFailed   = "failed"   // not accepted by the votepool
+)
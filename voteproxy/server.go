@@ -0,0 +1,211 @@
+package voteproxy
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cometbft/cometbft/votepool"
+	"golang.org/x/time/rate"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+	"github.com/bnb-chain/greenfield-challenger/vote"
+)
+
+// Broadcaster broadcasts the votes to the votepool.
+type Broadcaster interface {
+	BroadcastVote(v *votepool.Vote) error
+}
+
+type voteRequest struct {
+	PubKey    string `json:"pub_key"`   // hex encoded
+	Signature string `json:"signature"` // hex encoded
+	EventType uint8  `json:"event_type"`
+	EventHash string `json:"event_hash"` // hex encoded
+}
+
+type votesRequest struct {
+	Votes []voteRequest `json:"votes"`
+}
+
+type votesResponse struct {
+	Relayed int    `json:"relayed"`
+	Error   string `json:"error,omitempty"`
+}
+
+// client is a validator relaying its votes.
+type client struct {
+	name    string
+	token   []byte
+	pubKeys map[string]bool // hex encoded, lower case
+	limiter *rate.Limiter
+}
+
+// Server relays the votes of the validators whose nodes do not expose the votepool rpc: POST /votes with
+// {"votes": [{"pub_key": "...", "signature": "...", "event_type": 4, "event_hash": "..."}]} and the bearer token of the
+// client broadcasts the votes to the votepool. The votes of a request are checked all or none: each must be signed by
+// a bls key of the client, and fit in its rate.
+type Server struct {
+	cfg           *config.VoteProxyConfig
+	broadcaster   Broadcaster
+	metricService *metrics.MetricService
+	clients       []*client
+	mux           *http.ServeMux
+}
+
+func NewServer(cfg *config.VoteProxyConfig, broadcaster Broadcaster, metricService *metrics.MetricService) *Server {
+	perMinute := int(cfg.Rate())
+	s := &Server{
+		cfg:           cfg,
+		broadcaster:   broadcaster,
+		metricService: metricService,
+		clients:       make([]*client, 0, len(cfg.Clients)),
+		mux:           http.NewServeMux(),
+	}
+	for _, c := range cfg.Clients {
+		pubKeys := make(map[string]bool, len(c.BlsPubKeys))
+		for _, pubKey := range c.BlsPubKeys {
+			pubKeys[strings.ToLower(pubKey)] = true
+		}
+		s.clients = append(s.clients, &client{
+			name:    c.Name,
+			token:   []byte("Bearer " + c.AuthToken),
+			pubKeys: pubKeys,
+			limiter: rate.NewLimiter(rate.Every(time.Minute/time.Duration(perMinute)), perMinute),
+		})
+	}
+	s.mux.HandleFunc(VotesPath, s.serveVotes)
+	return s
+}
+
+// Start serves the proxy, it blocks until ctx is done.
+func (s *Server) Start(ctx context.Context) {
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", s.cfg.Port),
+		Handler:           s.mux,
+		ReadHeaderTimeout: RequestTimeout,
+		ReadTimeout:       RequestTimeout,
+		WriteTimeout:      RequestTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		panic(err)
+	}
+}
+
+// authenticate returns the client of the bearer token of r, nil if there is none. All tokens are compared, so that
+// the time taken does not tell which client is closer.
+func (s *Server) authenticate(r *http.Request) *client {
+	var found *client
+	authorization := []byte(r.Header.Get("Authorization"))
+	for _, c := range s.clients {
+		if subtle.ConstantTimeCompare(authorization, c.token) == 1 {
+			found = c
+		}
+	}
+	return found
+}
+
+// serveVotes serves POST /votes.
+func (s *Server) serveVotes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJson(w, http.StatusMethodNotAllowed, votesResponse{Error: "method not allowed"})
+		return
+	}
+	c := s.authenticate(r)
+	if c == nil {
+		writeJson(w, http.StatusUnauthorized, votesResponse{Error: "unauthorized"})
+		return
+	}
+	var req votesRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxRequestBytes)).Decode(&req); err != nil {
+		writeJson(w, http.StatusBadRequest, votesResponse{Error: fmt.Sprintf("invalid request body, %v", err)})
+		return
+	}
+	votes, err := s.parse(c, req)
+	if err != nil {
+		s.metricService.AddVoteProxyVotes(c.name, ResultRejected, len(req.Votes))
+		writeJson(w, http.StatusBadRequest, votesResponse{Error: err.Error()})
+		return
+	}
+	if !c.limiter.AllowN(time.Now(), len(votes)) {
+		s.metricService.AddVoteProxyVotes(c.name, ResultLimited, len(votes))
+		w.Header().Set("Retry-After", strconv.Itoa(int(time.Minute/time.Second)*len(votes)/int(s.cfg.Rate())+1))
+		writeJson(w, http.StatusTooManyRequests, votesResponse{Error: "too many votes"})
+		return
+	}
+	for i, v := range votes {
+		if err = s.broadcaster.BroadcastVote(v); err != nil {
+			s.metricService.AddVoteProxyVotes(c.name, ResultRelayed, i)
+			s.metricService.AddVoteProxyVotes(c.name, ResultFailed, len(votes)-i)
+			logging.VoteLogger.Errorf("failed to relay the vote of client %s for event hash %s, err=%+v", c.name,
+				hex.EncodeToString(v.EventHash), err.Error())
+			writeJson(w, http.StatusBadGateway, votesResponse{Relayed: i, Error: "broadcast the vote to the votepool " +
+				"error"})
+			return
+		}
+	}
+	s.metricService.AddVoteProxyVotes(c.name, ResultRelayed, len(votes))
+	writeJson(w, http.StatusOK, votesResponse{Relayed: len(votes)})
+}
+
+// parse decodes the votes of the request and checks that they are signed by the bls keys of c.
+func (s *Server) parse(c *client, req votesRequest) ([]*votepool.Vote, error) {
+	if len(req.Votes) == 0 {
+		return nil, errors.New("no votes")
+	}
+	maxVotes := MaxVotesPerRequest
+	if int(s.cfg.Rate()) < maxVotes {
+		maxVotes = int(s.cfg.Rate())
+	}
+	if len(req.Votes) > maxVotes {
+		return nil, fmt.Errorf("at most %d votes per request", maxVotes)
+	}
+	votes := make([]*votepool.Vote, 0, len(req.Votes))
+	for i, v := range req.Votes {
+		pubKey, err := hex.DecodeString(v.PubKey)
+		if err != nil {
+			return nil, fmt.Errorf("vote %d: invalid pub_key, %v", i, err)
+		}
+		signature, err := hex.DecodeString(v.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("vote %d: invalid signature, %v", i, err)
+		}
+		eventHash, err := hex.DecodeString(v.EventHash)
+		if err != nil {
+			return nil, fmt.Errorf("vote %d: invalid event_hash, %v", i, err)
+		}
+		parsed := votepool.NewVote(pubKey, signature, v.EventType, eventHash)
+		if err = parsed.ValidateBasic(); err != nil {
+			return nil, fmt.Errorf("vote %d: %v", i, err)
+		}
+		if !c.pubKeys[hex.EncodeToString(pubKey)] {
+			return nil, fmt.Errorf("vote %d: pub_key %s is not a key of the client", i, hex.EncodeToString(pubKey))
+		}
+		if err = vote.VerifySignature(parsed, eventHash); err != nil {
+			return nil, fmt.Errorf("vote %d: %v", i, err)
+		}
+		votes = append(votes, parsed)
+	}
+	return votes, nil
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logging.VoteLogger.Errorf("failed to write the vote proxy response, err=%+v", err.Error())
+	}
+}
@@ -0,0 +1,95 @@
+package voteproxy
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cometbft/cometbft/votepool"
+	"github.com/prysmaticlabs/prysm/crypto/bls/blst"
+	"github.com/prysmaticlabs/prysm/crypto/bls/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/metrics"
+)
+
+type fakeBroadcaster struct {
+	votes []*votepool.Vote
+	err   error
+}
+
+func (b *fakeBroadcaster) BroadcastVote(v *votepool.Vote) error {
+	if b.err != nil {
+		return b.err
+	}
+	b.votes = append(b.votes, v)
+	return nil
+}
+
+func serve(s *Server, token, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, VotesPath, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	s.mux.ServeHTTP(w, r)
+	return w
+}
+
+// signedVote returns the json vote of the event hash filled with b, signed by key.
+func signedVote(t *testing.T, key common.SecretKey, b byte) string {
+	eventHash := make([]byte, 32)
+	for i := range eventHash {
+		eventHash[i] = b
+	}
+	require.NotNil(t, key)
+	return fmt.Sprintf(`{"pub_key":"%s","signature":"%s","event_type":%d,"event_hash":"%s"}`,
+		hex.EncodeToString(key.PublicKey().Marshal()), hex.EncodeToString(key.Sign(eventHash).Marshal()),
+		votepool.DataAvailabilityChallengeEvent, hex.EncodeToString(eventHash))
+}
+
+func TestServer_Votes(t *testing.T) {
+	key, err := blst.RandKey()
+	require.NoError(t, err)
+	otherKey, err := blst.RandKey()
+	require.NoError(t, err)
+	cfg := &config.VoteProxyConfig{Port: 9330, VotesPerMinute: 3, Clients: []config.VoteProxyClient{
+		{Name: "validator-1", AuthToken: "token-1", BlsPubKeys: []string{hex.EncodeToString(key.PublicKey().Marshal())}},
+		{Name: "validator-2", AuthToken: "token-2", BlsPubKeys: []string{hex.EncodeToString(otherKey.PublicKey().Marshal())}},
+	}}
+	broadcaster := &fakeBroadcaster{}
+	s := NewServer(cfg, broadcaster, metrics.NewMetricService(&config.Config{}))
+
+	w := serve(s, "token-1", `{"votes":[`+signedVote(t, key, 1)+`,`+signedVote(t, key, 2)+`]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"relayed":2}`, w.Body.String())
+	require.Len(t, broadcaster.votes, 2)
+	require.Equal(t, key.PublicKey().Marshal(), broadcaster.votes[0].PubKey)
+
+	// the votes of other validators and the votes badly signed are rejected
+	badlySigned := strings.Replace(signedVote(t, key, 3), `"event_hash":"03`, `"event_hash":"04`, 1)
+	for _, v := range []string{signedVote(t, otherKey, 3), badlySigned, `{"pub_key":"zz"}`} {
+		w = serve(s, "token-1", `{"votes":[`+signedVote(t, key, 3)+`,`+v+`]}`)
+		require.Equal(t, http.StatusBadRequest, w.Code, v)
+	}
+	require.Equal(t, http.StatusBadRequest, serve(s, "token-1", `{"votes":[]}`).Code)
+	require.Len(t, broadcaster.votes, 2)
+
+	// the rate is per client
+	w = serve(s, "token-1", `{"votes":[`+signedVote(t, key, 3)+`,`+signedVote(t, key, 4)+`]}`)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.NotEmpty(t, w.Header().Get("Retry-After"))
+	require.Equal(t, http.StatusOK, serve(s, "token-2", `{"votes":[`+signedVote(t, otherKey, 3)+`]}`).Code)
+
+	broadcaster.err = errors.New("votepool unavailable")
+	w = serve(s, "token-2", `{"votes":[`+signedVote(t, otherKey, 4)+`]}`)
+	require.Equal(t, http.StatusBadGateway, w.Code)
+
+	require.Equal(t, http.StatusUnauthorized, serve(s, "", `{}`).Code)
+	require.Equal(t, http.StatusUnauthorized, serve(s, "token-3", `{}`).Code)
+}
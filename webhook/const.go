@@ -0,0 +1,25 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+)
+
+const (
+	TriggerEventIngested  = config.WebhookTriggerEventIngested
+	TriggerVoteBroadcast  = config.WebhookTriggerVoteBroadcast
+	TriggerVotesCollected = config.WebhookTriggerVotesCollected
+	TriggerAttested       = config.WebhookTriggerAttested
+	TriggerExpired        = config.WebhookTriggerExpired
+
+	// the headers of a notification, the signature is only set if a secret is configured
+	HeaderTrigger   = "X-Challenger-Trigger"
+	HeaderDelivery  = "X-Challenger-Delivery"
+	HeaderTimestamp = "X-Challenger-Timestamp"
+	HeaderSignature = "X-Challenger-Signature"
+
+	QueueSize      = 1000            // notifications waiting for a webhook, the later ones are dropped
+	RetryBaseDelay = 1 * time.Second // doubled after every failed attempt
+	MaxRetryDelay  = 1 * time.Minute
+)
@@ -0,0 +1,231 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+	"github.com/bnb-chain/greenfield-challenger/logging"
+)
+
+// Notification is the json body posted to the webhooks for a pipeline event of a challenge.
+type Notification struct {
+	// Id is the same for the retries of a notification and for all webhooks, receivers can dedup on it
+	Id                string    `json:"id"`
+	Trigger           string    `json:"trigger"`
+	Time              time.Time `json:"time"`
+	ChallengeId       uint64    `json:"challenge_id"`
+	ObjectId          string    `json:"object_id"`
+	SegmentIndex      uint32    `json:"segment_index"`
+	SpOperatorAddress string    `json:"sp_operator_address"`
+	Height            uint64    `json:"height"`
+	ExpiredHeight     uint64    `json:"expired_height"`
+	Status            string    `json:"status"`
+	VerifyResult      string    `json:"verify_result"`
+}
+
+func newNotification(trigger string, event *model.Event, status model.EventStatus) *Notification {
+	return &Notification{
+		Id:                fmt.Sprintf("%s-%d", trigger, event.ChallengeId),
+		Trigger:           trigger,
+		Time:              time.Now().UTC(),
+		ChallengeId:       event.ChallengeId,
+		ObjectId:          event.ObjectId,
+		SegmentIndex:      event.SegmentIndex,
+		SpOperatorAddress: event.SpOperatorAddress,
+		Height:            event.Height,
+		ExpiredHeight:     event.ExpiredHeight,
+		Status:            status.String(),
+		VerifyResult:      event.VerifyResult.String(),
+	}
+}
+
+// Sign returns the signature of a notification posted at timestamp, the hex encoded hmac-sha256 of
+// "<timestamp>.<body>". It is sent as "sha256=<signature>" in the signature header.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher posts the notifications to the webhooks in the background. Each webhook has its own queue, so that a
+// slow webhook does not delay the others.
+type Dispatcher struct {
+	cfg       *config.WebhookConfig
+	client    *http.Client
+	endpoints []*endpoint
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+type endpoint struct {
+	url   string
+	queue chan *Notification
+}
+
+func NewDispatcher(cfg *config.WebhookConfig) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout()},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for _, u := range cfg.Urls {
+		e := &endpoint{url: u, queue: make(chan *Notification, QueueSize)}
+		d.endpoints = append(d.endpoints, e)
+		d.wg.Add(1)
+		go d.run(e)
+	}
+	return d
+}
+
+// Notify queues n for every webhook if its trigger is configured, it does not block.
+func (d *Dispatcher) Notify(n *Notification) {
+	if !d.cfg.Triggered(n.Trigger) {
+		return
+	}
+	for _, e := range d.endpoints {
+		select {
+		case e.queue <- n:
+		default:
+			logging.Logger.Errorf("webhook queue is full, notification dropped, url=%s, id=%s", e.url, n.Id)
+		}
+	}
+}
+
+// Close stops the delivery, the notifications still queued or retried are dropped.
+func (d *Dispatcher) Close() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) run(e *endpoint) {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case n := <-e.queue:
+			if err := d.deliver(e.url, n); err != nil {
+				logging.Logger.Errorf("failed to notify webhook, url=%s, id=%s, err=%+v", e.url, n.Id, err.Error())
+			}
+		}
+	}
+}
+
+// deliver posts n to url, retrying the network errors and the 5xx and 429 responses with an exponential backoff.
+func (d *Dispatcher) deliver(url string, n *Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	delay := RetryBaseDelay
+	for attempt := 0; ; attempt++ {
+		retryable, err := d.post(url, n, body)
+		if err == nil || !retryable || attempt >= d.cfg.MaxRetries {
+			return err
+		}
+		select {
+		case <-d.ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > MaxRetryDelay {
+			delay = MaxRetryDelay
+		}
+	}
+}
+
+// post sends a single attempt of the notification, it reports whether a failure is worth retrying.
+func (d *Dispatcher) post(url string, n *Notification, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	// the timestamp is refreshed on retries, so that receivers can reject stale replays
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTrigger, n.Trigger)
+	req.Header.Set(HeaderDelivery, n.Id)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	if d.cfg.Secret != "" {
+		req.Header.Set(HeaderSignature, "sha256="+Sign(d.cfg.Secret, timestamp, body))
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retryable, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+}
+
+// dispatcher notifies the webhooks of the challenger, nothing is notified while it is nil.
+var dispatcher atomic.Pointer[Dispatcher]
+
+// Init starts notifying the webhooks of cfg, notifying stays off if it is not enabled.
+func Init(cfg *config.WebhookConfig) {
+	if !cfg.Enabled() {
+		return
+	}
+	dispatcher.Store(NewDispatcher(cfg))
+}
+
+// Close stops notifying the webhooks.
+func Close() {
+	if d := dispatcher.Swap(nil); d != nil {
+		d.Close()
+	}
+}
+
+// notify queues the notification of event, which reached status.
+func notify(trigger string, event *model.Event, status model.EventStatus) {
+	d := dispatcher.Load()
+	if d == nil {
+		return
+	}
+	d.Notify(newNotification(trigger, event, status))
+}
+
+// EventIngested notifies a challenge event saved by the monitor.
+func EventIngested(event *model.Event) {
+	notify(TriggerEventIngested, event, model.Unprocessed)
+}
+
+// VoteBroadcast notifies the first broadcast of the vote of the challenger.
+func VoteBroadcast(event *model.Event) {
+	notify(TriggerVoteBroadcast, event, model.SelfVoted)
+}
+
+// VotesCollected notifies a challenge whose votes reached 2/3 of the validators.
+func VotesCollected(event *model.Event) {
+	notify(TriggerVotesCollected, event, model.EnoughVotesCollected)
+}
+
+// Attested notifies a challenge attested on chain, status tells whether the challenger submitted the attestation.
+func Attested(event *model.Event, status model.EventStatus) {
+	notify(TriggerAttested, event, status)
+}
+
+// Expired notifies a challenge which expired before reaching a final status.
+func Expired(event *model.Event) {
+	notify(TriggerExpired, event, model.Expired)
+}
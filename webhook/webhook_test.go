@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/greenfield-challenger/config"
+	"github.com/bnb-chain/greenfield-challenger/db/model"
+)
+
+type received struct {
+	notification Notification
+	header       http.Header
+	body         []byte
+}
+
+func newWebhook(t *testing.T, statuses ...int) (*httptest.Server, chan received, *atomic.Int32) {
+	ch := make(chan received, 10)
+	attempts := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempt := int(attempts.Add(1))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		if attempt <= len(statuses) {
+			w.WriteHeader(statuses[attempt-1])
+			return
+		}
+		n := Notification{}
+		require.NoError(t, json.Unmarshal(body, &n))
+		ch <- received{notification: n, header: r.Header, body: body}
+	}))
+	t.Cleanup(server.Close)
+	return server, ch, attempts
+}
+
+func TestDispatcher_Notify(t *testing.T) {
+	server, ch, _ := newWebhook(t)
+	d := NewDispatcher(&config.WebhookConfig{Urls: []string{server.URL}, Secret: "secret",
+		Triggers: []string{TriggerAttested}})
+	defer d.Close()
+
+	event := &model.Event{ChallengeId: 7, ObjectId: "42", SpOperatorAddress: "0x01", Status: model.Submitted}
+	d.Notify(newNotification(TriggerExpired, event, model.Expired))
+	d.Notify(newNotification(TriggerAttested, event, model.SelfAttested))
+
+	r := <-ch
+	require.Equal(t, "attested-7", r.notification.Id)
+	require.Equal(t, uint64(7), r.notification.ChallengeId)
+	require.Equal(t, "self_attested", r.notification.Status)
+	require.Equal(t, TriggerAttested, r.header.Get(HeaderTrigger))
+	require.Equal(t, "attested-7", r.header.Get(HeaderDelivery))
+	timestamp, err := strconv.ParseInt(r.header.Get(HeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	require.Equal(t, "sha256="+Sign("secret", timestamp, r.body), r.header.Get(HeaderSignature))
+	// the expired notification is not configured
+	select {
+	case r = <-ch:
+		t.Fatalf("unexpected notification %s", r.notification.Id)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestDispatcher_Retries(t *testing.T) {
+	server, ch, attempts := newWebhook(t, http.StatusBadGateway, http.StatusTooManyRequests)
+	d := NewDispatcher(&config.WebhookConfig{Urls: []string{server.URL}, MaxRetries: 2})
+	defer d.Close()
+
+	err := d.deliver(server.URL, newNotification(TriggerExpired, &model.Event{ChallengeId: 1}, model.Expired))
+	require.NoError(t, err)
+	require.Equal(t, int32(3), attempts.Load())
+	r := <-ch
+	require.Empty(t, r.header.Get(HeaderSignature))
+}
+
+func TestDispatcher_GivesUp(t *testing.T) {
+	server, _, attempts := newWebhook(t, http.StatusInternalServerError, http.StatusInternalServerError)
+	d := NewDispatcher(&config.WebhookConfig{Urls: []string{server.URL}, MaxRetries: 1})
+	defer d.Close()
+	require.Error(t, d.deliver(server.URL, newNotification(TriggerExpired, &model.Event{}, model.Expired)))
+	require.Equal(t, int32(2), attempts.Load())
+
+	// a rejected notification is not retried
+	server, _, attempts = newWebhook(t, http.StatusBadRequest)
+	require.Error(t, d.deliver(server.URL, newNotification(TriggerExpired, &model.Event{}, model.Expired)))
+	require.Equal(t, int32(1), attempts.Load())
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac secret
+	require.Equal(t, "b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163", Sign("secret", 1700000000, []byte("{}")))
+}
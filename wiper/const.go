@@ -5,4 +5,6 @@ import "time"
 var (
 	DBWipeInterval = 1 * time.Hour
 	WipeBefore     = time.Now().Add(-1 * time.Hour).Unix()
+	// PurgeAfter is how long the events are kept at all, the deleted ones can be replayed until then
+	PurgeAfter = 7 * 24 * time.Hour
 )
@@ -1,6 +1,7 @@
 package wiper
 
 import (
+	"context"
 	"time"
 
 	"github.com/bnb-chain/greenfield-challenger/common"
@@ -17,26 +18,42 @@ func NewDBWiper(dao *dao.DaoManager) *DBWiper {
 	}
 }
 
-func (w *DBWiper) DBWipeLoop() {
+func (w *DBWiper) DBWipeLoop(ctx context.Context) {
 	ticker := time.NewTicker(DBWipeInterval)
-	for range ticker.C {
-		err := w.DBWipe()
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := w.DBWipe(ctx)
 		if err != nil {
-			time.Sleep(common.RetryInterval)
+			if !common.Sleep(ctx, common.RetryInterval()) {
+				return
+			}
 		}
 	}
 }
 
-func (w *DBWiper) DBWipe() error {
-	err := w.daoManager.DeleteEventsBefore(WipeBefore)
+func (w *DBWiper) DBWipe(ctx context.Context) error {
+	err := w.daoManager.DeleteEventsBefore(ctx, WipeBefore)
+	if err != nil {
+		return err
+	}
+	err = w.daoManager.PurgeEventsBefore(ctx, time.Now().Add(-PurgeAfter).Unix())
+	if err != nil {
+		return err
+	}
+	err = w.daoManager.DeleteBlocksBefore(ctx, WipeBefore)
 	if err != nil {
 		return err
 	}
-	err = w.daoManager.DeleteBlocksBefore(WipeBefore)
+	err = w.daoManager.DeleteVotesBefore(ctx, WipeBefore)
 	if err != nil {
 		return err
 	}
-	err = w.daoManager.DeleteVotesBefore(WipeBefore)
+	err = w.daoManager.DeleteVerificationJobsBefore(ctx, WipeBefore)
 	if err != nil {
 		return err
 	}